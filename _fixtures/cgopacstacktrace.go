@@ -0,0 +1,24 @@
+package main
+
+/*
+#cgo CFLAGS: -O0 -g
+#cgo arm64 CFLAGS: -mbranch-protection=pac-ret+leaf
+__attribute__((noinline)) int pacinner(int x) {
+	return x + 1;
+}
+
+__attribute__((noinline)) int pacmid(int x) {
+	return pacinner(x) * 2;
+}
+
+__attribute__((noinline)) int pacouter(int x) {
+	return pacmid(x) + 3;
+}
+*/
+import "C"
+
+import "fmt"
+
+func main() {
+	fmt.Println(C.pacouter(1))
+}
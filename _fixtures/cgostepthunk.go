@@ -0,0 +1,17 @@
+package main
+
+/*
+#cgo CFLAGS: -O0 -g
+#include <unistd.h>
+int stepme(void) {
+	int n = getpid();
+	return n > 0;
+}
+*/
+import "C"
+
+import "fmt"
+
+func main() {
+	fmt.Println(C.stepme())
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+func main() {
+	ch := make(chan int, 4)
+	for i := 0; i < 4; i++ {
+		ch <- i
+	}
+	<-ch
+	<-ch
+	ch <- 4
+	ch <- 5 // the buffer wraps around, it contains 2, 3, 4, 5
+
+	unbuf := make(chan string)
+	for i := 0; i < 2; i++ {
+		go func(i int) {
+			unbuf <- fmt.Sprint(i)
+		}(i)
+	}
+	recv := make(chan int)
+	go func() {
+		<-recv
+	}()
+
+	closed := make(chan int, 2)
+	closed <- 1
+	close(closed)
+
+	time.Sleep(100 * time.Millisecond)
+	runtime.Breakpoint()
+	fmt.Println(ch, unbuf, recv, closed)
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+//go:noinline
+func isAdmin(path string) bool {
+	return strings.Contains(path, "admin")
+}
+
+func handle(path string) {
+	fmt.Println(path)
+}
+
+func main() {
+	for _, path := range []string{"/index", "/admin/users", "/about"} {
+		handle(path)
+	}
+	fmt.Println(isAdmin("/"))
+}
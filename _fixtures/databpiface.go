@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"unsafe"
+)
+
+type T struct {
+	a, b int
+}
+
+var iface interface{}
+
+func main() {
+	runtime.LockOSThread()
+	n := len(os.Args)
+	iface = T{n, n + 1}
+	runtime.Breakpoint()
+	iface = T{n + 2, n + 3} // Position 0
+	t := (*[2]*T)(unsafe.Pointer(&iface))[1]
+	t.a = 10
+	fmt.Println(iface) // Position 1
+}
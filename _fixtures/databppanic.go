@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func f() {
+	defer func() {
+		recover()
+	}()
+	g()
+}
+
+func g() {
+	w := 1
+	runtime.Gosched() // Position 0
+	panic(w)
+}
+
+func main() {
+	runtime.LockOSThread()
+	f()
+	fmt.Printf("done\n")
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+type T struct {
+	field int
+}
+
+func main() {
+	runtime.LockOSThread()
+	p, q := &T{}, &T{}
+	runtime.Breakpoint()
+	p.field = 1 // Position 0
+	p = q       // Position 1
+	runtime.Breakpoint()
+	p.field = 2       // Position 2
+	fmt.Println(p, q) // Position 3
+}
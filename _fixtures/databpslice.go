@@ -0,0 +1,18 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+var s = make([]int, 4)
+
+func main() {
+	runtime.LockOSThread()
+	runtime.Breakpoint()
+	s = append(s, 1) // Position 0
+	s[3] = 2
+	fmt.Println(s) // Position 1
+	s = s[:2]
+	fmt.Println(s)
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func f() {
+	w := 0
+
+	g(1000, &w) // Position 0
+}
+
+func g(cnt int, p *int) {
+	if cnt == 0 {
+		*p = 10
+		return // Position 1
+	}
+	g(cnt-1, p)
+}
+
+func main() {
+	runtime.LockOSThread()
+	f() // Position 2
+	fmt.Printf("done\n")
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+var globalstr = "hello"
+var globalslice = []int{1, 2, 3}
+
+func main() { // Position 0
+	runtime.LockOSThread()
+	fmt.Println(globalstr, globalslice)
+	globalstr = globalstr + " world" // Position 1
+	fmt.Println(globalstr)
+	globalslice = append(globalslice, 4) // Position 2
+	fmt.Println(globalslice)
+}
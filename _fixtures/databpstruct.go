@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+type triple struct {
+	a, b, c int64
+}
+
+var globalstruct triple
+
+func main() { // Position 0
+	runtime.LockOSThread()
+	fmt.Println(globalstruct)
+	globalstruct.c = 3 // Position 1
+	fmt.Println(globalstruct)
+}
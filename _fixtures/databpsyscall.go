@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+)
+
+var globalvar1 = 0
+
+func main() { // Position 0
+	var fds [2]int
+	if err := syscall.Pipe(fds[:]); err != nil {
+		panic(err)
+	}
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		syscall.Write(fds[1], []byte{1})
+	}()
+	buf := make([]byte, 1)
+	syscall.Read(fds[0], buf) // blocks until the goroutine writes to the pipe
+	globalvar1 = int(buf[0])  // Position 1
+	fmt.Println(globalvar1)
+}
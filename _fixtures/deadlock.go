@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+type account struct {
+	mu      sync.Mutex
+	balance int
+}
+
+func transfer(from, to *account, amount int, wg *sync.WaitGroup) {
+	from.mu.Lock()
+	defer from.mu.Unlock()
+	wg.Done()
+	wg.Wait()
+	to.mu.Lock()
+	defer to.mu.Unlock()
+	from.balance -= amount
+	to.balance += amount
+}
+
+func relay(in <-chan int, out chan<- int) {
+	for v := range in {
+		out <- v
+	}
+}
+
+func main() {
+	a, b := &account{balance: 100}, &account{balance: 100}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go transfer(a, b, 10, &wg)
+	go transfer(b, a, 20, &wg)
+
+	ch1, ch2 := make(chan int), make(chan int)
+	go relay(ch1, ch2)
+	go relay(ch2, ch1)
+
+	time.Sleep(100 * time.Millisecond)
+	runtime.Breakpoint()
+	time.Sleep(time.Hour)
+	fmt.Println(a.balance, b.balance)
+}
@@ -176,6 +176,57 @@ func regabistacktest2(n1, n2, n3, n4, n5, n6, n7, n8, n9, n10 int) (int, int, in
 	return n1 + n2, n2 + n3, n3 + n4, n4 + n5, n5 + n6, n6 + n7, n7 + n8, n8 + n9, n9 + n10, n10 + n1
 }
 
+func variadicSum(prefix string, n ...int) string {
+	s := 0
+	for _, x := range n {
+		s += x
+	}
+	return fmt.Sprintf("%s%d", prefix, s)
+}
+
+func variadicStack(n1, n2, n3, n4, n5, n6, n7, n8, n9 int, args ...interface{}) string {
+	return fmt.Sprint(n1+n2+n3+n4+n5+n6+n7+n8+n9, args)
+}
+
+type litOptions struct {
+	Name    string
+	Retries int
+	Ratio   float64
+	Inner   astruct
+	P       *astruct
+	Tags    []string
+	Any     interface{}
+	M       map[string]int
+	Ch      chan int
+}
+
+func litProcess(o litOptions) string {
+	return fmt.Sprintf("%s %d %g %d %v %v %v", o.Name, o.Retries, o.Ratio, o.Inner.X, o.P, o.Tags, o.Any)
+}
+
+func litProcessPtr(o *litOptions) string {
+	return litProcess(*o)
+}
+
+func litFill(buf []byte) string {
+	for i := range buf {
+		buf[i] = 'a' + byte(i)
+	}
+	return fmt.Sprintf("%d %d %s", len(buf), cap(buf), buf)
+}
+
+func litSumArray(a [3]int) int {
+	return a[0] + a[1] + a[2]
+}
+
+func litSumAStructs(s []*astruct) int {
+	r := 0
+	for _, a := range s {
+		r += a.X
+	}
+	return r
+}
+
 func main() {
 	one, two := 1, 2
 	intslice := []int{1, 2, 3}
@@ -186,10 +237,14 @@ func main() {
 	a2 := a2struct{Y: 7}
 	var pa2 *astruct
 	var str string = "old string value"
+	bytebuf := []byte("old")
+	strmap := map[string]int{"a": 1}
 	longstrs := []string{"very long string 0123456789a0123456789b0123456789c0123456789d0123456789e0123456789f0123456789g012345678h90123456789i0123456789j0123456789"}
 	var vable_a VRcvrable = a
 	var vable_pa VRcvrable = pa
 	var pable_pa PRcvrable = pa
+	var vable_nil VRcvrable
+	var ifaceerr error = fmt.Errorf("error %d", 1)
 	var x X = 2
 	var x2 X2 = 2
 
@@ -208,5 +263,5 @@ func main() {
 	d.Method()
 	d.Base.Method()
 	x.CallMe()
-	fmt.Println(one, two, zero, call, call0, call2, callexit, callpanic, callbreak, callstacktrace, stringsJoin, intslice, stringslice, comma, a.VRcvr, a.PRcvr, pa, vable_a, vable_pa, pable_pa, fn2clos, fn2glob, fn2valmeth, fn2ptrmeth, fn2nil, ga, escapeArg, a2, square, intcallpanic, onetwothree, curriedAdd, getAStruct, getAStructPtr, getVRcvrableFromAStruct, getPRcvrableFromAStructPtr, getVRcvrableFromAStructPtr, pa2, noreturncall, str, d, x, x2.CallMe(5), longstrs, regabistacktest, regabistacktest2)
+	fmt.Println(one, two, zero, call, call0, call2, callexit, callpanic, callbreak, callstacktrace, stringsJoin, intslice, stringslice, comma, a.VRcvr, a.PRcvr, pa, vable_a, vable_pa, pable_pa, fn2clos, fn2glob, fn2valmeth, fn2ptrmeth, fn2nil, ga, escapeArg, a2, square, intcallpanic, onetwothree, curriedAdd, getAStruct, getAStructPtr, getVRcvrableFromAStruct, getPRcvrableFromAStructPtr, getVRcvrableFromAStructPtr, pa2, noreturncall, str, d, x, x2.CallMe(5), longstrs, regabistacktest, regabistacktest2, variadicSum, variadicStack, vable_nil, ifaceerr, litProcess, litProcessPtr, litFill, litSumArray, litSumAStructs, bytebuf, strmap)
 }
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+type Decimal struct {
+	Int, Frac int
+}
+
+type ID struct {
+	kind string
+	n    int
+}
+
+func (id ID) String() string {
+	return fmt.Sprintf("%s-%d", id.kind, id.n)
+}
+
+type Order struct {
+	ID    ID
+	Price Decimal
+}
+
+type parseError struct {
+	line int
+}
+
+func (err *parseError) Error() string {
+	return fmt.Sprintf("parse error at line %d", err.line)
+}
+
+type broken struct{}
+
+func (broken) String() string {
+	panic("broken")
+}
+
+func main() {
+	price := Decimal{12, 34}
+	id := ID{"ord", 7}
+	order := Order{id, price}
+	var err error = &parseError{3}
+	var nilerr error
+	b := broken{}
+	runtime.Breakpoint()
+	fmt.Println(price, id, order, err, nilerr, b)
+}
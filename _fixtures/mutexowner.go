@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+type cache struct {
+	mu      sync.Mutex
+	entries map[string]int
+}
+
+var rw sync.RWMutex
+
+func (c *cache) refresh(ready chan<- struct{}, block <-chan struct{}) {
+	c.mu.Lock()
+	ready <- struct{}{}
+	<-block
+	c.entries = map[string]int{"a": 1}
+	c.mu.Unlock()
+}
+
+func (c *cache) get(k string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[k]
+}
+
+func read(ready chan<- struct{}, block <-chan struct{}) {
+	rw.RLock()
+	ready <- struct{}{}
+	<-block
+	rw.RUnlock()
+}
+
+func write() {
+	rw.Lock()
+	rw.Unlock()
+}
+
+func main() {
+	c := &cache{}
+	ready, block := make(chan struct{}), make(chan struct{})
+	go c.refresh(ready, block)
+	<-ready
+	go c.get("a")
+	go c.get("b")
+
+	for i := 0; i < 2; i++ {
+		go read(ready, block)
+		<-ready
+	}
+	go write()
+
+	time.Sleep(100 * time.Millisecond)
+	runtime.Breakpoint()
+	close(block)
+	time.Sleep(100 * time.Millisecond)
+	fmt.Println(c.get("a"))
+}
@@ -0,0 +1,50 @@
+package main
+
+import "fmt"
+
+func thrower(n int) {
+	if n == 0 {
+		panic("boom")
+	}
+	thrower(n - 1)
+}
+
+func repanic() {
+	defer func() {
+		r := recover()
+		panic(fmt.Sprintf("re-panic: %v", r))
+	}()
+	thrower(2)
+}
+
+func nested() {
+	defer func() {
+		recover()
+	}()
+	defer func() {
+		panic("second")
+	}()
+	panic("first")
+}
+
+func recoverer(f func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("recovered: %v", r)
+		}
+	}()
+	middle(f)
+	return nil
+}
+
+func middle(f func()) {
+	f()
+	fmt.Println("not reached")
+}
+
+func main() {
+	fmt.Println(recoverer(func() { thrower(3) }))
+	fmt.Println(recoverer(repanic))
+	fmt.Println(recoverer(func() { nested(); thrower(0) }))
+	middle(func() { thrower(1) })
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+var counter int
+
+func spin() {
+	for {
+		counter++
+	}
+}
+
+func main() {
+	go spin()
+	time.Sleep(10 * time.Millisecond)
+	ch := make(chan int)
+	runtime.Breakpoint()
+	x := 0
+	for i := 0; i < 1000000; i++ {
+		x += i
+	}
+	fmt.Println(x)
+	<-ch
+	fmt.Println(counter)
+}
@@ -0,0 +1,21 @@
+package main
+
+import "fmt"
+
+func seq(n int) func(func(int) bool) {
+	return func(yield func(int) bool) {
+		for i := 0; i < n; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+}
+
+func main() {
+	sum := 0
+	for x := range seq(2) {
+		sum += x
+	}
+	fmt.Println(sum)
+}
@@ -0,0 +1,15 @@
+package main
+
+import "fmt"
+
+func fact(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	r := fact(n - 1)
+	return n * r
+}
+
+func main() {
+	fmt.Println(fact(4))
+}
@@ -0,0 +1,29 @@
+package main
+
+import "fmt"
+
+type node struct {
+	val  int
+	next *node
+}
+
+func corrupt(n *node) {
+	n.val++
+	n.next = nil
+	fmt.Println("corrupted")
+}
+
+func sum(n int) int {
+	tot := 0
+	for i := 0; i < n; i++ {
+		tot += i
+	}
+	return tot
+}
+
+func main() {
+	list := &node{val: 1, next: &node{val: 2}}
+	corrupt(list)
+	fmt.Println(sum(3))
+	fmt.Println(list.next)
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+func child() {
+	fmt.Println("child")
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "child" {
+		child()
+		return
+	}
+	cmd := exec.Command(os.Args[0], "child")
+	cmd.Stdout = os.Stdout
+	cmd.Run()
+}
@@ -0,0 +1,19 @@
+package main
+
+import "fmt"
+
+//go:noinline
+func inc(x int) int {
+	return x + 1
+}
+
+//go:noinline
+func double(x float64) float64 {
+	return x * 2
+}
+
+func main() {
+	n := inc(1)
+	f := double(1.5)
+	fmt.Println(n, f)
+}
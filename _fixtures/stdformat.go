@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
+	"runtime"
+	"time"
+)
+
+func main() {
+	utc := time.Date(2021, 3, 4, 5, 6, 7, 8, time.UTC)
+	cet := time.Date(2021, 3, 4, 6, 6, 7, 0, time.FixedZone("CET", 3600))
+	now := time.Now()
+	var zero time.Time
+	d := 1500 * time.Millisecond
+	month := time.March
+	ip4 := net.ParseIP("192.168.1.1").To4()
+	ip6 := net.ParseIP("2001:db8::1")
+	var nilip net.IP
+	addr4 := netip.MustParseAddr("10.0.0.1")
+	addr6 := netip.MustParseAddr("fe80::1%eth0")
+	mapped := netip.MustParseAddr("::ffff:10.0.0.1")
+	var invalid netip.Addr
+	bigint, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+	huge := new(big.Int).Exp(big.NewInt(10), big.NewInt(4096), nil)
+	bigfloat := big.NewFloat(1.5)
+	bigrat := big.NewRat(3, 4)
+	bigratint := big.NewRat(5, 1)
+	runtime.Breakpoint()
+	fmt.Println(utc, cet, now, zero, d, month, ip4, ip6, nilip, addr4, addr6, mapped, invalid)
+	fmt.Println(bigint, huge, bigfloat, bigrat, bigratint)
+}
@@ -0,0 +1,23 @@
+package main
+
+import "fmt"
+
+//go:noinline
+func f(a, b int) int {
+	return a + b
+}
+
+//go:noinline
+func g() int {
+	return 1
+}
+
+//go:noinline
+func h() int {
+	return 2
+}
+
+func main() {
+	x := f(g(), h())
+	fmt.Println(x)
+}
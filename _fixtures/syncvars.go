@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+func main() {
+	var wg sync.WaitGroup
+	wg.Add(3)
+	for i := 0; i < 2; i++ {
+		go func() {
+			wg.Wait()
+		}()
+	}
+
+	var once, pending sync.Once
+	once.Do(func() {})
+
+	var mu sync.Mutex
+	cond := sync.NewCond(&mu)
+	for i := 0; i < 2; i++ {
+		go func() {
+			mu.Lock()
+			cond.Wait()
+			mu.Unlock()
+		}()
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	runtime.Breakpoint()
+	wg.Add(-3)
+	cond.Broadcast()
+	pending.Do(func() {})
+	fmt.Println(&wg, &once, cond)
+}
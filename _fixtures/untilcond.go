@@ -0,0 +1,29 @@
+package main
+
+import "fmt"
+
+var counter int
+
+type state struct {
+	done bool
+	n    int
+}
+
+var st state
+
+func step(p *state) {
+	p.n += counter
+}
+
+func main() {
+	n := 0
+	for i := 0; i < 100; i++ {
+		n += i
+		counter++
+		step(&st)
+		if counter == 50 {
+			st.done = true
+		}
+	}
+	fmt.Println(n, counter, st)
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/go-delve/delve/pkg/gobuild"
 	"github.com/go-delve/delve/pkg/goversion"
 	"github.com/go-delve/delve/pkg/logflags"
+	"github.com/go-delve/delve/pkg/proc/gdbserial"
 	"github.com/go-delve/delve/pkg/terminal"
 	"github.com/go-delve/delve/pkg/version"
 	"github.com/go-delve/delve/service"
@@ -64,6 +65,16 @@ var (
 	// backend selection
 	backend string
 
+	// options for recording the target with the rr backend
+	rrChaos    bool
+	rrNumCores int
+	rrTraceDir string
+	rrArgs     string
+
+	// replayOnProcess selects the process of the trace that 'dlv replay'
+	// debugs.
+	replayOnProcess string
+
 	// checkGoVersion is true if the debugger should check the version of Go
 	// used to compile the executable and refuse to work on incompatible
 	// versions.
@@ -134,6 +145,10 @@ func New(docCall bool) *cobra.Command {
 	rootCommand.PersistentFlags().StringArrayVarP(&redirects, "redirect", "r", []string{}, "Specifies redirect rules for target process (see 'dlv help redirect')")
 	rootCommand.PersistentFlags().BoolVar(&allowNonTerminalInteractive, "allow-non-terminal-interactive", false, "Allows interactive sessions of Delve that don't have a terminal as stdin, stdout and stderr")
 	rootCommand.PersistentFlags().BoolVar(&disableASLR, "disable-aslr", false, "Disables address space randomization")
+	rootCommand.PersistentFlags().BoolVar(&rrChaos, "rr-chaos", false, "Records the target in rr's chaos mode, only valid with --backend=rr.")
+	rootCommand.PersistentFlags().IntVar(&rrNumCores, "rr-num-cores", 0, "Number of cores reported to the target while recording it, only valid with --backend=rr.")
+	rootCommand.PersistentFlags().StringVar(&rrTraceDir, "rr-trace-dir", "", "Directory where the rr trace is saved, it must not exist. Traces recorded again by 'restart -r' are saved in its numbered subdirectories. Only valid with --backend=rr.")
+	rootCommand.PersistentFlags().StringVar(&rrArgs, "rr-args", "", "Additional arguments passed to 'rr record', only valid with --backend=rr.")
 
 	// 'attach' subcommand.
 	attachCommand := &cobra.Command{
@@ -325,6 +340,11 @@ Currently supports linux/amd64 and linux/arm64 core files, windows/amd64 minidum
 
 The replay command will open a trace generated by mozilla rr. Mozilla rr must be installed:
 https://github.com/mozilla/rr
+
+By default the root process of the trace is debugged, use --onprocess to
+select a different process, either by its pid or with a regular expression
+matched against its command line. The processes recorded in a trace can be
+listed with 'rr ps'.
 			`,
 			PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 				if len(args) == 0 {
@@ -337,6 +357,7 @@ https://github.com/mozilla/rr
 				os.Exit(execute(0, []string{}, conf, args[0], debugger.ExecutingOther, args, buildFlags))
 			},
 		}
+		replayCommand.Flags().StringVar(&replayOnProcess, "onprocess", "", "Debugs the process of the trace with the specified pid, or whose command line matches the specified regular expression.")
 		rootCommand.AddCommand(replayCommand)
 	}
 
@@ -454,6 +475,7 @@ func dapCmd(cmd *cobra.Command, args []string) {
 				Backend:              backend,
 				Foreground:           true, // server always runs without terminal client
 				DebugInfoDirectories: conf.DebugInfoDirectories,
+				Formatters:           apiFormatters(conf),
 				CheckGoVersion:       checkGoVersion,
 			},
 			CheckLocalConnUser: checkLocalConnUser,
@@ -720,6 +742,18 @@ func waitForDisconnectSignal(disconnectChan chan struct{}) {
 	}
 }
 
+// logpointOutput returns the function printing logpoint messages as soon
+// as they are produced, when the terminal runs in the same process as the
+// debugger. Headless servers buffer the messages for their clients.
+func logpointOutput() func(api.LogpointMessage) {
+	if headless {
+		return nil
+	}
+	return func(msg api.LogpointMessage) {
+		fmt.Println(terminal.FormatLogpointMessage(msg))
+	}
+}
+
 func splitArgs(cmd *cobra.Command, args []string) ([]string, []string) {
 	if cmd.ArgsLenAtDash() >= 0 {
 		return args[:cmd.ArgsLenAtDash()], args[cmd.ArgsLenAtDash():]
@@ -806,6 +840,12 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 		return 1
 	}
 
+	recordOptions, err := parseRecordOptions(attachPid, coreFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
 	redirects, err := parseRedirects(redirects)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
@@ -855,10 +895,14 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 				BuildFlags:           buildFlags,
 				ExecuteKind:          kind,
 				DebugInfoDirectories: conf.DebugInfoDirectories,
+				Formatters:           apiFormatters(conf),
 				CheckGoVersion:       checkGoVersion,
 				TTY:                  tty,
 				Redirects:            redirects,
 				DisableASLR:          disableASLR,
+				RecordOptions:        recordOptions,
+				ReplayOnProcess:      replayOnProcess,
+				LogpointOutput:       logpointOutput(),
 			},
 		})
 	default:
@@ -901,6 +945,41 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 	return connect(listener.Addr().String(), clientConn, conf, kind)
 }
 
+// parseRecordOptions returns the options for recording the target with rr
+// specified on the command line. They are rejected unless the target is
+// going to be recorded.
+// apiFormatters returns the formatters specified by the configuration file.
+func apiFormatters(conf *config.Config) []api.Formatter {
+	r := make([]api.Formatter, len(conf.Formatters))
+	for i, f := range conf.Formatters {
+		r[i] = api.Formatter{TypeName: f.Type, Format: f.Format, CallString: f.CallString}
+	}
+	return r
+}
+
+func parseRecordOptions(attachPid int, coreFile string) (gdbserial.RecordOptions, error) {
+	opts := gdbserial.RecordOptions{
+		Chaos:          rrChaos,
+		NumCores:       rrNumCores,
+		OutputTraceDir: rrTraceDir,
+		ExtraArgs:      config.SplitQuotedFields(rrArgs, '"'),
+	}
+	if !opts.Chaos && opts.NumCores == 0 && opts.OutputTraceDir == "" && len(opts.ExtraArgs) == 0 {
+		return opts, nil
+	}
+	if backend != "rr" || attachPid != 0 || coreFile != "" {
+		return opts, errors.New("--rr-chaos, --rr-num-cores, --rr-trace-dir and --rr-args can only be used when recording the target with --backend=rr")
+	}
+	if opts.OutputTraceDir != "" {
+		var err error
+		opts.OutputTraceDir, err = filepath.Abs(opts.OutputTraceDir)
+		if err != nil {
+			return opts, err
+		}
+	}
+	return opts, opts.Validate()
+}
+
 func parseRedirects(redirects []string) ([3]string, error) {
 	r := [3]string{}
 	names := [3]string{"stdin", "stdout", "stderr"}
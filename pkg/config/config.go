@@ -29,6 +29,19 @@ type SubstitutePathRule struct {
 // SubstitutePathRules is a slice of source code path substitution rules.
 type SubstitutePathRules []SubstitutePathRule
 
+// Formatter describes how the values of a type are rendered when they are
+// printed.
+type Formatter struct {
+	// Fully qualified name of the type.
+	Type string `yaml:"type"`
+	// Format string, the expressions between braces are evaluated with the
+	// fields and methods of the value in scope.
+	Format string `yaml:"format,omitempty"`
+	// If CallString is true the value is rendered by calling its String
+	// method instead of using Format.
+	CallString bool `yaml:"call-string,omitempty"`
+}
+
 // Config defines all configuration options available to be set through the config file.
 type Config struct {
 	// Commands aliases.
@@ -45,6 +58,10 @@ type Config struct {
 	// MaxVariableRecurse is output evaluation depth of nested struct members, array and
 	// slice items and dereference pointers
 	MaxVariableRecurse *int `yaml:"max-variable-recurse,omitempty"`
+	// CallStringMethods makes the commands print, locals, args and vars
+	// show the result of the Error or String method of the values that
+	// implement error or fmt.Stringer.
+	CallStringMethods bool `yaml:"call-string-methods"`
 	// DisassembleFlavor allow user to specify output syntax flavor of assembly, one of
 	// this list "intel"(default), "gnu", "go"
 	DisassembleFlavor *string `yaml:"disassemble-flavor,omitempty"`
@@ -80,6 +97,10 @@ type Config struct {
 	// DebugFileDirectories is the list of directories Delve will use
 	// in order to resolve external debug info files.
 	DebugInfoDirectories []string `yaml:"debug-info-directories"`
+
+	// Formatters renders the values of the listed types when they are
+	// printed.
+	Formatters []Formatter `yaml:"formatters,omitempty"`
 }
 
 func (c *Config) GetSourceListLineCount() int {
@@ -266,6 +287,10 @@ substitute-path:
 # Output evaluation.
 # max-variable-recurse: 1
 
+# Uncomment the following line to make print, locals, args and vars show the
+# result of the Error or String method of errors and fmt.Stringer values.
+# call-string-methods: true
+
 # Uncomment the following line to make the whatis command also print the DWARF location expression of its argument.
 # show-location-expr: true
 
@@ -274,6 +299,12 @@ substitute-path:
 
 # List of directories to use when searching for separate debug info files.
 debug-info-directories: ["/usr/lib/debug/.build-id"]
+
+# Formatters for the values of specific types, the expressions between braces
+# are evaluated with the fields and methods of the value in scope.
+formatters:
+  # - {type: "example.com/pkg.Decimal", format: "{Int}.{Frac}"}
+  # - {type: "example.com/pkg.ID", call-string: true}
 `)
 	return err
 }
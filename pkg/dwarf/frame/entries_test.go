@@ -74,3 +74,28 @@ func BenchmarkFDEForPC(b *testing.B) {
 		_, _ = fdes.FDEForPC(0x455555555)
 	}
 }
+
+func TestNegateRAState(t *testing.T) {
+	// DW_CFA_AARCH64_negate_ra_state is emitted by C compilers for functions
+	// that sign their return address.
+	cie := &CommonInformationEntry{
+		CodeAlignmentFactor:   4,
+		DataAlignmentFactor:   -8,
+		ReturnAddressRegister: 30,
+		InitialInstructions:   []byte{DW_CFA_def_cfa, 31, 0},
+	}
+	fde := &FrameDescriptionEntry{
+		CIE:          cie,
+		Instructions: []byte{DW_CFA_AARCH64_negate_ra_state, DW_CFA_advance_loc | 1, DW_CFA_def_cfa_offset, 16, DW_CFA_offset | 30, 2},
+		begin:        0x1000,
+		size:         0x100,
+		order:        binary.LittleEndian,
+	}
+	ctx := fde.EstablishFrame(0x1008)
+	if ctx.CFA.Offset != 16 {
+		t.Errorf("wrong CFA offset %d", ctx.CFA.Offset)
+	}
+	if rule := ctx.Regs[30]; rule.Rule != RuleOffset || rule.Offset != -16 {
+		t.Errorf("wrong rule for the return address %#v", rule)
+	}
+}
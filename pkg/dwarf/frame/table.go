@@ -64,6 +64,11 @@ const (
 	DW_CFA_restore            = (0x3 << 6) // High 2 bits: 0x3, low 6: register
 )
 
+// Vendor extensions.
+const (
+	DW_CFA_AARCH64_negate_ra_state = 0x2d // No ops
+)
+
 // Rule rule defined for register values.
 type Rule byte
 
@@ -113,6 +118,8 @@ var fnlookup = map[byte]instruction{
 	DW_CFA_val_expression:     valexpression,
 	DW_CFA_lo_user:            louser,
 	DW_CFA_hi_user:            hiuser,
+
+	DW_CFA_AARCH64_negate_ra_state: negaterastate,
 }
 
 func executeCIEInstructions(cie *CommonInformationEntry) *FrameContext {
@@ -424,3 +431,10 @@ func louser(frame *FrameContext) {
 func hiuser(frame *FrameContext) {
 	frame.buf.Next(1)
 }
+
+// negaterastate toggles whether the return address is signed with a
+// pointer authentication code. Callers of this package are expected to
+// strip authentication codes from all return addresses so there is nothing
+// to do here.
+func negaterastate(frame *FrameContext) {
+}
@@ -44,6 +44,11 @@ const (
 	AMD64_SW      = 66
 	AMD64_XMM16   = 67  // XMM17 through XMM31 follow
 	AMD64_K0      = 118 // k1 through k7 follow
+
+	// The ABI doesn't assign DWARF register numbers to the AMX registers,
+	// these are only used internally.
+	AMD64_TILECFG = 130
+	AMD64_TMM0    = 131 // TMM1 through TMM7 follow
 )
 
 var amd64DwarfToName = map[uint64]string{
@@ -124,6 +129,15 @@ var amd64DwarfToName = map[uint64]string{
 	AMD64_K0 + 5:     "K5",
 	AMD64_K0 + 6:     "K6",
 	AMD64_K0 + 7:     "K7",
+	AMD64_TILECFG:    "TILECFG",
+	AMD64_TMM0:       "TMM0",
+	AMD64_TMM0 + 1:   "TMM1",
+	AMD64_TMM0 + 2:   "TMM2",
+	AMD64_TMM0 + 3:   "TMM3",
+	AMD64_TMM0 + 4:   "TMM4",
+	AMD64_TMM0 + 5:   "TMM5",
+	AMD64_TMM0 + 6:   "TMM6",
+	AMD64_TMM0 + 7:   "TMM7",
 }
 
 var AMD64NameToDwarf = func() map[string]int {
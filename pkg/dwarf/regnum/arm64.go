@@ -15,8 +15,12 @@ const (
 	ARM64_LR         = 30 // also X30
 	ARM64_SP         = 31
 	ARM64_PC         = 32
+	ARM64_VG         = 46 // SVE vector granule (vector length in 64-bit units)
+	ARM64_FFR        = 47 // SVE first fault register
+	ARM64_P0         = 48 // SVE predicate registers, P1 through P15 follow
 	ARM64_V0         = 64 // V1 through V31 follow
-	_ARM64_MaxRegNum = ARM64_V0 + 31
+	ARM64_Z0         = 96 // SVE vector registers, Z1 through Z31 follow
+	_ARM64_MaxRegNum = ARM64_Z0 + 31
 )
 
 func ARM64ToName(num uint64) string {
@@ -27,8 +31,16 @@ func ARM64ToName(num uint64) string {
 		return "SP"
 	case num == ARM64_PC:
 		return "PC"
+	case num == ARM64_VG:
+		return "VG"
+	case num == ARM64_FFR:
+		return "FFR"
+	case num >= ARM64_P0 && num <= ARM64_P0+15:
+		return fmt.Sprintf("P%d", num-ARM64_P0)
 	case num >= ARM64_V0 && num <= 95:
 		return fmt.Sprintf("V%d", num-64)
+	case num >= ARM64_Z0 && num <= ARM64_Z0+31:
+		return fmt.Sprintf("Z%d", num-ARM64_Z0)
 	default:
 		return fmt.Sprintf("unknown%d", num)
 	}
@@ -52,5 +64,14 @@ var ARM64NameToDwarf = func() map[string]int {
 		r[fmt.Sprintf("v%d", i)] = ARM64_V0 + i
 	}
 
+	r["vg"] = ARM64_VG
+	r["ffr"] = ARM64_FFR
+	for i := 0; i <= 15; i++ {
+		r[fmt.Sprintf("p%d", i)] = ARM64_P0 + i
+	}
+	for i := 0; i <= 31; i++ {
+		r[fmt.Sprintf("z%d", i)] = ARM64_Z0 + i
+	}
+
 	return r
 }()
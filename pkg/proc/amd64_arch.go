@@ -23,7 +23,9 @@ func AMD64Arch(goos string) *Arch {
 		ptrSize:                          8,
 		maxInstructionLength:             15,
 		breakpointInstruction:            amd64BreakInstruction,
+		syscallInstruction:               []byte{0x0f, 0x05},
 		breakInstrMovesPC:                true,
+		hwBreakpointSlots:                4,
 		derefTLS:                         goos == "windows",
 		prologues:                        prologuesAMD64,
 		fixFrameUnwindContext:            amd64FixFrameUnwindContext,
@@ -32,7 +34,7 @@ func AMD64Arch(goos string) *Arch {
 		RegistersToDwarfRegisters:        amd64RegistersToDwarfRegisters,
 		addrAndStackRegsToDwarfRegisters: amd64AddrAndStackRegsToDwarfRegisters,
 		DwarfRegisterToString:            amd64DwarfRegisterToString,
-		inhibitStepInto:                  func(*BinaryInfo, uint64) bool { return false },
+		isThunkSymbol:                    amd64IsThunkSymbol,
 		asmDecode:                        amd64AsmDecode,
 		PCRegNum:                         regnum.AMD64_Rip,
 		SPRegNum:                         regnum.AMD64_Rsp,
@@ -250,10 +252,20 @@ func amd64RegSize(rn uint64) int {
 	if rn > regnum.AMD64_Rip && rn <= 32 {
 		return 16
 	}
+	if rn >= regnum.AMD64_XMM16 && rn <= regnum.AMD64_XMM16+15 {
+		return 16
+	}
 	// x87 registers
 	if rn >= 33 && rn <= 40 {
 		return 10
 	}
+	// AMX registers
+	if rn == regnum.AMD64_TILECFG {
+		return 64
+	}
+	if rn >= regnum.AMD64_TMM0 && rn <= regnum.AMD64_TMM0+7 {
+		return 1024
+	}
 	return 8
 }
 
@@ -330,11 +342,19 @@ func amd64DwarfRegisterToString(i int, reg *op.DwarfRegister) (name string, floa
 	case "mxcsr":
 		return name, true, mxcsrDescription.Describe(reg.Uint64Val, 32)
 
+	case "k0", "k1", "k2", "k3", "k4", "k5", "k6", "k7":
+		return name, true, fmt.Sprintf("%#016x", reg.Uint64Val)
+
+	case "tilecfg":
+		return name, true, formatTileCfg(reg.Bytes)
+
 	default:
 		if reg.Bytes != nil && strings.HasPrefix(n, "xmm") {
 			return name, true, formatSSEReg(name, reg.Bytes)
 		} else if reg.Bytes != nil && strings.HasPrefix(n, "st(") {
 			return name, true, formatX87Reg(reg.Bytes)
+		} else if reg.Bytes != nil && strings.HasPrefix(n, "tmm") {
+			return name, true, formatTileReg(reg.Bytes)
 		} else if reg.Bytes == nil || (reg.Bytes != nil && len(reg.Bytes) <= 8) {
 			return name, false, fmt.Sprintf("%#016x", reg.Uint64Val)
 		} else {
@@ -361,10 +381,34 @@ func formatSSEReg(name string, reg []byte) string {
 	formatSSERegInternal(reg[32:], out)
 	fmt.Fprintf(out, "\n\t[%shh] ", "Z"+name[1:])
 	formatSSERegInternal(reg[48:], out)
+	fmt.Fprintf(out, "\n\t[%s] ", "Z"+name[1:])
+	formatZMMRegLanes(reg[:64], out)
 
 	return out.String()
 }
 
+// formatZMMRegLanes formats the full 512 bits of a ZMM register as lanes
+// of several widths.
+func formatZMMRegLanes(zmm []byte, out *bytes.Buffer) {
+	fmt.Fprintf(out, "v8_int={")
+	for i := 0; i < len(zmm); i += 8 {
+		fmt.Fprintf(out, " %016x", binary.LittleEndian.Uint64(zmm[i:]))
+	}
+	fmt.Fprintf(out, " }\tv16_int={")
+	for i := 0; i < len(zmm); i += 4 {
+		fmt.Fprintf(out, " %08x", binary.LittleEndian.Uint32(zmm[i:]))
+	}
+	fmt.Fprintf(out, " }\tv8_float={")
+	for i := 0; i < len(zmm); i += 8 {
+		fmt.Fprintf(out, " %g", math.Float64frombits(binary.LittleEndian.Uint64(zmm[i:])))
+	}
+	fmt.Fprintf(out, " }\tv16_float={")
+	for i := 0; i < len(zmm); i += 4 {
+		fmt.Fprintf(out, " %g", math.Float32frombits(binary.LittleEndian.Uint32(zmm[i:])))
+	}
+	fmt.Fprintf(out, " }")
+}
+
 func formatSSERegInternal(xmm []byte, out *bytes.Buffer) {
 	buf := bytes.NewReader(xmm)
 
@@ -462,3 +506,67 @@ func formatX87Reg(b []byte) string {
 
 	return fmt.Sprintf("%#04x%016x\t%g", exponent, mantissa, f)
 }
+
+// formatTileCfg formats the AMX tile configuration, listing the shape
+// (rows x bytes per row) of each configured tile.
+func formatTileCfg(b []byte) string {
+	if len(b) < 64 {
+		return fmt.Sprintf("%#x", b)
+	}
+	out := new(bytes.Buffer)
+	fmt.Fprintf(out, "palette=%d start_row=%d", b[0], b[1])
+	for i := 0; i < 8; i++ {
+		colsb := binary.LittleEndian.Uint16(b[16+i*2:])
+		rows := b[48+i]
+		if rows == 0 || colsb == 0 {
+			continue
+		}
+		fmt.Fprintf(out, " tmm%d=%dx%d", i, rows, colsb)
+	}
+	return out.String()
+}
+
+// formatTileReg formats an AMX tile register one row per line, in memory
+// order. Runs of rows that are zero are collapsed in a single line.
+func formatTileReg(b []byte) string {
+	const rowsz = 64
+	if len(b)%rowsz != 0 {
+		return fmt.Sprintf("%#x", b)
+	}
+	out := new(bytes.Buffer)
+	allzero := func(row []byte) bool {
+		for _, x := range row {
+			if x != 0 {
+				return false
+			}
+		}
+		return true
+	}
+	if allzero(b) {
+		return "0"
+	}
+	for i := 0; i*rowsz < len(b); i++ {
+		row := b[i*rowsz : (i+1)*rowsz]
+		if !allzero(row) {
+			fmt.Fprintf(out, "\n\t[row %d] %x", i, row)
+			continue
+		}
+		j := i
+		for (j+1)*rowsz < len(b) && allzero(b[(j+1)*rowsz:(j+2)*rowsz]) {
+			j++
+		}
+		if j == i {
+			fmt.Fprintf(out, "\n\t[row %d] 0", i)
+		} else {
+			fmt.Fprintf(out, "\n\t[rows %d-%d] 0", i, j)
+		}
+		i = j
+	}
+	return out.String()
+}
+
+// amd64IsThunkSymbol returns true for the retpoline thunks emitted by the
+// C compiler when building with -mindirect-branch=thunk.
+func amd64IsThunkSymbol(name string) bool {
+	return strings.HasPrefix(name, "__x86_indirect_thunk_")
+}
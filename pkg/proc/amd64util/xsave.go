@@ -13,11 +13,16 @@ import (
 // Manual, Volume 1: Basic Architecture.
 type AMD64Xstate struct {
 	AMD64PtraceFpRegs
-	Xsave       []byte // raw xsave area
-	AvxState    bool   // contains AVX state
-	YmmSpace    [256]byte
-	Avx512State bool // contains AVX512 state
-	ZmmSpace    [512]byte
+	Xsave        []byte // raw xsave area
+	AvxState     bool   // contains AVX state
+	YmmSpace     [256]byte
+	Avx512State  bool       // contains AVX512 state
+	ZmmSpace     [512]byte  // bits 256-511 of ZMM0 through ZMM15
+	Hi16ZmmSpace [1024]byte // ZMM16 through ZMM31
+	KSpace       [64]byte   // opmask registers K0 through K7
+	AmxState     bool       // contains AMX state
+	TileCfg      [64]byte   // tile configuration (XTILECFG)
+	TileData     []byte     // tile registers TMM0 through TMM7 (XTILEDATA), nil if they are all zero
 }
 
 // AMD64PtraceFpRegs tracks user_fpregs_struct in /usr/include/x86_64-linux-gnu/sys/user.h
@@ -68,20 +73,73 @@ func (xsave *AMD64Xstate) Decode() []proc.Register {
 		}
 	}
 
+	if !xsave.Avx512State {
+		return xsave.decodeAmx(regs)
+	}
+
+	// AVX-512 registers
+	for i := 0; i < len(xsave.Hi16ZmmSpace); i += 64 {
+		n := 16 + i/64
+		regs = proc.AppendBytesRegister(regs, fmt.Sprintf("XMM%d", n), xsave.Hi16ZmmSpace[i:i+16])
+		regs = proc.AppendBytesRegister(regs, fmt.Sprintf("YMM%d", n), xsave.Hi16ZmmSpace[i+16:i+32])
+		regs = proc.AppendBytesRegister(regs, fmt.Sprintf("ZMM%d", n), xsave.Hi16ZmmSpace[i+32:i+64])
+	}
+
+	for i := 0; i < len(xsave.KSpace); i += 8 {
+		regs = proc.AppendUint64Register(regs, fmt.Sprintf("K%d", i/8), binary.LittleEndian.Uint64(xsave.KSpace[i:]))
+	}
+
+	return xsave.decodeAmx(regs)
+}
+
+func (xsave *AMD64Xstate) decodeAmx(regs []proc.Register) []proc.Register {
+	if !xsave.AmxState {
+		return regs
+	}
+
+	regs = proc.AppendBytesRegister(regs, "TILECFG", xsave.TileCfg[:])
+	tiledata := xsave.TileData
+	if tiledata == nil {
+		tiledata = make([]byte, _XSAVE_AMX_TILEDATA_REGION_LEN)
+	}
+	for i := 0; i < len(tiledata); i += AMD64TileSize {
+		regs = proc.AppendBytesRegister(regs, fmt.Sprintf("TMM%d", i/AMD64TileSize), tiledata[i:i+AMD64TileSize])
+	}
 	return regs
 }
 
 const (
 	_XSTATE_MAX_KNOWN_SIZE = 2969
 
-	_XSAVE_XMM_REGION_START        = 160
-	_XSAVE_HEADER_START            = 512
-	_XSAVE_HEADER_LEN              = 64
-	_XSAVE_EXTENDED_REGION_START   = 576
-	_XSAVE_SSE_REGION_LEN          = 416
-	_XSAVE_AVX512_ZMM_REGION_START = 1152
+	_XSAVE_XMM_REGION_START             = 160
+	_XSAVE_SW_RESERVED_START            = 464
+	_XSAVE_HEADER_START                 = 512
+	_XSAVE_HEADER_LEN                   = 64
+	_XSAVE_EXTENDED_REGION_START        = 576
+	_XSAVE_SSE_REGION_LEN               = 416
+	_XSAVE_AVX512_OPMASK_REGION_START   = 1088
+	_XSAVE_AVX512_ZMM_REGION_START      = 1152
+	_XSAVE_AVX512_HI16_ZMM_REGION_START = 1664
+	_XSAVE_AMX_TILECFG_REGION_START     = 2752
+	_XSAVE_AMX_TILEDATA_REGION_START    = 2816
+	_XSAVE_AMX_TILEDATA_REGION_LEN      = 8192
+
+	_XSAVE_FEATURE_AVX             = 1 << 2
+	_XSAVE_FEATURE_AVX512_OPMASK   = 1 << 5
+	_XSAVE_FEATURE_AVX512_ZMM      = 1 << 6
+	_XSAVE_FEATURE_AVX512_HI16_ZMM = 1 << 7
+	_XSAVE_FEATURES_AVX512         = _XSAVE_FEATURE_AVX512_OPMASK | _XSAVE_FEATURE_AVX512_ZMM | _XSAVE_FEATURE_AVX512_HI16_ZMM
+	_XSAVE_FEATURE_AMX_TILECFG     = 1 << 17
+	_XSAVE_FEATURE_AMX_TILEDATA    = 1 << 18
+	_XSAVE_FEATURES_AMX            = _XSAVE_FEATURE_AMX_TILECFG | _XSAVE_FEATURE_AMX_TILEDATA
+
+	_FP_XSTATE_MAGIC1 = 0x46505853 // see source/arch/x86/include/uapi/asm/sigcontext.h
 )
 
+// AMD64TileSize is the size in bytes of an AMX tile register: 16 rows of
+// 64 bytes each.
+const AMD64TileSize = 1024
+
 // AMD64XstateRead reads a byte array containing an XSAVE area into regset.
 // If readLegacy is true regset.PtraceFpRegs will be filled with the
 // contents of the legacy region of the XSAVE area.
@@ -106,39 +164,74 @@ func AMD64XstateRead(xstateargs []byte, readLegacy bool, regset *AMD64Xstate) er
 		return nil
 	}
 
-	if xstate_bv&(1<<2) == 0 {
-		// AVX state not present
-		return nil
+	// A bit of xstate_bv is clear when the corresponding component is in its
+	// initial configuration (all zeroes), in that case the component could
+	// also be missing from the XSAVE area. Linux stores the list of
+	// components enabled for the process in the software reserved bytes of
+	// the legacy region, if they are there use them to decide which
+	// registers exist.
+	features := xstate_bv
+	swreserved := xstateargs[_XSAVE_SW_RESERVED_START:]
+	if binary.LittleEndian.Uint32(swreserved[0:4]) == _FP_XSTATE_MAGIC1 {
+		features = binary.LittleEndian.Uint64(swreserved[8:16])
 	}
 
-	avxstate := xstateargs[_XSAVE_EXTENDED_REGION_START:]
-	regset.AvxState = true
-	copy(regset.YmmSpace[:], avxstate[:len(regset.YmmSpace)])
+	readComponent := func(feature uint64, start int, dst []byte) bool {
+		if features&feature == 0 || start+len(dst) > len(xstateargs) {
+			return false
+		}
+		if xstate_bv&feature != 0 {
+			copy(dst, xstateargs[start:])
+		}
+		return true
+	}
 
-	if xstate_bv&(1<<6) == 0 {
-		// AVX512 state not present
+	if !readComponent(_XSAVE_FEATURE_AVX, _XSAVE_EXTENDED_REGION_START, regset.YmmSpace[:]) {
+		// AVX state not present
 		return nil
 	}
+	regset.AvxState = true
 
-	avx512state := xstateargs[_XSAVE_AVX512_ZMM_REGION_START:]
-	regset.Avx512State = true
-	copy(regset.ZmmSpace[:], avx512state[:len(regset.ZmmSpace)])
+	if features&_XSAVE_FEATURES_AVX512 == _XSAVE_FEATURES_AVX512 {
+		regset.Avx512State = readComponent(_XSAVE_FEATURE_AVX512_OPMASK, _XSAVE_AVX512_OPMASK_REGION_START, regset.KSpace[:]) &&
+			readComponent(_XSAVE_FEATURE_AVX512_ZMM, _XSAVE_AVX512_ZMM_REGION_START, regset.ZmmSpace[:]) &&
+			readComponent(_XSAVE_FEATURE_AVX512_HI16_ZMM, _XSAVE_AVX512_HI16_ZMM_REGION_START, regset.Hi16ZmmSpace[:])
+	}
 
-	// TODO(aarzilli): if xstate_bv&(1<<7) is set then xstateargs[1664:2688]
-	// contains ZMM16 through ZMM31, those aren't just the higher 256bits, it's
-	// the full register so each is 64 bytes (512bits)
+	if features&_XSAVE_FEATURES_AMX == _XSAVE_FEATURES_AMX {
+		// The tile data component is 8KB large, instead of copying it we keep
+		// a reference to the XSAVE area and only when the tiles are not in
+		// their initial configuration.
+		end := _XSAVE_AMX_TILEDATA_REGION_START + _XSAVE_AMX_TILEDATA_REGION_LEN
+		if end <= len(xstateargs) {
+			regset.AmxState = readComponent(_XSAVE_FEATURE_AMX_TILECFG, _XSAVE_AMX_TILECFG_REGION_START, regset.TileCfg[:])
+			if regset.AmxState && xstate_bv&_XSAVE_FEATURE_AMX_TILEDATA != 0 {
+				regset.TileData = xstateargs[_XSAVE_AMX_TILEDATA_REGION_START:end]
+			}
+		}
+	}
 
 	return nil
 }
 
 func (xstate *AMD64Xstate) SetXmmRegister(n int, value []byte) error {
-	if n >= 16 {
+	if n >= 32 {
 		return fmt.Errorf("setting register XMM%d not supported", n)
 	}
 	if len(value) > 64 {
 		return fmt.Errorf("value of register XMM%d too large (%d bytes)", n, len(value))
 	}
 
+	if n >= 16 {
+		// XMM16 through XMM31 only exist as part of ZMM16 through ZMM31,
+		// which are stored whole in their own component.
+		zmmpos := _XSAVE_AVX512_HI16_ZMM_REGION_START + ((n - 16) * 64)
+		if zmmpos+len(value) > len(xstate.Xsave) {
+			return fmt.Errorf("could not set XMM%d: not in XSAVE area", n)
+		}
+		copy(xstate.Xsave[zmmpos:], value)
+		return nil
+	}
 	// Copy least significant 16 bytes to Xsave area
 
 	xmmval := value
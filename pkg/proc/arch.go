@@ -17,9 +17,11 @@ type Arch struct {
 	prologues                []opcodeSeq
 	breakpointInstruction    []byte
 	altBreakpointInstruction []byte
+	syscallInstruction       []byte // instruction used to enter the kernel, nil if unknown
 	breakInstrMovesPC        bool
 	derefTLS                 bool
 	usesLR                   bool // architecture uses a link register, also called RA on some architectures
+	hwBreakpointSlots        int  // number of hardware breakpoint slots (debug registers) usable for watchpoints
 	PCRegNum                 uint64
 	SPRegNum                 uint64
 	BPRegNum                 uint64
@@ -45,8 +47,10 @@ type Arch struct {
 	// given register, the register value can be nil in which case only the
 	// register name will be returned.
 	DwarfRegisterToString func(int, *op.DwarfRegister) (string, bool, string)
-	// inhibitStepInto returns whether StepBreakpoint can be set at pc.
-	inhibitStepInto     func(bi *BinaryInfo, pc uint64) bool
+	// isThunkSymbol returns true if name is the name of a linker or
+	// compiler generated thunk (or veneer) that step should go through
+	// transparently.
+	isThunkSymbol       func(name string) bool
 	RegisterNameToDwarf func(s string) (int, bool)
 
 	// asmRegisters maps assembly register numbers to dwarf registers.
@@ -62,6 +66,11 @@ type Arch struct {
 	// the signal handler. See comment in FixFrameUnwindContext for a
 	// description of why this is needed.
 	sigreturnfn *Function
+
+	// pointerAuthMask is the set of bits of a code address that can hold a
+	// pointer authentication code (see ARMv8.3 PAC), they are cleared from
+	// return addresses during stack unwinding.
+	pointerAuthMask uint64
 }
 
 type asmRegister struct {
@@ -114,6 +123,12 @@ func (a *Arch) DerefTLS() bool {
 	return a.derefTLS
 }
 
+// stripPointerAuth removes the pointer authentication code from the code
+// address pc.
+func (a *Arch) stripPointerAuth(pc uint64) uint64 {
+	return pc &^ a.pointerAuthMask
+}
+
 // getAsmRegister returns the value of the asm register asmreg using the asmRegisters table of arch.
 // The interpretation of asmreg is architecture specific and defined by the disassembler.
 // A mask value of 0 inside asmRegisters is equivalent to ^uint64(0).
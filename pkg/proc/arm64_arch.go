@@ -16,12 +16,33 @@ var arm64BreakInstruction = []byte{0x0, 0x0, 0x20, 0xd4}
 // ARM64Arch returns an initialized ARM64
 // struct.
 func ARM64Arch(goos string) *Arch {
+	hwBreakpointSlots := 0
+	var pointerAuthMask uint64
+	var syscallInstruction []byte
+	switch goos {
+	case "darwin":
+		syscallInstruction = []byte{0x01, 0x10, 0x00, 0xd4} // SVC #0x80
+		// Hardware watchpoints are set through debugserver, which exposes
+		// the four watchpoint registers of Apple processors.
+		hwBreakpointSlots = 4
+		// User space addresses on darwin/arm64 are at most 47 bits wide, the
+		// bits above can only contain a pointer authentication code.
+		pointerAuthMask = ^uint64(1<<47 - 1)
+	case "linux":
+		// The architecture allows up to 16 watchpoint registers but most
+		// implementations have 4.
+		hwBreakpointSlots = 4
+		syscallInstruction = []byte{0x01, 0x00, 0x00, 0xd4} // SVC #0
+	}
 	return &Arch{
 		Name:                             "arm64",
 		ptrSize:                          8,
 		maxInstructionLength:             4,
 		breakpointInstruction:            arm64BreakInstruction,
+		syscallInstruction:               syscallInstruction,
 		breakInstrMovesPC:                false,
+		hwBreakpointSlots:                hwBreakpointSlots,
+		pointerAuthMask:                  pointerAuthMask,
 		derefTLS:                         false,
 		prologues:                        prologuesARM64,
 		fixFrameUnwindContext:            arm64FixFrameUnwindContext,
@@ -30,7 +51,7 @@ func ARM64Arch(goos string) *Arch {
 		RegistersToDwarfRegisters:        arm64RegistersToDwarfRegisters,
 		addrAndStackRegsToDwarfRegisters: arm64AddrAndStackRegsToDwarfRegisters,
 		DwarfRegisterToString:            arm64DwarfRegisterToString,
-		inhibitStepInto:                  func(*BinaryInfo, uint64) bool { return false },
+		isThunkSymbol:                    arm64IsThunkSymbol,
 		asmDecode:                        arm64AsmDecode,
 		usesLR:                           true,
 		PCRegNum:                         regnum.ARM64_PC,
@@ -151,6 +172,7 @@ func arm64SwitchStack(it *stackIterator, callFrameRegs *op.DwarfRegisters) bool
 				reg, _ := it.readRegisterAt(it.regs.BPRegNum, it.regs.SP()+8*14)
 				it.regs.AddReg(it.regs.BPRegNum, reg)
 			}
+			newlr = it.bi.Arch.stripPointerAuth(newlr)
 			it.regs.Reg(it.regs.LRRegNum).Uint64Val = uint64(newlr)
 			it.regs.Reg(it.regs.SPRegNum).Uint64Val = uint64(newsp)
 			it.pc = newlr
@@ -247,6 +269,14 @@ var arm64NameToDwarf = func() map[string]int {
 	for i := 0; i <= 31; i++ {
 		r[fmt.Sprintf("v%d", i)] = i + 64
 	}
+	r["vg"] = regnum.ARM64_VG
+	r["ffr"] = regnum.ARM64_FFR
+	for i := 0; i <= 15; i++ {
+		r[fmt.Sprintf("p%d", i)] = regnum.ARM64_P0 + i
+	}
+	for i := 0; i <= 31; i++ {
+		r[fmt.Sprintf("z%d", i)] = regnum.ARM64_Z0 + i
+	}
 	return r
 }()
 
@@ -312,8 +342,60 @@ func arm64DwarfRegisterToString(i int, reg *op.DwarfRegister) (name string, floa
 		fmt.Fprintf(&out, " s = {0x%02x%02x%02x%02x%02x%02x%02x%02x", vi[15], vi[14], vi[13], vi[12], vi[11], vi[10], vi[9], vi[8])
 		fmt.Fprintf(&out, "%02x%02x%02x%02x%02x%02x%02x%02x}}\n\t}", vi[7], vi[6], vi[5], vi[4], vi[3], vi[2], vi[1], vi[0])
 		return name, true, out.String()
+	} else if reg.Bytes != nil && name[0] == 'Z' {
+		return name, true, formatSVEReg(reg.Bytes)
+	} else if reg.Bytes != nil && (name[0] == 'P' || name == "FFR") {
+		return name, true, formatSVEPredicate(reg.Bytes)
+	} else if name == "VG" {
+		return name, true, fmt.Sprintf("%d (VL = %d bits)", reg.Uint64Val, reg.Uint64Val*64)
 	} else if reg.Bytes == nil || (reg.Bytes != nil && len(reg.Bytes) < 16) {
 		return name, false, fmt.Sprintf("%#016x", reg.Uint64Val)
 	}
 	return name, false, fmt.Sprintf("%#x", reg.Bytes)
 }
+
+// formatSVEReg formats the value of a SVE vector register as lanes of
+// every element size, the number of lanes depends on the vector length.
+func formatSVEReg(b []byte) string {
+	var out bytes.Buffer
+	out.WriteString(" {")
+	for _, lane := range []struct {
+		name string
+		sz   int
+	}{{"D", 8}, {"S", 4}, {"H", 2}, {"B", 1}} {
+		fmt.Fprintf(&out, "\n\t%s = {", lane.name)
+		for i := 0; i+lane.sz <= len(b); i += lane.sz {
+			if i > 0 {
+				out.WriteString(", ")
+			}
+			var v uint64
+			for j := lane.sz - 1; j >= 0; j-- {
+				v = v<<8 | uint64(b[i+j])
+			}
+			fmt.Fprintf(&out, "%#0*x", 2*lane.sz, v)
+		}
+		out.WriteString("}")
+	}
+	out.WriteString("\n\t}")
+	return out.String()
+}
+
+// formatSVEPredicate formats the value of a SVE predicate register, which
+// has one bit for each byte of a vector register, as a binary number.
+func formatSVEPredicate(b []byte) string {
+	var out bytes.Buffer
+	out.WriteString("0b")
+	for i := len(b) - 1; i >= 0; i-- {
+		fmt.Fprintf(&out, "%08b", b[i])
+	}
+	return out.String()
+}
+
+// arm64IsThunkSymbol returns true for the veneers inserted by the linker
+// when the target of a branch is out of range: GNU ld names them
+// __<target>_veneer, lld and gold __AArch64ADRPThunk_<target> and
+// __AArch64AbsLongThunk_<target>.
+func arm64IsThunkSymbol(name string) bool {
+	return (strings.HasPrefix(name, "__") && strings.HasSuffix(name, "_veneer")) ||
+		(strings.HasPrefix(name, "__AArch64") && strings.Contains(name, "Thunk_"))
+}
@@ -0,0 +1,206 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// bigDefaultDigits is the number of digits of the values of math/big
+// rendered if LoadConfig.MaxStringLen is not set.
+const bigDefaultDigits = 64
+
+// bigDigits returns the maximum number of digits of the values of
+// math/big rendered with cfg.
+func bigDigits(cfg LoadConfig) int {
+	if cfg.MaxStringLen > 0 {
+		return cfg.MaxStringLen
+	}
+	return bigDefaultDigits
+}
+
+// formatBigInt renders the math/big.Int v in decimal.
+func formatBigInt(v *Variable, cfg LoadConfig) (string, error) {
+	return bigIntText(v, bigDigits(cfg))
+}
+
+// formatBigRat renders the math/big.Rat v as a fraction, like its String
+// method.
+func formatBigRat(v *Variable, cfg LoadConfig) (string, error) {
+	digits := bigDigits(cfg)
+	a, err := v.structMember("a")
+	if err != nil {
+		return "", err
+	}
+	b, err := v.structMember("b")
+	if err != nil {
+		return "", err
+	}
+	num, err := bigIntText(a, digits)
+	if err != nil {
+		return "", err
+	}
+	// the denominator of a Rat is 1 if it is zero
+	den, err := bigIntText(b, digits)
+	if err != nil {
+		return "", err
+	}
+	if den == "0" {
+		den = "1"
+	}
+	return num + "/" + den, nil
+}
+
+// formatBigFloat renders the math/big.Float v like its Text method with
+// format 'g', using the precision of v.
+func formatBigFloat(v *Variable, cfg LoadConfig) (string, error) {
+	digits := bigDigits(cfg)
+	prec, err := fieldUint(v, "prec")
+	if err != nil {
+		return "", err
+	}
+	form, err := fieldUint(v, "form")
+	if err != nil {
+		return "", err
+	}
+	neg, err := fieldUint(v, "neg")
+	if err != nil {
+		return "", err
+	}
+	sign := ""
+	if neg != 0 {
+		sign = "-"
+	}
+	// see the form type of math/big
+	switch form {
+	case 0: // zero
+		return sign + "0", nil
+	case 1: // finite
+	case 2: // inf
+		if neg != 0 {
+			return "-Inf", nil
+		}
+		return "+Inf", nil
+	default:
+		return "", fmt.Errorf("unknown form %d", form)
+	}
+
+	exp, err := fieldInt(v, "exp")
+	if err != nil {
+		return "", err
+	}
+	mant, err := v.structMember("mant")
+	if err != nil {
+		return "", err
+	}
+	x, shift, err := readNat(mant, digits)
+	if err != nil {
+		return "", err
+	}
+	// the value of a finite Float is 0.mant * 2**exp
+	wordBits := int64(v.bi.Arch.PtrSize()) * 8
+	f := new(big.Float).SetInt(x)
+	f.SetMantExp(f, int(exp+shift-mant.Len*wordBits))
+	if prec > 0 && uint(prec) < f.Prec() {
+		f.SetPrec(uint(prec))
+	}
+	s := f.Text('g', -1)
+	if len(s) > digits {
+		s = f.Text('e', digits-1)
+	}
+	return sign + s, nil
+}
+
+// bigIntText renders the math/big.Int v in decimal, with at most digits
+// digits, see bigMagnitudeText.
+func bigIntText(v *Variable, digits int) (string, error) {
+	neg, err := fieldUint(v, "neg")
+	if err != nil {
+		return "", err
+	}
+	abs, err := v.structMember("abs")
+	if err != nil {
+		return "", err
+	}
+	x, shift, err := readNat(abs, digits)
+	if err != nil {
+		return "", err
+	}
+	s := bigMagnitudeText(x, shift, digits)
+	if neg != 0 && s != "0" {
+		s = "-" + s
+	}
+	return s, nil
+}
+
+// readNat returns the value of the math/big.nat v, a slice of words stored
+// least significant first. Only the most significant words needed to
+// compute digits decimal digits are read, the value of v is approximated
+// by x * 2**shift.
+func readNat(v *Variable, digits int) (x *big.Int, shift int64, err error) {
+	if v.Kind != reflect.Slice {
+		return nil, 0, errors.New("not a slice")
+	}
+	if v.Unreadable != nil {
+		return nil, 0, v.Unreadable
+	}
+	if v.Len < 0 {
+		return nil, 0, fmt.Errorf("invalid length %d", v.Len)
+	}
+	wordSize := int64(v.bi.Arch.PtrSize())
+	// a decimal digit takes less than 4 bits, the additional words
+	// guarantee that the leading digits are correct.
+	maxWords := int64(digits)*4/(wordSize*8) + 2
+	n, skip := v.Len, int64(0)
+	if n > maxWords {
+		n, skip = maxWords, v.Len-maxWords
+	}
+	buf := make([]byte, n*wordSize)
+	if n > 0 {
+		if _, err := DereferenceMemory(v.mem).ReadMemory(buf, v.Base+uint64(skip*wordSize)); err != nil {
+			return nil, 0, err
+		}
+	}
+	// words are little endian, the whole slice is a little endian number
+	for i, j := 0, len(buf)-1; i < j; i, j = i+1, j-1 {
+		buf[i], buf[j] = buf[j], buf[i]
+	}
+	return new(big.Int).SetBytes(buf), skip * wordSize * 8, nil
+}
+
+// bigMagnitudeText renders the non-negative integer x * 2**shift in
+// decimal. If it has more than digits digits only the leading digits are
+// rendered, followed by the magnitude of the value, for example
+// "123...(~10^4096)".
+func bigMagnitudeText(x *big.Int, shift int64, digits int) string {
+	if shift == 0 {
+		// x was read entirely and has at most a few more digits than
+		// requested, see readNat.
+		s := x.String()
+		if len(s) <= digits {
+			return s
+		}
+		return fmt.Sprintf("%s...(~10^%d)", s[:digits], len(s)-1)
+	}
+	f := new(big.Float).SetPrec(uint(digits)*4 + 64).SetInt(x)
+	f.SetMantExp(f, int(shift))
+	// d.ddde+n, with some more digits than needed so that the truncated
+	// leading digits are not rounded.
+	s := f.Text('e', digits+8)
+	e := strings.IndexByte(s, 'e')
+	if e < 0 {
+		return s
+	}
+	mant := strings.Replace(s[:e], ".", "", 1)
+	if len(mant) > digits {
+		mant = mant[:digits]
+	}
+	n, err := strconv.Atoi(s[e+1:])
+	if err != nil {
+		return s
+	}
+	return fmt.Sprintf("%s...(~10^%d)", mant, n)
+}
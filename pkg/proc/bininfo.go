@@ -66,6 +66,11 @@ type BinaryInfo struct {
 	Images []*Image
 
 	ElfDynamicSection ElfDynamicSection
+	// ElfDynamicLinkerBreak is the address of the function called by the
+	// dynamic linker every time the list of loaded shared objects changes
+	// (r_debug.r_brk), it is zero until the dynamic linker has been
+	// initialized.
+	ElfDynamicLinkerBreak uint64
 
 	lastModified time.Time // Time the executable of this process was last modified
 
@@ -312,6 +317,7 @@ type Function struct {
 	Entry, End uint64 // same as DW_AT_lowpc and DW_AT_highpc
 	offset     dwarf.Offset
 	cu         *compileUnit
+	trampoline bool // DW_AT_trampoline
 
 	// InlinedCalls lists all inlined calls to this function
 	InlinedCalls []InlinedCall
@@ -361,6 +367,26 @@ func (fn *Function) BaseName() string {
 	return fn.Name
 }
 
+// rangeParentName returns the name of the function containing the
+// range-over-func statement whose body was compiled into fn, or the empty
+// string if fn is not the body of a range-over-func statement.
+// The compiler names these bodies after the enclosing function followed
+// by "-rangeN", for example main.main-range1 or main.main-range1-range2 for
+// nested loops.
+func (fn *Function) rangeParentName() string {
+	const rangeSuffix = "-range"
+	i := strings.LastIndex(fn.Name, rangeSuffix)
+	if i <= 0 || i+len(rangeSuffix) == len(fn.Name) {
+		return ""
+	}
+	for _, c := range fn.Name[i+len(rangeSuffix):] {
+		if c < '0' || c > '9' {
+			return ""
+		}
+	}
+	return fn.Name[:i]
+}
+
 // Optimized returns true if the function was optimized by the compiler.
 func (fn *Function) Optimized() bool {
 	return fn.cu.optimized
@@ -640,6 +666,24 @@ func (bi *BinaryInfo) PCToImage(pc uint64) *Image {
 	return bi.funcToImage(fn)
 }
 
+// isThunk returns true if pc belongs to a PLT stub, to a linker generated
+// veneer or to a function marked with DW_AT_trampoline (for example the
+// ABI wrappers generated by the Go compiler). Step transparently goes
+// through those.
+func (bi *BinaryInfo) isThunk(pc uint64) bool {
+	for _, image := range bi.Images {
+		for _, rng := range image.thunkRanges {
+			if pc >= rng[0] && pc < rng[1] {
+				return true
+			}
+		}
+	}
+	if fn := bi.PCToFunc(pc); fn != nil && fn.trampoline {
+		return true
+	}
+	return false
+}
+
 // Image represents a loaded library file (shared object on linux, DLL on windows).
 type Image struct {
 	Path       string
@@ -671,6 +715,10 @@ type Image struct {
 	// which was added in go 1.11.
 	runtimeTypeToDIE map[uint64]runtimeTypeDIE
 
+	// thunkRanges lists the address ranges (relocated) of PLT sections and
+	// of linker generated veneers, see (*BinaryInfo).isThunk.
+	thunkRanges [][2]uint64
+
 	loadErrMu sync.Mutex
 	loadErr   error
 }
@@ -1178,6 +1226,12 @@ func loadBinaryInfoElf(bi *BinaryInfo, image *Image, path string, addr uint64, w
 		image.StaticBase = addr
 	}
 
+	for _, name := range []string{".plt", ".plt.got", ".plt.sec", ".iplt"} {
+		if sec := elfFile.Section(name); sec != nil && sec.Size > 0 {
+			image.thunkRanges = append(image.thunkRanges, [2]uint64{sec.Addr + image.StaticBase, sec.Addr + sec.Size + image.StaticBase})
+		}
+	}
+
 	dwarfFile := elfFile
 
 	var debugInfoBytes []byte
@@ -1243,6 +1297,15 @@ func (bi *BinaryInfo) loadSymbolName(image *Image, file *elf.File, wg *sync.Wait
 				s := symSec
 				bi.SymNames[symSec.Value+image.StaticBase] = &s
 			}
+			if symSec.Value != 0 && bi.Arch.isThunkSymbol(symSec.Name) {
+				size := symSec.Size
+				if size == 0 {
+					// some linkers emit veneer symbols without a size, at least
+					// recognize their entry point.
+					size = 1
+				}
+				image.thunkRanges = append(image.thunkRanges, [2]uint64{symSec.Value + image.StaticBase, symSec.Value + size + image.StaticBase})
+			}
 		}
 	}
 }
@@ -1999,6 +2062,7 @@ func (bi *BinaryInfo) addConcreteSubprogram(entry *dwarf.Entry, ctxt *loadDebugI
 	fn.End = highpc
 	fn.offset = entry.Offset
 	fn.cu = cu
+	fn.trampoline = entry.Val(dwarf.AttrTrampoline) != nil
 
 	if entry.Children {
 		bi.loadDebugInfoMapsInlinedCalls(ctxt, reader, cu)
@@ -1,6 +1,8 @@
 package proc
 
 import (
+	"bytes"
+	"debug/dwarf"
 	"errors"
 	"fmt"
 	"go/ast"
@@ -8,6 +10,15 @@ import (
 	"go/parser"
 	"go/token"
 	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-delve/delve/pkg/astutil"
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
+	"github.com/go-delve/delve/pkg/dwarf/reader"
 )
 
 const (
@@ -34,16 +45,62 @@ type Breakpoint struct {
 	Addr         uint64 // Address breakpoint is set for.
 	OriginalData []byte // If software breakpoint, the data we replace with breakpoint instruction.
 	Name         string // User defined name of the breakpoint
+	Group        string // User defined group of the breakpoint
 	LogicalID    int    // ID of the logical breakpoint that owns this physical breakpoint
 
 	WatchExpr    string
 	WatchType    WatchType
 	HWBreakIndex uint8 // hardware breakpoint index
 
+	// WatchPackage is the package of the scope used to evaluate WatchExpr
+	// when the watched memory is not on the stack. It is empty for
+	// watchpoints on stack variables. The expression of a watchpoint with a
+	// WatchPackage can be evaluated again, with PackageScope, after the
+	// target is restarted.
+	WatchPackage string
+	// WatchRaw is true if the watchpoint was created with SetWatchpointAddr,
+	// the watched memory has no type and WatchExpr is its address.
+	WatchRaw bool
+
+	// WatchField is the name of the header field watched by this physical
+	// watchpoint (for example "len" for a watchpoint on a slice) or the byte
+	// range it covers (for example "[8:16]") when a large variable is split
+	// across multiple watchpoints. It is empty if the watchpoint covers the
+	// whole variable.
+	WatchField string
+	// WatchOldValue and WatchNewValue are the values of the watched memory
+	// before and after the last time the watchpoint was hit.
+	WatchOldValue, WatchNewValue uint64
+
+	// watchData is the last value read from the watched memory.
+	watchData []byte
+	// watchDwarfType is the type of the watched expression, only set on the
+	// first physical breakpoint of a watchpoint.
+	watchDwarfType godwarf.Type
+	// watchIfaceType is the type of the watched interface, when the
+	// watchpoint watches the dynamic value of an interface.
+	watchIfaceType godwarf.Type
+	// watchSlice describes the slice containing the watched element, for
+	// watchpoints created with SetSliceWatchpoint.
+	watchSlice *watchSlice
+	// rewatch is the scope used to evaluate the expression of a watchpoint
+	// created with WatchRewatch, only set on the first physical breakpoint
+	// of a watchpoint.
+	rewatch *rewatchScope
+	// WatchSuspended is true if the expression of a watchpoint created with
+	// WatchRewatch could not be evaluated the last time the target stopped.
+	// Suspended watchpoints are not triggered, their hardware watchpoints
+	// are erased until the watchpoint is resumed.
+	WatchSuspended bool
+
 	// Breaklets is the list of overlapping breakpoints on this physical breakpoint.
 	// There can be at most one UserBreakpoint in this list but multiple internal breakpoints are allowed.
 	Breaklets []*Breaklet
 
+	// bpmap is the breakpoint map containing this breakpoint, it keeps the
+	// hit counts of the logical breakpoint.
+	bpmap *BreakpointMap
+
 	// Breakpoint information
 	Tracepoint  bool // Tracepoint flag
 	TraceReturn bool
@@ -52,6 +109,26 @@ type Breakpoint struct {
 	Variables   []string // Variables to evaluate
 	LoadArgs    *LoadConfig
 	LoadLocals  *LoadConfig
+	// FunctionRegexp is the regular expression used to select the functions
+	// this breakpoint is set on, if any.
+	FunctionRegexp string
+	// Package is the package whose functions this breakpoint is set on, if
+	// any. If ExcludeWrappers is set autogenerated wrappers are skipped.
+	Package         string
+	ExcludeWrappers bool
+	// FunctionCount is the number of functions covered by a breakpoint set
+	// using Package.
+	FunctionCount int
+	// Commands is a list of commands that the client should execute when
+	// the breakpoint is hit.
+	Commands []string
+	// CondWarnings lists problems found validating the condition of the
+	// breakpoint that did not prevent it from being set.
+	CondWarnings []string
+	// MaxHits, if greater than zero, is the number of times the logical
+	// breakpoint can be hit before it is automatically cleared, see
+	// SetMaxHitsCallback.
+	MaxHits int
 
 	// ReturnInfo describes how to collect return variables when this
 	// breakpoint is hit as a return breakpoint.
@@ -67,10 +144,51 @@ type Breaklet struct {
 
 	// Cond: if not nil the breakpoint will be triggered only if evaluating Cond returns true
 	Cond ast.Expr
-
+	// CondCalls: if true Cond is allowed to call functions of the target,
+	// the calls are injected in the goroutine that reached the breakpoint,
+	// see evalConditionsWithCalls.
+	CondCalls bool
+
+	// HitCount and TotalHitCount count the hits of this physical breakpoint
+	// only, HitCond, HitCondPerG and AutoCheckpoint are evaluated against the
+	// hit counts of the logical breakpoint, see BreakpointMap.
 	HitCount      map[int]uint64 // Number of times a breakpoint has been reached in a certain goroutine
 	TotalHitCount uint64         // Number of times a breakpoint has been reached
 
+	// LastHit is the time the breakpoint was last reached.
+	LastHit time.Time
+	// hitIntervals is a ring buffer containing the intervals between the
+	// last hits of the breakpoint, hitIntervalsCount is the number of
+	// intervals recorded since the hit counts were reset.
+	hitIntervals      [hitIntervalsLen]time.Duration
+	hitIntervalsCount int
+
+	// HitHistorySize is the maximum number of hits remembered in the hit
+	// history of the breakpoint, see TakeHitHistory. If it is zero the hit
+	// history is not recorded.
+	HitHistorySize int
+	// hitHistory is a ring buffer containing the last hits of the
+	// breakpoint, hitHistoryCount is the number of hits recorded since the
+	// hit history was last cleared.
+	hitHistory      []BreakpointHit
+	hitHistoryCount int
+
+	// AutoCheckpoint, if greater than zero, makes the breakpoint create a
+	// checkpoint every AutoCheckpoint hits while replaying a recording.
+	AutoCheckpoint int
+	// AutoCheckpointMax is the maximum number of automatic checkpoints kept
+	// for the breakpoint, when it is exceeded the oldest checkpoint is
+	// deleted. If it is zero DefaultAutoCheckpointMax is used.
+	AutoCheckpointMax int
+	// AutoCheckpointOnly, if set together with AutoCheckpoint, makes the
+	// breakpoint only create checkpoints, the target is resumed
+	// automatically after it is hit.
+	AutoCheckpointOnly bool
+
+	// condRegexps caches the regular expressions compiled by the matches
+	// builtin while evaluating Cond.
+	condRegexps map[string]*regexp.Regexp
+
 	// DeferReturns: when kind == NextDeferBreakpoint this breakpoint
 	// will also check if the caller is runtime.gopanic or if the return
 	// address is in the DeferReturns array.
@@ -83,11 +201,53 @@ type Breaklet struct {
 	DeferReturns []uint64
 
 	// HitCond: if not nil the breakpoint will be triggered only if the evaluated HitCond returns
-	// true with the TotalHitCount.
+	// true with the total hit count of the logical breakpoint.
 	HitCond *struct {
 		Op  token.Token
 		Val int
 	}
+	// HitCondPerG: if true HitCond is evaluated with the number of times the
+	// logical breakpoint has been reached by the current goroutine instead
+	// of its total hit count.
+	HitCondPerG bool
+
+	// Logpoint: if not nil the breakpoint is a logpoint, when it is triggered
+	// the logpoint message is rendered and the target is not stopped.
+	Logpoint *Logpoint
+
+	// watchpoint: when Kind == WatchOutOfScopeBreakpoint this is the
+	// watchpoint on a stack variable that goes out of scope when this
+	// breakpoint is triggered, when Kind == StackResizeBreakpoint this is
+	// the watchpoint on a stack variable that is moved when its stack is
+	// moved, when Kind == WatchRearmBreakpoint this is the
+	// watchpoint on the dynamic value of the interface, or on the element of
+	// the slice, whose header is watched by this breakpoint.
+	watchpoint *Breakpoint
+	// reverse: when Kind == WatchOutOfScopeBreakpoint this breakpoint is set
+	// on the CALL instruction that created the frame of the watched
+	// variable and only applies while the target executes backward,
+	// otherwise it is set on the return address of the frame and only
+	// applies while the target executes forward.
+	reverse bool
+
+	// callback: if not nil it is called when the breaklet is reached and its
+	// condition is true, the breaklet is only active if callback returns
+	// true. It is used by ContinueUntil to re-evaluate its condition when a
+	// watchpoint is triggered.
+	callback func(th Thread) (bool, error)
+}
+
+// hitIntervalsLen is the number of intervals between hits remembered by
+// each breaklet.
+const hitIntervalsLen = 16
+
+// BreakpointHit describes a hit of a breakpoint recorded in its hit
+// history.
+type BreakpointHit struct {
+	GoroutineID int
+	ThreadID    int
+	PC          uint64
+	Time        time.Time
 }
 
 // BreakpointKind determines the behavior of delve when the
@@ -109,6 +269,36 @@ const (
 	// Continue will set a new breakpoint (of NextBreakpoint kind) on the
 	// destination of CALL, delete this breakpoint and then continue again
 	StepBreakpoint
+	// WatchOutOfScopeBreakpoint is a breakpoint set on the return address
+	// of the frame owning a watched stack variable, when it is triggered the
+	// watchpoint is cleared.
+	WatchOutOfScopeBreakpoint
+	// WatchRearmBreakpoint is a watchpoint set on the data pointer of a
+	// watched interface value, when it is triggered the watchpoint is moved
+	// to the new dynamic value of the interface. It is also set on the data
+	// pointer and length of the slice containing the element watched by a
+	// watchpoint created with SetSliceWatchpoint.
+	WatchRearmBreakpoint
+	// DynamicLinkerBreakpoint is a breakpoint set on the function called by
+	// the dynamic linker every time the list of loaded shared objects
+	// changes, when it is triggered the callback set with
+	// SetSharedObjectsLoadedCallback is called and the target is resumed.
+	DynamicLinkerBreakpoint
+	// PinnedSchedulerBreakpoint is a breakpoint set by ResumePinned on the
+	// entry points of the scheduler, when it is triggered by the thread
+	// running the pinned goroutine the target is stopped because the
+	// goroutine can not continue running without the other goroutines.
+	PinnedSchedulerBreakpoint
+	// StackResizeBreakpoint is a breakpoint set on the return instructions
+	// of runtime.copystack, when it is triggered the watchpoints on stack
+	// variables of the goroutine whose stack was moved are moved to the new
+	// stack.
+	StackResizeBreakpoint
+	// SyscallReturnBreakpoint is a breakpoint set by
+	// continueOnceSoftwareWatch on the instruction following a system call,
+	// when it is triggered the thread that was blocked in the kernel has
+	// returned to user space and can be single stepped again.
+	SyscallReturnBreakpoint
 
 	steppingMask = NextBreakpoint | NextDeferBreakpoint | StepBreakpoint
 )
@@ -119,8 +309,20 @@ type WatchType uint8
 const (
 	WatchRead WatchType = 1 << iota
 	WatchWrite
+	// WatchSoftware marks a watchpoint that is implemented by single
+	// stepping the target and comparing the watched memory after each step,
+	// instead of using a hardware debug register.
+	WatchSoftware
+	// WatchRewatch marks a watchpoint whose expression is evaluated again
+	// every time the target stops, the watchpoint is moved if the address of
+	// the expression changed.
+	WatchRewatch
 )
 
+// WatchExecute is the WatchType of a hardware execute breakpoint, it has
+// neither the read nor the write flag set and a size of one byte.
+const WatchExecute WatchType = 1 << 4
+
 // Read returns true if the hardware breakpoint should trigger on memory reads.
 func (wtype WatchType) Read() bool {
 	return wtype&WatchRead != 0
@@ -131,6 +333,23 @@ func (wtype WatchType) Write() bool {
 	return wtype&WatchWrite != 0
 }
 
+// Software returns true if the watchpoint is implemented by single
+// stepping the target instead of using a hardware breakpoint.
+func (wtype WatchType) Software() bool {
+	return wtype&WatchSoftware != 0
+}
+
+// Execute returns true if this is a hardware execute breakpoint.
+func (wtype WatchType) Execute() bool {
+	return wtype != 0 && wtype&(WatchRead|WatchWrite) == 0
+}
+
+// Rewatch returns true if the expression of the watchpoint is evaluated
+// again every time the target stops.
+func (wtype WatchType) Rewatch() bool {
+	return wtype&WatchRewatch != 0
+}
+
 // Size returns the size in bytes of the hardware breakpoint.
 func (wtype WatchType) Size() int {
 	return int(wtype >> 4)
@@ -189,7 +408,25 @@ func (bpstate *BreakpointState) checkCond(breaklet *Breaklet, thread Thread) {
 	var condErr error
 	active := true
 	if breaklet.Cond != nil {
-		active, condErr = evalBreakpointCondition(thread, breaklet.Cond)
+		if breaklet.CondCalls && condHasCalls(breaklet.Cond) {
+			// function calls can not be injected while the target is being
+			// stopped, the condition is evaluated later by Continue.
+			bpstate.condCalls = append(bpstate.condCalls, breaklet)
+			return
+		}
+		if breaklet.condRegexps == nil {
+			breaklet.condRegexps = make(map[string]*regexp.Regexp)
+		}
+		active, condErr = evalBreakpointCondition(thread, breaklet.Cond, breaklet.condRegexps)
+	}
+	bpstate.applyCond(breaklet, thread, active, condErr)
+}
+
+// applyCond updates bpstate with the result of the evaluation of the
+// condition of breaklet on thread.
+func (bpstate *BreakpointState) applyCond(breaklet *Breaklet, thread Thread, active bool, condErr error) {
+	if active && condErr == nil && breaklet.callback != nil {
+		active, condErr = breaklet.callback(thread)
 	}
 
 	if condErr != nil && bpstate.CondError == nil {
@@ -201,11 +438,50 @@ func (bpstate *BreakpointState) checkCond(breaklet *Breaklet, thread Thread) {
 
 	switch breaklet.Kind {
 	case UserBreakpoint:
-		if g, err := GetG(thread); err == nil {
+		now := time.Now()
+		hits := bpstate.Breakpoint.logicalHits()
+		breaklet.TotalHitCount++
+		hits.total++
+		breaklet.recordHit(now)
+		if breaklet.AutoCheckpoint > 0 && hits.total%uint64(breaklet.AutoCheckpoint) == 0 {
+			bpstate.AutoCheckpoint = true
+		}
+		if bpstate.MaxHits > 0 && hits.total == uint64(bpstate.MaxHits) {
+			bpstate.MaxHitsReached = true
+		}
+		hitCount := hits.total
+		g, err := GetG(thread)
+		if err == nil {
 			breaklet.HitCount[g.ID]++
+			hits.perG[g.ID]++
+			if breaklet.HitCondPerG {
+				hitCount = hits.perG[g.ID]
+			}
+		}
+		if breaklet.HitHistorySize > 0 {
+			hit := BreakpointHit{ThreadID: thread.ThreadID(), PC: bpstate.Addr, Time: now}
+			if g != nil {
+				hit.GoroutineID = g.ID
+			}
+			if bpstate.WatchType != 0 && !bpstate.WatchType.Execute() {
+				// the address of a watchpoint is not a PC
+				if regs, err := thread.Registers(); err == nil {
+					hit.PC = regs.PC()
+				}
+			}
+			breaklet.recordHitHistory(hit)
+		}
+		active = checkHitCond(breaklet, hitCount)
+		if active && breaklet.Logpoint != nil {
+			bpstate.LogMessage = &LogpointMessage{LogicalID: bpstate.LogicalID, Text: breaklet.Logpoint.text, Values: breaklet.Logpoint.eval(thread, g)}
+			if g != nil {
+				bpstate.LogMessage.GoroutineID = g.ID
+			}
+			active = false
+		}
+		if breaklet.AutoCheckpoint > 0 && breaklet.AutoCheckpointOnly {
+			active = false
 		}
-		breaklet.TotalHitCount++
-		active = checkHitCond(breaklet)
 
 	case StepBreakpoint, NextBreakpoint, NextDeferBreakpoint:
 		nextDeferOk := true
@@ -227,6 +503,40 @@ func (bpstate *BreakpointState) checkCond(breaklet *Breaklet, thread Thread) {
 			}
 		}
 
+	case WatchOutOfScopeBreakpoint:
+		if active {
+			bpstate.WatchOutOfScope = append(bpstate.WatchOutOfScope, breaklet.watchpoint)
+		}
+		active = false
+
+	case WatchRearmBreakpoint:
+		if active {
+			bpstate.WatchRearm = append(bpstate.WatchRearm, breaklet.watchpoint)
+		}
+		active = false
+
+	case DynamicLinkerBreakpoint:
+		if active {
+			bpstate.SharedObjectsChanged = true
+		}
+		active = false
+
+	case PinnedSchedulerBreakpoint:
+		if active {
+			bpstate.PinnedGoroutineLeft = true
+		}
+		active = false
+
+	case StackResizeBreakpoint:
+		// the watchpoint is moved by the callback of the breaklet
+		active = false
+
+	case SyscallReturnBreakpoint:
+		if active {
+			bpstate.SyscallReturned = true
+		}
+		active = false
+
 	default:
 		bpstate.CondError = fmt.Errorf("internal error unknown breakpoint kind %v", breaklet.Kind)
 	}
@@ -236,27 +546,76 @@ func (bpstate *BreakpointState) checkCond(breaklet *Breaklet, thread Thread) {
 	}
 }
 
-// checkHitCond evaluates bp's hit condition on thread.
-func checkHitCond(breaklet *Breaklet) bool {
+// recordHit records the time of a hit of breaklet.
+func (breaklet *Breaklet) recordHit(now time.Time) {
+	if !breaklet.LastHit.IsZero() {
+		breaklet.hitIntervals[breaklet.hitIntervalsCount%hitIntervalsLen] = now.Sub(breaklet.LastHit)
+		breaklet.hitIntervalsCount++
+	}
+	breaklet.LastHit = now
+}
+
+// HitIntervals returns the intervals between the last hits of the
+// breakpoint, oldest first.
+func (breaklet *Breaklet) HitIntervals() []time.Duration {
+	n := breaklet.hitIntervalsCount
+	if n > hitIntervalsLen {
+		n = hitIntervalsLen
+	}
+	r := make([]time.Duration, 0, n)
+	for i := breaklet.hitIntervalsCount - n; i < breaklet.hitIntervalsCount; i++ {
+		r = append(r, breaklet.hitIntervals[i%hitIntervalsLen])
+	}
+	return r
+}
+
+// recordHitHistory records hit in the hit history of breaklet.
+func (breaklet *Breaklet) recordHitHistory(hit BreakpointHit) {
+	if len(breaklet.hitHistory) != breaklet.HitHistorySize {
+		// HitHistorySize was changed, the old history is discarded.
+		breaklet.hitHistory = make([]BreakpointHit, breaklet.HitHistorySize)
+		breaklet.hitHistoryCount = 0
+	}
+	breaklet.hitHistory[breaklet.hitHistoryCount%len(breaklet.hitHistory)] = hit
+	breaklet.hitHistoryCount++
+}
+
+// TakeHitHistory returns the hits recorded in the hit history of the
+// breakpoint, oldest first, and clears the hit history.
+func (breaklet *Breaklet) TakeHitHistory() []BreakpointHit {
+	n := breaklet.hitHistoryCount
+	if n > len(breaklet.hitHistory) {
+		n = len(breaklet.hitHistory)
+	}
+	r := make([]BreakpointHit, 0, n)
+	for i := breaklet.hitHistoryCount - n; i < breaklet.hitHistoryCount; i++ {
+		r = append(r, breaklet.hitHistory[i%len(breaklet.hitHistory)])
+	}
+	breaklet.hitHistoryCount = 0
+	return r
+}
+
+// checkHitCond evaluates bp's hit condition with the specified hit count.
+func checkHitCond(breaklet *Breaklet, hitCount uint64) bool {
 	if breaklet.HitCond == nil {
 		return true
 	}
 	// Evaluate the breakpoint condition.
 	switch breaklet.HitCond.Op {
 	case token.EQL:
-		return int(breaklet.TotalHitCount) == breaklet.HitCond.Val
+		return int(hitCount) == breaklet.HitCond.Val
 	case token.NEQ:
-		return int(breaklet.TotalHitCount) != breaklet.HitCond.Val
+		return int(hitCount) != breaklet.HitCond.Val
 	case token.GTR:
-		return int(breaklet.TotalHitCount) > breaklet.HitCond.Val
+		return int(hitCount) > breaklet.HitCond.Val
 	case token.LSS:
-		return int(breaklet.TotalHitCount) < breaklet.HitCond.Val
+		return int(hitCount) < breaklet.HitCond.Val
 	case token.GEQ:
-		return int(breaklet.TotalHitCount) >= breaklet.HitCond.Val
+		return int(hitCount) >= breaklet.HitCond.Val
 	case token.LEQ:
-		return int(breaklet.TotalHitCount) <= breaklet.HitCond.Val
+		return int(hitCount) <= breaklet.HitCond.Val
 	case token.REM:
-		return int(breaklet.TotalHitCount)%breaklet.HitCond.Val == 0
+		return int(hitCount)%breaklet.HitCond.Val == 0
 	}
 	return false
 }
@@ -328,7 +687,26 @@ func (bp *Breakpoint) UserBreaklet() *Breaklet {
 	return nil
 }
 
-func evalBreakpointCondition(thread Thread, cond ast.Expr) (bool, error) {
+// ResetHitCounts resets the total and per-goroutine hit counts of the user
+// breaklet of bp.
+func (bp *Breakpoint) ResetHitCounts() {
+	breaklet := bp.UserBreaklet()
+	if breaklet == nil {
+		return
+	}
+	breaklet.TotalHitCount = 0
+	breaklet.HitCount = map[int]uint64{}
+	if bp.bpmap != nil {
+		delete(bp.bpmap.logicalHits, bp.LogicalID)
+	}
+	breaklet.LastHit = time.Time{}
+	breaklet.hitIntervalsCount = 0
+	breaklet.hitHistoryCount = 0
+}
+
+// evalBreakpointCondition evaluates cond on thread, the regular
+// expressions used by cond are cached in regexps, if it is not nil.
+func evalBreakpointCondition(thread Thread, cond ast.Expr, regexps map[string]*regexp.Regexp) (bool, error) {
 	if cond == nil {
 		return true, nil
 	}
@@ -339,6 +717,12 @@ func evalBreakpointCondition(thread Thread, cond ast.Expr) (bool, error) {
 			return true, err
 		}
 	}
+	scope.regexps = regexps
+	return scope.evalCondition(cond)
+}
+
+// evalCondition evaluates the boolean expression cond in scope.
+func (scope *EvalScope) evalCondition(cond ast.Expr) (bool, error) {
 	v, err := scope.evalAST(cond)
 	if err != nil {
 		return true, fmt.Errorf("error evaluating expression: %v", err)
@@ -353,6 +737,189 @@ func evalBreakpointCondition(thread Thread, cond ast.Expr) (bool, error) {
 	return constant.BoolVal(v.Value), nil
 }
 
+// ValidateCondition checks that cond is a plausible condition for a
+// breakpoint set at pc, without evaluating it.
+// An error is returned if cond can not evaluate to a boolean value or if it
+// references an identifier that isn't defined anywhere in the function
+// containing pc or in its package. Identifiers that are local variables of
+// the function but are not visible at pc are returned as warnings.
+// If pc is 0 only the type of the top-level expression is checked.
+func (bi *BinaryInfo) ValidateCondition(pc uint64, cond ast.Expr) (warnings []string, err error) {
+	if cond == nil {
+		return nil, nil
+	}
+	if err := checkConditionBoolean(cond); err != nil {
+		return nil, err
+	}
+	if err := checkConditionRegexps(cond); err != nil {
+		return nil, err
+	}
+	if pc == 0 {
+		return nil, nil
+	}
+	fn := bi.PCToFunc(pc)
+	if fn == nil || fn.cu == nil {
+		return nil, nil
+	}
+	dwarfTree, err := fn.cu.image.getDwarfTree(fn.offset)
+	if err != nil {
+		return nil, nil
+	}
+	_, line, _ := bi.PCToLine(pc)
+	visible := reader.Variables(dwarfTree, pc, line, reader.VariablesOnlyVisible|reader.VariablesTrustDeclLine)
+	all := reader.Variables(dwarfTree, pc, line, 0)
+
+	for _, ident := range conditionIdents(cond, bi.PackageMap) {
+		switch {
+		case ident == "true" || ident == "false" || ident == "nil":
+		case hasVariable(visible, ident):
+		case hasVariable(all, ident):
+			warnings = append(warnings, fmt.Sprintf("variable %q may not be visible at %#x", ident, pc))
+		case bi.hasGlobal(fn.PackageName(), ident):
+		case validRegisterName(ident) != "":
+		default:
+			return warnings, fmt.Errorf("could not find symbol %s", ident)
+		}
+	}
+	return warnings, nil
+}
+
+// checkConditionBoolean returns an error if the top-level expression of
+// cond can not have a boolean value.
+func checkConditionBoolean(cond ast.Expr) error {
+	for {
+		paren, ok := cond.(*ast.ParenExpr)
+		if !ok {
+			break
+		}
+		cond = paren.X
+	}
+	switch cond := cond.(type) {
+	case *ast.BasicLit, *ast.CompositeLit, *ast.FuncLit, *ast.SliceExpr:
+		return errors.New("condition expression not boolean")
+	case *ast.BinaryExpr:
+		switch cond.Op {
+		case token.ADD, token.SUB, token.MUL, token.QUO, token.REM, token.AND, token.OR, token.XOR, token.SHL, token.SHR, token.AND_NOT:
+			return errors.New("condition expression not boolean")
+		}
+	case *ast.UnaryExpr:
+		switch cond.Op {
+		case token.SUB, token.ADD, token.XOR, token.AND:
+			return errors.New("condition expression not boolean")
+		}
+	}
+	return nil
+}
+
+// checkConditionRegexps returns an error if any of the constant patterns
+// passed to the matches builtin in cond is not a valid regular expression.
+func checkConditionRegexps(cond ast.Expr) error {
+	var err error
+	ast.Inspect(cond, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || err != nil {
+			return err == nil
+		}
+		if fn, ok := call.Fun.(*ast.Ident); !ok || fn.Name != "matches" || len(call.Args) != 2 {
+			return true
+		}
+		lit, ok := call.Args[1].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		pattern, uerr := strconv.Unquote(lit.Value)
+		if uerr != nil {
+			return true
+		}
+		if _, cerr := regexp.Compile(pattern); cerr != nil {
+			err = fmt.Errorf("invalid regular expression in %s: %v", exprToString(call), cerr)
+		}
+		return err == nil
+	})
+	return err
+}
+
+// conditionIdents returns the unqualified identifiers used as values in
+// cond. Identifiers that could be names of types, functions or builtins
+// and identifiers qualified by a package name are skipped.
+func conditionIdents(cond ast.Expr, packageMap map[string][]string) []string {
+	r := []string{}
+	var visit func(n ast.Node) bool
+	visit = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Ident:
+			r = append(r, n.Name)
+		case *ast.SelectorExpr:
+			if x, ok := n.X.(*ast.Ident); ok && len(packageMap[x.Name]) > 0 {
+				return false
+			}
+			ast.Inspect(n.X, visit)
+			return false
+		case *ast.CallExpr:
+			if _, isident := n.Fun.(*ast.Ident); !isident {
+				ast.Inspect(n.Fun, visit)
+			}
+			for _, arg := range n.Args {
+				ast.Inspect(arg, visit)
+			}
+			return false
+		case *ast.TypeAssertExpr:
+			ast.Inspect(n.X, visit)
+			return false
+		case *ast.CompositeLit, *ast.StarExpr, *ast.ArrayType, *ast.MapType, *ast.ChanType, *ast.FuncType, *ast.InterfaceType, *ast.StructType:
+			return false
+		}
+		return true
+	}
+	ast.Inspect(cond, visit)
+	return r
+}
+
+func hasVariable(vars []reader.Variable, name string) bool {
+	for _, v := range vars {
+		// variables captured by closures are named with a '&' prefix
+		if n, _ := v.Val(dwarf.AttrName).(string); n == name || n == "&"+name {
+			return true
+		}
+	}
+	return false
+}
+
+// hasGlobal returns true if pkgName.name is a package variable, function
+// or constant.
+func (bi *BinaryInfo) hasGlobal(pkgName, name string) bool {
+	fullNames := []string{pkgName + "." + name}
+	for _, pkgPath := range bi.PackageMap[pkgName] {
+		fullNames = append(fullNames, pkgPath+"."+name)
+	}
+	match := func(s string) bool {
+		for _, fullName := range fullNames {
+			if s == fullName || strings.HasSuffix(s, "/"+fullName) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, pkgvar := range bi.packageVars {
+		if match(pkgvar.name) {
+			return true
+		}
+	}
+	for _, fn := range bi.Functions {
+		if match(fn.Name) {
+			return true
+		}
+	}
+	for _, ctyp := range bi.consts {
+		for _, cval := range ctyp.values {
+			if match(cval.fullName) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // NoBreakpointError is returned when trying to
 // clear a breakpoint that does not exist.
 type NoBreakpointError struct {
@@ -367,10 +934,38 @@ func (nbp NoBreakpointError) Error() string {
 type BreakpointMap struct {
 	M map[uint64]*Breakpoint
 
+	// logicalHits contains the hit counts of each logical breakpoint, they
+	// are shared by all its physical breakpoints.
+	logicalHits map[int]*logicalHitCounts
+
 	breakpointIDCounter         int
 	internalBreakpointIDCounter int
 }
 
+// logicalHitCounts counts the hits of all the physical breakpoints
+// belonging to a logical breakpoint.
+type logicalHitCounts struct {
+	total uint64
+	perG  map[int]uint64
+}
+
+// logicalHits returns the hit counts of the logical breakpoint that owns
+// bp.
+func (bp *Breakpoint) logicalHits() *logicalHitCounts {
+	if bp.bpmap == nil {
+		return &logicalHitCounts{perG: map[int]uint64{}}
+	}
+	if bp.bpmap.logicalHits == nil {
+		bp.bpmap.logicalHits = make(map[int]*logicalHitCounts)
+	}
+	hits := bp.bpmap.logicalHits[bp.LogicalID]
+	if hits == nil {
+		hits = &logicalHitCounts{perG: map[int]uint64{}}
+		bp.bpmap.logicalHits[bp.LogicalID] = hits
+	}
+	return hits
+}
+
 // NewBreakpointMap creates a new BreakpointMap.
 func NewBreakpointMap() BreakpointMap {
 	return BreakpointMap{
@@ -378,15 +973,41 @@ func NewBreakpointMap() BreakpointMap {
 	}
 }
 
+// clearLogicalHits deletes the hit counts of the logical breakpoint with
+// the specified ID if it has no physical breakpoints left.
+func (bpmap *BreakpointMap) clearLogicalHits(logicalID int) {
+	for _, bp := range bpmap.M {
+		if bp.IsUser() && bp.LogicalID == logicalID {
+			return
+		}
+	}
+	delete(bpmap.logicalHits, logicalID)
+}
+
 // SetBreakpoint sets a breakpoint at addr, and stores it in the process wide
 // break point table.
 func (t *Target) SetBreakpoint(addr uint64, kind BreakpointKind, cond ast.Expr) (*Breakpoint, error) {
 	return t.setBreakpointInternal(addr, kind, 0, cond)
 }
 
+// SetHardwareBreakpoint sets a breakpoint at addr using a hardware
+// execute breakpoint instead of writing a breakpoint instruction in the
+// code of the target. Hardware breakpoints share the hardware slots used
+// by watchpoints.
+func (t *Target) SetHardwareBreakpoint(addr uint64, kind BreakpointKind, cond ast.Expr) (*Breakpoint, error) {
+	return t.setBreakpointInternal(addr, kind, WatchExecute, cond)
+}
+
 // SetWatchpoint sets a data breakpoint at addr and stores it in the
 // process wide break point table.
 func (t *Target) SetWatchpoint(scope *EvalScope, expr string, wtype WatchType, cond ast.Expr) (*Breakpoint, error) {
+	return t.setWatchpoint(scope, expr, wtype, cond, 0)
+}
+
+// setWatchpoint is like SetWatchpoint, extraSlots is the number of hardware
+// slots that the caller will use for internal breakpoints after the
+// watchpoint is set.
+func (t *Target) setWatchpoint(scope *EvalScope, expr string, wtype WatchType, cond ast.Expr, extraSlots int) (*Breakpoint, error) {
 	if (wtype&WatchWrite == 0) && (wtype&WatchRead == 0) {
 		return nil, errors.New("at least one of read and write must be set for watchpoint")
 	}
@@ -408,141 +1029,1274 @@ func (t *Target) SetWatchpoint(scope *EvalScope, expr string, wtype WatchType, c
 	if xv.Kind == reflect.UnsafePointer || xv.Kind == reflect.Invalid {
 		return nil, fmt.Errorf("can not watch variable of type %s", xv.Kind.String())
 	}
-	sz := xv.DwarfType.Size()
-	if sz <= 0 || sz > int64(t.BinInfo().Arch.PtrSize()) {
-		//TODO(aarzilli): it is reasonable to expect to be able to watch string
-		//and interface variables and we could support it by watching certain
-		//member fields here.
-		return nil, fmt.Errorf("can not watch variable of type %s", xv.DwarfType.String())
-	}
-	if xv.Addr >= scope.g.stack.lo && xv.Addr < scope.g.stack.hi {
-		//TODO(aarzilli): support watching stack variables
-		return nil, errors.New("can not watch stack allocated variable")
+	stackWatch := scope.g != nil && xv.Addr >= scope.g.stack.lo && xv.Addr < scope.g.stack.hi
+
+	// Interfaces are watched through their dynamic value, if it is stored
+	// outside of the interface.
+	watchv := xv
+	var ifaceData *Variable
+	if xv.Kind == reflect.Interface {
+		if data, dataptr := interfaceDynamicValue(xv); data != nil {
+			watchv, ifaceData = data, dataptr
+		}
 	}
 
-	bp, err := t.setBreakpointInternal(xv.Addr, UserBreakpoint, wtype.withSize(uint8(sz)), cond)
-	if bp != nil {
-		bp.WatchExpr = expr
+	if ifaceData != nil {
+		// one more slot for the WatchRearmBreakpoint on the data pointer
+		extraSlots++
 	}
-	return bp, err
-}
-
-func (t *Target) setBreakpointInternal(addr uint64, kind BreakpointKind, wtype WatchType, cond ast.Expr) (*Breakpoint, error) {
-	if valid, err := t.Valid(); !valid {
+	bp, err := t.setWatchpointComponents(watchv, expr, wtype, cond, extraSlots)
+	if err != nil {
 		return nil, err
 	}
-	bpmap := t.Breakpoints()
-	newBreaklet := &Breaklet{Kind: kind, Cond: cond}
-	if kind == UserBreakpoint {
-		newBreaklet.HitCount = map[int]uint64{}
-	}
-	if bp, ok := bpmap.M[addr]; ok {
-		if !bp.canOverlap(kind) {
-			return bp, BreakpointExistsError{bp.File, bp.Line, bp.Addr}
+	if ifaceData != nil {
+		if err := t.setWatchRearmBreakpoint(bp, xv.DwarfType, ifaceData.Addr); err != nil {
+			t.clearWatchpoint(bp)
+			return nil, err
 		}
-		bp.Breaklets = append(bp.Breaklets, newBreaklet)
-		return bp, nil
 	}
-
-	f, l, fn := t.BinInfo().PCToLine(uint64(addr))
-
-	fnName := ""
-	if fn != nil {
-		fnName = fn.Name
+	pkg := scope.pkg
+	if scope.Fn != nil {
+		pkg = scope.Fn.PackageName()
 	}
-
-	hwidx := uint8(0)
-	if wtype != 0 {
-		m := make(map[uint8]bool)
-		for _, bp := range bpmap.M {
-			if bp.WatchType != 0 {
-				m[bp.HWBreakIndex] = true
+	if !stackWatch {
+		for _, other := range t.Breakpoints().M {
+			if other.WatchType != 0 && other.LogicalID == bp.LogicalID {
+				other.WatchPackage = pkg
 			}
 		}
-		for hwidx = 0; true; hwidx++ {
-			if !m[hwidx] {
-				break
-			}
+	}
+	if wtype.Rewatch() {
+		// The watchpoint is suspended, instead of cleared, when the expression
+		// goes out of scope.
+		bp.rewatch = &rewatchScope{goid: -1, frameOffset: scope.frameOffset, pkg: pkg}
+		if scope.g != nil {
+			bp.rewatch.goid = scope.g.ID
 		}
+		return bp, nil
 	}
-
-	newBreakpoint := &Breakpoint{
-		FunctionName: fnName,
-		WatchType:    wtype,
-		HWBreakIndex: hwidx,
-		File:         f,
-		Line:         l,
-		Addr:         addr,
+	if !stackWatch {
+		return bp, nil
 	}
-
-	err := t.proc.WriteBreakpoint(newBreakpoint)
-	if err != nil {
+	if err := t.setStackWatchBreakpoints(scope, bp); err != nil {
+		t.clearWatchpoint(bp)
 		return nil, err
 	}
-
-	if kind != UserBreakpoint {
-		bpmap.internalBreakpointIDCounter++
-		newBreakpoint.LogicalID = bpmap.internalBreakpointIDCounter
-	} else {
-		bpmap.breakpointIDCounter++
-		newBreakpoint.LogicalID = bpmap.breakpointIDCounter
-	}
-
-	newBreakpoint.Breaklets = append(newBreakpoint.Breaklets, newBreaklet)
-
-	bpmap.M[addr] = newBreakpoint
-
-	return newBreakpoint, nil
+	return bp, nil
 }
 
-// SetBreakpointWithID creates a breakpoint at addr, with the specified logical ID.
-func (t *Target) SetBreakpointWithID(id int, addr uint64) (*Breakpoint, error) {
-	bpmap := t.Breakpoints()
-	bp, err := t.SetBreakpoint(addr, UserBreakpoint, nil)
-	if err == nil {
-		bp.LogicalID = id
-		bpmap.breakpointIDCounter--
+// SetWatchpointAddr sets a data breakpoint on the size bytes of memory
+// starting at addr. Unlike SetWatchpoint the watched memory does not need
+// to be the value of an expression, the WatchExpr of the watchpoint is the
+// address itself.
+func (t *Target) SetWatchpointAddr(addr uint64, size int, wtype WatchType, cond ast.Expr) (*Breakpoint, error) {
+	if (wtype&WatchWrite == 0) && (wtype&WatchRead == 0) {
+		return nil, errors.New("at least one of read and write must be set for watchpoint")
 	}
-	return bp, err
-}
-
-// canOverlap returns true if a breakpoint of kind can be overlapped to the
-// already existing breaklets in bp.
-// At most one user breakpoint can be set but multiple internal breakpoints are allowed.
-// All other internal breakpoints are allowed to overlap freely.
-func (bp *Breakpoint) canOverlap(kind BreakpointKind) bool {
-	if kind == UserBreakpoint {
-		return !bp.IsUser()
+	if wtype.Rewatch() {
+		return nil, errors.New("can not evaluate again a watchpoint on an address")
 	}
-	return true
+	if ptrSize := t.BinInfo().Arch.PtrSize(); size <= 0 || size > ptrSize || size&(size-1) != 0 {
+		return nil, fmt.Errorf("invalid watchpoint size %d: must be a power of two no larger than %d", size, ptrSize)
+	}
+	if addr%uint64(size) != 0 {
+		// hardware watchpoints can only watch regions aligned to their size
+		return nil, fmt.Errorf("invalid watchpoint address %#x: must be aligned to the watchpoint size %d", addr, size)
+	}
+	bp, err := t.setBreakpointInternal(addr, UserBreakpoint, wtype.withSize(uint8(size)), cond)
+	if err != nil {
+		return nil, err
+	}
+	bp.WatchExpr = fmt.Sprintf("%#x", addr)
+	bp.WatchRaw = true
+	return bp, nil
 }
 
-// ClearBreakpoint clears the breakpoint at addr.
-func (t *Target) ClearBreakpoint(addr uint64) (*Breakpoint, error) {
-	if valid, err := t.Valid(); !valid {
+// setWatchpointComponents sets the physical watchpoints needed to watch
+// the value of xv and returns the first one. The extraSlots hardware slots
+// needed by the caller are counted when checking that enough slots are
+// available.
+func (t *Target) setWatchpointComponents(xv *Variable, expr string, wtype WatchType, cond ast.Expr, extraSlots int) (*Breakpoint, error) {
+	components, err := watchComponents(xv, int64(t.BinInfo().Arch.PtrSize()))
+	if err != nil {
 		return nil, err
 	}
-	bp, ok := t.Breakpoints().M[addr]
-	if !ok {
-		return nil, NoBreakpointError{Addr: addr}
+
+	// If there aren't enough hardware slots for all the components, fall back
+	// to software watchpoints for all of them instead of watching only part
+	// of the value in hardware.
+	if slots := t.WatchpointSlots(); !wtype.Software() && slots.Total > 0 {
+		if needed, avail := len(components)+extraSlots, slots.Total-slots.Used; avail < needed {
+			t.BinInfo().logger.Debugf("not enough hardware watchpoint slots to watch %q (%d needed, %d available), using a software watchpoint", expr, needed, avail)
+			wtype |= WatchSoftware
+		}
 	}
 
-	for i := range bp.Breaklets {
-		if bp.Breaklets[i].Kind == UserBreakpoint {
-			bp.Breaklets[i] = nil
+	// Regions larger than a single hardware watchpoint are watched by setting
+	// one physical watchpoint for each component, all belonging to the same
+	// logical breakpoint.
+	bpmap := t.Breakpoints()
+	bps := make([]*Breakpoint, 0, len(components))
+	for i, c := range components {
+		bp, err := t.setBreakpointInternal(xv.Addr+uint64(c.off), UserBreakpoint, wtype.withSize(uint8(c.size)), cond)
+		if err != nil {
+			for _, bp := range bps {
+				t.ClearBreakpoint(bp.Addr)
+			}
+			return nil, err
+		}
+		if i > 0 {
+			bp.LogicalID = bps[0].LogicalID
+			bpmap.breakpointIDCounter--
 		}
+		bp.WatchExpr = expr
+		bp.WatchField = c.field
+		bps = append(bps, bp)
 	}
+	bps[0].watchDwarfType = xv.DwarfType
+	return bps[0], nil
+}
 
-	_, err := t.finishClearBreakpoint(bp)
-	if err != nil {
-		return nil, err
+// watchComponents returns the components of the memory that must be
+// watched to watch the value of xv.
+func watchComponents(xv *Variable, ptrSize int64) ([]watchComponent, error) {
+	switch xv.Kind {
+	case reflect.String:
+		// watch the data pointer and the length of the string header
+		return []watchComponent{{0, ptrSize, "ptr"}, {ptrSize, ptrSize, "len"}}, nil
+	case reflect.Slice:
+		// watch the data pointer, length and capacity of the slice header
+		return []watchComponent{{0, ptrSize, "ptr"}, {ptrSize, ptrSize, "len"}, {2 * ptrSize, ptrSize, "cap"}}, nil
 	}
-	return bp, nil
+	sz := xv.DwarfType.Size()
+	if sz <= 0 {
+		return nil, fmt.Errorf("can not watch variable of type %s", xv.DwarfType.String())
+	}
+	if sz <= ptrSize {
+		return []watchComponent{{0, sz, ""}}, nil
+	}
+	return splitWatchRegion(xv.Addr, sz, ptrSize), nil
+}
+
+// maxStackWatchDepth is the maximum depth of the frame owning a watched
+// stack variable.
+const maxStackWatchDepth = 1000
+
+// setStackWatchBreakpoints sets a WatchOutOfScopeBreakpoint on the return
+// address of the frame of scope, so that the watchpoint is cleared when
+// the variable it watches goes out of scope.
+// WatchOutOfScopeBreakpoints are also set on the calls to
+// runtime.deferreturn of the frames enclosing the frame of scope, where
+// execution resumes when one of them recovers a panic that unwound the
+// frame of the variable.
+// On recorded targets a second WatchOutOfScopeBreakpoint is set on the
+// CALL instruction that created the frame, to detect the variable going
+// out of scope while executing backward.
+// Finally StackResizeBreakpoints are set to move the watchpoint when the
+// runtime moves the stack of the goroutine.
+func (t *Target) setStackWatchBreakpoints(scope *EvalScope, watchpoint *Breakpoint) error {
+	frames, err := scope.g.Stacktrace(maxStackWatchDepth, 0)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(frames)-1; i++ {
+		if frames[i].FrameOffset() != scope.frameOffset {
+			continue
+		}
+		retframe := &frames[i+1]
+		sameGCond := sameGoroutineCondition(scope.g)
+		cond := astutil.And(sameGCond, frameoffCondition(retframe))
+		bp, err := t.SetBreakpoint(retframe.Current.PC, WatchOutOfScopeBreakpoint, cond)
+		if err != nil {
+			return err
+		}
+		bp.Breaklets[len(bp.Breaklets)-1].watchpoint = watchpoint
+
+		if err := t.setWatchPanicBreakpoints(frames[i+1:], sameGCond, watchpoint); err != nil {
+			return err
+		}
+		if err := t.setStackResizeBreakpoints(scope.g, watchpoint); err != nil {
+			return err
+		}
+
+		if recorded, _ := t.Recorded(); !recorded || retframe.Current.Fn == nil {
+			return nil
+		}
+		callpc, err := findCallInstrForRet(t, t.Memory(), frames[i].Ret, retframe.Current.Fn)
+		if err != nil {
+			return err
+		}
+		bp, err = t.SetBreakpoint(callpc, WatchOutOfScopeBreakpoint, cond)
+		if err != nil {
+			return err
+		}
+		bp.Breaklets[len(bp.Breaklets)-1].watchpoint = watchpoint
+		bp.Breaklets[len(bp.Breaklets)-1].reverse = true
+		return nil
+	}
+	return errors.New("could not find the return address of the frame of the watched variable")
+}
+
+// setWatchPanicBreakpoints sets a WatchOutOfScopeBreakpoint on every call
+// to runtime.deferreturn in the functions of frames, with a condition
+// matching the frame. When a deferred function recovers a panic execution
+// resumes at one of those calls, in the frame that deferred it, after all
+// the frames it encloses, including the frame of the watched variable,
+// have been unwound.
+func (t *Target) setWatchPanicBreakpoints(frames []Stackframe, sameGCond ast.Expr, watchpoint *Breakpoint) error {
+	deferreturns := make(map[*Function][]uint64)
+	for i := range frames {
+		fn := frames[i].Current.Fn
+		if fn == nil || frames[i].SystemStack {
+			continue
+		}
+		pcs, ok := deferreturns[fn]
+		if !ok {
+			text, err := disassemble(t.Memory(), nil, t.Breakpoints(), t.BinInfo(), fn.Entry, fn.End, false)
+			if err != nil {
+				return err
+			}
+			pcs = FindDeferReturnCalls(text)
+			deferreturns[fn] = pcs
+		}
+		for _, pc := range pcs {
+			bp, err := t.SetBreakpoint(pc, WatchOutOfScopeBreakpoint, astutil.And(sameGCond, frameoffCondition(&frames[i])))
+			if err != nil {
+				return err
+			}
+			bp.Breaklets[len(bp.Breaklets)-1].watchpoint = watchpoint
+		}
+	}
+	return nil
+}
+
+// setStackResizeBreakpoints sets a StackResizeBreakpoint on the return
+// instructions of runtime.copystack. When the stack of g is moved the
+// watchpoint is moved to the same offset from the top of the new stack.
+// Stack moves are not followed while executing backward.
+func (t *Target) setStackResizeBreakpoints(g *G, watchpoint *Breakpoint) error {
+	fn := t.BinInfo().LookupFunc["runtime.copystack"]
+	if fn == nil {
+		return nil
+	}
+	text, err := disassemble(t.Memory(), nil, t.Breakpoints(), t.BinInfo(), fn.Entry, fn.End, false)
+	if err != nil {
+		return err
+	}
+	goid, stackhi := g.ID, g.stack.hi
+	callback := func(th Thread) (bool, error) {
+		if t.GetDirection() == Backward {
+			return false, nil
+		}
+		g, err := FindGoroutine(t, goid)
+		if err != nil || g == nil || g.stack.hi == stackhi {
+			return false, nil
+		}
+		addr := watchpoint.Addr - stackhi + g.stack.hi
+		if err := t.moveWatchpoint(watchpoint, addr); err != nil {
+			t.BinInfo().logger.Errorf("could not move watchpoint %q to the new stack of goroutine %d: %v", watchpoint.WatchExpr, goid, err)
+			return false, nil
+		}
+		stackhi = g.stack.hi
+		return false, nil
+	}
+	for _, instr := range text {
+		if !instr.IsRet() {
+			continue
+		}
+		bp, err := t.SetBreakpoint(instr.Loc.PC, StackResizeBreakpoint, nil)
+		if err != nil {
+			return err
+		}
+		breaklet := bp.Breaklets[len(bp.Breaklets)-1]
+		breaklet.watchpoint = watchpoint
+		breaklet.callback = callback
+	}
+	return nil
+}
+
+// interfaceDynamicValue returns the dynamic value of the interface xv and
+// the data pointer of xv, pointing to it. Returns nil if the interface is
+// nil or if its dynamic value is stored directly in the data pointer.
+func interfaceDynamicValue(xv *Variable) (*Variable, *Variable) {
+	_type, dataptr, isnil := xv.readInterface()
+	if isnil || dataptr == nil || xv.Unreadable != nil {
+		return nil, nil
+	}
+	typ, kind, err := runtimeTypeToDIE(_type, dataptr.Addr)
+	if err != nil || kind&kindDirectIface != 0 {
+		return nil, nil
+	}
+	if _, isptr := resolveTypedef(typ).(*godwarf.PtrType); isptr {
+		return nil, nil
+	}
+	data := dataptr.newVariable("data", dataptr.Addr, pointerTo(typ, xv.bi.Arch), dataptr.mem).maybeDereference()
+	if data.Addr == 0 || data.Unreadable != nil {
+		return nil, nil
+	}
+	return data, dataptr
+}
+
+// setWatchRearmBreakpoint sets a WatchRearmBreakpoint on the data pointer
+// of a watched interface, at dataptr, so that the watchpoint follows the
+// dynamic value of the interface when it is reassigned.
+func (t *Target) setWatchRearmBreakpoint(watchpoint *Breakpoint, ifaceType godwarf.Type, dataptr uint64) error {
+	ptrSize := t.BinInfo().Arch.PtrSize()
+	bp, err := t.setBreakpointInternal(dataptr, WatchRearmBreakpoint, WatchWrite.withSize(uint8(ptrSize)), nil)
+	if err != nil {
+		return err
+	}
+	bp.Breaklets[len(bp.Breaklets)-1].watchpoint = watchpoint
+	watchpoint.watchIfaceType = ifaceType
+	return nil
+}
+
+// rearmWatchpoints moves the watchpoints on interfaces whose data pointer
+// was changed by any of threads to the new dynamic value of the interface,
+// and the watchpoints on slice elements whose slice header was changed to
+// the new backing array of the slice.
+// Returns the first thread that moved a watchpoint created with the notify
+// option of SetSliceWatchpoint, or nil.
+func (t *Target) rearmWatchpoints(threads []Thread) Thread {
+	var r Thread
+	for _, th := range threads {
+		bpstate := th.Breakpoint()
+		for _, wp := range bpstate.WatchRearm {
+			if wp.watchSlice != nil {
+				m, err := t.migrateSliceWatchpoint(wp)
+				if err != nil {
+					t.BinInfo().logger.Errorf("could not migrate watchpoint %q: %v", wp.WatchExpr, err)
+				}
+				if m != nil {
+					t.watchMigrations = append(t.watchMigrations, *m)
+					if wp.watchSlice.notify && r == nil {
+						r = th
+					}
+				}
+				continue
+			}
+			if err := t.rearmWatchpoint(wp, bpstate.Breakpoint.Addr); err != nil {
+				t.BinInfo().logger.Errorf("could not re-arm watchpoint %q: %v", wp.WatchExpr, err)
+			}
+		}
+		bpstate.WatchRearm = nil
+	}
+	return r
+}
+
+// rearmWatchpoint moves the physical breakpoints of wp to the dynamic value
+// of the interface whose data pointer is at dataptr. If the interface is nil
+// or its dynamic value is stored directly in the data pointer, for example
+// because it is a pointer, the watchpoint is suspended until the interface
+// is assigned a value stored outside of it.
+func (t *Target) rearmWatchpoint(wp *Breakpoint, dataptr uint64) error {
+	bi := t.BinInfo()
+	iface := newVariable("", dataptr-uint64(bi.Arch.PtrSize()), wp.watchIfaceType, bi, t.Memory())
+	data, _ := interfaceDynamicValue(iface)
+	if data == nil {
+		return t.setWatchSuspended(wp, true)
+	}
+	if err := t.retargetWatchpoint(wp, data); err != nil {
+		return err
+	}
+	return t.setWatchSuspended(wp, false)
+}
+
+// retargetWatchpoint moves the watchpoint wp to the value of xv. If the
+// type of xv needs a different set of physical breakpoints, for example
+// because the dynamic type of a watched interface changed, the watched
+// region is resized.
+func (t *Target) retargetWatchpoint(wp *Breakpoint, xv *Variable) error {
+	components, err := watchComponents(xv, int64(t.BinInfo().Arch.PtrSize()))
+	if err != nil {
+		return err
+	}
+	bps := t.watchpointComponents(wp)
+	same := len(bps) == len(components)
+	for i := 0; same && i < len(bps); i++ {
+		same = int64(bps[i].Addr-wp.Addr) == components[i].off && int64(bps[i].WatchType.Size()) == components[i].size
+	}
+	if !same {
+		if err := t.resizeWatchpoint(wp, xv.Addr, components); err != nil {
+			return err
+		}
+	} else if xv.Addr != wp.Addr {
+		if err := t.moveWatchpoint(wp, xv.Addr); err != nil {
+			return err
+		}
+	}
+	wp.watchDwarfType = xv.DwarfType
+	return nil
+}
+
+// watchpointComponents returns the physical breakpoints of the watchpoint
+// wp, sorted by address.
+func (t *Target) watchpointComponents(wp *Breakpoint) []*Breakpoint {
+	var bps []*Breakpoint
+	for _, bp := range t.Breakpoints().M {
+		if bp.WatchType != 0 && bp.LogicalID == wp.LogicalID && bp.IsUser() {
+			bps = append(bps, bp)
+		}
+	}
+	sort.Slice(bps, func(i, j int) bool { return bps[i].Addr < bps[j].Addr })
+	return bps
+}
+
+// moveWatchpoint moves all physical breakpoints of the watchpoint wp so
+// that the first one is at addr.
+// The memory at the new address is read before the watchpoint is changed,
+// if any step fails the physical breakpoints are restored at their old
+// addresses.
+func (t *Target) moveWatchpoint(wp *Breakpoint, addr uint64) error {
+	delta := addr - wp.Addr
+	bpmap := t.Breakpoints()
+	bps := t.watchpointComponents(wp)
+	own := make(map[*Breakpoint]bool)
+	for _, bp := range bps {
+		own[bp] = true
+	}
+	for _, bp := range bps {
+		if other, exists := bpmap.M[bp.Addr+delta]; exists && !own[other] {
+			return fmt.Errorf("address %#x is already watched", bp.Addr+delta)
+		}
+	}
+	data := make([][]byte, len(bps))
+	for i, bp := range bps {
+		data[i] = make([]byte, len(bp.watchData))
+		if _, err := t.Memory().ReadMemory(data[i], bp.Addr+delta); err != nil {
+			return fmt.Errorf("could not read watched memory at %#x: %v", bp.Addr+delta, err)
+		}
+	}
+
+	oldAddrs := make([]uint64, len(bps))
+	for i, bp := range bps {
+		oldAddrs[i] = bp.Addr
+	}
+	armed := func(bp *Breakpoint) bool { return !bp.WatchType.Software() && !bp.WatchSuspended }
+	var erased, written []*Breakpoint
+	// restore puts the physical breakpoints back at their old addresses.
+	restore := func(err error) error {
+		for _, bp := range written {
+			t.proc.EraseBreakpoint(bp)
+		}
+		for i, bp := range bps {
+			if bpmap.M[bp.Addr] == bp {
+				delete(bpmap.M, bp.Addr)
+			}
+			bp.Addr = oldAddrs[i]
+		}
+		for _, bp := range bps {
+			bpmap.M[bp.Addr] = bp
+		}
+		for _, bp := range erased {
+			t.proc.WriteBreakpoint(bp)
+		}
+		return err
+	}
+
+	for _, bp := range bps {
+		if armed(bp) {
+			if err := t.proc.EraseBreakpoint(bp); err != nil {
+				return restore(err)
+			}
+			erased = append(erased, bp)
+		}
+	}
+	for _, bp := range bps {
+		delete(bpmap.M, bp.Addr)
+	}
+	for _, bp := range bps {
+		bp.Addr += delta
+		bpmap.M[bp.Addr] = bp
+	}
+	for _, bp := range bps {
+		if armed(bp) {
+			if err := t.proc.WriteBreakpoint(bp); err != nil {
+				return restore(err)
+			}
+			written = append(written, bp)
+		}
+	}
+	for i, bp := range bps {
+		copy(bp.watchData, data[i])
+	}
+	return nil
+}
+
+// resizeWatchpoint replaces the physical breakpoints of wp with one physical
+// breakpoint for each of components, relative to addr. The first physical
+// breakpoint, wp itself, is reused so that the breakpoints referring to it
+// remain valid.
+func (t *Target) resizeWatchpoint(wp *Breakpoint, addr uint64, components []watchComponent) error {
+	bpmap := t.Breakpoints()
+	bps := t.watchpointComponents(wp)
+	own := make(map[*Breakpoint]bool)
+	for _, bp := range bps {
+		own[bp] = true
+	}
+	if slots := t.WatchpointSlots(); !wp.WatchType.Software() && slots.Total > 0 {
+		// wp keeps its slot, the slots of the other physical breakpoints are
+		// released.
+		if avail := slots.Total - slots.Used + len(bps) - 1; avail < len(components)-1 {
+			return fmt.Errorf("not enough hardware watchpoint slots to watch %q: %d needed, %d available", wp.WatchExpr, len(components)-1, avail)
+		}
+	}
+	data := make([][]byte, len(components))
+	for i, c := range components {
+		if other, exists := bpmap.M[addr+uint64(c.off)]; exists && !own[other] {
+			return fmt.Errorf("address %#x is already watched", addr+uint64(c.off))
+		}
+		data[i] = make([]byte, c.size)
+		if _, err := t.Memory().ReadMemory(data[i], addr+uint64(c.off)); err != nil {
+			return fmt.Errorf("could not read watched memory at %#x: %v", addr+uint64(c.off), err)
+		}
+	}
+
+	for _, bp := range bps {
+		if !bp.WatchType.Software() && !bp.WatchSuspended {
+			if err := t.proc.EraseBreakpoint(bp); err != nil {
+				return err
+			}
+		}
+		delete(bpmap.M, bp.Addr)
+	}
+
+	var cond ast.Expr
+	if breaklet := wp.UserBreaklet(); breaklet != nil {
+		cond = breaklet.Cond
+	}
+	wp.Addr = addr
+	wp.WatchType = wp.WatchType.withSize(uint8(components[0].size))
+	wp.WatchField = components[0].field
+	wp.watchData = data[0]
+	if !wp.WatchType.Software() && !wp.WatchSuspended {
+		if err := t.proc.WriteBreakpoint(wp); err != nil {
+			return err
+		}
+	}
+	bpmap.M[wp.Addr] = wp
+	for i, c := range components[1:] {
+		bp, err := t.setBreakpointInternal(addr+uint64(c.off), UserBreakpoint, wp.WatchType.withSize(uint8(c.size)), cond)
+		if err != nil {
+			return err
+		}
+		bp.LogicalID = wp.LogicalID
+		bpmap.breakpointIDCounter--
+		bp.WatchExpr = wp.WatchExpr
+		bp.WatchField = c.field
+		bp.WatchPackage = wp.WatchPackage
+		copy(bp.watchData, data[i+1])
+	}
+	// physical breakpoints created while wp is suspended must be suspended too
+	return t.setWatchSuspended(wp, wp.WatchSuspended)
+}
+
+// rewatchScope describes the scope where the expression of a watchpoint
+// created with WatchRewatch is evaluated.
+type rewatchScope struct {
+	goid        int // goroutine of the scope, -1 if the scope has no goroutine
+	frameOffset int64
+	pkg         string // package of the scope, used if the scope has no goroutine
+}
+
+// evalScope returns the scope described by rs. Scopes without a goroutine,
+// for example the scope used to set again watchpoints on package variables
+// after the target is restarted, are package scopes.
+func (rs *rewatchScope) evalScope(t *Target) (*EvalScope, error) {
+	if rs.goid < 0 {
+		return PackageScope(t, rs.pkg), nil
+	}
+	g, err := FindGoroutine(t, rs.goid)
+	if err != nil {
+		return nil, err
+	}
+	if g == nil {
+		return nil, fmt.Errorf("unknown goroutine %d", rs.goid)
+	}
+	frames, err := g.Stacktrace(maxStackWatchDepth, 0)
+	if err != nil {
+		return nil, err
+	}
+	for i := range frames {
+		if frames[i].FrameOffset() == rs.frameOffset {
+			return FrameToScope(t, t.BinInfo(), t.Memory(), g, frames[i:]...), nil
+		}
+	}
+	return nil, errors.New("frame not found")
+}
+
+// rewatchWatchpoints evaluates again the expressions of the watchpoints
+// created with WatchRewatch, moving them if the address of the expression
+// changed. Watchpoints whose expression can not be evaluated are marked as
+// suspended.
+func (t *Target) rewatchWatchpoints() {
+	if valid, _ := t.Valid(); !valid {
+		return
+	}
+	var wps []*Breakpoint
+	for _, bp := range t.Breakpoints().M {
+		if bp.rewatch != nil {
+			wps = append(wps, bp)
+		}
+	}
+	for _, wp := range wps {
+		err := t.rewatchWatchpoint(wp)
+		if err != nil {
+			t.BinInfo().logger.Debugf("watchpoint %q suspended: %v", wp.WatchExpr, err)
+		}
+		if err := t.setWatchSuspended(wp, err != nil); err != nil {
+			t.BinInfo().logger.Errorf("could not suspend or resume watchpoint %q: %v", wp.WatchExpr, err)
+		}
+	}
+}
+
+// rewatchWatchpoint evaluates again the expression of wp and moves it to
+// the new address of the expression.
+func (t *Target) rewatchWatchpoint(wp *Breakpoint) error {
+	scope, err := wp.rewatch.evalScope(t)
+	if err != nil {
+		return err
+	}
+	expr, err := parser.ParseExpr(wp.WatchExpr)
+	if err != nil {
+		return err
+	}
+	xv, err := scope.evalAST(expr)
+	if err != nil {
+		return err
+	}
+	if xv.Addr == 0 || xv.Flags&VariableFakeAddress != 0 || xv.Unreadable != nil {
+		return fmt.Errorf("can not watch %q", wp.WatchExpr)
+	}
+	if wp.watchIfaceType != nil {
+		data, dataptr := interfaceDynamicValue(xv)
+		if data == nil {
+			return errors.New("interface has no dynamic value")
+		}
+		xv = data
+		if err := t.moveWatchRearmBreakpoint(wp, dataptr.Addr); err != nil {
+			return err
+		}
+	}
+	return t.retargetWatchpoint(wp, xv)
+}
+
+// moveWatchRearmBreakpoint moves the WatchRearmBreakpoint of wp to dataptr.
+func (t *Target) moveWatchRearmBreakpoint(wp *Breakpoint, dataptr uint64) error {
+	for _, bp := range t.Breakpoints().M {
+		for i := range bp.Breaklets {
+			if bp.Breaklets[i].Kind != WatchRearmBreakpoint || bp.Breaklets[i].watchpoint != wp {
+				continue
+			}
+			if bp.Addr == dataptr {
+				return nil
+			}
+			bp.Breaklets[i] = nil
+			if _, err := t.finishClearBreakpoint(bp); err != nil {
+				return err
+			}
+			return t.setWatchRearmBreakpoint(wp, wp.watchIfaceType, dataptr)
+		}
+	}
+	return nil
+}
+
+// watchSlice describes the slice containing the element watched by a
+// watchpoint created with SetSliceWatchpoint.
+type watchSlice struct {
+	hdr    uint64 // address of the slice header
+	index  int64  // index of the watched element
+	off    uint64 // offset of the watched element in the backing array
+	notify bool   // stop the target when the watchpoint is moved
+}
+
+// SetSliceWatchpoint is like SetWatchpoint but expr must be an index
+// expression on a slice. The data pointer and length of the slice header
+// are also watched and when they change, for example because append
+// reallocated the backing array of the slice, the watchpoint is moved to
+// the element with the same index in the new backing array. If the index
+// is no longer in range the watchpoint is suspended until the slice grows
+// again.
+// Each move is recorded and reported by WatchMigrations, if notify is true
+// the target also stops, with StopWatchMigrated, when the watchpoint is
+// moved.
+func (t *Target) SetSliceWatchpoint(scope *EvalScope, expr string, wtype WatchType, cond ast.Expr, notify bool) (*Breakpoint, error) {
+	n, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	idxexpr, ok := n.(*ast.IndexExpr)
+	if !ok {
+		return nil, fmt.Errorf("%q is not an index expression", expr)
+	}
+	sv, err := scope.evalAST(idxexpr.X)
+	if err != nil {
+		return nil, err
+	}
+	if sv.Kind != reflect.Slice {
+		return nil, fmt.Errorf("%s is not a slice", exprToString(idxexpr.X))
+	}
+	if sv.Addr == 0 || sv.Flags&VariableFakeAddress != 0 {
+		return nil, fmt.Errorf("can not watch the header of %s", exprToString(idxexpr.X))
+	}
+	idxv, err := scope.evalAST(idxexpr.Index)
+	if err != nil {
+		return nil, err
+	}
+	idxv.loadValue(loadFullValue)
+	if idxv.Unreadable != nil {
+		return nil, idxv.Unreadable
+	}
+	if idxv.Value == nil || idxv.Value.Kind() != constant.Int {
+		return nil, fmt.Errorf("index %s is not an integer", exprToString(idxexpr.Index))
+	}
+	index, _ := constant.Int64Val(idxv.Value)
+
+	// two more slots for the WatchRearmBreakpoints on the slice header
+	bp, err := t.setWatchpoint(scope, expr, wtype, cond, 2)
+	if err != nil {
+		return nil, err
+	}
+	if bp.watchIfaceType != nil {
+		t.clearWatchpoint(bp)
+		return nil, errors.New("can not follow the dynamic value of an interface stored in a slice")
+	}
+	bp.watchSlice = &watchSlice{hdr: sv.Addr, index: index, off: bp.Addr - sv.Base, notify: notify}
+	ptrSize := uint64(t.BinInfo().Arch.PtrSize())
+	for _, addr := range []uint64{sv.Addr, sv.Addr + ptrSize} {
+		if err := t.setWatchRearmBreakpoint(bp, nil, addr); err != nil {
+			t.clearWatchpoint(bp)
+			return nil, err
+		}
+	}
+	return bp, nil
+}
+
+// WatchpointMigration describes a watchpoint created with
+// SetSliceWatchpoint that was moved, or suspended, because the slice
+// containing the watched element changed.
+type WatchpointMigration struct {
+	// Breakpoint is the first physical breakpoint of the watchpoint.
+	Breakpoint *Breakpoint
+	// OldAddr and NewAddr are the addresses of the watched element before
+	// and after the move, NewAddr is zero if the watchpoint was suspended.
+	OldAddr, NewAddr uint64
+	// Reason describes why the watchpoint was suspended.
+	Reason string
+}
+
+// WatchMigrations returns the watchpoints that were moved or suspended
+// during the last resume of the target.
+func (t *Target) WatchMigrations() []WatchpointMigration {
+	return t.watchMigrations
+}
+
+// migrateSliceWatchpoint moves wp to the element with the watched index in
+// the current backing array of its slice, or suspends it if the index is
+// out of range. Returns nil if the watchpoint did not change.
+func (t *Target) migrateSliceWatchpoint(wp *Breakpoint) (*WatchpointMigration, error) {
+	ptrSize := t.BinInfo().Arch.PtrSize()
+	buf := make([]byte, 2*ptrSize)
+	if _, err := t.Memory().ReadMemory(buf, wp.watchSlice.hdr); err != nil {
+		return nil, err
+	}
+	base := watchDataToUint(buf[:ptrSize])
+	length := int64(watchDataToUint(buf[ptrSize:]))
+
+	m := &WatchpointMigration{Breakpoint: wp, OldAddr: wp.Addr}
+	if base == 0 || wp.watchSlice.index >= length {
+		if wp.WatchSuspended {
+			return nil, nil
+		}
+		m.Reason = fmt.Sprintf("index %d out of range [0:%d]", wp.watchSlice.index, length)
+		if err := t.setWatchSuspended(wp, true); err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+	addr := base + wp.watchSlice.off
+	if addr == wp.Addr && !wp.WatchSuspended {
+		return nil, nil
+	}
+	if addr != wp.Addr {
+		if err := t.moveWatchpoint(wp, addr); err != nil {
+			return nil, err
+		}
+	}
+	if err := t.setWatchSuspended(wp, false); err != nil {
+		return nil, err
+	}
+	m.NewAddr = addr
+	return m, nil
+}
+
+// setWatchSuspended sets the WatchSuspended field of all physical
+// breakpoints of wp. The hardware watchpoints of a suspended watchpoint are
+// erased, so that it is not triggered by writes to memory it no longer
+// watches, and written again, after reading the current value of the
+// watched memory, when it is resumed.
+func (t *Target) setWatchSuspended(wp *Breakpoint, suspended bool) error {
+	var firstErr error
+	for _, bp := range t.Breakpoints().M {
+		if bp.WatchType == 0 || bp.LogicalID != wp.LogicalID || !bp.IsUser() || bp.WatchSuspended == suspended {
+			continue
+		}
+		var err error
+		if !suspended {
+			_, err = t.Memory().ReadMemory(bp.watchData, bp.Addr)
+		}
+		if err == nil && !bp.WatchType.Software() {
+			if suspended {
+				err = t.proc.EraseBreakpoint(bp)
+			} else {
+				err = t.proc.WriteBreakpoint(bp)
+			}
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		bp.WatchSuspended = suspended
+	}
+	return firstErr
+}
+
+// clearWatchpoint clears all physical breakpoints of the watchpoint wp.
+func (t *Target) clearWatchpoint(wp *Breakpoint) {
+	for _, bp := range t.Breakpoints().M {
+		if bp.WatchType != 0 && bp.LogicalID == wp.LogicalID {
+			t.ClearBreakpoint(bp.Addr)
+		}
+	}
+}
+
+// WatchpointOutOfScope describes a watchpoint on a stack variable that was
+// cleared because the variable went out of scope.
+type WatchpointOutOfScope struct {
+	// Breakpoint is the first physical breakpoint of the watchpoint.
+	Breakpoint *Breakpoint
+	// LastValue is the last value of the watched variable, as it was the
+	// last time the watchpoint was hit.
+	LastValue string
+	// Location is where the frame of the variable was destroyed, that is
+	// the return address in the caller of the function owning the variable.
+	Location Location
+}
+
+// WatchOutOfScope returns the watchpoints that went out of scope during
+// the last resume of the target.
+func (t *Target) WatchOutOfScope() []WatchpointOutOfScope {
+	return t.watchOutOfScope
+}
+
+// watchpointsOutOfScope clears the watchpoints that went out of scope on
+// any of threads, recording them in t.watchOutOfScope. Returns the first
+// thread where a watchpoint went out of scope or nil.
+// Only the WatchOutOfScopeBreakpoints that apply to the current direction
+// of execution are considered.
+func (t *Target) watchpointsOutOfScope(threads []Thread) Thread {
+	var r Thread
+	backward := t.GetDirection() == Backward
+	for _, th := range threads {
+		for _, wp := range th.Breakpoint().WatchOutOfScope {
+			if !th.Breakpoint().watchOutOfScopeApplies(wp, backward) {
+				continue
+			}
+			info := WatchpointOutOfScope{Breakpoint: wp}
+			if loc, err := th.Location(); err == nil {
+				info.Location = *loc
+			}
+			// The frame has already returned and its memory can be reused at
+			// any time, the value is rendered from the last snapshot of the
+			// watched memory. When executing backward the value was never
+			// seen in the frame.
+			if wp.watchDwarfType != nil && !backward {
+				v := newVariable(wp.WatchExpr, wp.Addr, wp.watchDwarfType, t.BinInfo(), t.watchSnapshot(wp))
+				v.loadValue(logpointLoadConfig)
+				var buf bytes.Buffer
+				formatValue(&buf, v, false)
+				info.LastValue = buf.String()
+			}
+			t.watchOutOfScope = append(t.watchOutOfScope, info)
+			t.clearWatchpoint(wp)
+			if r == nil {
+				r = th
+			}
+		}
+	}
+	return r
+}
+
+// watchSnapshot returns the memory of the variable watched by wp as it was
+// the last time the watchpoint was hit: the watched region is taken from
+// the watchData of the physical breakpoints of wp, everything else is read
+// from the target.
+func (t *Target) watchSnapshot(wp *Breakpoint) MemoryReadWriter {
+	data := make([]byte, wp.watchDwarfType.Size())
+	_, _ = t.Memory().ReadMemory(data, wp.Addr)
+	for _, bp := range t.Breakpoints().M {
+		if bp.WatchType == 0 || bp.LogicalID != wp.LogicalID || !bp.IsUser() || bp.Addr < wp.Addr {
+			continue
+		}
+		if off := bp.Addr - wp.Addr; off < uint64(len(data)) {
+			copy(data[off:], bp.watchData)
+		}
+	}
+	return &memCache{loaded: true, cacheAddr: wp.Addr, cache: data, mem: t.Memory()}
+}
+
+// watchOutOfScopeApplies returns true if one of the
+// WatchOutOfScopeBreakpoint breaklets of wp hit by bpstate applies to the
+// current direction of execution.
+func (bpstate *BreakpointState) watchOutOfScopeApplies(wp *Breakpoint, backward bool) bool {
+	for _, breaklet := range bpstate.Breaklets {
+		if breaklet != nil && breaklet.Kind == WatchOutOfScopeBreakpoint && breaklet.watchpoint == wp && breaklet.reverse == backward {
+			return true
+		}
+	}
+	return false
+}
+
+// watchComponent is a portion of a watched memory region that can be
+// covered by a single hardware watchpoint.
+type watchComponent struct {
+	off, size int64
+	field     string
+}
+
+// splitWatchRegion splits the memory region of size sz starting at addr
+// into aligned components of at most ptrSize bytes each.
+func splitWatchRegion(addr uint64, sz, ptrSize int64) []watchComponent {
+	r := []watchComponent{}
+	for off := int64(0); off < sz; {
+		n := ptrSize
+		for n > 1 && ((addr+uint64(off))%uint64(n) != 0 || off+n > sz) {
+			n /= 2
+		}
+		r = append(r, watchComponent{off, n, fmt.Sprintf("[%d:%d]", off, off+n)})
+		off += n
+	}
+	return r
+}
+
+// WatchpointSlots describes the hardware breakpoint slots that can be used
+// to implement watchpoints.
+type WatchpointSlots struct {
+	Total   int // number of hardware breakpoint slots of the architecture
+	Used    int // number of slots currently used by watchpoints
+	MaxSize int // maximum number of bytes that can be watched by a single slot
+}
+
+// WatchpointSlots returns the number of hardware breakpoint slots
+// available and in use.
+func (t *Target) WatchpointSlots() WatchpointSlots {
+	r := WatchpointSlots{Total: t.BinInfo().Arch.hwBreakpointSlots}
+	if r.Total > 0 {
+		r.MaxSize = t.BinInfo().Arch.PtrSize()
+	}
+	for _, bp := range t.Breakpoints().M {
+		if bp.WatchType != 0 && !bp.WatchType.Software() {
+			r.Used++
+		}
+	}
+	return r
+}
+
+// updateWatchValue reads the current value of the memory watched by bp and
+// records the old and new values in WatchOldValue and WatchNewValue.
+func (bp *Breakpoint) updateWatchValue(mem MemoryReadWriter) {
+	if len(bp.watchData) == 0 {
+		return
+	}
+	buf := make([]byte, len(bp.watchData))
+	if _, err := mem.ReadMemory(buf, bp.Addr); err != nil {
+		return
+	}
+	bp.WatchOldValue = watchDataToUint(bp.watchData)
+	bp.WatchNewValue = watchDataToUint(buf)
+	copy(bp.watchData, buf)
+}
+
+func watchDataToUint(buf []byte) uint64 {
+	var n uint64
+	for i := len(buf) - 1; i >= 0; i-- {
+		n = n<<8 | uint64(buf[i])
+	}
+	return n
+}
+
+func (t *Target) setBreakpointInternal(addr uint64, kind BreakpointKind, wtype WatchType, cond ast.Expr) (*Breakpoint, error) {
+	if valid, err := t.Valid(); !valid {
+		return nil, err
+	}
+	bpmap := t.Breakpoints()
+	newBreaklet := &Breaklet{Kind: kind, Cond: cond}
+	if kind == UserBreakpoint {
+		newBreaklet.HitCount = map[int]uint64{}
+	}
+	if bp, ok := bpmap.M[addr]; ok {
+		if !bp.canOverlap(kind) {
+			return bp, BreakpointExistsError{bp.File, bp.Line, bp.Addr}
+		}
+		bp.Breaklets = append(bp.Breaklets, newBreaklet)
+		return bp, nil
+	}
+
+	f, l, fn := t.BinInfo().PCToLine(uint64(addr))
+
+	fnName := ""
+	if fn != nil {
+		fnName = fn.Name
+	}
+
+	hwidx := uint8(0)
+	if wtype != 0 && !wtype.Software() {
+		var ok bool
+		hwidx, ok = t.freeHWBreakIndex()
+		if !ok {
+			if wtype.Execute() {
+				return nil, fmt.Errorf("can not set hardware breakpoint: all %d hardware breakpoint slots are in use", t.BinInfo().Arch.hwBreakpointSlots)
+			}
+			// All hardware slots are in use, fall back to a software watchpoint.
+			wtype |= WatchSoftware
+			hwidx = 0
+		}
+	}
+
+	if err := t.checkSoftwareWatch(wtype); err != nil {
+		return nil, err
+	}
+
+	newBreakpoint := &Breakpoint{
+		FunctionName: fnName,
+		WatchType:    wtype,
+		HWBreakIndex: hwidx,
+		File:         f,
+		Line:         l,
+		Addr:         addr,
+		bpmap:        bpmap,
+	}
+
+	if wtype != 0 && !wtype.Execute() {
+		newBreakpoint.watchData = make([]byte, wtype.Size())
+		if _, err := t.Memory().ReadMemory(newBreakpoint.watchData, addr); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	if !wtype.Software() {
+		err = t.proc.WriteBreakpoint(newBreakpoint)
+		switch {
+		case err == ErrHWBreakUnsupported && wtype.Execute():
+			err = errors.New("hardware breakpoints are not supported by this backend")
+		case err == ErrHWBreakUnsupported && wtype != 0:
+			// The backend can not set hardware watchpoints, fall back to a
+			// software watchpoint.
+			newBreakpoint.WatchType |= WatchSoftware
+			newBreakpoint.HWBreakIndex = 0
+			err = t.checkSoftwareWatch(newBreakpoint.WatchType)
+		case err != nil && wtype == 0:
+			// The breakpoint instruction could not be written, for example
+			// because the code is not writable, try using a hardware breakpoint.
+			err = t.writeHWExecuteBreakpoint(newBreakpoint, err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if kind != UserBreakpoint {
+		bpmap.internalBreakpointIDCounter++
+		newBreakpoint.LogicalID = bpmap.internalBreakpointIDCounter
+	} else {
+		bpmap.breakpointIDCounter++
+		newBreakpoint.LogicalID = bpmap.breakpointIDCounter
+	}
+
+	newBreakpoint.Breaklets = append(newBreakpoint.Breaklets, newBreaklet)
+
+	bpmap.M[addr] = newBreakpoint
+
+	return newBreakpoint, nil
+}
+
+// checkSoftwareWatch returns an error if a watchpoint of type wtype can not
+// be implemented in software on the target architecture: read accesses are
+// only detected on amd64, see continueOnceSoftwareWatch.
+func (t *Target) checkSoftwareWatch(wtype WatchType) error {
+	if arch := t.BinInfo().Arch.Name; wtype.Software() && wtype.Read() && arch != "amd64" {
+		return fmt.Errorf("software watchpoints can not detect reads on %s, a hardware watchpoint slot is needed to watch reads", arch)
+	}
+	return nil
+}
+
+// freeHWBreakIndex returns the first hardware breakpoint slot not used by
+// any breakpoint, returns false if all slots are in use.
+func (t *Target) freeHWBreakIndex() (uint8, bool) {
+	m := make(map[uint8]bool)
+	for _, bp := range t.Breakpoints().M {
+		if bp.WatchType != 0 && !bp.WatchType.Software() {
+			m[bp.HWBreakIndex] = true
+		}
+	}
+	for hwidx := uint8(0); int(hwidx) < t.BinInfo().Arch.hwBreakpointSlots; hwidx++ {
+		if !m[hwidx] {
+			return hwidx, true
+		}
+	}
+	return 0, false
+}
+
+// writeHWExecuteBreakpoint writes bp as a hardware execute breakpoint,
+// after writing it as a software breakpoint failed with swerr.
+func (t *Target) writeHWExecuteBreakpoint(bp *Breakpoint, swerr error) error {
+	hwidx, ok := t.freeHWBreakIndex()
+	if !ok {
+		if t.BinInfo().Arch.hwBreakpointSlots == 0 {
+			return swerr
+		}
+		return fmt.Errorf("%v (could not use a hardware breakpoint instead: all %d hardware breakpoint slots are in use)", swerr, t.BinInfo().Arch.hwBreakpointSlots)
+	}
+	bp.WatchType = WatchExecute
+	bp.HWBreakIndex = hwidx
+	if err := t.proc.WriteBreakpoint(bp); err != nil {
+		bp.WatchType = 0
+		bp.HWBreakIndex = 0
+		return swerr
+	}
+	return nil
+}
+
+// SetBreakpointWithID creates a breakpoint at addr, with the specified logical ID.
+func (t *Target) SetBreakpointWithID(id int, addr uint64) (*Breakpoint, error) {
+	return t.setBreakpointWithID(id, addr, 0)
+}
+
+// SetHardwareBreakpointWithID creates a hardware execute breakpoint at
+// addr, with the specified logical ID.
+func (t *Target) SetHardwareBreakpointWithID(id int, addr uint64) (*Breakpoint, error) {
+	return t.setBreakpointWithID(id, addr, WatchExecute)
+}
+
+// SetWatchpointWithID creates a watchpoint on expr, evaluated in scope,
+// with the specified logical ID.
+func (t *Target) SetWatchpointWithID(id int, scope *EvalScope, expr string, wtype WatchType, cond ast.Expr) (*Breakpoint, error) {
+	bpmap := t.Breakpoints()
+	bp, err := t.SetWatchpoint(scope, expr, wtype, cond)
+	if err != nil {
+		return nil, err
+	}
+	oldID := bp.LogicalID
+	for _, other := range bpmap.M {
+		if other.WatchType != 0 && other.LogicalID == oldID && other.IsUser() {
+			other.LogicalID = id
+		}
+	}
+	bpmap.breakpointIDCounter--
+	return bp, nil
+}
+
+func (t *Target) setBreakpointWithID(id int, addr uint64, wtype WatchType) (*Breakpoint, error) {
+	bpmap := t.Breakpoints()
+	bp, err := t.setBreakpointInternal(addr, UserBreakpoint, wtype, nil)
+	if err == nil {
+		bp.LogicalID = id
+		bpmap.breakpointIDCounter--
+	}
+	return bp, err
+}
+
+// canOverlap returns true if a breakpoint of kind can be overlapped to the
+// already existing breaklets in bp.
+// At most one user breakpoint can be set but multiple internal breakpoints are allowed.
+// All other internal breakpoints are allowed to overlap freely.
+func (bp *Breakpoint) canOverlap(kind BreakpointKind) bool {
+	if kind == UserBreakpoint {
+		return !bp.IsUser()
+	}
+	return true
+}
+
+// ClearBreakpoint clears the breakpoint at addr.
+func (t *Target) ClearBreakpoint(addr uint64) (*Breakpoint, error) {
+	if valid, err := t.Valid(); !valid {
+		return nil, err
+	}
+	bp, ok := t.Breakpoints().M[addr]
+	if !ok {
+		return nil, NoBreakpointError{Addr: addr}
+	}
+
+	for i := range bp.Breaklets {
+		if bp.Breaklets[i].Kind == UserBreakpoint {
+			bp.Breaklets[i] = nil
+		}
+	}
+
+	_, err := t.finishClearBreakpoint(bp)
+	if err != nil {
+		return nil, err
+	}
+	t.Breakpoints().clearLogicalHits(bp.LogicalID)
+
+	if bp.WatchType != 0 {
+		// clear the breakpoints detecting when the watched variable goes out
+		// of scope or when the watched interface is reassigned
+		for _, bp2 := range t.Breakpoints().M {
+			for i := range bp2.Breaklets {
+				if kind := bp2.Breaklets[i].Kind; (kind == WatchOutOfScopeBreakpoint || kind == WatchRearmBreakpoint || kind == StackResizeBreakpoint) && bp2.Breaklets[i].watchpoint == bp {
+					bp2.Breaklets[i] = nil
+				}
+			}
+			if _, err := t.finishClearBreakpoint(bp2); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return bp, nil
+}
+
+// ClearLogicalBreakpoint clears every physical breakpoint owned by the
+// logical breakpoint with the specified ID, including the internal
+// breakpoints used by watchpoints, and returns the physical breakpoints
+// that were cleared. If some of them can not be cleared the others are
+// cleared anyway and the first error is returned.
+func (t *Target) ClearLogicalBreakpoint(logicalID int) ([]*Breakpoint, error) {
+	if valid, err := t.Valid(); !valid {
+		return nil, err
+	}
+	var bps []*Breakpoint
+	for _, bp := range t.Breakpoints().M {
+		if bp.IsUser() && bp.LogicalID == logicalID {
+			bps = append(bps, bp)
+		}
+	}
+	if len(bps) == 0 {
+		return nil, fmt.Errorf("no breakpoint with ID %d", logicalID)
+	}
+	sort.Slice(bps, func(i, j int) bool { return bps[i].Addr < bps[j].Addr })
+
+	var cleared []*Breakpoint
+	var firstErr error
+	for _, bp := range bps {
+		if _, err := t.ClearBreakpoint(bp.Addr); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("address %#x: %v", bp.Addr, err)
+			}
+			continue
+		}
+		cleared = append(cleared, bp)
+	}
+	return cleared, firstErr
 }
 
 // ClearInternalBreakpoints removes all stepping breakpoints from the map,
 // calling clearBreakpoint on each one.
 func (t *Target) ClearSteppingBreakpoints() error {
+	t.stepSkipActive = false
 	bpmap := t.Breakpoints()
 	threads := t.ThreadList()
 	for _, bp := range bpmap.M {
@@ -580,8 +2334,10 @@ func (t *Target) finishClearBreakpoint(bp *Breakpoint) (bool, error) {
 	if len(bp.Breaklets) > 0 {
 		return false, nil
 	}
-	if err := t.proc.EraseBreakpoint(bp); err != nil {
-		return false, err
+	if !bp.WatchType.Software() && !bp.WatchSuspended {
+		if err := t.proc.EraseBreakpoint(bp); err != nil {
+			return false, err
+		}
 	}
 
 	delete(t.Breakpoints().M, bp.Addr)
@@ -602,7 +2358,17 @@ func (bpmap *BreakpointMap) HasSteppingBreakpoints() bool {
 // HasHWBreakpoints returns true if there are hardware breakpoints.
 func (bpmap *BreakpointMap) HasHWBreakpoints() bool {
 	for _, bp := range bpmap.M {
-		if bp.WatchType != 0 {
+		if bp.WatchType != 0 && !bp.WatchType.Software() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasSoftwareWatchpoints returns true if there are software watchpoints.
+func (bpmap *BreakpointMap) HasSoftwareWatchpoints() bool {
+	for _, bp := range bpmap.M {
+		if bp.WatchType.Software() {
 			return true
 		}
 	}
@@ -623,6 +2389,33 @@ type BreakpointState struct {
 	// CondError contains any error encountered while evaluating the
 	// breakpoint's condition.
 	CondError error
+	// LogMessage is the message produced by a logpoint, if any.
+	LogMessage *LogpointMessage
+	// WatchOutOfScope lists the watchpoints on stack variables that went
+	// out of scope.
+	WatchOutOfScope []*Breakpoint
+	// WatchRearm lists the watchpoints on interface values whose data
+	// pointer was changed and the watchpoints on slice elements whose slice
+	// header was changed.
+	WatchRearm []*Breakpoint
+	// SharedObjectsChanged is true if the dynamic linker changed the list
+	// of loaded shared objects.
+	SharedObjectsChanged bool
+	// PinnedGoroutineLeft is true if the goroutine pinned by ResumePinned
+	// left its thread.
+	PinnedGoroutineLeft bool
+	// SyscallReturned is true if the thread returned from a system call
+	// while the target was resumed by continueOnceSoftwareWatch.
+	SyscallReturned bool
+	// AutoCheckpoint is true if the hit of the breakpoint must create an
+	// automatic checkpoint, see Breaklet.AutoCheckpoint.
+	AutoCheckpoint bool
+	// MaxHitsReached is true if this hit of the breakpoint was the last one
+	// allowed by MaxHits, see SetMaxHitsCallback.
+	MaxHitsReached bool
+	// condCalls lists the breaklets whose condition contains function calls
+	// and has not been evaluated yet.
+	condCalls []*Breaklet
 }
 
 // Clear zeros the struct.
@@ -632,6 +2425,15 @@ func (bpstate *BreakpointState) Clear() {
 	bpstate.Stepping = false
 	bpstate.SteppingInto = false
 	bpstate.CondError = nil
+	bpstate.LogMessage = nil
+	bpstate.WatchOutOfScope = nil
+	bpstate.WatchRearm = nil
+	bpstate.SharedObjectsChanged = false
+	bpstate.PinnedGoroutineLeft = false
+	bpstate.SyscallReturned = false
+	bpstate.AutoCheckpoint = false
+	bpstate.MaxHitsReached = false
+	bpstate.condCalls = nil
 }
 
 func (bpstate *BreakpointState) String() string {
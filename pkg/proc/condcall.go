@@ -0,0 +1,179 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"reflect"
+	"sync/atomic"
+	"time"
+)
+
+// condCallTimeout is the maximum amount of time the function calls of a
+// breakpoint condition are allowed to run.
+const condCallTimeout = 5 * time.Second
+
+// condHasCalls returns true if cond contains a function call, or
+// something that looks like one (for example a type conversion).
+func condHasCalls(cond ast.Expr) bool {
+	found := false
+	ast.Inspect(cond, func(n ast.Node) bool {
+		if _, ok := n.(*ast.CallExpr); ok {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// suppressBreakpoints deactivates the breakpoints reached by threads.
+func suppressBreakpoints(threads []Thread) {
+	for _, th := range threads {
+		bpstate := th.Breakpoint()
+		bpstate.Active = false
+		bpstate.Stepping = false
+		bpstate.SteppingInto = false
+		bpstate.CondError = nil
+		bpstate.LogMessage = nil
+		bpstate.condCalls = nil
+	}
+}
+
+// evalConditionsWithCalls evaluates the breakpoint conditions containing
+// function calls that were deferred by checkCond while the target was
+// being stopped, see Breaklet.CondCalls.
+// The function calls are injected in the goroutine that reached the
+// breakpoint, which requires resuming the target: while they are executed
+// all other breakpoints, including the internal breakpoints set by
+// Next/Step/StepOut, are ignored.
+// Evaluation fails open, if the condition can not be evaluated, its
+// function calls fail or do not complete within condCallTimeout the
+// breakpoint is considered active and the error is recorded in its
+// CondError.
+// Conditions are only evaluated if a single thread stopped at a breakpoint,
+// the thread is returned if its breakpoint state was changed.
+func (dbp *Target) evalConditionsWithCalls(threads []Thread) Thread {
+	if dbp.condCallG != 0 {
+		return nil
+	}
+	var condthread Thread
+	concurrent := false
+	for _, th := range threads {
+		bpstate := th.Breakpoint()
+		if len(bpstate.condCalls) > 0 {
+			if condthread != nil {
+				concurrent = true
+			}
+			condthread = th
+		} else if bpstate.Active {
+			concurrent = true
+		}
+	}
+	if condthread == nil {
+		return nil
+	}
+
+	if concurrent {
+		// Resuming the target would make the other threads lose their
+		// breakpoint.
+		for _, th := range threads {
+			bpstate := th.Breakpoint()
+			pending := bpstate.condCalls
+			bpstate.condCalls = nil
+			for _, breaklet := range pending {
+				bpstate.applyCond(breaklet, th, true, errors.New("condition with function calls not evaluated: multiple threads stopped at breakpoints"))
+			}
+			dbp.collectLogpointMessage(th)
+			dbp.clearMaxHitsBreakpoint(th)
+		}
+		return condthread
+	}
+
+	bpstate := *condthread.Breakpoint()
+	pending := bpstate.condCalls
+	bpstate.condCalls = nil
+	for _, breaklet := range pending {
+		active, condErr := dbp.evalBreakpointConditionWithCalls(condthread, breaklet.Cond)
+		bpstate.applyCond(breaklet, condthread, active, condErr)
+	}
+	*condthread.Breakpoint() = bpstate
+	dbp.collectLogpointMessage(condthread)
+	dbp.clearMaxHitsBreakpoint(condthread)
+	return condthread
+}
+
+// evalBreakpointConditionWithCalls evaluates cond on thread, injecting the
+// function calls it contains in the goroutine running on thread.
+func (dbp *Target) evalBreakpointConditionWithCalls(thread Thread, cond ast.Expr) (bool, error) {
+	g, err := GetG(thread)
+	if err != nil {
+		return true, fmt.Errorf("could not evaluate condition: %v", err)
+	}
+	if err := condCallSafePoint(dbp, thread, g); err != nil {
+		return true, fmt.Errorf("could not evaluate condition: %v", err)
+	}
+
+	dbp.condCallG = g.ID
+	defer func() {
+		dbp.condCallG = 0
+	}()
+
+	var timedOut int32
+	timer := time.AfterFunc(condCallTimeout, func() {
+		atomic.StoreInt32(&timedOut, 1)
+		dbp.RequestManualStop()
+	})
+	err = EvalExpressionWithCalls(dbp, g, exprToString(cond), loadSingleValue, true)
+	timer.Stop()
+
+	rets := thread.Common().returnValues
+	thread.Common().CallReturn = false
+	thread.Common().returnValues = nil
+
+	switch {
+	case dbp.fncallForG[g.ID] != nil && atomic.LoadInt32(&timedOut) != 0:
+		return true, fmt.Errorf("function calls in condition did not complete within %v", condCallTimeout)
+	case dbp.fncallForG[g.ID] != nil:
+		return true, errors.New("evaluation of condition interrupted")
+	case err != nil:
+		return true, fmt.Errorf("error evaluating expression: %v", err)
+	case len(rets) != 1:
+		return true, errors.New("condition expression not boolean")
+	}
+	v := rets[0]
+	if v.Name == "~panic" {
+		return true, errors.New("function call in condition panicked")
+	}
+	if v.Kind != reflect.Bool {
+		return true, errors.New("condition expression not boolean")
+	}
+	if v.Unreadable != nil {
+		return true, fmt.Errorf("condition expression unreadable: %v", v.Unreadable)
+	}
+	return constant.BoolVal(v.Value), nil
+}
+
+// condCallSafePoint returns an error if the function calls of a breakpoint
+// condition can not be injected in goroutine g, running on thread.
+// Whether the current instruction is an asynchronous safe point is also
+// checked by the runtime when the injection starts.
+func condCallSafePoint(dbp *Target, thread Thread, g *G) error {
+	if !dbp.SupportsFunctionCalls() {
+		return errFuncCallUnsupportedBackend
+	}
+	if g == nil {
+		return errNoGoroutine
+	}
+	if len(dbp.fncallForG) > 0 {
+		return errFuncCallInProgress
+	}
+	loc, err := thread.Location()
+	if err != nil {
+		return err
+	}
+	if loc.Fn == nil || loc.Fn.privateRuntime() || g.System(dbp) {
+		return fmt.Errorf("goroutine %d is not at a safe point for function calls", g.ID)
+	}
+	return nil
+}
@@ -159,6 +159,10 @@ type process struct {
 
 	entryPoint uint64
 
+	// pointerAuthMask is the mask of the bits used by pointer authentication
+	// codes in code addresses, read from the NT_ARM_PAC_MASK note.
+	pointerAuthMask uint64
+
 	bi          *proc.BinaryInfo
 	breakpoints proc.BreakpointMap
 }
@@ -226,7 +230,8 @@ func OpenCore(corePath, exePath string, debugInfoDirs []string) (*proc.Target, e
 		DebugInfoDirs:       debugInfoDirs,
 		DisableAsyncPreempt: false,
 		StopReason:          proc.StopAttached,
-		CanDump:             false})
+		CanDump:             false,
+		PointerAuthMask:     p.pointerAuthMask})
 }
 
 // BinInfo will return the binary info.
@@ -251,6 +256,20 @@ func (p *process) Recorded() (bool, string) { return true, "" }
 // Restart will only return an error for core files, as they are not executing.
 func (p *process) Restart(string) (proc.Thread, error) { return nil, ErrContinueCore }
 
+// SeekTicks will only return an error for core files, you can't move
+// through a core file.
+func (p *process) SeekTicks(int64) (proc.Thread, error) { return nil, ErrContinueCore }
+
+// PassSignals returns an empty SignalPassing, core files do not receive
+// signals.
+func (p *process) PassSignals() proc.SignalPassing { return proc.SignalPassing{} }
+
+// SetPassSignals will only return an error for core files.
+func (p *process) SetPassSignals([]int) error { return proc.ErrPassSignalsUnsupported }
+
+// SetResumeThread will only return an error for core files.
+func (p *process) SetResumeThread(int) error { return proc.ErrResumeThreadUnsupported }
+
 // ChangeDirection will only return an error as you cannot continue a core process.
 func (p *process) ChangeDirection(proc.Direction) error { return ErrContinueCore }
 
@@ -260,6 +279,11 @@ func (p *process) GetDirection() proc.Direction { return proc.Forward }
 // When does not apply to core files, it is to support the Mozilla 'rr' backend.
 func (p *process) When() (string, error) { return "", nil }
 
+// Position does not apply to core files, it is to support the Mozilla 'rr' backend.
+func (p *process) Position() (proc.RecordingPosition, error) {
+	return proc.RecordingPosition{}, proc.ErrNotRecorded
+}
+
 // Checkpoint for core files returns an error, there is no execution of a core file.
 func (p *process) Checkpoint(string) (int, error) { return -1, ErrContinueCore }
 
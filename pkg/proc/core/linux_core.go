@@ -34,6 +34,13 @@ const _NT_AUXV elf.NType = 0x6
 // NT_FPREGSET is the note type for floating point registers.
 const _NT_FPREGSET elf.NType = 0x2
 
+// NT_ARM_SVE is the note type for the SVE registers of ARM64 CPUs.
+const _NT_ARM_SVE elf.NType = 0x405
+
+// NT_ARM_PAC_MASK is the note type for the pointer authentication masks of
+// ARM64 CPUs.
+const _NT_ARM_PAC_MASK elf.NType = 0x406
+
 // Fetch architecture using exeELF.Machine from core file
 // Refer http://man7.org/linux/man-pages/man5/elf.5.html
 const (
@@ -72,12 +79,22 @@ func linuxThreadsFromNotes(p *process, notes []*note, machineType elf.Machine) p
 					lastThreadARM.regs.Fpregs = note.Desc.(*linutil.ARM64PtraceFpRegs).Decode()
 				}
 			}
+		case _NT_ARM_SVE:
+			if machineType == _EM_AARCH64 {
+				if lastThreadARM != nil {
+					lastThreadARM.regs.Fpregs = append(lastThreadARM.regs.Fpregs, note.Desc.(*linutil.ARM64SveRegs).Decode()...)
+				}
+			}
 		case _NT_X86_XSTATE:
 			if machineType == _EM_X86_64 {
 				if lastThreadAMD != nil {
 					lastThreadAMD.regs.Fpregs = note.Desc.(*amd64util.AMD64Xstate).Decode()
 				}
 			}
+		case _NT_ARM_PAC_MASK:
+			if mask, ok := note.Desc.(uint64); ok {
+				p.pointerAuthMask = mask
+			}
 		case elf.NT_PRPSINFO:
 			p.pid = int(note.Desc.(*linuxPrPsInfo).Pid)
 		}
@@ -322,6 +339,19 @@ func readNote(r io.ReadSeeker, machineType elf.Machine) (*note, error) {
 		}
 	case _NT_AUXV, elfwriter.DelveHeaderNoteType, elfwriter.DelveThreadNodeType:
 		note.Desc = desc
+	case _NT_ARM_SVE:
+		if machineType == _EM_AARCH64 {
+			sveregs := &linutil.ARM64SveRegs{}
+			if err := linutil.ARM64SveRead(desc, sveregs); err != nil {
+				return nil, err
+			}
+			note.Desc = sveregs
+		}
+	case _NT_ARM_PAC_MASK:
+		if machineType == _EM_AARCH64 && len(desc) >= 16 {
+			// struct user_pac_mask, only the instruction mask is needed
+			note.Desc = binary.LittleEndian.Uint64(desc[8:])
+		}
 	case _NT_FPREGSET:
 		if machineType == _EM_AARCH64 {
 			fpregs := &linutil.ARM64PtraceFpRegs{}
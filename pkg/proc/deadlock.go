@@ -0,0 +1,449 @@
+package proc
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
+)
+
+const (
+	// deadlockStackDepth is the maximum depth of the stack traces examined
+	// by FindDeadlocks.
+	deadlockStackDepth = 50
+	// deadlockMaxCycles is the maximum number of cycles returned by
+	// FindDeadlocks.
+	deadlockMaxCycles = 100
+	// deadlockMaxWaitingList is the maximum number of channels read from
+	// the list of sudogs of a goroutine blocked in a select statement.
+	deadlockMaxWaitingList = 64
+	// deadlockMaxStackScan is the maximum number of bytes of stack scanned
+	// for each goroutine when looking for pointers to a blocking object.
+	deadlockMaxStackScan = 1024 * 1024
+)
+
+// BlockKind is the kind of synchronization object a goroutine is blocked
+// on.
+type BlockKind uint8
+
+const (
+	BlockedOnMutex       BlockKind = iota + 1 // sync.Mutex.Lock
+	BlockedOnRWMutex                          // sync.RWMutex.Lock
+	BlockedOnRWMutexRead                      // sync.RWMutex.RLock
+	BlockedOnWaitGroup                        // sync.WaitGroup.Wait
+	BlockedOnCond                             // sync.Cond.Wait
+	BlockedOnChanSend                         // send statement
+	BlockedOnChanRecv                         // receive operation
+	BlockedOnSelect                           // select statement
+)
+
+func (k BlockKind) String() string {
+	switch k {
+	case BlockedOnMutex:
+		return "sync.Mutex"
+	case BlockedOnRWMutex:
+		return "sync.RWMutex"
+	case BlockedOnRWMutexRead:
+		return "sync.RWMutex (read)"
+	case BlockedOnWaitGroup:
+		return "sync.WaitGroup"
+	case BlockedOnCond:
+		return "sync.Cond"
+	case BlockedOnChanSend:
+		return "chan send"
+	case BlockedOnChanRecv:
+		return "chan receive"
+	case BlockedOnSelect:
+		return "select"
+	default:
+		return "unknown"
+	}
+}
+
+// IsMutex returns true if k is the kind of a lock that is held by the
+// goroutine that acquired it.
+func (k BlockKind) IsMutex() bool {
+	return k == BlockedOnMutex || k == BlockedOnRWMutex || k == BlockedOnRWMutexRead
+}
+
+// BlockedGoroutine is a goroutine parked on a synchronization object.
+type BlockedGoroutine struct {
+	G    *G
+	Kind BlockKind
+	// Objects contains the addresses of the objects the goroutine is waiting
+	// on: the address of the mutex, wait group or condition variable or the
+	// address of the runtime.hchan struct of the channels. It can be empty
+	// if the address could not be determined, for example because the
+	// receiver of a sync method is not available in optimized code.
+	Objects []uint64
+	// Frame is the first frame of the stack that is not part of the
+	// runtime or of the sync package, where the blocking operation was
+	// requested.
+	Frame *Stackframe
+}
+
+// WaitEdge is an edge of the wait-for graph built by FindDeadlocks:
+// goroutine Waiter waits on Object, of kind Kind, which goroutine Holder
+// holds, if Object is a lock, or may operate on otherwise.
+type WaitEdge struct {
+	Waiter, Holder int
+	Kind           BlockKind
+	Object         uint64
+}
+
+// DeadlockReport is the result of FindDeadlocks.
+type DeadlockReport struct {
+	// Blocked lists all the goroutines blocked on a synchronization object.
+	Blocked []*BlockedGoroutine
+	// Cycles lists the cycles of the wait-for graph, each cycle is a list of
+	// edges where the holder of one edge is the waiter of the next one.
+	Cycles [][]WaitEdge
+}
+
+// blockingFunctions maps the functions where a goroutine can block to the
+// kind of object it is waiting on and the name of the argument containing
+// its address. The channels of chansend, chanrecv and selectgo are read
+// from the list of sudogs of the goroutine.
+var blockingFunctions = map[string]struct {
+	kind BlockKind
+	arg  string
+}{
+	"sync.(*Mutex).Lock":              {BlockedOnMutex, "m"},
+	"sync.(*Mutex).lockSlow":          {BlockedOnMutex, "m"},
+	"sync.(*RWMutex).Lock":            {BlockedOnRWMutex, "rw"},
+	"sync.(*RWMutex).RLock":           {BlockedOnRWMutexRead, "rw"},
+	"sync.(*WaitGroup).Wait":          {BlockedOnWaitGroup, "wg"},
+	"sync.(*Cond).Wait":               {BlockedOnCond, "c"},
+	"runtime.chansend":                {BlockedOnChanSend, ""},
+	"runtime.chansend1":               {BlockedOnChanSend, ""},
+	"runtime.chanrecv":                {BlockedOnChanRecv, ""},
+	"runtime.chanrecv1":               {BlockedOnChanRecv, ""},
+	"runtime.chanrecv2":               {BlockedOnChanRecv, ""},
+	"runtime.selectgo":                {BlockedOnSelect, ""},
+	"runtime.block":                   {BlockedOnSelect, ""},
+	"sync.runtime_SemacquireRWMutexR": {BlockedOnRWMutexRead, ""},
+}
+
+// FindDeadlocks looks for goroutines that are waiting on each other.
+//
+// Every goroutine parked on a mutex, read-write mutex, wait group,
+// condition variable or channel operation is classified by the object it
+// is waiting on, which is read from the receiver of the sync method or
+// from the sudogs of the goroutine for channel operations.
+// The holder of a lock, or the goroutines that could operate on a channel,
+// can not be read from the runtime, instead a goroutine is assumed to be
+// able to release an object if the object is reachable from the variables
+// of its stack frames, following at most two pointers, or if a pointer to
+// it is stored on its stack. Cycles in the resulting wait-for graph are
+// reported as potential deadlocks.
+func (t *Target) FindDeadlocks() (*DeadlockReport, error) {
+	gs, _, err := GoroutinesInfo(t, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	r := &DeadlockReport{}
+	stacks := map[int][]Stackframe{}
+	for _, g := range gs {
+		if g.Status != Gwaiting || g.Unreadable != nil {
+			continue
+		}
+		frames, err := g.Stacktrace(deadlockStackDepth, 0)
+		if err != nil {
+			continue
+		}
+		if bg := t.classifyBlocked(g, frames); bg != nil {
+			r.Blocked = append(r.Blocked, bg)
+			stacks[g.ID] = frames
+		}
+	}
+
+	refs := make(map[int]*objectRefs, len(r.Blocked))
+	for _, bg := range r.Blocked {
+		refs[bg.G.ID] = t.goroutineRefs(bg.G, stacks[bg.G.ID])
+	}
+
+	graph := map[int][]WaitEdge{}
+	for _, w := range r.Blocked {
+		for _, obj := range w.Objects {
+			if w.Kind == BlockedOnMutex && !t.mutexLocked(obj) {
+				continue
+			}
+			for _, h := range r.Blocked {
+				if h == w || h.waitsOn(obj) || !refs[h.G.ID].contains(obj, w.Kind) {
+					continue
+				}
+				graph[w.G.ID] = append(graph[w.G.ID], WaitEdge{Waiter: w.G.ID, Holder: h.G.ID, Kind: w.Kind, Object: obj})
+			}
+		}
+	}
+	r.Cycles = waitCycles(graph)
+	return r, nil
+}
+
+// classifyBlocked returns the description of the object g is blocked on,
+// or nil if g is not blocked on a synchronization object.
+func (t *Target) classifyBlocked(g *G, frames []Stackframe) *BlockedGoroutine {
+	var bg *BlockedGoroutine
+	for i := range frames {
+		fn := frames[i].Call.Fn
+		if fn == nil {
+			continue
+		}
+		name := strings.TrimPrefix(fn.Name, "internal/")
+		if bf, ok := blockingFunctions[name]; ok {
+			// the outermost blocking function determines the kind, for
+			// example RWMutex.Lock blocks in Mutex.Lock.
+			if bg == nil {
+				bg = &BlockedGoroutine{G: g}
+			}
+			bg.Kind = bf.kind
+			bg.Objects = nil
+			if bf.arg != "" {
+				if addr := t.frameArgPointer(g, frames[i:], bf.arg); addr != 0 {
+					bg.Objects = []uint64{addr}
+				}
+			}
+			continue
+		}
+		if pkg := fn.PackageName(); pkg == "runtime" || pkg == "sync" || pkg == "internal/sync" {
+			continue
+		}
+		if bg != nil {
+			bg.Frame = &frames[i]
+		}
+		break
+	}
+	if bg == nil {
+		return nil
+	}
+	switch bg.Kind {
+	case BlockedOnChanSend, BlockedOnChanRecv, BlockedOnSelect:
+		bg.Objects = t.waitingChannels(g)
+	}
+	return bg
+}
+
+// frameArgPointer returns the value of the pointer argument called name
+// of the topmost frame in frames.
+func (t *Target) frameArgPointer(g *G, frames []Stackframe, name string) uint64 {
+	scope := FrameToScope(t, t.BinInfo(), t.Memory(), g, frames...)
+	vars, err := scope.Locals()
+	if err != nil {
+		return 0
+	}
+	for _, v := range vars {
+		if v.Name != name || v.Unreadable != nil {
+			continue
+		}
+		if _, isptr := v.RealType.(*godwarf.PtrType); !isptr {
+			return 0
+		}
+		addr, err := readUintRaw(v.mem, v.Addr, int64(t.BinInfo().Arch.PtrSize()))
+		if err != nil {
+			return 0
+		}
+		return addr
+	}
+	return 0
+}
+
+// waitingChannels returns the addresses of the channels g is waiting on,
+// read from the list of sudogs in g.waiting.
+func (t *Target) waitingChannels(g *G) []uint64 {
+	if g.variable == nil {
+		return nil
+	}
+	ptrSize := int64(t.BinInfo().Arch.PtrSize())
+	sudog, err := g.variable.structMember("waiting")
+	if err != nil {
+		return nil
+	}
+	var r []uint64
+	for i := 0; i < deadlockMaxWaitingList; i++ {
+		ptyp, isptr := sudog.RealType.(*godwarf.PtrType)
+		if !isptr {
+			break
+		}
+		addr, err := readUintRaw(sudog.mem, sudog.Addr, ptrSize)
+		if err != nil || addr == 0 {
+			break
+		}
+		sudog = newVariable("", addr, ptyp.Type, t.BinInfo(), sudog.mem)
+		if c, err := sudog.structMember("c"); err == nil {
+			if chaddr, err := readUintRaw(c.mem, c.Addr, ptrSize); err == nil && chaddr != 0 {
+				r = append(r, chaddr)
+			}
+		}
+		sudog, err = sudog.structMember("waitlink")
+		if err != nil {
+			break
+		}
+	}
+	return r
+}
+
+// mutexLocked returns false if the sync.Mutex at addr is known to be
+// unlocked.
+func (t *Target) mutexLocked(addr uint64) bool {
+	const mutexLockedFlag = 1
+	state, err := readUintRaw(t.Memory(), addr, 4)
+	if err != nil {
+		return true
+	}
+	return state&mutexLockedFlag != 0
+}
+
+func (bg *BlockedGoroutine) waitsOn(addr uint64) bool {
+	for _, obj := range bg.Objects {
+		if obj == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// objectRefs records the memory reachable from the stack of a goroutine.
+type objectRefs struct {
+	ranges [][2]uint64         // memory ranges of variables and their pointees
+	words  map[uint64]struct{} // pointer values stored in variables or on the stack
+}
+
+// contains returns true if an object of kind k at addr is referenced.
+func (refs *objectRefs) contains(addr uint64, k BlockKind) bool {
+	if refs == nil {
+		return false
+	}
+	if _, ok := refs.words[addr]; ok {
+		return true
+	}
+	switch k {
+	case BlockedOnChanSend, BlockedOnChanRecv, BlockedOnSelect:
+		// channels are only referenced through their pointer
+		return false
+	}
+	for _, rng := range refs.ranges {
+		if addr >= rng[0] && addr < rng[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// goroutineRefs collects the memory reachable from the stack of g.
+func (t *Target) goroutineRefs(g *G, frames []Stackframe) *objectRefs {
+	refs := &objectRefs{words: map[uint64]struct{}{}}
+	mem := t.Memory()
+	ptrSize := int64(t.BinInfo().Arch.PtrSize())
+
+	var visit func(typ godwarf.Type, addr uint64, depth int)
+	visit = func(typ godwarf.Type, addr uint64, depth int) {
+		typ = resolveTypedef(typ)
+		if addr == 0 || typ.Size() <= 0 {
+			return
+		}
+		refs.ranges = append(refs.ranges, [2]uint64{addr, addr + uint64(typ.Size())})
+		switch typ := typ.(type) {
+		case *godwarf.ChanType:
+			if p, err := readUintRaw(mem, addr, ptrSize); err == nil && p != 0 {
+				refs.words[p] = struct{}{}
+			}
+		case *godwarf.PtrType:
+			p, err := readUintRaw(mem, addr, ptrSize)
+			if err != nil || p == 0 {
+				return
+			}
+			refs.words[p] = struct{}{}
+			if depth > 0 {
+				visit(typ.Type, p, depth-1)
+			}
+		case *godwarf.StructType:
+			for _, field := range typ.Field {
+				visit(field.Type, addr+uint64(field.ByteOffset), depth)
+			}
+		}
+	}
+
+	for i := range frames {
+		fn := frames[i].Call.Fn
+		if fn == nil || fn.PackageName() == "runtime" {
+			continue
+		}
+		scope := FrameToScope(t, t.BinInfo(), mem, g, frames[i:]...)
+		vars, err := scope.Locals()
+		if err != nil {
+			continue
+		}
+		for _, v := range vars {
+			if v.Unreadable == nil && v.Addr != 0 {
+				visit(v.DwarfType, v.Addr, 2)
+			}
+		}
+	}
+
+	// pointers spilled to the stack, for example the receivers saved by
+	// deferred calls
+	lo, hi := g.SP, g.stack.hi
+	if lo == 0 || lo >= hi {
+		return refs
+	}
+	if hi-lo > deadlockMaxStackScan {
+		hi = lo + deadlockMaxStackScan
+	}
+	buf := make([]byte, hi-lo)
+	if _, err := mem.ReadMemory(buf, lo); err != nil {
+		return refs
+	}
+	for off := 0; off+int(ptrSize) <= len(buf); off += int(ptrSize) {
+		var p uint64
+		for i := int(ptrSize) - 1; i >= 0; i-- {
+			p = p<<8 | uint64(buf[off+i])
+		}
+		if p != 0 {
+			refs.words[p] = struct{}{}
+		}
+	}
+	return refs
+}
+
+// waitCycles returns the elementary cycles of graph, each cycle is
+// returned once, starting from the edge whose waiter has the smallest ID.
+func waitCycles(graph map[int][]WaitEdge) [][]WaitEdge {
+	nodes := make([]int, 0, len(graph))
+	for id := range graph {
+		nodes = append(nodes, id)
+	}
+	sort.Ints(nodes)
+
+	var r [][]WaitEdge
+	var path []WaitEdge
+	onpath := map[int]bool{}
+
+	var visit func(start, id int)
+	visit = func(start, id int) {
+		if len(r) >= deadlockMaxCycles {
+			return
+		}
+		onpath[id] = true
+		for _, e := range graph[id] {
+			switch {
+			case e.Holder == start:
+				cycle := make([]WaitEdge, len(path)+1)
+				copy(cycle, path)
+				cycle[len(path)] = e
+				r = append(r, cycle)
+			case e.Holder > start && !onpath[e.Holder]:
+				// only visit goroutines with a larger ID than start so that
+				// each cycle is found once, from its smallest goroutine.
+				path = append(path, e)
+				visit(start, e.Holder)
+				path = path[:len(path)-1]
+			}
+		}
+		onpath[id] = false
+	}
+
+	for _, id := range nodes {
+		visit(id, id)
+	}
+	return r
+}
@@ -3,6 +3,7 @@ package proc
 import (
 	"bytes"
 	"debug/dwarf"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"go/ast"
@@ -11,7 +12,9 @@ import (
 	"go/printer"
 	"go/scanner"
 	"go/token"
+	"math/big"
 	"reflect"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -24,6 +27,8 @@ import (
 
 var errOperationOnSpecialFloat = errors.New("operations on non-finite floats not implemented")
 
+var errKeyNotFound = errors.New("key not found")
+
 // EvalScope is the scope for variable evaluation. Contains the thread,
 // current location (PC), and canonical frame address.
 type EvalScope struct {
@@ -36,6 +41,24 @@ type EvalScope struct {
 
 	frameOffset int64
 
+	// pkg is the package used to resolve unqualified identifiers in scopes
+	// that don't belong to a function (see PackageScope).
+	pkg string
+
+	// regexps, if not nil, caches the regular expressions with a constant
+	// pattern compiled by the matches builtin. It is used to compile the
+	// regular expressions of a breakpoint condition only once.
+	regexps map[string]*regexp.Regexp
+
+	// receiver, if not nil, is the value formatted by a Formatter:
+	// unqualified identifiers are resolved to its fields and methods before
+	// anything else.
+	receiver *Variable
+
+	// mapKey and mapValue, if not nil, are the key and value of the map
+	// entry tested by a map filter, see mapFilter.
+	mapKey, mapValue *Variable
+
 	// When the following pointer is not nil this EvalScope was created
 	// by CallFunction and the expression evaluation is executing on a
 	// different goroutine from the debugger's main goroutine.
@@ -54,6 +77,11 @@ type EvalScope struct {
 	// will have one assigned by looking at their position in the argument
 	// list.
 	trustArgOrder bool
+
+	// If inPlace is true strings and slices that can not be allocated,
+	// because function calls are not allowed, are assigned by overwriting
+	// the contents of the old value, see SetVariableInPlace.
+	inPlace bool
 }
 
 // ConvertEvalScope returns a new EvalScope in the context of the
@@ -129,6 +157,16 @@ func FrameToScope(t *Target, bi *BinaryInfo, thread MemoryReadWriter, g *G, fram
 	return s
 }
 
+// PackageScope returns an EvalScope that evaluates expressions as if they
+// appeared at the top level of package pkg of the executable: unqualified
+// identifiers are resolved to package-level variables of pkg.
+func PackageScope(t *Target, pkg string) *EvalScope {
+	scope := globalScope(t.BinInfo(), t.BinInfo().Images[0], t.Memory())
+	scope.target = t
+	scope.pkg = pkg
+	return scope
+}
+
 // ThreadScope returns an EvalScope for the given thread.
 func ThreadScope(t *Target, thread Thread) (*EvalScope, error) {
 	locations, err := ThreadStacktrace(thread, 1)
@@ -163,7 +201,7 @@ func (scope *EvalScope) EvalExpression(expr string, cfg LoadConfig) (*Variable,
 		// makes sure that the other goroutine won't wait forever if we make a mistake
 		defer close(scope.callCtx.continueRequest)
 	}
-	t, err := parser.ParseExpr(expr)
+	t, err := parser.ParseExpr(rewriteMapFilterIdents(expr))
 	if eqOff, isAs := isAssignment(err); scope.callCtx != nil && isAs {
 		lexpr := expr[:eqOff]
 		rexpr := expr[eqOff+1:]
@@ -176,14 +214,23 @@ func (scope *EvalScope) EvalExpression(expr string, cfg LoadConfig) (*Variable,
 		return nil, err
 	}
 
-	ev, err := scope.evalToplevelTypeCast(t, cfg)
-	if ev == nil && err == nil {
+	ev, err := scope.evalStringConversion(t)
+	if ev != nil {
+		truncateStringConversion(ev, cfg)
+	} else if err == nil {
 		ev, err = scope.evalAST(t)
 	}
 	if err != nil {
 		scope.callCtx.doReturn(nil, err)
 		return nil, err
 	}
+	if cfg.Start != 0 || cfg.MapContinuation != "" {
+		ev, err = ev.page(cfg)
+		if err != nil {
+			scope.callCtx.doReturn(nil, err)
+			return nil, err
+		}
+	}
 	ev.loadValue(cfg)
 	if ev.Name == "" {
 		ev.Name = expr
@@ -302,6 +349,10 @@ func afterLastArgAddr(vars []*Variable) uint64 {
 // * If srcv is nil and dstv is of a nil'able type then dstv is nilled.
 // * If srcv is the empty string and dstv is a string then dstv is set to the
 //   empty string.
+// * If srcv is a string, or a []byte or []rune converted from a string, whose
+//   contents only exist in the debugger the contents are allocated in the
+//   target, if function calls are allowed, or written over the contents of
+//   dstv if scope.inPlace is set.
 // * If dstv is an "interface {}" and srcv is either an interface (possibly
 //   non-empty) or a pointer shaped type (map, channel, pointer or struct
 //   containing a single pointer field) the type conversion to "interface {}"
@@ -349,7 +400,11 @@ func (scope *EvalScope) setValue(dstv, srcv *Variable, srcExpr string) error {
 	}
 
 	if srcv.Kind == reflect.String {
-		if err := allocString(scope, srcv); err != nil {
+		err := allocString(scope, srcv)
+		if err == errFuncCallNotAllowedStrAlloc && scope.inPlace {
+			return scope.overwriteContents(dstv, srcv)
+		}
+		if err != nil {
 			return err
 		}
 		return dstv.writeString(uint64(srcv.Len), uint64(srcv.Base))
@@ -358,6 +413,13 @@ func (scope *EvalScope) setValue(dstv, srcv *Variable, srcExpr string) error {
 	// slice assignment (this is not handled by the writeCopy below so that
 	// results of a reslice operation can be used here).
 	if srcv.Kind == reflect.Slice {
+		err := allocConvertedSlice(scope, srcv)
+		if err == errFuncCallNotAllowedConvAlloc && scope.inPlace {
+			return scope.overwriteContents(dstv, srcv)
+		}
+		if err != nil {
+			return err
+		}
 		return dstv.writeSlice(srcv.Len, srcv.Cap, srcv.Base)
 	}
 
@@ -374,6 +436,29 @@ func (scope *EvalScope) setValue(dstv, srcv *Variable, srcExpr string) error {
 	return fmt.Errorf("can not set variables of type %s (not implemented)", dstv.Kind.String())
 }
 
+// overwriteContents assigns srcv, a string or a slice whose contents only
+// exist in the debugger, to dstv by writing them over the contents of the
+// current value of dstv, which must not be shorter.
+func (scope *EvalScope) overwriteContents(dstv, srcv *Variable) error {
+	dstv.loadValue(loadSingleValue)
+	if dstv.Unreadable != nil {
+		return dstv.Unreadable
+	}
+	if srcv.Len > dstv.Len {
+		return fmt.Errorf("can not overwrite value in place: new length %d is greater than the current length %d", srcv.Len, dstv.Len)
+	}
+	if srcv.Kind == reflect.String {
+		if _, err := scope.Mem.WriteMemory(dstv.Base, []byte(constant.StringVal(srcv.Value))); err != nil {
+			return err
+		}
+		return dstv.writeString(uint64(srcv.Len), dstv.Base)
+	}
+	if err := writeConvertedSliceElems(scope.Mem, dstv.Base, srcv); err != nil {
+		return err
+	}
+	return dstv.writeSlice(srcv.Len, dstv.Cap, dstv.Base)
+}
+
 // EvalVariable returns the value of the given expression (backwards compatibility).
 func (scope *EvalScope) EvalVariable(name string, cfg LoadConfig) (*Variable, error) {
 	return scope.EvalExpression(name, cfg)
@@ -386,7 +471,28 @@ func (scope *EvalScope) SetVariable(name, value string) error {
 		return err
 	}
 
+	if idx, ok := t.(*ast.IndexExpr); ok && scope.callCtx != nil {
+		// assigning to a map element that doesn't exist adds it to the map,
+		// which is done by calling runtime.mapassign.
+		mapv, err := scope.evalAST(idx.X)
+		if err != nil {
+			return err
+		}
+		if mapv.Kind == reflect.Map {
+			yv, err := scope.evalValueExpr(value)
+			if err != nil {
+				return err
+			}
+			return scope.setMapElem(mapv, idx.Index, yv)
+		}
+	}
+
 	xv, err := scope.evalAST(t)
+	if err == errKeyNotFound && scope.callCtx == nil {
+		if _, isIndex := t.(*ast.IndexExpr); isIndex {
+			return errFuncCallNotAllowedMapMod
+		}
+	}
 	if err != nil {
 		return err
 	}
@@ -399,17 +505,114 @@ func (scope *EvalScope) SetVariable(name, value string) error {
 		return fmt.Errorf("Expression \"%s\" is unreadable: %v", name, xv.Unreadable)
 	}
 
-	t, err = parser.ParseExpr(value)
+	yv, err := scope.evalValueExpr(value)
 	if err != nil {
 		return err
 	}
 
+	if xv.Flags&VariableCPURegister != 0 && !xv.loaded {
+		return scope.setRegister(xv, yv, value)
+	}
+
+	return scope.setValue(xv, yv, value)
+}
+
+// evalValueExpr evaluates value, the right hand side of an assignment.
+func (scope *EvalScope) evalValueExpr(value string) (*Variable, error) {
+	t, err := parser.ParseExpr(value)
+	if err != nil {
+		return nil, err
+	}
 	yv, err := scope.evalAST(t)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	yv.Name = value
+	return yv, nil
+}
 
-	return scope.setValue(xv, yv, value)
+// SetVariableInPlace is like SetVariable but, if function calls are not
+// allowed, a string or a []byte or []rune slice can still be assigned a
+// new value that needs to be allocated (for example a string literal) as
+// long as it is not longer than the current value: the contents of the
+// current value are overwritten instead.
+// Note that this also changes every other string or slice that shares the
+// same backing array, including string constants of the program.
+func (scope *EvalScope) SetVariableInPlace(name, value string) error {
+	scope.inPlace = true
+	defer func() {
+		scope.inPlace = false
+	}()
+	return scope.SetVariable(name, value)
+}
+
+// setRegister changes the value of the CPU register dstv.
+// The new value can be an integer, of any size, or a string of
+// hexadecimal digits, with the least significant byte first (the same
+// format used to print registers larger than 64 bits). Values smaller
+// than the register are zero extended.
+func (scope *EvalScope) setRegister(dstv, srcv *Variable, srcExpr string) error {
+	regnum, ok := scope.BinInfo.Arch.RegisterNameToDwarf(validRegisterName(dstv.Name))
+	if !ok {
+		return fmt.Errorf("unknown register %s", dstv.Name)
+	}
+	if scope.Regs.ChangeFunc == nil {
+		return fmt.Errorf("can not change register %s of this frame", dstv.Name)
+	}
+
+	sz := len(dstv.reg.Bytes)
+	var val []byte
+
+	switch {
+	case srcv.Flags&VariableCPURegister != 0 && !srcv.loaded:
+		srcv.reg.FillBytes()
+		val = srcv.reg.Bytes
+	default:
+		srcv.loadValue(loadSingleValue)
+		if srcv.Unreadable != nil {
+			return fmt.Errorf("Expression \"%s\" is unreadable: %v", srcExpr, srcv.Unreadable)
+		}
+		if srcv.Value == nil {
+			return fmt.Errorf("can not assign %s to register %s: not an integer or a string", srcExpr, dstv.Name)
+		}
+		switch srcv.Value.Kind() {
+		case constant.Int:
+			n, ok := new(big.Int).SetString(srcv.Value.ExactString(), 0)
+			if !ok {
+				return fmt.Errorf("can not convert %s to an integer", srcExpr)
+			}
+			if n.Sign() < 0 {
+				n.Add(n, new(big.Int).Lsh(big.NewInt(1), uint(8*sz)))
+			}
+			if n.Sign() < 0 || n.BitLen() > 8*sz {
+				return fmt.Errorf("value %s does not fit in register %s (%d bytes)", srcExpr, dstv.Name, sz)
+			}
+			val = n.Bytes()
+			for i, j := 0, len(val)-1; i < j; i, j = i+1, j-1 {
+				val[i], val[j] = val[j], val[i]
+			}
+		case constant.String:
+			var err error
+			val, err = hex.DecodeString(constant.StringVal(srcv.Value))
+			if err != nil {
+				return fmt.Errorf("can not assign %s to register %s: %v", srcExpr, dstv.Name, err)
+			}
+		default:
+			return fmt.Errorf("can not assign %s to register %s: not an integer or a string", srcExpr, dstv.Name)
+		}
+	}
+
+	if len(val) > sz {
+		return fmt.Errorf("value %s does not fit in register %s (%d bytes)", srcExpr, dstv.Name, sz)
+	}
+	buf := make([]byte, sz)
+	copy(buf, val)
+	reg := op.DwarfRegisterFromBytes(buf)
+	if err := scope.Regs.ChangeFunc(uint64(regnum), reg); err != nil {
+		return err
+	}
+	scope.Regs.AddReg(uint64(regnum), reg)
+	return nil
 }
 
 // LocalVariables returns all local variables from the current function scope.
@@ -569,9 +772,18 @@ func (scope *EvalScope) PtrSize() int {
 	return scope.BinInfo.Arch.PtrSize()
 }
 
-// evalToplevelTypeCast implements certain type casts that we only support
-// at the outermost levels of an expression.
-func (scope *EvalScope) evalToplevelTypeCast(t ast.Expr, cfg LoadConfig) (*Variable, error) {
+// maxStringConversionLen is the maximum length of the argument of a
+// conversion between string, []byte and []rune whose argument or result is
+// a slice, every element of the slice is loaded by the debugger.
+const maxStringConversionLen = 1 << 20
+
+// evalStringConversion implements the conversions between string, []byte
+// and []rune. The result is computed by the debugger from the value of the
+// argument, which is loaded entirely, instead of being allocated in the
+// target, so that the conversions also work on core files and recordings.
+// The result has no address in the target and is flagged with
+// VariableFakeAddress.
+func (scope *EvalScope) evalStringConversion(t ast.Expr) (*Variable, error) {
 	call, _ := t.(*ast.CallExpr)
 	if call == nil || len(call.Args) != 1 {
 		return nil, nil
@@ -580,9 +792,9 @@ func (scope *EvalScope) evalToplevelTypeCast(t ast.Expr, cfg LoadConfig) (*Varia
 	var targetType godwarf.Type
 	switch targetTypeStr {
 	case "[]byte", "[]uint8":
-		targetType = fakeSliceType(&godwarf.IntType{BasicType: godwarf.BasicType{CommonType: godwarf.CommonType{ByteSize: 1, Name: "uint8"}, BitSize: 8, BitOffset: 0}})
+		targetType = scope.stringConversionSliceType("uint8", &godwarf.UintType{BasicType: godwarf.BasicType{CommonType: godwarf.CommonType{ByteSize: 1, Name: "uint8", ReflectKind: reflect.Uint8}, BitSize: 8}})
 	case "[]int32", "[]rune":
-		targetType = fakeSliceType(&godwarf.IntType{BasicType: godwarf.BasicType{CommonType: godwarf.CommonType{ByteSize: 1, Name: "int32"}, BitSize: 32, BitOffset: 0}})
+		targetType = scope.stringConversionSliceType("int32", &godwarf.IntType{BasicType: godwarf.BasicType{CommonType: godwarf.CommonType{ByteSize: 4, Name: "int32", ReflectKind: reflect.Int32}, BitSize: 32}})
 	case "string":
 		var err error
 		targetType, err = scope.BinInfo.findType("string")
@@ -593,20 +805,26 @@ func (scope *EvalScope) evalToplevelTypeCast(t ast.Expr, cfg LoadConfig) (*Varia
 		return nil, nil
 	}
 
-	argv, err := scope.evalToplevelTypeCast(call.Args[0], cfg)
+	argv, err := scope.evalStringConversion(call.Args[0])
 	if argv == nil && err == nil {
 		argv, err = scope.evalAST(call.Args[0])
 	}
 	if err != nil {
 		return nil, err
 	}
-	argv.loadValue(cfg)
+	// The result of the conversion can be passed to a function call or
+	// assigned to a variable, it must not be truncated.
+	if argv.Len > maxStringConversionLen && (targetTypeStr != "string" || argv.Kind != reflect.String) {
+		return nil, fmt.Errorf("can not convert %q to %s: length %d exceeds the maximum of %d", exprToString(call.Args[0]), targetTypeStr, argv.Len, maxStringConversionLen)
+	}
+	argv.loadValue(LoadConfig{MaxStringLen: int(argv.Len), MaxArrayValues: int(argv.Len)})
 	if argv.Unreadable != nil {
 		return nil, argv.Unreadable
 	}
 
 	v := newVariable("", 0, targetType, scope.BinInfo, scope.Mem)
 	v.loaded = true
+	v.Flags |= VariableFakeAddress
 
 	converr := fmt.Errorf("can not convert %q to %s", exprToString(call.Args[0]), targetTypeStr)
 
@@ -615,28 +833,20 @@ func (scope *EvalScope) evalToplevelTypeCast(t ast.Expr, cfg LoadConfig) (*Varia
 		if argv.Kind != reflect.String {
 			return nil, converr
 		}
-		for i, ch := range []byte(constant.StringVal(argv.Value)) {
-			e := newVariable("", argv.Addr+uint64(i), targetType.(*godwarf.SliceType).ElemType, scope.BinInfo, argv.mem)
-			e.loaded = true
-			e.Value = constant.MakeInt64(int64(ch))
-			v.Children = append(v.Children, *e)
+		for _, ch := range []byte(constant.StringVal(argv.Value)) {
+			v.Children = append(v.Children, *stringConversionElem(v, constant.MakeInt64(int64(ch))))
 		}
-		v.Len = int64(len(v.Children))
-		v.Cap = v.Len
+		setStringConversionLen(v)
 		return v, nil
 
 	case "[]int32", "[]rune":
 		if argv.Kind != reflect.String {
 			return nil, converr
 		}
-		for i, ch := range constant.StringVal(argv.Value) {
-			e := newVariable("", argv.Addr+uint64(i), targetType.(*godwarf.SliceType).ElemType, scope.BinInfo, argv.mem)
-			e.loaded = true
-			e.Value = constant.MakeInt64(int64(ch))
-			v.Children = append(v.Children, *e)
+		for _, ch := range []rune(constant.StringVal(argv.Value)) {
+			v.Children = append(v.Children, *stringConversionElem(v, constant.MakeInt64(int64(ch))))
 		}
-		v.Len = int64(len(v.Children))
-		v.Cap = v.Len
+		setStringConversionLen(v)
 		return v, nil
 
 	case "string":
@@ -659,7 +869,7 @@ func (scope *EvalScope) evalToplevelTypeCast(t ast.Expr, cfg LoadConfig) (*Varia
 			} else {
 				elem = argv.RealType.(*godwarf.ArrayType).Type
 			}
-			switch elemType := elem.(type) {
+			switch elemType := resolveTypedef(elem).(type) {
 			case *godwarf.UintType:
 				if elemType.Name != "uint8" && elemType.Name != "byte" {
 					return nil, nil
@@ -696,9 +906,63 @@ func (scope *EvalScope) evalToplevelTypeCast(t ast.Expr, cfg LoadConfig) (*Varia
 	return nil, nil
 }
 
+// stringConversionSliceType returns the type of slices of elemName, using
+// elemType if the type isn't used by the target.
+func (scope *EvalScope) stringConversionSliceType(elemName string, elemType godwarf.Type) godwarf.Type {
+	if typ, err := scope.BinInfo.findType("[]" + elemName); err == nil {
+		return typ
+	}
+	if typ, err := scope.BinInfo.findType(elemName); err == nil {
+		elemType = typ
+	}
+	return fakeSliceType(elemType)
+}
+
+// setStringConversionLen sets the length of the slice v, the result of a
+// conversion of a string, to the number of its elements, which are all
+// loaded. Non-empty slices get a fake base address, so that clients know
+// their elements can be examined.
+func setStringConversionLen(v *Variable) {
+	v.Len = int64(len(v.Children))
+	v.Cap = v.Len
+	if v.Len > 0 {
+		v.Base = fakeAddressUnresolv
+	}
+}
+
+// truncateStringConversion truncates the value of v, the result of
+// evalStringConversion, to the limits of cfg. The length of v is not
+// changed. Only values that are not used by other expressions can be
+// truncated.
+func truncateStringConversion(v *Variable, cfg LoadConfig) {
+	switch v.Kind {
+	case reflect.String:
+		if s := constant.StringVal(v.Value); len(s) > cfg.MaxStringLen {
+			v.Value = constant.MakeString(s[:cfg.MaxStringLen])
+		}
+	case reflect.Slice:
+		if len(v.Children) > cfg.MaxArrayValues {
+			v.Children = v.Children[:cfg.MaxArrayValues]
+		}
+	}
+}
+
+// stringConversionElem returns an element of the slice v, the result of a
+// conversion of a string, with value val.
+func stringConversionElem(v *Variable, val constant.Value) *Variable {
+	e := v.newVariable("", 0, v.RealType.(*godwarf.SliceType).ElemType, v.mem)
+	e.loaded = true
+	e.Flags |= VariableFakeAddress
+	e.Value = val
+	return e
+}
+
 func (scope *EvalScope) evalAST(t ast.Expr) (*Variable, error) {
 	switch node := t.(type) {
 	case *ast.CallExpr:
+		if v, err := scope.evalStringConversion(node); v != nil || err != nil {
+			return v, err
+		}
 		if len(node.Args) == 1 {
 			v, err := scope.evalTypeCast(node)
 			if err == nil || err != reader.TypeNotFoundErr {
@@ -753,6 +1017,9 @@ func (scope *EvalScope) evalAST(t ast.Expr) (*Variable, error) {
 		return scope.evalTypeAssert(node)
 
 	case *ast.IndexExpr:
+		if isGoroutineLabels(node.X) {
+			return scope.evalGoroutineLabel(node)
+		}
 		return scope.evalIndex(node)
 
 	case *ast.SliceExpr:
@@ -781,6 +1048,9 @@ func (scope *EvalScope) evalAST(t ast.Expr) (*Variable, error) {
 	case *ast.BasicLit:
 		return newConstant(constant.MakeFromLiteral(node.Value, node.Kind, 0), scope.Mem), nil
 
+	case *ast.CompositeLit:
+		return scope.evalCompositeLit(node)
+
 	default:
 		return nil, fmt.Errorf("expression %T not implemented", t)
 
@@ -793,6 +1063,21 @@ func exprToString(t ast.Expr) string {
 	return buf.String()
 }
 
+// allocatesValue returns true if evaluating expr allocates a value in the
+// target, i.e. if it is a composite literal or a call to make.
+func allocatesValue(expr ast.Expr) bool {
+	switch node := removeParen(expr).(type) {
+	case *ast.CompositeLit:
+		return true
+	case *ast.UnaryExpr:
+		return node.Op == token.AND && allocatesValue(node.X)
+	case *ast.CallExpr:
+		fnnode, ok := node.Fun.(*ast.Ident)
+		return ok && fnnode.Name == "make"
+	}
+	return false
+}
+
 func removeParen(n ast.Expr) ast.Expr {
 	for {
 		p, ok := n.(*ast.ParenExpr)
@@ -806,6 +1091,13 @@ func removeParen(n ast.Expr) ast.Expr {
 
 // Eval type cast expressions
 func (scope *EvalScope) evalTypeCast(node *ast.CallExpr) (*Variable, error) {
+	if allocatesValue(node.Args[0]) {
+		// node could be a function call, look up the type first so that the
+		// argument isn't allocated twice.
+		if _, err := scope.BinInfo.findTypeExpr(removeParen(node.Fun)); err != nil {
+			return nil, err
+		}
+	}
 	argv, err := scope.evalAST(node.Args[0])
 	if err != nil {
 		return nil, err
@@ -925,11 +1217,6 @@ func convertInt(n uint64, signed bool, size int64) uint64 {
 }
 
 func (scope *EvalScope) evalBuiltinCall(node *ast.CallExpr) (*Variable, error) {
-	fnnode, ok := node.Fun.(*ast.Ident)
-	if !ok {
-		return nil, nil
-	}
-
 	callBuiltinWithArgs := func(builtin func([]*Variable, []ast.Expr) (*Variable, error)) (*Variable, error) {
 		args := make([]*Variable, len(node.Args))
 
@@ -944,6 +1231,23 @@ func (scope *EvalScope) evalBuiltinCall(node *ast.CallExpr) (*Variable, error) {
 		return builtin(args, node.Args)
 	}
 
+	if sel, ok := node.Fun.(*ast.SelectorExpr); ok {
+		if pkg, ok := sel.X.(*ast.Ident); ok && pkg.Name == "runtime" {
+			switch sel.Sel.Name {
+			case "callerfunc":
+				return callBuiltinWithArgs(scope.callerfuncBuiltin)
+			case "stackdepth":
+				return callBuiltinWithArgs(scope.stackdepthBuiltin)
+			}
+		}
+		return nil, nil
+	}
+
+	fnnode, ok := node.Fun.(*ast.Ident)
+	if !ok {
+		return nil, nil
+	}
+
 	switch fnnode.Name {
 	case "cap":
 		return callBuiltinWithArgs(capBuiltin)
@@ -955,11 +1259,108 @@ func (scope *EvalScope) evalBuiltinCall(node *ast.CallExpr) (*Variable, error) {
 		return callBuiltinWithArgs(imagBuiltin)
 	case "real":
 		return callBuiltinWithArgs(realBuiltin)
+	case "matches":
+		return callBuiltinWithArgs(scope.matchesBuiltin)
+	case "make":
+		return scope.makeBuiltin(node)
+	case "append":
+		return scope.appendBuiltin(node)
+	case "delete":
+		return scope.deleteBuiltin(node)
 	}
 
 	return nil, nil
 }
 
+// maxStackdepthBuiltin is the maximum number of frames counted by
+// runtime.stackdepth when no limit is specified.
+const maxStackdepthBuiltin = 1000
+
+// callerfuncBuiltin implements runtime.callerfunc(n), which returns the
+// name of the function n frames above the frame of scope, or the empty
+// string if the stack has fewer frames. Only n+1 frames are unwound.
+func (scope *EvalScope) callerfuncBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("wrong number of arguments to runtime.callerfunc: %d", len(args))
+	}
+	n, err := builtinIntArg("runtime.callerfunc", args[0], nodeargs[0])
+	if err != nil {
+		return nil, err
+	}
+	frames, err := scope.stacktrace(int(n))
+	if err != nil {
+		return nil, err
+	}
+	name := ""
+	if int(n) < len(frames) && frames[n].Err == nil && frames[n].Call.Fn != nil {
+		name = frames[n].Call.Fn.Name
+	}
+	return newConstant(constant.MakeString(name), scope.Mem), nil
+}
+
+// stackdepthBuiltin implements runtime.stackdepth([limit]), which returns
+// the number of frames of the stack starting at the frame of scope. At most
+// limit frames, or maxStackdepthBuiltin if limit is not specified, are
+// unwound and counted.
+func (scope *EvalScope) stackdepthBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
+	limit := int64(maxStackdepthBuiltin)
+	switch len(args) {
+	case 0:
+	case 1:
+		var err error
+		limit, err = builtinIntArg("runtime.stackdepth", args[0], nodeargs[0])
+		if err != nil {
+			return nil, err
+		}
+		if limit <= 0 {
+			return nil, fmt.Errorf("invalid limit %d for runtime.stackdepth", limit)
+		}
+	default:
+		return nil, fmt.Errorf("wrong number of arguments to runtime.stackdepth: %d", len(args))
+	}
+	frames, err := scope.stacktrace(int(limit - 1))
+	if err != nil {
+		return nil, err
+	}
+	depth := int64(0)
+	for _, frame := range frames {
+		if frame.Err == nil {
+			depth++
+		}
+	}
+	if depth > limit {
+		depth = limit
+	}
+	return newConstant(constant.MakeInt64(depth), scope.Mem), nil
+}
+
+// builtinIntArg returns the value of the integer argument v of builtin fn.
+func builtinIntArg(fn string, v *Variable, node ast.Expr) (int64, error) {
+	v.loadValue(loadFullValue)
+	if v.Unreadable != nil {
+		return 0, v.Unreadable
+	}
+	if v.Value == nil || v.Value.Kind() != constant.Int {
+		return 0, fmt.Errorf("invalid argument %s (type %s) to %s", exprToString(node), v.TypeString(), fn)
+	}
+	n, _ := constant.Int64Val(v.Value)
+	if n < 0 {
+		return 0, fmt.Errorf("invalid argument %s to %s", exprToString(node), fn)
+	}
+	return n, nil
+}
+
+// stacktrace returns up to depth+1 frames of the stack starting at the
+// frame of scope.
+func (scope *EvalScope) stacktrace(depth int) ([]Stackframe, error) {
+	var stackhi uint64
+	if scope.g != nil {
+		stackhi = scope.g.stack.hi
+	}
+	it := newStackIterator(scope.BinInfo, scope.Mem, scope.Regs, stackhi, scope.g, 0)
+	return it.stacktrace(depth)
+}
+
 func capBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
 	if len(args) != 1 {
 		return nil, fmt.Errorf("wrong number of arguments to cap: %d", len(args))
@@ -980,7 +1381,7 @@ func capBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
 	case reflect.Slice:
 		return newConstant(constant.MakeInt64(arg.Cap), arg.mem), nil
 	case reflect.Chan:
-		arg.loadValue(loadFullValue)
+		arg.loadValue(loadSingleValue)
 		if arg.Unreadable != nil {
 			return nil, arg.Unreadable
 		}
@@ -1013,7 +1414,7 @@ func lenBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
 		}
 		return newConstant(constant.MakeInt64(arg.Len), arg.mem), nil
 	case reflect.Chan:
-		arg.loadValue(loadFullValue)
+		arg.loadValue(loadSingleValue)
 		if arg.Unreadable != nil {
 			return nil, arg.Unreadable
 		}
@@ -1122,6 +1523,57 @@ func realBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
 	return newConstant(constant.Real(arg.Value), arg.mem), nil
 }
 
+// maxMatchesStringLen is the maximum number of bytes of a string loaded by
+// the matches builtin.
+const maxMatchesStringLen = 1 << 20
+
+// matchesBuiltin implements matches(s, pattern), which returns true if the
+// string s contains a match of the regular expression pattern.
+func (scope *EvalScope) matchesBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("wrong number of arguments to matches: %d", len(args))
+	}
+	s, err := matchesStringArg(args[0], nodeargs[0])
+	if err != nil {
+		return nil, err
+	}
+	pattern, err := matchesStringArg(args[1], nodeargs[1])
+	if err != nil {
+		return nil, err
+	}
+	re := scope.regexps[pattern]
+	if re == nil {
+		re, err = regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if _, isconst := nodeargs[1].(*ast.BasicLit); isconst && scope.regexps != nil {
+			scope.regexps[pattern] = re
+		}
+	}
+	return newConstant(constant.MakeBool(re.MatchString(s)), scope.Mem), nil
+}
+
+// matchesStringArg returns the value of the string argument v of the
+// matches builtin, loading up to maxMatchesStringLen bytes of it.
+func matchesStringArg(v *Variable, node ast.Expr) (string, error) {
+	if v.Kind != reflect.String {
+		return "", fmt.Errorf("invalid argument %s (type %s) to matches", exprToString(node), v.TypeString())
+	}
+	if v.loaded && v.Value != nil && v.Addr != 0 && int64(len(constant.StringVal(v.Value))) < v.Len {
+		// the string was truncated when it was loaded, load it again
+		v = v.newVariable(v.Name, v.Addr, v.DwarfType, v.mem)
+	}
+	v.loadValue(LoadConfig{MaxStringLen: maxMatchesStringLen})
+	if v.Unreadable != nil {
+		return "", v.Unreadable
+	}
+	if v.Value == nil {
+		return "", fmt.Errorf("invalid argument %s (type %s) to matches", exprToString(node), v.TypeString())
+	}
+	return constant.StringVal(v.Value), nil
+}
+
 // Evaluates identifier expressions
 func (scope *EvalScope) evalIdent(node *ast.Ident) (*Variable, error) {
 	switch node.Name {
@@ -1131,6 +1583,27 @@ func (scope *EvalScope) evalIdent(node *ast.Ident) (*Variable, error) {
 		return nilVariable, nil
 	}
 
+	if v, ok, err := scope.mapFilterEntry(node.Name); ok {
+		return v, err
+	}
+
+	if scope.receiver != nil {
+		if v, err := scope.receiver.structMember(node.Name); err == nil {
+			return v, nil
+		}
+		if v, err := scope.receiver.findMethod(node.Name); err == nil && v != nil {
+			return v, nil
+		}
+	}
+
+	if scope.Fn == nil && scope.pkg != "" {
+		if v, err := scope.findGlobal(scope.pkg, node.Name); err == nil {
+			v.Name = node.Name
+			return v, nil
+		}
+		return nil, fmt.Errorf("could not find symbol value for %s", node.Name)
+	}
+
 	vars, err := scope.Locals()
 	if err != nil {
 		return nil, err
@@ -1250,6 +1723,45 @@ func (scope *EvalScope) evalTypeAssert(node *ast.TypeAssertExpr) (*Variable, err
 	return &xv.Children[0], nil
 }
 
+// isGoroutineLabels returns true if node is the expression
+// runtime.curg.labels.
+func isGoroutineLabels(node ast.Expr) bool {
+	sel, ok := node.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "labels" {
+		return false
+	}
+	curg, ok := sel.X.(*ast.SelectorExpr)
+	if !ok || curg.Sel.Name != "curg" {
+		return false
+	}
+	pkg, ok := curg.X.(*ast.Ident)
+	return ok && pkg.Name == "runtime"
+}
+
+// evalGoroutineLabel evaluates runtime.curg.labels[key], returning the
+// value of the pprof label key of the current goroutine or the empty
+// string if the goroutine doesn't have such label.
+// Labels are read using G.Labels, which caches them for the current stop,
+// instead of evaluating the labels map through evalIndex.
+func (scope *EvalScope) evalGoroutineLabel(node *ast.IndexExpr) (*Variable, error) {
+	keyv, err := scope.evalAST(node.Index)
+	if err != nil {
+		return nil, err
+	}
+	keyv.loadValue(loadFullValue)
+	if keyv.Unreadable != nil {
+		return nil, keyv.Unreadable
+	}
+	if keyv.Kind != reflect.String || keyv.Value == nil {
+		return nil, fmt.Errorf("invalid label key %s (type %s)", exprToString(node.Index), keyv.TypeString())
+	}
+	value := ""
+	if scope.g != nil {
+		value = scope.g.Labels()[constant.StringVal(keyv.Value)]
+	}
+	return newConstant(constant.MakeString(value), scope.Mem), nil
+}
+
 // Evaluates expressions <subexpr>[<subexpr>] (subscript access to arrays, slices and maps)
 func (scope *EvalScope) evalIndex(node *ast.IndexExpr) (*Variable, error) {
 	xev, err := scope.evalAST(node.X)
@@ -1264,6 +1776,10 @@ func (scope *EvalScope) evalIndex(node *ast.IndexExpr) (*Variable, error) {
 		xev = xev.maybeDereference()
 	}
 
+	if xev.Kind == reflect.Map && isMapFilter(node.Index) {
+		return scope.filterMap(xev, node.Index)
+	}
+
 	idxev, err := scope.evalAST(node.Index)
 	if err != nil {
 		return nil, err
@@ -1402,7 +1918,7 @@ func (scope *EvalScope) evalAddrOf(node *ast.UnaryExpr) (*Variable, error) {
 	if err != nil {
 		return nil, err
 	}
-	if xev.Addr == 0 || xev.DwarfType == nil {
+	if xev.Addr == 0 || xev.Flags&VariableFakeAddress != 0 || xev.DwarfType == nil {
 		return nil, fmt.Errorf("can not take address of \"%s\"", exprToString(node.X))
 	}
 
@@ -1957,7 +2473,7 @@ func (v *Variable) mapAccess(idx *Variable) (*Variable, error) {
 		return nil, v.Unreadable
 	}
 	// go would return zero for the map value type here, we do not have the ability to create zeroes
-	return nil, fmt.Errorf("key not found")
+	return nil, errKeyNotFound
 }
 
 // LoadResliced returns a new array, slice or map that starts at index start and contains
@@ -2033,6 +2549,16 @@ func (v *Variable) reslice(low int64, high int64) (*Variable, error) {
 // findMethod finds method mname in the type of variable v
 func (v *Variable) findMethod(mname string) (*Variable, error) {
 	if _, isiface := v.RealType.(*godwarf.InterfaceType); isiface {
+		_, data, isnil := v.readInterface()
+		if v.Unreadable != nil {
+			return nil, v.Unreadable
+		}
+		if isnil {
+			return nil, fmt.Errorf("%s is a nil interface", v.Name)
+		}
+		if fn := v.itabMethod(mname); fn != nil && data != nil {
+			return v.bi.itabMethodToVariable(fn, data)
+		}
 		v.loadInterface(0, false, loadFullValue)
 		if v.Unreadable != nil {
 			return nil, v.Unreadable
@@ -2116,6 +2642,112 @@ func (v *Variable) findMethod(mname string) (*Variable, error) {
 	return nil, nil
 }
 
+// itabMethod returns the implementation of the method called mname of the
+// concrete value stored in v, a non-empty interface, by searching the
+// method table of its itab. Returns nil if v is an empty interface or the
+// method could not be found.
+func (v *Variable) itabMethod(mname string) *Function {
+	ityp := resolveTypedef(&v.RealType.(*godwarf.InterfaceType).TypedefType).(*godwarf.StructType)
+	var tab *Variable
+	for _, f := range ityp.Field {
+		if f.Name == "tab" {
+			tab, _ = v.toField(f)
+			break
+		}
+	}
+	if tab == nil {
+		return nil
+	}
+	tab = tab.maybeDereference()
+
+	member := func(v *Variable, names ...string) *Variable {
+		for _, name := range names {
+			if r, err := v.structMember(name); err == nil {
+				return r
+			}
+		}
+		return nil
+	}
+
+	// the itab of Go 1.21 and later is internal/abi.ITab
+	inter := member(tab, "inter", "Inter")
+	fun := member(tab, "fun", "Fun")
+	if inter == nil || fun == nil {
+		return nil
+	}
+	inter = inter.maybeDereference()
+	methods := member(inter, "mhdr", "Methods")
+	if methods == nil {
+		return nil
+	}
+	methods.loadValue(loadSingleValue)
+	if methods.Unreadable != nil {
+		return nil
+	}
+
+	ptrSize := int64(v.bi.Arch.PtrSize())
+	for i := int64(0); i < methods.Len; i++ {
+		pc, err := readUintRaw(v.mem, fun.Addr+uint64(i*ptrSize), ptrSize)
+		if err != nil {
+			return nil
+		}
+		if fn := v.bi.PCToFunc(pc); fn != nil && fn.BaseName() == mname {
+			return fn
+		}
+	}
+	return nil
+}
+
+// itabMethodToVariable returns a variable for fn, a method implementation
+// read from an itab, bound to the receiver stored in the data word data
+// of the interface.
+// Itabs of non-pointer concrete types point to autogenerated wrappers
+// with a pointer receiver, when the corresponding value method exists it is
+// called directly instead, with a copy of the value stored in the
+// interface allocation as receiver.
+func (bi *BinaryInfo) itabMethodToVariable(fn *Function, data *Variable) (*Variable, error) {
+	recvAddr := data.Addr
+	if file, _, _ := bi.PCToLine(fn.Entry); file == "<autogenerated>" {
+		if i := strings.Index(fn.Name, ".(*"); i >= 0 {
+			if j := strings.Index(fn.Name[i:], ")."); j >= 0 {
+				valname := fn.Name[:i] + "." + fn.Name[i+len(".(*"):i+j] + fn.Name[i+j+1:]
+				if valfn := bi.LookupFunc[valname]; valfn != nil {
+					ptr, err := readUintRaw(data.mem, data.Addr, int64(bi.Arch.PtrSize()))
+					if err != nil {
+						return nil, err
+					}
+					fn, recvAddr = valfn, ptr
+				}
+			}
+		}
+	}
+
+	dwarfTree, err := fn.cu.image.getDwarfTree(fn.offset)
+	if err != nil {
+		return nil, err
+	}
+	var recvType godwarf.Type
+	for _, entry := range dwarfTree.Children {
+		if entry.Tag == dwarf.TagFormalParameter {
+			_, recvType, err = readVarEntry(entry, fn.cu.image)
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	if recvType == nil {
+		return nil, fmt.Errorf("could not find receiver of %s", fn.Name)
+	}
+
+	r, err := functionToVariable(fn, bi, data.mem)
+	if err != nil {
+		return nil, err
+	}
+	r.Children = append(r.Children, *newVariable("", recvAddr, recvType, bi, data.mem))
+	return r, nil
+}
+
 func functionToVariable(fn *Function, bi *BinaryInfo, mem MemoryReadWriter) (*Variable, error) {
 	typ, err := fn.fakeType(bi, true)
 	if err != nil {
@@ -51,17 +51,21 @@ const (
 )
 
 var (
-	errFuncCallUnsupported        = errors.New("function calls not supported by this version of Go")
-	errFuncCallUnsupportedBackend = errors.New("backend does not support function calls")
-	errFuncCallInProgress         = errors.New("cannot call function while another function call is already in progress")
-	errNoGoroutine                = errors.New("no goroutine selected")
-	errGoroutineNotRunning        = errors.New("selected goroutine not running")
-	errNotEnoughStack             = errors.New("not enough stack space")
-	errTooManyArguments           = errors.New("too many arguments")
-	errNotEnoughArguments         = errors.New("not enough arguments")
-	errNotAGoFunction             = errors.New("not a Go function")
-	errFuncCallNotAllowed         = errors.New("function calls not allowed without using 'call'")
-	errFuncCallNotAllowedStrAlloc = errors.New("literal string can not be allocated because function calls are not allowed without using 'call'")
+	errFuncCallUnsupported         = errors.New("function calls not supported by this version of Go")
+	errFuncCallUnsupportedBackend  = errors.New("backend does not support function calls")
+	errFuncCallInProgress          = errors.New("cannot call function while another function call is already in progress")
+	errNoGoroutine                 = errors.New("no goroutine selected")
+	errGoroutineNotRunning         = errors.New("selected goroutine not running")
+	errNotEnoughStack              = errors.New("not enough stack space")
+	errTooManyArguments            = errors.New("too many arguments")
+	errNotEnoughArguments          = errors.New("not enough arguments")
+	errNotAGoFunction              = errors.New("not a Go function")
+	errFuncCallNotAllowed          = errors.New("function calls not allowed without using 'call'")
+	errFuncCallNotAllowedStrAlloc  = errors.New("literal string can not be allocated because function calls are not allowed without using 'call'")
+	errFuncCallNotAllowedLitAlloc  = errors.New("composite literals and make can not be allocated because function calls are not allowed without using 'call'")
+	errFuncCallNotAllowedConvAlloc = errors.New("converted slice can not be allocated because function calls are not allowed without using 'call'")
+	errFuncCallNotAllowedMapMod    = errors.New("maps can not be modified because function calls are not allowed without using 'call'")
+	errFuncCallNotAllowedAppend    = errors.New("append can not be evaluated because function calls are not allowed without using 'call'")
 )
 
 type functionCallState struct {
@@ -79,6 +83,10 @@ type functionCallState struct {
 	closureAddr uint64
 	// formalArgs are the formal arguments of fn
 	formalArgs []funcCallArg
+	// variadic is true if the last formal argument of fn is a slice that
+	// can receive the trailing actual arguments of the call, see
+	// funcCallVariadicArg.
+	variadic bool
 	// argFrameSize contains the size of the arguments
 	argFrameSize int64
 	// retvars contains the return variables after the function call terminates without panic'ing
@@ -89,6 +97,10 @@ type functionCallState struct {
 	// lateCallFailure is set to true if the function call could not be
 	// completed after we started evaluating the arguments.
 	lateCallFailure bool
+	// actualArgs, if not nil, are the values of the arguments of the call,
+	// computed by the debugger, used instead of evaluating expr.Args. It is
+	// used to call runtime functions, see funcCallRuntime.
+	actualArgs []*Variable
 }
 
 type callContext struct {
@@ -150,6 +162,12 @@ func (callCtx *callContext) doReturn(ret *Variable, err error) {
 // Because this can only be done in the current goroutine, unlike
 // EvalExpression, EvalExpressionWithCalls is not a method of EvalScope.
 func EvalExpressionWithCalls(t *Target, g *G, expr string, retLoadCfg LoadConfig, checkEscape bool) error {
+	return evalExpressionWithCalls(t, g, expr, nil, retLoadCfg, checkEscape)
+}
+
+// evalExpressionWithCalls is like EvalExpressionWithCalls, if receiver is
+// not nil its fields and methods are in scope, see EvalScope.receiver.
+func evalExpressionWithCalls(t *Target, g *G, expr string, receiver *Variable, retLoadCfg LoadConfig, checkEscape bool) error {
 	bi := t.BinInfo()
 	if !t.SupportsFunctionCalls() {
 		return errFuncCallUnsupportedBackend
@@ -180,6 +198,7 @@ func EvalExpressionWithCalls(t *Target, g *G, expr string, retLoadCfg LoadConfig
 	continueRequest := make(chan continueRequest)
 	continueCompleted := make(chan *G)
 
+	scope.receiver = receiver
 	scope.callCtx = &callContext{
 		p:                 t,
 		checkEscape:       checkEscape,
@@ -249,6 +268,13 @@ func evalFunctionCall(scope *EvalScope, node *ast.CallExpr) (*Variable, error) {
 		// it was a builtin call
 		return r, err
 	}
+	return injectFunctionCall(scope, node, nil)
+}
+
+// injectFunctionCall calls the function node.Fun using the function call
+// injection protocol, see evalFunctionCall. If actualArgs is not nil it
+// contains the values of the arguments and node.Args is not used.
+func injectFunctionCall(scope *EvalScope, node *ast.CallExpr, actualArgs []*Variable) (*Variable, error) {
 	if scope.callCtx == nil {
 		return nil, errFuncCallNotAllowed
 	}
@@ -302,8 +328,9 @@ func evalFunctionCall(scope *EvalScope, node *ast.CallExpr) (*Variable, error) {
 	}
 
 	fncall := functionCallState{
-		expr:      node,
-		savedRegs: regs,
+		expr:       node,
+		savedRegs:  regs,
+		actualArgs: actualArgs,
 	}
 
 	err = funcCallEvalFuncExpr(scope, &fncall, false)
@@ -461,7 +488,7 @@ func funcCallEvalFuncExpr(scope *EvalScope, fncall *functionCallState, allowCall
 	if fnvar.Kind != reflect.Func {
 		return fmt.Errorf("expression %q is not a function", exprToString(fncall.expr.Fun))
 	}
-	fnvar.loadValue(LoadConfig{false, 0, 0, 0, 0, 0})
+	fnvar.loadValue(LoadConfig{})
 	if fnvar.Unreadable != nil {
 		return fnvar.Unreadable
 	}
@@ -483,18 +510,36 @@ func funcCallEvalFuncExpr(scope *EvalScope, fncall *functionCallState, allowCall
 	}
 
 	argnum := len(fncall.expr.Args)
+	if fncall.actualArgs != nil {
+		argnum = len(fncall.actualArgs)
+	}
+
+	// DWARF does not record whether a function is variadic, a call is
+	// treated as a call to a variadic function if the last formal argument
+	// is a slice and the call doesn't use the '...' syntax, funcCallEvalArgs
+	// decides whether the actual arguments need to be packed into a new
+	// slice.
+	fncall.variadic = false
+	if n := len(fncall.formalArgs); n > 0 && !fncall.expr.Ellipsis.IsValid() && fncall.actualArgs == nil {
+		_, fncall.variadic = fncall.formalArgs[n-1].typ.(*godwarf.SliceType)
+	}
 
 	// If the function variable has a child then that child is the method
 	// receiver. However, if the method receiver is not being used (e.g.
 	// func (_ X) Foo()) then it will not actually be listed as a formal
 	// argument. Ensure that we are really off by 1 to add the receiver to
 	// the function call.
-	if len(fnvar.Children) > 0 && argnum == (len(fncall.formalArgs)-1) {
+	// The receiver of a variadic method is always assumed to be a formal
+	// argument.
+	if len(fnvar.Children) > 0 && (argnum == (len(fncall.formalArgs)-1) || fncall.variadic) {
 		argnum++
 		fncall.receiver = &fnvar.Children[0]
 		fncall.receiver.Name = exprToString(fncall.expr.Fun)
 	}
 
+	if fncall.variadic && argnum >= len(fncall.formalArgs)-1 {
+		return nil
+	}
 	if argnum > len(fncall.formalArgs) {
 		return errTooManyArguments
 	}
@@ -532,11 +577,22 @@ func funcCallEvalArgs(scope *EvalScope, fncall *functionCallState, formalScope *
 	for i := range fncall.formalArgs {
 		formalArg := &fncall.formalArgs[i]
 
-		actualArg, err := scope.evalAST(fncall.expr.Args[i])
-		if err != nil {
-			return fmt.Errorf("error evaluating %q as argument %s in function %s: %v", exprToString(fncall.expr.Args[i]), formalArg.name, fncall.fn.Name, err)
+		var actualArg *Variable
+		var err error
+		if fncall.actualArgs != nil {
+			actualArg = fncall.actualArgs[i]
+		} else if fncall.variadic && i == len(fncall.formalArgs)-1 {
+			actualArg, err = funcCallVariadicArg(scope, fncall, formalArg, fncall.expr.Args[i:])
+			if err != nil {
+				return err
+			}
+		} else {
+			actualArg, err = scope.evalAST(fncall.expr.Args[i])
+			if err != nil {
+				return fmt.Errorf("error evaluating %q as argument %s in function %s: %v", exprToString(fncall.expr.Args[i]), formalArg.name, fncall.fn.Name, err)
+			}
+			actualArg.Name = exprToString(fncall.expr.Args[i])
 		}
-		actualArg.Name = exprToString(fncall.expr.Args[i])
 
 		err = funcCallCopyOneArg(scope, fncall, actualArg, formalArg, formalScope)
 		if err != nil {
@@ -547,8 +603,144 @@ func funcCallEvalArgs(scope *EvalScope, fncall *functionCallState, formalScope *
 	return nil
 }
 
-func funcCallCopyOneArg(scope *EvalScope, fncall *functionCallState, actualArg *Variable, formalArg *funcCallArg, formalScope *EvalScope) error {
+// funcCallVariadicArg evaluates args, the actual arguments corresponding to
+// formalArg, the variadic argument of the function being called, and
+// returns the slice that should be passed as formalArg.
+// If args is a single slice of the same type as formalArg it is passed
+// as is, otherwise a new backing array is allocated in the target and the
+// value of each argument is copied into it, values are boxed into
+// interfaces if the element type of formalArg is an interface.
+func funcCallVariadicArg(scope *EvalScope, fncall *functionCallState, formalArg *funcCallArg, args []ast.Expr) (*Variable, error) {
+	if len(args) == 0 {
+		return nilVariable, nil
+	}
+
+	actualArgs := make([]*Variable, len(args))
+	for i := range args {
+		actualArg, err := scope.evalAST(args[i])
+		if err != nil {
+			return nil, fmt.Errorf("error evaluating %q as argument %s in function %s: %v", exprToString(args[i]), formalArg.name, fncall.fn.Name, err)
+		}
+		actualArg.Name = exprToString(args[i])
+		actualArgs[i] = actualArg
+	}
+
+	if len(actualArgs) == 1 && actualArgs[0].Kind == reflect.Slice && sameType(formalArg.typ, actualArgs[0].RealType) {
+		return actualArgs[0], nil
+	}
+
 	if scope.callCtx.checkEscape {
+		for _, actualArg := range actualArgs {
+			if err := escapeCheck(actualArg, formalArg.name, scope.g.stack); err != nil {
+				return nil, fmt.Errorf("cannot use %s as argument %s in function %s: %v", actualArg.Name, formalArg.name, fncall.fn.Name, err)
+			}
+		}
+	}
+
+	bi := scope.BinInfo
+	elemType := resolveTypedef(formalArg.typ.(*godwarf.SliceType).ElemType)
+	base, err := funcCallAllocType(scope, elemType, int64(len(actualArgs)))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, actualArg := range actualArgs {
+		elemv := newVariable(fmt.Sprintf("%s[%d]", formalArg.name, i), base+uint64(int64(i)*elemType.Size()), elemType, bi, scope.Mem)
+		if err := scope.setValueBoxed(elemv, actualArg); err != nil {
+			return nil, fmt.Errorf("cannot use %s as argument %s in function %s: %v", actualArg.Name, formalArg.name, fncall.fn.Name, err)
+		}
+	}
+
+	slicev := newSliceVariable(formalArg.typ, base, int64(len(actualArgs)), int64(len(actualArgs)), bi, scope.Mem)
+	slicev.Name = formalArg.name
+	return slicev, nil
+}
+
+// funcCallBoxArg stores actualArg into the interface variable dstv, if
+// actualArg isn't pointer shaped its value is copied into a newly
+// allocated object first.
+// Untyped constants are converted to their default type.
+// Returns a *typeConvErr if dstv is not an empty interface.
+func funcCallBoxArg(scope *EvalScope, actualArg, dstv *Variable) error {
+	if dstv.RealType.String() != "interface {}" {
+		return &typeConvErr{actualArg.DwarfType, dstv.RealType}
+	}
+	typ := actualArg.DwarfType
+	if typ == nil {
+		if actualArg.Value == nil {
+			return fmt.Errorf("can not convert %s to interface {}", actualArg.Name)
+		}
+		var typename string
+		switch actualArg.Value.Kind() {
+		case constant.Bool:
+			typename = "bool"
+		case constant.String:
+			typename = "string"
+		case constant.Int:
+			typename = "int"
+		case constant.Float:
+			typename = "float64"
+		case constant.Complex:
+			typename = "complex128"
+		default:
+			return fmt.Errorf("can not convert %s to interface {}", actualArg.Name)
+		}
+		var err error
+		typ, err = scope.BinInfo.findType(typename)
+		if err != nil {
+			return err
+		}
+	}
+
+	typeAddr, typeKind, found, err := dwarfToRuntimeType(scope.BinInfo, scope.Mem, typ)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("could not find runtime type of %s", typ)
+	}
+	if typeKind&kindDirectIface != 0 && actualArg.DwarfType != nil {
+		return convertToEface(actualArg, dstv)
+	}
+
+	addr, err := funcCallAlloc(scope, typ.Size(), typeAddr)
+	if err != nil {
+		return err
+	}
+	boxv := newVariable("", addr, typ, scope.BinInfo, scope.Mem)
+	if err := scope.setValue(boxv, actualArg, actualArg.Name); err != nil {
+		return err
+	}
+
+	dstType, dstData, _ := dstv.readInterface()
+	if dstv.Unreadable != nil {
+		return dstv.Unreadable
+	}
+	if err := dstType.writeUint(typeAddr, dstType.RealType.Size()); err != nil {
+		return err
+	}
+	return dstData.writeUint(addr, dstData.RealType.Size())
+}
+
+// setValueBoxed stores srcv into dstv, like setValue, if dstv is an empty
+// interface and srcv is not an interface srcv is boxed into it, see
+// funcCallBoxArg.
+func (scope *EvalScope) setValueBoxed(dstv, srcv *Variable) error {
+	if dstv.Kind == reflect.Interface && srcv != nilVariable && srcv.Kind != reflect.Interface && srcv.isType(dstv.RealType, dstv.Kind) != nil {
+		if err := funcCallBoxArg(scope, srcv, dstv); err == nil {
+			return nil
+		} else if _, isTypeConvErr := err.(*typeConvErr); !isTypeConvErr {
+			return err
+		}
+	}
+	return scope.setValue(dstv, srcv, srcv.Name)
+}
+
+func funcCallCopyOneArg(scope *EvalScope, fncall *functionCallState, actualArg *Variable, formalArg *funcCallArg, formalScope *EvalScope) error {
+	// Arguments computed by the debugger are passed to runtime functions
+	// that do not retain them (for example the key passed to mapassign is
+	// copied into the map), they can point to the stack.
+	if scope.callCtx.checkEscape && fncall.actualArgs == nil {
 		//TODO(aarzilli): only apply the escapeCheck to leaking parameters.
 		if err := escapeCheck(actualArg, formalArg.name, scope.g.stack); err != nil {
 			return fmt.Errorf("cannot use %s as argument %s in function %s: %v", actualArg.Name, formalArg.name, fncall.fn.Name, err)
@@ -976,37 +1168,803 @@ func allocString(scope *EvalScope, v *Variable) error {
 	if scope.callCtx == nil {
 		return errFuncCallNotAllowedStrAlloc
 	}
+	base, err := funcCallAlloc(scope, v.Len, 0)
+	if err != nil {
+		return err
+	}
+	v.Base = base
+	_, err = scope.Mem.WriteMemory(v.Base, []byte(constant.StringVal(v.Value)))
+	return err
+}
+
+// allocConvertedSlice allocates space for the contents of v if it is a
+// []byte or []rune slice converted from a string by the debugger (see
+// evalStringConversion), whose elements only exist in the debugger.
+func allocConvertedSlice(scope *EvalScope, v *Variable) error {
+	if v.Flags&VariableFakeAddress == 0 || v.Len == 0 {
+		// already allocated
+		return nil
+	}
+
+	if scope.callCtx == nil {
+		return errFuncCallNotAllowedConvAlloc
+	}
+	base, err := funcCallAllocType(scope, v.RealType.(*godwarf.SliceType).ElemType, v.Len)
+	if err != nil {
+		return err
+	}
+	if err := writeConvertedSliceElems(scope.Mem, base, v); err != nil {
+		return err
+	}
+	v.Base = base
+	v.Cap = v.Len
+	v.Flags &^= VariableFakeAddress
+	return nil
+}
+
+// writeConvertedSliceElems writes the elements of v, a slice converted
+// from a string by the debugger, to the array at base.
+func writeConvertedSliceElems(mem MemoryReadWriter, base uint64, v *Variable) error {
+	size := v.RealType.(*godwarf.SliceType).ElemType.Size()
+	buf := make([]byte, int64(len(v.Children))*size)
+	for i := range v.Children {
+		n, _ := constant.Int64Val(v.Children[i].Value)
+		switch size {
+		case 1:
+			buf[i] = byte(n)
+		case 4:
+			binary.LittleEndian.PutUint32(buf[int64(i)*size:], uint32(n))
+		default:
+			return fmt.Errorf("unsupported element type %s", v.RealType.(*godwarf.SliceType).ElemType)
+		}
+	}
+	_, err := mem.WriteMemory(base, buf)
+	return err
+}
+
+// funcCallAlloc allocates size bytes in the target by calling
+// runtime.mallocgc. If typeAddr is not zero it is the address of the
+// runtime type of the objects stored in the allocated memory, which is
+// zeroed, otherwise the memory is neither zeroed nor scanned by the garbage
+// collector.
+func funcCallAlloc(scope *EvalScope, size int64, typeAddr uint64) (uint64, error) {
+	if scope.callCtx == nil {
+		return 0, errFuncCallNotAllowed
+	}
 	savedLoadCfg := scope.callCtx.retLoadCfg
 	scope.callCtx.retLoadCfg = loadFullValue
 	defer func() {
 		scope.callCtx.retLoadCfg = savedLoadCfg
 	}()
+	args := []ast.Expr{
+		&ast.BasicLit{Kind: token.INT, Value: strconv.FormatInt(size, 10)},
+		&ast.Ident{Name: "nil"},
+		&ast.Ident{Name: "false"},
+	}
+	if typeAddr != 0 {
+		// (*runtime._type)(typeAddr)
+		args[1] = &ast.CallExpr{
+			Fun: &ast.ParenExpr{X: &ast.StarExpr{X: &ast.SelectorExpr{
+				X:   &ast.Ident{Name: "runtime"},
+				Sel: &ast.Ident{Name: "_type"},
+			}}},
+			Args: []ast.Expr{&ast.BasicLit{Kind: token.INT, Value: fmt.Sprintf("%#x", typeAddr)}},
+		}
+		args[2] = &ast.Ident{Name: "true"}
+	}
 	mallocv, err := evalFunctionCall(scope, &ast.CallExpr{
 		Fun: &ast.SelectorExpr{
 			X:   &ast.Ident{Name: "runtime"},
 			Sel: &ast.Ident{Name: "mallocgc"},
 		},
-		Args: []ast.Expr{
-			&ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(int(v.Len))},
-			&ast.Ident{Name: "nil"},
-			&ast.Ident{Name: "false"},
-		},
+		Args: args,
 	})
 	if err != nil {
-		return err
+		return 0, err
 	}
 	if mallocv.Unreadable != nil {
-		return mallocv.Unreadable
+		return 0, mallocv.Unreadable
 	}
 	if mallocv.DwarfType.String() != "*void" {
-		return fmt.Errorf("unexpected return type for mallocgc call: %v", mallocv.DwarfType.String())
+		return 0, fmt.Errorf("unexpected return type for mallocgc call: %v", mallocv.DwarfType.String())
 	}
 	if len(mallocv.Children) != 1 {
-		return errors.New("internal error, could not interpret return value of mallocgc call")
+		return 0, errors.New("internal error, could not interpret return value of mallocgc call")
 	}
-	v.Base = mallocv.Children[0].Addr
-	_, err = scope.Mem.WriteMemory(v.Base, []byte(constant.StringVal(v.Value)))
-	return err
+	return mallocv.Children[0].Addr, nil
+}
+
+// funcCallAllocType allocates n zeroed values of type typ in the target,
+// see funcCallAlloc, and returns the address of the first one.
+func funcCallAllocType(scope *EvalScope, typ godwarf.Type, n int64) (uint64, error) {
+	typeAddr, _, found, err := dwarfToRuntimeType(scope.BinInfo, scope.Mem, typ)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, fmt.Errorf("could not find runtime type of %s", typ)
+	}
+	return funcCallAlloc(scope, typ.Size()*n, typeAddr)
+}
+
+// funcCallRuntime calls the runtime function runtime.fnname. The values of
+// its arguments are returned by mkargs, given its formal arguments,
+// because the signatures of runtime functions change between versions of
+// Go.
+func funcCallRuntime(scope *EvalScope, fnname string, mkargs func(formalArgs []funcCallArg) ([]*Variable, error)) (*Variable, error) {
+	if scope.callCtx == nil {
+		return nil, errFuncCallNotAllowed
+	}
+	fn := scope.BinInfo.LookupFunc["runtime."+fnname]
+	if fn == nil {
+		return nil, fmt.Errorf("could not find function runtime.%s", fnname)
+	}
+	_, formalArgs, err := funcCallArgs(fn, scope.BinInfo, false)
+	if err != nil {
+		return nil, err
+	}
+	args, err := mkargs(formalArgs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range args {
+		if args[i].Name == "" && i < len(formalArgs) {
+			args[i].Name = formalArgs[i].name
+		}
+	}
+	savedLoadCfg := scope.callCtx.retLoadCfg
+	scope.callCtx.retLoadCfg = loadFullValue
+	defer func() {
+		scope.callCtx.retLoadCfg = savedLoadCfg
+	}()
+	return injectFunctionCall(scope, &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   &ast.Ident{Name: "runtime"},
+			Sel: &ast.Ident{Name: fnname},
+		},
+	}, args)
+}
+
+// funcCallPtrArg returns a value of the pointer type typ, the type of a
+// formal argument of a runtime function, pointing to addr.
+func funcCallPtrArg(scope *EvalScope, typ godwarf.Type, addr uint64) (*Variable, error) {
+	ptyp, ok := resolveTypedef(typ).(*godwarf.PtrType)
+	if !ok {
+		return nil, fmt.Errorf("unexpected argument type %s", typ)
+	}
+	v := newVariable("", 0, typ, scope.BinInfo, scope.Mem)
+	v.loaded = true
+	v.Children = []Variable{*newVariable("", addr, ptyp.Type, scope.BinInfo, scope.Mem)}
+	v.Children[0].OnlyAddr = true
+	return v, nil
+}
+
+// funcCallRetPtr returns the value of retv, the unsafe.Pointer returned by
+// the runtime function fnname.
+func funcCallRetPtr(fnname string, retv *Variable) (uint64, error) {
+	if retv.Unreadable != nil {
+		return 0, retv.Unreadable
+	}
+	if retv.Kind != reflect.UnsafePointer || len(retv.Children) != 1 {
+		return 0, fmt.Errorf("internal error, could not interpret return value of %s call", fnname)
+	}
+	return retv.Children[0].Addr, nil
+}
+
+// mapRuntimeFunc returns the name of the runtime function implementing op,
+// "mapassign" or "mapdelete", for maps with keys of type keyType. The
+// generic function is used if the linker kept it, otherwise the one
+// specialized for the type of the key, that the compiler uses for it.
+func mapRuntimeFunc(bi *BinaryInfo, op string, keyType godwarf.Type) (string, error) {
+	if bi.LookupFunc["runtime."+op] != nil {
+		return op, nil
+	}
+	suffix := ""
+	size := keyType.Size()
+	switch resolveTypedef(keyType).(type) {
+	case *godwarf.StringType:
+		suffix = "_faststr"
+	case *godwarf.IntType, *godwarf.UintType:
+		if size == 4 || size == 8 {
+			suffix = fmt.Sprintf("_fast%d", size*8)
+		}
+	case *godwarf.PtrType, *godwarf.MapType, *godwarf.ChanType:
+		if size == 4 || size == 8 {
+			suffix = fmt.Sprintf("_fast%d", size*8)
+			if op == "mapassign" {
+				// mapdelete has no versions specialized for pointers
+				suffix += "ptr"
+			}
+		}
+	}
+	if suffix != "" && bi.LookupFunc["runtime."+op+suffix] != nil {
+		return op + suffix, nil
+	}
+	return "", fmt.Errorf("could not find runtime.%s for keys of type %s", op, keyType)
+}
+
+// mapRuntimeCall calls the runtime function implementing op, "mapassign"
+// or "mapdelete", for the key keyv of the map mapv and returns its result.
+func mapRuntimeCall(scope *EvalScope, op string, mapv, keyv *Variable) (*Variable, error) {
+	maptyp := mapv.RealType.(*godwarf.MapType)
+	fnname, err := mapRuntimeFunc(scope.BinInfo, op, maptyp.KeyType)
+	if err != nil {
+		return nil, err
+	}
+	typeAddr, _, found, err := dwarfToRuntimeType(scope.BinInfo, scope.Mem, mapv.DwarfType)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("could not find runtime type of %s", mapv.DwarfType)
+	}
+	if mapv.mapIterator() == nil {
+		return nil, fmt.Errorf("can not access unreadable map: %v", mapv.Unreadable)
+	}
+	hmapAddr := mapv.Base
+
+	// The generic functions take a pointer to the key and the specialized
+	// ones take the key by value, either way the key must be stored in the
+	// target with the key type of the map.
+	keyAddr := keyv.Addr
+	if keyAddr == 0 || keyv.Flags&VariableFakeAddress != 0 || keyv.DwarfType == nil || !sameType(keyv.DwarfType, maptyp.KeyType) {
+		keyAddr, err = funcCallAllocType(scope, maptyp.KeyType, 1)
+		if err != nil {
+			return nil, err
+		}
+		if err := scope.setValueBoxed(newVariable("", keyAddr, maptyp.KeyType, scope.BinInfo, scope.Mem), keyv); err != nil {
+			return nil, fmt.Errorf("cannot use %s as map key of type %s: %v", keyv.Name, maptyp.KeyType, err)
+		}
+	}
+
+	return funcCallRuntime(scope, fnname, func(formalArgs []funcCallArg) ([]*Variable, error) {
+		if len(formalArgs) != 3 {
+			return nil, fmt.Errorf("unexpected signature of runtime.%s", fnname)
+		}
+		typv, err := funcCallPtrArg(scope, formalArgs[0].typ, typeAddr)
+		if err != nil {
+			return nil, err
+		}
+		hv, err := funcCallPtrArg(scope, formalArgs[1].typ, hmapAddr)
+		if err != nil {
+			return nil, err
+		}
+		var kv *Variable
+		if fnname == op {
+			kv, err = funcCallPtrArg(scope, formalArgs[2].typ, keyAddr)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			kv = newVariable("", keyAddr, formalArgs[2].typ, scope.BinInfo, scope.Mem)
+		}
+		return []*Variable{typv, hv, kv}, nil
+	})
+}
+
+// growSlice allocates a new backing array for slicev, with a capacity of
+// at least newLen elements, by calling runtime.growslice, which also
+// copies the current elements. Returns the address and the capacity of
+// the new backing array.
+func growSlice(scope *EvalScope, slicev *Variable, newLen int64) (uint64, int64, error) {
+	elemType := slicev.RealType.(*godwarf.SliceType).ElemType
+	typeAddr, _, found, err := dwarfToRuntimeType(scope.BinInfo, scope.Mem, elemType)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !found {
+		return 0, 0, fmt.Errorf("could not find runtime type of %s", elemType)
+	}
+
+	retv, err := funcCallRuntime(scope, "growslice", func(formalArgs []funcCallArg) ([]*Variable, error) {
+		args := make([]*Variable, len(formalArgs))
+		for i, formalArg := range formalArgs {
+			var err error
+			switch formalArg.name {
+			case "et":
+				args[i], err = funcCallPtrArg(scope, formalArg.typ, typeAddr)
+			case "old":
+				// before Go 1.20: growslice(et *_type, old slice, cap int) slice
+				args[i], err = runtimeSliceArg(scope, formalArg.typ, slicev)
+			case "cap", "newLen":
+				args[i] = newConstant(constant.MakeInt64(newLen), scope.Mem)
+			case "oldPtr":
+				// since Go 1.20: growslice(oldPtr unsafe.Pointer, newLen, oldCap, num int, et *_type) slice
+				args[i], err = funcCallPtrArg(scope, formalArg.typ, slicev.Base)
+			case "oldCap":
+				args[i] = newConstant(constant.MakeInt64(slicev.Cap), scope.Mem)
+			case "num":
+				args[i] = newConstant(constant.MakeInt64(newLen-slicev.Len), scope.Mem)
+			default:
+				return nil, fmt.Errorf("unexpected argument %s of runtime.growslice", formalArg.name)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		return args, nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	if retv.Unreadable != nil {
+		return 0, 0, retv.Unreadable
+	}
+
+	// the return value is a runtime.slice
+	var base uint64
+	capacity := int64(-1)
+	for i := range retv.Children {
+		field := &retv.Children[i]
+		switch field.Name {
+		case "array":
+			if len(field.Children) == 1 {
+				base = field.Children[0].Addr
+			}
+		case "cap":
+			if field.Value != nil {
+				capacity, _ = constant.Int64Val(field.Value)
+			}
+		}
+	}
+	if base == 0 || capacity < newLen {
+		return 0, 0, errors.New("internal error, could not interpret return value of growslice call")
+	}
+	return base, capacity, nil
+}
+
+// runtimeSliceArg returns a value of type typ, runtime.slice, describing
+// the slice slicev.
+func runtimeSliceArg(scope *EvalScope, typ godwarf.Type, slicev *Variable) (*Variable, error) {
+	ptrSize := scope.BinInfo.Arch.PtrSize()
+	if typ.Size() != int64(3*ptrSize) {
+		return nil, fmt.Errorf("unexpected argument type %s", typ)
+	}
+	cmem, err := newCompositeMemory(scope.Mem, scope.BinInfo.Arch, op.DwarfRegisters{}, []op.Piece{
+		{Size: ptrSize, Kind: op.ImmPiece, Val: slicev.Base},
+		{Size: ptrSize, Kind: op.ImmPiece, Val: uint64(slicev.Len)},
+		{Size: ptrSize, Kind: op.ImmPiece, Val: uint64(slicev.Cap)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	cmem.base = fakeAddressUnresolv
+	v := newVariable("", cmem.base, typ, scope.BinInfo, cmem)
+	v.Flags |= VariableFakeAddress
+	return v, nil
+}
+
+// compositeLitType returns the type described by expr, the type of a
+// composite literal or the first argument of make.
+func (scope *EvalScope) compositeLitType(expr ast.Expr) (godwarf.Type, error) {
+	switch expr.(type) {
+	case *ast.MapType:
+		return nil, fmt.Errorf("can not create %s: maps are not supported", exprToString(expr))
+	case *ast.ChanType:
+		return nil, fmt.Errorf("can not create %s: channels are not supported", exprToString(expr))
+	}
+	typ, err := scope.BinInfo.findTypeExpr(expr)
+	if err == reader.TypeNotFoundErr {
+		if anode, ok := expr.(*ast.ArrayType); ok && anode.Len == nil {
+			// byte and rune are called uint8 and int32 in the debug info
+			switch exprToString(anode.Elt) {
+			case "byte":
+				typ, err = scope.BinInfo.findType("[]uint8")
+			case "rune":
+				typ, err = scope.BinInfo.findType("[]int32")
+			}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not find type %s: %v", exprToString(expr), err)
+	}
+	return typ, nil
+}
+
+// evalCompositeLit allocates a value of the type of node in the target and
+// initializes it with the elements of node. Struct, array and slice
+// literals are supported.
+func (scope *EvalScope) evalCompositeLit(node *ast.CompositeLit) (*Variable, error) {
+	if node.Type == nil {
+		return nil, fmt.Errorf("missing type in composite literal %s", exprToString(node))
+	}
+	typ, err := scope.compositeLitType(node.Type)
+	if err != nil {
+		return nil, err
+	}
+	return scope.compositeLit(node, typ)
+}
+
+// compositeLit allocates a value of type typ in the target and initializes
+// it with the elements of node, whose type may have been elided.
+func (scope *EvalScope) compositeLit(node *ast.CompositeLit, typ godwarf.Type) (*Variable, error) {
+	if scope.callCtx == nil {
+		return nil, errFuncCallNotAllowedLitAlloc
+	}
+	bi := scope.BinInfo
+
+	switch rtyp := resolveTypedef(typ).(type) {
+	case *godwarf.StructType:
+		addr, err := funcCallAllocType(scope, typ, 1)
+		if err != nil {
+			return nil, err
+		}
+		keyed := false
+		if len(node.Elts) > 0 {
+			_, keyed = node.Elts[0].(*ast.KeyValueExpr)
+		}
+		if !keyed && len(node.Elts) > 0 && len(node.Elts) != len(rtyp.Field) {
+			return nil, fmt.Errorf("wrong number of values in struct literal of type %s", typ)
+		}
+		for i, elt := range node.Elts {
+			kv, iskv := elt.(*ast.KeyValueExpr)
+			if iskv != keyed {
+				return nil, errors.New("mixture of field:value and value elements in struct literal")
+			}
+			var field *godwarf.StructField
+			if iskv {
+				ident, ok := kv.Key.(*ast.Ident)
+				if !ok {
+					return nil, fmt.Errorf("invalid field name %s in struct literal", exprToString(kv.Key))
+				}
+				for _, f := range rtyp.Field {
+					if f.Name == ident.Name {
+						field = f
+						break
+					}
+				}
+				if field == nil {
+					return nil, fmt.Errorf("unknown field %s in struct literal of type %s", ident.Name, typ)
+				}
+				elt = kv.Value
+			} else {
+				field = rtyp.Field[i]
+			}
+			fieldv := newVariable(field.Name, addr+uint64(field.ByteOffset), field.Type, bi, scope.Mem)
+			if err := scope.setCompositeLitElem(fieldv, elt); err != nil {
+				return nil, fmt.Errorf("cannot use %s as field %s of %s: %v", exprToString(elt), field.Name, typ, err)
+			}
+		}
+		return newVariable("", addr, typ, bi, scope.Mem), nil
+
+	case *godwarf.ArrayType:
+		idx, n, err := scope.compositeLitIndices(node)
+		if err != nil {
+			return nil, err
+		}
+		if n > rtyp.Count {
+			return nil, fmt.Errorf("index %d out of bounds for %s", n-1, typ)
+		}
+		addr, err := funcCallAllocType(scope, typ, 1)
+		if err != nil {
+			return nil, err
+		}
+		if err := scope.setCompositeLitElems(node, idx, addr, rtyp.Type, rtyp.StrideBitSize/8); err != nil {
+			return nil, err
+		}
+		return newVariable("", addr, typ, bi, scope.Mem), nil
+
+	case *godwarf.SliceType:
+		idx, n, err := scope.compositeLitIndices(node)
+		if err != nil {
+			return nil, err
+		}
+		base, err := funcCallAllocType(scope, rtyp.ElemType, n)
+		if err != nil {
+			return nil, err
+		}
+		if err := scope.setCompositeLitElems(node, idx, base, rtyp.ElemType, rtyp.ElemType.Size()); err != nil {
+			return nil, err
+		}
+		return newSliceVariable(typ, base, n, n, bi, scope.Mem), nil
+
+	case *godwarf.MapType:
+		return nil, fmt.Errorf("can not create %s: maps are not supported", typ)
+	case *godwarf.ChanType:
+		return nil, fmt.Errorf("can not create %s: channels are not supported", typ)
+	default:
+		return nil, fmt.Errorf("invalid composite literal type %s", typ)
+	}
+}
+
+// compositeLitIndices returns the index of each element of the array or
+// slice literal node and the length of the literal.
+func (scope *EvalScope) compositeLitIndices(node *ast.CompositeLit) ([]int64, int64, error) {
+	idx := make([]int64, len(node.Elts))
+	seen := make(map[int64]bool)
+	var i, n int64
+	for j, elt := range node.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			keyv, err := scope.evalAST(kv.Key)
+			if err != nil {
+				return nil, 0, err
+			}
+			if keyv.Value == nil || keyv.Value.Kind() != constant.Int {
+				return nil, 0, fmt.Errorf("index %s must be an integer constant", exprToString(kv.Key))
+			}
+			i, _ = constant.Int64Val(keyv.Value)
+			if i < 0 {
+				return nil, 0, fmt.Errorf("index %s must be non-negative", exprToString(kv.Key))
+			}
+		}
+		if seen[i] {
+			return nil, 0, fmt.Errorf("duplicate index %d in array or slice literal", i)
+		}
+		seen[i] = true
+		idx[j] = i
+		i++
+		if i > n {
+			n = i
+		}
+	}
+	return idx, n, nil
+}
+
+// setCompositeLitElems stores the elements of the array or slice literal
+// node into the array of elemType starting at base, the i-th element is
+// stored at index idx[i].
+func (scope *EvalScope) setCompositeLitElems(node *ast.CompositeLit, idx []int64, base uint64, elemType godwarf.Type, stride int64) error {
+	for i, elt := range node.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			elt = kv.Value
+		}
+		elemv := newVariable(fmt.Sprintf("[%d]", idx[i]), base+uint64(idx[i]*stride), elemType, scope.BinInfo, scope.Mem)
+		if err := scope.setCompositeLitElem(elemv, elt); err != nil {
+			return fmt.Errorf("cannot use %s as element %d: %v", exprToString(elt), idx[i], err)
+		}
+	}
+	return nil
+}
+
+// setCompositeLitElem evaluates expr, an element of a composite literal,
+// and stores it into dstv. Like in Go the type of a composite literal can be
+// elided if it is the type of dstv, or if dstv is a pointer to it, with or
+// without the & operator.
+func (scope *EvalScope) setCompositeLitElem(dstv *Variable, expr ast.Expr) error {
+	lit, isaddr := expr.(*ast.CompositeLit), false
+	if node, ok := expr.(*ast.UnaryExpr); ok && node.Op == token.AND {
+		lit, isaddr = node.X.(*ast.CompositeLit)
+	}
+
+	var v *Variable
+	var err error
+	if lit != nil && lit.Type == nil {
+		typ := dstv.DwarfType
+		ptyp, isptr := dstv.RealType.(*godwarf.PtrType)
+		if isptr {
+			typ = ptyp.Type
+		} else if isaddr {
+			return fmt.Errorf("invalid composite literal type %s", dstv.DwarfType)
+		}
+		v, err = scope.compositeLit(lit, typ)
+		if err == nil && isptr {
+			v = v.pointerToVariable()
+		}
+	} else {
+		v, err = scope.evalAST(expr)
+	}
+	if err != nil {
+		return err
+	}
+	v.Name = exprToString(expr)
+	return scope.setValueBoxed(dstv, v)
+}
+
+// makeBuiltin implements make for slices, the backing array is allocated
+// in the target.
+func (scope *EvalScope) makeBuiltin(node *ast.CallExpr) (*Variable, error) {
+	if len(node.Args) < 2 {
+		return nil, errors.New("not enough arguments to make")
+	}
+	if len(node.Args) > 3 {
+		return nil, errors.New("too many arguments to make")
+	}
+	typ, err := scope.compositeLitType(node.Args[0])
+	if err != nil {
+		return nil, err
+	}
+	var elemType godwarf.Type
+	switch rtyp := resolveTypedef(typ).(type) {
+	case *godwarf.SliceType:
+		elemType = rtyp.ElemType
+	case *godwarf.MapType:
+		return nil, fmt.Errorf("can not make %s: maps are not supported", typ)
+	case *godwarf.ChanType:
+		return nil, fmt.Errorf("can not make %s: channels are not supported", typ)
+	default:
+		return nil, fmt.Errorf("invalid argument %s for make", exprToString(node.Args[0]))
+	}
+	if scope.callCtx == nil {
+		return nil, errFuncCallNotAllowedLitAlloc
+	}
+
+	args := make([]int64, len(node.Args)-1)
+	for i, arg := range node.Args[1:] {
+		argv, err := scope.evalAST(arg)
+		if err != nil {
+			return nil, err
+		}
+		if args[i], err = builtinIntArg("make", argv, arg); err != nil {
+			return nil, err
+		}
+	}
+	n, capacity := args[0], args[0]
+	if len(args) > 1 {
+		capacity = args[1]
+		if capacity < n {
+			return nil, fmt.Errorf("len larger than cap in make(%s)", exprToString(node.Args[0]))
+		}
+	}
+
+	base, err := funcCallAllocType(scope, elemType, capacity)
+	if err != nil {
+		return nil, err
+	}
+	return newSliceVariable(typ, base, n, capacity, scope.BinInfo, scope.Mem), nil
+}
+
+// newSliceVariable returns a variable of the slice type typ with the given
+// backing array, length and capacity.
+func newSliceVariable(typ godwarf.Type, base uint64, n, capacity int64, bi *BinaryInfo, mem MemoryReadWriter) *Variable {
+	v := newVariable("", 0, typ, bi, mem)
+	v.Base = base
+	v.Len = n
+	v.Cap = capacity
+	v.loaded = true
+	return v
+}
+
+// appendBuiltin implements the append builtin. If the capacity of the
+// slice is not enough a new backing array is allocated by calling
+// runtime.growslice.
+func (scope *EvalScope) appendBuiltin(node *ast.CallExpr) (*Variable, error) {
+	if len(node.Args) < 1 {
+		return nil, errors.New("not enough arguments to append")
+	}
+	slicev, err := scope.evalAST(node.Args[0])
+	if err != nil {
+		return nil, err
+	}
+	if slicev.Kind != reflect.Slice {
+		return nil, fmt.Errorf("first argument to append must be a slice, got %s", exprToString(node.Args[0]))
+	}
+	if scope.callCtx == nil {
+		return nil, errFuncCallNotAllowedAppend
+	}
+	slicev.loadValue(loadSingleValue)
+	if slicev.Unreadable != nil {
+		return nil, slicev.Unreadable
+	}
+	elemType := slicev.RealType.(*godwarf.SliceType).ElemType
+
+	var elems []*Variable
+	if node.Ellipsis.IsValid() {
+		if len(node.Args) != 2 {
+			return nil, errors.New("can only use ... with final argument of append")
+		}
+		elems, err = scope.appendEllipsisArg(node.Args[1], elemType)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for _, arg := range node.Args[1:] {
+			argv, err := scope.evalAST(arg)
+			if err != nil {
+				return nil, err
+			}
+			argv.Name = exprToString(arg)
+			elems = append(elems, argv)
+		}
+	}
+
+	newLen := slicev.Len + int64(len(elems))
+	base, capacity := slicev.Base, slicev.Cap
+	if newLen > capacity {
+		base, capacity, err = growSlice(scope, slicev, newLen)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stride := elemType.Size()
+	for i, elem := range elems {
+		dstv := newVariable("", base+uint64((slicev.Len+int64(i))*stride), elemType, scope.BinInfo, scope.Mem)
+		if err := scope.setValueBoxed(dstv, elem); err != nil {
+			return nil, fmt.Errorf("cannot use %s as type %s in append: %v", elem.Name, elemType, err)
+		}
+	}
+	return newSliceVariable(slicev.DwarfType, base, newLen, capacity, scope.BinInfo, scope.Mem), nil
+}
+
+// appendEllipsisArg returns the elements of expr, the last argument of a
+// call to append using the '...' syntax, which is either a slice or, if
+// the element type is byte, a string.
+func (scope *EvalScope) appendEllipsisArg(expr ast.Expr, elemType godwarf.Type) ([]*Variable, error) {
+	argv, err := scope.evalAST(expr)
+	if err != nil {
+		return nil, err
+	}
+	switch argv.Kind {
+	case reflect.String:
+		if t, ok := resolveTypedef(elemType).(*godwarf.UintType); !ok || t.Size() != 1 {
+			return nil, fmt.Errorf("cannot use %s as type %s in append", exprToString(expr), elemType)
+		}
+		argv.loadValue(LoadConfig{MaxStringLen: int(argv.Len)})
+		if argv.Unreadable != nil {
+			return nil, argv.Unreadable
+		}
+		s := constant.StringVal(argv.Value)
+		elems := make([]*Variable, len(s))
+		for i := range elems {
+			elems[i] = newConstant(constant.MakeInt64(int64(s[i])), scope.Mem)
+		}
+		return elems, nil
+	case reflect.Slice:
+		argv.loadValue(loadSingleValue)
+		if argv.Unreadable != nil {
+			return nil, argv.Unreadable
+		}
+		elems := make([]*Variable, argv.Len)
+		if argv.Flags&VariableFakeAddress != 0 {
+			// converted from a string by the debugger, see evalStringConversion
+			for i := range elems {
+				elems[i] = &argv.Children[i]
+			}
+			return elems, nil
+		}
+		argElemType := argv.RealType.(*godwarf.SliceType).ElemType
+		mem := DereferenceMemory(argv.mem)
+		for i := range elems {
+			elems[i] = newVariable(fmt.Sprintf("%s[%d]", exprToString(expr), i), argv.Base+uint64(int64(i)*argElemType.Size()), argElemType, scope.BinInfo, mem)
+		}
+		return elems, nil
+	default:
+		return nil, fmt.Errorf("cannot use %s as type []%s in append", exprToString(expr), elemType)
+	}
+}
+
+// deleteBuiltin implements the delete builtin by calling runtime.mapdelete.
+func (scope *EvalScope) deleteBuiltin(node *ast.CallExpr) (*Variable, error) {
+	if len(node.Args) != 2 {
+		return nil, errors.New("wrong number of arguments to delete")
+	}
+	mapv, err := scope.evalAST(node.Args[0])
+	if err != nil {
+		return nil, err
+	}
+	if mapv.Kind != reflect.Map {
+		return nil, fmt.Errorf("first argument to delete must be a map, got %s", exprToString(node.Args[0]))
+	}
+	if scope.callCtx == nil {
+		return nil, errFuncCallNotAllowedMapMod
+	}
+	keyv, err := scope.evalAST(node.Args[1])
+	if err != nil {
+		return nil, err
+	}
+	keyv.Name = exprToString(node.Args[1])
+	return mapRuntimeCall(scope, "mapdelete", mapv, keyv)
+}
+
+// setMapElem assigns yv to the element of the map mapv with key keyExpr,
+// adding it to the map if it doesn't exist, by calling runtime.mapassign.
+func (scope *EvalScope) setMapElem(mapv *Variable, keyExpr ast.Expr, yv *Variable) error {
+	keyv, err := scope.evalAST(keyExpr)
+	if err != nil {
+		return err
+	}
+	keyv.Name = exprToString(keyExpr)
+
+	retv, err := mapRuntimeCall(scope, "mapassign", mapv, keyv)
+	if err != nil {
+		return err
+	}
+	elemAddr, err := funcCallRetPtr("mapassign", retv)
+	if err != nil {
+		return err
+	}
+	elemv := newVariable("", elemAddr, mapv.RealType.(*godwarf.MapType).ElemType, scope.BinInfo, scope.Mem)
+	return scope.setValueBoxed(elemv, yv)
 }
 
 func isCallInjectionStop(t *Target, thread Thread, loc *Location) bool {
@@ -0,0 +1,393 @@
+package proc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/constant"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
+)
+
+// formatterCallTimeout is the maximum amount of time the String method
+// called by a formatter is allowed to run.
+const formatterCallTimeout = 5 * time.Second
+
+// maxFormatterCalls is the maximum number of String methods called to
+// format the variables loaded by a single evaluation, each call resumes
+// the target.
+const maxFormatterCalls = 10
+
+// Formatter describes how the values of a type are rendered when they are
+// loaded, see Variable.Formatted.
+type Formatter struct {
+	// TypeName is the fully qualified name of the type, for example
+	// "github.com/some/package.Decimal".
+	TypeName string
+	// Format is a format string using the same syntax as the format of a
+	// logpoint: expressions between braces are evaluated with the fields and
+	// methods of the value in scope, function calls are not allowed.
+	Format string
+	// CallString is true if the value is rendered by calling its String
+	// method instead of using Format.
+	CallString bool
+
+	lp *Logpoint
+}
+
+// FormatterList is the list of formatters of a target.
+type FormatterList struct {
+	m map[string]*Formatter
+}
+
+// Add registers a formatter for the type called typeName, replacing the
+// formatter already registered for it, if any.
+func (l *FormatterList) Add(typeName, format string, callString bool) (*Formatter, error) {
+	if typeName == "" {
+		return nil, errors.New("empty type name")
+	}
+	f := &Formatter{TypeName: typeName, Format: format, CallString: callString}
+	if callString {
+		if format != "" {
+			return nil, errors.New("a formatter can not have both a format string and call the String method")
+		}
+	} else {
+		if format == "" {
+			return nil, errors.New("empty format string")
+		}
+		lp, err := ParseLogpoint(format)
+		if err != nil {
+			return nil, err
+		}
+		for _, expr := range lp.exprs {
+			if condHasCalls(expr) {
+				return nil, fmt.Errorf("function calls are not allowed in formatters: %s", exprToString(expr))
+			}
+		}
+		f.lp = lp
+	}
+	if l.m == nil {
+		l.m = make(map[string]*Formatter)
+	}
+	l.m[typeName] = f
+	return f, nil
+}
+
+// Remove deletes the formatter of the type called typeName.
+func (l *FormatterList) Remove(typeName string) error {
+	if _, ok := l.m[typeName]; !ok {
+		return fmt.Errorf("no formatter for type %s", typeName)
+	}
+	delete(l.m, typeName)
+	return nil
+}
+
+// Formatters returns the list of formatters, sorted by type name.
+func (l *FormatterList) Formatters() []*Formatter {
+	r := make([]*Formatter, 0, len(l.m))
+	for _, f := range l.m {
+		r = append(r, f)
+	}
+	sort.Slice(r, func(i, j int) bool { return r[i].TypeName < r[j].TypeName })
+	return r
+}
+
+// Empty returns true if there are no formatters.
+func (l *FormatterList) Empty() bool {
+	return len(l.m) == 0
+}
+
+// lookup returns the formatter for the type of v.
+func (l *FormatterList) lookup(v *Variable) *Formatter {
+	if len(l.m) == 0 || v.DwarfType == nil {
+		return nil
+	}
+	return l.m[v.DwarfType.Common().Name]
+}
+
+// Formatters returns the formatters of the target.
+func (t *Target) Formatters() *FormatterList {
+	return t.formatters
+}
+
+// SetFormatters replaces the formatters of the target, it is used to keep
+// the formatters of a debugging session when the target is restarted.
+func (t *Target) SetFormatters(l *FormatterList) {
+	t.formatters = l
+}
+
+// ApplyFormatters sets the Formatted field of vars, and of the variables
+// loaded as their children, that have a formatter.
+// If cfg.CallStringMethods is set the values without a formatter whose
+// type implements error or fmt.Stringer are formatted by calling their
+// Error or String method, if the call fails the value is left unformatted.
+// Methods are never called on recordings, core files and while a
+// breakpoint condition is evaluated.
+// Formatters that call the String method resume the target, the values of
+// vars must be loaded before calling ApplyFormatters.
+func (scope *EvalScope) ApplyFormatters(cfg LoadConfig, vars ...*Variable) {
+	if scope.target == nil || scope.callCtx != nil {
+		return
+	}
+	callStringMethods := cfg.CallStringMethods
+	if recorded, _ := scope.target.Recorded(); recorded || scope.target.condCallG != 0 {
+		callStringMethods = false
+	}
+	if scope.target.formatters.Empty() && !callStringMethods {
+		return
+	}
+	calls := 0
+	var visit func(v *Variable)
+	visit = func(v *Variable) {
+		if v.Unreadable != nil || v.OnlyAddr {
+			return
+		}
+		if f := scope.target.formatters.lookup(v); f != nil {
+			if f.CallString {
+				if calls < maxFormatterCalls {
+					calls++
+					s, err := scope.callStringMethod(v, "String")
+					if err != nil {
+						s = fmt.Sprintf("<error: %v>", err)
+					}
+					v.Formatted = s
+				} else {
+					v.Formatted = "<error: too many String calls>"
+				}
+			} else {
+				v.Formatted = scope.renderFormat(f.lp, v)
+			}
+		} else if callStringMethods && v.Formatted == "" && calls < maxFormatterCalls {
+			if mname := stringerMethod(v); mname != "" {
+				calls++
+				if s, err := scope.callStringMethod(v, mname); err == nil {
+					v.Formatted = s
+				}
+			}
+		}
+		if v.Formatted != "" {
+			return
+		}
+		for i := range v.Children {
+			visit(&v.Children[i])
+		}
+	}
+	for _, v := range vars {
+		visit(v)
+	}
+}
+
+// stringerMethod returns "Error" or "String" if v implements error or
+// fmt.Stringer respectively, using the methods described by the debug
+// symbols. Returns the empty string otherwise, or if v is a nil pointer or
+// a nil interface.
+func stringerMethod(v *Variable) string {
+	switch v.Kind {
+	case reflect.Ptr:
+		if len(v.Children) != 1 || v.Children[0].Addr == 0 {
+			return ""
+		}
+	case reflect.Interface:
+		if len(v.Children) != 1 || v.Children[0].Kind == reflect.Invalid {
+			return ""
+		}
+	}
+	for _, mname := range []string{"Error", "String"} {
+		fnvar, err := v.findMethod(mname)
+		if err != nil || fnvar == nil || fnvar.Kind != reflect.Func {
+			continue
+		}
+		fn := v.bi.PCToFunc(fnvar.Base)
+		if fn == nil {
+			continue
+		}
+		_, formalArgs, err := funcCallArgs(fn, v.bi, true)
+		if err != nil {
+			continue
+		}
+		nargs, nrets, retIsString := 0, 0, false
+		for _, arg := range formalArgs {
+			if arg.isret {
+				nrets++
+				_, retIsString = resolveTypedef(arg.typ).(*godwarf.StringType)
+			} else {
+				nargs++
+			}
+		}
+		// the receiver is the only argument
+		if nargs <= 1 && nrets == 1 && retIsString {
+			return mname
+		}
+	}
+	return ""
+}
+
+// renderFormat evaluates the expressions of lp with the fields and methods
+// of v in scope.
+func (scope *EvalScope) renderFormat(lp *Logpoint, v *Variable) string {
+	fscope := *scope
+	fscope.receiver = v
+	var buf bytes.Buffer
+	for i, expr := range lp.exprs {
+		buf.WriteString(lp.text[i])
+		fv, err := fscope.evalAST(expr)
+		if err != nil {
+			fmt.Fprintf(&buf, "<error: %v>", err)
+			continue
+		}
+		fv.loadValue(logpointLoadConfig)
+		formatValue(&buf, fv, true)
+	}
+	buf.WriteString(lp.text[len(lp.text)-1])
+	return buf.String()
+}
+
+// callStringMethod calls the method mname, a method without arguments
+// returning a string, of v in the goroutine of the scope and returns its
+// result.
+// While the method runs all breakpoints are ignored, when it returns the
+// goroutine and thread selected before the call, and the stop reason, are
+// restored.
+func (scope *EvalScope) callStringMethod(v *Variable, mname string) (string, error) {
+	t := scope.target
+	g := scope.g
+	if g == nil || g.Thread == nil {
+		return "", fmt.Errorf("%s can only be called on a goroutine running on a thread", mname)
+	}
+	if t.condCallG != 0 {
+		return "", fmt.Errorf("%s can not be called while a breakpoint condition is evaluated", mname)
+	}
+	if err := condCallSafePoint(t, g.Thread, g); err != nil {
+		return "", err
+	}
+
+	selg, curthread, stopReason := t.selectedGoroutine, t.currentThread, t.StopReason
+	t.condCallG = g.ID
+	defer func() {
+		t.condCallG = 0
+		t.selectedGoroutine, t.currentThread, t.StopReason = selg, curthread, stopReason
+	}()
+
+	var timedOut int32
+	timer := time.AfterFunc(formatterCallTimeout, func() {
+		atomic.StoreInt32(&timedOut, 1)
+		t.RequestManualStop()
+	})
+	err := evalExpressionWithCalls(t, g, mname+"()", v, loadSingleValue, true)
+	timer.Stop()
+
+	rets := g.Thread.Common().returnValues
+	g.Thread.Common().CallReturn = false
+	g.Thread.Common().returnValues = nil
+
+	switch {
+	case t.fncallForG[g.ID] != nil && atomic.LoadInt32(&timedOut) != 0:
+		return "", fmt.Errorf("%s did not return within %v", mname, formatterCallTimeout)
+	case t.fncallForG[g.ID] != nil:
+		return "", fmt.Errorf("%s call interrupted", mname)
+	case err != nil:
+		return "", err
+	case len(rets) != 1:
+		return "", fmt.Errorf("%s does not return a single value", mname)
+	}
+	ret := rets[0]
+	if ret.Name == "~panic" {
+		return "", fmt.Errorf("%s panicked", mname)
+	}
+	if ret.Kind != reflect.String {
+		return "", fmt.Errorf("%s does not return a string", mname)
+	}
+	var buf bytes.Buffer
+	formatValue(&buf, ret, true)
+	return buf.String(), nil
+}
+
+// formatValue writes a single line representation of v to buf. It is used
+// for values that must be rendered to text inside proc, like the output of
+// formatters, other values are converted by the api package.
+func formatValue(buf *bytes.Buffer, v *Variable, top bool) {
+	if v.Unreadable != nil {
+		fmt.Fprintf(buf, "(unreadable %v)", v.Unreadable)
+		return
+	}
+	switch v.Kind {
+	case reflect.String:
+		s := constant.StringVal(v.Value)
+		if top {
+			buf.WriteString(s)
+		} else {
+			fmt.Fprintf(buf, "%q", s)
+		}
+		if v.Len > int64(len(s)) {
+			fmt.Fprintf(buf, "...+%d more", v.Len-int64(len(s)))
+		}
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		if v.Value == nil {
+			buf.WriteString("?")
+			return
+		}
+		buf.WriteString(v.Value.String())
+	case reflect.Ptr:
+		if len(v.Children) == 0 || v.Children[0].Addr == 0 {
+			buf.WriteString("nil")
+			return
+		}
+		buf.WriteString("*")
+		formatValue(buf, &v.Children[0], false)
+	case reflect.Interface:
+		if len(v.Children) == 0 || v.Children[0].Kind == reflect.Invalid {
+			buf.WriteString("nil")
+			return
+		}
+		formatValue(buf, &v.Children[0], false)
+	case reflect.Slice, reflect.Array:
+		buf.WriteString("[")
+		for i := range v.Children {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			formatValue(buf, &v.Children[i], false)
+		}
+		if v.Len > int64(len(v.Children)) {
+			fmt.Fprintf(buf, ", ...+%d more", v.Len-int64(len(v.Children)))
+		}
+		buf.WriteString("]")
+	case reflect.Map:
+		buf.WriteString("map[")
+		for i := 0; i+1 < len(v.Children); i += 2 {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			formatValue(buf, &v.Children[i], false)
+			buf.WriteString(": ")
+			formatValue(buf, &v.Children[i+1], false)
+		}
+		if v.Len > int64(len(v.Children)/2) {
+			fmt.Fprintf(buf, ", ...+%d more", v.Len-int64(len(v.Children)/2))
+		}
+		buf.WriteString("]")
+	case reflect.Struct:
+		buf.WriteString(v.TypeString())
+		if len(v.Children) == 0 && v.Len > 0 {
+			buf.WriteString("{...}")
+			return
+		}
+		buf.WriteString("{")
+		for i := range v.Children {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			fmt.Fprintf(buf, "%s: ", v.Children[i].Name)
+			formatValue(buf, &v.Children[i], false)
+		}
+		buf.WriteString("}")
+	default:
+		fmt.Fprintf(buf, "%s(%#x)", v.TypeString(), v.Addr)
+	}
+}
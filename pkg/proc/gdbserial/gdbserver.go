@@ -72,6 +72,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -154,6 +155,17 @@ type gdbProcess struct {
 	threadStopInfo bool   // true if the stub supports qThreadStopInfo
 	tracedir       string // if attached to rr the path to the trace directory
 
+	// rrStart is the position of the start of the recording, rrEndEvent is
+	// the last event of the recording, zero until the end of the recording
+	// is reached for the first time.
+	rrStart    proc.RecordingPosition
+	rrEndEvent int64
+
+	passSignalsOk bool  // true if the stub supports QPassSignals
+	passSignals   []int // signals delivered to the target without stopping it
+
+	resumeThread *gdbThread // if not nil the only thread resumed by ContinueOnce
+
 	loadGInstrAddr uint64 // address of the g loading instruction, zero if we couldn't allocate it
 
 	breakpointKind int // breakpoint kind to pass to 'z' and 'Z' when creating software breakpoints
@@ -173,8 +185,9 @@ type gdbThread struct {
 	regs              gdbRegisters
 	CurrentBreakpoint proc.BreakpointState
 	p                 *gdbProcess
-	sig               uint8 // signal received by thread after last stop
-	setbp             bool  // thread was stopped because of a breakpoint
+	sig               uint8  // signal received by thread after last stop
+	setbp             bool   // thread was stopped because of a breakpoint
+	watchAddr         uint64 // address that triggered a watchpoint, if the thread was stopped by one
 	common            proc.CommonThread
 }
 
@@ -224,6 +237,7 @@ func newProcess(process *os.Process) *gdbProcess {
 			inbuf:               make([]byte, 0, initialInputBufferSize),
 			direction:           proc.Forward,
 			log:                 logger,
+			memcache:            new(memoryCache),
 		},
 		threads:        make(map[int]*gdbThread),
 		bi:             proc.NewBinaryInfo(runtime.GOOS, runtime.GOARCH),
@@ -337,6 +351,17 @@ func (p *gdbProcess) Connect(conn net.Conn, path string, pid int, debugInfoDirs
 		p.gcmdok = false
 	}
 
+	// Stubs that don't support QPassSignals will report every signal to us,
+	// we propagate them back to the target in handleThreadSignals.
+	passSignals := p.defaultPassSignals()
+	if err := p.conn.passSignals(passSignals); err == nil {
+		p.passSignalsOk = true
+		p.passSignals = passSignals
+	} else if _, isProt := err.(*GdbProtocolError); !isProt {
+		conn.Close()
+		return nil, err
+	}
+
 	tgt, err := p.initialize(path, debugInfoDirs, stopReason)
 	if err != nil {
 		return nil, err
@@ -798,6 +823,7 @@ func (p *gdbProcess) ContinueOnce() (proc.Thread, proc.StopReason, error) {
 
 	for _, th := range p.threads {
 		th.clearBreakpointState()
+		th.watchAddr = 0
 	}
 
 	p.setCtrlC(false)
@@ -809,12 +835,18 @@ func (p *gdbProcess) ContinueOnce() (proc.Thread, proc.StopReason, error) {
 	var atstart bool
 continueLoop:
 	for {
-		var err error
-		var sig uint8
 		tu.Reset()
-		threadID, sig, err = p.conn.resume(p.threads, &tu)
+		sp, err := p.conn.resume(p.threads, p.resumeThread, &tu)
+		threadID = sp.threadID
 		if err != nil {
 			if _, exited := err.(proc.ErrProcessExited); exited {
+				if p.tracedir != "" && p.conn.direction == proc.Forward {
+					// rr has no command returning the number of events in the
+					// recording, remember it once the end is reached.
+					if event, err := p.rrCounter(whenPrefix, "when"); err == nil {
+						p.rrEndEvent = event
+					}
+				}
 				p.exited = true
 				return nil, proc.StopExited, err
 			}
@@ -829,7 +861,8 @@ continueLoop:
 		if trapthread != nil && !p.threadStopInfo {
 			// For stubs that do not support qThreadStopInfo we manually set the
 			// reason the thread returned by resume() stopped.
-			trapthread.sig = sig
+			trapthread.sig = sp.sig
+			trapthread.watchAddr = sp.watchAddr
 		}
 
 		var shouldStop bool
@@ -1051,6 +1084,7 @@ func (p *gdbProcess) Restart(pos string) (proc.Thread, error) {
 
 	for _, th := range p.threads {
 		th.clearBreakpointState()
+		th.watchAddr = 0
 	}
 
 	p.setCtrlC(false)
@@ -1062,7 +1096,7 @@ func (p *gdbProcess) Restart(pos string) (proc.Thread, error) {
 
 	// for some reason we have to send a vCont;c after a vRun to make rr behave
 	// properly, because that's what gdb does.
-	_, _, err = p.conn.resume(nil, nil)
+	_, err = p.conn.resume(nil, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1074,8 +1108,11 @@ func (p *gdbProcess) Restart(pos string) (proc.Thread, error) {
 	p.clearThreadSignals()
 	p.clearThreadRegisters()
 
-	for addr := range p.breakpoints.M {
-		p.conn.setBreakpoint(addr, p.breakpointKind)
+	for _, bp := range p.breakpoints.M {
+		if bp.WatchType.Software() {
+			continue
+		}
+		p.conn.setBreakpoint(bp.Addr, breakpointTypeOf(bp), p.breakpointKindOf(bp))
 	}
 
 	return p.currentThread, p.setCurrentBreakpoints()
@@ -1094,6 +1131,147 @@ func (p *gdbProcess) When() (string, error) {
 	return strings.TrimSpace(event), nil
 }
 
+const (
+	whenPrefix      = "Current event: "
+	whenTicksPrefix = "Current tick: "
+)
+
+// Position returns the current recording position by parsing the output
+// of the 'when' and 'when-ticks' commands of the Mozilla RR backend.
+// The total number of events is only known after the end of the recording
+// has been reached once.
+func (p *gdbProcess) Position() (proc.RecordingPosition, error) {
+	if p.tracedir == "" {
+		return proc.RecordingPosition{}, proc.ErrNotRecorded
+	}
+	if p.exited && p.rrEndEvent != 0 {
+		return proc.RecordingPosition{Event: p.rrEndEvent, TotalEvents: p.rrEndEvent, AtEnd: true}, nil
+	}
+	pos, err := p.position()
+	if err != nil {
+		return proc.RecordingPosition{}, err
+	}
+	pos.TotalEvents = p.rrEndEvent
+	pos.AtStart = pos.Event == p.rrStart.Event && pos.Ticks == p.rrStart.Ticks
+	pos.AtEnd = p.exited
+	return pos, nil
+}
+
+func (p *gdbProcess) position() (proc.RecordingPosition, error) {
+	event, err := p.rrCounter(whenPrefix, "when")
+	if err != nil {
+		return proc.RecordingPosition{}, err
+	}
+	ticks, err := p.rrCounter(whenTicksPrefix, "when-ticks")
+	if err != nil {
+		return proc.RecordingPosition{}, err
+	}
+	return proc.RecordingPosition{Event: event, Ticks: ticks}, nil
+}
+
+// rrCounter executes cmd and parses its response, which must be prefix
+// followed by a number.
+func (p *gdbProcess) rrCounter(prefix, cmd string) (int64, error) {
+	resp, err := p.conn.qRRCmd(cmd)
+	if err != nil {
+		return 0, err
+	}
+	resp = strings.TrimSpace(resp)
+	if !strings.HasPrefix(resp, prefix) {
+		return 0, fmt.Errorf("can not parse %s response %q", cmd, resp)
+	}
+	n, err := strconv.ParseInt(resp[len(prefix):], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("can not parse %s response %q: %v", cmd, resp, err)
+	}
+	return n, nil
+}
+
+// SeekTicks moves the recording forward until the current thread reaches
+// the specified tick count, using the 'seek-ticks' command of the Mozilla
+// RR backend.
+func (p *gdbProcess) SeekTicks(ticks int64) (proc.Thread, error) {
+	if p.tracedir == "" {
+		return nil, proc.ErrNotRecorded
+	}
+
+	for _, th := range p.threads {
+		th.clearBreakpointState()
+	}
+
+	if _, err := p.conn.qRRCmd("seek-ticks", strconv.FormatInt(ticks, 10)); err != nil {
+		return nil, err
+	}
+
+	err := p.updateThreadList(&threadUpdater{p: p})
+	if err != nil {
+		return nil, err
+	}
+	p.clearThreadSignals()
+	p.clearThreadRegisters()
+
+	return p.currentThread, p.setCurrentBreakpoints()
+}
+
+// defaultPassSignals returns the signals passed through to the target by
+// default: SIGURG, which the Go runtime uses for asynchronous preemption.
+func (p *gdbProcess) defaultPassSignals() []int {
+	// Mozilla RR uses gdb's signal numbers, debugserver and lldb-server use
+	// the numbers of the host operating system.
+	if p.tracedir == "" && runtime.GOOS == "linux" {
+		return []int{0x17}
+	}
+	return []int{0x10}
+}
+
+// PassSignals returns the signals that the stub delivers to the target
+// without stopping it.
+func (p *gdbProcess) PassSignals() proc.SignalPassing {
+	return proc.SignalPassing{Signals: p.passSignals, Configurable: p.passSignalsOk}
+}
+
+// SetResumeThread restricts ContinueOnce to resuming the thread tid using
+// a 'vCont' command with a 'c' action for that thread only. Not supported
+// by rr, which can only replay all threads together.
+func (p *gdbProcess) SetResumeThread(tid int) error {
+	if p.tracedir != "" {
+		return proc.ErrResumeThreadUnsupported
+	}
+	if tid == 0 {
+		p.resumeThread = nil
+		return nil
+	}
+	th, ok := p.threads[tid]
+	if !ok {
+		return fmt.Errorf("unknown thread %d", tid)
+	}
+	p.resumeThread = th
+	return nil
+}
+
+// SetPassSignals changes the signals that the stub delivers to the target
+// without stopping it, using the 'QPassSignals' command.
+func (p *gdbProcess) SetPassSignals(sigs []int) error {
+	if !p.passSignalsOk {
+		return proc.ErrPassSignalsUnsupported
+	}
+	sigs = append([]int(nil), sigs...)
+	sort.Ints(sigs)
+	for i, sig := range sigs {
+		if sig <= 0 || sig > 0xff {
+			return fmt.Errorf("invalid signal %d", sig)
+		}
+		if i > 0 && sigs[i-1] == sig {
+			return fmt.Errorf("duplicate signal %d", sig)
+		}
+	}
+	if err := p.conn.passSignals(sigs); err != nil {
+		return err
+	}
+	p.passSignals = sigs
+	return nil
+}
+
 const (
 	checkpointPrefix = "Checkpoint "
 )
@@ -1149,7 +1327,8 @@ func (p *gdbProcess) Checkpoints() ([]proc.Checkpoint, error) {
 		if err != nil {
 			return nil, fmt.Errorf("can not parse \"info checkpoints\" output line %q: %v", line, err)
 		}
-		r = append(r, proc.Checkpoint{ID: cpid, When: fields[1], Where: fields[2]})
+		event, _ := strconv.ParseInt(fields[1], 10, 64)
+		r = append(r, proc.Checkpoint{ID: cpid, When: fields[1], Where: fields[2], Event: event})
 	}
 	return r, nil
 }
@@ -1211,15 +1390,55 @@ func (p *gdbProcess) FindBreakpoint(pc uint64) (*proc.Breakpoint, bool) {
 	return nil, false
 }
 
+// findWatchpoint returns the hardware watchpoint covering addr.
+func (p *gdbProcess) findWatchpoint(addr uint64) *proc.Breakpoint {
+	for _, bp := range p.breakpoints.M {
+		if bp.WatchType == 0 || bp.WatchType.Software() || bp.WatchType.Execute() {
+			continue
+		}
+		if addr >= bp.Addr && addr < bp.Addr+uint64(bp.WatchType.Size()) {
+			return bp
+		}
+	}
+	return nil
+}
+
 func (p *gdbProcess) WriteBreakpoint(bp *proc.Breakpoint) error {
-	if bp.WatchType != 0 {
-		return errors.New("hardware breakpoints not supported")
+	err := p.conn.setBreakpoint(bp.Addr, breakpointTypeOf(bp), p.breakpointKindOf(bp))
+	if bp.WatchType != 0 && !bp.WatchType.Execute() && isProtocolErrorUnsupported(err) {
+		// proc will fall back to a software watchpoint.
+		return proc.ErrHWBreakUnsupported
 	}
-	return p.conn.setBreakpoint(bp.Addr, p.breakpointKind)
+	return err
 }
 
 func (p *gdbProcess) EraseBreakpoint(bp *proc.Breakpoint) error {
-	return p.conn.clearBreakpoint(bp.Addr, p.breakpointKind)
+	return p.conn.clearBreakpoint(bp.Addr, breakpointTypeOf(bp), p.breakpointKindOf(bp))
+}
+
+// breakpointTypeOf returns the type of breakpoint used to implement bp.
+func breakpointTypeOf(bp *proc.Breakpoint) breakpointType {
+	switch {
+	case bp.WatchType == 0 || bp.WatchType.Software():
+		return swBreakpoint
+	case bp.WatchType.Execute():
+		return hwBreakpoint
+	case bp.WatchType.Read() && bp.WatchType.Write():
+		return accessWatchpoint
+	case bp.WatchType.Read():
+		return readWatchpoint
+	default:
+		return writeWatchpoint
+	}
+}
+
+// breakpointKindOf returns the kind to pass to 'z' and 'Z' for bp, for
+// watchpoints this is the number of bytes watched.
+func (p *gdbProcess) breakpointKindOf(bp *proc.Breakpoint) int {
+	if bp.WatchType != 0 && !bp.WatchType.Execute() {
+		return bp.WatchType.Size()
+	}
+	return p.breakpointKind
 }
 
 type threadUpdater struct {
@@ -1311,7 +1530,7 @@ func (p *gdbProcess) updateThreadList(tu *threadUpdater) error {
 
 	for _, th := range p.threads {
 		if p.threadStopInfo {
-			sig, reason, err := p.conn.threadStopInfo(th.strID)
+			sp, err := p.conn.threadStopInfo(th.strID)
 			if err != nil {
 				if isProtocolErrorUnsupported(err) {
 					p.threadStopInfo = false
@@ -1319,10 +1538,12 @@ func (p *gdbProcess) updateThreadList(tu *threadUpdater) error {
 				}
 				return err
 			}
-			th.setbp = (reason == "breakpoint" || (reason == "" && sig == breakpointSignal))
-			th.sig = sig
+			th.setbp = (sp.reason == "breakpoint" || sp.reason == "watchpoint" || (sp.reason == "" && sp.sig == breakpointSignal))
+			th.sig = sp.sig
+			th.watchAddr = sp.watchAddr
 		} else {
 			th.sig = 0
+			th.watchAddr = 0
 		}
 	}
 
@@ -1339,6 +1560,7 @@ func (p *gdbProcess) clearThreadRegisters() {
 func (p *gdbProcess) clearThreadSignals() {
 	for _, th := range p.threads {
 		th.sig = 0
+		th.watchAddr = 0
 	}
 }
 
@@ -1439,12 +1661,12 @@ func (t *gdbThread) Common() *proc.CommonThread {
 // StepInstruction will step exactly 1 CPU instruction.
 func (t *gdbThread) StepInstruction() error {
 	pc := t.regs.PC()
-	if _, atbp := t.p.breakpoints.M[pc]; atbp {
-		err := t.p.conn.clearBreakpoint(pc, t.p.breakpointKind)
+	if bp, atbp := t.p.breakpoints.M[pc]; atbp {
+		err := t.p.conn.clearBreakpoint(pc, breakpointTypeOf(bp), t.p.breakpointKindOf(bp))
 		if err != nil {
 			return err
 		}
-		defer t.p.conn.setBreakpoint(pc, t.p.breakpointKind)
+		defer t.p.conn.setBreakpoint(pc, breakpointTypeOf(bp), t.p.breakpointKindOf(bp))
 	}
 	// Reset thread registers so the next call to
 	// Thread.Registers will not be cached.
@@ -1664,13 +1886,13 @@ func (t *gdbThread) reloadGAtPC() error {
 	// around by clearing and re-setting the breakpoint in a specific sequence
 	// with the memory writes.
 	// Additionally all breakpoints in [pc, pc+len(movinstr)] need to be removed
-	for addr := range t.p.breakpoints.M {
+	for addr, bp := range t.p.breakpoints.M {
 		if addr >= pc && addr <= pc+uint64(len(movinstr)) {
-			err := t.p.conn.clearBreakpoint(addr, t.p.breakpointKind)
+			err := t.p.conn.clearBreakpoint(addr, breakpointTypeOf(bp), t.p.breakpointKindOf(bp))
 			if err != nil {
 				return err
 			}
-			defer t.p.conn.setBreakpoint(addr, t.p.breakpointKind)
+			defer t.p.conn.setBreakpoint(addr, breakpointTypeOf(bp), t.p.breakpointKindOf(bp))
 		}
 	}
 
@@ -1782,6 +2004,14 @@ func (t *gdbThread) SetCurrentBreakpoint(adjustPC bool) error {
 	// adjustPC is ignored, it is the stub's responsibiility to set the PC
 	// address correctly after hitting a breakpoint.
 	t.clearBreakpointState()
+	if t.watchAddr != 0 {
+		// The stub reported the address that triggered a watchpoint, the PC
+		// of the thread points after the instruction that accessed it.
+		if bp := t.p.findWatchpoint(t.watchAddr); bp != nil {
+			t.CurrentBreakpoint = bp.CheckCondition(t)
+			return nil
+		}
+	}
 	regs, err := t.Registers()
 	if err != nil {
 		return err
@@ -1870,9 +2100,11 @@ func (t *gdbThread) SetReg(regNum uint64, reg *op.DwarfRegister) error {
 		return fmt.Errorf("could not set register %s: not found", regName)
 	}
 	reg.FillBytes()
-	if len(reg.Bytes) != len(gdbreg.value) {
+	if len(reg.Bytes) > len(gdbreg.value) {
 		return fmt.Errorf("could not set register %s: wrong size, expected %d got %d", regName, len(gdbreg.value), len(reg.Bytes))
 	}
+	// Values smaller than the register (for example a XMM value written to a
+	// YMM register) only change the least significant bytes.
 	copy(gdbreg.value, reg.Bytes)
 	return t.p.conn.writeRegister(t.strID, gdbreg.regnum, gdbreg.value)
 }
@@ -1888,6 +2120,16 @@ func (regs *gdbRegisters) Slice(floatingPoint bool) ([]proc.Register, error) {
 			r = proc.AppendBytesRegister(r, "Rflags", regs.regs[reginfo.Name].value)
 		case reginfo.Name == "mxcsr":
 			r = proc.AppendBytesRegister(r, reginfo.Name, regs.regs[reginfo.Name].value)
+		case reginfo.Bitsize == 64 && regs.arch.Name == "amd64" && isAMD64OpmaskRegister(reginfo.Name):
+			// AVX-512 opmask registers
+			if floatingPoint {
+				r = proc.AppendBytesRegister(r, strings.ToUpper(reginfo.Name), regs.regs[reginfo.Name].value)
+			}
+		case regs.arch.Name == "arm64" && isARM64SveRegister(reginfo.Name):
+			// SVE registers, their size depends on the vector length
+			if floatingPoint {
+				r = proc.AppendBytesRegister(r, strings.ToUpper(reginfo.Name), regs.regs[reginfo.Name].value)
+			}
 		case reginfo.Bitsize == 16:
 			r = proc.AppendBytesRegister(r, reginfo.Name, regs.regs[reginfo.Name].value)
 		case reginfo.Bitsize == 32:
@@ -1938,6 +2180,22 @@ func (regs *gdbRegisters) Slice(floatingPoint bool) ([]proc.Register, error) {
 	return r, nil
 }
 
+func isAMD64OpmaskRegister(name string) bool {
+	return len(name) == 2 && (name[0] == 'k' || name[0] == 'K') && name[1] >= '0' && name[1] <= '7'
+}
+
+func isARM64SveRegister(name string) bool {
+	name = strings.ToLower(name)
+	switch {
+	case name == "vg" || name == "ffr":
+		return true
+	case len(name) > 1 && (name[0] == 'z' || name[0] == 'p'):
+		_, err := strconv.Atoi(name[1:])
+		return err == nil
+	}
+	return false
+}
+
 func (regs *gdbRegisters) Copy() (proc.Registers, error) {
 	savedRegs := &gdbRegisters{}
 	savedRegs.init(regs.regsInfo, regs.arch, regs.regnames)
@@ -46,6 +46,8 @@ type gdbConn struct {
 	isDebugserver         bool // true if the stub is debugserver
 	xcmdok                bool // x command can be used to transfer memory
 
+	memcache *memoryCache // caches memory reads, nil if disabled
+
 	log *logrus.Entry
 }
 
@@ -404,18 +406,34 @@ func (conn *gdbConn) qXfer(kind, annex string, binary bool) ([]byte, error) {
 	return out, nil
 }
 
-// setBreakpoint executes a 'Z' (insert breakpoint) command of type '0' and kind '1' or '4'
-func (conn *gdbConn) setBreakpoint(addr uint64, kind int) error {
+// breakpointType is the type of a breakpoint set with the 'Z' command.
+type breakpointType uint8
+
+const (
+	swBreakpoint     breakpointType = 0 // software breakpoint
+	hwBreakpoint     breakpointType = 1 // hardware execute breakpoint
+	writeWatchpoint  breakpointType = 2 // write watchpoint
+	readWatchpoint   breakpointType = 3 // read watchpoint
+	accessWatchpoint breakpointType = 4 // access (read or write) watchpoint
+)
+
+// setBreakpoint executes a 'Z' (insert breakpoint) command, kind is either
+// the size of the breakpoint instruction or the number of bytes watched
+func (conn *gdbConn) setBreakpoint(addr uint64, typ breakpointType, kind int) error {
+	conn.memcache.clear()
 	conn.outbuf.Reset()
-	fmt.Fprintf(&conn.outbuf, "$Z0,%x,%d", addr, kind)
+	fmt.Fprintf(&conn.outbuf, "$Z%d,%x,%d", typ, addr, kind)
 	_, err := conn.exec(conn.outbuf.Bytes(), "set breakpoint")
 	return err
 }
 
-// clearBreakpoint executes a 'z' (remove breakpoint) command of type '0' and kind '1' or '4'
-func (conn *gdbConn) clearBreakpoint(addr uint64, kind int) error {
+// clearBreakpoint executes a 'z' (remove breakpoint) command, kind is
+// either the size of the breakpoint instruction or the number of bytes
+// watched
+func (conn *gdbConn) clearBreakpoint(addr uint64, typ breakpointType, kind int) error {
+	conn.memcache.clear()
 	conn.outbuf.Reset()
-	fmt.Fprintf(&conn.outbuf, "$z0,%x,%d", addr, kind)
+	fmt.Fprintf(&conn.outbuf, "$z%d,%x,%d", typ, addr, kind)
 	_, err := conn.exec(conn.outbuf.Bytes(), "clear breakpoint")
 	return err
 }
@@ -505,6 +523,12 @@ func (conn *gdbConn) readRegister(threadID string, regnum int, data []byte) erro
 		return err
 	}
 
+	if len(resp) > 2*len(data) {
+		// The size of some registers (for example the SVE registers on ARM64)
+		// can change while the target is running.
+		return fmt.Errorf("register %d: unexpected size %d (expected %d)", regnum, len(resp)/2, len(data))
+	}
+
 	for i := 0; i < len(resp); i += 2 {
 		n, _ := strconv.ParseUint(string(resp[i:i+2]), 16, 8)
 		data[i/2] = uint8(n)
@@ -537,19 +561,29 @@ func (conn *gdbConn) writeRegister(threadID string, regnum int, data []byte) err
 // resume each thread. If a thread has sig == 0 the 'c' action will be used,
 // otherwise the 'C' action will be used and the value of sig will be passed
 // to it.
-func (conn *gdbConn) resume(threads map[int]*gdbThread, tu *threadUpdater) (string, uint8, error) {
+// If only is not nil only that thread is resumed, the other threads are
+// left stopped.
+func (conn *gdbConn) resume(threads map[int]*gdbThread, only *gdbThread, tu *threadUpdater) (stopPacket, error) {
+	conn.memcache.clear()
 	if conn.direction == proc.Forward {
 		conn.outbuf.Reset()
 		fmt.Fprintf(&conn.outbuf, "$vCont")
-		for _, th := range threads {
-			if th.sig != 0 {
-				fmt.Fprintf(&conn.outbuf, ";C%02x:%s", th.sig, th.strID)
+		switch {
+		case only == nil:
+			for _, th := range threads {
+				if th.sig != 0 {
+					fmt.Fprintf(&conn.outbuf, ";C%02x:%s", th.sig, th.strID)
+				}
 			}
+			fmt.Fprintf(&conn.outbuf, ";c")
+		case only.sig != 0:
+			fmt.Fprintf(&conn.outbuf, ";C%02x:%s", only.sig, only.strID)
+		default:
+			fmt.Fprintf(&conn.outbuf, ";c:%s", only.strID)
 		}
-		fmt.Fprintf(&conn.outbuf, ";c")
 	} else {
 		if err := conn.selectThread('c', "p-1.-1", "resume"); err != nil {
-			return "", 0, err
+			return stopPacket{}, err
 		}
 		conn.outbuf.Reset()
 		fmt.Fprint(&conn.outbuf, "$bc")
@@ -557,7 +591,7 @@ func (conn *gdbConn) resume(threads map[int]*gdbThread, tu *threadUpdater) (stri
 	conn.manualStopMutex.Lock()
 	if err := conn.send(conn.outbuf.Bytes()); err != nil {
 		conn.manualStopMutex.Unlock()
-		return "", 0, err
+		return stopPacket{}, err
 	}
 	conn.running = true
 	conn.manualStopMutex.Unlock()
@@ -575,6 +609,7 @@ func (conn *gdbConn) resume(threads map[int]*gdbThread, tu *threadUpdater) (stri
 
 // step executes a 'vCont' command on the specified thread with 's' action.
 func (conn *gdbConn) step(threadID string, tu *threadUpdater, ignoreFaultSignal bool) error {
+	conn.memcache.clear()
 	if conn.direction != proc.Forward {
 		if err := conn.selectThread('c', threadID, "step"); err != nil {
 			return err
@@ -584,7 +619,7 @@ func (conn *gdbConn) step(threadID string, tu *threadUpdater, ignoreFaultSignal
 		if err := conn.send(conn.outbuf.Bytes()); err != nil {
 			return err
 		}
-		_, _, err := conn.waitForvContStop("singlestep", threadID, tu)
+		_, err := conn.waitForvContStop("singlestep", threadID, tu)
 		return err
 	}
 	var sig uint8 = 0
@@ -601,11 +636,11 @@ func (conn *gdbConn) step(threadID string, tu *threadUpdater, ignoreFaultSignal
 		if tu != nil {
 			tu.Reset()
 		}
-		var err error
-		_, sig, err = conn.waitForvContStop("singlestep", threadID, tu)
+		sp, err := conn.waitForvContStop("singlestep", threadID, tu)
 		if err != nil {
 			return err
 		}
+		sig = sp.sig
 		switch sig {
 		case faultSignal:
 			if ignoreFaultSignal { // we attempting to read the TLS, a fault here should be ignored
@@ -626,7 +661,7 @@ func (conn *gdbConn) step(threadID string, tu *threadUpdater, ignoreFaultSignal
 
 var errThreadBlocked = errors.New("thread blocked")
 
-func (conn *gdbConn) waitForvContStop(context string, threadID string, tu *threadUpdater) (string, uint8, error) {
+func (conn *gdbConn) waitForvContStop(context string, threadID string, tu *threadUpdater) (stopPacket, error) {
 	count := 0
 	failed := false
 	for {
@@ -647,22 +682,23 @@ func (conn *gdbConn) waitForvContStop(context string, threadID string, tu *threa
 			}
 			count++
 		} else if failed {
-			return "", 0, errThreadBlocked
+			return stopPacket{}, errThreadBlocked
 		} else if err != nil {
-			return "", 0, err
+			return stopPacket{}, err
 		} else {
 			repeat, sp, err := conn.parseStopPacket(resp, threadID, tu)
 			if !repeat {
-				return sp.threadID, sp.sig, err
+				return sp, err
 			}
 		}
 	}
 }
 
 type stopPacket struct {
-	threadID string
-	sig      uint8
-	reason   string
+	threadID  string
+	sig       uint8
+	reason    string
+	watchAddr uint64 // address that triggered a watchpoint
 }
 
 // executes 'vCont' (continue/step) command
@@ -683,6 +719,8 @@ func (conn *gdbConn) parseStopPacket(resp []byte, threadID string, tu *threadUpd
 			conn.log.Debugf("full stop packet: %s", string(resp))
 		}
 
+		var descrWatchAddr uint64
+
 		buf := resp[3:]
 		for buf != nil {
 			colon := bytes.Index(buf, []byte{':'})
@@ -712,9 +750,27 @@ func (conn *gdbConn) parseStopPacket(resp []byte, threadID string, tu *threadUpd
 				}
 			case "reason":
 				sp.reason = string(value)
+			case "watch", "rwatch", "awatch":
+				// gdbserver and rr
+				sp.watchAddr, _ = strconv.ParseUint(string(value), 16, 64)
+			case "description":
+				// debugserver and lldb-server describe the watchpoint hit with
+				// hex encoded text: "<address> <index> ...".
+				if len(value)%2 != 0 {
+					break
+				}
+				if desc, ok := decodeHexString(value); ok {
+					if fields := strings.Fields(desc); len(fields) > 0 {
+						descrWatchAddr, _ = strconv.ParseUint(fields[0], 0, 64)
+					}
+				}
 			}
 		}
 
+		if sp.reason == "watchpoint" && sp.watchAddr == 0 {
+			sp.watchAddr = descrWatchAddr
+		}
+
 		return false, sp, nil
 
 	case 'W', 'X':
@@ -871,7 +927,78 @@ func (conn *gdbConn) appendThreadSelector(threadID string) {
 	fmt.Fprintf(&conn.outbuf, ";thread:%s;", threadID)
 }
 
+const (
+	memoryCacheBlockSize = 4096 // size and alignment of the blocks read by memoryCache, must be a power of two
+	memoryCacheMaxBlocks = 16   // reads spanning more blocks than this bypass the cache
+)
+
+// memoryCache caches the memory of the target while it is stopped. Memory
+// is read from the stub in aligned blocks of memoryCacheBlockSize bytes and
+// adjacent missing blocks are read with a single request, so that the many
+// small reads done while loading a variable only cost a few round trips.
+// The cache is cleared every time the target is resumed, its memory is
+// written or a breakpoint is changed.
+type memoryCache struct {
+	blocks map[uint64][]byte
+}
+
+func (c *memoryCache) clear() {
+	if c != nil {
+		c.blocks = nil
+	}
+}
+
+// readMemory reads len(data) bytes of memory at addr, through the memory
+// cache if it is enabled.
 func (conn *gdbConn) readMemory(data []byte, addr uint64) error {
+	c := conn.memcache
+	if c == nil || len(data) == 0 {
+		return conn.readMemoryUncached(data, addr)
+	}
+	const blockMask = memoryCacheBlockSize - 1
+	end := addr + uint64(len(data))
+	if end < addr || end > ^uint64(blockMask) {
+		return conn.readMemoryUncached(data, addr)
+	}
+	first, last := addr&^blockMask, (end-1)&^blockMask
+	if (last-first)/memoryCacheBlockSize >= memoryCacheMaxBlocks {
+		return conn.readMemoryUncached(data, addr)
+	}
+	if c.blocks == nil {
+		c.blocks = make(map[uint64][]byte)
+	}
+
+	for blk := first; blk <= last; {
+		if c.blocks[blk] != nil {
+			blk += memoryCacheBlockSize
+			continue
+		}
+		runEnd := blk + memoryCacheBlockSize
+		for runEnd <= last && c.blocks[runEnd] == nil {
+			runEnd += memoryCacheBlockSize
+		}
+		buf := make([]byte, runEnd-blk)
+		if err := conn.readMemoryUncached(buf, blk); err != nil {
+			// Part of the blocks could be unreadable, read only what was
+			// requested.
+			return conn.readMemoryUncached(data, addr)
+		}
+		for b := blk; b < runEnd; b += memoryCacheBlockSize {
+			c.blocks[b] = buf[b-blk : b-blk+memoryCacheBlockSize]
+		}
+		blk = runEnd
+	}
+
+	for n := 0; n < len(data); {
+		a := addr + uint64(n)
+		blk := a &^ blockMask
+		n += copy(data[n:], c.blocks[blk][a-blk:])
+	}
+	return nil
+}
+
+// readMemoryUncached reads len(data) bytes of memory at addr from the stub.
+func (conn *gdbConn) readMemoryUncached(data []byte, addr uint64) error {
 	if conn.xcmdok && len(data) > conn.packetSize {
 		return conn.readMemoryBinary(data, addr)
 	}
@@ -942,6 +1069,7 @@ func (conn *gdbConn) writeMemory(addr uint64, data []byte) (written int, err err
 		// LLDB can't parse requests for 0-length writes and hangs if we emit them
 		return 0, nil
 	}
+	conn.memcache.clear()
 	conn.outbuf.Reset()
 	//TODO(aarzilli): do not send packets larger than conn.PacketSize
 	fmt.Fprintf(&conn.outbuf, "$M%x,%x:", addr, len(data))
@@ -967,22 +1095,35 @@ func (conn *gdbConn) allocMemory(sz uint64) (uint64, error) {
 
 // threadStopInfo executes a 'qThreadStopInfo' and returns the reason the
 // thread stopped.
-func (conn *gdbConn) threadStopInfo(threadID string) (sig uint8, reason string, err error) {
+func (conn *gdbConn) threadStopInfo(threadID string) (stopPacket, error) {
 	conn.outbuf.Reset()
 	fmt.Fprintf(&conn.outbuf, "$qThreadStopInfo%s", threadID)
 	resp, err := conn.exec(conn.outbuf.Bytes(), "thread stop info")
 	if err != nil {
-		return 0, "", err
+		return stopPacket{}, err
 	}
 	_, sp, err := conn.parseStopPacket(resp, "", nil)
-	if err != nil {
-		return 0, "", err
+	return sp, err
+}
+
+// passSignals executes a 'QPassSignals' command, the stub will deliver the
+// specified signals directly to the target without reporting them.
+func (conn *gdbConn) passSignals(sigs []int) error {
+	conn.outbuf.Reset()
+	fmt.Fprint(&conn.outbuf, "$QPassSignals:")
+	for i, sig := range sigs {
+		if i > 0 {
+			fmt.Fprint(&conn.outbuf, ";")
+		}
+		fmt.Fprintf(&conn.outbuf, "%x", sig)
 	}
-	return sp.sig, sp.reason, nil
+	_, err := conn.exec(conn.outbuf.Bytes(), "pass signals")
+	return err
 }
 
 // restart executes a 'vRun' command.
 func (conn *gdbConn) restart(pos string) error {
+	conn.memcache.clear()
 	conn.outbuf.Reset()
 	fmt.Fprint(&conn.outbuf, "$vRun;")
 	if pos != "" {
@@ -998,6 +1139,7 @@ func (conn *gdbConn) qRRCmd(args ...string) (string, error) {
 	if len(args) == 0 {
 		panic("must specify at least one argument for qRRCmd")
 	}
+	conn.memcache.clear()
 	conn.outbuf.Reset()
 	fmt.Fprint(&conn.outbuf, "$qRRCmd")
 	for _, arg := range args {
@@ -0,0 +1,183 @@
+package gdbserial
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-delve/delve/pkg/logflags"
+)
+
+// fakeStub is a stub that only serves memory reads and writes, every
+// request is answered after the specified latency.
+type fakeStub struct {
+	conn    net.Conn
+	base    uint64
+	mem     []byte
+	latency time.Duration
+
+	mu    sync.Mutex
+	reads int // number of memory read requests received
+}
+
+func (stub *fakeStub) serve() {
+	rdr := bufio.NewReader(stub.conn)
+	for {
+		pkt, err := rdr.ReadBytes('#')
+		if err != nil {
+			return
+		}
+		if _, err := io.ReadFull(rdr, make([]byte, 2)); err != nil {
+			return
+		}
+		resp := stub.handle(string(pkt[1 : len(pkt)-1]))
+		time.Sleep(stub.latency)
+		fmt.Fprintf(stub.conn, "$%s#%02x", resp, checksum([]byte("$"+resp)))
+	}
+}
+
+func (stub *fakeStub) handle(req string) string {
+	if len(req) == 0 || (req[0] != 'm' && req[0] != 'M') {
+		return ""
+	}
+	v := strings.SplitN(req[1:], ",", 2)
+	addr, _ := strconv.ParseUint(v[0], 16, 64)
+	v = strings.SplitN(v[1], ":", 2)
+	sz, _ := strconv.ParseUint(v[0], 16, 64)
+	if addr < stub.base || addr+sz > stub.base+uint64(len(stub.mem)) {
+		return "E01"
+	}
+	mem := stub.mem[addr-stub.base : addr-stub.base+sz]
+	if req[0] == 'M' {
+		for i := range mem {
+			n, _ := strconv.ParseUint(v[1][i*2:i*2+2], 16, 8)
+			mem[i] = byte(n)
+		}
+		return "OK"
+	}
+	stub.mu.Lock()
+	stub.reads++
+	stub.mu.Unlock()
+	var buf bytes.Buffer
+	writeAsciiBytes(&buf, mem)
+	return buf.String()
+}
+
+func (stub *fakeStub) readCount() int {
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+	return stub.reads
+}
+
+func newFakeStubConn(stub *fakeStub, cached bool) *gdbConn {
+	client, server := net.Pipe()
+	stub.conn = server
+	go stub.serve()
+	conn := &gdbConn{
+		conn:       client,
+		rdr:        bufio.NewReader(client),
+		inbuf:      make([]byte, 0, initialInputBufferSize),
+		packetSize: 0x4000,
+		log:        logflags.GdbWireLogger(),
+	}
+	if cached {
+		conn.memcache = new(memoryCache)
+	}
+	return conn
+}
+
+func newFakeStub(base uint64, size int, latency time.Duration) *fakeStub {
+	mem := make([]byte, size)
+	rand.New(rand.NewSource(0)).Read(mem)
+	return &fakeStub{base: base, mem: mem, latency: latency}
+}
+
+func TestMemoryCache(t *testing.T) {
+	const base = 0x10000
+	stub := newFakeStub(base, 0x40000, 0)
+	conn := newFakeStubConn(stub, true)
+	defer conn.conn.Close()
+
+	read := func(addr uint64, sz int, expReads int) {
+		t.Helper()
+		data := make([]byte, sz)
+		if err := conn.readMemory(data, addr); err != nil {
+			t.Fatalf("reading %#x: %v", addr, err)
+		}
+		if !bytes.Equal(data, stub.mem[addr-base:addr-base+uint64(sz)]) {
+			t.Fatalf("wrong data read at %#x", addr)
+		}
+		if n := stub.readCount(); n != expReads {
+			t.Fatalf("wrong number of reads after reading %#x: %d (expected %d)", addr, n, expReads)
+		}
+	}
+
+	read(base+10, 8, 1)
+	read(base+100, 16, 1)                                                               // same block
+	read(base+memoryCacheBlockSize-8, 16, 2)                                            // next block is missing
+	read(base+3*memoryCacheBlockSize, 8, 3)                                             // two blocks are read with a single request
+	read(base+memoryCacheBlockSize/2, 3*memoryCacheBlockSize, 4)                        // reads the missing block
+	read(base+memoryCacheBlockSize*20, memoryCacheBlockSize*memoryCacheMaxBlocks+1, 13) // split in 9 requests by readMemoryHex
+	read(base+memoryCacheBlockSize*20, 8, 14)                                           // the previous read was not cached
+
+	if _, err := conn.writeMemory(base+100, []byte{1, 2, 3, 4}); err != nil {
+		t.Fatal(err)
+	}
+	read(base+100, 16, 15)
+
+	if err := conn.readMemory(make([]byte, 16), base-8); err == nil {
+		t.Fatalf("reading unmapped memory did not fail")
+	}
+	read(base-8+memoryCacheBlockSize, 16, 16) // the failed read did not cache anything
+}
+
+// BenchmarkLoadVariableLatency simulates the memory reads done to load a
+// []string with 64 elements over a connection with 100µs of latency.
+func BenchmarkLoadVariableLatency(b *testing.B) {
+	const (
+		base    = 0x10000
+		n       = 64
+		arrOff  = 0x100
+		dataOff = 0x2000
+	)
+	load := func(conn *gdbConn) {
+		conn.memcache.clear()
+		hdr := make([]byte, 24)
+		arr := make([]byte, n*16)
+		str := make([]byte, 20)
+		if err := conn.readMemory(hdr, base); err != nil {
+			b.Fatal(err)
+		}
+		if err := conn.readMemory(arr, base+arrOff); err != nil {
+			b.Fatal(err)
+		}
+		for i := 0; i < n; i++ {
+			if err := conn.readMemory(str, base+dataOff+uint64(i)*40); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	for _, cached := range []bool{false, true} {
+		name := "uncached"
+		if cached {
+			name = "cached"
+		}
+		b.Run(name, func(b *testing.B) {
+			stub := newFakeStub(base, 0x10000, 100*time.Microsecond)
+			conn := newFakeStubConn(stub, cached)
+			defer conn.conn.Close()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				load(conn)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"syscall"
@@ -17,11 +18,101 @@ import (
 	"github.com/go-delve/delve/pkg/proc"
 )
 
+// RecordOptions are the options used to record a program with rr.
+type RecordOptions struct {
+	// Chaos enables rr's chaos mode, which randomizes scheduling decisions
+	// to make scheduling-dependent bugs more likely to happen.
+	Chaos bool
+	// NumCores is the number of cores reported to the recorded program,
+	// zero uses rr's default.
+	NumCores int
+	// OutputTraceDir is the directory where the trace is saved, it must not
+	// exist. If it is empty rr picks a directory and the trace is deleted
+	// when the debugger detaches from it.
+	OutputTraceDir string
+	// ExtraArgs are passed to 'rr record' verbatim.
+	ExtraArgs []string
+}
+
+// Validate returns an error if opts can not be used to record a program.
+func (opts *RecordOptions) Validate() error {
+	if opts.NumCores < 0 {
+		return fmt.Errorf("invalid number of cores %d", opts.NumCores)
+	}
+	if opts.OutputTraceDir != "" {
+		if _, err := os.Stat(opts.OutputTraceDir); err == nil {
+			return fmt.Errorf("trace directory %s already exists", opts.OutputTraceDir)
+		}
+	}
+	for _, arg := range opts.ExtraArgs {
+		opt := arg
+		if i := strings.Index(opt, "="); i >= 0 {
+			opt = opt[:i]
+		}
+		switch opt {
+		case "--print-trace-dir":
+			return fmt.Errorf("rr argument %s can not be specified", arg)
+		case "-h", "--chaos":
+			if opts.Chaos {
+				return fmt.Errorf("rr argument %s conflicts with chaos mode", arg)
+			}
+		case "--num-cores":
+			if opts.NumCores != 0 {
+				return fmt.Errorf("rr argument %s conflicts with the number of cores", arg)
+			}
+		case "-o", "--output-trace-dir":
+			// the trace directory must be known to decide whether it should be
+			// deleted on detach.
+			return fmt.Errorf("rr argument %s can not be specified, set the trace directory instead", arg)
+		}
+	}
+	return nil
+}
+
+// Rerecord returns the options used to record the program again after it
+// was recorded with opts. The trace directory of the previous recording
+// already exists and is kept, so the new trace is saved in the first
+// numbered subdirectory of it (1, 2, ...) that does not exist.
+func (opts RecordOptions) Rerecord() RecordOptions {
+	if opts.OutputTraceDir == "" {
+		return opts
+	}
+	if _, err := os.Stat(opts.OutputTraceDir); err != nil {
+		// the previous recording did not create the trace directory
+		return opts
+	}
+	for n := 1; ; n++ {
+		dir := filepath.Join(opts.OutputTraceDir, strconv.Itoa(n))
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			opts.OutputTraceDir = dir
+			return opts
+		}
+	}
+}
+
+// args returns the arguments for 'rr record' corresponding to opts.
+func (opts *RecordOptions) args() []string {
+	var args []string
+	if opts.Chaos {
+		args = append(args, "--chaos")
+	}
+	if opts.NumCores > 0 {
+		args = append(args, "--num-cores="+strconv.Itoa(opts.NumCores))
+	}
+	if opts.OutputTraceDir != "" {
+		args = append(args, "--output-trace-dir="+opts.OutputTraceDir)
+	}
+	return append(args, opts.ExtraArgs...)
+}
+
 // RecordAsync configures rr to record the execution of the specified
 // program. Returns a run function which will actually record the program, a
 // stop function which will prematurely terminate the recording of the
 // program.
-func RecordAsync(cmd []string, wd string, quiet bool, redirects [3]string) (run func() (string, error), stop func() error, err error) {
+func RecordAsync(cmd []string, wd string, quiet bool, redirects [3]string, opts RecordOptions) (run func() (string, error), stop func() error, err error) {
+	if err := opts.Validate(); err != nil {
+		return nil, nil, err
+	}
 	if err := checkRRAvailable(); err != nil {
 		return nil, nil, err
 	}
@@ -31,8 +122,10 @@ func RecordAsync(cmd []string, wd string, quiet bool, redirects [3]string) (run
 		return nil, nil, err
 	}
 
-	args := make([]string, 0, len(cmd)+2)
+	optargs := opts.args()
+	args := make([]string, 0, len(cmd)+len(optargs)+2)
 	args = append(args, "record", "--print-trace-dir=3")
+	args = append(args, optargs...)
 	args = append(args, cmd...)
 	rrcmd := exec.Command("rr", args...)
 	var closefn func()
@@ -113,8 +206,8 @@ func openRedirects(redirects [3]string, quiet bool) (stdin, stdout, stderr *os.F
 
 // Record uses rr to record the execution of the specified program and
 // returns the trace directory's path.
-func Record(cmd []string, wd string, quiet bool, redirects [3]string) (tracedir string, err error) {
-	run, _, err := RecordAsync(cmd, wd, quiet, redirects)
+func Record(cmd []string, wd string, quiet bool, redirects [3]string, opts RecordOptions) (tracedir string, err error) {
+	run, _, err := RecordAsync(cmd, wd, quiet, redirects, opts)
 	if err != nil {
 		return "", err
 	}
@@ -123,14 +216,107 @@ func Record(cmd []string, wd string, quiet bool, redirects [3]string) (tracedir
 	return run()
 }
 
+// RecordedProcess is a process recorded in a rr trace.
+type RecordedProcess struct {
+	// Pid is the pid of the process during the recording.
+	Pid int
+	// Ppid is the pid of the parent process, zero for the root process of
+	// the trace.
+	Ppid int
+	// Exit is the exit status of the process, as reported by rr.
+	Exit string
+	// Cmdline is the command line of the process, processes that did not
+	// call exec after forking are reported as "(forked without exec)".
+	Cmdline string
+}
+
+// RecordedProcesses returns the list of processes recorded in the
+// specified trace directory, using 'rr ps'.
+func RecordedProcesses(tracedir string) ([]RecordedProcess, error) {
+	if err := checkRRAvailable(); err != nil {
+		return nil, err
+	}
+	out, err := exec.Command("rr", "ps", tracedir).Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not list processes of trace %s: %v", tracedir, err)
+	}
+	return rrParsePs(string(out))
+}
+
+// rrParsePs parses the output of 'rr ps', which is a header line followed
+// by one line per process: pid, parent pid ("--" for the root process),
+// exit status and command line, separated by tabs.
+func rrParsePs(out string) ([]RecordedProcess, error) {
+	var r []RecordedProcess
+	for i, line := range strings.Split(out, "\n") {
+		if i == 0 || line == "" {
+			continue
+		}
+		fields := strings.SplitN(line, "\t", 4)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("malformed rr ps line %q", line)
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("malformed rr ps line %q: %v", line, err)
+		}
+		ppid, _ := strconv.Atoi(fields[1])
+		r = append(r, RecordedProcess{Pid: pid, Ppid: ppid, Exit: fields[2], Cmdline: fields[3]})
+	}
+	return r, nil
+}
+
+// findRecordedProcess returns the process of procs selected by sel, which
+// is either a pid or a regular expression matched against the command line
+// of the processes. If the regular expression matches more than one
+// process the first one is returned.
+func findRecordedProcess(procs []RecordedProcess, sel string) (RecordedProcess, error) {
+	if pid, err := strconv.Atoi(sel); err == nil {
+		for _, rp := range procs {
+			if rp.Pid == pid {
+				return rp, nil
+			}
+		}
+		return RecordedProcess{}, fmt.Errorf("no process with pid %d in the trace", pid)
+	}
+	re, err := regexp.Compile(sel)
+	if err != nil {
+		return RecordedProcess{}, fmt.Errorf("invalid process selector %q: %v", sel, err)
+	}
+	for _, rp := range procs {
+		if re.MatchString(rp.Cmdline) {
+			return rp, nil
+		}
+	}
+	return RecordedProcess{}, fmt.Errorf("no process matching %q in the trace", sel)
+}
+
 // Replay starts an instance of rr in replay mode, with the specified trace
 // directory, and connects to it.
-func Replay(tracedir string, quiet, deleteOnDetach bool, debugInfoDirs []string) (*proc.Target, error) {
+// If onProcess is not empty it selects the process to debug, either by
+// its pid or by a regular expression matched against its command line
+// (see RecordedProcesses), otherwise the root process of the trace is
+// debugged.
+func Replay(tracedir string, quiet, deleteOnDetach bool, debugInfoDirs []string, onProcess string) (*proc.Target, error) {
 	if err := checkRRAvailable(); err != nil {
 		return nil, err
 	}
 
-	rrcmd := exec.Command("rr", "replay", "--dbgport=0", tracedir)
+	args := []string{"replay", "--dbgport=0"}
+	if onProcess != "" {
+		procs, err := RecordedProcesses(tracedir)
+		if err != nil {
+			return nil, err
+		}
+		rp, err := findRecordedProcess(procs, onProcess)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--onprocess="+strconv.Itoa(rp.Pid))
+	}
+	args = append(args, tracedir)
+
+	rrcmd := exec.Command("rr", args...)
 	rrcmd.Stdout = os.Stdout
 	stderr, err := rrcmd.StderrPipe()
 	if err != nil {
@@ -164,6 +350,7 @@ func Replay(tracedir string, quiet, deleteOnDetach bool, debugInfoDirs []string)
 		rrcmd.Process.Kill()
 		return nil, err
 	}
+	p.rrStart, _ = p.position()
 
 	return tgt, nil
 }
@@ -336,12 +523,12 @@ func splitQuotedFields(in string) []string {
 }
 
 // RecordAndReplay acts like calling Record and then Replay.
-func RecordAndReplay(cmd []string, wd string, quiet bool, debugInfoDirs []string, redirects [3]string) (*proc.Target, string, error) {
-	tracedir, err := Record(cmd, wd, quiet, redirects)
+func RecordAndReplay(cmd []string, wd string, quiet bool, debugInfoDirs []string, redirects [3]string, opts RecordOptions, onProcess string) (*proc.Target, string, error) {
+	tracedir, err := Record(cmd, wd, quiet, redirects, opts)
 	if tracedir == "" {
 		return nil, "", err
 	}
-	t, err := Replay(tracedir, quiet, true, debugInfoDirs)
+	t, err := Replay(tracedir, quiet, opts.OutputTraceDir == "", debugInfoDirs, onProcess)
 	return t, tracedir, err
 }
 
@@ -3,6 +3,8 @@ package gdbserial_test
 import (
 	"flag"
 	"fmt"
+	"go/constant"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -30,7 +32,7 @@ func withTestRecording(name string, t testing.TB, fn func(t *proc.Target, fixtur
 		t.Skip("test skipped, rr not found")
 	}
 	t.Log("recording")
-	p, tracedir, err := gdbserial.RecordAndReplay([]string{fixture.Path}, ".", true, []string{}, [3]string{})
+	p, tracedir, err := gdbserial.RecordAndReplay([]string{fixture.Path}, ".", true, []string{}, [3]string{}, gdbserial.RecordOptions{}, "")
 	if err != nil {
 		t.Fatal("Launch():", err)
 	}
@@ -277,6 +279,58 @@ func TestCheckpoints(t *testing.T) {
 	})
 }
 
+func TestAtCheckpoint(t *testing.T) {
+	// AtCheckpoint must evaluate at the checkpoint and then return to the
+	// current position, with the same goroutine selected.
+	protest.AllowRecording(t)
+	withTestRecording("testnextprog", t, func(p *proc.Target, fixture protest.Fixture) {
+		evalI := func() int64 {
+			scope, err := proc.GoroutineScope(p, p.CurrentThread())
+			assertNoError(err, t, "GoroutineScope")
+			v, err := scope.EvalExpression("i", proc.LoadConfig{})
+			assertNoError(err, t, "EvalExpression(i)")
+			n, _ := constant.Int64Val(v.Value)
+			return n
+		}
+
+		setFileBreakpoint(p, t, fixture, 31)
+		assertNoError(p.Continue(), t, "Continue")
+		cpid, err := p.Checkpoint("first hit")
+		assertNoError(err, t, "Checkpoint")
+		assertNoError(p.Continue(), t, "Continue")
+		when0, loc0 := getPosition(p, t)
+		tid0 := p.CurrentThread().ThreadID()
+		if i := evalI(); i != 1 {
+			t.Fatalf("wrong value of i before AtCheckpoint: %d", i)
+		}
+
+		var iAtCheckpoint int64 = -1
+		assertNoError(p.AtCheckpoint(cpid, -1, func() error {
+			iAtCheckpoint = evalI()
+			return nil
+		}), t, "AtCheckpoint")
+		if iAtCheckpoint != 0 {
+			t.Fatalf("wrong value of i at checkpoint: %d", iAtCheckpoint)
+		}
+
+		when1, loc1 := getPosition(p, t)
+		if when0 != when1 || loc0.PC != loc1.PC {
+			t.Fatalf("position changed by AtCheckpoint: %q %#x -> %q %#x", when0, loc0.PC, when1, loc1.PC)
+		}
+		if tid := p.CurrentThread().ThreadID(); tid != tid0 {
+			t.Fatalf("current thread changed by AtCheckpoint: %d -> %d", tid0, tid)
+		}
+		if i := evalI(); i != 1 {
+			t.Fatalf("wrong value of i after AtCheckpoint: %d", i)
+		}
+		checkpoints, err := p.Checkpoints()
+		assertNoError(err, t, "Checkpoints")
+		if len(checkpoints) != 1 {
+			t.Fatalf("temporary checkpoint was not deleted: %v", checkpoints)
+		}
+	})
+}
+
 func TestIssue1376(t *testing.T) {
 	// Backward Continue should terminate when it encounters the start of the process.
 	protest.AllowRecording(t)
@@ -289,3 +343,380 @@ func TestIssue1376(t *testing.T) {
 		assertNoError(p.Continue(), t, "Continue (backward)")
 	})
 }
+
+func TestRewindTo(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestRecording("continuetestprog", t, func(p *proc.Target, fixture protest.Fixture) {
+		bp := setFunctionBreakpoint(p, t, "main.sayhi")
+		assertNoError(p.Continue(), t, "Continue (forward)")
+		_, err := p.ClearBreakpoint(bp.Addr)
+		assertNoError(err, t, "ClearBreakpoint")
+
+		addrs, err := proc.FindFunctionLocation(p, "main.sleepytime", 0)
+		assertNoError(err, t, "FindFunctionLocation")
+		assertNoError(p.RewindTo(addrs), t, "RewindTo(main.sleepytime)")
+		_, loc := getPosition(p, t)
+		if loc.Fn == nil || loc.Fn.Name != "main.sleepytime" {
+			t.Fatalf("wrong location after RewindTo: %v", loc)
+		}
+		if p.Breakpoints().HasSteppingBreakpoints() {
+			t.Fatal("internal breakpoints left behind by RewindTo")
+		}
+
+		// main.sayhi is never reached going backwards from main.sleepytime.
+		addrs, err = proc.FindFunctionLocation(p, "main.sayhi", 0)
+		assertNoError(err, t, "FindFunctionLocation")
+		if err := p.RewindTo(addrs); err != proc.ErrStartOfRecording {
+			t.Fatalf("expected ErrStartOfRecording, got %v", err)
+		}
+	})
+}
+
+func assertFactFrame(p *proc.Target, t *testing.T, lineno int, n int64) {
+	_, f, l, _ := runtime.Caller(1)
+	f = filepath.Base(f)
+
+	_, loc := getPosition(p, t)
+	if loc.Fn == nil || loc.Fn.Name != "main.fact" || (lineno > 0 && loc.Line != lineno) {
+		t.Fatalf("%s:%d: wrong location %s:%d (expected main.fact at line %d)", f, l, loc.File, loc.Line, lineno)
+	}
+	scope, err := proc.GoroutineScope(p, p.CurrentThread())
+	assertNoError(err, t, "GoroutineScope")
+	v, err := scope.EvalExpression("n", proc.LoadConfig{})
+	assertNoError(err, t, "EvalExpression(n)")
+	if got, _ := constant.Int64Val(v.Value); got != n {
+		t.Fatalf("%s:%d: wrong invocation of main.fact, n = %d (expected %d)", f, l, got, n)
+	}
+}
+
+func TestReverseStepRecursion(t *testing.T) {
+	// Reverse next, step and stepout must stay in the invocation of a
+	// recursive function they were started in.
+	protest.AllowRecording(t)
+
+	setup := func(p *proc.Target, t *testing.T, fixture protest.Fixture) {
+		// The first time line 10 is reached is in fact(2), after fact(1)
+		// returned.
+		bp := setFileBreakpoint(p, t, fixture, 10)
+		assertNoError(p.Continue(), t, "Continue (forward)")
+		assertFactFrame(p, t, 10, 2)
+		_, err := p.ClearBreakpoint(bp.Addr)
+		assertNoError(err, t, "ClearBreakpoint")
+		assertNoError(p.ChangeDirection(proc.Backward), t, "Switching to backward direction")
+	}
+
+	t.Run("next", func(t *testing.T) {
+		withTestRecording("reverserecursion", t, func(p *proc.Target, fixture protest.Fixture) {
+			setup(p, t, fixture)
+			assertNoError(p.Next(), t, "reverse Next")
+			assertFactFrame(p, t, 9, 2)
+			assertNoError(p.Next(), t, "reverse Next")
+			assertFactFrame(p, t, 6, 2)
+			assertNoError(p.Next(), t, "reverse Next")
+			assertFactFrame(p, t, 9, 3)
+		})
+	})
+
+	t.Run("step", func(t *testing.T) {
+		withTestRecording("reverserecursion", t, func(p *proc.Target, fixture protest.Fixture) {
+			setup(p, t, fixture)
+			assertNoError(p.Step(), t, "reverse Step")
+			assertFactFrame(p, t, 0, 1)
+		})
+	})
+
+	t.Run("stepout", func(t *testing.T) {
+		withTestRecording("reverserecursion", t, func(p *proc.Target, fixture protest.Fixture) {
+			setup(p, t, fixture)
+			assertNoError(p.StepOut(), t, "reverse StepOut")
+			assertFactFrame(p, t, 9, 3)
+			assertNoError(p.StepOut(), t, "reverse StepOut")
+			assertFactFrame(p, t, 9, 4)
+		})
+	})
+}
+
+func TestSeekTo(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestRecording("continuetestprog", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFunctionBreakpoint(p, t, "main.sayhi")
+		assertNoError(p.Continue(), t, "Continue")
+		pos, err := p.Position()
+		assertNoError(err, t, "Position")
+		t.Logf("position %#v", pos)
+		if pos.Event <= 0 {
+			t.Fatalf("wrong event number %d", pos.Event)
+		}
+
+		assertNoError(p.Restart(""), t, "Restart")
+		assertNoError(p.SeekTo(pos), t, "SeekTo")
+		pos2, err := p.Position()
+		assertNoError(err, t, "Position")
+		if pos2 != pos {
+			t.Fatalf("wrong position after SeekTo: %#v (expected %#v)", pos2, pos)
+		}
+		_, loc := getPosition(p, t)
+		if loc.Fn == nil || loc.Fn.Name != "main.sayhi" {
+			t.Fatalf("wrong location after SeekTo: %v", loc)
+		}
+	})
+}
+
+func TestAutoCheckpoint(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestRecording("bpcountstest", t, func(p *proc.Target, fixture protest.Fixture) {
+		bp := setFileBreakpoint(p, t, fixture, 12)
+		bp.UserBreaklet().AutoCheckpoint = 5
+		bp.UserBreaklet().AutoCheckpointMax = 2
+		for i := 0; i < 16; i++ {
+			assertNoError(p.Continue(), t, "Continue")
+		}
+		cpid, err := p.Checkpoint("manual")
+		assertNoError(err, t, "Checkpoint")
+
+		checkpoints, err := p.Checkpoints()
+		assertNoError(err, t, "Checkpoints")
+		t.Logf("%#v", checkpoints)
+		var auto []string
+		for _, cp := range checkpoints {
+			if cp.Auto {
+				auto = append(auto, cp.Where)
+			} else if cp.ID != cpid {
+				t.Errorf("unexpected manual checkpoint %#v", cp)
+			}
+		}
+		expected := []string{fmt.Sprintf("auto: breakpoint %d hit 10", bp.LogicalID), fmt.Sprintf("auto: breakpoint %d hit 15", bp.LogicalID)}
+		if fmt.Sprint(auto) != fmt.Sprint(expected) {
+			t.Fatalf("wrong automatic checkpoints %q (expected %q)", auto, expected)
+		}
+	})
+}
+
+func TestAutoCheckpointOnly(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestRecording("bpcountstest", t, func(p *proc.Target, fixture protest.Fixture) {
+		bp := setFileBreakpoint(p, t, fixture, 12)
+		bp.UserBreaklet().AutoCheckpoint = 50
+		bp.UserBreaklet().AutoCheckpointOnly = true
+		setFileBreakpoint(p, t, fixture, 17)
+
+		// the first breakpoint only creates checkpoints, the target stops on
+		// the second one
+		assertNoError(p.Continue(), t, "Continue")
+		if _, loc := getPosition(p, t); loc.Line != 17 {
+			t.Fatalf("stopped at %s:%d, expected line 17", loc.File, loc.Line)
+		}
+
+		checkpoints, err := p.Checkpoints()
+		assertNoError(err, t, "Checkpoints")
+		n := 0
+		for _, cp := range checkpoints {
+			if cp.Auto {
+				n++
+			}
+		}
+		if hits := bp.UserBreaklet().TotalHitCount; n != int(hits/50) {
+			t.Fatalf("wrong number of automatic checkpoints %d after %d hits", n, hits)
+		}
+	})
+}
+
+func TestRecordingPosition(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestRecording("continuetestprog", t, func(p *proc.Target, fixture protest.Fixture) {
+		pos, err := p.Position()
+		assertNoError(err, t, "Position")
+		if !pos.AtStart || pos.AtEnd || pos.TotalEvents != 0 {
+			t.Fatalf("wrong position at the start of the recording: %#v", pos)
+		}
+
+		err = p.Continue()
+		if _, exited := err.(proc.ErrProcessExited); !exited {
+			t.Fatalf("expected process exit, got %v", err)
+		}
+		end, err := p.Position()
+		assertNoError(err, t, "Position")
+		if !end.AtEnd || end.TotalEvents <= pos.Event || end.Event != end.TotalEvents {
+			t.Fatalf("wrong position at the end of the recording: %#v", end)
+		}
+
+		assertNoError(p.Restart(""), t, "Restart")
+		pos, err = p.Position()
+		assertNoError(err, t, "Position")
+		if !pos.AtStart || pos.TotalEvents != end.TotalEvents {
+			t.Fatalf("wrong position after restart: %#v", pos)
+		}
+	})
+}
+
+func TestPassSignals(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestRecording("continuetestprog", t, func(p *proc.Target, fixture protest.Fixture) {
+		sp := p.PassSignals()
+		if !sp.Configurable || sp.All || len(sp.Signals) != 1 || sp.Signals[0] != 0x10 {
+			t.Fatalf("wrong default signal passing: %#v", sp)
+		}
+		assertNoError(p.SetPassSignals([]int{0x1e, 0x10}), t, "SetPassSignals")
+		sp = p.PassSignals()
+		if len(sp.Signals) != 2 || sp.Signals[0] != 0x10 || sp.Signals[1] != 0x1e {
+			t.Fatalf("wrong signal passing after SetPassSignals: %#v", sp)
+		}
+		if err := p.SetPassSignals([]int{0}); err == nil {
+			t.Fatalf("SetPassSignals accepted signal 0")
+		}
+		err := p.Continue()
+		if _, exited := err.(proc.ErrProcessExited); !exited {
+			t.Fatalf("expected process exit, got %v", err)
+		}
+	})
+}
+
+func TestReplayOnProcess(t *testing.T) {
+	protest.AllowRecording(t)
+	protest.MustHaveRecordingAllowed(t)
+	if path, _ := exec.LookPath("rr"); path == "" {
+		t.Skip("test skipped, rr not found")
+	}
+	fixture := protest.BuildFixture("rrchild", 0)
+	tracedir, err := gdbserial.Record([]string{fixture.Path}, ".", true, [3]string{}, gdbserial.RecordOptions{})
+	assertNoError(err, t, "Record")
+
+	procs, err := gdbserial.RecordedProcesses(tracedir)
+	assertNoError(err, t, "RecordedProcesses")
+	t.Logf("%#v", procs)
+	if len(procs) != 2 || procs[0].Ppid != 0 || procs[1].Ppid != procs[0].Pid {
+		t.Fatalf("wrong list of recorded processes: %#v", procs)
+	}
+
+	p, err := gdbserial.Replay(tracedir, true, true, nil, " child$")
+	assertNoError(err, t, "Replay")
+	defer p.Detach(true)
+	if p.Pid() != procs[1].Pid {
+		t.Fatalf("wrong process replayed: %d (expected %d)", p.Pid(), procs[1].Pid)
+	}
+	setFunctionBreakpoint(p, t, "main.child")
+	assertNoError(p.Continue(), t, "Continue")
+	loc, err := p.CurrentThread().Location()
+	assertNoError(err, t, "Location")
+	if loc.Fn == nil || loc.Fn.Name != "main.child" {
+		t.Fatalf("wrong stop location %#v", loc)
+	}
+}
+
+func TestReverseWatchpoint(t *testing.T) {
+	// A watchpoint hit while executing backward must stop where the watched
+	// memory was last written.
+	protest.AllowRecording(t)
+	withTestRecording("reversewatch", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFileBreakpoint(p, t, fixture, 28)
+		assertNoError(p.Continue(), t, "Continue")
+		scope, err := proc.GoroutineScope(p, p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope")
+		_, err = p.SetWatchpoint(scope, "list.next", proc.WatchWrite, nil)
+		assertNoError(err, t, "SetWatchpoint")
+
+		assertNoError(p.ChangeDirection(proc.Backward), t, "ChangeDirection")
+		assertNoError(p.Continue(), t, "Continue")
+		if p.StopReason != proc.StopWatchpoint {
+			t.Fatalf("wrong stop reason %v", p.StopReason)
+		}
+		_, loc := getPosition(p, t)
+		// The stop is reported after the writing instruction executes, which
+		// could be the last instruction of line 12.
+		if loc.Fn == nil || loc.Fn.Name != "main.corrupt" || (loc.Line != 12 && loc.Line != 13) {
+			t.Fatalf("wrong location %s:%d (expected main.corrupt at line 12)", loc.File, loc.Line)
+		}
+	})
+}
+
+func TestReverseWatchpointOutOfScope(t *testing.T) {
+	// A watched stack variable goes out of scope, while executing backward,
+	// when the CALL instruction that created its frame is reached.
+	protest.AllowRecording(t)
+	withTestRecording("reversewatch", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFileBreakpoint(p, t, fixture, 21)
+		assertNoError(p.Continue(), t, "Continue")
+		scope, err := proc.GoroutineScope(p, p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope")
+		_, err = p.SetWatchpoint(scope, "tot", proc.WatchWrite, nil)
+		assertNoError(err, t, "SetWatchpoint")
+
+		assertNoError(p.ChangeDirection(proc.Backward), t, "ChangeDirection")
+		for i := 0; i < 10; i++ {
+			assertNoError(p.Continue(), t, "Continue")
+			if p.StopReason != proc.StopWatchpoint {
+				break
+			}
+			_, loc := getPosition(p, t)
+			if loc.Fn == nil || loc.Fn.Name != "main.sum" {
+				t.Fatalf("watchpoint hit outside of main.sum at %s:%d", loc.File, loc.Line)
+			}
+		}
+		if p.StopReason != proc.StopWatchOutOfScope {
+			t.Fatalf("wrong stop reason %v", p.StopReason)
+		}
+		_, loc := getPosition(p, t)
+		if loc.Fn == nil || loc.Fn.Name != "main.main" || loc.Line != 27 {
+			t.Fatalf("wrong location %s:%d (expected main.main at line 27)", loc.File, loc.Line)
+		}
+		if wos := p.WatchOutOfScope(); len(wos) != 1 || wos[0].LastValue != "" {
+			t.Fatalf("wrong watchpoints out of scope %#v", wos)
+		}
+		for _, bp := range p.Breakpoints().M {
+			if bp.WatchType != 0 {
+				t.Fatalf("watchpoint not cleared %#x", bp.Addr)
+			}
+		}
+	})
+}
+
+func TestRecordOptionsValidate(t *testing.T) {
+	existing, err := ioutil.TempDir("", "dlv-rr-test")
+	assertNoError(err, t, "TempDir")
+	defer os.RemoveAll(existing)
+
+	for _, tc := range []struct {
+		opts gdbserial.RecordOptions
+		ok   bool
+	}{
+		{gdbserial.RecordOptions{}, true},
+		{gdbserial.RecordOptions{Chaos: true, NumCores: 2, ExtraArgs: []string{"--wait"}}, true},
+		{gdbserial.RecordOptions{OutputTraceDir: filepath.Join(existing, "trace")}, true},
+		{gdbserial.RecordOptions{NumCores: -1}, false},
+		{gdbserial.RecordOptions{OutputTraceDir: existing}, false},
+		{gdbserial.RecordOptions{Chaos: true, ExtraArgs: []string{"-h"}}, false},
+		{gdbserial.RecordOptions{NumCores: 2, ExtraArgs: []string{"--num-cores=4"}}, false},
+		{gdbserial.RecordOptions{ExtraArgs: []string{"-o", "/tmp/trace"}}, false},
+		{gdbserial.RecordOptions{ExtraArgs: []string{"--print-trace-dir=1"}}, false},
+	} {
+		err := tc.opts.Validate()
+		if (err == nil) != tc.ok {
+			t.Errorf("%#v: unexpected result %v", tc.opts, err)
+		}
+	}
+}
+
+func TestRecordOptionsRerecord(t *testing.T) {
+	existing, err := ioutil.TempDir("", "dlv-rr-test")
+	assertNoError(err, t, "TempDir")
+	defer os.RemoveAll(existing)
+
+	if opts := (gdbserial.RecordOptions{}).Rerecord(); opts.OutputTraceDir != "" {
+		t.Errorf("unexpected trace directory %q", opts.OutputTraceDir)
+	}
+	missing := filepath.Join(existing, "missing")
+	if opts := (gdbserial.RecordOptions{OutputTraceDir: missing}).Rerecord(); opts.OutputTraceDir != missing {
+		t.Errorf("unexpected trace directory %q", opts.OutputTraceDir)
+	}
+
+	opts := gdbserial.RecordOptions{OutputTraceDir: existing}
+	for _, tgt := range []string{"1", "2"} {
+		next := opts.Rerecord()
+		if next.OutputTraceDir != filepath.Join(existing, tgt) {
+			t.Fatalf("unexpected trace directory %q", next.OutputTraceDir)
+		}
+		assertNoError(next.Validate(), t, "Validate")
+		// rr creates the trace directory when it records
+		assertNoError(os.Mkdir(next.OutputTraceDir, 0700), t, "Mkdir")
+	}
+}
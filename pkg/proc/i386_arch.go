@@ -15,13 +15,19 @@ var i386BreakInstruction = []byte{0xCC}
 // I386Arch returns an initialized I386Arch
 // struct.
 func I386Arch(goos string) *Arch {
+	var syscallInstruction []byte
+	if goos != "windows" {
+		syscallInstruction = []byte{0xcd, 0x80} // INT $0x80
+	}
 	return &Arch{
 		Name:                             "386",
 		ptrSize:                          4,
 		maxInstructionLength:             15,
 		breakpointInstruction:            i386BreakInstruction,
 		altBreakpointInstruction:         []byte{0xcd, 0x03},
+		syscallInstruction:               syscallInstruction,
 		breakInstrMovesPC:                true,
+		hwBreakpointSlots:                4,
 		derefTLS:                         false,
 		prologues:                        prologuesI386,
 		fixFrameUnwindContext:            i386FixFrameUnwindContext,
@@ -30,7 +36,7 @@ func I386Arch(goos string) *Arch {
 		RegistersToDwarfRegisters:        i386RegistersToDwarfRegisters,
 		addrAndStackRegsToDwarfRegisters: i386AddrAndStackRegsToDwarfRegisters,
 		DwarfRegisterToString:            i386DwarfRegisterToString,
-		inhibitStepInto:                  i386InhibitStepInto,
+		isThunkSymbol:                    i386IsThunkSymbol,
 		asmDecode:                        i386AsmDecode,
 		PCRegNum:                         regnum.I386_Eip,
 		SPRegNum:                         regnum.I386_Esp,
@@ -230,14 +236,10 @@ func i386DwarfRegisterToString(j int, reg *op.DwarfRegister) (name string, float
 	}
 }
 
-// i386InhibitStepInto returns whether StepBreakpoint can be set at pc.
+// i386IsThunkSymbol returns true for thunks inserted by the compiler or
+// the linker on 386.
 // When cgo or pie on 386 linux, compiler will insert more instructions (ex: call __x86.get_pc_thunk.).
-// StepBreakpoint shouldn't be set on __x86.get_pc_thunk and skip it.
 // See comments on stacksplit in $GOROOT/src/cmd/internal/obj/x86/obj6.go for generated instructions details.
-func i386InhibitStepInto(bi *BinaryInfo, pc uint64) bool {
-	if bi.SymNames != nil && bi.SymNames[pc] != nil &&
-		strings.HasPrefix(bi.SymNames[pc].Name, "__x86.get_pc_thunk.") {
-		return true
-	}
-	return false
+func i386IsThunkSymbol(name string) bool {
+	return strings.HasPrefix(name, "__x86.get_pc_thunk.") || strings.HasPrefix(name, "__x86_indirect_thunk_")
 }
@@ -36,6 +36,22 @@ type ProcessInternal interface {
 	// number.
 	// Returns the new current thread after the restart has completed.
 	Restart(pos string) (Thread, error)
+	// SeekTicks moves the recording forward until the current thread reaches
+	// the specified tick count.
+	// Returns the new current thread after the seek has completed.
+	SeekTicks(ticks int64) (Thread, error)
+	// PassSignals returns the signals that are delivered to the target
+	// without stopping it.
+	PassSignals() SignalPassing
+	// SetPassSignals changes the list of signals delivered to the target
+	// without stopping it. Returns ErrPassSignalsUnsupported if the backend
+	// can not be configured.
+	SetPassSignals(sigs []int) error
+	// SetResumeThread restricts ContinueOnce to resuming the thread with
+	// the specified ID, all other threads are kept stopped. If tid is zero
+	// all threads are resumed. Returns ErrResumeThreadUnsupported if the
+	// backend can not resume a single thread.
+	SetResumeThread(tid int) error
 	Detach(bool) error
 	ContinueOnce() (trapthread Thread, stopReason StopReason, err error)
 
@@ -60,6 +76,9 @@ type RecordingManipulation interface {
 	GetDirection() Direction
 	// When returns current recording position.
 	When() (string, error)
+	// Position returns the current recording position as an event number
+	// and tick count.
+	Position() (RecordingPosition, error)
 	// Checkpoint sets a checkpoint at the current position.
 	Checkpoint(where string) (id int, err error)
 	// Checkpoints returns the list of currently set checkpoint.
@@ -83,6 +102,39 @@ type Checkpoint struct {
 	ID    int
 	When  string
 	Where string
+	Event int64
+	// Auto is true for checkpoints created automatically by a breakpoint,
+	// see Breaklet.AutoCheckpoint.
+	Auto bool
+}
+
+// RecordingPosition is a position in a recording.
+type RecordingPosition struct {
+	// Event is the event number.
+	Event int64
+	// Ticks is the tick count of the current thread, zero if the position
+	// is the start of the event.
+	Ticks int64
+	// TotalEvents is the number of events in the recording, zero if it is
+	// not known yet.
+	TotalEvents int64
+	// AtStart is true if the position is the start of the recording.
+	AtStart bool
+	// AtEnd is true if the position is the end of the recording.
+	AtEnd bool
+}
+
+// SignalPassing describes which signals received by the target are
+// delivered to it directly, without stopping it.
+type SignalPassing struct {
+	// Signals is the list of signals passed through to the target, signal
+	// numbers are the ones used by the backend.
+	Signals []int
+	// All is true if the backend delivers every signal it doesn't use
+	// itself to the target, regardless of Signals.
+	All bool
+	// Configurable is true if Signals can be changed.
+	Configurable bool
 }
 
 // Info is an interface that provides general information on the target.
@@ -157,12 +157,18 @@ func ElfUpdateSharedObjects(p proc.Process) error {
 	// Offsets of the fields of the r_debug and link_map structs,
 	// see /usr/include/elf/link.h for a full description of those structs.
 	debugMapOffset := uint64(p.BinInfo().Arch.PtrSize())
+	debugBrkOffset := 2 * uint64(p.BinInfo().Arch.PtrSize())
 
 	r_map, err := readPtr(p, debugAddr+debugMapOffset)
 	if err != nil {
 		return err
 	}
 
+	bi.ElfDynamicLinkerBreak, err = readPtr(p, debugAddr+debugBrkOffset)
+	if err != nil {
+		return err
+	}
+
 	libs := []string{}
 
 	for {
@@ -207,13 +207,19 @@ func (r *AMD64Registers) SetReg(regNum uint64, reg *op.DwarfRegister) (bool, err
 		r.loadFpRegs = nil
 	}
 
-	if regNum < regnum.AMD64_XMM0 || regNum > regnum.AMD64_XMM0+15 {
+	var n int
+	switch {
+	case regNum >= regnum.AMD64_XMM0 && regNum <= regnum.AMD64_XMM0+15:
+		n = int(regNum - regnum.AMD64_XMM0)
+	case regNum >= regnum.AMD64_XMM16 && regNum <= regnum.AMD64_XMM16+15:
+		n = 16 + int(regNum-regnum.AMD64_XMM16)
+	default:
 		return false, fmt.Errorf("can not set %s", regnum.AMD64ToName(regNum))
 	}
 
 	reg.FillBytes()
 
-	err := r.Fpregset.SetXmmRegister(int(regNum-regnum.AMD64_XMM0), reg.Bytes)
+	err := r.Fpregset.SetXmmRegister(n, reg.Bytes)
 	if err != nil {
 		return false, err
 	}
@@ -1,8 +1,11 @@
 package linutil
 
 import (
+	"encoding/binary"
 	"fmt"
 
+	"github.com/go-delve/delve/pkg/dwarf/op"
+	"github.com/go-delve/delve/pkg/dwarf/regnum"
 	"github.com/go-delve/delve/pkg/proc"
 )
 
@@ -138,6 +141,55 @@ func (r *ARM64Registers) Copy() (proc.Registers, error) {
 	return &rr, nil
 }
 
+// SetReg changes the value of one of the registers, returning true if the
+// register is a floating point register, in which case Fpregset is changed.
+func (r *ARM64Registers) SetReg(regNum uint64, reg *op.DwarfRegister) (bool, error) {
+	var p *uint64
+	switch {
+	case regNum >= regnum.ARM64_X0 && regNum <= regnum.ARM64_X0+30:
+		p = &r.Regs.Regs[regNum-regnum.ARM64_X0]
+	case regNum == regnum.ARM64_SP:
+		p = &r.Regs.Sp
+	case regNum == regnum.ARM64_PC:
+		p = &r.Regs.Pc
+	}
+
+	if p != nil {
+		if reg.Bytes != nil && len(reg.Bytes) != 8 {
+			return false, fmt.Errorf("wrong number of bytes for register %s (%d)", regnum.ARM64ToName(regNum), len(reg.Bytes))
+		}
+		*p = reg.Uint64Val
+		return false, nil
+	}
+
+	if regNum < regnum.ARM64_V0 || regNum > regnum.ARM64_V0+31 {
+		return false, fmt.Errorf("can not set %s", regnum.ARM64ToName(regNum))
+	}
+
+	if r.loadFpRegs != nil {
+		err := r.loadFpRegs(r)
+		if err != nil {
+			return false, err
+		}
+		r.loadFpRegs = nil
+	}
+
+	reg.FillBytes()
+	if len(reg.Bytes) > 16 {
+		return false, fmt.Errorf("value of register %s too large (%d bytes)", regnum.ARM64ToName(regNum), len(reg.Bytes))
+	}
+	vpos := int(regNum-regnum.ARM64_V0) * 16
+	if vpos+16 > len(r.Fpregset) {
+		return false, fmt.Errorf("could not set %s: floating point registers not available", regnum.ARM64ToName(regNum))
+	}
+	v := r.Fpregset[vpos : vpos+16]
+	for i := range v {
+		v[i] = 0
+	}
+	copy(v, reg.Bytes)
+	return true, nil
+}
+
 type ARM64PtraceFpRegs struct {
 	Vregs []byte
 	Fpsr  uint32
@@ -157,3 +209,230 @@ func (fpregs *ARM64PtraceFpRegs) Byte() []byte {
 	fpregs.Vregs = make([]byte, _ARM_FP_REGS_LENGTH)
 	return fpregs.Vregs[:]
 }
+
+// ARM64HWDebugRegs is the struct used by the linux kernel to return the
+// hardware watchpoint registers of ARM64 CPUs (NT_ARM_HW_WATCH), see
+// struct user_hwdebug_state in source/arch/arm64/include/uapi/asm/ptrace.h.
+type ARM64HWDebugRegs struct {
+	DbgInfo uint32
+	pad     uint32
+	DbgRegs [16]struct {
+		Addr uint64
+		Ctrl uint32
+		pad  uint32
+	}
+}
+
+// ARM64MaxWatchAccessSize is the size of the largest memory access
+// performed by a single ARM64 instruction that can trigger a watchpoint
+// (LDP/STP of two Q registers).
+const ARM64MaxWatchAccessSize = 32
+
+// Fields of the control register of a watchpoint (DBGWCR), described in
+// the ARM Architecture Reference Manual, section D13.3.
+const (
+	arm64WatchEnable    = 1 << 0
+	arm64WatchPrivEL0   = 2 << 1
+	arm64WatchLoad      = 1 << 3
+	arm64WatchStore     = 2 << 3
+	arm64WatchBASOffset = 5
+)
+
+// Slots returns the number of watchpoint registers supported by the CPU.
+func (drs *ARM64HWDebugRegs) Slots() int {
+	return int(drs.DbgInfo & 0xff)
+}
+
+// Size returns the size of the register set, as expected by
+// PTRACE_SETREGSET, for the number of watchpoint registers of the CPU.
+func (drs *ARM64HWDebugRegs) Size() int {
+	return 8 + 16*drs.Slots()
+}
+
+// watchpoint returns the range of memory watched by the watchpoint
+// register at index idx, the size is zero if it is disabled.
+func (drs *ARM64HWDebugRegs) watchpoint(idx uint8) (addr uint64, sz int) {
+	reg := &drs.DbgRegs[idx]
+	if reg.Ctrl&arm64WatchEnable == 0 {
+		return 0, 0
+	}
+	bas := (reg.Ctrl >> arm64WatchBASOffset) & 0xff
+	if bas == 0 {
+		return 0, 0
+	}
+	off := 0
+	for bas&1 == 0 {
+		bas >>= 1
+		off++
+	}
+	for bas&1 != 0 {
+		bas >>= 1
+		sz++
+	}
+	return reg.Addr + uint64(off), sz
+}
+
+// SetWatchpoint sets the watchpoint register at index idx to watch the sz
+// bytes starting at addr. The watched bytes are selected with the byte
+// address select field of the control register, so that accesses to
+// other bytes of the same doubleword do not trigger the watchpoint.
+// If the register is already in use but the parameters match it does
+// nothing.
+func (drs *ARM64HWDebugRegs) SetWatchpoint(idx uint8, addr uint64, read, write bool, sz int) error {
+	if drs.Slots() == 0 {
+		return fmt.Errorf("%v: the CPU has no watchpoint registers", proc.ErrHWBreakUnsupported)
+	}
+	if int(idx) >= drs.Slots() {
+		return fmt.Errorf("hardware watchpoints exhausted (the CPU has %d watchpoint registers)", drs.Slots())
+	}
+	off := addr & 7
+	if sz <= 0 || off+uint64(sz) > 8 {
+		return fmt.Errorf("data breakpoint of size %d at %#x not supported: it must be contained in a doubleword", sz, addr)
+	}
+	if !read && !write {
+		return fmt.Errorf("at least one of read and write must be set for watchpoint")
+	}
+
+	ctrl := uint32(arm64WatchEnable | arm64WatchPrivEL0)
+	if read {
+		ctrl |= arm64WatchLoad
+	}
+	if write {
+		ctrl |= arm64WatchStore
+	}
+	ctrl |= ((1<<uint(sz) - 1) << off) << arm64WatchBASOffset
+
+	reg := &drs.DbgRegs[idx]
+	if reg.Ctrl&arm64WatchEnable != 0 {
+		if reg.Addr != addr&^7 || reg.Ctrl != ctrl {
+			curaddr, _ := drs.watchpoint(idx)
+			return fmt.Errorf("hardware breakpoint %d already in use (address %#x)", idx, curaddr)
+		}
+		// watchpoint already set
+		return nil
+	}
+	reg.Addr = addr &^ 7
+	reg.Ctrl = ctrl
+	return nil
+}
+
+// ClearWatchpoint disables the watchpoint register at index idx.
+func (drs *ARM64HWDebugRegs) ClearWatchpoint(idx uint8) {
+	drs.DbgRegs[idx].Ctrl &^= arm64WatchEnable
+}
+
+// ClearAll disables all watchpoint registers.
+func (drs *ARM64HWDebugRegs) ClearAll() {
+	for i := range drs.DbgRegs {
+		drs.DbgRegs[i].Ctrl &^= arm64WatchEnable
+	}
+}
+
+// WatchpointFor returns the index of the watchpoint register triggered by
+// a memory access at far, the address reported by the kernel in the
+// si_addr field of the SIGTRAP. The reported address is the lowest address
+// accessed, an access can only have triggered a watchpoint if the
+// ARM64MaxWatchAccessSize bytes starting at far overlap the watched bytes.
+// A watchpoint register watching far itself is preferred.
+// Returns false if no watchpoint register could have been triggered.
+func (drs *ARM64HWDebugRegs) WatchpointFor(far uint64) (idx uint8, ok bool) {
+	for i := 0; i < drs.Slots() && i < len(drs.DbgRegs); i++ {
+		addr, sz := drs.watchpoint(uint8(i))
+		if sz == 0 || far >= addr+uint64(sz) || far+ARM64MaxWatchAccessSize <= addr {
+			continue
+		}
+		if far >= addr {
+			return uint8(i), true
+		}
+		if !ok {
+			idx, ok = uint8(i), true
+		}
+	}
+	return idx, ok
+}
+
+// ARM64SveRegs holds the contents of the SVE registers of a thread, see
+// struct user_sve_header and the SVE_PT_* macros in
+// source/arch/arm64/include/uapi/asm/sve_context.h and ptrace.h.
+type ARM64SveRegs struct {
+	Vl    int    // vector length in bytes
+	Zregs []byte // Z0 through Z31, Vl bytes each
+	Pregs []byte // P0 through P15, Vl/8 bytes each
+	Ffr   []byte // Vl/8 bytes
+}
+
+const (
+	// ARM64SveHeaderSize is the size of struct user_sve_header.
+	ARM64SveHeaderSize = 16
+
+	arm64SveRegsMask = 1 << 0
+	arm64SveRegsSVE  = 1
+	arm64SveVqBytes  = 16
+	arm64SveNumZregs = 32
+	arm64SveNumPregs = 16
+	arm64FpsimdVregs = 32 * 16
+	arm64SveMaxVl    = 512 // architectural maximum of 2048 bits
+)
+
+// ARM64SveRegsetSize returns the size of the NT_ARM_SVE register set
+// described by hdr, which must hold at least ARM64SveHeaderSize bytes.
+func ARM64SveRegsetSize(hdr []byte) int {
+	return int(binary.LittleEndian.Uint32(hdr[0:]))
+}
+
+// ARM64SveRead reads the contents of a NT_ARM_SVE register set into
+// regs. The layout of the register set depends on the vector length of
+// the thread at the moment it was read.
+func ARM64SveRead(buf []byte, regs *ARM64SveRegs) error {
+	if len(buf) < ARM64SveHeaderSize {
+		return fmt.Errorf("SVE register set too short (%d bytes)", len(buf))
+	}
+	vl := int(binary.LittleEndian.Uint16(buf[8:]))
+	flags := binary.LittleEndian.Uint16(buf[12:])
+	if vl == 0 || vl%arm64SveVqBytes != 0 || vl > arm64SveMaxVl {
+		return fmt.Errorf("invalid SVE vector length %d", vl)
+	}
+	payload := buf[ARM64SveHeaderSize:]
+
+	regs.Vl = vl
+	regs.Zregs = make([]byte, arm64SveNumZregs*vl)
+	regs.Pregs = make([]byte, arm64SveNumPregs*vl/8)
+	regs.Ffr = make([]byte, vl/8)
+
+	if flags&arm64SveRegsMask != arm64SveRegsSVE {
+		// The SVE registers are not live, the payload is a struct
+		// user_fpsimd_state and the Z registers contain the V registers
+		// zero-extended.
+		if len(payload) < arm64FpsimdVregs {
+			return fmt.Errorf("SVE register set too short (%d bytes)", len(buf))
+		}
+		for i := 0; i < arm64SveNumZregs; i++ {
+			copy(regs.Zregs[i*vl:], payload[i*16:(i+1)*16])
+		}
+		return nil
+	}
+
+	zsz, psz := arm64SveNumZregs*vl, (arm64SveNumPregs+1)*vl/8
+	if len(payload) < zsz+psz {
+		return fmt.Errorf("SVE register set too short (%d bytes) for vector length %d", len(buf), vl)
+	}
+	copy(regs.Zregs, payload[:zsz])
+	copy(regs.Pregs, payload[zsz:])
+	copy(regs.Ffr, payload[zsz+len(regs.Pregs):])
+	return nil
+}
+
+// Decode decodes the SVE registers to a list of name/value pairs.
+func (regs *ARM64SveRegs) Decode() []proc.Register {
+	var out []proc.Register
+	out = proc.AppendUint64Register(out, "VG", uint64(regs.Vl/8))
+	for i := 0; i < arm64SveNumZregs; i++ {
+		out = proc.AppendBytesRegister(out, fmt.Sprintf("Z%d", i), regs.Zregs[i*regs.Vl:(i+1)*regs.Vl])
+	}
+	psz := regs.Vl / 8
+	for i := 0; i < arm64SveNumPregs; i++ {
+		out = proc.AppendBytesRegister(out, fmt.Sprintf("P%d", i), regs.Pregs[i*psz:(i+1)*psz])
+	}
+	out = proc.AppendBytesRegister(out, "FFR", regs.Ffr)
+	return out
+}
@@ -0,0 +1,153 @@
+package proc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+)
+
+// maxLogpointMessages is the maximum number of logpoint messages buffered
+// by a Target, when the buffer is full the oldest messages are discarded.
+const maxLogpointMessages = 1000
+
+var logpointLoadConfig = LoadConfig{FollowPointers: true, MaxVariableRecurse: 1, MaxStringLen: 64, MaxArrayValues: 16, MaxStructFields: -1}
+
+// Logpoint is a format string, with embedded expressions, that is
+// rendered every time the breakpoint it belongs to is hit.
+// Expressions are enclosed in curly braces, '{{' and '}}' can be used to
+// write literal braces.
+type Logpoint struct {
+	Format string
+
+	text  []string   // literal text, len(text) == len(exprs)+1
+	exprs []ast.Expr // embedded expressions
+}
+
+// LogpointMessage is a message produced by a logpoint, it is rendered by
+// the client facing layer.
+type LogpointMessage struct {
+	LogicalID   int         // ID of the logical breakpoint that produced the message
+	GoroutineID int         // goroutine that hit the logpoint
+	Text        []string    // literal text of the format string, len(Text) == len(Values)+1
+	Values      []*Variable // values of the expressions of the format string
+}
+
+// ParseLogpoint parses the format string of a logpoint.
+func ParseLogpoint(format string) (*Logpoint, error) {
+	lp := &Logpoint{Format: format}
+	var cur bytes.Buffer
+	for i := 0; i < len(format); i++ {
+		switch format[i] {
+		case '{':
+			if i+1 < len(format) && format[i+1] == '{' {
+				cur.WriteByte('{')
+				i++
+				continue
+			}
+			depth := 1
+			start := i + 1
+			for i++; i < len(format) && depth > 0; i++ {
+				switch format[i] {
+				case '{':
+					depth++
+				case '}':
+					depth--
+				}
+			}
+			if depth > 0 {
+				return nil, errors.New("unterminated expression in logpoint format")
+			}
+			i--
+			expr, err := parser.ParseExpr(format[start:i])
+			if err != nil {
+				return nil, fmt.Errorf("could not parse %q: %v", format[start:i], err)
+			}
+			lp.text = append(lp.text, cur.String())
+			lp.exprs = append(lp.exprs, expr)
+			cur.Reset()
+		case '}':
+			if i+1 < len(format) && format[i+1] == '}' {
+				i++
+			}
+			cur.WriteByte('}')
+		default:
+			cur.WriteByte(format[i])
+		}
+	}
+	lp.text = append(lp.text, cur.String())
+	return lp, nil
+}
+
+// eval evaluates the expressions of the logpoint on thread, running
+// goroutine g, which can be nil. Expressions that can not be evaluated are
+// returned as unreadable variables.
+func (lp *Logpoint) eval(thread Thread, g *G) []*Variable {
+	if len(lp.exprs) == 0 {
+		return nil
+	}
+	var scope *EvalScope
+	locations, err := ThreadStacktrace(thread, 1)
+	if err == nil && len(locations) < 1 {
+		err = errors.New("could not decode first frame")
+	}
+	if err == nil {
+		scope = FrameToScope(nil, thread.BinInfo(), thread.ProcessMemory(), g, locations...)
+	}
+	vals := make([]*Variable, len(lp.exprs))
+	for i, expr := range lp.exprs {
+		var v *Variable
+		if err == nil {
+			var everr error
+			v, everr = scope.evalAST(expr)
+			if everr != nil {
+				v = &Variable{Unreadable: everr}
+			}
+		} else {
+			v = &Variable{Unreadable: err}
+		}
+		if v.Unreadable == nil {
+			v.loadValue(logpointLoadConfig)
+		}
+		vals[i] = v
+	}
+	return vals
+}
+
+// appendLogpointMessage adds msg to the buffer of logpoint messages,
+// discarding the oldest message if the buffer is full.
+func (t *Target) appendLogpointMessage(msg LogpointMessage) {
+	if len(t.logpointMessages) >= maxLogpointMessages {
+		copy(t.logpointMessages, t.logpointMessages[1:])
+		t.logpointMessages = t.logpointMessages[:len(t.logpointMessages)-1]
+		t.logpointMessagesDropped++
+	}
+	t.logpointMessages = append(t.logpointMessages, msg)
+}
+
+// collectLogpointMessage moves the message produced by a logpoint hit by
+// thread, if any, to the function set by SetLogpointOutput or, if there
+// isn't one, to the buffer of logpoint messages.
+func (t *Target) collectLogpointMessage(thread Thread) {
+	bpstate := thread.Breakpoint()
+	if bpstate.LogMessage == nil {
+		return
+	}
+	if t.logpointOutput != nil {
+		t.logpointOutput(*bpstate.LogMessage)
+	} else {
+		t.appendLogpointMessage(*bpstate.LogMessage)
+	}
+	bpstate.LogMessage = nil
+}
+
+// GetBufferedLogpointMessages returns the messages produced by logpoints
+// since the last call and the number of messages that were discarded
+// because the buffer was full, the buffer is emptied.
+func (t *Target) GetBufferedLogpointMessages() ([]LogpointMessage, int) {
+	msgs, dropped := t.logpointMessages, t.logpointMessagesDropped
+	t.logpointMessages = nil
+	t.logpointMessagesDropped = 0
+	return msgs, dropped
+}
@@ -0,0 +1,171 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"reflect"
+	"strings"
+)
+
+// Map filters select the entries of a map using a predicate on their keys
+// or values, for example m[@key == "a"] or m[@value.Count > 10]. The
+// predicate is evaluated while the map is loaded, only the entries that
+// satisfy it are loaded.
+//
+// Since @ can not appear in a Go expression @key and @value are replaced
+// with mapFilterKeyIdent and mapFilterValueIdent before parsing.
+const (
+	mapFilterKeyIdent   = "__delve_map_key"
+	mapFilterValueIdent = "__delve_map_value"
+)
+
+var errMapFilterOutside = errors.New("@key and @value can only be used to filter the entries of a map")
+
+// MapFilterResult describes the entries examined while loading a map
+// through a filter.
+type MapFilterResult struct {
+	// Scanned is the number of entries examined, not counting the entries
+	// skipped by reslicing the map. Loading can resume from the first
+	// entry that wasn't examined by reslicing the map at the number of
+	// skipped entries plus Scanned.
+	Scanned int64
+	// Matched is the number of entries that satisfied the filter.
+	Matched int64
+}
+
+// mapFilter is the predicate used to select the entries of a map.
+type mapFilter struct {
+	scope *EvalScope
+	expr  ast.Expr
+}
+
+// rewriteMapFilterIdents replaces @key and @value in expr, outside of
+// string and character literals, with identifiers the parser accepts.
+func rewriteMapFilterIdents(expr string) string {
+	if !strings.Contains(expr, "@") {
+		return expr
+	}
+	var buf strings.Builder
+	var quote byte
+	for i := 0; i < len(expr); i++ {
+		ch := expr[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' && quote != '`' && i+1 < len(expr) {
+				buf.WriteByte(ch)
+				i++
+				ch = expr[i]
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'' || ch == '`':
+			quote = ch
+		case ch == '@':
+			if ident, n := mapFilterIdent(expr[i+1:]); n > 0 {
+				buf.WriteString(ident)
+				i += n
+				continue
+			}
+		}
+		buf.WriteByte(ch)
+	}
+	return buf.String()
+}
+
+// mapFilterIdent returns the identifier replacing the name at the start of
+// s, if it is either key or value, and its length.
+func mapFilterIdent(s string) (string, int) {
+	for _, r := range []struct{ name, ident string }{{"key", mapFilterKeyIdent}, {"value", mapFilterValueIdent}} {
+		if strings.HasPrefix(s, r.name) && (len(s) == len(r.name) || !isIdentByte(s[len(r.name)])) {
+			return r.ident, len(r.name)
+		}
+	}
+	return "", 0
+}
+
+func isIdentByte(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+}
+
+// isMapFilter returns true if expr uses @key or @value.
+func isMapFilter(expr ast.Expr) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && (ident.Name == mapFilterKeyIdent || ident.Name == mapFilterValueIdent) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// mapFilterExprToString is like exprToString but prints @key and @value.
+func mapFilterExprToString(expr ast.Expr) string {
+	s := exprToString(expr)
+	s = strings.ReplaceAll(s, mapFilterKeyIdent, "@key")
+	return strings.ReplaceAll(s, mapFilterValueIdent, "@value")
+}
+
+// filterMap returns a copy of the map xev that, when loaded, only loads the
+// entries satisfying the predicate filter.
+func (scope *EvalScope) filterMap(xev *Variable, filter ast.Expr) (*Variable, error) {
+	if condHasCalls(filter) {
+		return nil, errors.New("function calls are not allowed in map filters")
+	}
+	r := xev.clone()
+	r.Children = nil
+	r.loaded = false
+	r.mapFilter = &mapFilter{scope: scope, expr: filter}
+	return r, nil
+}
+
+// match returns true if the entry with the given key and value satisfies
+// the filter.
+func (f *mapFilter) match(key, val *Variable) (bool, error) {
+	fscope := *f.scope
+	// the predicate loads the values it uses, the entry is loaded again
+	// using the load configuration of the map if it matches.
+	fscope.mapKey, fscope.mapValue = key.clone(), val.clone()
+	v, err := fscope.evalAST(f.expr)
+	if err != nil {
+		return false, err
+	}
+	v.loadValue(loadSingleValue)
+	if v.Unreadable != nil {
+		return false, v.Unreadable
+	}
+	if v.Kind != reflect.Bool {
+		return false, fmt.Errorf("map filter %s is not a boolean expression", mapFilterExprToString(f.expr))
+	}
+	return constant.BoolVal(v.Value), nil
+}
+
+// mapFilterEntry returns the key or value of the map entry tested by a map
+// filter, if name is one of the identifiers replacing @key and @value.
+func (scope *EvalScope) mapFilterEntry(name string) (*Variable, bool, error) {
+	var v *Variable
+	switch name {
+	case mapFilterKeyIdent:
+		v = scope.mapKey
+	case mapFilterValueIdent:
+		v = scope.mapValue
+	default:
+		return nil, false, nil
+	}
+	if v == nil {
+		return nil, true, errMapFilterOutside
+	}
+	if v.Kind == reflect.Interface {
+		// compare the concrete value stored in interface keys and values
+		v.loadValue(loadFullValue)
+		if v.Unreadable != nil {
+			return nil, true, v.Unreadable
+		}
+		if len(v.Children) > 0 {
+			return &v.Children[0], true, nil
+		}
+	}
+	return v, true, nil
+}
@@ -43,7 +43,8 @@ func (m *memCache) ReadMemory(data []byte, addr uint64) (n int, err error) {
 		if !m.loaded {
 			_, err := m.mem.ReadMemory(m.cache, m.cacheAddr)
 			if err != nil {
-				return 0, err
+				// part of the cached area could still be readable
+				return m.mem.ReadMemory(data, addr)
 			}
 			m.loaded = true
 		}
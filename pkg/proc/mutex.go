@@ -0,0 +1,323 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Flags and shifts of the state of a sync.Mutex, see the sync package.
+const (
+	syncMutexLocked      = 1
+	syncMutexWoken       = 2
+	syncMutexStarving    = 4
+	syncMutexWaiterShift = 3
+)
+
+// MutexInfo describes the state of a sync.Mutex or sync.RWMutex.
+type MutexInfo struct {
+	Addr uint64
+	// RW is true for a sync.RWMutex.
+	RW bool
+
+	// Locked is true if the mutex, or the write lock of a read-write mutex,
+	// is held.
+	Locked   bool
+	Woken    bool
+	Starving bool
+	// Waiters is the number of goroutines waiting to acquire the mutex, or
+	// the write lock of a read-write mutex, as recorded by its state.
+	Waiters int64
+
+	// Readers is the number of goroutines holding the read lock of a
+	// read-write mutex.
+	Readers int64
+	// WriterPending is true if a writer holds the write lock, or is waiting
+	// for the readers to release the read lock.
+	WriterPending bool
+
+	// Waiting lists the goroutines parked acquiring the mutex, or the write
+	// lock of a read-write mutex.
+	Waiting []int
+	// WaitingReaders lists the goroutines parked acquiring the read lock of
+	// a read-write mutex.
+	WaitingReaders []int
+
+	// PossibleOwners lists the goroutines that may hold the lock, see
+	// EvalScope.MutexInfo.
+	PossibleOwners []MutexOwner
+}
+
+// MutexOwner is a goroutine that may hold a lock.
+type MutexOwner struct {
+	G *G
+	// Frame is the frame of the function that acquired the lock.
+	Frame *Stackframe
+	// Reader is true if the read lock of a read-write mutex was acquired.
+	Reader bool
+	// ReferencesMutex is true if the mutex is reachable from the stack of
+	// the goroutine.
+	ReferencesMutex bool
+}
+
+// lockCall is a call to one of the lock or unlock methods of the sync
+// package.
+type lockCall struct {
+	pc     uint64
+	lock   bool
+	reader bool
+}
+
+// mutexLockFunctions and rwmutexLockFunctions map the functions that
+// acquire and release a mutex and a read-write mutex, respectively, to the
+// kind of call. The slow paths are included because the fast paths are
+// usually inlined.
+var (
+	mutexLockFunctions = map[string]lockCall{
+		"sync.(*Mutex).Lock":       {lock: true},
+		"sync.(*Mutex).lockSlow":   {lock: true},
+		"sync.(*Mutex).Unlock":     {},
+		"sync.(*Mutex).unlockSlow": {},
+	}
+	rwmutexLockFunctions = map[string]lockCall{
+		"sync.(*RWMutex).Lock":            {lock: true},
+		"sync.(*RWMutex).RLock":           {lock: true, reader: true},
+		"sync.runtime_SemacquireRWMutexR": {lock: true, reader: true},
+		"sync.(*RWMutex).Unlock":          {},
+		"sync.(*RWMutex).RUnlock":         {reader: true},
+		"sync.(*RWMutex).rUnlockSlow":     {reader: true},
+	}
+)
+
+// MutexInfo evaluates expr, which must be a sync.Mutex, a sync.RWMutex or
+// a pointer to one of them, and describes the state of the lock.
+//
+// The goroutines parked on the semaphores of the lock are read from
+// runtime.semtable and from the receivers of the sync methods they are
+// blocked in.
+// The runtime does not record which goroutine holds a lock, instead the
+// stacks of all other goroutines are scanned for frames between a call
+// that acquires a lock of the same kind and the call that releases it. If
+// the lock is reachable from the stack of some of those goroutines only
+// they are returned. Owners found this way should be treated as a best
+// effort guess.
+func (scope *EvalScope) MutexInfo(expr string) (*MutexInfo, error) {
+	v, err := scope.EvalExpression(expr, loadSingleValue)
+	if err != nil {
+		return nil, err
+	}
+	if v.Kind == reflect.Ptr {
+		v = v.maybeDereference()
+	}
+	if v.Unreadable != nil {
+		return nil, v.Unreadable
+	}
+	r := &MutexInfo{Addr: v.Addr}
+	if v.DwarfType == nil || v.Addr == 0 {
+		return nil, fmt.Errorf("%s is not addressable", expr)
+	}
+	var semas, readerSemas []uint64
+	switch v.DwarfType.Common().Name {
+	case "sync.Mutex":
+		sema, err := r.loadMutex(v)
+		if err != nil {
+			return nil, err
+		}
+		semas = []uint64{sema}
+	case "sync.RWMutex":
+		r.RW = true
+		w, err := v.structMember("w")
+		if err != nil {
+			return nil, err
+		}
+		sema, err := r.loadMutex(w)
+		if err != nil {
+			return nil, err
+		}
+		readerCount, err := syncIntMember(v, "readerCount")
+		if err != nil {
+			return nil, err
+		}
+		readerWait, err := syncIntMember(v, "readerWait")
+		if err != nil {
+			return nil, err
+		}
+		// a writer holding w announces itself by subtracting the maximum
+		// number of readers from readerCount and waits for readerWait
+		// readers to leave, the readers arriving later are parked.
+		r.WriterPending = readerCount < 0
+		if r.WriterPending {
+			r.Readers = readerWait
+		} else {
+			r.Readers = readerCount
+		}
+		r.Locked = r.WriterPending && readerWait == 0
+		writerSem, err := v.structMember("writerSem")
+		if err != nil {
+			return nil, err
+		}
+		readerSem, err := v.structMember("readerSem")
+		if err != nil {
+			return nil, err
+		}
+		semas = []uint64{sema, writerSem.Addr}
+		readerSemas = []uint64{readerSem.Addr}
+	default:
+		return nil, fmt.Errorf("%s is not a sync.Mutex or sync.RWMutex (%s)", expr, v.TypeString())
+	}
+
+	waiters := semaWaiters(scope.BinInfo, scope.Mem, append(semas, readerSemas...))
+	for _, addr := range semas {
+		r.Waiting = append(r.Waiting, waiters[addr]...)
+	}
+	for _, addr := range readerSemas {
+		r.WaitingReaders = append(r.WaitingReaders, waiters[addr]...)
+	}
+
+	if err := scope.findMutexOwners(r); err != nil {
+		return nil, err
+	}
+	sort.Ints(r.Waiting)
+	sort.Ints(r.WaitingReaders)
+	return r, nil
+}
+
+// loadMutex reads the state of the sync.Mutex v into r and returns the
+// address of its semaphore. Since Go 1.24 sync.Mutex wraps the
+// internal/sync.Mutex in field mu.
+func (r *MutexInfo) loadMutex(v *Variable) (uint64, error) {
+	if mu, err := v.structMember("mu"); err == nil {
+		v = mu
+	}
+	state, err := syncIntMember(v, "state")
+	if err != nil {
+		return 0, err
+	}
+	sema, err := v.structMember("sema")
+	if err != nil {
+		return 0, err
+	}
+	r.Locked = state&syncMutexLocked != 0
+	r.Woken = state&syncMutexWoken != 0
+	r.Starving = state&syncMutexStarving != 0
+	r.Waiters = state >> syncMutexWaiterShift
+	return sema.Addr, nil
+}
+
+// findMutexOwners sets r.PossibleOwners and adds to r.Waiting and
+// r.WaitingReaders the goroutines blocked on the lock that were not found
+// in runtime.semtable, for example because they have not parked yet.
+func (scope *EvalScope) findMutexOwners(r *MutexInfo) error {
+	t := scope.target
+	if t == nil {
+		return errors.New("no target")
+	}
+	if !r.Locked && r.Readers == 0 && !r.WriterPending {
+		return nil
+	}
+	gs, _, err := GoroutinesInfo(t, 0, 0)
+	if err != nil {
+		return err
+	}
+	waiting := map[int]bool{}
+	for _, goid := range append(r.Waiting, r.WaitingReaders...) {
+		waiting[goid] = true
+	}
+	lockFunctions := mutexLockFunctions
+	if r.RW {
+		lockFunctions = rwmutexLockFunctions
+	}
+	calls := map[*Function][]lockCall{}
+
+	var owners []MutexOwner
+	for _, g := range gs {
+		if g.Unreadable != nil || waiting[g.ID] {
+			continue
+		}
+		frames, err := g.Stacktrace(deadlockStackDepth, 0)
+		if err != nil {
+			continue
+		}
+		if g.Status == Gwaiting {
+			if bg := t.classifyBlocked(g, frames); bg != nil && bg.Kind.IsMutex() && bg.waitsOn(r.Addr) {
+				if bg.Kind == BlockedOnRWMutexRead {
+					r.WaitingReaders = append(r.WaitingReaders, g.ID)
+				} else {
+					r.Waiting = append(r.Waiting, g.ID)
+				}
+				continue
+			}
+		}
+		for i := range frames {
+			fn := frames[i].Call.Fn
+			if fn == nil || frames[i].Inlined {
+				// inlined frames are examined as part of the function
+				// containing them
+				continue
+			}
+			switch fn.PackageName() {
+			case "runtime", "sync", "internal/sync":
+				continue
+			}
+			fncalls, ok := calls[fn]
+			if !ok {
+				fncalls = t.lockCalls(fn, lockFunctions)
+				calls[fn] = fncalls
+			}
+			if held, reader := criticalSection(fncalls, frames[i].Current.PC); held {
+				owners = append(owners, MutexOwner{G: g, Frame: &frames[i], Reader: reader})
+				owners[len(owners)-1].ReferencesMutex = t.goroutineRefs(g, frames).contains(r.Addr, BlockedOnMutex)
+				break
+			}
+		}
+	}
+
+	references := false
+	for _, o := range owners {
+		if o.ReferencesMutex {
+			references = true
+			break
+		}
+	}
+	for _, o := range owners {
+		if o.ReferencesMutex || !references {
+			r.PossibleOwners = append(r.PossibleOwners, o)
+		}
+	}
+	return nil
+}
+
+// lockCalls returns the calls to the functions in lockFunctions made by
+// fn, sorted by address.
+func (t *Target) lockCalls(fn *Function, lockFunctions map[string]lockCall) []lockCall {
+	text, err := disassemble(t.Memory(), nil, t.Breakpoints(), t.BinInfo(), fn.Entry, fn.End, false)
+	if err != nil {
+		return nil
+	}
+	var r []lockCall
+	for _, instr := range text {
+		if !instr.IsCall() || instr.DestLoc == nil || instr.DestLoc.Fn == nil {
+			continue
+		}
+		if c, ok := lockFunctions[strings.TrimPrefix(instr.DestLoc.Fn.Name, "internal/")]; ok {
+			c.pc = instr.Loc.PC
+			r = append(r, c)
+		}
+	}
+	return r
+}
+
+// criticalSection returns true if pc follows a call that acquires a lock
+// without an intervening call that releases it. Since the order of the
+// calls in the code is not the order of execution this is a heuristic.
+func criticalSection(calls []lockCall, pc uint64) (held, reader bool) {
+	for i := len(calls) - 1; i >= 0; i-- {
+		if calls[i].pc >= pc {
+			continue
+		}
+		return calls[i].lock, calls[i].reader
+	}
+	return false, false
+}
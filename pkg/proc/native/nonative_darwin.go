@@ -12,6 +12,9 @@ import (
 
 var ErrNativeBackendDisabled = errors.New("native backend disabled during compilation")
 
+// resumeThreadSupported is true if SetResumeThread is implemented.
+const resumeThreadSupported = false
+
 // Launch returns ErrNativeBackendDisabled.
 func Launch(_ []string, _ string, _ proc.LaunchFlags, _ []string, _ string, _ [3]string) (*proc.Target, error) {
 	return nil, ErrNativeBackendDisabled
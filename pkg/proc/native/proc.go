@@ -1,6 +1,7 @@
 package native
 
 import (
+	"fmt"
 	"os"
 	"runtime"
 	"sync"
@@ -37,12 +38,20 @@ type nativeProcess struct {
 	// why a thread is found to have stopped.
 	manualStopRequested bool
 
+	// resumeThread, if not zero, is the ID of the only thread resumed by
+	// ContinueOnce, see SetResumeThread.
+	resumeThread int
+
 	// Controlling terminal file descriptor for
 	// this process.
 	ctty *os.File
 
 	iscgo bool
 
+	// pointerAuthMask is the mask of the bits used by pointer authentication
+	// codes in code addresses, if the target uses them.
+	pointerAuthMask uint64
+
 	exited, detached bool
 }
 
@@ -79,6 +88,37 @@ func (dbp *nativeProcess) Recorded() (bool, string) { return false, "" }
 // recorded traces.
 func (dbp *nativeProcess) Restart(string) (proc.Thread, error) { return nil, proc.ErrNotRecorded }
 
+// SeekTicks will always return an error in the native proc backend, only
+// for recorded traces.
+func (dbp *nativeProcess) SeekTicks(int64) (proc.Thread, error) { return nil, proc.ErrNotRecorded }
+
+// PassSignals reports that every signal is delivered to the target, the
+// native backend forwards all signals it doesn't use itself when resuming
+// the target.
+func (dbp *nativeProcess) PassSignals() proc.SignalPassing {
+	return proc.SignalPassing{All: true}
+}
+
+// SetPassSignals will always return an error in the native proc backend,
+// the list of signals passed to the target can not be changed.
+func (dbp *nativeProcess) SetPassSignals([]int) error { return proc.ErrPassSignalsUnsupported }
+
+// SetResumeThread restricts ContinueOnce to resuming the thread tid, all
+// other threads, including the ones created while the process runs, are
+// kept stopped. If tid is zero all threads are resumed.
+func (dbp *nativeProcess) SetResumeThread(tid int) error {
+	if !resumeThreadSupported {
+		return proc.ErrResumeThreadUnsupported
+	}
+	if tid != 0 {
+		if _, ok := dbp.threads[tid]; !ok {
+			return fmt.Errorf("unknown thread %d", tid)
+		}
+	}
+	dbp.resumeThread = tid
+	return nil
+}
+
 // ChangeDirection will always return an error in the native proc backend, only for
 // recorded traces.
 func (dbp *nativeProcess) ChangeDirection(dir proc.Direction) error {
@@ -94,6 +134,12 @@ func (p *nativeProcess) GetDirection() proc.Direction { return proc.Forward }
 // When will always return an empty string and nil, not supported on native proc backend.
 func (dbp *nativeProcess) When() (string, error) { return "", nil }
 
+// Position will always return an error on the native proc backend,
+// only supported for recorded traces.
+func (dbp *nativeProcess) Position() (proc.RecordingPosition, error) {
+	return proc.RecordingPosition{}, proc.ErrNotRecorded
+}
+
 // Checkpoint will always return an error on the native proc backend,
 // only supported for recorded traces.
 func (dbp *nativeProcess) Checkpoint(string) (int, error) { return -1, proc.ErrNotRecorded }
@@ -279,7 +325,7 @@ func (dbp *nativeProcess) ContinueOnce() (proc.Thread, proc.StopReason, error) {
 func (dbp *nativeProcess) FindBreakpoint(pc uint64, adjustPC bool) (*proc.Breakpoint, bool) {
 	if adjustPC {
 		// Check to see if address is past the breakpoint, (i.e. breakpoint was hit).
-		if bp, ok := dbp.breakpoints.M[pc-uint64(dbp.bi.Arch.BreakpointSize())]; ok {
+		if bp, ok := dbp.breakpoints.M[pc-uint64(dbp.bi.Arch.BreakpointSize())]; ok && bp.WatchType == 0 {
 			return bp, true
 		}
 	}
@@ -308,7 +354,8 @@ func (dbp *nativeProcess) initialize(path string, debugInfoDirs []string) (*proc
 		DebugInfoDirs:       debugInfoDirs,
 		DisableAsyncPreempt: runtime.GOOS == "windows" || runtime.GOOS == "freebsd",
 		StopReason:          stopReason,
-		CanDump:             runtime.GOOS == "linux"})
+		CanDump:             runtime.GOOS == "linux",
+		PointerAuthMask:     dbp.pointerAuthMask})
 	if err != nil {
 		return nil, err
 	}
@@ -206,6 +206,9 @@ func (dbp *nativeProcess) requestManualStop() (err error) {
 	return nil
 }
 
+// resumeThreadSupported is true if SetResumeThread is implemented.
+const resumeThreadSupported = false
+
 var couldNotGetThreadCount = errors.New("could not get thread count")
 var couldNotGetThreadList = errors.New("could not get thread list")
 
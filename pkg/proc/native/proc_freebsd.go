@@ -19,6 +19,9 @@ import (
 	isatty "github.com/mattn/go-isatty"
 )
 
+// resumeThreadSupported is true if SetResumeThread is implemented.
+const resumeThreadSupported = false
+
 // Process statuses
 const (
 	statusIdle     = 1
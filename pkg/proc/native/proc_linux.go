@@ -41,6 +41,9 @@ const (
 	_ADDR_NO_RANDOMIZE        = 0x0040000  // ADDR_NO_RANDOMIZE linux constant
 )
 
+// resumeThreadSupported is true if SetResumeThread is implemented.
+const resumeThreadSupported = true
+
 // osProcessDetails contains Linux specific
 // process details.
 type osProcessDetails struct {
@@ -183,6 +186,10 @@ func initialize(dbp *nativeProcess) error {
 		comm = match[1]
 	}
 	dbp.os.comm = strings.ReplaceAll(string(comm), "%", "%%")
+
+	// Errors are ignored, without the mask stack traces through code using
+	// pointer authentication will be truncated but everything else works.
+	dbp.execPtraceFunc(func() { dbp.pointerAuthMask, _ = ptraceGetPacMask(dbp.pid) })
 	return nil
 }
 
@@ -269,7 +276,7 @@ func (dbp *nativeProcess) addThread(tid int, attach bool) (*nativeThread, error)
 		dbp.memthread = dbp.threads[tid]
 	}
 	for _, bp := range dbp.Breakpoints().M {
-		if bp.WatchType != 0 {
+		if bp.WatchType != 0 && !bp.WatchType.Software() {
 			err := dbp.threads[tid].writeHardwareBreakpoint(bp.Addr, bp.WatchType, bp.HWBreakIndex)
 			if err != nil {
 				return nil, err
@@ -378,7 +385,11 @@ func (dbp *nativeProcess) trapWaitInternal(pid int, options trapWaitOptions) (*n
 				dbp.threads[int(wpid)].os.running = false
 				return nil, nil
 			}
-			if err = th.Continue(); err != nil {
+			if dbp.resumeThread != 0 {
+				// only the thread selected by SetResumeThread is allowed to
+				// run, the new thread is kept stopped.
+				th.os.running = false
+			} else if err = th.Continue(); err != nil {
 				if err == sys.ESRCH {
 					// thread died while we were adding it
 					delete(dbp.threads, th.ID)
@@ -513,6 +524,9 @@ func (dbp *nativeProcess) resume() error {
 	}
 	// everything is resumed
 	for _, thread := range dbp.threads {
+		if dbp.resumeThread != 0 && thread.ID != dbp.resumeThread {
+			continue
+		}
 		if err := thread.resume(); err != nil && err != sys.ESRCH {
 			return err
 		}
@@ -596,6 +610,11 @@ func (dbp *nativeProcess) stop(trapthread *nativeThread) (*nativeThread, error)
 				err1 = err
 				continue
 			}
+			if !th.os.setbp && th.ThreadID() == trapthread.ThreadID() {
+				// SetCurrentBreakpoint discarded the stop of the thread (a
+				// watchpoint hit that did not access the watched memory).
+				switchTrapthread = true
+			}
 		}
 
 		if th.CurrentBreakpoint.Breakpoint == nil && th.os.setbp && (th.Status != nil) && ((*sys.WaitStatus)(th.Status).StopSignal() == sys.SIGTRAP) && dbp.BinInfo().Arch.BreakInstrMovesPC() {
@@ -233,6 +233,9 @@ const (
 	waitDontHandleExceptions
 )
 
+// resumeThreadSupported is true if SetResumeThread is implemented.
+const resumeThreadSupported = false
+
 const _MS_VC_EXCEPTION = 0x406D1388 // part of VisualC protocol to set thread names
 
 func (dbp *nativeProcess) waitForDebugEvent(flags waitForDebugEventFlags) (threadID, exitCode int, err error) {
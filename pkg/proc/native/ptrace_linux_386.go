@@ -92,3 +92,8 @@ func processVmWrite(tid int, addr uintptr, data []byte) (int, error) {
 	}
 	return int(n), nil
 }
+
+// ptraceGetPacMask returns zero, pointer authentication only exists on arm64.
+func ptraceGetPacMask(tid int) (uint64, error) {
+	return 0, nil
+}
@@ -40,3 +40,8 @@ func ptraceGetRegset(tid int) (regset amd64util.AMD64Xstate, err error) {
 	err = amd64util.AMD64XstateRead(regset.Xsave, false, &regset)
 	return
 }
+
+// ptraceGetPacMask returns zero, pointer authentication only exists on arm64.
+func ptraceGetPacMask(tid int) (uint64, error) {
+	return 0, nil
+}
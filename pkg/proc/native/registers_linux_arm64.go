@@ -2,6 +2,7 @@ package native
 
 import (
 	"debug/elf"
+	"encoding/binary"
 	"fmt"
 	"syscall"
 	"unsafe"
@@ -9,7 +10,6 @@ import (
 	sys "golang.org/x/sys/unix"
 
 	"github.com/go-delve/delve/pkg/dwarf/op"
-	"github.com/go-delve/delve/pkg/dwarf/regnum"
 	"github.com/go-delve/delve/pkg/proc"
 	"github.com/go-delve/delve/pkg/proc/linutil"
 )
@@ -18,6 +18,9 @@ const (
 	_AARCH64_GREGS_SIZE  = 34 * 8
 	_AARCH64_FPREGS_SIZE = 32*16 + 8
 	_NT_ARM_TLS          = 0x401 // used in PTRACE_GETREGSET on ARM64 to retrieve the value of TPIDR_EL0, see source/include/uapi/linux/elf.h and source/arch/arm64/kernel/ptrace.c
+	_NT_ARM_HW_WATCH     = 0x403 // used in PTRACE_GETREGSET and PTRACE_SETREGSET on ARM64 to access the watchpoint registers
+	_NT_ARM_SVE          = 0x405 // used in PTRACE_GETREGSET on ARM64 to retrieve the SVE registers
+	_NT_ARM_PAC_MASK     = 0x406 // used in PTRACE_GETREGSET on ARM64 to retrieve the pointer authentication masks
 )
 
 func ptraceGetGRegs(pid int, regs *linutil.ARM64PtraceRegs) (err error) {
@@ -47,8 +50,63 @@ func ptraceSetGRegs(pid int, regs *linutil.ARM64PtraceRegs) (err error) {
 	return
 }
 
+// ptraceGetHWDebugRegs reads the watchpoint registers of the specified
+// thread.
+func ptraceGetHWDebugRegs(tid int, drs *linutil.ARM64HWDebugRegs) (err error) {
+	iov := sys.Iovec{Base: (*byte)(unsafe.Pointer(drs)), Len: uint64(unsafe.Sizeof(*drs))}
+	_, _, err = syscall.Syscall6(syscall.SYS_PTRACE, sys.PTRACE_GETREGSET, uintptr(tid), uintptr(_NT_ARM_HW_WATCH), uintptr(unsafe.Pointer(&iov)), 0, 0)
+	if err == syscall.Errno(0) {
+		err = nil
+	}
+	return
+}
+
+// ptraceSetHWDebugRegs writes the watchpoint registers of the specified
+// thread.
+func ptraceSetHWDebugRegs(tid int, drs *linutil.ARM64HWDebugRegs) (err error) {
+	// The kernel rejects writes to registers the CPU does not have.
+	iov := sys.Iovec{Base: (*byte)(unsafe.Pointer(drs)), Len: uint64(drs.Size())}
+	_, _, err = syscall.Syscall6(syscall.SYS_PTRACE, sys.PTRACE_SETREGSET, uintptr(tid), uintptr(_NT_ARM_HW_WATCH), uintptr(unsafe.Pointer(&iov)), 0, 0)
+	if err == syscall.Errno(0) {
+		err = nil
+	}
+	return
+}
+
+// ptraceGetSiginfo returns the signal number, signal code and faulting
+// address of the signal that stopped the specified thread.
+func ptraceGetSiginfo(tid int) (signo, code int32, addr uint64, err error) {
+	var siginfo [128]byte
+	_, _, err = syscall.Syscall6(syscall.SYS_PTRACE, sys.PTRACE_GETSIGINFO, uintptr(tid), 0, uintptr(unsafe.Pointer(&siginfo[0])), 0, 0)
+	if err != syscall.Errno(0) {
+		return 0, 0, 0, err
+	}
+	// see struct siginfo in source/include/uapi/asm-generic/siginfo.h
+	signo = int32(binary.LittleEndian.Uint32(siginfo[0:]))
+	code = int32(binary.LittleEndian.Uint32(siginfo[8:]))
+	addr = binary.LittleEndian.Uint64(siginfo[16:])
+	return signo, code, addr, nil
+}
+
 // ptraceGetFpRegset returns floating point registers of the specified thread
 // using PTRACE.
+// ptraceGetPacMask returns the bits of instruction addresses used by
+// pointer authentication codes, or zero if pointer authentication is not
+// enabled for the target.
+func ptraceGetPacMask(tid int) (uint64, error) {
+	var mask [2]uint64 // struct user_pac_mask in source/arch/arm64/include/uapi/asm/ptrace.h
+	iov := sys.Iovec{Base: (*byte)(unsafe.Pointer(&mask[0])), Len: uint64(unsafe.Sizeof(mask))}
+	_, _, err := syscall.Syscall6(syscall.SYS_PTRACE, sys.PTRACE_GETREGSET, uintptr(tid), uintptr(_NT_ARM_PAC_MASK), uintptr(unsafe.Pointer(&iov)), 0, 0)
+	if err != syscall.Errno(0) {
+		if err == syscall.EINVAL || err == syscall.ENODEV {
+			// pointer authentication not supported by the CPU or the kernel
+			return 0, nil
+		}
+		return 0, err
+	}
+	return mask[1], nil
+}
+
 func ptraceGetFpRegset(tid int) (fpregset []byte, err error) {
 	var arm64_fpregs [_AARCH64_FPREGS_SIZE]byte
 	iov := sys.Iovec{Base: &arm64_fpregs[0], Len: _AARCH64_FPREGS_SIZE}
@@ -66,6 +124,49 @@ func ptraceGetFpRegset(tid int) (fpregset []byte, err error) {
 	return fpregset, err
 }
 
+// ptraceSetFpRegset sets the floating point registers of the specified
+// thread using PTRACE, fpregset must not contain the FPSR and FPCR
+// registers.
+func ptraceSetFpRegset(tid int, fpregset []byte) (err error) {
+	iov := sys.Iovec{Base: &fpregset[0], Len: uint64(len(fpregset))}
+	_, _, err = syscall.Syscall6(syscall.SYS_PTRACE, sys.PTRACE_SETREGSET, uintptr(tid), uintptr(elf.NT_FPREGSET), uintptr(unsafe.Pointer(&iov)), 0, 0)
+	if err == syscall.Errno(0) {
+		err = nil
+	}
+	return
+}
+
+// ptraceGetSveRegset returns the SVE registers of the specified thread, or
+// nil if the CPU does not support SVE.
+// The size of the register set depends on the current vector length of
+// the thread, which can change at any time, so the header is read first.
+func ptraceGetSveRegset(tid int) (sveregset []byte, err error) {
+	getRegset := func(buf []byte) error {
+		iov := sys.Iovec{Base: &buf[0], Len: uint64(len(buf))}
+		_, _, err := syscall.Syscall6(syscall.SYS_PTRACE, sys.PTRACE_GETREGSET, uintptr(tid), uintptr(_NT_ARM_SVE), uintptr(unsafe.Pointer(&iov)), 0, 0)
+		if err != syscall.Errno(0) {
+			return err
+		}
+		return nil
+	}
+
+	var hdr [linutil.ARM64SveHeaderSize]byte
+	if err := getRegset(hdr[:]); err != nil {
+		if err == syscall.EINVAL || err == syscall.ENODEV {
+			err = nil
+		}
+		return nil, err
+	}
+	sveregset = make([]byte, linutil.ARM64SveRegsetSize(hdr[:]))
+	if len(sveregset) < len(hdr) {
+		return nil, fmt.Errorf("wrong SVE register set size %d", len(sveregset))
+	}
+	if err := getRegset(sveregset); err != nil {
+		return nil, err
+	}
+	return sveregset, nil
+}
+
 // setPC sets PC to the value specified by 'pc'.
 func (thread *nativeThread) setPC(pc uint64) error {
 	ir, err := registers(thread)
@@ -84,19 +185,20 @@ func (thread *nativeThread) SetReg(regNum uint64, reg *op.DwarfRegister) error {
 		return err
 	}
 	r := ir.(*linutil.ARM64Registers)
-
-	switch regNum {
-	case regnum.ARM64_PC:
-		r.Regs.Pc = reg.Uint64Val
-	case regnum.ARM64_SP:
-		r.Regs.Sp = reg.Uint64Val
-	default:
-		//TODO(aarzilli): when the register calling convention is adopted by Go on
-		// arm64 this should be implemented.
-		return fmt.Errorf("changing register %d not implemented", regNum)
+	fpchanged, err := r.SetReg(regNum, reg)
+	if err != nil {
+		return err
 	}
 
-	thread.dbp.execPtraceFunc(func() { err = ptraceSetGRegs(thread.ID, r.Regs) })
+	thread.dbp.execPtraceFunc(func() {
+		err = ptraceSetGRegs(thread.ID, r.Regs)
+		if err != nil {
+			return
+		}
+		if fpchanged {
+			err = ptraceSetFpRegset(thread.ID, r.Fpregset)
+		}
+	})
 	return err
 }
 
@@ -54,7 +54,7 @@ func (t *nativeThread) StepInstruction() (err error) {
 		t.singleStepping = false
 	}()
 
-	if bp := t.CurrentBreakpoint.Breakpoint; bp != nil && bp.WatchType != 0 && t.dbp.Breakpoints().M[bp.Addr] == bp {
+	if bp := t.CurrentBreakpoint.Breakpoint; bp != nil && bp.WatchType != 0 && !bp.WatchType.Software() && t.dbp.Breakpoints().M[bp.Addr] == bp {
 		err = t.clearHardwareBreakpoint(bp.Addr, bp.WatchType, bp.HWBreakIndex)
 		if err != nil {
 			return err
@@ -70,7 +70,18 @@ func (t *nativeThread) StepInstruction() (err error) {
 	}
 
 	bp, ok := t.dbp.FindBreakpoint(pc, false)
-	if ok {
+	if ok && bp.WatchType.Execute() {
+		if bp != t.CurrentBreakpoint.Breakpoint {
+			// Clear the hardware breakpoint so that we can continue execution.
+			err = t.clearHardwareBreakpoint(bp.Addr, bp.WatchType, bp.HWBreakIndex)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				err = t.writeHardwareBreakpoint(bp.Addr, bp.WatchType, bp.HWBreakIndex)
+			}()
+		}
+	} else if ok && bp.WatchType == 0 {
 		// Clear the breakpoint so that we can continue execution.
 		err = t.clearSoftwareBreakpoint(bp)
 		if err != nil {
@@ -1,10 +1,8 @@
 package native
 
 import (
-	"debug/elf"
 	"fmt"
 	"syscall"
-	"unsafe"
 
 	sys "golang.org/x/sys/unix"
 
@@ -19,6 +17,20 @@ func (thread *nativeThread) fpRegisters() ([]proc.Register, []byte, error) {
 	fpregs := arm_fpregs.Decode()
 	if err != nil {
 		err = fmt.Errorf("could not get floating point registers: %v", err.Error())
+		return fpregs, arm_fpregs.Vregs, err
+	}
+
+	var sveregset []byte
+	thread.dbp.execPtraceFunc(func() { sveregset, err = ptraceGetSveRegset(thread.ID) })
+	if err == nil && sveregset != nil {
+		var sveregs linutil.ARM64SveRegs
+		err = linutil.ARM64SveRead(sveregset, &sveregs)
+		if err == nil {
+			fpregs = append(fpregs, sveregs.Decode()...)
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("could not get SVE registers: %v", err.Error())
 	}
 	return fpregs, arm_fpregs.Vregs, err
 }
@@ -29,12 +41,11 @@ func (t *nativeThread) restoreRegisters(savedRegs proc.Registers) error {
 	var restoreRegistersErr error
 	t.dbp.execPtraceFunc(func() {
 		restoreRegistersErr = ptraceSetGRegs(t.ID, sr.Regs)
-		if restoreRegistersErr != syscall.Errno(0) {
+		if restoreRegistersErr != nil {
 			return
 		}
 		if sr.Fpregset != nil {
-			iov := sys.Iovec{Base: &sr.Fpregset[0], Len: uint64(len(sr.Fpregset))}
-			_, _, restoreRegistersErr = syscall.Syscall6(syscall.SYS_PTRACE, sys.PTRACE_SETREGSET, uintptr(t.ID), uintptr(elf.NT_FPREGSET), uintptr(unsafe.Pointer(&iov)), 0, 0)
+			restoreRegistersErr = ptraceSetFpRegset(t.ID, sr.Fpregset)
 		}
 	})
 	if restoreRegistersErr == syscall.Errno(0) {
@@ -43,14 +54,108 @@ func (t *nativeThread) restoreRegisters(savedRegs proc.Registers) error {
 	return restoreRegistersErr
 }
 
+const _TRAP_HWBKPT = 4 // si_code of a SIGTRAP caused by a hardware breakpoint or watchpoint
+
+func (t *nativeThread) withDebugRegisters(f func(*linutil.ARM64HWDebugRegs) error) error {
+	var err error
+	t.dbp.execPtraceFunc(func() {
+		var drs linutil.ARM64HWDebugRegs
+		err = ptraceGetHWDebugRegs(t.ID, &drs)
+		if err != nil {
+			return
+		}
+		saved := drs
+		err = f(&drs)
+		if err == nil && drs != saved {
+			err = ptraceSetHWDebugRegs(t.ID, &drs)
+		}
+	})
+	if err == sys.ESRCH {
+		err = nil
+	}
+	return err
+}
+
 func (t *nativeThread) writeHardwareBreakpoint(addr uint64, wtype proc.WatchType, idx uint8) error {
-	return proc.ErrHWBreakUnsupported
+	if wtype.Execute() {
+		return proc.ErrHWBreakUnsupported
+	}
+	return t.withDebugRegisters(func(drs *linutil.ARM64HWDebugRegs) error {
+		return drs.SetWatchpoint(idx, addr, wtype.Read(), wtype.Write(), wtype.Size())
+	})
 }
 
 func (t *nativeThread) clearHardwareBreakpoint(addr uint64, wtype proc.WatchType, idx uint8) error {
-	return proc.ErrHWBreakUnsupported
+	if wtype.Execute() {
+		return proc.ErrHWBreakUnsupported
+	}
+	return t.withDebugRegisters(func(drs *linutil.ARM64HWDebugRegs) error {
+		drs.ClearWatchpoint(idx)
+		return nil
+	})
 }
 
+// findHardwareBreakpoint returns the watchpoint that stopped the thread,
+// using the address of the memory access reported by the kernel.
+// Watchpoint exceptions are taken before the access is executed. If the
+// access could not have touched any of the watched bytes the thread is
+// made to step over it and the stop is discarded.
 func (t *nativeThread) findHardwareBreakpoint() (*proc.Breakpoint, error) {
-	return nil, nil
+	var signo, code int32
+	var far uint64
+	var err error
+	t.dbp.execPtraceFunc(func() { signo, code, far, err = ptraceGetSiginfo(t.ID) })
+	if err != nil || signo != int32(sys.SIGTRAP) || code != _TRAP_HWBKPT {
+		return nil, nil
+	}
+
+	var retbp *proc.Breakpoint
+	spurious := false
+	err = t.withDebugRegisters(func(drs *linutil.ARM64HWDebugRegs) error {
+		idx, ok := drs.WatchpointFor(far)
+		if !ok {
+			spurious = true
+			return nil
+		}
+		for _, bp := range t.dbp.Breakpoints().M {
+			if bp.WatchType != 0 && !bp.WatchType.Software() && bp.HWBreakIndex == idx {
+				retbp = bp
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if spurious {
+		if err := t.stepOverWatchpoints(); err != nil {
+			return nil, err
+		}
+		t.os.setbp = false
+	}
+	return retbp, nil
+}
+
+// stepOverWatchpoints executes the current instruction with all
+// watchpoint registers disabled.
+func (t *nativeThread) stepOverWatchpoints() error {
+	var saved linutil.ARM64HWDebugRegs
+	err := t.withDebugRegisters(func(drs *linutil.ARM64HWDebugRegs) error {
+		saved = *drs
+		drs.ClearAll()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = t.singleStep()
+	err2 := t.withDebugRegisters(func(drs *linutil.ARM64HWDebugRegs) error {
+		*drs = saved
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return err2
 }
@@ -0,0 +1,127 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrStaleMapContinuation is returned when a map continuation token, see
+// LoadConfig.MapContinuation, does not describe a position in the current
+// state of the map, usually because the target ran and modified the map
+// after the token was returned.
+var ErrStaleMapContinuation = errors.New("stale map continuation token: the map changed since the token was returned")
+
+// mapPosition is the position of a mapIterator, it is the decoded form of
+// a map continuation token.
+type mapPosition struct {
+	// header of the map when the token was returned, used to detect stale
+	// tokens
+	buckets, oldbuckets uint64
+	numbuckets          uint64
+	count               int64
+
+	bucketIdx uint64
+	ovfDepth  int
+	idx       int64
+}
+
+const mapContinuationFormat = "m1:%x:%x:%x:%x:%x:%x:%x"
+
+func (pos *mapPosition) String() string {
+	return fmt.Sprintf(mapContinuationFormat, pos.buckets, pos.oldbuckets, pos.numbuckets, pos.count, pos.bucketIdx, pos.ovfDepth, pos.idx)
+}
+
+func parseMapContinuation(tok string) (*mapPosition, error) {
+	pos := &mapPosition{}
+	n, err := fmt.Sscanf(tok, mapContinuationFormat, &pos.buckets, &pos.oldbuckets, &pos.numbuckets, &pos.count, &pos.bucketIdx, &pos.ovfDepth, &pos.idx)
+	if err != nil || n != 7 {
+		return nil, fmt.Errorf("malformed map continuation token %q", tok)
+	}
+	return pos, nil
+}
+
+// position returns the current position of the iterator, the entry
+// returned by the last call to next is the last one before the position.
+func (it *mapIterator) position() *mapPosition {
+	return &mapPosition{
+		buckets:    it.buckets.Addr,
+		oldbuckets: it.oldbuckets.Addr,
+		numbuckets: it.numbuckets,
+		count:      it.v.Len,
+		bucketIdx:  it.bucketIdx,
+		ovfDepth:   it.ovfDepth,
+		idx:        it.idx,
+	}
+}
+
+// checkPosition returns ErrStaleMapContinuation if pos was not returned by
+// an iterator on the map in its current state.
+func (it *mapIterator) checkPosition(pos *mapPosition) error {
+	if it.buckets == nil || it.buckets.Addr != pos.buckets || it.oldbuckets.Addr != pos.oldbuckets || it.numbuckets != pos.numbuckets || it.v.Len != pos.count {
+		return ErrStaleMapContinuation
+	}
+	return nil
+}
+
+// seek moves the iterator to pos, without reading the buckets preceding
+// it.
+func (it *mapIterator) seek(pos *mapPosition) error {
+	if err := it.checkPosition(pos); err != nil {
+		return err
+	}
+	it.bidx = pos.bucketIdx
+	if !it.nextBucket() || it.bucketIdx != pos.bucketIdx {
+		return ErrStaleMapContinuation
+	}
+	for it.ovfDepth < pos.ovfDepth {
+		if it.overflow == nil || it.overflow.Addr == 0 || !it.nextBucket() {
+			return ErrStaleMapContinuation
+		}
+	}
+	if pos.idx > it.tophashes.Len {
+		return ErrStaleMapContinuation
+	}
+	it.idx = pos.idx
+	return nil
+}
+
+// page returns the part of v, an array, slice or map, requested by
+// cfg.Start and cfg.MapContinuation.
+func (v *Variable) page(cfg LoadConfig) (*Variable, error) {
+	if cfg.Start < 0 {
+		return nil, fmt.Errorf("negative start index %d", cfg.Start)
+	}
+	switch v.Kind {
+	case reflect.Array, reflect.Slice:
+		if cfg.MapContinuation != "" {
+			return nil, fmt.Errorf("map continuation token used with %s", v.TypeString())
+		}
+		if int64(cfg.Start) >= v.Len {
+			return nil, fmt.Errorf("start index %d out of bounds [0:%d]", cfg.Start, v.Len)
+		}
+		return v.reslice(int64(cfg.Start), v.Len)
+	case reflect.Map:
+		r := v.clone()
+		r.Children = nil
+		r.loaded = false
+		r.mapSkip += cfg.Start
+		if cfg.MapContinuation != "" {
+			pos, err := parseMapContinuation(cfg.MapContinuation)
+			if err != nil {
+				return nil, err
+			}
+			it := r.mapIterator()
+			if it == nil {
+				return nil, r.Unreadable
+			}
+			if err := it.checkPosition(pos); err != nil {
+				return nil, err
+			}
+			r.mapResume = pos
+		}
+		return r, nil
+	default:
+		return nil, fmt.Errorf("can not load %s starting at an offset", v.TypeString())
+	}
+}
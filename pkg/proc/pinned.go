@@ -0,0 +1,148 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// pinnedSchedulerFunctions are the runtime functions reached by a thread
+// when the goroutine it is running can not continue running by itself:
+// runtime.schedule is called when the goroutine blocks, yields, is
+// preempted or exits, runtime.stopTheWorldWithSema needs every other
+// thread to stop at a safe point.
+var pinnedSchedulerFunctions = []string{"runtime.schedule", "runtime.stopTheWorldWithSema"}
+
+// ErrPinnedGoroutineLeft is returned by ResumePinned when the pinned
+// goroutine can not make progress without running other goroutines.
+type ErrPinnedGoroutineLeft struct {
+	GoroutineID int
+	// Reason describes why the goroutine left its thread.
+	Reason string
+	// Location is the position of the goroutine in user code, if known.
+	Location *Location
+}
+
+func (err ErrPinnedGoroutineLeft) Error() string {
+	s := fmt.Sprintf("goroutine %d %s", err.GoroutineID, err.Reason)
+	if err.Location != nil && err.Location.Fn != nil {
+		s += fmt.Sprintf(" at %s:%d %s", err.Location.File, err.Location.Line, err.Location.Fn.Name)
+	}
+	return s + ", it can not continue while the other goroutines are frozen"
+}
+
+// pinnedResume describes the goroutine pinned by ResumePinned.
+type pinnedResume struct {
+	goid, tid int
+}
+
+// ResumePinned calls resume, a function that resumes the target like
+// Continue, Next or Step do, while only the thread running the selected
+// goroutine is allowed to run: every other thread, and therefore every
+// other goroutine, stays frozen where it is.
+//
+// Breakpoints are set on the entry points of the scheduler so that, if the
+// selected goroutine blocks on something that only another goroutine could
+// provide, yields, is preempted, exits or tries to stop the world the
+// target is stopped and an ErrPinnedGoroutineLeft error is returned,
+// instead of letting the thread run a different goroutine or waiting
+// forever.
+func (dbp *Target) ResumePinned(resume func() error) error {
+	if _, err := dbp.Valid(); err != nil {
+		return err
+	}
+	if dbp.GetDirection() == Backward {
+		return errors.New("can not resume a single goroutine backwards")
+	}
+	g := dbp.SelectedGoroutine()
+	if g == nil {
+		return errors.New("no goroutine selected")
+	}
+	if g.Thread == nil {
+		return fmt.Errorf("goroutine %d is not running on a thread and can not be pinned", g.ID)
+	}
+	tid := g.Thread.ThreadID()
+	if err := dbp.proc.SetResumeThread(tid); err != nil {
+		return err
+	}
+	defer dbp.proc.SetResumeThread(0)
+
+	for _, fnname := range pinnedSchedulerFunctions {
+		fn := dbp.BinInfo().LookupFunc[fnname]
+		if fn == nil {
+			continue
+		}
+		bp, err := dbp.SetBreakpoint(fn.Entry, PinnedSchedulerBreakpoint, nil)
+		if err != nil {
+			dbp.clearPinnedSchedulerBreakpoints()
+			return err
+		}
+		bp.Breaklets[len(bp.Breaklets)-1].callback = func(th Thread) (bool, error) {
+			return th.ThreadID() == tid, nil
+		}
+	}
+	defer dbp.clearPinnedSchedulerBreakpoints()
+
+	dbp.pinned = &pinnedResume{goid: g.ID, tid: tid}
+	defer func() {
+		dbp.pinned = nil
+	}()
+	return resume()
+}
+
+// clearPinnedSchedulerBreakpoints removes the breakpoints set by
+// ResumePinned.
+func (dbp *Target) clearPinnedSchedulerBreakpoints() {
+	for _, bp := range dbp.Breakpoints().M {
+		for i := range bp.Breaklets {
+			if bp.Breaklets[i].Kind == PinnedSchedulerBreakpoint {
+				bp.Breaklets[i] = nil
+			}
+		}
+		if cleared, _ := dbp.finishClearBreakpoint(bp); cleared {
+			for _, thread := range dbp.ThreadList() {
+				if thread.Breakpoint().Breakpoint == bp {
+					thread.Breakpoint().Clear()
+				}
+			}
+		}
+	}
+}
+
+// pinnedGoroutineLeft returns the thread that was running the pinned
+// goroutine and an error describing why the goroutine left it, if one of
+// threads reached a PinnedSchedulerBreakpoint.
+func (dbp *Target) pinnedGoroutineLeft(threads []Thread) (Thread, error) {
+	if dbp.pinned == nil {
+		return nil, nil
+	}
+	for _, th := range threads {
+		bpstate := th.Breakpoint()
+		if !bpstate.PinnedGoroutineLeft {
+			continue
+		}
+		err := ErrPinnedGoroutineLeft{GoroutineID: dbp.pinned.goid}
+		if fn := dbp.BinInfo().PCToFunc(bpstate.Addr); fn != nil && fn.Name == "runtime.stopTheWorldWithSema" {
+			err.Reason = "is trying to stop the world"
+		}
+		g, _ := FindGoroutine(dbp, dbp.pinned.goid)
+		if g != nil {
+			loc := g.UserCurrent()
+			err.Location = &loc
+		}
+		if err.Reason == "" {
+			switch {
+			case g == nil || g.Status == Gdead:
+				err.Reason = "exited"
+				err.Location = nil
+			case g.Status == Gwaiting:
+				err.Reason = "is blocked"
+			case g.Status == Grunnable:
+				err.Reason = "was descheduled"
+			default:
+				err.Reason = "left its thread"
+			}
+		}
+		return th, err
+	}
+	return nil, nil
+}
@@ -1,6 +1,7 @@
 package proc
 
 import (
+	"fmt"
 	"path/filepath"
 	"runtime"
 	"testing"
@@ -100,6 +101,97 @@ func TestReadCStringValue(t *testing.T) {
 	}
 }
 
+// partialMem is a memory where the addresses starting at end are not
+// readable.
+type partialMem struct {
+	dummyMem
+	end uint64
+}
+
+func (pm *partialMem) ReadMemory(buf []byte, addr uint64) (int, error) {
+	if addr+uint64(len(buf)) > pm.end {
+		return 0, fmt.Errorf("unreadable address %#x", pm.end)
+	}
+	return pm.dummyMem.ReadMemory(buf, addr)
+}
+
+func TestReadStringRange(t *testing.T) {
+	const base = 0x5000
+	pm := &partialMem{dummyMem: dummyMem{t: t, base: base, mem: make([]byte, 4*readStringRangePage)}}
+	for i := range pm.mem {
+		pm.mem[i] = byte(i)
+	}
+	pm.end = base + uint64(len(pm.mem))
+
+	out, err := ReadStringRange(pm, base, 3000, 1000, 10)
+	assertNoError(err, t, "ReadStringRange")
+	if len(out) != 10 || out[0] != pm.mem[1000] {
+		t.Errorf("wrong data %v", out)
+	}
+	out, err = ReadStringRange(pm, base, 3000, 2995, 1000)
+	assertNoError(err, t, "ReadStringRange")
+	if len(out) != 5 {
+		t.Errorf("count not truncated at the end of the contents: %d", len(out))
+	}
+	if _, err := ReadStringRange(pm, base, 3000, 3001, 1); err == nil {
+		t.Errorf("offset past the end did not fail")
+	}
+
+	// the bytes preceding the first unreadable address are returned
+	pm.end = base + 2*readStringRangePage + 10
+	out, err = ReadStringRange(pm, base, int64(len(pm.mem)), 100, int64(len(pm.mem)))
+	if err == nil {
+		t.Errorf("reading unreadable memory did not fail")
+	}
+	if len(out) != 2*readStringRangePage-100 {
+		t.Errorf("wrong length of partial result %d", len(out))
+	}
+	for i := range out {
+		if out[i] != pm.mem[100+i] {
+			t.Fatalf("wrong partial result at %d", i)
+		}
+	}
+}
+
+func TestExamineType(t *testing.T) {
+	fixture := protest.BuildFixture("testvariables2", 0)
+	bi := NewBinaryInfo(runtime.GOOS, runtime.GOARCH)
+	assertNoError(bi.LoadBinaryInfo(fixture.Path, 0, nil), t, "LoadBinaryInfo")
+
+	const base = 0x5000
+	ptrSize := bi.Arch.PtrSize()
+	pm := &partialMem{dummyMem: dummyMem{t: t, base: base, mem: make([]byte, 4*ptrSize)}}
+	for i := 0; i < 4; i++ {
+		pm.mem[i*ptrSize] = byte(i + 1)
+	}
+	pm.end = base + uint64(len(pm.mem))
+	cfg := LoadConfig{FollowPointers: true, MaxVariableRecurse: 1, MaxStringLen: 64, MaxArrayValues: 64, MaxStructFields: -1}
+
+	v, err := ExamineType(bi, pm, base, "main.astruct", 1, cfg)
+	assertNoError(err, t, "ExamineType")
+	if v.Unreadable != nil || len(v.Children) != 2 || v.Children[0].Value.String() != "1" || v.Children[1].Value.String() != "2" {
+		t.Errorf("wrong value %#v", v)
+	}
+
+	v, err = ExamineType(bi, pm, base, "[2]int", 2, cfg)
+	assertNoError(err, t, "ExamineType")
+	if v.Len != 2 || len(v.Children) != 2 || v.Children[1].Len != 2 || v.Children[1].Children[1].Value.String() != "4" {
+		t.Errorf("wrong value for array %#v", v)
+	}
+
+	if _, err := ExamineType(bi, pm, base, "main.nonexistent", 1, cfg); err == nil {
+		t.Errorf("unknown type did not fail")
+	}
+
+	// the fields that can be read are loaded
+	pm.end = base + uint64(ptrSize)
+	v, err = ExamineType(bi, pm, base, "main.astruct", 1, cfg)
+	assertNoError(err, t, "ExamineType")
+	if len(v.Children) != 2 || v.Children[0].Unreadable != nil || v.Children[0].Value.String() != "1" || v.Children[1].Unreadable == nil {
+		t.Errorf("wrong value for partially readable struct %#v", v)
+	}
+}
+
 func assertNoError(err error, t testing.TB, s string) {
 	if err != nil {
 		_, file, line, _ := runtime.Caller(1)
@@ -132,3 +224,134 @@ func TestRegabiFlagSentinel(t *testing.T) {
 		t.Errorf("regabi flag not set")
 	}
 }
+
+func TestStepSkipMatch(t *testing.T) {
+	var l StepSkipList
+	_, err := l.Add(StepSkipFunction, `fmt\..*`)
+	assertNoError(err, t, "Add(function)")
+	_, err = l.Add(StepSkipFile, "**/vendor/**")
+	assertNoError(err, t, "Add(file vendor)")
+	gen, err := l.Add(StepSkipFile, "*.pb.go")
+	assertNoError(err, t, "Add(file pb)")
+	_, err = l.Add(StepSkipFile, "/usr/local/go/src/*/*.go")
+	assertNoError(err, t, "Add(file goroot)")
+
+	if _, err := l.Add(StepSkipFunction, "("); err == nil {
+		t.Errorf("invalid regular expression accepted")
+	}
+	if _, err := l.Add("other", "x"); err == nil {
+		t.Errorf("invalid kind accepted")
+	}
+
+	for _, tc := range []struct {
+		fnname, file string
+		match        bool
+	}{
+		{"fmt.Println", "/usr/local/go/src/fmt/print.go", true},
+		{"main.fmt.Println", "/home/user/main.go", false},
+		{"github.com/a/b.F", "/home/user/vendor/github.com/a/b/b.go", true},
+		{"main.vendor", "/home/user/vendor.go", false},
+		{"main.(*T).Reset", "/home/user/api/api.pb.go", true},
+		{"main.(*T).Reset", "/home/user/api/api.pb.go.txt", false},
+		{"strings.Index", "/usr/local/go/src/strings/strings.go", true},
+		{"internal/bytealg.Index", "/usr/local/go/src/internal/bytealg/index.go", false},
+	} {
+		if got := l.Match(tc.fnname, tc.file); got != tc.match {
+			t.Errorf("Match(%q, %q) = %v, expected %v", tc.fnname, tc.file, got, tc.match)
+		}
+	}
+
+	assertNoError(l.Remove(gen.ID), t, "Remove")
+	if l.Match("main.(*T).Reset", "/home/user/api/api.pb.go") {
+		t.Errorf("removed rule still matches")
+	}
+	if err := l.Remove(gen.ID); err == nil {
+		t.Errorf("removing a rule twice did not fail")
+	}
+	if len(l.Rules()) != 3 {
+		t.Errorf("wrong number of rules %d", len(l.Rules()))
+	}
+}
+
+func TestRangeParentName(t *testing.T) {
+	for _, tc := range []struct {
+		name, parent string
+	}{
+		{"main.main", ""},
+		{"main.main-range1", "main.main"},
+		{"main.main-range1-range2", "main.main-range1"},
+		{"main.(*T).M-range12", "main.(*T).M"},
+		{"main.main-range", ""},
+		{"main.main-rangex", ""},
+		{"main.main.func1", ""},
+	} {
+		fn := &Function{Name: tc.name}
+		if got := fn.rangeParentName(); got != tc.parent {
+			t.Errorf("rangeParentName(%q) = %q, expected %q", tc.name, got, tc.parent)
+		}
+	}
+}
+
+func TestWaitCycles(t *testing.T) {
+	edge := func(w, h int) WaitEdge { return WaitEdge{Waiter: w, Holder: h, Kind: BlockedOnMutex} }
+	graph := map[int][]WaitEdge{
+		1: {edge(1, 2)},
+		2: {edge(2, 1), edge(2, 3)},
+		3: {edge(3, 4)},
+		4: {edge(4, 2)},
+		5: {edge(5, 1)},
+	}
+	cycles := waitCycles(graph)
+	var got []string
+	for _, cycle := range cycles {
+		s := ""
+		for _, e := range cycle {
+			s += fmt.Sprintf("%d->%d ", e.Waiter, e.Holder)
+		}
+		got = append(got, s)
+	}
+	want := []string{"1->2 2->1 ", "2->3 3->4 4->2 "}
+	if len(got) != len(want) {
+		t.Fatalf("wrong cycles %q", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("wrong cycles %q", got)
+		}
+	}
+}
+
+func TestFormatterListAdd(t *testing.T) {
+	var l FormatterList
+	for _, tc := range []struct {
+		typeName, format string
+		callString       bool
+		err              bool
+	}{
+		{"main.Decimal", "{Int}.{Frac}", false, false},
+		{"main.ID", "", true, false},
+		{"", "{x}", false, true},
+		{"main.T", "", false, true},
+		{"main.T", "{x}", true, true},
+		{"main.T", "{x", false, true},
+		{"main.T", "{f(x)}", false, true},
+	} {
+		_, err := l.Add(tc.typeName, tc.format, tc.callString)
+		if (err != nil) != tc.err {
+			t.Errorf("Add(%q, %q, %v): unexpected error %v", tc.typeName, tc.format, tc.callString, err)
+		}
+	}
+	if _, err := l.Add("main.Decimal", "{Int}", false); err != nil {
+		t.Fatal(err)
+	}
+	fs := l.Formatters()
+	if len(fs) != 2 || fs[0].TypeName != "main.Decimal" || fs[0].Format != "{Int}" || fs[1].TypeName != "main.ID" {
+		t.Fatalf("unexpected formatters %v", fs)
+	}
+	if err := l.Remove("main.ID"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Remove("main.ID"); err == nil {
+		t.Fatal("removing a formatter twice succeeded")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"go/ast"
 	"go/constant"
+	"go/parser"
 	"go/token"
 	"io/ioutil"
 	"math/rand"
@@ -35,7 +36,7 @@ import (
 	"github.com/go-delve/delve/service/api"
 )
 
-var normalLoadConfig = proc.LoadConfig{true, 1, 64, 64, -1, 0}
+var normalLoadConfig = proc.LoadConfig{FollowPointers: true, MaxVariableRecurse: 1, MaxStringLen: 64, MaxArrayValues: 64, MaxStructFields: -1}
 var testBackend, buildMode string
 
 func init() {
@@ -107,7 +108,7 @@ func withTestProcessArgs(name string, t testing.TB, wd string, args []string, bu
 	case "rr":
 		protest.MustHaveRecordingAllowed(t)
 		t.Log("recording")
-		p, tracedir, err = gdbserial.RecordAndReplay(append([]string{fixture.Path}, args...), wd, true, []string{}, [3]string{})
+		p, tracedir, err = gdbserial.RecordAndReplay(append([]string{fixture.Path}, args...), wd, true, []string{}, [3]string{}, gdbserial.RecordOptions{}, "")
 		t.Logf("replaying %q", tracedir)
 	default:
 		t.Fatal("unknown backend")
@@ -329,6 +330,51 @@ func TestBreakpoint(t *testing.T) {
 	})
 }
 
+func TestHardwareBreakpoint(t *testing.T) {
+	skipOn(t, "not implemented", "windows")
+	skipOn(t, "not implemented", "freebsd")
+	skipOn(t, "not implemented", "darwin")
+	skipOn(t, "not implemented", "386")
+	skipOn(t, "not implemented", "arm64")
+	skipOn(t, "not implemented", "rr")
+
+	withTestProcess("testprog", t, func(p *proc.Target, fixture protest.Fixture) {
+		addr := findFunctionLocation(p, t, "main.helloworld")
+		bp, err := p.SetHardwareBreakpoint(addr, proc.UserBreakpoint, nil)
+		assertNoError(err, t, "SetHardwareBreakpoint")
+		if !bp.WatchType.Execute() {
+			t.Fatalf("breakpoint is not a hardware breakpoint: %#v", bp.WatchType)
+		}
+
+		// exhaust the remaining hardware slots
+		slots := p.WatchpointSlots()
+		for i := slots.Used; i < slots.Total; i++ {
+			_, err := p.SetHardwareBreakpoint(addr+uint64(i), proc.UserBreakpoint, nil)
+			assertNoError(err, t, fmt.Sprintf("SetHardwareBreakpoint(%d)", i))
+		}
+		if _, err := p.SetHardwareBreakpoint(addr+uint64(slots.Total), proc.UserBreakpoint, nil); err == nil {
+			t.Fatalf("no error setting hardware breakpoint with all slots in use")
+		}
+		for i := slots.Used; i < slots.Total; i++ {
+			_, err := p.ClearBreakpoint(addr + uint64(i))
+			assertNoError(err, t, fmt.Sprintf("ClearBreakpoint(%d)", i))
+		}
+
+		assertNoError(p.Continue(), t, "Continue()")
+		regs, err := p.CurrentThread().Registers()
+		assertNoError(err, t, "Registers")
+		if pc := regs.PC(); pc != bp.Addr {
+			t.Fatalf("Break not respected: PC:%#x FN:%#x", pc, bp.Addr)
+		}
+		if bp.UserBreaklet().TotalHitCount != 1 {
+			t.Fatalf("Breakpoint should be hit once, got %d", bp.UserBreaklet().TotalHitCount)
+		}
+		if p.StopReason != proc.StopBreakpoint {
+			t.Fatalf("wrong stop reason %v", p.StopReason)
+		}
+	})
+}
+
 func TestBreakpointInSeparateGoRoutine(t *testing.T) {
 	protest.AllowRecording(t)
 	withTestProcess("testthreads", t, func(p *proc.Target, fixture protest.Fixture) {
@@ -1673,6 +1719,33 @@ func TestCondBreakpoint(t *testing.T) {
 	})
 }
 
+func TestValidateCondition(t *testing.T) {
+	withTestProcess("testnextprog", t, func(p *proc.Target, fixture protest.Fixture) {
+		pc := findFileLocation(p, t, fixture.Source, 24)
+		for _, tc := range []struct {
+			cond  string
+			valid bool
+		}{
+			{"j > 1", true},
+			{"i == f", true},
+			{"runtime.curg != nil", true},
+			{"j + 1", false},
+			{"1", false},
+			{"k == 1", false},
+			{"len(k) > 0", false},
+			{`matches("abc", "a.c")`, true},
+			{`matches("abc", "a[")`, false},
+		} {
+			cond, err := parser.ParseExpr(tc.cond)
+			assertNoError(err, t, "ParseExpr")
+			_, err = p.BinInfo().ValidateCondition(pc, cond)
+			if (err == nil) != tc.valid {
+				t.Errorf("%q: unexpected validation result: %v", tc.cond, err)
+			}
+		}
+	})
+}
+
 func TestCondBreakpointError(t *testing.T) {
 	skipOn(t, "broken", "freebsd")
 	protest.AllowRecording(t)
@@ -1738,6 +1811,32 @@ func TestHitCondBreakpointEQ(t *testing.T) {
 	})
 }
 
+func TestHitCondBreakpointMultipleLocations(t *testing.T) {
+	// The hit condition of a breakpoint with multiple locations must be
+	// evaluated against the hits of all its locations.
+	withTestProcess("testtoggle", t, func(p *proc.Target, fixture protest.Fixture) {
+		bp1 := setFunctionBreakpoint(p, t, "main.lineOne")
+		bp2, err := p.SetBreakpointWithID(bp1.LogicalID, findFunctionLocation(p, t, "main.lineTwo"))
+		assertNoError(err, t, "SetBreakpointWithID")
+		for _, bp := range []*proc.Breakpoint{bp1, bp2} {
+			bp.UserBreaklet().HitCond = &struct {
+				Op  token.Token
+				Val int
+			}{token.EQL, 2}
+		}
+
+		assertNoError(p.Continue(), t, "Continue()")
+		if fn := p.BinInfo().PCToFunc(currentPC(p, t)); fn == nil || fn.Name != "main.lineTwo" {
+			t.Fatalf("Stopped in the wrong function %v", fn)
+		}
+
+		err = p.Continue()
+		if _, exited := err.(proc.ErrProcessExited); !exited {
+			t.Fatalf("Unexpected error on Continue(): %v", err)
+		}
+	})
+}
+
 func TestHitCondBreakpointGEQ(t *testing.T) {
 	protest.AllowRecording(t)
 	withTestProcess("break", t, func(p *proc.Target, fixture protest.Fixture) {
@@ -1787,6 +1886,37 @@ func TestHitCondBreakpointREM(t *testing.T) {
 	})
 }
 
+func TestHitCondBreakpointPerG(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("bpcountstest", t, func(p *proc.Target, fixture protest.Fixture) {
+		bp := setFileBreakpoint(p, t, fixture.Source, 12)
+		bp.UserBreaklet().HitCond = &struct {
+			Op  token.Token
+			Val int
+		}{token.EQL, 1}
+		bp.UserBreaklet().HitCondPerG = true
+
+		seen := map[int]bool{}
+		for it := 0; it < 2; it++ {
+			assertNoError(p.Continue(), t, "Continue()")
+			ivar := evalVariable(p, t, "i")
+			if i, _ := constant.Int64Val(ivar.Value); i != 0 {
+				t.Fatalf("Stopped on wrong iteration %d", i)
+			}
+			g := p.SelectedGoroutine()
+			if seen[g.ID] {
+				t.Fatalf("Stopped twice on goroutine %d", g.ID)
+			}
+			seen[g.ID] = true
+		}
+
+		err := p.Continue()
+		if _, exited := err.(proc.ErrProcessExited); !exited {
+			t.Fatalf("Unexpected error on Continue(): %v", err)
+		}
+	})
+}
+
 func TestIssue356(t *testing.T) {
 	// slice with a typedef does not get printed correctly
 	protest.AllowRecording(t)
@@ -2408,6 +2538,17 @@ func TestGoroutineLables(t *testing.T) {
 		if v := labels["k2"]; v != "v2" {
 			t.Errorf("Unexpected label value k2=%v", v)
 		}
+
+		for _, tc := range []struct{ expr, tgt string }{
+			{`runtime.curg.labels["k1"]`, "v1"},
+			{`runtime.curg.labels["k2"]`, "v2"},
+			{`runtime.curg.labels["missing"]`, ""},
+		} {
+			v := evalVariable(p, t, tc.expr)
+			if v.Kind != reflect.String || constant.StringVal(v.Value) != tc.tgt {
+				t.Errorf("%s: expected %q got %v", tc.expr, tc.tgt, v.Value)
+			}
+		}
 	})
 }
 
@@ -2743,7 +2884,7 @@ func BenchmarkTrace(b *testing.B) {
 			assertNoError(p.Continue(), b, "Continue()")
 			s, err := proc.GoroutineScope(p, p.CurrentThread())
 			assertNoError(err, b, "Scope()")
-			_, err = s.FunctionArguments(proc.LoadConfig{false, 0, 64, 0, 3, 0})
+			_, err = s.FunctionArguments(proc.LoadConfig{MaxStringLen: 64, MaxStructFields: 3})
 			assertNoError(err, b, "FunctionArguments()")
 		}
 		b.StopTimer()
@@ -5256,9 +5397,7 @@ func TestVariablesWithExternalLinking(t *testing.T) {
 func TestWatchpointsBasic(t *testing.T) {
 	skipOn(t, "not implemented", "windows")
 	skipOn(t, "not implemented", "freebsd")
-	skipOn(t, "not implemented", "darwin")
 	skipOn(t, "not implemented", "386")
-	skipOn(t, "not implemented", "arm64")
 	skipOn(t, "not implemented", "rr")
 
 	withTestProcess("databpeasy", t, func(p *proc.Target, fixture protest.Fixture) {
@@ -5299,95 +5438,406 @@ func TestWatchpointsBasic(t *testing.T) {
 	})
 }
 
-func TestWatchpointCounts(t *testing.T) {
+func TestWatchpointStack(t *testing.T) {
 	skipOn(t, "not implemented", "windows")
 	skipOn(t, "not implemented", "freebsd")
-	skipOn(t, "not implemented", "darwin")
 	skipOn(t, "not implemented", "386")
-	skipOn(t, "not implemented", "arm64")
 	skipOn(t, "not implemented", "rr")
 
-	protest.AllowRecording(t)
-	withTestProcess("databpcountstest", t, func(p *proc.Target, fixture protest.Fixture) {
-		setFunctionBreakpoint(p, t, "main.main")
+	withTestProcess("databpstack", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFileBreakpoint(p, t, fixture.Source, 11)
 		assertNoError(p.Continue(), t, "Continue 0")
+		assertLineNumber(p, t, 11, "Continue 0") // Position 0
 
 		scope, err := proc.GoroutineScope(p, p.CurrentThread())
 		assertNoError(err, t, "GoroutineScope")
 
-		bp, err := p.SetWatchpoint(scope, "globalvar1", proc.WatchWrite, nil)
-		assertNoError(err, t, "SetWatchpoint(write-only)")
+		_, err = p.SetWatchpoint(scope, "w", proc.WatchWrite, nil)
+		assertNoError(err, t, "SetDataBreakpoint(write-only)")
 
-		for {
-			if err := p.Continue(); err != nil {
-				if _, exited := err.(proc.ErrProcessExited); exited {
-					break
+		assertNoError(p.Continue(), t, "Continue 1")
+		assertLineNumber(p, t, 17, "Continue 1") // Position 1
+
+		assertNoError(p.Continue(), t, "Continue 2")
+		assertLineNumber(p, t, 24, "Continue 2")
+
+		wos := p.WatchOutOfScope()
+		if len(wos) != 1 {
+			t.Fatalf("wrong number of out of scope watchpoints, expected 1 got %d", len(wos))
+		}
+		if wos[0].Breakpoint.WatchExpr != "w" || wos[0].LastValue != "10" {
+			t.Errorf("wrong out of scope watchpoint %q (last value %s)", wos[0].Breakpoint.WatchExpr, wos[0].LastValue)
+		}
+		if wos[0].Location.Line != 24 {
+			// the location is the return address in the caller of f
+			t.Errorf("wrong out of scope location %s:%d", wos[0].Location.File, wos[0].Location.Line)
+		}
+		for _, bp := range p.Breakpoints().M {
+			if bp.WatchType != 0 {
+				t.Errorf("watchpoint not cleared: %#v", bp)
+			}
+			for _, breaklet := range bp.Breaklets {
+				if breaklet.Kind == proc.WatchOutOfScopeBreakpoint || breaklet.Kind == proc.StackResizeBreakpoint {
+					t.Errorf("out of scope sentinel not cleared: %#v", bp)
 				}
-				assertNoError(err, t, "Continue()")
 			}
 		}
+	})
+}
 
-		t.Logf("TotalHitCount: %d", bp.UserBreaklet().TotalHitCount)
-		if bp.UserBreaklet().TotalHitCount != 200 {
-			t.Fatalf("Wrong TotalHitCount for the breakpoint (%d)", bp.UserBreaklet().TotalHitCount)
+func TestWatchpointStackPanic(t *testing.T) {
+	skipOn(t, "not implemented", "windows")
+	skipOn(t, "not implemented", "freebsd")
+	skipOn(t, "not implemented", "386")
+	skipOn(t, "not implemented", "rr")
+
+	withTestProcess("databppanic", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFileBreakpoint(p, t, fixture.Source, 17)
+		assertNoError(p.Continue(), t, "Continue 0")
+		assertLineNumber(p, t, 17, "Continue 0") // Position 0
+
+		scope, err := proc.GoroutineScope(p, p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope")
+
+		_, err = p.SetWatchpoint(scope, "w", proc.WatchWrite, nil)
+		assertNoError(err, t, "SetDataBreakpoint(write-only)")
+
+		// the panic is recovered in f, unwinding the frame of w
+		assertNoError(p.Continue(), t, "Continue 1")
+		if p.StopReason != proc.StopWatchOutOfScope {
+			t.Fatalf("wrong stop reason %v", p.StopReason)
+		}
+		wos := p.WatchOutOfScope()
+		if len(wos) != 1 || wos[0].Breakpoint.WatchExpr != "w" {
+			t.Fatalf("wrong out of scope watchpoints %#v", wos)
+		}
+		for _, bp := range p.Breakpoints().M {
+			if bp.WatchType != 0 {
+				t.Errorf("watchpoint not cleared: %#v", bp)
+			}
 		}
+	})
+}
 
-		if len(bp.UserBreaklet().HitCount) != 2 {
-			t.Fatalf("Wrong number of goroutines for breakpoint (%d)", len(bp.UserBreaklet().HitCount))
+func TestWatchpointInterface(t *testing.T) {
+	skipOn(t, "not implemented", "windows")
+	skipOn(t, "not implemented", "freebsd")
+	skipOn(t, "not implemented", "386")
+	skipOn(t, "not implemented", "rr")
+
+	withTestProcess("databpiface", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue 0")
+		assertLineNumber(p, t, 21, "Continue 0") // Position 0
+
+		scope, err := proc.GoroutineScope(p, p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope")
+
+		_, err = p.SetWatchpoint(scope, "iface", proc.WatchWrite, nil)
+		assertNoError(err, t, "SetDataBreakpoint(write-only)")
+
+		// reassigning the interface moves the watchpoint to the new value
+		// without stopping
+		assertNoError(p.Continue(), t, "Continue 1")
+		assertLineNumber(p, t, 24, "Continue 1") // Position 1
+	})
+}
+
+func TestWatchpointRewatch(t *testing.T) {
+	skipOn(t, "not implemented", "windows")
+	skipOn(t, "not implemented", "freebsd")
+	skipOn(t, "not implemented", "386")
+	skipOn(t, "not implemented", "rr")
+
+	withTestProcess("databprewatch", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue 0")
+		assertLineNumber(p, t, 16, "Continue 0") // Position 0
+
+		scope, err := proc.GoroutineScope(p, p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope")
+
+		bp, err := p.SetWatchpoint(scope, "p.field", proc.WatchWrite|proc.WatchRewatch, nil)
+		assertNoError(err, t, "SetDataBreakpoint(write-only, rewatch)")
+		addr := bp.Addr
+
+		assertNoError(p.Continue(), t, "Continue 1")
+		assertLineNumber(p, t, 17, "Continue 1") // Position 1
+
+		assertNoError(p.Continue(), t, "Continue 2")
+		assertLineNumber(p, t, 19, "Continue 2") // Position 2
+		if bp.Addr == addr {
+			t.Errorf("watchpoint was not moved after p was reassigned")
+		}
+		if bp.WatchSuspended {
+			t.Errorf("watchpoint suspended")
 		}
 
-		for _, v := range bp.UserBreaklet().HitCount {
-			if v != 100 {
-				t.Fatalf("Wrong HitCount for breakpoint (%v)", bp.UserBreaklet().HitCount)
-			}
+		assertNoError(p.Continue(), t, "Continue 3")
+		assertLineNumber(p, t, 20, "Continue 3") // Position 3
+	})
+}
+
+func TestWatchpointsSoftware(t *testing.T) {
+	skipOn(t, "not implemented", "windows")
+	skipOn(t, "not implemented", "freebsd")
+	skipOn(t, "not implemented", "386")
+	skipOn(t, "not implemented", "rr")
+
+	withTestProcess("databpeasy", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFunctionBreakpoint(p, t, "main.main")
+		assertNoError(p.Continue(), t, "Continue 0")
+		assertLineNumber(p, t, 11, "Continue 0") // Position 0
+
+		scope, err := proc.GoroutineScope(p, p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope")
+
+		bp, err := p.SetWatchpoint(scope, "globalvar1", proc.WatchWrite|proc.WatchSoftware, nil)
+		assertNoError(err, t, "SetDataBreakpoint(write-only, software)")
+		if !bp.WatchType.Software() {
+			t.Fatalf("watchpoint is not a software watchpoint")
 		}
+
+		assertNoError(p.Continue(), t, "Continue 1")
+		assertLineNumber(p, t, 17, "Continue 1") // Position 1
+
+		p.ClearBreakpoint(bp.Addr)
+
+		assertNoError(p.Continue(), t, "Continue 2")
+		assertLineNumber(p, t, 19, "Continue 2") // Position 2
 	})
 }
 
-func TestManualStopWhileStopped(t *testing.T) {
-	// Checks that RequestManualStop sent to a stopped thread does not cause the target process to die.
-	withTestProcess("loopprog", t, func(p *proc.Target, fixture protest.Fixture) {
-		asyncCont := func(done chan struct{}) {
-			defer close(done)
-			err := p.Continue()
-			t.Logf("%v\n", err)
-			if err != nil {
-				panic(err)
-			}
-			for _, th := range p.ThreadList() {
-				if th.Breakpoint().Breakpoint != nil {
-					t.Logf("unexpected stop at breakpoint: %v", th.Breakpoint().Breakpoint)
-					panic("unexpected stop at breakpoint")
+func TestWatchpointsSoftwareSyscall(t *testing.T) {
+	// A software watchpoint must not hang the target when the threads
+	// being stepped block in a system call.
+	skipUnlessOn(t, "linux only", "linux")
+	skipOn(t, "not implemented", "386")
+	skipOn(t, "not implemented", "rr")
+
+	withTestProcess("databpsyscall", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFunctionBreakpoint(p, t, "main.main")
+		assertNoError(p.Continue(), t, "Continue 0")
+		assertLineNumber(p, t, 11, "Continue 0") // Position 0
+
+		scope, err := proc.GoroutineScope(p, p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope")
+
+		_, err = p.SetWatchpoint(scope, "globalvar1", proc.WatchWrite|proc.WatchSoftware, nil)
+		assertNoError(err, t, "SetWatchpoint(write-only, software)")
+
+		timer := time.AfterFunc(30*time.Second, func() {
+			p.RequestManualStop()
+		})
+		defer timer.Stop()
+
+		assertNoError(p.Continue(), t, "Continue 1")
+		if p.StopReason == proc.StopManual {
+			t.Fatal("target did not reach the watchpoint")
+		}
+		if p.StopReason != proc.StopWatchpoint {
+			t.Fatalf("wrong stop reason %v", p.StopReason)
+		}
+		assertLineNumber(p, t, 22, "Continue 1") // Position 1
+	})
+}
+
+func TestWatchpointsStringSlice(t *testing.T) {
+	skipOn(t, "not implemented", "windows")
+	skipOn(t, "not implemented", "freebsd")
+	skipOn(t, "not implemented", "386")
+	skipOn(t, "not implemented", "rr")
+
+	withTestProcess("databpstrings", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFunctionBreakpoint(p, t, "main.main")
+		assertNoError(p.Continue(), t, "Continue 0")
+
+		scope, err := proc.GoroutineScope(p, p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope")
+
+		bp, err := p.SetWatchpoint(scope, "globalstr", proc.WatchWrite, nil)
+		assertNoError(err, t, "SetWatchpoint(globalstr)")
+		n := 0
+		for _, pbp := range p.Breakpoints().M {
+			if pbp.LogicalID == bp.LogicalID {
+				if pbp.WatchExpr != "globalstr" {
+					t.Errorf("wrong WatchExpr %q", pbp.WatchExpr)
 				}
+				n++
 			}
 		}
+		if n != 2 {
+			t.Fatalf("wrong number of physical watchpoints for string: %d", n)
+		}
 
-		const (
-			repeatsSlow = 3
-			repeatsFast = 5
-		)
-
-		for i := 0; i < repeatsSlow; i++ {
-			t.Logf("Continue %d (slow)", i)
-			done := make(chan struct{})
-			go asyncCont(done)
-			time.Sleep(1 * time.Second)
-			p.RequestManualStop()
-			time.Sleep(1 * time.Second)
-			p.RequestManualStop()
-			time.Sleep(1 * time.Second)
-			<-done
+		assertNoError(p.Continue(), t, "Continue 1")
+		if p.StopReason != proc.StopWatchpoint {
+			t.Fatalf("wrong stop reason %v", p.StopReason)
 		}
-		for i := 0; i < repeatsFast; i++ {
-			t.Logf("Continue %d (fast)", i)
-			rch := make(chan struct{})
-			done := make(chan struct{})
-			p.ResumeNotify(rch)
-			go asyncCont(done)
-			<-rch
-			p.RequestManualStop()
-			p.RequestManualStop()
-			<-done
+		wbp := p.CurrentThread().Breakpoint().Breakpoint
+		if wbp.WatchField != "ptr" && wbp.WatchField != "len" {
+			t.Fatalf("wrong WatchField %q", wbp.WatchField)
+		}
+		if wbp.WatchField == "len" && (wbp.WatchOldValue != 5 || wbp.WatchNewValue != 11) {
+			t.Errorf("wrong old/new values for len: %d %d", wbp.WatchOldValue, wbp.WatchNewValue)
+		}
+	})
+}
+
+func TestWatchpointsSplit(t *testing.T) {
+	skipOn(t, "not implemented", "windows")
+	skipOn(t, "not implemented", "freebsd")
+	skipOn(t, "not implemented", "386")
+	skipOn(t, "not implemented", "rr")
+
+	withTestProcess("databpstruct", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFunctionBreakpoint(p, t, "main.main")
+		assertNoError(p.Continue(), t, "Continue 0")
+
+		scope, err := proc.GoroutineScope(p, p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope")
+
+		bp, err := p.SetWatchpoint(scope, "globalstruct", proc.WatchWrite, nil)
+		assertNoError(err, t, "SetWatchpoint(globalstruct)")
+		n := 0
+		for _, pbp := range p.Breakpoints().M {
+			if pbp.LogicalID == bp.LogicalID {
+				n++
+			}
+		}
+		if n != 3 {
+			t.Fatalf("wrong number of physical watchpoints: %d", n)
+		}
+		if slots := p.WatchpointSlots(); slots.Total != 4 || slots.Used != 3 || slots.MaxSize != 8 {
+			t.Fatalf("wrong watchpoint slots: %#v", slots)
+		}
+
+		assertNoError(p.Continue(), t, "Continue 1")
+		wbp := p.CurrentThread().Breakpoint().Breakpoint
+		if wbp == nil || wbp.WatchField != "[16:24]" || wbp.WatchNewValue != 3 {
+			t.Fatalf("wrong watchpoint hit: %#v", wbp)
+		}
+	})
+}
+
+func TestWatchpointSplitSoftwareFallback(t *testing.T) {
+	skipOn(t, "not implemented", "windows")
+	skipOn(t, "not implemented", "freebsd")
+	skipOn(t, "not implemented", "darwin")
+	skipOn(t, "not implemented", "386")
+	skipOn(t, "not implemented", "arm64")
+	skipOn(t, "not implemented", "rr")
+
+	withTestProcess("databpstruct", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFunctionBreakpoint(p, t, "main.main")
+		assertNoError(p.Continue(), t, "Continue 0")
+
+		// leave only two hardware slots free
+		addr := findFunctionLocation(p, t, "fmt.Println")
+		slots := p.WatchpointSlots()
+		for i := slots.Used; i < slots.Total-2; i++ {
+			_, err := p.SetHardwareBreakpoint(addr+uint64(i), proc.UserBreakpoint, nil)
+			assertNoError(err, t, fmt.Sprintf("SetHardwareBreakpoint(%d)", i))
+		}
+
+		scope, err := proc.GoroutineScope(p, p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope")
+
+		bp, err := p.SetWatchpoint(scope, "globalstruct", proc.WatchWrite, nil)
+		assertNoError(err, t, "SetWatchpoint(globalstruct)")
+		for _, pbp := range p.Breakpoints().M {
+			if pbp.LogicalID == bp.LogicalID && pbp.WatchType != 0 && !pbp.WatchType.Software() {
+				t.Fatalf("physical watchpoint at %#x is not a software watchpoint", pbp.Addr)
+			}
+		}
+	})
+}
+
+func TestWatchpointCounts(t *testing.T) {
+	skipOn(t, "not implemented", "windows")
+	skipOn(t, "not implemented", "freebsd")
+	skipOn(t, "not implemented", "386")
+	skipOn(t, "not implemented", "rr")
+
+	protest.AllowRecording(t)
+	withTestProcess("databpcountstest", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFunctionBreakpoint(p, t, "main.main")
+		assertNoError(p.Continue(), t, "Continue 0")
+
+		scope, err := proc.GoroutineScope(p, p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope")
+
+		bp, err := p.SetWatchpoint(scope, "globalvar1", proc.WatchWrite, nil)
+		assertNoError(err, t, "SetWatchpoint(write-only)")
+
+		for {
+			if err := p.Continue(); err != nil {
+				if _, exited := err.(proc.ErrProcessExited); exited {
+					break
+				}
+				assertNoError(err, t, "Continue()")
+			}
+		}
+
+		t.Logf("TotalHitCount: %d", bp.UserBreaklet().TotalHitCount)
+		if bp.UserBreaklet().TotalHitCount != 200 {
+			t.Fatalf("Wrong TotalHitCount for the breakpoint (%d)", bp.UserBreaklet().TotalHitCount)
+		}
+
+		if len(bp.UserBreaklet().HitCount) != 2 {
+			t.Fatalf("Wrong number of goroutines for breakpoint (%d)", len(bp.UserBreaklet().HitCount))
+		}
+
+		for _, v := range bp.UserBreaklet().HitCount {
+			if v != 100 {
+				t.Fatalf("Wrong HitCount for breakpoint (%v)", bp.UserBreaklet().HitCount)
+			}
+		}
+	})
+}
+
+func TestManualStopWhileStopped(t *testing.T) {
+	// Checks that RequestManualStop sent to a stopped thread does not cause the target process to die.
+	withTestProcess("loopprog", t, func(p *proc.Target, fixture protest.Fixture) {
+		asyncCont := func(done chan struct{}) {
+			defer close(done)
+			err := p.Continue()
+			t.Logf("%v\n", err)
+			if err != nil {
+				panic(err)
+			}
+			for _, th := range p.ThreadList() {
+				if th.Breakpoint().Breakpoint != nil {
+					t.Logf("unexpected stop at breakpoint: %v", th.Breakpoint().Breakpoint)
+					panic("unexpected stop at breakpoint")
+				}
+			}
+		}
+
+		const (
+			repeatsSlow = 3
+			repeatsFast = 5
+		)
+
+		for i := 0; i < repeatsSlow; i++ {
+			t.Logf("Continue %d (slow)", i)
+			done := make(chan struct{})
+			go asyncCont(done)
+			time.Sleep(1 * time.Second)
+			p.RequestManualStop()
+			time.Sleep(1 * time.Second)
+			p.RequestManualStop()
+			time.Sleep(1 * time.Second)
+			<-done
+		}
+		for i := 0; i < repeatsFast; i++ {
+			t.Logf("Continue %d (fast)", i)
+			rch := make(chan struct{})
+			done := make(chan struct{})
+			p.ResumeNotify(rch)
+			go asyncCont(done)
+			<-rch
+			p.RequestManualStop()
+			p.RequestManualStop()
+			<-done
 		}
 	})
 }
@@ -5416,3 +5866,880 @@ func TestDwrapStartLocation(t *testing.T) {
 		}
 	})
 }
+
+func TestSharedObjectsLoadedCallback(t *testing.T) {
+	// The callback is called when the dynamic linker loads a shared object,
+	// breakpoints can be set on it before the target is resumed.
+	skipUnlessOn(t, "linux only", "linux")
+	pluginFixtures := protest.WithPlugins(t, protest.AllNonOptimized, "plugin1/", "plugin2/")
+
+	withTestProcessArgs("plugintest", t, ".", []string{pluginFixtures[0].Path, pluginFixtures[1].Path}, protest.AllNonOptimized, func(p *proc.Target, fixture protest.Fixture) {
+		var bp *proc.Breakpoint
+		p.SetSharedObjectsLoadedCallback(func() {
+			if bp != nil {
+				return
+			}
+			addrs, err := proc.FindFileLocation(p, pluginFixtures[0].Source, 6)
+			if err != nil {
+				return
+			}
+			bp, err = p.SetBreakpoint(addrs[0], proc.UserBreakpoint, nil)
+			assertNoError(err, t, "SetBreakpoint")
+		})
+
+		assertNoError(p.Continue(), t, "Continue 1")
+		if bp == nil {
+			t.Fatal("callback not called after plugin1 was loaded")
+		}
+		assertNoError(p.Continue(), t, "Continue 2")
+		assertNoError(p.Continue(), t, "Continue 3")
+		assertLineNumber(p, t, 6, "Fn1")
+	})
+}
+
+func TestClearLogicalBreakpoint(t *testing.T) {
+	// ClearLogicalBreakpoint clears every physical breakpoint owned by a
+	// logical breakpoint.
+	withTestProcess("testnextprog", t, func(p *proc.Target, fixture protest.Fixture) {
+		bp1 := setFunctionBreakpoint(p, t, "main.helloworld")
+		bp2 := setFunctionBreakpoint(p, t, "main.testnext")
+		bp2.LogicalID = bp1.LogicalID
+		bp3 := setFunctionBreakpoint(p, t, "main.main")
+
+		cleared, err := p.ClearLogicalBreakpoint(bp1.LogicalID)
+		assertNoError(err, t, "ClearLogicalBreakpoint")
+		if len(cleared) != 2 {
+			t.Fatalf("wrong number of cleared breakpoints: %d", len(cleared))
+		}
+		for _, bp := range p.Breakpoints().M {
+			if bp.LogicalID == bp1.LogicalID {
+				t.Fatalf("breakpoint at %#x not cleared", bp.Addr)
+			}
+		}
+		if _, ok := p.Breakpoints().M[bp3.Addr]; !ok {
+			t.Fatal("unrelated breakpoint was cleared")
+		}
+		if _, err := p.ClearLogicalBreakpoint(bp1.LogicalID); err == nil {
+			t.Fatal("expected error clearing a breakpoint twice")
+		}
+	})
+}
+
+func TestStackBuiltins(t *testing.T) {
+	// runtime.callerfunc and runtime.stackdepth can be used in expressions
+	// and breakpoint conditions.
+	withTestProcess("testnextprog", t, func(p *proc.Target, fixture protest.Fixture) {
+		bp := setFunctionBreakpoint(p, t, "main.sleepytime")
+		cond, err := parser.ParseExpr(`runtime.callerfunc(1) == "main.testnext" && runtime.stackdepth(3) == 3`)
+		assertNoError(err, t, "ParseExpr")
+		bp.UserBreaklet().Cond = cond
+		assertNoError(p.Continue(), t, "Continue")
+		assertLineNumber(p, t, 9, "sleepytime")
+
+		for _, tc := range []struct {
+			expr, tgt string
+		}{
+			{"runtime.callerfunc(0)", "main.sleepytime"},
+			{"runtime.callerfunc(1)", "main.testnext"},
+			{"runtime.callerfunc(2)", "main.main"},
+			{"runtime.callerfunc(1000)", ""},
+		} {
+			v := evalVariable(p, t, tc.expr)
+			if v.Kind != reflect.String || constant.StringVal(v.Value) != tc.tgt {
+				t.Errorf("%s: expected %q got %v", tc.expr, tc.tgt, v.Value)
+			}
+		}
+
+		if n, _ := constant.Int64Val(evalVariable(p, t, "runtime.stackdepth(2)").Value); n != 2 {
+			t.Errorf("runtime.stackdepth(2): expected 2 got %d", n)
+		}
+		if n, _ := constant.Int64Val(evalVariable(p, t, "runtime.stackdepth()").Value); n < 3 {
+			t.Errorf("runtime.stackdepth(): expected at least 3 got %d", n)
+		}
+
+		bp.UserBreaklet().Cond, err = parser.ParseExpr(`runtime.callerfunc(1) == "main.main"`)
+		assertNoError(err, t, "ParseExpr")
+		if err := p.Continue(); err == nil {
+			t.Fatal("breakpoint with a false condition was hit")
+		}
+	})
+}
+
+func TestWatchpointPackageScope(t *testing.T) {
+	// Watchpoints on package-level variables can be set, with a given ID,
+	// before the target reaches main.main by using a package scope, this is
+	// how they are restored after a restart.
+	skipOn(t, "not implemented", "windows")
+	skipOn(t, "not implemented", "freebsd")
+	skipOn(t, "not implemented", "386")
+	skipOn(t, "not implemented", "rr")
+
+	withTestProcess("databpeasy", t, func(p *proc.Target, fixture protest.Fixture) {
+		scope := proc.PackageScope(p, "main")
+		if _, err := p.SetWatchpointWithID(10, scope, "nonexistent", proc.WatchWrite, nil); err == nil {
+			t.Fatal("expected error watching a nonexistent variable")
+		}
+
+		bp, err := p.SetWatchpointWithID(10, scope, "globalvar1", proc.WatchWrite, nil)
+		assertNoError(err, t, "SetWatchpointWithID")
+		if bp.LogicalID != 10 {
+			t.Errorf("wrong logical ID %d", bp.LogicalID)
+		}
+		if bp.WatchPackage != "main" {
+			t.Errorf("wrong watch package %q", bp.WatchPackage)
+		}
+
+		assertNoError(p.Continue(), t, "Continue")
+		assertLineNumber(p, t, 17, "Continue")
+	})
+}
+
+func TestWatchpointAddr(t *testing.T) {
+	// Watchpoints can be set on a region of memory specified by its address
+	// and size.
+	skipOn(t, "not implemented", "windows")
+	skipOn(t, "not implemented", "freebsd")
+	skipOn(t, "not implemented", "386")
+	skipOn(t, "not implemented", "rr")
+
+	withTestProcess("databpeasy", t, func(p *proc.Target, fixture protest.Fixture) {
+		v, err := proc.PackageScope(p, "main").EvalVariable("globalvar1", normalLoadConfig)
+		assertNoError(err, t, "EvalVariable")
+
+		if _, err := p.SetWatchpointAddr(v.Addr, 3, proc.WatchWrite, nil); err == nil {
+			t.Fatal("expected error watching 3 bytes")
+		}
+		if _, err := p.SetWatchpointAddr(v.Addr+4, 8, proc.WatchWrite, nil); err == nil {
+			t.Fatal("expected error watching an unaligned address")
+		}
+
+		bp, err := p.SetWatchpointAddr(v.Addr, 8, proc.WatchWrite, nil)
+		assertNoError(err, t, "SetWatchpointAddr")
+		if !bp.WatchRaw || bp.WatchExpr != fmt.Sprintf("%#x", v.Addr) {
+			t.Errorf("wrong watchpoint %v %q", bp.WatchRaw, bp.WatchExpr)
+		}
+
+		assertNoError(p.Continue(), t, "Continue")
+		assertLineNumber(p, t, 17, "Continue")
+		if bp.WatchOldValue != 0 || bp.WatchNewValue != 2 {
+			t.Errorf("wrong watched values %#x %#x", bp.WatchOldValue, bp.WatchNewValue)
+		}
+	})
+}
+
+func TestSliceWatchpoint(t *testing.T) {
+	// A watchpoint on a slice element created with SetSliceWatchpoint
+	// follows the element when append reallocates the slice and is
+	// suspended when the slice shrinks.
+	skipOn(t, "not implemented", "windows")
+	skipOn(t, "not implemented", "freebsd")
+	skipOn(t, "not implemented", "386")
+	skipOn(t, "not implemented", "rr")
+
+	withTestProcess("databpslice", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue 0")
+		assertLineNumber(p, t, 13, "Continue 0") // Position 0
+
+		scope, err := proc.GoroutineScope(p, p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope")
+
+		if _, err := p.SetSliceWatchpoint(scope, "s", proc.WatchWrite, nil, true); err == nil {
+			t.Fatal("expected error watching a slice that is not indexed")
+		}
+		bp, err := p.SetSliceWatchpoint(scope, "s[3]", proc.WatchWrite, nil, true)
+		assertNoError(err, t, "SetSliceWatchpoint")
+		oldAddr := bp.Addr
+
+		assertNoError(p.Continue(), t, "Continue 1")
+		if p.StopReason != proc.StopWatchMigrated {
+			t.Fatalf("wrong stop reason %v", p.StopReason)
+		}
+		if m := p.WatchMigrations(); len(m) != 1 || m[0].OldAddr != oldAddr || m[0].NewAddr != bp.Addr || bp.Addr == oldAddr {
+			t.Fatalf("wrong migrations %#v (watchpoint at %#x)", m, bp.Addr)
+		}
+
+		assertNoError(p.Continue(), t, "Continue 2")
+		assertLineNumber(p, t, 15, "Continue 2") // Position 1
+		if bp.WatchNewValue != 2 {
+			t.Errorf("wrong new value %d", bp.WatchNewValue)
+		}
+
+		assertNoError(p.Continue(), t, "Continue 3")
+		if p.StopReason != proc.StopWatchMigrated {
+			t.Fatalf("wrong stop reason %v", p.StopReason)
+		}
+		if m := p.WatchMigrations(); len(m) != 1 || m[0].NewAddr != 0 || m[0].Reason == "" {
+			t.Fatalf("wrong migrations %#v", m)
+		}
+		if !bp.WatchSuspended {
+			t.Error("watchpoint not suspended")
+		}
+	})
+}
+
+func TestMatchesBuiltin(t *testing.T) {
+	withTestProcess("testnextprog", t, func(p *proc.Target, fixture protest.Fixture) {
+		scope := proc.PackageScope(p, "main")
+		for _, tc := range []struct {
+			expr string
+			tgt  bool
+		}{
+			{`matches("/api/v2/users", "^/api/v[0-9]+/users")`, true},
+			{`matches("/api/vX/users", "^/api/v[0-9]+/users")`, false},
+			{`matches("/api/v2/users/1", "users$")`, false},
+		} {
+			v, err := scope.EvalVariable(tc.expr, normalLoadConfig)
+			assertNoError(err, t, tc.expr)
+			if v.Kind != reflect.Bool || constant.BoolVal(v.Value) != tc.tgt {
+				t.Errorf("%s: expected %v got %v", tc.expr, tc.tgt, v.Value)
+			}
+		}
+		for _, expr := range []string{`matches("abc", "a[")`, `matches(1, "a")`, `matches("abc")`} {
+			if _, err := scope.EvalVariable(expr, normalLoadConfig); err == nil {
+				t.Errorf("%s: expected error", expr)
+			}
+		}
+	})
+}
+
+func TestSetRegisters(t *testing.T) {
+	// Sets the registers used to pass the arguments of a function at its
+	// entry point and checks that the function sees the new values.
+	var intReg, floatReg string
+	switch {
+	case runtime.GOARCH == "amd64" && goversion.VersionAfterOrEqual(runtime.Version(), 1, 17):
+		intReg, floatReg = "RAX", "XMM0"
+	case runtime.GOARCH == "arm64" && goversion.VersionAfterOrEqual(runtime.Version(), 1, 18):
+		intReg, floatReg = "X0", "V0"
+	default:
+		t.Skip("arguments are not passed in registers")
+	}
+	skipOn(t, "not implemented", "freebsd")
+	skipOn(t, "registers can not be changed", "rr")
+
+	protest.AllowRecording(t)
+	withTestProcess("setreg", t, func(p *proc.Target, fixture protest.Fixture) {
+		for _, fn := range []string{"main.inc", "main.double"} {
+			_, err := p.SetBreakpoint(p.BinInfo().LookupFunc[fn].Entry, proc.UserBreakpoint, nil)
+			assertNoError(err, t, "SetBreakpoint("+fn+")")
+		}
+		setFileBreakpoint(p, t, fixture.Source, 18)
+
+		assertNoError(p.Continue(), t, "Continue 1")
+		assertNoError(setVariable(p, intReg, "41"), t, "set "+intReg)
+		if v := evalVariable(p, t, intReg); constant.Compare(v.Value, token.NEQ, constant.MakeInt64(41)) {
+			t.Errorf("wrong value of %s after set: %v", intReg, v.Value)
+		}
+
+		assertNoError(p.Continue(), t, "Continue 2")
+		// 21.0 as a float64, followed by bytes that are not part of the argument
+		assertNoError(setVariable(p, floatReg, "0x01020304050607084035000000000000"), t, "set "+floatReg)
+		v := evalVariable(p, t, floatReg+".uint64")
+		if len(v.Children) < 2 || constant.Compare(v.Children[0].Value, token.NEQ, constant.MakeUint64(0x4035000000000000)) || constant.Compare(v.Children[1].Value, token.NEQ, constant.MakeUint64(0x0102030405060708)) {
+			t.Errorf("wrong value of %s after set: %v", floatReg, v.Children)
+		}
+		for i := 2; i < len(v.Children); i++ {
+			if constant.Compare(v.Children[i].Value, token.NEQ, constant.MakeUint64(0)) {
+				t.Errorf("%s was not zero extended: %v", floatReg, v.Children)
+			}
+		}
+		if err := setVariable(p, floatReg, `"00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff00"`); err == nil {
+			t.Errorf("setting %s to a value that is too large did not fail", floatReg)
+		}
+
+		assertNoError(p.Continue(), t, "Continue 3")
+		assertLineNumber(p, t, 18, "Continue 3")
+		if n := evalVariable(p, t, "n"); constant.Compare(n.Value, token.NEQ, constant.MakeInt64(42)) {
+			t.Errorf("function did not see the new value of %s: %v", intReg, n.Value)
+		}
+		if f := evalVariable(p, t, "f"); constant.Compare(f.Value, token.NEQ, constant.MakeFloat64(42)) {
+			t.Errorf("function did not see the new value of %s: %v", floatReg, f.Value)
+		}
+	})
+}
+
+func TestStepThroughPLT(t *testing.T) {
+	// Stepping into a call to a shared library function should go through
+	// its PLT stub instead of stopping inside it.
+	skipUnlessOn(t, "PLT stubs are only recognized on linux", "linux")
+	protest.MustHaveCgo(t)
+	protest.AllowRecording(t)
+	withTestProcess("cgostepthunk", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFunctionBreakpoint(p, t, "C.stepme")
+		assertNoError(p.Continue(), t, "Continue()")
+		assertLineNumber(p, t, 7, "Continue()")
+		assertNoError(p.Step(), t, "Step()")
+		assertLineNumber(p, t, 8, "Step()")
+	})
+}
+
+func TestCgoStacktracePointerAuth(t *testing.T) {
+	// Return addresses saved by C code compiled with pointer authentication
+	// must be stripped of their authentication code during unwinding.
+	skipUnlessOn(t, "pointer authentication is an arm64 feature", "arm64")
+	protest.MustHaveCgo(t)
+	protest.AllowRecording(t)
+	withTestProcess("cgopacstacktrace", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFunctionBreakpoint(p, t, "C.pacinner")
+		assertNoError(p.Continue(), t, "Continue()")
+		g, err := proc.GetG(p.CurrentThread())
+		assertNoError(err, t, "GetG()")
+		frames, err := g.Stacktrace(100, 0)
+		assertNoError(err, t, "Stacktrace()")
+		logStacktrace(t, p, frames)
+		if stacktraceCheck(t, []string{"C.pacinner", "C.pacmid", "C.pacouter", "main.main"}, frames) == nil {
+			t.Fatal("stack trace mismatch")
+		}
+	})
+}
+
+func TestStepIntoTargets(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("stepintotargets", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFunctionBreakpoint(p, t, "main.main")
+		assertNoError(p.Continue(), t, "Continue()")
+		assertLineNumber(p, t, 21, "Continue()")
+
+		tgts, err := p.StepIntoTargets()
+		assertNoError(err, t, "StepIntoTargets()")
+		var names []string
+		var fpc uint64
+		for _, tgt := range tgts {
+			if tgt.Fn == nil {
+				continue
+			}
+			names = append(names, tgt.Fn.Name)
+			if tgt.Fn.Name == "main.f" {
+				fpc = tgt.PC
+			}
+		}
+		if !reflect.DeepEqual(names, []string{"main.g", "main.h", "main.f"}) {
+			t.Fatalf("wrong step into targets: %v", names)
+		}
+
+		assertNoError(p.StepInto(fpc), t, "StepInto()")
+		assertLineNumber(p, t, 7, "StepInto()")
+		loc, err := p.CurrentThread().Location()
+		assertNoError(err, t, "CurrentThread().Location()")
+		if loc.Fn == nil || loc.Fn.Name != "main.f" {
+			t.Fatalf("stepped into %v instead of main.f", loc.Fn)
+		}
+
+		if err := p.StepInto(fpc); err == nil {
+			t.Fatal("StepInto with a call that is not on the current line did not fail")
+		}
+	})
+}
+
+func TestStepSkip(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("stepintotargets", t, func(p *proc.Target, fixture protest.Fixture) {
+		_, err := p.StepSkips().Add(proc.StepSkipFunction, "main.[gh]")
+		assertNoError(err, t, "Add")
+		setFunctionBreakpoint(p, t, "main.main")
+		assertNoError(p.Continue(), t, "Continue()")
+		assertLineNumber(p, t, 21, "Continue()")
+		// main.g and main.h are skipped, the first function step stops in is
+		// main.f
+		assertNoError(p.Step(), t, "Step()")
+		assertLineNumber(p, t, 7, "Step()")
+		loc, err := p.CurrentThread().Location()
+		assertNoError(err, t, "CurrentThread().Location()")
+		if loc.Fn == nil || loc.Fn.Name != "main.f" {
+			t.Fatalf("stepped into %v instead of main.f", loc.Fn)
+		}
+	})
+}
+
+func TestContinueUntil(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("untilcond", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFunctionBreakpoint(p, t, "main.main")
+		assertNoError(p.Continue(), t, "Continue()")
+
+		until := func(expr string) {
+			t.Helper()
+			scope, err := proc.GoroutineScope(p, p.CurrentThread())
+			assertNoError(err, t, "GoroutineScope()")
+			assertNoError(p.ContinueUntil(scope, expr), t, fmt.Sprintf("ContinueUntil(%q)", expr))
+			if len(p.Breakpoints().M) != 1 {
+				t.Fatalf("temporary breakpoints left after ContinueUntil(%q): %d", expr, len(p.Breakpoints().M))
+			}
+		}
+
+		// watched package variable
+		until("counter == 10")
+		if v := constant.MakeInt64(10); !constant.Compare(evalVariable(p, t, "counter").Value, token.EQL, v) {
+			t.Fatalf("wrong value of counter after until")
+		}
+
+		// watched field of a package variable
+		until("st.done")
+		if v := constant.MakeInt64(50); !constant.Compare(evalVariable(p, t, "counter").Value, token.EQL, v) {
+			t.Fatalf("wrong value of counter after until")
+		}
+
+		// local variable, not watchable because of the call to len
+		until("n > 2000 && len(\"a\") == 1")
+		n, _ := constant.Int64Val(evalVariable(p, t, "n").Value)
+		if n <= 2000 || n > 2100 {
+			t.Fatalf("wrong value of n after until: %d", n)
+		}
+
+		scope, err := proc.GoroutineScope(p, p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope()")
+		if err := p.ContinueUntil(scope, "counter > 0"); err == nil {
+			t.Fatal("ContinueUntil did not fail on a condition that is already true")
+		}
+		if err := p.ContinueUntil(scope, "counter"); err == nil {
+			t.Fatal("ContinueUntil did not fail on a non boolean condition")
+		}
+	})
+}
+
+func TestStepOutRangeOverFunc(t *testing.T) {
+	if !goversion.VersionAfterOrEqual(runtime.Version(), 1, 23) {
+		t.Skip("range-over-func requires go1.23")
+	}
+	assertFunction := func(p *proc.Target, t *testing.T, name, descr string) {
+		t.Helper()
+		loc, err := p.CurrentThread().Location()
+		assertNoError(err, t, "Location()")
+		if loc.Fn == nil || loc.Fn.Name != name {
+			t.Fatalf("%s: stopped in %v instead of %s", descr, loc.Fn, name)
+		}
+	}
+
+	protest.AllowRecording(t)
+	withTestProcess("rangeoverfunc", t, func(p *proc.Target, fixture protest.Fixture) {
+		bp := setFileBreakpoint(p, t, fixture.Source, 18)
+		assertNoError(p.Continue(), t, "Continue()")
+		_, err := p.ClearBreakpoint(bp.Addr)
+		assertNoError(err, t, "ClearBreakpoint()")
+		// the first stepout stops at the start of the second iteration of the
+		// loop, the second one after the loop
+		assertNoError(p.StepOut(), t, "StepOut()")
+		assertFunction(p, t, "main.main-range1", "first StepOut()")
+		assertNoError(p.StepOut(), t, "StepOut()")
+		assertFunction(p, t, "main.main", "second StepOut()")
+	})
+
+	withTestProcess("rangeoverfunc", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFileBreakpoint(p, t, fixture.Source, 18)
+		assertNoError(p.Continue(), t, "Continue()")
+		assertNoError(p.StepOutIterator(), t, "StepOutIterator()")
+		assertFunction(p, t, "main.seq.func1", "StepOutIterator()")
+	})
+}
+
+func TestStepIntoNewGoroutine(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("gostmtstep", t, func(p *proc.Target, fixture protest.Fixture) {
+		bp := setFileBreakpoint(p, t, fixture.Source, 17)
+		assertNoError(p.Continue(), t, "Continue()")
+		assertNoError(p.Continue(), t, "Continue()")
+		_, err := p.ClearBreakpoint(bp.Addr)
+		assertNoError(err, t, "ClearBreakpoint()")
+		parent := p.SelectedGoroutine()
+
+		assertNoError(p.StepIntoNewGoroutine(), t, "StepIntoNewGoroutine()")
+		g := p.SelectedGoroutine()
+		if g.ID == parent.ID {
+			t.Fatalf("still on goroutine %d", g.ID)
+		}
+		loc, err := p.CurrentThread().Location()
+		assertNoError(err, t, "Location()")
+		if loc.Fn == nil || loc.PC != loc.Fn.Entry || loc.PC != g.StartPC {
+			t.Fatalf("not stopped at the entry point of the goroutine: %#x %v (start %#x)", loc.PC, loc.Fn, g.StartPC)
+		}
+		if goloc := g.Go(); goloc.Line != 17 {
+			t.Fatalf("goroutine created at line %d", goloc.Line)
+		}
+		// the goroutine is the one created by the second iteration of the loop
+		n := 0
+		gs, _, err := proc.GoroutinesInfo(p, 0, 0)
+		assertNoError(err, t, "GoroutinesInfo()")
+		for _, g2 := range gs {
+			if g2.Go().Line == 17 && g2.ID < g.ID {
+				n++
+			}
+		}
+		if n > 1 {
+			t.Fatalf("stopped on goroutine created by iteration %d", n)
+		}
+	})
+}
+
+func TestBreakpointConditionWithCalls(t *testing.T) {
+	protest.MustSupportFunctionCalls(t, testBackend)
+	withTestProcess("condcalls", t, func(p *proc.Target, fixture protest.Fixture) {
+		bpcall := setFileBreakpoint(p, t, fixture.Source, 19)
+		bpcond := setFileBreakpoint(p, t, fixture.Source, 14)
+		bpcond.UserBreaklet().Cond = &ast.CallExpr{Fun: &ast.Ident{Name: "isAdmin"}, Args: []ast.Expr{&ast.Ident{Name: "path"}}}
+		bpcond.UserBreaklet().CondCalls = true
+		// only reached by the call injected to evaluate the condition of
+		// bpcond and by main after the loop
+		setFileBreakpoint(p, t, fixture.Source, 10)
+
+		assertPath := func(path string) {
+			t.Helper()
+			v := evalVariable(p, t, "path")
+			if s := constant.StringVal(v.Value); s != path {
+				t.Fatalf("wrong value of path %q, expected %q", s, path)
+			}
+		}
+
+		assertNoError(p.Continue(), t, "Continue()")
+		assertLineNumber(p, t, 19, "")
+		assertPath("/index")
+
+		// the condition of bpcond is false, next must not be interrupted by
+		// it or by the breakpoint reached by the injected call.
+		assertNoError(p.Next(), t, "Next()")
+		assertLineNumber(p, t, 18, "after Next")
+
+		assertNoError(p.Continue(), t, "Continue()")
+		assertLineNumber(p, t, 19, "")
+		assertPath("/admin/users")
+		assertNoError(p.Continue(), t, "Continue()")
+		assertLineNumber(p, t, 14, "")
+		assertPath("/admin/users")
+
+		// a condition that can not be evaluated stops at the breakpoint
+		bperr := setFileBreakpoint(p, t, fixture.Source, 21)
+		bperr.UserBreaklet().Cond = &ast.CallExpr{Fun: &ast.Ident{Name: "isAdmin"}}
+		bperr.UserBreaklet().CondCalls = true
+		_, err := p.ClearBreakpoint(bpcall.Addr)
+		assertNoError(err, t, "ClearBreakpoint()")
+		if err := p.Continue(); err == nil {
+			t.Fatal("expected error evaluating condition")
+		}
+		assertLineNumber(p, t, 21, "")
+
+		assertNoError(p.Continue(), t, "Continue()")
+		assertLineNumber(p, t, 10, "")
+		assertPath("/")
+	})
+}
+
+func TestStepInstructions(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("teststepprog", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFunctionBreakpoint(p, t, "main.main")
+		assertNoError(p.Continue(), t, "Continue()")
+		assertLineNumber(p, t, 20, "")
+
+		// step over the calls on line 20
+		for i := 0; ; i++ {
+			if i > 100 {
+				t.Fatal("did not reach line 21")
+			}
+			n, err := p.StepInstructions(1, true)
+			assertNoError(err, t, "StepInstructions(1, true)")
+			if n != 1 {
+				t.Fatalf("wrong number of instructions executed %d", n)
+			}
+			loc, err := p.CurrentThread().Location()
+			assertNoError(err, t, "Location()")
+			if loc.Fn == nil || loc.Fn.Name != "main.main" {
+				t.Fatalf("stepped into %v", loc.Fn)
+			}
+			if loc.Line == 21 {
+				break
+			}
+		}
+		if p.Breakpoints().HasSteppingBreakpoints() {
+			t.Fatal("stepping breakpoints left after StepInstructions")
+		}
+
+		// stepping stops early at a breakpoint
+		setFunctionBreakpoint(p, t, "main.CallEface")
+		n, err := p.StepInstructions(1000, false)
+		assertNoError(err, t, "StepInstructions(1000, false)")
+		if n <= 0 || n >= 1000 {
+			t.Fatalf("wrong number of instructions executed %d", n)
+		}
+		if loc, _ := p.CurrentThread().Location(); loc.Fn == nil || loc.Fn.Name != "main.CallEface" {
+			t.Fatalf("did not stop at breakpoint: %v", loc.Fn)
+		}
+	})
+
+	withTestProcess("teststepprog", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFunctionBreakpoint(p, t, "main.main")
+		assertNoError(p.Continue(), t, "Continue()")
+
+		// stepping over a call stops early at a breakpoint inside the called
+		// function
+		setFunctionBreakpoint(p, t, "main.CallFn2")
+		n, err := p.StepInstructions(1000, true)
+		assertNoError(err, t, "StepInstructions(1000, true)")
+		if n >= 1000 {
+			t.Fatalf("wrong number of instructions executed %d", n)
+		}
+		if loc, _ := p.CurrentThread().Location(); loc.Fn == nil || loc.Fn.Name != "main.CallFn2" {
+			t.Fatalf("did not stop at breakpoint: %v", loc.Fn)
+		}
+		if p.Breakpoints().HasSteppingBreakpoints() {
+			t.Fatal("stepping breakpoints left after StepInstructions")
+		}
+	})
+}
+
+func TestFindDeadlocks(t *testing.T) {
+	withTestProcess("deadlock", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+		r, err := p.FindDeadlocks()
+		assertNoError(err, t, "FindDeadlocks()")
+
+		count := map[proc.BlockKind]int{}
+		for _, bg := range r.Blocked {
+			t.Logf("goroutine %d blocked on %v %#x", bg.G.ID, bg.Kind, bg.Objects)
+			if bg.Frame == nil || bg.Frame.Call.Fn == nil {
+				continue
+			}
+			switch bg.Frame.Call.Fn.Name {
+			case "main.transfer", "main.relay":
+				count[bg.Kind]++
+				if len(bg.Objects) == 0 {
+					t.Errorf("no blocking object for goroutine %d", bg.G.ID)
+				}
+			}
+		}
+		if count[proc.BlockedOnMutex] != 2 || count[proc.BlockedOnChanRecv]+count[proc.BlockedOnChanSend] != 2 {
+			t.Fatalf("wrong blocked goroutines: %v", count)
+		}
+
+		kinds := map[bool]int{}
+		for _, cycle := range r.Cycles {
+			t.Logf("cycle %v", cycle)
+			if len(cycle) != 2 {
+				continue
+			}
+			if cycle[0].Holder != cycle[1].Waiter || cycle[1].Holder != cycle[0].Waiter {
+				t.Errorf("malformed cycle %v", cycle)
+			}
+			kinds[cycle[0].Kind == proc.BlockedOnMutex]++
+		}
+		if kinds[true] != 1 || kinds[false] != 1 {
+			t.Fatalf("wrong cycles: %v", r.Cycles)
+		}
+	})
+}
+
+func TestMutexInfo(t *testing.T) {
+	withTestProcess("mutexowner", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+		scope, err := proc.GoroutineScope(p, p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope()")
+
+		mi, err := scope.MutexInfo("c.mu")
+		assertNoError(err, t, "MutexInfo(c.mu)")
+		t.Logf("c.mu %#v", mi)
+		if mi.RW || !mi.Locked || mi.Waiters != 2 || len(mi.Waiting) != 2 {
+			t.Fatalf("wrong state of c.mu: %#v", mi)
+		}
+		if len(mi.PossibleOwners) != 1 || mi.PossibleOwners[0].Frame.Call.Fn.Name != "main.(*cache).refresh" || !mi.PossibleOwners[0].ReferencesMutex {
+			t.Fatalf("wrong owners of c.mu: %#v", mi.PossibleOwners)
+		}
+
+		mi, err = scope.MutexInfo("&rw")
+		assertNoError(err, t, "MutexInfo(&rw)")
+		t.Logf("rw %#v", mi)
+		if !mi.RW || mi.Locked || !mi.WriterPending || mi.Readers != 2 || len(mi.Waiting) != 1 {
+			t.Fatalf("wrong state of rw: %#v", mi)
+		}
+		if len(mi.PossibleOwners) != 2 {
+			t.Fatalf("wrong owners of rw: %#v", mi.PossibleOwners)
+		}
+		for _, o := range mi.PossibleOwners {
+			if !o.Reader || o.Frame.Call.Fn.Name != "main.read" {
+				t.Errorf("wrong owner of rw: goroutine %d at %s", o.G.ID, o.Frame.Call.Fn.Name)
+			}
+		}
+
+		_, err = scope.MutexInfo("c.entries")
+		if err == nil {
+			t.Fatal("MutexInfo(c.entries) did not fail")
+		}
+	})
+}
+
+func TestResumeWithTimeout(t *testing.T) {
+	withTestProcess("loopprog", t, func(p *proc.Target, fixture protest.Fixture) {
+		bp := setFunctionBreakpoint(p, t, "main.loop")
+		assertNoError(p.Continue(), t, "Continue")
+		_, err := p.ClearBreakpoint(bp.Addr)
+		assertNoError(err, t, "ClearBreakpoint")
+
+		t0 := time.Now()
+		assertNoError(p.ResumeWithTimeout(200*time.Millisecond, p.Continue), t, "ResumeWithTimeout")
+		if p.StopReason != proc.StopTimeout {
+			t.Fatalf("wrong stop reason %v", p.StopReason)
+		}
+		if d := time.Since(t0); d < 200*time.Millisecond {
+			t.Fatalf("stopped too early (%v)", d)
+		}
+
+		// the timer is cancelled when the target stops first
+		setFileBreakpoint(p, t, fixture.Source, 10)
+		assertNoError(p.ResumeWithTimeout(time.Hour, p.Continue), t, "ResumeWithTimeout")
+		if p.StopReason != proc.StopBreakpoint {
+			t.Fatalf("wrong stop reason %v", p.StopReason)
+		}
+	})
+}
+
+func TestResumePinned(t *testing.T) {
+	if runtime.GOOS != "linux" || testBackend != "native" {
+		t.Skip("resuming a single thread is only supported by the native backend on linux")
+	}
+	withTestProcess("pinnedresume", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue")
+		counter := func() int64 {
+			v := evalVariable(p, t, "main.counter")
+			n, _ := constant.Int64Val(v.Value)
+			return n
+		}
+		c0 := counter()
+
+		// the goroutine running spin does not run while main is pinned
+		setFileBreakpoint(p, t, fixture.Source, 26)
+		assertNoError(p.ResumePinned(p.Continue), t, "ResumePinned")
+		assertLineNumber(p, t, 26, "")
+		if c1 := counter(); c1 != c0 {
+			t.Fatalf("other goroutine ran while pinned: %d -> %d", c0, c1)
+		}
+
+		// main blocks receiving from ch
+		err := p.ResumePinned(p.Continue)
+		if _, ok := err.(proc.ErrPinnedGoroutineLeft); !ok {
+			t.Fatalf("expected ErrPinnedGoroutineLeft, got %v", err)
+		}
+		t.Logf("%v", err)
+		if c1 := counter(); c1 != c0 {
+			t.Fatalf("other goroutine ran while pinned: %d -> %d", c0, c1)
+		}
+		for _, bp := range p.Breakpoints().M {
+			for _, breaklet := range bp.Breaklets {
+				if breaklet.Kind == proc.PinnedSchedulerBreakpoint {
+					t.Fatalf("breakpoint left at %#x", bp.Addr)
+				}
+			}
+		}
+
+		// other goroutines run again when not pinned
+		assertNoError(p.ResumeWithTimeout(100*time.Millisecond, p.Continue), t, "ResumeWithTimeout")
+		if c1 := counter(); c1 == c0 {
+			t.Fatal("other goroutine did not run after unpinning")
+		}
+	})
+}
+
+func TestNextPanicRecover(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("nextpanicrecover", t, func(p *proc.Target, fixture protest.Fixture) {
+		setFileBreakpoint(p, t, fixture.Source, 41) // f() in main.middle
+
+		// the panic is recovered by main.recoverer, two frames above the frame
+		// being stepped, by re-panicking in a deferred function and after a
+		// panic recovered below the frame being stepped
+		for i := 0; i < 3; i++ {
+			assertNoError(p.Continue(), t, "Continue")
+			assertLineNumber(p, t, 41, "")
+			assertNoError(p.Next(), t, "Next")
+			if p.StopReason != proc.StopNextFinished {
+				t.Fatalf("%d: wrong stop reason %v", i, p.StopReason)
+			}
+			if loc, _ := p.CurrentThread().Location(); loc == nil || loc.Fn == nil || loc.Fn.Name != "main.recoverer" {
+				t.Fatalf("%d: expected to stop in main.recoverer, got %v", i, loc)
+			}
+		}
+
+		// nothing recovers the last panic
+		assertNoError(p.Continue(), t, "Continue")
+		assertLineNumber(p, t, 41, "")
+		err := p.Next()
+		if _, exited := err.(proc.ErrProcessExited); !exited {
+			assertNoError(err, t, "Next")
+			if bp := p.CurrentThread().Breakpoint(); bp.Breakpoint == nil || bp.Name != proc.UnrecoveredPanic {
+				t.Fatalf("not on unrecovered-panic breakpoint: %v", bp)
+			}
+			if p.Breakpoints().HasSteppingBreakpoints() {
+				t.Fatal("stepping breakpoints left after unrecovered panic")
+			}
+		}
+	})
+}
+
+func TestFormatters(t *testing.T) {
+	withTestProcess("formatters", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue")
+		_, err := p.Formatters().Add("main.Decimal", "{Int}.{Frac} ({{raw}})", false)
+		assertNoError(err, t, "Add")
+		scope, err := proc.GoroutineScope(p, p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope")
+
+		price, err := scope.EvalVariable("price", normalLoadConfig)
+		assertNoError(err, t, "EvalVariable")
+		order, err := scope.EvalVariable("order", normalLoadConfig)
+		assertNoError(err, t, "EvalVariable")
+		scope.ApplyFormatters(normalLoadConfig, price, order)
+		if price.Formatted != "12.34 ({raw})" {
+			t.Errorf("price: got %q", price.Formatted)
+		}
+		if order.Formatted != "" || order.Children[1].Formatted != "12.34 ({raw})" {
+			t.Errorf("order: got %q %q", order.Formatted, order.Children[1].Formatted)
+		}
+		if len(price.Children) != 2 {
+			t.Errorf("price: children not loaded")
+		}
+	})
+}
+
+func TestFormatterCallString(t *testing.T) {
+	protest.MustSupportFunctionCalls(t, testBackend)
+	withTestProcess("formatters", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue")
+		_, err := p.Formatters().Add("main.ID", "", true)
+		assertNoError(err, t, "Add")
+		scope, err := proc.GoroutineScope(p, p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope")
+		id, err := scope.EvalVariable("id", normalLoadConfig)
+		assertNoError(err, t, "EvalVariable")
+		gid := p.SelectedGoroutine().ID
+		scope.ApplyFormatters(normalLoadConfig, id)
+		if id.Formatted != "ord-7" {
+			t.Errorf("id: got %q", id.Formatted)
+		}
+		if p.SelectedGoroutine().ID != gid {
+			t.Errorf("selected goroutine changed from %d to %d", gid, p.SelectedGoroutine().ID)
+		}
+	})
+}
+
+func TestCallStringMethods(t *testing.T) {
+	protest.MustSupportFunctionCalls(t, testBackend)
+	withTestProcess("formatters", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue")
+		cfg := normalLoadConfig
+		cfg.CallStringMethods = true
+		for _, tc := range []struct {
+			expr, tgt string
+		}{
+			{"id", "ord-7"},
+			{"err", "parse error at line 3"},
+			{"price", ""},
+			{"nilerr", ""},
+			{"b", ""}, // String panics, the value is left unformatted
+		} {
+			scope, err := proc.GoroutineScope(p, p.CurrentThread())
+			assertNoError(err, t, "GoroutineScope")
+			v, err := scope.EvalVariable(tc.expr, cfg)
+			assertNoError(err, t, fmt.Sprintf("EvalVariable(%s)", tc.expr))
+			scope.ApplyFormatters(cfg, v)
+			if v.Formatted != tc.tgt {
+				t.Errorf("%s: expected %q got %q", tc.expr, tc.tgt, v.Formatted)
+			}
+		}
+	})
+}
@@ -1,7 +1,12 @@
 package proc
 
 import (
+	"go/constant"
+	"math/big"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestAlignAddr(t *testing.T) {
@@ -96,3 +101,126 @@ func TestConvertInt(t *testing.T) {
 		}
 	}
 }
+
+func TestBreakletHitIntervals(t *testing.T) {
+	var breaklet Breaklet
+	start := time.Unix(0, 0)
+	for i := 0; i < hitIntervalsLen+5; i++ {
+		breaklet.recordHit(start.Add(time.Duration(i*i) * time.Millisecond))
+	}
+	intervals := breaklet.HitIntervals()
+	if len(intervals) != hitIntervalsLen {
+		t.Fatalf("wrong number of intervals: %d", len(intervals))
+	}
+	for i, d := range intervals {
+		// the interval between hit n-1 and hit n is (2n-1)ms, only the last
+		// hitIntervalsLen intervals are kept.
+		n := i + 5
+		if d != time.Duration(2*n-1)*time.Millisecond {
+			t.Errorf("wrong interval %d: %v", i, d)
+		}
+	}
+	if last := start.Add(time.Duration((hitIntervalsLen+4)*(hitIntervalsLen+4)) * time.Millisecond); !breaklet.LastHit.Equal(last) {
+		t.Errorf("wrong LastHit: %v", breaklet.LastHit)
+	}
+}
+
+func TestBreakletHitHistory(t *testing.T) {
+	breaklet := Breaklet{HitHistorySize: 4}
+	for i := 0; i < 6; i++ {
+		breaklet.recordHitHistory(BreakpointHit{GoroutineID: i, PC: uint64(0x1000 + i)})
+	}
+	hits := breaklet.TakeHitHistory()
+	if len(hits) != 4 {
+		t.Fatalf("wrong number of hits: %d", len(hits))
+	}
+	for i, hit := range hits {
+		// only the last 4 hits are kept, oldest first
+		if hit.GoroutineID != i+2 || hit.PC != uint64(0x1000+i+2) {
+			t.Errorf("wrong hit %d: %#v", i, hit)
+		}
+	}
+	if hits := breaklet.TakeHitHistory(); len(hits) != 0 {
+		t.Errorf("hit history not cleared: %#v", hits)
+	}
+
+	// changing the size discards the history
+	breaklet.recordHitHistory(BreakpointHit{GoroutineID: 10})
+	breaklet.HitHistorySize = 2
+	breaklet.recordHitHistory(BreakpointHit{GoroutineID: 11})
+	if hits := breaklet.TakeHitHistory(); len(hits) != 1 || hits[0].GoroutineID != 11 {
+		t.Errorf("wrong hits after resize: %#v", hits)
+	}
+}
+
+func TestRewriteMapFilterIdents(t *testing.T) {
+	for _, tc := range []struct{ in, out string }{
+		{`m[@key == "a"]`, `m[` + mapFilterKeyIdent + ` == "a"]`},
+		{`m[@value.A > 1 && @key != "@key"]`, `m[` + mapFilterValueIdent + `.A > 1 && ` + mapFilterKeyIdent + ` != "@key"]`},
+		{"m[@key == `\\` || @key == '@' || @key == \"\\\"@value\"]", "m[" + mapFilterKeyIdent + " == `\\` || " + mapFilterKeyIdent + " == '@' || " + mapFilterKeyIdent + " == \"\\\"@value\"]"},
+		{`m[@keys == 1]`, `m[@keys == 1]`},
+		{`a + b`, `a + b`},
+	} {
+		if out := rewriteMapFilterIdents(tc.in); out != tc.out {
+			t.Errorf("%s: expected %s got %s", tc.in, tc.out, out)
+		}
+	}
+}
+
+func TestMapContinuationToken(t *testing.T) {
+	pos := &mapPosition{buckets: 0xc000123000, oldbuckets: 0, numbuckets: 8, count: 42, bucketIdx: 5, ovfDepth: 1, idx: 3}
+	pos2, err := parseMapContinuation(pos.String())
+	if err != nil {
+		t.Fatalf("parseMapContinuation(%q): %v", pos.String(), err)
+	}
+	if *pos2 != *pos {
+		t.Errorf("expected %#v got %#v", pos, pos2)
+	}
+	if _, err := parseMapContinuation("m1:1:2"); err == nil {
+		t.Errorf("no error for malformed token")
+	}
+}
+
+func TestBigMagnitudeText(t *testing.T) {
+	exp := func(n int64) *big.Int {
+		return new(big.Int).Exp(big.NewInt(10), big.NewInt(n), nil)
+	}
+	for _, tc := range []struct {
+		x      *big.Int
+		shift  int64
+		digits int
+		tgt    string
+	}{
+		{big.NewInt(0), 0, 10, "0"},
+		{big.NewInt(1234567890), 0, 10, "1234567890"},
+		{big.NewInt(12345678901), 0, 10, "1234567890...(~10^10)"},
+		{big.NewInt(1), 64, 5, "18446...(~10^19)"},
+		{exp(4096), 0, 8, "10000000...(~10^4096)"},
+	} {
+		if s := bigMagnitudeText(tc.x, tc.shift, tc.digits); s != tc.tgt {
+			t.Errorf("bigMagnitudeText(%v, %d, %d) = %q, expected %q", tc.x, tc.shift, tc.digits, s, tc.tgt)
+		}
+	}
+	// the value is approximated when the least significant words are not
+	// read, the leading digits are still correct.
+	x := exp(4096)
+	x.Rsh(x, 1024)
+	if s := bigMagnitudeText(x, 1024, 16); !strings.HasPrefix(s, "1000000000000000...") {
+		t.Errorf("approximated value %q", s)
+	}
+}
+
+func TestTruncateStringConversion(t *testing.T) {
+	// truncating the result of a conversion for display must not change its
+	// length
+	sv := &Variable{Kind: reflect.String, Value: constant.MakeString("abcdef"), Len: 6}
+	truncateStringConversion(sv, LoadConfig{MaxStringLen: 2})
+	if s := constant.StringVal(sv.Value); s != "ab" || sv.Len != 6 {
+		t.Errorf("wrong string %q len %d", s, sv.Len)
+	}
+	slv := &Variable{Kind: reflect.Slice, Children: make([]Variable, 6), Len: 6, Cap: 6}
+	truncateStringConversion(slv, LoadConfig{MaxArrayValues: 4})
+	if len(slv.Children) != 4 || slv.Len != 6 || slv.Cap != 6 {
+		t.Errorf("wrong slice %d children len %d cap %d", len(slv.Children), slv.Len, slv.Cap)
+	}
+}
@@ -434,6 +434,9 @@ func (it *stackIterator) advanceRegs() (callFrameRegs op.DwarfRegisters, ret uin
 		if ret == 0 && it.regs.Reg(it.regs.LRRegNum) != nil {
 			ret = it.regs.Reg(it.regs.LRRegNum).Uint64Val
 		}
+		// Return addresses saved by C code compiled with pointer
+		// authentication enabled are signed.
+		ret = it.bi.Arch.stripPointerAuth(ret)
 	}
 
 	return callFrameRegs, ret, retaddr
@@ -574,7 +577,7 @@ func (g *G) readDefers(frames []Stackframe) {
 }
 
 func (d *Defer) load() {
-	d.variable.loadValue(LoadConfig{false, 1, 0, 0, -1, 0})
+	d.variable.loadValue(LoadConfig{MaxVariableRecurse: 1, MaxStructFields: -1})
 	if d.variable.Unreadable != nil {
 		d.Unreadable = d.variable.Unreadable
 		return
@@ -0,0 +1,374 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"go/constant"
+	"net"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
+)
+
+// Constants of the representation of time.Time, see the time package.
+const (
+	timeHasMonotonic   = 1 << 63
+	timeNsecMask       = 1<<30 - 1
+	timeNsecShift      = 30
+	timeWallToInternal = 59453308800
+	timeInternalToUnix = -62135596800
+)
+
+// builtinFormatter returns the function that renders the values of
+// typename, if it is one of the types of the standard library whose fields
+// are hard to interpret, see Variable.Formatted. The formatters only read
+// the memory of the target, so they also work on core files and
+// recordings.
+func builtinFormatter(typename string) func(v *Variable, cfg LoadConfig) (string, error) {
+	switch typename {
+	case "time.Time":
+		return formatTime
+	case "time.Duration":
+		return formatDuration
+	case "time.Month":
+		return formatMonth
+	case "time.Weekday":
+		return formatWeekday
+	case "net.IP":
+		return formatIP
+	case "net/netip.Addr":
+		return formatNetipAddr
+	case "math/big.Int":
+		return formatBigInt
+	case "math/big.Float":
+		return formatBigFloat
+	case "math/big.Rat":
+		return formatBigRat
+	}
+	return nil
+}
+
+// formatBuiltin sets v.Formatted if the type of v has a built-in
+// formatter. If the value can not be rendered v is left unformatted, so
+// that its fields can be examined.
+func (v *Variable) formatBuiltin(cfg LoadConfig) {
+	if v.Formatted != "" || v.DwarfType == nil {
+		return
+	}
+	f := builtinFormatter(v.DwarfType.Common().Name)
+	if f == nil {
+		return
+	}
+	if s, err := f(v, cfg); err == nil {
+		v.Formatted = s
+	}
+}
+
+// formatTime renders the time.Time v in RFC3339 format, followed by the
+// name of the time zone and by the reading of the monotonic clock, if any.
+// Both the layout introduced by Go 1.9, with the wall and ext fields, and
+// the older layout, with the sec and nsec fields, are supported.
+func formatTime(v *Variable, _ LoadConfig) (string, error) {
+	var sec, nsec, mono int64
+	hasMono := false
+	if wall, err := fieldUint(v, "wall"); err == nil {
+		ext, err := fieldInt(v, "ext")
+		if err != nil {
+			return "", err
+		}
+		nsec = int64(wall & timeNsecMask)
+		if wall&timeHasMonotonic != 0 {
+			sec = timeWallToInternal + int64(wall<<1>>(timeNsecShift+1))
+			mono, hasMono = ext, true
+		} else {
+			sec = ext
+		}
+	} else {
+		if sec, err = fieldInt(v, "sec"); err != nil {
+			return "", err
+		}
+		if nsec, err = fieldInt(v, "nsec"); err != nil {
+			return "", err
+		}
+	}
+	unix := sec + timeInternalToUnix
+
+	loc, err := v.structMember("loc")
+	if err != nil {
+		return "", err
+	}
+	name, offset, err := timeZone(loc, unix)
+	if err != nil {
+		return "", err
+	}
+
+	s := time.Unix(unix, nsec).In(time.FixedZone(name, offset)).Format(time.RFC3339Nano)
+	if name != "UTC" && name != "" {
+		s += " " + name
+	}
+	if hasMono {
+		sign, m := '+', uint64(mono)
+		if mono < 0 {
+			sign, m = '-', -m
+		}
+		s += fmt.Sprintf(" m=%c%d.%09d", sign, m/1e9, m%1e9)
+	}
+	return s, nil
+}
+
+// timeZone returns the name and the offset of the zone of the
+// *time.Location loc in effect at unix seconds since the epoch, a nil
+// location is UTC. The zone is looked up in the cache of the location
+// first, then in its list of transitions, the rule for the times after the
+// last transition is not evaluated.
+func timeZone(loc *Variable, unix int64) (string, int, error) {
+	loc = loc.maybeDereference()
+	if loc.Unreadable != nil {
+		return "", 0, loc.Unreadable
+	}
+	if loc.Addr == 0 {
+		return "UTC", 0, nil
+	}
+	zones, err := loc.structMember("zone")
+	if err != nil {
+		return "", 0, err
+	}
+	if zones.Unreadable != nil {
+		return "", 0, zones.Unreadable
+	}
+	if zones.Len <= 0 {
+		return "UTC", 0, nil
+	}
+
+	if start, err := fieldInt(loc, "cacheStart"); err == nil && start <= unix {
+		if end, err := fieldInt(loc, "cacheEnd"); err == nil && unix < end {
+			if zone, err := loc.structMember("cacheZone"); err == nil {
+				if zone = zone.maybeDereference(); zone.Unreadable == nil && zone.Addr != 0 {
+					return timeZoneEntry(zone)
+				}
+			}
+		}
+	}
+
+	tx, err := loc.structMember("tx")
+	if err != nil {
+		return "", 0, err
+	}
+	// index of the first transition after unix
+	var txErr error
+	n := sort.Search(int(tx.Len), func(i int) bool {
+		t, err := tx.sliceAccess(i)
+		if err != nil {
+			txErr = err
+			return true
+		}
+		when, err := fieldInt(t, "when")
+		if err != nil {
+			txErr = err
+			return true
+		}
+		return when > unix
+	})
+	if txErr != nil {
+		return "", 0, txErr
+	}
+	if n == 0 {
+		// before the first transition, use the first standard time zone
+		for i := 0; i < int(zones.Len); i++ {
+			zone, err := zones.sliceAccess(i)
+			if err != nil {
+				return "", 0, err
+			}
+			isDST, err := fieldUint(zone, "isDST")
+			if err != nil {
+				return "", 0, err
+			}
+			if isDST == 0 {
+				return timeZoneEntry(zone)
+			}
+		}
+		zone, err := zones.sliceAccess(0)
+		if err != nil {
+			return "", 0, err
+		}
+		return timeZoneEntry(zone)
+	}
+	t, err := tx.sliceAccess(n - 1)
+	if err != nil {
+		return "", 0, err
+	}
+	idx, err := fieldUint(t, "index")
+	if err != nil {
+		return "", 0, err
+	}
+	zone, err := zones.sliceAccess(int(idx))
+	if err != nil {
+		return "", 0, err
+	}
+	return timeZoneEntry(zone)
+}
+
+// timeZoneEntry returns the name and the offset of the time.zone v.
+func timeZoneEntry(v *Variable) (string, int, error) {
+	name, err := v.structMember("name")
+	if err != nil {
+		return "", 0, err
+	}
+	name.loadValue(loadSingleValue)
+	if name.Unreadable != nil {
+		return "", 0, name.Unreadable
+	}
+	offset, err := fieldInt(v, "offset")
+	if err != nil {
+		return "", 0, err
+	}
+	return constant.StringVal(name.Value), int(offset), nil
+}
+
+func formatDuration(v *Variable, _ LoadConfig) (string, error) {
+	n, err := intValue(v)
+	return time.Duration(n).String(), err
+}
+
+func formatMonth(v *Variable, _ LoadConfig) (string, error) {
+	n, err := intValue(v)
+	return time.Month(n).String(), err
+}
+
+func formatWeekday(v *Variable, _ LoadConfig) (string, error) {
+	n, err := intValue(v)
+	return time.Weekday(n).String(), err
+}
+
+// formatIP renders the net.IP v, which must have the length of an IPv4 or
+// IPv6 address.
+func formatIP(v *Variable, _ LoadConfig) (string, error) {
+	if v.Kind != reflect.Slice || v.Unreadable != nil {
+		return "", errors.New("not a slice")
+	}
+	if v.Len == 0 {
+		return net.IP(nil).String(), nil
+	}
+	if v.Len != net.IPv4len && v.Len != net.IPv6len {
+		return "", fmt.Errorf("invalid length %d", v.Len)
+	}
+	buf := make([]byte, v.Len)
+	if _, err := DereferenceMemory(v.mem).ReadMemory(buf, v.Base); err != nil {
+		return "", err
+	}
+	return net.IP(buf).String(), nil
+}
+
+// formatNetipAddr renders the net/netip.Addr v. Since Go 1.23 the zone of
+// the address is a unique.Handle[addrDetail], before it was an
+// *intern.Value holding the zone string, or nil for IPv4 addresses.
+func formatNetipAddr(v *Variable, _ LoadConfig) (string, error) {
+	addr, err := v.structMember("addr")
+	if err != nil {
+		return "", err
+	}
+	hi, err := fieldUint(addr, "hi")
+	if err != nil {
+		return "", err
+	}
+	lo, err := fieldUint(addr, "lo")
+	if err != nil {
+		return "", err
+	}
+	z, err := v.structMember("z")
+	if err != nil {
+		return "", err
+	}
+	if _, isstruct := z.RealType.(*godwarf.StructType); isstruct {
+		if z, err = z.structMember("value"); err != nil {
+			return "", err
+		}
+	}
+	z = z.maybeDereference()
+	if z.Unreadable != nil {
+		return "", z.Unreadable
+	}
+	if z.Addr == 0 {
+		return "invalid IP", nil
+	}
+
+	var isV6 bool
+	var zone string
+	if isV6Field, err := fieldUint(z, "isV6"); err == nil {
+		isV6 = isV6Field != 0
+		zoneField, err := z.structMember("zoneV6")
+		if err != nil {
+			return "", err
+		}
+		zoneField.loadValue(loadSingleValue)
+		if zoneField.Unreadable != nil {
+			return "", zoneField.Unreadable
+		}
+		zone = constant.StringVal(zoneField.Value)
+	} else {
+		cmpVal, err := z.structMember("cmpVal")
+		if err != nil {
+			return "", err
+		}
+		if _, _, isnil := cmpVal.readInterface(); !isnil {
+			cmpVal.loadValue(loadSingleValue)
+			if cmpVal.Unreadable != nil {
+				return "", cmpVal.Unreadable
+			}
+			if len(cmpVal.Children) != 1 || cmpVal.Children[0].Kind != reflect.String || cmpVal.Children[0].Value == nil {
+				return "", errors.New("unexpected zone of net/netip.Addr")
+			}
+			isV6 = true
+			zone = constant.StringVal(cmpVal.Children[0].Value)
+		}
+	}
+
+	if !isV6 {
+		return net.IPv4(byte(lo>>24), byte(lo>>16), byte(lo>>8), byte(lo)).String(), nil
+	}
+	ip := make(net.IP, net.IPv6len)
+	for i := 0; i < 8; i++ {
+		ip[i] = byte(hi >> uint(56-8*i))
+		ip[i+8] = byte(lo >> uint(56-8*i))
+	}
+	s := ip.String()
+	if ip.To4() != nil {
+		// net.IP renders IPv4-mapped IPv6 addresses as IPv4 addresses
+		s = "::ffff:" + s
+	}
+	if zone != "" {
+		s += "%" + zone
+	}
+	return s, nil
+}
+
+// fieldInt returns the value of the signed integer field name of the
+// struct v.
+func fieldInt(v *Variable, name string) (int64, error) {
+	f, err := v.structMember(name)
+	if err != nil {
+		return 0, err
+	}
+	return readIntRaw(f.mem, f.Addr, f.RealType.Size())
+}
+
+// fieldUint returns the value of the unsigned integer or boolean field
+// name of the struct v.
+func fieldUint(v *Variable, name string) (uint64, error) {
+	f, err := v.structMember(name)
+	if err != nil {
+		return 0, err
+	}
+	return readUintRaw(f.mem, f.Addr, f.RealType.Size())
+}
+
+// intValue returns the value of the loaded integer v.
+func intValue(v *Variable) (int64, error) {
+	if v.Value == nil || v.Value.Kind() != constant.Int {
+		return 0, errors.New("not an integer")
+	}
+	n, _ := constant.Int64Val(v.Value)
+	return n, nil
+}
@@ -0,0 +1,134 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-delve/delve/pkg/astutil"
+)
+
+// stepIntoNewGoroutineTimeout is the maximum amount of time
+// StepIntoNewGoroutine waits for the new goroutine to start running.
+const stepIntoNewGoroutineTimeout = 10 * time.Second
+
+// StepIntoNewGoroutine executes the first go statement on the current line
+// and stops at the first instruction of the entry function of the
+// goroutine it creates.
+//
+// Only the goroutine created by this execution of the go statement is
+// followed, other goroutines starting the same function, including the
+// ones created by the same go statement in a loop, are ignored.
+// An error is returned if the selected goroutine exits before the new
+// goroutine starts running or if the new goroutine does not start running
+// within a few seconds.
+// If the new goroutine is already running when the go statement returns
+// it is selected wherever it is.
+func (dbp *Target) StepIntoNewGoroutine() error {
+	if _, err := dbp.Valid(); err != nil {
+		return err
+	}
+	if dbp.Breakpoints().HasSteppingBreakpoints() {
+		return fmt.Errorf("next while nexting")
+	}
+	if dbp.GetDirection() == Backward {
+		return errors.New("can not step into a new goroutine backwards")
+	}
+	parent := dbp.SelectedGoroutine()
+	if parent == nil {
+		return errors.New("no goroutine selected")
+	}
+
+	topframe, calls, err := currentLineCalls(dbp)
+	if err != nil {
+		return err
+	}
+	var gopc uint64
+	for _, instr := range calls {
+		if instr.DestLoc != nil && instr.DestLoc.Fn != nil && instr.DestLoc.Fn.Name == "runtime.newproc" {
+			gopc = instr.Loc.PC + uint64(instr.Size)
+			break
+		}
+	}
+	if gopc == 0 {
+		return errors.New("no go statement on the current line")
+	}
+
+	defer dbp.ClearSteppingBreakpoints()
+
+	// Continue until the go statement has created the new goroutine, the
+	// return address of runtime.newproc is the creation PC of the
+	// goroutine.
+	_, err = dbp.SetBreakpoint(gopc, NextBreakpoint, astutil.And(sameGoroutineCondition(parent), frameoffCondition(&topframe)))
+	if err != nil {
+		return err
+	}
+	if err := dbp.Continue(); err != nil {
+		return err
+	}
+	if dbp.StopReason != StopNextFinished {
+		// stopped by a breakpoint or a manual stop request
+		return nil
+	}
+	if err := dbp.ClearSteppingBreakpoints(); err != nil {
+		return err
+	}
+
+	gs, _, err := GoroutinesInfo(dbp, 0, 0)
+	if err != nil {
+		return err
+	}
+	var newg *G
+	for _, g := range gs {
+		if g.GoPC != gopc || (g.parentID != 0 && g.parentID != parent.ID) {
+			continue
+		}
+		if newg == nil || g.ID > newg.ID {
+			newg = g
+		}
+	}
+	if newg == nil {
+		return fmt.Errorf("could not find the goroutine created at %#x", gopc)
+	}
+	if newg.Thread != nil || newg.PC != newg.StartPC {
+		// the new goroutine was already scheduled
+		return dbp.SwitchGoroutine(newg)
+	}
+
+	bp, err := dbp.SetBreakpoint(newg.StartPC, NextBreakpoint, nil)
+	if err != nil {
+		return err
+	}
+	bp.Breaklets[len(bp.Breaklets)-1].callback = func(th Thread) (bool, error) {
+		g, err := GetG(th)
+		if err != nil || g == nil {
+			return false, nil
+		}
+		return g.ID == newg.ID && g.GoPC == gopc && (g.parentID == 0 || g.parentID == parent.ID), nil
+	}
+	if fn := dbp.BinInfo().LookupFunc["runtime.goexit1"]; fn != nil {
+		bp, err := dbp.SetBreakpoint(fn.Entry, NextBreakpoint, sameGoroutineCondition(parent))
+		if err != nil {
+			return err
+		}
+		bp.Breaklets[len(bp.Breaklets)-1].callback = func(Thread) (bool, error) {
+			return true, fmt.Errorf("goroutine %d exited before goroutine %d started running", parent.ID, newg.ID)
+		}
+	}
+
+	var timedOut int32
+	timer := time.AfterFunc(stepIntoNewGoroutineTimeout, func() {
+		atomic.StoreInt32(&timedOut, 1)
+		dbp.RequestManualStop()
+	})
+	err = dbp.Continue()
+	timer.Stop()
+	if err != nil {
+		return err
+	}
+	if dbp.StopReason == StopManual && atomic.LoadInt32(&timedOut) != 0 {
+		return fmt.Errorf("timed out waiting for goroutine %d to start running", newg.ID)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package proc
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// StepSkipKind describes what the pattern of a StepSkip is matched against.
+type StepSkipKind string
+
+const (
+	// StepSkipFunction rules match the regular expression in their pattern
+	// against the full name of the function.
+	StepSkipFunction StepSkipKind = "function"
+	// StepSkipFile rules match the glob in their pattern against the path of
+	// the file containing the function.
+	StepSkipFile StepSkipKind = "file"
+)
+
+// StepSkip is a rule that makes Step step over the functions it matches
+// instead of stopping inside them.
+// Step skip rules do not affect breakpoints, next or stepout.
+type StepSkip struct {
+	ID      int
+	Kind    StepSkipKind
+	Pattern string
+
+	re *regexp.Regexp
+}
+
+// StepSkipList is the list of step skip rules of a target.
+type StepSkipList struct {
+	rules  []*StepSkip
+	lastID int
+}
+
+// Add creates a new step skip rule.
+// Patterns of file rules are globs where '*' matches any sequence of
+// characters except '/', '**' matches any sequence of characters and '?'
+// matches any one character except '/'. A glob that does not start with
+// '/' matches the trailing path components of a file.
+func (l *StepSkipList) Add(kind StepSkipKind, pattern string) (*StepSkip, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("empty %s pattern", kind)
+	}
+	var expr string
+	switch kind {
+	case StepSkipFunction:
+		expr = "^(?:" + pattern + ")$"
+	case StepSkipFile:
+		expr = globToRegexp(pattern)
+	default:
+		return nil, fmt.Errorf("unknown step skip kind %q", kind)
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s pattern %q: %v", kind, pattern, err)
+	}
+	l.lastID++
+	rule := &StepSkip{ID: l.lastID, Kind: kind, Pattern: pattern, re: re}
+	l.rules = append(l.rules, rule)
+	return rule, nil
+}
+
+// Remove deletes the step skip rule with the specified ID.
+func (l *StepSkipList) Remove(id int) error {
+	for i := range l.rules {
+		if l.rules[i].ID == id {
+			copy(l.rules[i:], l.rules[i+1:])
+			l.rules[len(l.rules)-1] = nil
+			l.rules = l.rules[:len(l.rules)-1]
+			return nil
+		}
+	}
+	return fmt.Errorf("no step skip rule with id %d", id)
+}
+
+// Rules returns the list of step skip rules, in the order they were
+// created.
+func (l *StepSkipList) Rules() []*StepSkip {
+	return l.rules
+}
+
+// Empty returns true if there are no step skip rules.
+func (l *StepSkipList) Empty() bool {
+	return len(l.rules) == 0
+}
+
+// Match returns true if a function called fnname, defined in file, is
+// matched by one of the rules.
+func (l *StepSkipList) Match(fnname, file string) bool {
+	for _, rule := range l.rules {
+		switch rule.Kind {
+		case StepSkipFunction:
+			if rule.re.MatchString(fnname) {
+				return true
+			}
+		case StepSkipFile:
+			if file != "" && rule.re.MatchString(file) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchFunction returns true if fn is matched by one of the rules.
+func (l *StepSkipList) matchFunction(fn *Function) bool {
+	if fn == nil || l.Empty() {
+		return false
+	}
+	var file string
+	if fn.cu != nil && fn.cu.lineInfo != nil {
+		file, _ = fn.cu.lineInfo.PCToLine(fn.Entry, fn.Entry)
+	}
+	return l.Match(fn.Name, file)
+}
+
+// StepSkips returns the step skip rules of the target.
+func (t *Target) StepSkips() *StepSkipList {
+	return t.stepSkips
+}
+
+// SetStepSkips replaces the step skip rules of the target, it is used to
+// keep the rules of a debugging session when the target is restarted.
+func (t *Target) SetStepSkips(l *StepSkipList) {
+	t.stepSkips = l
+}
+
+// globToRegexp converts a file glob into a regular expression, see
+// StepSkipList.Add for the syntax.
+func globToRegexp(glob string) string {
+	var buf strings.Builder
+	if strings.HasPrefix(glob, "/") {
+		buf.WriteString("^")
+	} else {
+		buf.WriteString("(?:^|/)")
+	}
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**"):
+			buf.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			buf.WriteString("[^/]*")
+		case glob[i] == '?':
+			buf.WriteString("[^/]")
+		default:
+			buf.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	buf.WriteString("$")
+	return buf.String()
+}
@@ -0,0 +1,384 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"go/constant"
+	"reflect"
+
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
+	"github.com/go-delve/delve/pkg/goversion"
+)
+
+const (
+	// semaMaxNodes is the maximum number of sudogs read from a treap of
+	// runtime.semtable.
+	semaMaxNodes = 10000
+	// waitGroupBubbleFlag is the bit of the waiter count of a
+	// sync.WaitGroup recording its membership in a synctest bubble, since
+	// Go 1.25.
+	waitGroupBubbleFlag = 0x80000000
+)
+
+// syncMembers lists the children appended by loadSyncContents to the
+// values of each type.
+var syncMembers = map[string][]string{
+	"sync.WaitGroup": {"counter", "waiters"},
+	"sync.Once":      {"completed"},
+	"sync.Cond":      {"waiters"},
+}
+
+// loadSyncContents appends to v, a struct whose fields have been loaded,
+// children describing the state of values of the sync package that is
+// otherwise kept in opaque fields:
+//
+//   - sync.WaitGroup: "counter", the value of the counter, and "waiters",
+//     the IDs of the goroutines parked in Wait
+//   - sync.Once: "completed", true if the function passed to Do returned
+//   - sync.Cond: "waiters", the IDs of the goroutines parked in Wait
+//
+// The layout of these types changes between versions of Go, the version is
+// read from the producer of the debug info and nothing is appended if it is
+// unknown or if the fields do not have the layout of that version.
+func (v *Variable) loadSyncContents() {
+	if v.DwarfType == nil {
+		return
+	}
+	typename := v.DwarfType.Common().Name
+	if _, ok := syncMembers[typename]; !ok {
+		return
+	}
+	producer := v.bi.Producer()
+	if producer == "" || !goversion.ProducerAfterOrEqual(producer, goversion.MinSupportedVersionOfGoMajor, goversion.MinSupportedVersionOfGoMinor) {
+		return
+	}
+	mem := DereferenceMemory(v.mem)
+
+	switch typename {
+	case "sync.WaitGroup":
+		counter, waiters, sema, err := v.waitGroupState(producer)
+		if err != nil {
+			return
+		}
+		var goids []int
+		if waiters > 0 {
+			goids = semaWaiters(v.bi, mem, []uint64{sema})[sema]
+		}
+		v.Children = append(v.Children, *v.syncConstant("counter", constant.MakeInt64(int64(counter))), *v.goroutineIDs("waiters", goids))
+
+	case "sync.Once":
+		done, err := syncIntMember(v, "done")
+		if err != nil {
+			return
+		}
+		v.Children = append(v.Children, *v.syncConstant("completed", constant.MakeBool(done != 0)))
+
+	case "sync.Cond":
+		goids, err := v.notifyListWaiters()
+		if err != nil {
+			return
+		}
+		v.Children = append(v.Children, *v.goroutineIDs("waiters", goids))
+	}
+}
+
+// syncMember returns the child called name appended by loadSyncContents
+// to v, or nil if v does not have one.
+func (v *Variable) syncMember(name string) *Variable {
+	if v.Kind == reflect.Ptr {
+		v = v.maybeDereference()
+	}
+	if v.DwarfType == nil {
+		return nil
+	}
+	found := false
+	for _, member := range syncMembers[v.DwarfType.Common().Name] {
+		if member == name {
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+	sv := v.clone()
+	sv.Children = nil
+	sv.loaded = false
+	sv.loadValue(loadFullValue)
+	for i := range sv.Children {
+		if sv.Children[i].Name == name {
+			return &sv.Children[i]
+		}
+	}
+	return nil
+}
+
+func (v *Variable) syncConstant(name string, val constant.Value) *Variable {
+	r := newConstant(val, DereferenceMemory(v.mem))
+	r.Name = name
+	return r
+}
+
+// waitGroupState returns the counter and the number of waiters of the
+// sync.WaitGroup v and the address of its semaphore.
+func (v *Variable) waitGroupState(producer string) (counter int32, waiters uint32, sema uint64, err error) {
+	field := func(name string, size int64) (*Variable, error) {
+		f, err := v.structMember(name)
+		if err != nil {
+			return nil, err
+		}
+		if f.RealType.Size() != size {
+			return nil, fmt.Errorf("unexpected size of field %s of sync.WaitGroup", name)
+		}
+		return f, nil
+	}
+
+	var stateAddr uint64
+	switch {
+	case goversion.ProducerAfterOrEqual(producer, 1, 20):
+		// state atomic.Uint64; sema uint32
+		state, err := field("state", 8)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		semaVar, err := field("sema", 4)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		stateAddr, sema = state.Addr, semaVar.Addr
+	case goversion.ProducerAfterOrEqual(producer, 1, 18):
+		// state1 uint64; state2 uint32, on 32bit architectures the first
+		// 32 bits are the semaphore if state1 is not 64bit aligned.
+		state1, err := field("state1", 8)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		state2, err := field("state2", 4)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		if state1.Addr%8 == 0 {
+			stateAddr, sema = state1.Addr, state2.Addr
+		} else {
+			stateAddr, sema = state1.Addr+4, state1.Addr
+		}
+	default:
+		// state1 [3]uint32, the state is the 64bit aligned pair of elements
+		// and the semaphore the remaining one.
+		state1, err := field("state1", 12)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		if state1.Addr%8 == 0 {
+			stateAddr, sema = state1.Addr, state1.Addr+8
+		} else {
+			stateAddr, sema = state1.Addr+4, state1.Addr
+		}
+	}
+
+	state, err := readUintRaw(v.mem, stateAddr, 8)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	counter, waiters = int32(state>>32), uint32(state)
+	if goversion.ProducerAfterOrEqual(producer, 1, 25) {
+		waiters &^= waitGroupBubbleFlag
+	}
+	return counter, waiters, sema, nil
+}
+
+// notifyListWaiters returns the IDs of the goroutines in the notify list
+// of the sync.Cond v.
+func (v *Variable) notifyListWaiters() ([]int, error) {
+	notify, err := v.structMember("notify")
+	if err != nil {
+		return nil, err
+	}
+	wait, err := syncIntMember(notify, "wait")
+	if err != nil {
+		return nil, err
+	}
+	notified, err := syncIntMember(notify, "notify")
+	if err != nil {
+		return nil, err
+	}
+	head, err := notify.structMember("head")
+	if err != nil {
+		return nil, err
+	}
+	if uint32(wait) == uint32(notified) {
+		return nil, nil
+	}
+	// the head of the list is an unsafe.Pointer in the copy of
+	// runtime.notifyList declared by the sync package.
+	sudogType, err := v.bi.findType("runtime.sudog")
+	if err != nil {
+		return nil, err
+	}
+	ptrSize := int64(v.bi.Arch.PtrSize())
+	mem := DereferenceMemory(v.mem)
+	p, err := readUintRaw(head.mem, head.Addr, ptrSize)
+	if err != nil {
+		return nil, err
+	}
+	var goids []int
+	for p != 0 && len(goids) < maxWaiters {
+		s := newVariable("", p, sudogType, v.bi, mem)
+		if goid := sudogGoroutineID(s); goid != 0 {
+			goids = append(goids, goid)
+		}
+		p = sudogPointer(s, "next", ptrSize)
+	}
+	return goids, nil
+}
+
+// syncIntMember returns the value of the integer field name of v, which
+// can also be one of the types of sync/atomic.
+func syncIntMember(v *Variable, name string) (int64, error) {
+	f, err := v.structMember(name)
+	if err != nil {
+		return 0, err
+	}
+	if _, isstruct := f.RealType.(*godwarf.StructType); isstruct {
+		if f, err = f.structMember("v"); err != nil {
+			return 0, err
+		}
+	}
+	f.loadValue(loadSingleValue)
+	if f.Unreadable != nil {
+		return 0, f.Unreadable
+	}
+	if f.Value == nil || f.Value.Kind() != constant.Int {
+		return 0, fmt.Errorf("field %s of %s is not an integer", name, v.TypeString())
+	}
+	n, _ := constant.Int64Val(f.Value)
+	return n, nil
+}
+
+// runtimeSemtable returns runtime.semtable, the table of the goroutines
+// parked on semaphores.
+func runtimeSemtable(bi *BinaryInfo, mem MemoryReadWriter) (*Variable, error) {
+	for _, pkgvar := range bi.packageVars {
+		if pkgvar.name != "runtime.semtable" {
+			continue
+		}
+		reader := pkgvar.cu.image.dwarfReader
+		reader.Seek(pkgvar.offset)
+		entry, err := reader.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, typ, err := readVarEntry(godwarf.EntryToTree(entry), pkgvar.cu.image)
+		if err != nil {
+			return nil, err
+		}
+		return newVariable(pkgvar.name, pkgvar.addr, typ, bi, mem), nil
+	}
+	return nil, errors.New("could not find runtime.semtable")
+}
+
+// semaWaiters returns the IDs of the goroutines parked on the semaphores
+// at addrs, read from the treaps of runtime.semtable.
+func semaWaiters(bi *BinaryInfo, mem MemoryReadWriter, addrs []uint64) map[uint64][]int {
+	r := map[uint64][]int{}
+	semtable, err := runtimeSemtable(bi, mem)
+	if err != nil {
+		return r
+	}
+	arrtyp, isarr := resolveTypedef(semtable.DwarfType).(*godwarf.ArrayType)
+	if !isarr || arrtyp.Count <= 0 {
+		return r
+	}
+	ptrSize := int64(bi.Arch.PtrSize())
+	want := map[uint64]bool{}
+	for _, addr := range addrs {
+		want[addr] = true
+	}
+
+	visited := map[uint64]bool{}
+	for _, addr := range addrs {
+		// see semroot in runtime/sema.go
+		idx := (addr >> 3) % uint64(arrtyp.Count)
+		entry := newVariable("", semtable.Addr+idx*uint64(arrtyp.Type.Size()), arrtyp.Type, bi, mem)
+		root, err := entry.structMember("root")
+		if err != nil {
+			continue
+		}
+		treap, err := root.structMember("treap")
+		if err != nil {
+			continue
+		}
+		ptyp, isptr := treap.RealType.(*godwarf.PtrType)
+		if !isptr {
+			continue
+		}
+		p, err := readUintRaw(treap.mem, treap.Addr, ptrSize)
+		if err != nil {
+			continue
+		}
+		// the sudogs parked on different addresses are the nodes of the
+		// treap, linked by prev and next, the sudogs parked on the same
+		// address are linked to the node by waitlink.
+		stack := []uint64{p}
+		for len(stack) > 0 && len(visited) < semaMaxNodes {
+			p := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if p == 0 || visited[p] {
+				continue
+			}
+			visited[p] = true
+			node := newVariable("", p, ptyp.Type, bi, mem)
+			elem := sudogPointer(node, "elem", ptrSize)
+			if want[elem] {
+				for s := node; len(r[elem]) < semaMaxNodes; {
+					if goid := sudogGoroutineID(s); goid != 0 {
+						r[elem] = append(r[elem], goid)
+					}
+					next := sudogPointer(s, "waitlink", ptrSize)
+					if next == 0 || visited[next] {
+						break
+					}
+					visited[next] = true
+					s = newVariable("", next, ptyp.Type, bi, mem)
+				}
+			}
+			stack = append(stack, sudogPointer(node, "prev", ptrSize), sudogPointer(node, "next", ptrSize))
+		}
+	}
+	return r
+}
+
+// sudogPointer returns the value of the pointer field name of the
+// runtime.sudog s, or 0 if it can not be read.
+func sudogPointer(s *Variable, name string, ptrSize int64) uint64 {
+	f, err := s.structMember(name)
+	if err != nil {
+		return 0
+	}
+	p, err := readUintRaw(f.mem, f.Addr, ptrSize)
+	if err != nil {
+		return 0
+	}
+	return p
+}
+
+// sudogGoroutineID returns the ID of the goroutine of the runtime.sudog s.
+func sudogGoroutineID(s *Variable) int {
+	g, err := s.structMember("g")
+	if err != nil {
+		return 0
+	}
+	g = g.maybeDereference()
+	if g.Unreadable != nil || g.Addr == 0 {
+		return 0
+	}
+	goid, err := g.structMember("goid")
+	if err != nil {
+		return 0
+	}
+	goid.loadValue(loadSingleValue)
+	if goid.Unreadable != nil || goid.Value == nil {
+		return 0
+	}
+	n, _ := constant.Int64Val(goid.Value)
+	return int(n)
+}
@@ -6,6 +6,7 @@ import (
 	"go/constant"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/go-delve/delve/pkg/dwarf/op"
@@ -17,6 +18,18 @@ var (
 	// only possible on recorded (traced) programs.
 	ErrNotRecorded = errors.New("not a recording")
 
+	// ErrStartOfRecording is returned by RewindTo when the start of the
+	// recording is reached before the requested location.
+	ErrStartOfRecording = errors.New("start of recording reached")
+
+	// ErrPassSignalsUnsupported is returned when the list of signals passed
+	// through to the target can not be changed by the current backend.
+	ErrPassSignalsUnsupported = errors.New("backend does not support configuring signal pass-through")
+
+	// ErrResumeThreadUnsupported is returned when the current backend can
+	// not resume a single thread of the target.
+	ErrResumeThreadUnsupported = errors.New("backend does not support resuming a single thread")
+
 	// ErrNoRuntimeAllG is returned when the runtime.allg list could
 	// not be found.
 	ErrNoRuntimeAllG = errors.New("could not find goroutine array")
@@ -56,6 +69,14 @@ type Target struct {
 	// fncallForG stores a mapping of current active function calls.
 	fncallForG map[int]*callInjection
 
+	// condCallG is the ID of the goroutine where a breakpoint condition
+	// containing function calls is being evaluated, while it is not zero
+	// breakpoints do not stop the target.
+	condCallG int
+
+	// pinned is the goroutine being resumed by ResumePinned, if any.
+	pinned *pinnedResume
+
 	asyncPreemptChanged bool  // runtime/debug.asyncpreemptoff was changed
 	asyncPreemptOff     int64 // cached value of runtime/debug.asyncpreemptoff
 
@@ -74,6 +95,55 @@ type Target struct {
 	// can be given a unique address.
 	fakeMemoryRegistry    []*compositeMemory
 	fakeMemoryRegistryMap map[string]*compositeMemory
+
+	// resumeNotify is the channel passed to the last call to ResumeNotify,
+	// it is needed when the target is resumed without calling ContinueOnce
+	// (i.e. when software watchpoints are set).
+	resumeNotify chan<- struct{}
+
+	// manualStopRequests counts the calls to RequestManualStop, it is used
+	// by ResumeWithTimeout to tell whether a manual stop was requested only
+	// by its timer. Accessed atomically.
+	manualStopRequests int32
+
+	// logpointMessages contains the messages produced by logpoints that
+	// haven't been retrieved yet by GetBufferedLogpointMessages.
+	logpointMessages        []LogpointMessage
+	logpointMessagesDropped int
+	// logpointOutput, if set, receives the messages produced by logpoints
+	// instead of logpointMessages, see SetLogpointOutput.
+	logpointOutput func(LogpointMessage)
+
+	// watchOutOfScope lists the watchpoints that went out of scope during
+	// the last resume.
+	watchOutOfScope []WatchpointOutOfScope
+	// watchMigrations lists the watchpoints on slice elements that were
+	// moved or suspended during the last resume of the target.
+	watchMigrations []WatchpointMigration
+
+	// sharedObjectsLoaded is called every time the dynamic linker changes
+	// the list of loaded shared objects, see SetSharedObjectsLoadedCallback.
+	sharedObjectsLoaded func()
+
+	// maxHitsReached is called when a logical breakpoint reaches its
+	// MaxHits, see SetMaxHitsCallback.
+	maxHitsReached func(logicalID int)
+	// dynamicLinkerBreak is the address of the DynamicLinkerBreakpoint, zero
+	// if it isn't set.
+	dynamicLinkerBreak uint64
+
+	// autoCheckpoints maps the ID of a logical breakpoint to the IDs of the
+	// checkpoints it created automatically, oldest first.
+	autoCheckpoints map[int][]int
+
+	// stepSkips lists the functions that Step should step over.
+	stepSkips *StepSkipList
+	// stepSkipActive is true while the stepping operation in progress is
+	// a step and stepSkips apply to it.
+	stepSkipActive bool
+
+	// formatters renders the values of the types registered by the user.
+	formatters *FormatterList
 }
 
 // ErrProcessExited indicates that the process has exited and contains both
@@ -115,6 +185,12 @@ func (sr StopReason) String() string {
 		return "call returned"
 	case StopWatchpoint:
 		return "watchpoint"
+	case StopWatchOutOfScope:
+		return "watchpoint out of scope"
+	case StopWatchMigrated:
+		return "watchpoint migrated"
+	case StopTimeout:
+		return "timeout"
 	default:
 		return ""
 	}
@@ -131,6 +207,9 @@ const (
 	StopNextFinished                   // The next/step/stepout command terminated
 	StopCallReturned                   // An injected call completed
 	StopWatchpoint                     // The target process hit one or more watchpoints
+	StopWatchOutOfScope                // A watched stack variable went out of scope
+	StopWatchMigrated                  // A watchpoint on a slice element was moved to the new backing array of the slice
+	StopTimeout                        // The timeout of ResumeWithTimeout expired
 )
 
 // NewTargetConfig contains the configuration for a new Target object,
@@ -140,6 +219,7 @@ type NewTargetConfig struct {
 	DisableAsyncPreempt bool       // Go 1.14 asynchronous preemption should be disabled
 	StopReason          StopReason // Initial stop reason
 	CanDump             bool       // Can create core dumps (must implement ProcessInternal.MemoryMap)
+	PointerAuthMask     uint64     // Bits of code addresses used for pointer authentication codes (arm64 only), overrides the default for the target OS
 }
 
 // DisableAsyncPreemptEnv returns a process environment (like os.Environ)
@@ -163,6 +243,10 @@ func NewTarget(p Process, currentThread Thread, cfg NewTargetConfig) (*Target, e
 		return nil, err
 	}
 
+	if cfg.PointerAuthMask != 0 {
+		p.BinInfo().Arch.pointerAuthMask = cfg.PointerAuthMask
+	}
+
 	err = p.BinInfo().LoadBinaryInfo(cfg.Path, entryPoint, cfg.DebugInfoDirs)
 	if err != nil {
 		return nil, err
@@ -180,6 +264,8 @@ func NewTarget(p Process, currentThread Thread, cfg NewTargetConfig) (*Target, e
 		StopReason:    cfg.StopReason,
 		currentThread: currentThread,
 		CanDump:       cfg.CanDump,
+		stepSkips:     &StepSkipList{},
+		formatters:    &FormatterList{},
 	}
 
 	g, _ := GetG(currentThread)
@@ -187,6 +273,7 @@ func NewTarget(p Process, currentThread Thread, cfg NewTargetConfig) (*Target, e
 
 	t.createUnrecoveredPanicBreakpoint()
 	t.createFatalThrowBreakpoint()
+	t.setDynamicLinkerBreakpoint()
 
 	t.gcache.init(p.BinInfo())
 	t.fakeMemoryRegistryMap = make(map[string]*compositeMemory)
@@ -270,6 +357,173 @@ func (t *Target) Restart(from string) error {
 	return nil
 }
 
+// SeekTo restarts the recording at the start of event pos.Event, if
+// pos.Ticks is not zero execution then moves forward until the current
+// thread reaches the specified tick count.
+// This is only useful for recorded targets.
+func (t *Target) SeekTo(pos RecordingPosition) error {
+	from := ""
+	if pos.Event > 0 {
+		from = strconv.FormatInt(pos.Event, 10)
+	}
+	if err := t.Restart(from); err != nil {
+		return err
+	}
+	if pos.Ticks == 0 {
+		return nil
+	}
+	t.ClearCaches()
+	currentThread, err := t.proc.SeekTicks(pos.Ticks)
+	if err != nil {
+		return err
+	}
+	t.currentThread = currentThread
+	t.selectedGoroutine, _ = GetG(t.CurrentThread())
+	t.StopReason = StopManual
+	return nil
+}
+
+// AtCheckpoint restarts the recording from checkpoint id, selects
+// goroutine goid and calls fn, then returns to the current position.
+// When it returns the current thread, the selected goroutine and the stop
+// reason are the same as they were before AtCheckpoint was called.
+// This is only useful for recorded targets.
+func (t *Target) AtCheckpoint(id, goid int, fn func() error) error {
+	if recorded, _ := t.Recorded(); !recorded {
+		return ErrNotRecorded
+	}
+	if _, err := t.Valid(); err != nil {
+		return err
+	}
+	tid := t.CurrentThread().ThreadID()
+	selg := -1
+	if t.selectedGoroutine != nil {
+		selg = t.selectedGoroutine.ID
+	}
+	stopReason := t.StopReason
+
+	tmpid, err := t.Checkpoint("temporary: return position")
+	if err != nil {
+		return err
+	}
+	defer t.ClearCheckpoint(tmpid)
+
+	err = t.Restart(fmt.Sprintf("c%d", id))
+	if err == nil {
+		var g *G
+		g, err = FindGoroutine(t, goid)
+		if err == nil {
+			err = t.SwitchGoroutine(g)
+		}
+	}
+	if err == nil {
+		err = fn()
+	}
+
+	if err2 := t.Restart(fmt.Sprintf("c%d", tmpid)); err2 != nil {
+		return fmt.Errorf("could not return to the original position: %v", err2)
+	}
+	if err2 := t.SwitchThread(tid); err2 != nil {
+		return fmt.Errorf("could not return to the original position: %v", err2)
+	}
+	if selg >= 0 {
+		// The thread switch above already selects the goroutine running on
+		// it, this is only needed for parked goroutines.
+		if g, _ := FindGoroutine(t, selg); g != nil {
+			t.SwitchGoroutine(g)
+		}
+	}
+	t.StopReason = stopReason
+	return err
+}
+
+// DefaultAutoCheckpointMax is the maximum number of automatic checkpoints
+// kept for each breakpoint when Breaklet.AutoCheckpointMax is zero.
+const DefaultAutoCheckpointMax = 10
+
+// Checkpoints returns the list of currently set checkpoints, automatic
+// checkpoints created by breakpoints are marked as such.
+func (t *Target) Checkpoints() ([]Checkpoint, error) {
+	cps, err := t.Process.Checkpoints()
+	if err != nil {
+		return nil, err
+	}
+	for i := range cps {
+		cps[i].Auto = t.isAutoCheckpoint(cps[i].ID)
+	}
+	return cps, nil
+}
+
+func (t *Target) isAutoCheckpoint(id int) bool {
+	for _, ids := range t.autoCheckpoints {
+		for _, id2 := range ids {
+			if id2 == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// autoCheckpoint creates a checkpoint for each thread that hit a
+// breakpoint that has Breaklet.AutoCheckpoint set, if the hit count of the
+// breakpoint is a multiple of it, whether or not the breakpoint stops the
+// target. Checkpoints exceeding the maximum number of automatic checkpoints
+// of the breakpoint are deleted, oldest first.
+// Automatic checkpoints are best effort, errors are ignored.
+func (t *Target) autoCheckpoint(threads []Thread) {
+	if recorded, _ := t.Recorded(); !recorded {
+		return
+	}
+	for _, th := range threads {
+		bpstate := th.Breakpoint()
+		if bpstate.Breakpoint == nil || !bpstate.AutoCheckpoint {
+			continue
+		}
+		bpstate.AutoCheckpoint = false
+		breaklet := bpstate.UserBreaklet()
+		if breaklet == nil {
+			continue
+		}
+		id, err := t.Checkpoint(fmt.Sprintf("auto: breakpoint %d hit %d", bpstate.LogicalID, bpstate.logicalHits().total))
+		if err != nil {
+			continue
+		}
+		if t.autoCheckpoints == nil {
+			t.autoCheckpoints = make(map[int][]int)
+		}
+		ids := append(t.autoCheckpoints[bpstate.LogicalID], id)
+		max := breaklet.AutoCheckpointMax
+		if max <= 0 {
+			max = DefaultAutoCheckpointMax
+		}
+		for len(ids) > max {
+			_ = t.ClearCheckpoint(ids[0])
+			ids = ids[1:]
+		}
+		t.autoCheckpoints[bpstate.LogicalID] = ids
+	}
+}
+
+// PassSignals returns the signals that are delivered to the target without
+// stopping it.
+func (t *Target) PassSignals() SignalPassing {
+	return t.proc.PassSignals()
+}
+
+// SetPassSignals changes the list of signals that are delivered to the
+// target without stopping it.
+func (t *Target) SetPassSignals(sigs []int) error {
+	return t.proc.SetPassSignals(sigs)
+}
+
+// ResumeNotify specifies a channel that will be closed the next time
+// Continue finishes resuming the target.
+func (t *Target) ResumeNotify(ch chan<- struct{}) {
+	t.resumeNotify = ch
+	t.Process.ResumeNotify(ch)
+}
+
 // SelectedGoroutine returns the currently selected goroutine.
 func (t *Target) SelectedGoroutine() *G {
 	return t.selectedGoroutine
@@ -384,6 +638,107 @@ func (t *Target) createFatalThrowBreakpoint() {
 	}
 }
 
+// setDynamicLinkerBreakpoint sets a DynamicLinkerBreakpoint on the function
+// called by the dynamic linker every time the list of loaded shared objects
+// changes. Until the address of this function is known the breakpoint is set
+// on the entry point of the Go runtime, which is reached after the dynamic
+// linker has loaded the shared objects the executable depends on.
+func (t *Target) setDynamicLinkerBreakpoint() {
+	bi := t.BinInfo()
+	if bi.ElfDynamicSection.Addr == 0 {
+		return
+	}
+	addr := bi.ElfDynamicLinkerBreak
+	if addr == 0 {
+		fn, ok := bi.LookupFunc["runtime.rt0_go"]
+		if !ok {
+			return
+		}
+		addr = fn.Entry
+	}
+	if addr == t.dynamicLinkerBreak {
+		return
+	}
+	if bp, ok := t.Breakpoints().M[t.dynamicLinkerBreak]; ok {
+		for i := range bp.Breaklets {
+			if bp.Breaklets[i].Kind == DynamicLinkerBreakpoint {
+				bp.Breaklets[i] = nil
+			}
+		}
+		if cleared, _ := t.finishClearBreakpoint(bp); cleared {
+			for _, thread := range t.ThreadList() {
+				if thread.Breakpoint().Breakpoint == bp {
+					thread.Breakpoint().Clear()
+				}
+			}
+		}
+	}
+	t.dynamicLinkerBreak = 0
+	if _, err := t.SetBreakpoint(addr, DynamicLinkerBreakpoint, nil); err == nil {
+		t.dynamicLinkerBreak = addr
+	}
+}
+
+// SetSharedObjectsLoadedCallback sets a function that will be called every
+// time the dynamic linker changes the list of shared objects loaded by the
+// target. The function is called while the target is stopped, after the
+// new shared objects have been added to BinInfo().Images, and the target is
+// resumed once it returns.
+func (t *Target) SetSharedObjectsLoadedCallback(fn func()) {
+	t.sharedObjectsLoaded = fn
+}
+
+// SetLogpointOutput sets a function that receives every message produced
+// by a logpoint as soon as the logpoint is hit. The function is called
+// while the target is stopped. If fn is nil messages are buffered until
+// they are retrieved by GetBufferedLogpointMessages.
+func (t *Target) SetLogpointOutput(fn func(LogpointMessage)) {
+	t.logpointOutput = fn
+}
+
+// SetMaxHitsCallback sets a function that will be called with the logical
+// ID of every breakpoint hit for the MaxHits-th time. The function is called
+// while the target is stopped and is responsible for clearing the
+// breakpoint. If no function is set the breakpoint is cleared with
+// ClearLogicalBreakpoint.
+func (t *Target) SetMaxHitsCallback(fn func(logicalID int)) {
+	t.maxHitsReached = fn
+}
+
+// clearMaxHitsBreakpoint clears the breakpoint thread is stopped at if
+// this hit was the last one allowed by its MaxHits. Breakpoints are cleared
+// as soon as they are hit, even if they don't stop the target, so that
+// logpoints and breakpoints that only create checkpoints don't keep
+// trapping the target.
+func (t *Target) clearMaxHitsBreakpoint(thread Thread) {
+	bpstate := thread.Breakpoint()
+	if bpstate.Breakpoint == nil || !bpstate.MaxHitsReached {
+		return
+	}
+	bpstate.MaxHitsReached = false
+	if t.maxHitsReached != nil {
+		t.maxHitsReached(bpstate.LogicalID)
+		return
+	}
+	_, _ = t.ClearLogicalBreakpoint(bpstate.LogicalID)
+}
+
+// sharedObjectsChanged calls the callback set by
+// SetSharedObjectsLoadedCallback if any of threads stopped on the
+// DynamicLinkerBreakpoint.
+func (t *Target) sharedObjectsChanged(threads []Thread) {
+	changed := false
+	for _, th := range threads {
+		if th.Breakpoint().SharedObjectsChanged {
+			changed = true
+		}
+	}
+	t.setDynamicLinkerBreakpoint()
+	if changed && t.sharedObjectsLoaded != nil {
+		t.sharedObjectsLoaded()
+	}
+}
+
 // CurrentThread returns the currently selected thread which will be used
 // for next/step/stepout and for reading variables, unless a goroutine is
 // selected.
@@ -396,6 +751,13 @@ func (t *Target) SetNextBreakpointID(id int) {
 	t.Breakpoints().breakpointIDCounter = id
 }
 
+// NewBreakpointID reserves a new breakpoint ID, it is used for logical
+// breakpoints that do not have any physical breakpoint yet.
+func (t *Target) NewBreakpointID() int {
+	t.Breakpoints().breakpointIDCounter++
+	return t.Breakpoints().breakpointIDCounter
+}
+
 const (
 	fakeAddressBase     = 0xbeef000000000000
 	fakeAddressUnresolv = 0xbeed000000000000 // this address never resloves to memory
@@ -9,6 +9,9 @@ import (
 	"go/token"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-delve/delve/pkg/astutil"
 	"github.com/go-delve/delve/pkg/dwarf/reader"
@@ -16,6 +19,10 @@ import (
 
 const maxSkipAutogeneratedWrappers = 5 // maximum recursion depth for skipAutogeneratedWrappers
 
+const maxRangeParentDepth = 50 // maximum number of frames between the body of a range-over-func loop and the enclosing function
+
+const recoveryStacktraceDepth = 100 // maximum number of frames between runtime.recovery and the frame recovering a panic
+
 // ErrNoSourceForPC is returned when the given address
 // does not correspond with a source file location.
 type ErrNoSourceForPC struct {
@@ -35,7 +42,7 @@ func (dbp *Target) Next() (err error) {
 		return fmt.Errorf("next while nexting")
 	}
 
-	if err = next(dbp, false, false); err != nil {
+	if err = next(dbp, false, false, 0); err != nil {
 		dbp.ClearSteppingBreakpoints()
 		return
 	}
@@ -54,6 +61,8 @@ func (dbp *Target) Continue() error {
 		thread.Common().CallReturn = false
 		thread.Common().returnValues = nil
 	}
+	dbp.watchOutOfScope = nil
+	dbp.watchMigrations = nil
 	dbp.CheckAndClearManualStopRequest()
 	defer func() {
 		// Make sure we clear internal breakpoints if we simultaneously receive a
@@ -62,6 +71,7 @@ func (dbp *Target) Continue() error {
 			dbp.StopReason = StopManual
 			dbp.ClearSteppingBreakpoints()
 		}
+		dbp.rewatchWatchpoints()
 	}()
 	for {
 		if dbp.CheckAndClearManualStopRequest() {
@@ -70,7 +80,16 @@ func (dbp *Target) Continue() error {
 			return nil
 		}
 		dbp.ClearCaches()
-		trapthread, stopReason, err := dbp.proc.ContinueOnce()
+		var trapthread Thread
+		var stopReason StopReason
+		var err error
+		if dbp.Breakpoints().HasSoftwareWatchpoints() && len(dbp.fncallForG) == 0 {
+			// Software watchpoints are disabled during call injection.
+			trapthread, stopReason, err = continueOnceSoftwareWatch(dbp)
+		} else {
+			dbp.resumeNotify = nil
+			trapthread, stopReason, err = dbp.proc.ContinueOnce()
+		}
 		dbp.StopReason = stopReason
 		if err != nil {
 			// Attempt to refresh status of current thread/current goroutine, see
@@ -95,6 +114,32 @@ func (dbp *Target) Continue() error {
 
 		threads := dbp.ThreadList()
 
+		if dbp.condCallG != 0 {
+			// breakpoints reached while evaluating a breakpoint condition are
+			// ignored, including the ones reached by the injected calls.
+			suppressBreakpoints(threads)
+		}
+
+		for _, th := range threads {
+			if bp := th.Breakpoint().Breakpoint; bp != nil && bp.WatchType != 0 {
+				bp.updateWatchValue(dbp.Memory())
+			}
+			dbp.collectLogpointMessage(th)
+		}
+		dbp.autoCheckpoint(threads)
+		for _, th := range threads {
+			dbp.clearMaxHitsBreakpoint(th)
+		}
+		watchMigratedThread := dbp.rearmWatchpoints(threads)
+		if watchMigratedThread == nil && dbp.StopReason == StopWatchMigrated {
+			// the watchpoint was moved while single stepping a software
+			// watchpoint
+			watchMigratedThread = trapthread
+		}
+		dbp.sharedObjectsChanged(threads)
+		watchOutOfScopeThread := dbp.watchpointsOutOfScope(threads)
+		pinnedThread, pinnedErr := dbp.pinnedGoroutineLeft(threads)
+
 		callInjectionDone, callErr := callInjectionProtocol(dbp, threads)
 		// callErr check delayed until after pickCurrentThread, which must always
 		// happen, otherwise the debugger could be left in an inconsistent
@@ -108,10 +153,40 @@ func (dbp *Target) Continue() error {
 			return callErr
 		}
 
+		if condthread := dbp.evalConditionsWithCalls(threads); condthread != nil {
+			trapthread = condthread
+			threads = dbp.ThreadList()
+			if err := pickCurrentThread(dbp, trapthread, threads); err != nil {
+				return err
+			}
+		}
+
 		curthread := dbp.CurrentThread()
 		curbp := curthread.Breakpoint()
 
 		switch {
+		case pinnedThread != nil && !curbp.Active:
+			if err := dbp.SwitchThread(pinnedThread.ThreadID()); err != nil {
+				return err
+			}
+			if g, _ := FindGoroutine(dbp, dbp.pinned.goid); g != nil {
+				// the thread is running the scheduler, select the goroutine
+				// that left it instead
+				dbp.selectedGoroutine = g
+			}
+			return pinnedErr
+		case watchOutOfScopeThread != nil && !curbp.Active:
+			if err := dbp.SwitchThread(watchOutOfScopeThread.ThreadID()); err != nil {
+				return err
+			}
+			dbp.StopReason = StopWatchOutOfScope
+			return conditionErrors(threads)
+		case watchMigratedThread != nil && !curbp.Active:
+			if err := dbp.SwitchThread(watchMigratedThread.ThreadID()); err != nil {
+				return err
+			}
+			dbp.StopReason = StopWatchMigrated
+			return conditionErrors(threads)
 		case curbp.Breakpoint == nil:
 			// runtime.Breakpoint, manual stop or debugCallV1-related stop
 			recorded, _ := dbp.Recorded()
@@ -182,6 +257,31 @@ func (dbp *Target) Continue() error {
 					return dbp.StepInstruction()
 				}
 			} else {
+				if dbp.GetDirection() == Forward && dbp.BinInfo().isThunk(curbp.Breakpoint.Addr) {
+					if err := conditionErrors(threads); err != nil {
+						return err
+					}
+					// we stopped at the entry point of a thunk reached by a step
+					// into, step through it and resume execution
+					if err := stepThroughThunk(dbp, curthread, sameGoroutineCondition(dbp.SelectedGoroutine())); err != nil {
+						return err
+					}
+					break
+				}
+				if dbp.stepSkipActive && dbp.GetDirection() == Forward && curbp.Breakpoint.returnInfo != nil {
+					if err := conditionErrors(threads); err != nil {
+						return err
+					}
+					// a step returned into a function matched by the step skip
+					// rules, keep stepping out of it
+					skipped, err := stepOutOfSkippedFunction(dbp, curthread)
+					if err != nil {
+						return err
+					}
+					if skipped {
+						break
+					}
+				}
 				curthread.Common().returnValues = curbp.Breakpoint.returnInfo.Collect(dbp, curthread)
 				if err := dbp.ClearSteppingBreakpoints(); err != nil {
 					return err
@@ -204,7 +304,7 @@ func (dbp *Target) Continue() error {
 				dbp.ClearSteppingBreakpoints()
 			}
 			dbp.StopReason = StopBreakpoint
-			if curbp.Breakpoint.WatchType != 0 {
+			if curbp.Breakpoint.WatchType != 0 && !curbp.Breakpoint.WatchType.Execute() {
 				dbp.StopReason = StopWatchpoint
 			}
 			return conditionErrors(threads)
@@ -220,6 +320,386 @@ func (dbp *Target) Continue() error {
 	}
 }
 
+// RequestManualStop attempts to stop all the threads of the target, the
+// stop is reported with StopReason set to StopManual.
+func (dbp *Target) RequestManualStop() error {
+	atomic.AddInt32(&dbp.manualStopRequests, 1)
+	return dbp.Process.RequestManualStop()
+}
+
+// ResumeWithTimeout calls resume, a function that resumes the target
+// like Continue, Next or Step do, and requests a manual stop if resume has
+// not returned after timeout. If the target was stopped because the
+// timeout expired StopReason is set to StopTimeout. If a manual stop was
+// also requested by someone else, for example by the user, StopReason is
+// left set to StopManual.
+// A timeout less than or equal to zero disables the timer.
+func (dbp *Target) ResumeWithTimeout(timeout time.Duration, resume func() error) error {
+	if timeout <= 0 {
+		return resume()
+	}
+	requests := atomic.LoadInt32(&dbp.manualStopRequests)
+	var mu sync.Mutex
+	done, expired := false, false
+	timer := time.AfterFunc(timeout, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if done {
+			return
+		}
+		expired = true
+		dbp.RequestManualStop()
+	})
+	err := resume()
+	timer.Stop()
+	mu.Lock()
+	// after this point the timer can no longer request a stop
+	done = true
+	mu.Unlock()
+	if !expired {
+		return err
+	}
+	if dbp.CheckAndClearManualStopRequest() {
+		// the target stopped for a different reason before the request
+		// was received
+		return err
+	}
+	if err == nil && dbp.StopReason == StopManual && atomic.LoadInt32(&dbp.manualStopRequests) == requests+1 {
+		// the only manual stop requested while the target was running is the
+		// one of the timer
+		dbp.StopReason = StopTimeout
+	}
+	return err
+}
+
+// RewindTo resumes execution backwards until one of the addresses in pcs
+// is reached. Internal breakpoints are used so that the user breakpoint
+// table is left untouched, a user breakpoint hit before reaching pcs will
+// stop execution as usual.
+// If the start of the recording is reached first ErrStartOfRecording is
+// returned.
+func (dbp *Target) RewindTo(pcs []uint64) error {
+	if _, err := dbp.Valid(); err != nil {
+		return err
+	}
+	if recorded, _ := dbp.Recorded(); !recorded {
+		return ErrNotRecorded
+	}
+	if dbp.Breakpoints().HasSteppingBreakpoints() {
+		return fmt.Errorf("rewind while nexting")
+	}
+	if len(pcs) == 0 {
+		return errors.New("no address specified")
+	}
+	for _, pc := range pcs {
+		if _, err := allowDuplicateBreakpoint(dbp.SetBreakpoint(pc, NextBreakpoint, nil)); err != nil {
+			dbp.ClearSteppingBreakpoints()
+			return err
+		}
+	}
+	defer dbp.ClearSteppingBreakpoints()
+	if err := dbp.ChangeDirection(Backward); err != nil {
+		return err
+	}
+	err := dbp.Continue()
+	if _, exited := err.(ErrProcessExited); exited || (err == nil && dbp.StopReason == StopLaunched) {
+		return ErrStartOfRecording
+	}
+	return err
+}
+
+// softwareWatchRefresh is the number of instructions stepped by
+// continueOnceSoftwareWatch before the list of threads to step is computed
+// again.
+const softwareWatchRefresh = 1000
+
+// continueOnceSoftwareWatch resumes the target by single stepping its
+// threads in turn, after each step the memory watched by each software
+// watchpoint is compared with its previous value.
+// Threads that are about to execute a system call, or are blocked in one,
+// are not stepped: single stepping them would only return once the
+// kernel wakes them up, possibly never if they are waiting for one of the
+// stopped threads. When no other thread can be stepped the target is
+// resumed with a breakpoint after each of those system calls, until one of
+// them returns to user space, and the watched memory is compared with its
+// previous value when the target stops again.
+// Detection of read accesses is best-effort, it relies on decoding the
+// memory operands of the instruction being executed and is only available
+// on amd64. Instructions are only decoded while a software read
+// watchpoint exists.
+func continueOnceSoftwareWatch(dbp *Target) (Thread, StopReason, error) {
+	if dbp.resumeNotify != nil {
+		close(dbp.resumeNotify)
+		dbp.resumeNotify = nil
+		dbp.Process.ResumeNotify(nil)
+	}
+
+	selected := dbp.CurrentThread()
+	if g := dbp.SelectedGoroutine(); g != nil && g.Thread != nil {
+		selected = g.Thread
+	}
+
+	var threads []Thread
+	// inUser is true for the threads known to be executing user code, i.e.
+	// the threads that were stepped or returned from a system call since
+	// continueOnceSoftwareWatch was called.
+	inUser := make(map[int]bool)
+	next := 0
+	buf := []byte{}
+	for step := 0; ; step++ {
+		if dbp.CheckAndClearManualStopRequest() {
+			return selected, StopManual, nil
+		}
+		if step%softwareWatchRefresh == 0 {
+			threads = softwareWatchThreads(dbp, selected)
+		}
+
+		// Watchpoints can be moved, resized or cleared while the target is
+		// stepped.
+		var watchpoints []*Breakpoint
+		hasRead := false
+		for _, bp := range dbp.Breakpoints().M {
+			if bp.WatchType.Software() {
+				watchpoints = append(watchpoints, bp)
+				if bp.WatchType.Read() && !bp.WatchSuspended {
+					hasRead = true
+				}
+			}
+		}
+
+		thread := nextSoftwareWatchThread(dbp, threads, &next, inUser)
+		if thread == nil {
+			// The threads created since the list was computed must not run
+			// freely.
+			threads = softwareWatchThreads(dbp, selected)
+			thread = nextSoftwareWatchThread(dbp, threads, &next, inUser)
+		}
+
+		if thread == nil {
+			// Every thread is in a system call.
+			trapthread, stopReason, err := dbp.continueToSyscallReturn(threads, inUser)
+			if err != nil {
+				return trapthread, stopReason, err
+			}
+			dbp.ClearCaches()
+			dbp.collectLogpointMessage(trapthread)
+			dbp.clearMaxHitsBreakpoint(trapthread)
+			if trapthread.Breakpoint().Active {
+				return trapthread, StopBreakpoint, nil
+			}
+			if stopReason, stop := dbp.checkSoftwareWatchpoints(trapthread, watchpoints, 0, 0, &buf); stop {
+				return trapthread, stopReason, nil
+			}
+			continue
+		}
+
+		var readAddr uint64
+		var readSize int
+		if hasRead {
+			if regs, err := thread.Registers(); err == nil {
+				readAddr, readSize = memoryOperand(dbp, thread, regs)
+			}
+		}
+
+		if err := thread.StepInstruction(); err != nil {
+			if _, exited := err.(ErrProcessExited); exited {
+				return nil, StopExited, err
+			}
+			return thread, StopUnknown, err
+		}
+		inUser[thread.ThreadID()] = true
+		dbp.ClearCaches()
+
+		thread.Breakpoint().Clear()
+		if err := thread.SetCurrentBreakpoint(false); err != nil {
+			return thread, StopUnknown, err
+		}
+		dbp.collectLogpointMessage(thread)
+		migrated := dbp.rearmWatchpoints([]Thread{thread})
+		dbp.clearMaxHitsBreakpoint(thread)
+		if thread.Breakpoint().Active {
+			return thread, StopBreakpoint, nil
+		}
+		if len(thread.Breakpoint().WatchOutOfScope) > 0 {
+			return thread, StopWatchOutOfScope, nil
+		}
+		if migrated != nil {
+			return thread, StopWatchMigrated, nil
+		}
+
+		if stopReason, stop := dbp.checkSoftwareWatchpoints(thread, watchpoints, readAddr, readSize, &buf); stop {
+			return thread, stopReason, nil
+		}
+	}
+}
+
+// checkSoftwareWatchpoints compares the memory watched by each software
+// watchpoint with its previous value after thread executed, the read
+// accesses of thread are described by readAddr and readSize. Returns true
+// and the stop reason if the target must be stopped.
+func (dbp *Target) checkSoftwareWatchpoints(thread Thread, watchpoints []*Breakpoint, readAddr uint64, readSize int, buf *[]byte) (StopReason, bool) {
+	for _, bp := range watchpoints {
+		if bp.WatchSuspended {
+			continue
+		}
+		triggered := false
+		if bp.WatchType.Read() && readSize > 0 && readAddr < bp.Addr+uint64(len(bp.watchData)) && bp.Addr < readAddr+uint64(readSize) {
+			triggered = true
+		}
+		if len(*buf) < len(bp.watchData) {
+			*buf = make([]byte, len(bp.watchData))
+		}
+		data := (*buf)[:len(bp.watchData)]
+		changed := false
+		if _, err := dbp.Memory().ReadMemory(data, bp.Addr); err == nil && !bytes.Equal(data, bp.watchData) {
+			changed = true
+			if bp.WatchType.Write() {
+				triggered = true
+			}
+		}
+		if triggered {
+			*thread.Breakpoint() = bp.CheckCondition(thread)
+			dbp.collectLogpointMessage(thread)
+			migrated := dbp.rearmWatchpoints([]Thread{thread})
+			dbp.clearMaxHitsBreakpoint(thread)
+			if thread.Breakpoint().Active {
+				return StopWatchpoint, true
+			}
+			thread.Breakpoint().Clear()
+			if migrated != nil {
+				return StopWatchMigrated, true
+			}
+		}
+		if changed {
+			bp.updateWatchValue(dbp.Memory())
+		}
+	}
+	return StopUnknown, false
+}
+
+// softwareWatchThreads returns the threads stepped by
+// continueOnceSoftwareWatch: selected followed by all the other threads.
+// If the system call instruction of the architecture is not known only the
+// threads running a goroutine that is not in a system call are returned.
+func softwareWatchThreads(dbp *Target, selected Thread) []Thread {
+	syscallKnown := len(dbp.BinInfo().Arch.syscallInstruction) > 0
+	threads := []Thread{selected}
+	for _, th := range dbp.ThreadList() {
+		if th.ThreadID() == selected.ThreadID() {
+			continue
+		}
+		if !syscallKnown {
+			if g, _ := GetG(th); g == nil || g.Status != Grunning {
+				continue
+			}
+		}
+		threads = append(threads, th)
+	}
+	return threads
+}
+
+// nextSoftwareWatchThread returns the first thread of threads, starting
+// at *next, that is not in a system call and advances *next past it.
+// Returns nil if every thread is in a system call.
+func nextSoftwareWatchThread(dbp *Target, threads []Thread, next *int, inUser map[int]bool) Thread {
+	for i := range threads {
+		th := threads[(*next+i)%len(threads)]
+		if _, insyscall := dbp.syscallReturnAddr(th, inUser[th.ThreadID()]); !insyscall {
+			*next = (*next + i + 1) % len(threads)
+			return th
+		}
+	}
+	return nil
+}
+
+// syscallReturnAddr returns true if thread is stopped at a system call
+// instruction or, unless inUser is true, right after one, which is where
+// the threads blocked in the kernel are stopped. The first return value is
+// the address where thread will return to user space.
+// A thread executing user code can also appear to be stopped after a
+// system call instruction if the bytes preceding its PC happen to have
+// the same encoding, in that case it will return immediately when resumed
+// by continueToSyscallReturn.
+func (dbp *Target) syscallReturnAddr(thread Thread, inUser bool) (uint64, bool) {
+	instr := dbp.BinInfo().Arch.syscallInstruction
+	if len(instr) == 0 {
+		return 0, false
+	}
+	regs, err := thread.Registers()
+	if err != nil {
+		return 0, false
+	}
+	pc := regs.PC()
+	n := uint64(len(instr))
+	mem := make([]byte, 2*n)
+	if _, err := dbp.Memory().ReadMemory(mem, pc-n); err != nil {
+		return 0, false
+	}
+	for i, addr := range []uint64{pc - n, pc} {
+		if bp, ok := dbp.Breakpoints().M[addr]; ok {
+			copy(mem[uint64(i)*n:], bp.OriginalData)
+		}
+	}
+	if bytes.Equal(mem[n:], instr) {
+		return pc + n, true
+	}
+	if !inUser && bytes.Equal(mem[:n], instr) {
+		return pc, true
+	}
+	return 0, false
+}
+
+// continueToSyscallReturn resumes the target, all of whose threads are
+// in a system call, until one of them returns to user space. A
+// SyscallReturnBreakpoint is set on the return address of each thread.
+func (dbp *Target) continueToSyscallReturn(threads []Thread, inUser map[int]bool) (Thread, StopReason, error) {
+	for _, th := range threads {
+		addr, _ := dbp.syscallReturnAddr(th, inUser[th.ThreadID()])
+		inUser[th.ThreadID()] = false
+		if addr == 0 {
+			continue
+		}
+		if _, err := dbp.SetBreakpoint(addr, SyscallReturnBreakpoint, nil); err != nil {
+			dbp.clearSyscallReturnBreakpoints()
+			return nil, StopUnknown, err
+		}
+	}
+	trapthread, stopReason, err := dbp.proc.ContinueOnce()
+	if err == nil {
+		// Only the state of trapthread is reliable: threads still blocked
+		// in the kernel are stopped on the return address too.
+		if trapthread.Breakpoint().SyscallReturned {
+			inUser[trapthread.ThreadID()] = true
+		}
+		for _, th := range dbp.ThreadList() {
+			if th.ThreadID() != trapthread.ThreadID() {
+				th.Breakpoint().Clear()
+			}
+		}
+	}
+	dbp.clearSyscallReturnBreakpoints()
+	return trapthread, stopReason, err
+}
+
+// clearSyscallReturnBreakpoints removes the breakpoints set by
+// continueToSyscallReturn.
+func (dbp *Target) clearSyscallReturnBreakpoints() {
+	for _, bp := range dbp.Breakpoints().M {
+		for i := range bp.Breaklets {
+			if bp.Breaklets[i].Kind == SyscallReturnBreakpoint {
+				bp.Breaklets[i] = nil
+			}
+		}
+		if cleared, _ := dbp.finishClearBreakpoint(bp); cleared {
+			for _, thread := range dbp.ThreadList() {
+				if thread.Breakpoint().Breakpoint == bp {
+					thread.Breakpoint().Clear()
+				}
+			}
+		}
+	}
+}
+
 func conditionErrors(threads []Thread) error {
 	var condErr error
 	for _, th := range threads {
@@ -264,6 +744,22 @@ func disassembleCurrentInstruction(p Process, thread Thread, off int64) ([]AsmIn
 	return disassemble(p.Memory(), regs, p.Breakpoints(), p.BinInfo(), pc, pc+uint64(p.BinInfo().Arch.MaxInstructionLength()), true)
 }
 
+// memoryOperand returns the address and size of the memory read by the
+// instruction thread is stopped at. The returned size is 0 if the
+// instruction doesn't access memory or the architecture isn't supported.
+func memoryOperand(p Process, thread Thread, regs Registers) (uint64, int) {
+	text, err := disassembleCurrentInstruction(p, thread, 0)
+	if err != nil || len(text) == 0 {
+		return 0, 0
+	}
+	inst, ok := text[0].Inst.(*x86Inst)
+	if !ok {
+		return 0, 0
+	}
+	bi := p.BinInfo()
+	return inst.memoryOperand(regs.PC(), bi.Arch.RegistersToDwarfRegisters(0, regs), bi)
+}
+
 // stepInstructionOut repeatedly calls StepInstruction until the current
 // function is neither fnname1 or fnname2.
 // This function is used to step out of runtime.Breakpoint as well as
@@ -311,7 +807,7 @@ func (dbp *Target) Step() (err error) {
 		return fmt.Errorf("next while nexting")
 	}
 
-	if err = next(dbp, true, false); err != nil {
+	if err = next(dbp, true, false, 0); err != nil {
 		_ = dbp.ClearSteppingBreakpoints()
 		return err
 	}
@@ -324,6 +820,111 @@ func (dbp *Target) Step() (err error) {
 	return dbp.Continue()
 }
 
+// StepIntoTarget is a call instruction on the current line that can be
+// stepped into with StepInto.
+type StepIntoTarget struct {
+	PC uint64    // address of the call instruction
+	Fn *Function // called function, nil if it is unknown (for example indirect calls)
+}
+
+// StepIntoTargets returns the call instructions, on the current line of
+// the selected goroutine, that have not been executed yet.
+// Calls to functions that Step would not step into are not listed.
+func (dbp *Target) StepIntoTargets() ([]StepIntoTarget, error) {
+	topframe, calls, err := currentLineCalls(dbp)
+	if err != nil {
+		return nil, err
+	}
+
+	stepIntoUnexportedRuntime := strings.HasPrefix(topframe.Current.Fn.Name, "runtime.")
+
+	var r []StepIntoTarget
+	for _, instr := range calls {
+		var fn *Function
+		if instr.DestLoc != nil {
+			fn = instr.DestLoc.Fn
+			if fn != nil && !stepIntoUnexportedRuntime && fn.privateRuntime() {
+				continue
+			}
+		}
+		r = append(r, StepIntoTarget{PC: instr.Loc.PC, Fn: fn})
+	}
+	return r, nil
+}
+
+// currentLineCalls returns the topmost frame of the selected goroutine and
+// the call instructions of the current line that haven't been executed yet.
+func currentLineCalls(dbp *Target) (Stackframe, []AsmInstruction, error) {
+	if _, err := dbp.Valid(); err != nil {
+		return Stackframe{}, nil, err
+	}
+	selg := dbp.SelectedGoroutine()
+	topframe, _, err := topframe(selg, dbp.CurrentThread())
+	if err != nil {
+		return Stackframe{}, nil, err
+	}
+	if topframe.Current.Fn == nil {
+		return Stackframe{}, nil, &ErrNoSourceForPC{topframe.Current.PC}
+	}
+	var regs Registers
+	if selg != nil && selg.Thread != nil {
+		regs, err = selg.Thread.Registers()
+		if err != nil {
+			return Stackframe{}, nil, err
+		}
+	}
+	text, err := disassemble(dbp.Memory(), regs, dbp.Breakpoints(), dbp.BinInfo(), topframe.Current.Fn.Entry, topframe.Current.Fn.End, false)
+	if err != nil {
+		return Stackframe{}, nil, err
+	}
+	var calls []AsmInstruction
+	for _, instr := range text {
+		if instr.Loc.PC < topframe.Current.PC || instr.Loc.File != topframe.Current.File || instr.Loc.Line != topframe.Current.Line || !instr.IsCall() {
+			continue
+		}
+		calls = append(calls, instr)
+	}
+	return topframe, calls, nil
+}
+
+// StepInto works like Step but only steps into the function called by
+// the call instruction at address pc, which must be one of the targets
+// returned by StepIntoTargets. All other calls on the current line are
+// stepped over.
+func (dbp *Target) StepInto(pc uint64) (err error) {
+	if _, err := dbp.Valid(); err != nil {
+		return err
+	}
+	if dbp.Breakpoints().HasSteppingBreakpoints() {
+		return fmt.Errorf("next while nexting")
+	}
+	if dbp.GetDirection() == Backward {
+		return errors.New("can not step into a specific call backwards")
+	}
+
+	targets, err := dbp.StepIntoTargets()
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, target := range targets {
+		if target.PC == pc {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("no call instruction at %#x on the current line", pc)
+	}
+
+	if err = next(dbp, true, false, pc); err != nil {
+		_ = dbp.ClearSteppingBreakpoints()
+		return err
+	}
+
+	return dbp.Continue()
+}
+
 // sameGoroutineCondition returns an expression that evaluates to true when
 // the current goroutine is g.
 func sameGoroutineCondition(g *G) ast.Expr {
@@ -339,7 +940,23 @@ func frameoffCondition(frame *Stackframe) ast.Expr {
 
 // StepOut will continue until the current goroutine exits the
 // function currently being executed or a deferred function is executed
+//
+// If the current function is the body of a range-over-func loop execution
+// continues until the enclosing function is reached again, either at the
+// start of the next iteration of the loop or after the loop, instead of
+// stopping inside the iterator function, see StepOutIterator.
 func (dbp *Target) StepOut() error {
+	return dbp.stepOut(false)
+}
+
+// StepOutIterator is like StepOut but it always continues to the caller of
+// the current function, even if that is the iterator function of a
+// range-over-func loop.
+func (dbp *Target) StepOutIterator() error {
+	return dbp.stepOut(true)
+}
+
+func (dbp *Target) stepOut(iterator bool) error {
 	backward := dbp.GetDirection() == Backward
 	if _, err := dbp.Valid(); err != nil {
 		return err
@@ -363,8 +980,21 @@ func (dbp *Target) StepOut() error {
 		}
 	}()
 
+	sameGCond := sameGoroutineCondition(selg)
+
+	if !backward && !iterator && topframe.Current.Fn != nil && topframe.Current.Fn.rangeParentName() != "" {
+		ok, err := stepOutRangeBody(dbp, selg, curthread, topframe.Current.Fn, sameGCond)
+		if err != nil {
+			return err
+		}
+		if ok {
+			success = true
+			return dbp.Continue()
+		}
+	}
+
 	if topframe.Inlined {
-		if err := next(dbp, false, true); err != nil {
+		if err := next(dbp, false, true, 0); err != nil {
 			return err
 		}
 
@@ -372,8 +1002,6 @@ func (dbp *Target) StepOut() error {
 		return dbp.Continue()
 	}
 
-	sameGCond := sameGoroutineCondition(selg)
-
 	if backward {
 		if err := stepOutReverse(dbp, topframe, retframe, sameGCond); err != nil {
 			return err
@@ -412,6 +1040,58 @@ func (dbp *Target) StepOut() error {
 	return dbp.Continue()
 }
 
+// stepOutRangeBody sets the breakpoints needed to step out of fn, the body
+// of a range-over-func loop, back into the function containing the loop: one
+// on the return address of the call to the iterator function, reached when
+// the loop ends, and one on the body itself, reached at the start of the
+// next iteration.
+// Returns false if the frame of the enclosing function could not be found
+// on the stack.
+func stepOutRangeBody(dbp *Target, g *G, thread Thread, fn *Function, sameGCond ast.Expr) (bool, error) {
+	var frames []Stackframe
+	var err error
+	if g == nil {
+		frames, err = ThreadStacktrace(thread, maxRangeParentDepth)
+	} else {
+		frames, err = g.Stacktrace(maxRangeParentDepth, 0)
+	}
+	if err != nil {
+		return false, err
+	}
+	parentName := fn.rangeParentName()
+	parent := -1
+	for i := 1; i < len(frames); i++ {
+		if frames[i].Current.Fn != nil && frames[i].Current.Fn.Name == parentName {
+			parent = i
+			break
+		}
+	}
+	if parent < 0 || frames[parent-1].Inlined {
+		// the iterator function was inlined into the enclosing function, the
+		// return address of the body is inside the iterator
+		return false, nil
+	}
+
+	parentFrame := &frames[parent]
+	_, err = allowDuplicateBreakpoint(dbp.SetBreakpoint(parentFrame.Current.PC, NextBreakpoint, astutil.And(sameGCond, frameoffCondition(parentFrame))))
+	if err != nil {
+		return false, err
+	}
+
+	pc, err := FirstPCAfterPrologue(dbp, fn, false)
+	if err != nil {
+		return false, err
+	}
+	if _, err := allowDuplicateBreakpoint(dbp.SetBreakpoint(pc, NextBreakpoint, sameGCond)); err != nil {
+		return false, err
+	}
+
+	if bp := thread.Breakpoint(); bp.Breakpoint == nil {
+		thread.SetCurrentBreakpoint(false)
+	}
+	return true, nil
+}
+
 // StepInstruction will continue the current thread for exactly
 // one instruction. This method affects only the thread
 // associated with the selected goroutine. All other
@@ -449,6 +1129,95 @@ func (dbp *Target) StepInstruction() (err error) {
 	return nil
 }
 
+// StepInstructions executes count instructions on the selected goroutine,
+// a count smaller than 1 is treated as 1.
+// If stepOverCalls is true a CALL instruction is executed, together with
+// the function it calls, as if it was a single instruction, by continuing
+// to its return address.
+// Execution stops early if a breakpoint is reached or a manual stop is
+// requested. Returns the number of instructions executed.
+func (dbp *Target) StepInstructions(count int, stepOverCalls bool) (int, error) {
+	if count < 1 {
+		count = 1
+	}
+	if stepOverCalls {
+		if dbp.GetDirection() == Backward {
+			return 0, errors.New("can not step over calls backwards")
+		}
+		if dbp.Breakpoints().HasSteppingBreakpoints() {
+			return 0, fmt.Errorf("next while nexting")
+		}
+	}
+	for i := 0; i < count; i++ {
+		if i > 0 && dbp.CheckAndClearManualStopRequest() {
+			dbp.StopReason = StopManual
+			return i, nil
+		}
+		if stepOverCalls {
+			stepped, err := dbp.stepOverCallInstruction()
+			if err != nil {
+				return i, err
+			}
+			if stepped {
+				if dbp.StopReason != StopNextFinished {
+					// stopped by a breakpoint inside the called function
+					return i, dbp.ClearSteppingBreakpoints()
+				}
+				continue
+			}
+		}
+		if err := dbp.StepInstruction(); err != nil {
+			return i, err
+		}
+		thread := dbp.CurrentThread()
+		if g := dbp.SelectedGoroutine(); g != nil && g.Thread != nil {
+			thread = g.Thread
+		}
+		if thread.Breakpoint().Active {
+			return i + 1, nil
+		}
+	}
+	return count, nil
+}
+
+// stepOverCallInstruction executes the CALL instruction the selected
+// goroutine is stopped at by continuing to its return address.
+// Returns false, without resuming the target, if the current instruction
+// is not a CALL.
+func (dbp *Target) stepOverCallInstruction() (bool, error) {
+	thread := dbp.CurrentThread()
+	g := dbp.SelectedGoroutine()
+	if g != nil {
+		if g.Thread == nil {
+			return false, nil
+		}
+		thread = g.Thread
+	}
+	text, err := disassembleCurrentInstruction(dbp, thread, 0)
+	if err != nil {
+		return false, err
+	}
+	if len(text) == 0 || !text[0].IsCall() {
+		return false, nil
+	}
+	topframe, err := ThreadStacktrace(thread, 0)
+	if err != nil {
+		return false, err
+	}
+	if len(topframe) == 0 {
+		return false, errors.New("empty stack trace")
+	}
+	cond := frameoffCondition(&topframe[0])
+	if g != nil {
+		cond = astutil.And(sameGoroutineCondition(g), cond)
+	}
+	retaddr := text[0].Loc.PC + uint64(text[0].Size)
+	if _, err := dbp.SetBreakpoint(retaddr, NextBreakpoint, cond); err != nil {
+		return false, err
+	}
+	return true, dbp.Continue()
+}
+
 // Set breakpoints at every line, and the return address. Also look for
 // a deferred function and set a breakpoint there too.
 // If stepInto is true it will also set breakpoints inside all
@@ -476,7 +1245,11 @@ func (dbp *Target) StepInstruction() (err error) {
 // for an inlined function call. Everything works the same as normal except
 // when removing instructions belonging to inlined calls we also remove all
 // instructions belonging to the current inlined call.
-func next(dbp *Target, stepInto, inlinedStepOut bool) error {
+//
+// If stepInto is true and stepIntoCall is not zero only the call
+// instruction at address stepIntoCall will be stepped into, all other calls
+// on the current line will be stepped over.
+func next(dbp *Target, stepInto, inlinedStepOut bool, stepIntoCall uint64) error {
 	backward := dbp.GetDirection() == Backward
 	selg := dbp.SelectedGoroutine()
 	curthread := dbp.CurrentThread()
@@ -505,6 +1278,10 @@ func next(dbp *Target, stepInto, inlinedStepOut bool) error {
 		}
 	}()
 
+	// Step skip rules only apply to step, stepping into an explicitly
+	// selected call ignores them.
+	dbp.stepSkipActive = stepInto && !backward && stepIntoCall == 0 && !dbp.stepSkips.Empty()
+
 	ext := filepath.Ext(topframe.Current.File)
 	csource := ext != ".go" && ext != ".s"
 	var regs Registers
@@ -551,7 +1328,7 @@ func next(dbp *Target, stepInto, inlinedStepOut bool) error {
 	}
 
 	if stepInto && !backward {
-		err := setStepIntoBreakpoints(dbp, topframe.Current.Fn, text, topframe, sameGCond)
+		err := setStepIntoBreakpoints(dbp, topframe.Current.Fn, text, topframe, stepIntoCall, sameGCond)
 		if err != nil {
 			return err
 		}
@@ -562,6 +1339,9 @@ func next(dbp *Target, stepInto, inlinedStepOut bool) error {
 		if err != nil {
 			return err
 		}
+		if err := setRecoveryBreakpoint(dbp, topframe, sameGCond); err != nil {
+			return err
+		}
 	}
 
 	// Add breakpoints on all the lines in the current function
@@ -594,6 +1374,13 @@ func next(dbp *Target, stepInto, inlinedStepOut bool) error {
 		if err != nil {
 			return err
 		}
+	} else if dbp.stepSkipActive {
+		// Don't stop inside inlined calls to functions matched by the step
+		// skip rules.
+		pcs, err = removeSkippedInlinedCalls(dbp, pcs, topframe)
+		if err != nil {
+			return err
+		}
 	}
 
 	if !csource {
@@ -621,7 +1408,7 @@ func next(dbp *Target, stepInto, inlinedStepOut bool) error {
 	}
 
 	if stepInto && backward {
-		err := setStepIntoBreakpointsReverse(dbp, text, topframe, sameGCond)
+		err := setStepIntoBreakpointsReverse(dbp, text, topframe, sameFrameCond)
 		if err != nil {
 			return err
 		}
@@ -650,11 +1437,14 @@ func next(dbp *Target, stepInto, inlinedStepOut bool) error {
 	return nil
 }
 
-func setStepIntoBreakpoints(dbp *Target, curfn *Function, text []AsmInstruction, topframe Stackframe, sameGCond ast.Expr) error {
+func setStepIntoBreakpoints(dbp *Target, curfn *Function, text []AsmInstruction, topframe Stackframe, stepIntoCall uint64, sameGCond ast.Expr) error {
 	for _, instr := range text {
 		if instr.Loc.File != topframe.Current.File || instr.Loc.Line != topframe.Current.Line || !instr.IsCall() {
 			continue
 		}
+		if stepIntoCall != 0 && instr.Loc.PC != stepIntoCall {
+			continue
+		}
 
 		if instr.DestLoc != nil {
 			if err := setStepIntoBreakpoint(dbp, curfn, []AsmInstruction{instr}, sameGCond); err != nil {
@@ -670,7 +1460,7 @@ func setStepIntoBreakpoints(dbp *Target, curfn *Function, text []AsmInstruction,
 	return nil
 }
 
-func setStepIntoBreakpointsReverse(dbp *Target, text []AsmInstruction, topframe Stackframe, sameGCond ast.Expr) error {
+func setStepIntoBreakpointsReverse(dbp *Target, text []AsmInstruction, topframe Stackframe, sameFrameCond ast.Expr) error {
 	bpmap := dbp.Breakpoints()
 	// Set a breakpoint after every CALL instruction
 	for i, instr := range text {
@@ -685,7 +1475,7 @@ func setStepIntoBreakpointsReverse(dbp *Target, text []AsmInstruction, topframe
 		if nextIdx := i + 1; nextIdx < len(text) {
 			_, ok := bpmap.M[text[nextIdx].Loc.PC]
 			if !ok {
-				if _, err := allowDuplicateBreakpoint(dbp.SetBreakpoint(text[nextIdx].Loc.PC, StepBreakpoint, sameGCond)); err != nil {
+				if _, err := allowDuplicateBreakpoint(dbp.SetBreakpoint(text[nextIdx].Loc.PC, StepBreakpoint, sameFrameCond)); err != nil {
 					return err
 				}
 			}
@@ -708,6 +1498,27 @@ func FindDeferReturnCalls(text []AsmInstruction) []uint64 {
 	return deferreturns
 }
 
+// removeSkippedInlinedCalls removes from pcs the instructions belonging to
+// inlined calls, in the function containing topframe, of functions matched
+// by the step skip rules.
+func removeSkippedInlinedCalls(dbp *Target, pcs []uint64, topframe Stackframe) ([]uint64, error) {
+	fn := topframe.Call.Fn
+	dwarfTree, err := fn.cu.image.getDwarfTree(fn.offset)
+	if err != nil {
+		return pcs, err
+	}
+	for _, e := range reader.InlineStack(dwarfTree, 0) {
+		name, _ := e.Val(dwarf.AttrName).(string)
+		for _, rng := range e.Ranges {
+			file, _ := fn.cu.lineInfo.PCToLine(fn.Entry, rng[0])
+			if dbp.stepSkips.Match(name, file) {
+				pcs = removePCsBetween(pcs, rng[0], rng[1])
+			}
+		}
+	}
+	return pcs, nil
+}
+
 // Removes instructions belonging to inlined calls of topframe from pcs.
 // If includeCurrentFn is true it will also remove all instructions
 // belonging to the current function.
@@ -762,18 +1573,23 @@ func setStepIntoBreakpoint(dbp *Target, curfn *Function, text []AsmInstruction,
 		return nil
 	}
 
-	//TODO(aarzilli): if we want to let users hide functions
-	// or entire packages from being stepped into with 'step'
-	// those extra checks should be done here.
-
 	pc := instr.DestLoc.PC
 
-	// Skip InhibitStepInto functions for different arch.
-	if dbp.BinInfo().Arch.inhibitStepInto(dbp.BinInfo(), pc) {
+	fn, pc = skipAutogeneratedWrappersIn(dbp, fn, pc)
+
+	// Step over functions matched by the step skip rules, the breakpoints
+	// set by next will stop execution once they return.
+	if dbp.stepSkipActive && dbp.stepSkips.matchFunction(fn) {
 		return nil
 	}
 
-	fn, pc = skipAutogeneratedWrappersIn(dbp, fn, pc)
+	if dbp.BinInfo().isThunk(pc) {
+		// The destination is a PLT stub, a veneer or a trampoline, its target
+		// can only be determined by executing it: stop at its entry point and
+		// let Continue step through it (see stepThroughThunk).
+		_, err := allowDuplicateBreakpoint(dbp.SetBreakpoint(pc, NextBreakpoint, cond))
+		return err
+	}
 
 	// We want to skip the function prologue but we should only do it if the
 	// destination address of the CALL instruction is the entry point of the
@@ -793,6 +1609,78 @@ func setStepIntoBreakpoint(dbp *Target, curfn *Function, text []AsmInstruction,
 	return nil
 }
 
+// stepOutOfSkippedFunction is called when a step returns into a function
+// matched by the step skip rules, it replaces the stepping breakpoints with
+// a breakpoint on the return address of the current frame.
+// Returns false if the current function is not matched by the step skip
+// rules.
+func stepOutOfSkippedFunction(dbp *Target, curthread Thread) (bool, error) {
+	selg := dbp.SelectedGoroutine()
+	topframe, retframe, err := topframe(selg, curthread)
+	if err != nil {
+		return false, err
+	}
+	if topframe.Inlined || retframe.Current.Fn == nil || !dbp.stepSkips.matchFunction(topframe.Current.Fn) {
+		return false, nil
+	}
+	if err := dbp.ClearSteppingBreakpoints(); err != nil {
+		return false, err
+	}
+	dbp.stepSkipActive = true
+	retFrameCond := astutil.And(sameGoroutineCondition(selg), frameoffCondition(&retframe))
+	bp, err := allowDuplicateBreakpoint(dbp.SetBreakpoint(retframe.Current.PC, NextBreakpoint, retFrameCond))
+	if err != nil {
+		return false, err
+	}
+	configureReturnBreakpoint(dbp.BinInfo(), bp, &topframe, retFrameCond)
+	return true, nil
+}
+
+// maxThunkSteps is the maximum number of instructions stepThroughThunk
+// will execute before giving up.
+const maxThunkSteps = 64
+
+// stepThroughThunk single steps curthread until it leaves the thunk it is
+// stopped in, then, if it landed on the entry point of a function, sets a
+// breakpoint after its prologue, like setStepIntoBreakpoint would.
+// If the thunk returned to its caller or jumped into a function without
+// debug symbols nothing is done, the breakpoints set by next will stop
+// execution once the called function returns.
+func stepThroughThunk(dbp *Target, curthread Thread, cond ast.Expr) error {
+	defer dbp.ClearCaches()
+	bi := dbp.BinInfo()
+	var pc uint64
+	for count := 0; count < maxThunkSteps; count++ {
+		if err := curthread.StepInstruction(); err != nil {
+			return err
+		}
+		regs, err := curthread.Registers()
+		if err != nil {
+			return err
+		}
+		pc = regs.PC()
+		if !bi.isThunk(pc) {
+			break
+		}
+	}
+	if err := curthread.SetCurrentBreakpoint(true); err != nil {
+		return err
+	}
+	fn := bi.PCToFunc(pc)
+	if fn == nil || fn.Entry != pc || fn.privateRuntime() || bi.isThunk(pc) {
+		return nil
+	}
+	fn, pc = skipAutogeneratedWrappersIn(dbp, fn, pc)
+	if dbp.stepSkipActive && dbp.stepSkips.matchFunction(fn) {
+		return nil
+	}
+	if fn.Entry == pc {
+		pc, _ = FirstPCAfterPrologue(dbp, fn, false)
+	}
+	_, err := allowDuplicateBreakpoint(dbp.SetBreakpoint(pc, NextBreakpoint, cond))
+	return err
+}
+
 func allowDuplicateBreakpoint(bp *Breakpoint, err error) (*Breakpoint, error) {
 	if err != nil {
 		if _, isexists := err.(BreakpointExistsError); isexists {
@@ -908,8 +1796,9 @@ func skipAutogeneratedWrappersOut(g *G, thread Thread, startTopframe, startRetfr
 func setDeferBreakpoint(p *Target, text []AsmInstruction, topframe Stackframe, sameGCond ast.Expr, stepInto bool) (uint64, error) {
 	// Set breakpoint on the most recently deferred function (if any)
 	var deferpc uint64
+	var deferfn *Function
 	if topframe.TopmostDefer != nil && topframe.TopmostDefer.DwrapPC != 0 {
-		_, _, deferfn := topframe.TopmostDefer.DeferredFunc(p)
+		_, _, deferfn = topframe.TopmostDefer.DeferredFunc(p)
 		var err error
 		deferpc, err = FirstPCAfterPrologue(p, deferfn, false)
 		if err != nil {
@@ -921,10 +1810,11 @@ func setDeferBreakpoint(p *Target, text []AsmInstruction, topframe Stackframe, s
 		if err != nil {
 			return 0, err
 		}
-		if bp != nil && stepInto {
+		if bp != nil && stepInto && !(p.stepSkipActive && p.stepSkips.matchFunction(deferfn)) {
 			// If DeferReturns is set then the breakpoint will also be triggered when
 			// called from runtime.deferreturn. We only do this for the step command,
-			// not for next or stepout.
+			// not for next or stepout, and only if the deferred function isn't
+			// matched by the step skip rules.
 			for _, breaklet := range bp.Breaklets {
 				if breaklet.Kind == NextDeferBreakpoint {
 					breaklet.DeferReturns = FindDeferReturnCalls(text)
@@ -937,6 +1827,86 @@ func setDeferBreakpoint(p *Target, text []AsmInstruction, topframe Stackframe, s
 	return deferpc, nil
 }
 
+// setRecoveryBreakpoint is a helper function used by next to set a
+// breakpoint on runtime.recovery. If a panic started while stepping unwinds
+// past topframe and is recovered by a deferred function of one of its
+// callers, the breakpoint sets next breakpoints in the frame where the
+// goroutine resumes normal execution, so that the step stops there instead
+// of continuing until the next user breakpoint.
+// A panic that nobody recovers reaches the unrecovered panic breakpoint or
+// terminates the target.
+func setRecoveryBreakpoint(p *Target, topframe Stackframe, sameGCond ast.Expr) error {
+	fn := p.BinInfo().LookupFunc["runtime.recovery"]
+	if fn == nil || sameGCond == nil {
+		return nil
+	}
+	bp, err := p.SetBreakpoint(fn.Entry, NextBreakpoint, sameGCond)
+	if err != nil {
+		return err
+	}
+	frameoff := topframe.FrameOffset()
+	bp.Breaklets[len(bp.Breaklets)-1].callback = func(th Thread) (bool, error) {
+		// runtime.recovery is never a place to stop at, execution always
+		// continues into the recovering frame.
+		return false, setRecoveringFrameBreakpoints(p, th, frameoff, sameGCond)
+	}
+	return nil
+}
+
+// setRecoveringFrameBreakpoints sets next breakpoints on the frame where
+// the goroutine running on th, which is stopped on runtime.recovery, is
+// going to resume execution, if it is a caller of the frame at frameoff.
+func setRecoveringFrameBreakpoints(p *Target, th Thread, frameoff int64, sameGCond ast.Expr) error {
+	g, err := GetG(th)
+	if err != nil || g == nil {
+		return err
+	}
+	pc, sp, err := g.recoveryTarget()
+	if err != nil {
+		return err
+	}
+	frames, err := g.Stacktrace(recoveryStacktraceDepth, StacktraceG)
+	if err != nil {
+		return err
+	}
+	for i := range frames {
+		frame := &frames[i]
+		if frame.Inlined || frame.Current.Fn == nil || pc < frame.Current.Fn.Entry || pc >= frame.Current.Fn.End || uint64(frame.Regs.SP()) != sp {
+			continue
+		}
+		if frame.FrameOffset() <= frameoff {
+			// recovered by the frame being stepped, or by one of its callees,
+			// the breakpoints set by next already cover this
+			return nil
+		}
+		fn := frame.Current.Fn
+		frameCond := astutil.And(sameGCond, frameoffCondition(frame))
+		pcs, err := fn.cu.lineInfo.AllPCsBetween(fn.Entry, fn.End-1, "", 0)
+		if err != nil {
+			return err
+		}
+		pcs, err = removeInlinedCalls(append(pcs, pc), *frame)
+		if err != nil {
+			return err
+		}
+		for _, pc := range pcs {
+			if _, err := allowDuplicateBreakpoint(p.SetBreakpoint(pc, NextBreakpoint, frameCond)); err != nil {
+				return err
+			}
+		}
+		if i+1 < len(frames) && frames[i+1].Current.Fn != nil {
+			retframe := &frames[i+1]
+			retFrameCond := astutil.And(sameGCond, frameoffCondition(retframe))
+			bp, _ := allowDuplicateBreakpoint(p.SetBreakpoint(retframe.Current.PC, NextBreakpoint, retFrameCond))
+			if bp != nil {
+				configureReturnBreakpoint(p.BinInfo(), bp, frame, retFrameCond)
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
 // findCallInstrForRet returns the PC address of the CALL instruction
 // immediately preceding the instruction at ret.
 func findCallInstrForRet(p Process, mem MemoryReadWriter, ret uint64, fn *Function) (uint64, error) {
@@ -987,6 +1957,10 @@ func stepOutReverse(p *Target, topframe, retframe Stackframe, sameGCond ast.Expr
 	}
 
 	var callpc uint64
+	// callerFrame is the frame containing callpc, checking its frame offset
+	// in the breakpoint condition prevents stopping on the same CALL
+	// instruction of a more recent invocation of a recursive function.
+	callerFrame := &retframe
 
 	if ok, panicFrame := isPanicCall(frames); ok {
 		if len(frames) < panicFrame+2 || frames[panicFrame+1].Current.Fn == nil {
@@ -1000,8 +1974,12 @@ func stepOutReverse(p *Target, topframe, retframe Stackframe, sameGCond ast.Expr
 		if err != nil {
 			return err
 		}
+		callerFrame = &frames[panicFrame+1]
 	} else if ok, pc := isDeferReturnCall(frames, deferReturns); ok {
 		callpc = pc
+		if len(frames) >= 2 {
+			callerFrame = &frames[1]
+		}
 	} else {
 		callpc, err = findCallInstrForRet(p, p.Memory(), topframe.Ret, retframe.Current.Fn)
 		if err != nil {
@@ -1009,7 +1987,12 @@ func stepOutReverse(p *Target, topframe, retframe Stackframe, sameGCond ast.Expr
 		}
 	}
 
-	_, err = allowDuplicateBreakpoint(p.SetBreakpoint(callpc, NextBreakpoint, sameGCond))
+	var callerFrameCond ast.Expr
+	if sameGCond != nil {
+		callerFrameCond = astutil.And(sameGCond, frameoffCondition(callerFrame))
+	}
+
+	_, err = allowDuplicateBreakpoint(p.SetBreakpoint(callpc, NextBreakpoint, callerFrameCond))
 
 	return err
 }
@@ -1051,7 +2034,7 @@ type onNextGoroutineWalker struct {
 
 func (w *onNextGoroutineWalker) Visit(n ast.Node) ast.Visitor {
 	if binx, isbin := n.(*ast.BinaryExpr); isbin && binx.Op == token.EQL && exprToString(binx.X) == "runtime.curg.goid" {
-		w.ret, w.err = evalBreakpointCondition(w.thread, n.(ast.Expr))
+		w.ret, w.err = evalBreakpointCondition(w.thread, n.(ast.Expr), nil)
 		return nil
 	}
 	return w
@@ -422,7 +422,7 @@ func nameOfInterfaceRuntimeType(mds []moduleData, _type *Variable, kind, tflag i
 	buf.WriteString("interface {")
 
 	methods, _ := _type.structMember(interfacetypeFieldMhdr)
-	methods.loadArrayValues(0, LoadConfig{false, 1, 0, 4096, -1, 0})
+	methods.loadArrayValues(0, LoadConfig{MaxVariableRecurse: 1, MaxArrayValues: 4096, MaxStructFields: -1})
 	if methods.Unreadable != nil {
 		return "", nil
 	}
@@ -483,7 +483,7 @@ func nameOfStructRuntimeType(mds []moduleData, _type *Variable, kind, tflag int6
 	buf.WriteString("struct {")
 
 	fields, _ := _type.structMember("fields")
-	fields.loadArrayValues(0, LoadConfig{false, 2, 0, 4096, -1, 0})
+	fields.loadArrayValues(0, LoadConfig{MaxVariableRecurse: 2, MaxArrayValues: 4096, MaxStructFields: -1})
 	if fields.Unreadable != nil {
 		return "", fields.Unreadable
 	}
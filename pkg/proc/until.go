@@ -0,0 +1,401 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"regexp"
+
+	"github.com/go-delve/delve/pkg/astutil"
+)
+
+// ErrUntilOutOfScope is returned by ContinueUntil when the frame the
+// condition was evaluated in returns before the condition becomes true.
+var ErrUntilOutOfScope = errors.New("the frame of the condition returned before the condition became true")
+
+// maxUntilDepth is the maximum depth of the frame a condition passed to
+// ContinueUntil is evaluated in.
+const maxUntilDepth = 1000
+
+// untilCondition is the condition of a ContinueUntil operation, it is
+// evaluated in the frame it was created in, identified by its goroutine
+// and frame offset, or as if it appeared at the top level of pkg if it
+// doesn't reference any local variable.
+type untilCondition struct {
+	expr        ast.Expr
+	local       bool
+	goid        int
+	frameOffset int64
+	pkg         string
+	regexps     map[string]*regexp.Regexp
+
+	// satisfied is set when the condition is found to be true.
+	satisfied bool
+}
+
+// ContinueUntil resumes execution of the target until the boolean
+// expression expr, evaluated in scope, becomes true.
+//
+// If expr only reads memory that can be watched, for example local and
+// package variables and their fields, a temporary watchpoint is set on
+// every memory region read and expr is evaluated again every time one of
+// them is triggered. If expr reads local variables execution also stops
+// when the frame of scope returns, in which case ErrUntilOutOfScope is
+// returned.
+//
+// Otherwise, for example when expr calls a builtin or dereferences a
+// pointer, the goroutine of scope is single stepped, while all other
+// threads are stopped, and expr is evaluated every time a new source line
+// is reached. This is several orders of magnitude slower than executing
+// the target normally and can not terminate if the goroutine blocks
+// waiting for another goroutine; it can be interrupted with
+// RequestManualStop.
+//
+// Breakpoints reached before expr becomes true stop execution as usual.
+// All temporary breakpoints are cleared when ContinueUntil returns.
+func (dbp *Target) ContinueUntil(scope *EvalScope, expr string) error {
+	if _, err := dbp.Valid(); err != nil {
+		return err
+	}
+	if dbp.Breakpoints().HasSteppingBreakpoints() {
+		return fmt.Errorf("next while nexting")
+	}
+	if dbp.GetDirection() == Backward {
+		return errors.New("can not continue until a condition is true backwards")
+	}
+
+	n, err := parser.ParseExpr(expr)
+	if err != nil {
+		return err
+	}
+	uc := &untilCondition{expr: n, goid: -1, frameOffset: scope.frameOffset, pkg: scope.pkg, regexps: make(map[string]*regexp.Regexp)}
+	if scope.g != nil {
+		uc.goid = scope.g.ID
+	}
+	if scope.Fn != nil {
+		uc.pkg = scope.Fn.PackageName()
+		uc.local = referencesLocals(scope, n)
+	}
+	if uc.local && scope.g == nil {
+		return errors.New("can not evaluate a condition on local variables without a goroutine")
+	}
+	scope.regexps = uc.regexps
+	v, err := scope.evalCondition(n)
+	if err != nil {
+		return err
+	}
+	if v {
+		return fmt.Errorf("%s is already true", expr)
+	}
+
+	defer dbp.ClearSteppingBreakpoints()
+
+	regions, watchable := untilWatchRegions(scope, n)
+	if !watchable {
+		return continueUntilSingleStep(dbp, uc)
+	}
+
+	for _, rgn := range regions {
+		bp, err := dbp.setBreakpointInternal(rgn[0], NextBreakpoint, WatchWrite.withSize(uint8(rgn[1])), nil)
+		if err != nil {
+			return err
+		}
+		bp.Breaklets[len(bp.Breaklets)-1].callback = uc.check(dbp)
+	}
+	if uc.local {
+		if err := setUntilFrameReturnBreakpoint(dbp, scope); err != nil {
+			return err
+		}
+	}
+
+	if err := dbp.Continue(); err != nil {
+		return err
+	}
+	if dbp.StopReason == StopNextFinished && !uc.satisfied {
+		return ErrUntilOutOfScope
+	}
+	return nil
+}
+
+// check returns the callback used by the watchpoints set by ContinueUntil.
+func (uc *untilCondition) check(t *Target) func(Thread) (bool, error) {
+	return func(Thread) (bool, error) {
+		v, err := uc.eval(t)
+		if err != nil {
+			return true, err
+		}
+		uc.satisfied = v
+		return v, nil
+	}
+}
+
+// eval evaluates the condition.
+func (uc *untilCondition) eval(t *Target) (bool, error) {
+	var scope *EvalScope
+	if uc.local {
+		g, err := FindGoroutine(t, uc.goid)
+		if err != nil {
+			return false, err
+		}
+		if g == nil {
+			return false, ErrUntilOutOfScope
+		}
+		frames, err := g.Stacktrace(maxUntilDepth, 0)
+		if err != nil {
+			return false, err
+		}
+		for i := range frames {
+			if frames[i].FrameOffset() == uc.frameOffset {
+				scope = FrameToScope(t, t.BinInfo(), t.Memory(), g, frames[i:]...)
+				break
+			}
+		}
+		if scope == nil {
+			return false, ErrUntilOutOfScope
+		}
+	} else {
+		scope = PackageScope(t, uc.pkg)
+	}
+	scope.regexps = uc.regexps
+	return scope.evalCondition(uc.expr)
+}
+
+// setUntilFrameReturnBreakpoint sets a breakpoint on the return address
+// of the frame of scope.
+func setUntilFrameReturnBreakpoint(dbp *Target, scope *EvalScope) error {
+	frames, err := scope.g.Stacktrace(maxUntilDepth, 0)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(frames)-1; i++ {
+		if frames[i].FrameOffset() != scope.frameOffset {
+			continue
+		}
+		retframe := &frames[i+1]
+		cond := astutil.And(sameGoroutineCondition(scope.g), frameoffCondition(retframe))
+		_, err := allowDuplicateBreakpoint(dbp.SetBreakpoint(retframe.Current.PC, NextBreakpoint, cond))
+		return err
+	}
+	return nil
+}
+
+// continueUntilSingleStep single steps the thread running the goroutine
+// of uc until uc becomes true, evaluating it every time a new source line
+// is reached.
+func continueUntilSingleStep(dbp *Target, uc *untilCondition) error {
+	thread := dbp.CurrentThread()
+	if g := dbp.SelectedGoroutine(); g != nil && uc.goid >= 0 && g.ID != uc.goid {
+		g, err := FindGoroutine(dbp, uc.goid)
+		if err != nil {
+			return err
+		}
+		if g == nil || g.Thread == nil {
+			return fmt.Errorf("goroutine %d is not running on a thread", uc.goid)
+		}
+		thread = g.Thread
+	} else if g != nil && g.Thread != nil {
+		thread = g.Thread
+	}
+	if err := dbp.SwitchThread(thread.ThreadID()); err != nil {
+		return err
+	}
+
+	if dbp.resumeNotify != nil {
+		close(dbp.resumeNotify)
+		dbp.resumeNotify = nil
+		dbp.Process.ResumeNotify(nil)
+	}
+
+	bi := dbp.BinInfo()
+	var lastFile string
+	var lastLine int
+	for {
+		if dbp.CheckAndClearManualStopRequest() {
+			dbp.StopReason = StopManual
+			return nil
+		}
+		if err := thread.StepInstruction(); err != nil {
+			if pe, exited := err.(ErrProcessExited); exited {
+				dbp.exitStatus = pe.Status
+			}
+			return err
+		}
+		dbp.ClearCaches()
+		thread.Breakpoint().Clear()
+		if err := thread.SetCurrentBreakpoint(false); err != nil {
+			return err
+		}
+		dbp.collectLogpointMessage(thread)
+		dbp.clearMaxHitsBreakpoint(thread)
+		if bpstate := thread.Breakpoint(); bpstate.Active {
+			dbp.StopReason = StopBreakpoint
+			if bpstate.Breakpoint.WatchType != 0 && !bpstate.Breakpoint.WatchType.Execute() {
+				dbp.StopReason = StopWatchpoint
+			}
+			return conditionErrors([]Thread{thread})
+		}
+
+		regs, err := thread.Registers()
+		if err != nil {
+			return err
+		}
+		file, line, fn := bi.PCToLine(regs.PC())
+		if fn == nil || (file == lastFile && line == lastLine) {
+			continue
+		}
+		lastFile, lastLine = file, line
+		v, err := uc.eval(dbp)
+		if err == ErrUntilOutOfScope {
+			dbp.StopReason = StopNextFinished
+			return err
+		}
+		if err != nil {
+			// the condition can not be evaluated in the middle of the prologue
+			// of a function, or while the goroutine is being switched
+			continue
+		}
+		if v {
+			dbp.StopReason = StopNextFinished
+			dbp.selectedGoroutine, _ = GetG(thread)
+			return nil
+		}
+	}
+}
+
+// referencesLocals returns true if expr references a local variable, or a
+// CPU register, of scope.
+func referencesLocals(scope *EvalScope, expr ast.Expr) bool {
+	locals := map[string]bool{}
+	if vars, err := scope.Locals(); err == nil {
+		for _, v := range vars {
+			if v.Flags&VariableShadowed == 0 {
+				locals[v.Name] = true
+			}
+		}
+	}
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			// only the leftmost identifier of a selector can be a local variable
+			ast.Inspect(n.X, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok && (locals[id.Name] || validRegisterName(id.Name) != "") {
+					found = true
+				}
+				return !found
+			})
+			return false
+		case *ast.Ident:
+			if locals[n.Name] || validRegisterName(n.Name) != "" {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// untilWatchRegions returns the memory regions that have to be watched to
+// detect a change in the value of expr, as address, size pairs, suitable
+// for hardware watchpoints. Returns false if the value of expr can change
+// without writing any memory region that can be watched, for example
+// because it dereferences a pointer or calls a function.
+func untilWatchRegions(scope *EvalScope, expr ast.Expr) ([][2]uint64, bool) {
+	ptrSize := int64(scope.BinInfo.Arch.PtrSize())
+	var regions [][2]uint64
+	var visit func(n ast.Expr) bool
+	visit = func(n ast.Expr) bool {
+		switch n := n.(type) {
+		case *ast.ParenExpr:
+			return visit(n.X)
+		case *ast.UnaryExpr:
+			switch n.Op {
+			case token.NOT, token.SUB, token.ADD, token.XOR:
+				return visit(n.X)
+			}
+			return false
+		case *ast.BinaryExpr:
+			return visit(n.X) && visit(n.Y)
+		case *ast.BasicLit:
+			return true
+		case *ast.Ident, *ast.SelectorExpr, *ast.IndexExpr:
+			if !directAccess(scope, n) {
+				return false
+			}
+			v, err := scope.evalAST(n)
+			if err != nil {
+				return false
+			}
+			if v.Addr == 0 && v.Value != nil {
+				// constant
+				return true
+			}
+			if v.Addr == 0 || v.Flags&(VariableFakeAddress|VariableCPURegister) != 0 || v.DwarfType == nil {
+				return false
+			}
+			var sz int64
+			switch v.Kind {
+			case reflect.Slice, reflect.Interface, reflect.Func:
+				// the value can change without changing the memory of the variable
+				return false
+			case reflect.String:
+				// strings are immutable, watching the string header is enough
+				sz = 2 * ptrSize
+			default:
+				sz = v.DwarfType.Size()
+			}
+			if sz <= 0 {
+				return false
+			}
+			if sz <= ptrSize && v.Addr%uint64(sz) == 0 && sz&(sz-1) == 0 {
+				regions = append(regions, [2]uint64{v.Addr, uint64(sz)})
+				return true
+			}
+			for _, c := range splitWatchRegion(v.Addr, sz, ptrSize) {
+				regions = append(regions, [2]uint64{v.Addr + uint64(c.off), uint64(c.size)})
+			}
+			return true
+		default:
+			return false
+		}
+	}
+	if !visit(expr) {
+		return nil, false
+	}
+	return regions, true
+}
+
+// directAccess returns true if evaluating n doesn't dereference any
+// pointer, i.e. if the value of n is stored inside the memory of the
+// variable named by its leftmost identifier.
+func directAccess(scope *EvalScope, n ast.Expr) bool {
+	switch n := n.(type) {
+	case *ast.Ident:
+		return true
+	case *ast.SelectorExpr:
+		if id, ok := n.X.(*ast.Ident); ok {
+			if _, err := scope.evalAST(id); err != nil {
+				// package qualified variable
+				return true
+			}
+		}
+		x, err := scope.evalAST(n.X)
+		if err != nil || x.Kind != reflect.Struct {
+			return false
+		}
+		return directAccess(scope, n.X)
+	case *ast.IndexExpr:
+		if _, ok := n.Index.(*ast.BasicLit); !ok {
+			return false
+		}
+		x, err := scope.evalAST(n.X)
+		if err != nil || x.Kind != reflect.Array {
+			return false
+		}
+		return directAccess(scope, n.X)
+	}
+	return false
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"go/constant"
+	"go/parser"
 	"go/token"
 	"math"
 	"reflect"
@@ -117,6 +118,10 @@ type Variable struct {
 
 	// number of elements to skip when loading a map
 	mapSkip int
+	// mapFilter, if not nil, selects the elements loaded from a map
+	mapFilter *mapFilter
+	// mapResume, if not nil, is the position loading a map starts from
+	mapResume *mapPosition
 
 	Children []Variable
 
@@ -125,6 +130,27 @@ type Variable struct {
 
 	LocationExpr *locationExpr // location expression
 	DeclLine     int64         // line number of this variable's declaration
+
+	// Formatted is the value rendered by the formatter of its type, or
+	// returned by its Error or String method, see EvalScope.ApplyFormatters.
+	// Some types of the standard library, like time.Time, are rendered when
+	// they are loaded, see builtinFormatter.
+	Formatted string
+
+	// MapFilter is set for maps loaded through a filter, such as
+	// m[@key == "a"].
+	MapFilter *MapFilterResult
+
+	// MapContinuation is set for maps when loading stopped because
+	// LoadConfig.MaxArrayValues entries were loaded, passing it in
+	// LoadConfig.MapContinuation loads the following entries.
+	MapContinuation string
+
+	// Symbol is the name of the function containing the code address
+	// stored in the variable, followed by the offset from its entry point
+	// if it isn't the entry point. Only set when the variable was loaded
+	// with LoadConfig.SymbolizePointers.
+	Symbol string
 }
 
 // LoadConfig controls how variables are loaded from the targets memory.
@@ -168,11 +194,33 @@ type LoadConfig struct {
 	// sparse map is in scope, but evaluating a single variable will still work
 	// correctly, even if the variable in question is a very sparse map.
 	MaxMapBuckets int
-}
 
-var loadSingleValue = LoadConfig{false, 0, 64, 0, 0, 0}
-var loadFullValue = LoadConfig{true, 1, 64, 64, -1, 0}
-var loadFullValueLongerStrings = LoadConfig{true, 1, 1024 * 1024, 64, -1, 0}
+	// CallStringMethods requests the Error or String method of the loaded
+	// values that implement error or fmt.Stringer to be called, storing the
+	// result in Variable.Formatted, see EvalScope.ApplyFormatters.
+	CallStringMethods bool
+
+	// Start is the index of the first element loaded from the array or
+	// slice returned by EvalExpression, or the number of entries skipped
+	// when it returns a map. The elements of arrays and slices preceding it
+	// are not read.
+	Start int
+	// MapContinuation, if not empty, is the token returned in
+	// Variable.MapContinuation by a previous evaluation of the same map:
+	// the entries of the map returned by EvalExpression are loaded starting
+	// after the last entry loaded by the previous evaluation, without
+	// reading the preceding ones.
+	MapContinuation string
+
+	// SymbolizePointers requests function values, and uintptr and
+	// unsafe.Pointer values pointing into the code of a known function, to
+	// be resolved to the function, storing its name in Variable.Symbol.
+	SymbolizePointers bool
+}
+
+var loadSingleValue = LoadConfig{MaxStringLen: 64}
+var loadFullValue = LoadConfig{FollowPointers: true, MaxVariableRecurse: 1, MaxStringLen: 64, MaxArrayValues: 64, MaxStructFields: -1}
+var loadFullValueLongerStrings = LoadConfig{FollowPointers: true, MaxVariableRecurse: 1, MaxStringLen: 1024 * 1024, MaxArrayValues: 64, MaxStructFields: -1}
 
 // G status, from: src/runtime/runtime2.go
 const (
@@ -200,6 +248,8 @@ type G struct {
 	Status  uint64
 	stack   stack // value of stack
 
+	parentID int // ID of the goroutine that created this goroutine (go >= 1.21), 0 if unknown
+
 	WaitSince  int64
 	WaitReason int64
 
@@ -481,6 +531,42 @@ func (g *G) Defer() *Defer {
 	return d
 }
 
+// recoveryTarget returns the PC and SP where g resumes execution after
+// runtime.recovery unwinds its stack to the frame that recovered the
+// current panic.
+func (g *G) recoveryTarget() (pc, sp uint64, err error) {
+	if g.variable.Unreadable != nil {
+		return 0, 0, g.variable.Unreadable
+	}
+	readField := func(v *Variable, name string) (uint64, bool) {
+		fv, err := v.structMember(name)
+		if err != nil {
+			return 0, false
+		}
+		n, err := readUintRaw(fv.mem, fv.Addr, int64(g.variable.bi.Arch.PtrSize()))
+		return n, err == nil
+	}
+	// Since Go 1.22 the target of recovery is stored in the panic.
+	if pvar, _ := g.variable.structMember("_panic"); pvar != nil {
+		pvar = pvar.maybeDereference()
+		if pvar.Addr != 0 {
+			pc, okpc := readField(pvar, "retpc")
+			sp, oksp := readField(pvar, "sp")
+			if okpc && oksp {
+				return pc, sp, nil
+			}
+		}
+	}
+	// Before Go 1.22 runtime.gopanic passes it to runtime.recovery through
+	// g.sigcode0 and g.sigcode1.
+	sp, oksp := readField(g.variable, "sigcode0")
+	pc, okpc := readField(g.variable, "sigcode1")
+	if !okpc || !oksp {
+		return 0, 0, errors.New("could not read the recovery target of the current panic")
+	}
+	return pc, sp, nil
+}
+
 // UserCurrent returns the location the users code is at,
 // or was at before entering a runtime function.
 func (g *G) UserCurrent() Location {
@@ -893,6 +979,10 @@ func (v *Variable) parseG() (*G, error) {
 	}
 
 	status := loadInt64Maybe("atomicstatus")
+	var parentID int64
+	if parentVar := v.loadFieldNamed("parentGoid"); parentVar != nil {
+		parentID, _ = constant.Int64Val(parentVar.Value)
+	}
 
 	if unreadable {
 		return nil, ErrUnreadableG
@@ -916,6 +1006,7 @@ func (v *Variable) parseG() (*G, error) {
 		CurrentLoc: Location{PC: uint64(pc), File: f, Line: l, Fn: fn},
 		variable:   v,
 		stack:      stack{hi: stackhi, lo: stacklo},
+		parentID:   int(parentID),
 	}
 	return g, nil
 }
@@ -1049,6 +1140,19 @@ func (v *Variable) structMember(memberName string) (*Variable, error) {
 	}
 	switch v.Kind {
 	case reflect.Chan:
+		switch memberName {
+		case "buffered", "recvWaiting", "sendWaiting":
+			// children added by loadChanContents
+			cv := v.clone()
+			cv.Children = nil
+			cv.loaded = false
+			cv.loadValue(loadFullValue)
+			for i := range cv.Children {
+				if cv.Children[i].Name == memberName {
+					return &cv.Children[i], nil
+				}
+			}
+		}
 		v = v.clone()
 		v.RealType = resolveTypedef(&(v.RealType.(*godwarf.ChanType).TypedefType))
 	case reflect.Interface:
@@ -1111,6 +1215,10 @@ func (v *Variable) structMember(memberName string) (*Variable, error) {
 		first = false
 	}
 
+	if sv := v.syncMember(memberName); sv != nil {
+		// children added by loadSyncContents
+		return sv, nil
+	}
 	return nil, fmt.Errorf("%s has no member %s", vname, memberName)
 }
 
@@ -1225,6 +1333,9 @@ func (v *Variable) loadValueInternal(recurseLevel int, cfg LoadConfig) {
 		} else {
 			v.Children[0].OnlyAddr = true
 		}
+		if v.Kind == reflect.UnsafePointer && cfg.SymbolizePointers {
+			v.Symbol = v.bi.symbolizeAddr(v.Children[0].Addr)
+		}
 
 	case reflect.Chan:
 		sv := v.clone()
@@ -1234,6 +1345,11 @@ func (v *Variable) loadValueInternal(recurseLevel int, cfg LoadConfig) {
 		v.Children = sv.Children
 		v.Len = sv.Len
 		v.Base = sv.Addr
+		if recurseLevel == 0 && cfg.MaxVariableRecurse > 0 && v.Base != 0 && sv.Unreadable == nil {
+			// only for channels that are explicitly evaluated, not for the ones
+			// found inside other values
+			v.loadChanContents(recurseLevel, cfg)
+		}
 
 	case reflect.Map:
 		if recurseLevel <= cfg.MaxVariableRecurse {
@@ -1291,6 +1407,9 @@ func (v *Variable) loadValueInternal(recurseLevel int, cfg LoadConfig) {
 				v.Children[i].Name = field.Name
 				v.Children[i].loadValueInternal(recurseLevel+1, cfg)
 			}
+			if len(v.Children) == len(t.Field) {
+				v.loadSyncContents()
+			}
 		}
 
 	case reflect.Interface:
@@ -1309,6 +1428,9 @@ func (v *Variable) loadValueInternal(recurseLevel int, cfg LoadConfig) {
 			var val uint64
 			val, v.Unreadable = readUintRaw(v.mem, v.Addr, v.RealType.(*godwarf.UintType).ByteSize)
 			v.Value = constant.MakeUint64(val)
+			if cfg.SymbolizePointers && v.Unreadable == nil && v.RealType.String() == "uintptr" {
+				v.Symbol = v.bi.symbolizeAddr(val)
+			}
 		}
 	case reflect.Bool:
 		val := make([]byte, 1)
@@ -1331,9 +1453,16 @@ func (v *Variable) loadValueInternal(recurseLevel int, cfg LoadConfig) {
 		}
 	case reflect.Func:
 		v.readFunctionPtr()
+		if cfg.SymbolizePointers && v.Unreadable == nil {
+			v.Symbol = v.bi.symbolizeAddr(v.Base)
+		}
 	default:
 		v.Unreadable = fmt.Errorf("unknown or unsupported kind: \"%s\"", v.Kind.String())
 	}
+
+	if v.Unreadable == nil {
+		v.formatBuiltin(cfg)
+	}
 }
 
 // convertToEface converts srcv into an "interface {}" and writes it to
@@ -1410,6 +1539,92 @@ func readStringValue(mem MemoryReadWriter, addr uint64, strlen int64, cfg LoadCo
 	return string(val), nil
 }
 
+const (
+	// readStringRangeChunk is the size of the reads made by
+	// ReadStringRange, larger than the reads cached by the gdbserial
+	// backend so that they are sent in as few packets as possible.
+	readStringRangeChunk = 1 << 20
+	// readStringRangePage is the granularity used by ReadStringRange to find
+	// the first unreadable address, see readCStringValue.
+	readStringRangePage = 1024
+)
+
+// ReadStringRange reads count bytes, starting at offset, of the contents of
+// a string or byte slice that are length bytes long and start at base, see
+// Variable.Base and Variable.Len. Count is truncated at the end of the
+// contents. If part of the range can not be read the bytes preceding the
+// first unreadable address are returned along with the error.
+func ReadStringRange(mem MemoryReadWriter, base uint64, length, offset, count int64) ([]byte, error) {
+	if offset < 0 || offset > length {
+		return nil, fmt.Errorf("offset %d out of range [0, %d]", offset, length)
+	}
+	if count < 0 {
+		return nil, fmt.Errorf("invalid count %d", count)
+	}
+	if base >= fakeAddressUnresolv {
+		return nil, errors.New("contents are not stored in the memory of the target")
+	}
+	if count > length-offset {
+		count = length - offset
+	}
+	data := make([]byte, count)
+	for n := int64(0); n < count; {
+		sz := count - n
+		if sz > readStringRangeChunk {
+			sz = readStringRangeChunk
+		}
+		addr := base + uint64(offset+n)
+		if _, err := mem.ReadMemory(data[n:n+sz], addr); err == nil {
+			n += sz
+			continue
+		}
+		// read what precedes the unreadable memory
+		for end := n + sz; n < end; {
+			k := int64((addr | (readStringRangePage - 1)) - addr + 1)
+			if k > end-n {
+				k = end - n
+			}
+			if _, err := mem.ReadMemory(data[n:n+k], addr); err != nil {
+				return data[:n], fmt.Errorf("could not read string at %#x due to %s", addr, err)
+			}
+			n, addr = n+k, addr+uint64(k)
+		}
+	}
+	return data, nil
+}
+
+// ExamineType returns the value of type typename stored at addr, or an
+// array of count such values if count is greater than 1, loaded with cfg.
+// The address does not need to belong to a known variable. The type is
+// either the name of a type, as it appears in the debug info, or a Go type
+// expression like *main.Header or [4]uint32. If part of the memory is
+// unreadable the corresponding fields and elements are marked as
+// unreadable.
+func ExamineType(bi *BinaryInfo, mem MemoryReadWriter, addr uint64, typename string, count int64, cfg LoadConfig) (*Variable, error) {
+	if count < 1 {
+		return nil, fmt.Errorf("invalid count %d", count)
+	}
+	typ, err := bi.findType(typename)
+	if err != nil {
+		expr, perr := parser.ParseExpr(typename)
+		if perr != nil {
+			return nil, fmt.Errorf("could not parse type %q: %v", typename, perr)
+		}
+		typ, err = bi.findTypeExpr(expr)
+		if err != nil {
+			return nil, fmt.Errorf("could not find type %s: %v", typename, err)
+		}
+	}
+	name := fmt.Sprintf("*(*%s)(%#x)", typename, addr)
+	if count > 1 {
+		typ = fakeArrayType(uint64(count), typ)
+		name = fmt.Sprintf("*(*[%d]%s)(%#x)", count, typename, addr)
+	}
+	v := newVariable(name, addr, typ, bi, mem)
+	v.loadValue(cfg)
+	return v, nil
+}
+
 func readCStringValue(mem MemoryReadWriter, addr uint64, cfg LoadConfig) (string, bool, error) {
 	buf := make([]byte, cfg.MaxStringLen) //
 	val := buf[:0]                        // part of the string we've already read
@@ -1556,6 +1771,114 @@ func (v *Variable) loadChanInfo() {
 	}
 }
 
+// loadChanContents appends three children to v, a channel whose
+// runtime.hchan struct has been loaded: "buffered", the elements in the
+// buffer of the channel in the order they will be received, "recvWaiting"
+// and "sendWaiting", the IDs of the goroutines blocked receiving from and
+// sending to the channel.
+func (v *Variable) loadChanContents(recurseLevel int, cfg LoadConfig) {
+	chanType, ok := v.RealType.(*godwarf.ChanType)
+	if !ok {
+		return
+	}
+	field := func(name string) *Variable {
+		for i := range v.Children {
+			if v.Children[i].Name == name {
+				return &v.Children[i]
+			}
+		}
+		return nil
+	}
+	qcountVar, dataqsizVar, recvxVar, bufVar := field("qcount"), field("dataqsiz"), field("recvx"), field("buf")
+	if qcountVar == nil || dataqsizVar == nil || recvxVar == nil || bufVar == nil {
+		return
+	}
+	qcount, err1 := qcountVar.asUint()
+	dataqsiz, err2 := dataqsizVar.asUint()
+	recvx, err3 := recvxVar.asUint()
+	if err1 != nil || err2 != nil || err3 != nil || qcount > dataqsiz || (dataqsiz > 0 && recvx >= dataqsiz) {
+		return
+	}
+	bufaddr, err := readUintRaw(bufVar.mem, bufVar.Addr, int64(v.bi.Arch.PtrSize()))
+	if err != nil {
+		return
+	}
+
+	mem := DereferenceMemory(v.mem)
+	elemSize := uint64(chanType.ElemType.Size())
+	buffered := v.newVariable("buffered", 0, fakeSliceType(chanType.ElemType), mem)
+	buffered.Base = bufaddr
+	buffered.Len = int64(qcount)
+	buffered.Cap = int64(qcount)
+	buffered.loaded = true
+	for i := uint64(0); i < qcount && i < uint64(cfg.MaxArrayValues); i++ {
+		elem := v.newVariable("", bufaddr+((recvx+i)%dataqsiz)*elemSize, chanType.ElemType, mem)
+		elem.loadValueInternal(recurseLevel+1, cfg)
+		buffered.Children = append(buffered.Children, *elem)
+	}
+
+	recvWaiting := v.chanWaiters("recvWaiting", field("recvq"))
+	sendWaiting := v.chanWaiters("sendWaiting", field("sendq"))
+	v.Children = append(v.Children, *buffered, *recvWaiting, *sendWaiting)
+}
+
+// maxWaiters is the maximum number of goroutines read from a list of
+// goroutines waiting on a channel or a synchronization object.
+const maxWaiters = 100
+
+// chanWaiters returns a slice, called name, containing the IDs of the
+// goroutines in q, a runtime.waitq.
+func (v *Variable) chanWaiters(name string, q *Variable) *Variable {
+	var goids []int
+	if q != nil {
+		s, _ := q.structMember("first")
+		for s != nil && len(goids) < maxWaiters {
+			s = s.maybeDereference()
+			if s.Addr == 0 || s.Unreadable != nil {
+				break
+			}
+			goid := sudogGoroutineID(s)
+			if goid == 0 {
+				break
+			}
+			goids = append(goids, goid)
+			var err error
+			s, err = s.structMember("next")
+			if err != nil {
+				break
+			}
+		}
+	}
+	return v.goroutineIDs(name, goids)
+}
+
+// goroutineIDs returns a slice, called name, containing goids.
+func (v *Variable) goroutineIDs(name string, goids []int) *Variable {
+	var goidType godwarf.Type = &godwarf.IntType{BasicType: godwarf.BasicType{CommonType: godwarf.CommonType{ByteSize: 8, Name: "int64", ReflectKind: reflect.Int64}, BitSize: 64}}
+	if gtyp, err := v.bi.findType("runtime.g"); err == nil {
+		if styp, ok := resolveTypedef(gtyp).(*godwarf.StructType); ok {
+			for _, f := range styp.Field {
+				if f.Name == "goid" {
+					goidType = f.Type
+				}
+			}
+		}
+	}
+	mem := DereferenceMemory(v.mem)
+	r := v.newVariable(name, 0, fakeSliceType(goidType), mem)
+	r.Len = int64(len(goids))
+	r.Cap = r.Len
+	r.Children = make([]Variable, len(goids))
+	for i, goid := range goids {
+		c := v.newVariable("", 0, goidType, mem)
+		c.Value = constant.MakeInt64(int64(goid))
+		c.loaded = true
+		r.Children[i] = *c
+	}
+	r.loaded = true
+	return r
+}
+
 func (v *Variable) loadArrayValues(recurseLevel int, cfg LoadConfig) {
 	if v.Unreadable != nil {
 		return
@@ -1821,6 +2144,25 @@ func (v *Variable) readFunctionPtr() {
 	v.Value = constant.MakeString(fn.Name)
 }
 
+// symbolizeAddr returns the name of the function containing the code at
+// addr, followed by the offset of addr from its entry point, or the empty
+// string if addr is not inside a known function. For closures the name of
+// the function, for example main.main.func1, starts with the name of the
+// enclosing function.
+func (bi *BinaryInfo) symbolizeAddr(addr uint64) string {
+	if addr == 0 {
+		return ""
+	}
+	fn := bi.PCToFunc(addr)
+	if fn == nil {
+		return ""
+	}
+	if addr == fn.Entry {
+		return fn.Name
+	}
+	return fmt.Sprintf("%s+%#x", fn.Name, addr-fn.Entry)
+}
+
 // funcvalAddr reads the address of the funcval contained in a function variable.
 func (v *Variable) funcvalAddr() uint64 {
 	val, err := readUintRaw(v.mem, v.Addr, int64(v.bi.Arch.PtrSize()))
@@ -1837,11 +2179,21 @@ func (v *Variable) loadMap(recurseLevel int, cfg LoadConfig) {
 		return
 	}
 	it.maxNumBuckets = uint64(cfg.MaxMapBuckets)
+	if v.mapFilter != nil {
+		v.MapFilter = &MapFilterResult{}
+	}
 
 	if v.Len == 0 || int64(v.mapSkip) >= v.Len || cfg.MaxArrayValues == 0 {
 		return
 	}
 
+	if v.mapResume != nil {
+		if err := it.seek(v.mapResume); err != nil {
+			v.Unreadable = err
+			return
+		}
+	}
+
 	for skip := 0; skip < v.mapSkip; skip++ {
 		if ok := it.next(); !ok {
 			v.Unreadable = fmt.Errorf("map index out of bounds")
@@ -1851,6 +2203,8 @@ func (v *Variable) loadMap(recurseLevel int, cfg LoadConfig) {
 
 	count := 0
 	errcount := 0
+	filterErrcount := 0
+	var filterErr error
 	for it.next() {
 		key := it.key()
 		var val *Variable
@@ -1859,6 +2213,20 @@ func (v *Variable) loadMap(recurseLevel int, cfg LoadConfig) {
 		} else {
 			val = v.newVariable("", it.values.Addr, it.values.fieldType, DereferenceMemory(v.mem))
 		}
+		if v.mapFilter != nil {
+			v.MapFilter.Scanned++
+			ok, err := v.mapFilter.match(key, val)
+			if err != nil {
+				filterErrcount++
+				if filterErr == nil {
+					filterErr = err
+				}
+			}
+			if !ok {
+				continue
+			}
+			v.MapFilter.Matched++
+		}
 		key.loadValueInternal(recurseLevel+1, cfg)
 		val.loadValueInternal(recurseLevel+1, cfg)
 		if key.Unreadable != nil || val.Unreadable != nil {
@@ -1869,10 +2237,19 @@ func (v *Variable) loadMap(recurseLevel int, cfg LoadConfig) {
 		if errcount > maxErrCount {
 			break
 		}
-		if count >= cfg.MaxArrayValues || int64(count) >= v.Len {
+		if count >= cfg.MaxArrayValues {
+			v.MapContinuation = it.position().String()
+			break
+		}
+		if int64(count) >= v.Len {
 			break
 		}
 	}
+	if v.mapFilter != nil && filterErr != nil && int64(filterErrcount) == v.MapFilter.Scanned {
+		// the filter could not be evaluated on any entry, report the error
+		// instead of an empty map
+		v.Unreadable = filterErr
+	}
 }
 
 type mapIterator struct {
@@ -1891,6 +2268,9 @@ type mapIterator struct {
 
 	maxNumBuckets uint64 // maximum number of buckets to scan
 
+	bucketIdx uint64 // index of the current bucket, or of the bucket it overflows from
+	ovfDepth  int    // number of overflow buckets followed to reach the current bucket
+
 	idx int64
 
 	hashTophashEmptyOne uint64 // Go 1.12 and later has two sentinel tophash values for an empty cell, this is the second one (the first one hashTophashEmptyZero, the same as Go 1.11 and earlier)
@@ -1963,6 +2343,7 @@ var errMapBucketsNotStruct = errors.New("malformed map type: buckets, oldbuckets
 func (it *mapIterator) nextBucket() bool {
 	if it.overflow != nil && it.overflow.Addr > 0 {
 		it.b = it.overflow
+		it.ovfDepth++
 	} else {
 		it.b = nil
 
@@ -2008,6 +2389,8 @@ func (it *mapIterator) nextBucket() bool {
 		if it.b == nil {
 			return false
 		}
+		it.bucketIdx = it.bidx
+		it.ovfDepth = 0
 		it.bidx++
 	}
 
@@ -127,3 +127,37 @@ func resolveCallArgX86(inst *x86asm.Inst, instAddr uint64, currentGoroutine bool
 	}
 	return &Location{PC: pc, File: file, Line: line, Fn: fn}
 }
+
+// memoryOperand returns the address and size of the first memory operand
+// of inst, if it can be computed from regs. The returned size is 0 if inst
+// does not have a memory operand or its address can not be computed.
+func (inst *x86Inst) memoryOperand(pc uint64, regs *op.DwarfRegisters, bininfo *BinaryInfo) (uint64, int) {
+	if inst == nil {
+		return 0, 0
+	}
+	reg := func(r x86asm.Reg) (uint64, error) {
+		switch r {
+		case 0:
+			return 0, nil
+		case x86asm.RIP, x86asm.EIP:
+			return pc + uint64(inst.Len), nil
+		}
+		return bininfo.Arch.getAsmRegister(regs, int(r))
+	}
+	for _, arg := range inst.Args {
+		mem, ok := arg.(x86asm.Mem)
+		if !ok {
+			continue
+		}
+		if mem.Segment != 0 {
+			return 0, 0
+		}
+		base, err1 := reg(mem.Base)
+		index, err2 := reg(mem.Index)
+		if err1 != nil || err2 != nil {
+			return 0, 0
+		}
+		return uint64(int64(base) + int64(index*uint64(mem.Scale)) + mem.Disp), inst.MemBytes
+	}
+	return 0, 0
+}
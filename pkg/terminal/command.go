@@ -119,10 +119,28 @@ func DebugCommands(client service.Client) *Commands {
 Type "help" followed by the name of a command for more information about it.`},
 		{aliases: []string{"break", "b"}, group: breakCmds, cmdFn: breakpoint, helpMsg: `Sets a breakpoint.
 
-	break [name] <linespec>
+	break [name] <linespec>[;<linespec>...]
 
 See $GOPATH/src/github.com/go-delve/delve/Documentation/cli/locspec.md for the syntax of linespec.
 
+If multiple linespecs separated by ';' are specified a single breakpoint covering all of their locations is created, the breakpoint is enabled, disabled and conditioned as a whole.
+
+	break [name] -r <regexp>
+
+Sets a single breakpoint on the entry point of every function whose name matches the regular expression. The regular expression is evaluated again when the target is restarted and when it loads a shared object. Functions that already have a breakpoint are skipped.
+
+	break [name] -package [-no-wrappers] <package>
+
+Sets a single breakpoint on the entry point of every function of the package, including closures and methods. The package can be specified by its full import path or by the last components of its path, as long as they match a single package: 'template' is rejected if the program uses both text/template and html/template. Functions that already have a breakpoint are skipped. With -no-wrappers autogenerated wrappers are excluded. The functions of the package are enumerated again when the target is restarted.
+
+	break [name] -hw <linespec>
+
+Sets a hardware breakpoint, which does not modify the code of the program. Hardware breakpoints share the hardware slots used by watchpoints and are used automatically when the breakpoint instruction can not be written, for example because the code is not writable.
+
+	break [name] -suspended <linespec>
+
+Sets a breakpoint on a location that may belong to a shared library that has not been loaded yet. If the location can not be found the breakpoint is suspended and it is enabled automatically when the program loads a library containing it, for example with dlopen. The location can also be specified with -r or -package, for example 'break -suspended -r ^mylib\.', in which case the breakpoint is suspended until a matching function is loaded. Breakpoints on shared libraries are also suspended when the program is restarted, until the library is loaded again.
+
 See also: "help on", "help cond" and "help clear"`},
 		{aliases: []string{"trace", "t"}, group: breakCmds, cmdFn: tracepoint, allowedPrefixes: onPrefix, helpMsg: `Set tracepoint.
 
@@ -133,11 +151,21 @@ A tracepoint is a breakpoint that does not stop the execution of the program, in
 See also: "help on", "help cond" and "help clear"`},
 		{aliases: []string{"watch"}, group: breakCmds, cmdFn: watchpoint, helpMsg: `Set watchpoint.
 	
-	watch [-r|-w|-rw] <expr>
+	watch [-r|-w|-rw] [-s] [-rewatch] [-follow [-notify]] <expr>
+	watch [-r|-w|-rw] [-s] -size <n> <address>
 	
-	-r	stops when the memory location is read
-	-w	stops when the memory location is written
-	-rw	stops when the memory location is read or written
+	-r		stops when the memory location is read
+	-w		stops when the memory location is written
+	-rw		stops when the memory location is read or written
+	-s		use a software watchpoint
+	-rewatch	evaluate the expression again every time the program stops
+	-follow		follow the reallocations of the slice indexed by expr
+	-notify		stop when a -follow watchpoint is moved
+	-size		watch n bytes of memory starting at address
+
+Software watchpoints are implemented by single stepping the threads running goroutines and are much slower than hardware watchpoints, they are used automatically when all hardware watchpoint slots are in use or the backend does not support hardware watchpoints. A watchpoint that needs more hardware slots than are free, for example a watchpoint on a string, is implemented entirely in software. Software watchpoints can only detect reads on amd64.
+
+The options can be specified in any order before the expression.
 
 The memory location is specified with the same expression language used by 'print', for example:
 
@@ -145,6 +173,24 @@ The memory location is specified with the same expression language used by 'prin
 
 will watch the address of variable 'v'.
 
+With -size the memory location is specified directly by its address, for example:
+
+	watch -w -size 8 0xc000123456
+
+will watch the 8 bytes of memory starting at 0xc000123456. The size must be a power of two no larger than the size of a pointer and the address must be a multiple of the size. Since the watched memory has no type, the old and new bytes are printed when the watchpoint is hit.
+
+Watchpoints on stack variables are automatically cleared when the function owning the variable returns, or when its frame is unwound by a panic that is recovered by one of its callers. They are moved with the stack of their goroutine when the runtime grows or shrinks it.
+
+Watchpoints created with -rewatch track the expression instead of the address it had when the watchpoint was created, for example 'watch -w -rewatch p.field' keeps watching the field after p is reassigned. The expression is evaluated in the frame where the watchpoint was created every time the program stops and the watchpoint is moved if its address changed. If the expression can not be evaluated, for example because its frame returned, the watchpoint is suspended and is not triggered until the expression can be evaluated again.
+
+Watchpoints on interface values watch the dynamic value of the interface, when the interface is reassigned the watchpoint is moved to the new dynamic value and resized to its type. While the interface is nil, or holds a value stored directly in the interface such as a pointer, the watchpoint is suspended.
+
+Watchpoints created with -follow on an element of a slice, for example 'watch -w -follow s[3]', also watch the data pointer and length of the slice header, using two more hardware slots. When the backing array of the slice is reallocated, for example by append, the watchpoint is moved to the element with the same index in the new backing array. If the slice shrinks so that the index is out of range the watchpoint is suspended until the slice grows again. Moved and suspended watchpoints are reported the next time the program stops, with -notify the program also stops every time the watchpoint is moved.
+
+Watchpoints on strings and slices watch the fields of their header (the data pointer, the length and, for slices, the capacity), using one hardware slot for each field. Other variables larger than a pointer are split into multiple pointer sized regions, each using one hardware slot.
+
+Watchpoints on package-level variables are set again when the program is restarted, by evaluating their expression in the scope of their package. Watchpoints on stack variables, or whose expression can no longer be evaluated, are discarded.
+
 See also: "help print".`},
 		{aliases: []string{"restart", "r"}, group: runCmds, cmdFn: restart, helpMsg: `Restart process.
 
@@ -170,28 +216,88 @@ A list of file redirections can be specified after the new argument list to over
 		{aliases: []string{"rebuild"}, group: runCmds, cmdFn: c.rebuild, allowedPrefixes: revPrefix, helpMsg: "Rebuild the target executable and restarts it. It does not work if the executable was not built by delve."},
 		{aliases: []string{"continue", "c"}, group: runCmds, cmdFn: c.cont, allowedPrefixes: revPrefix, helpMsg: `Run until breakpoint or program termination.
 
-	continue [<linespec>]
+	continue [-timeout <duration>] [<linespec>]
 
 Optional linespec argument allows you to continue until a specific location is reached. The program will halt if a breakpoint is hit before reaching the specified location.
 
+If -timeout is specified the program will be stopped after running for the specified duration, even if no breakpoint is hit.
+
 For example:
 
 	continue main.main
 	continue encoding/json.Marshal
+	continue -timeout 500ms
+`},
+		{aliases: []string{"step", "s"}, group: runCmds, cmdFn: c.step, allowedPrefixes: revPrefix, helpMsg: `Single step through program.
+
+	step [-newgoroutine]
+
+With -newgoroutine executes the go statement on the current line and stops at the first instruction of the goroutine it creates, other goroutines starting the same function are ignored. Fails if the current goroutine exits, or if the new goroutine does not start running within a few seconds.`},
+		{aliases: []string{"step-into"}, group: runCmds, cmdFn: c.stepInto, helpMsg: `Step into a specific function called on the current line.
+
+	step-into [<function name> | *<address>]
+
+Steps over every call on the current line except the one to the specified function, which is stepped into. Indirect calls can be selected with the address of the call instruction. Without arguments lists the calls remaining on the current line.
 `},
-		{aliases: []string{"step", "s"}, group: runCmds, cmdFn: c.step, allowedPrefixes: revPrefix, helpMsg: "Single step through program."},
-		{aliases: []string{"step-instruction", "si"}, group: runCmds, allowedPrefixes: revPrefix, cmdFn: c.stepInstruction, helpMsg: "Single step a single cpu instruction."},
+		{aliases: []string{"skip"}, group: runCmds, cmdFn: skipCmd, helpMsg: `Manages the functions that step steps over instead of stopping inside them.
+
+	skip
+	skip function <regexp>
+	skip file <glob>
+	skip delete <id> ...
+
+Without arguments prints the list of skip rules. The 'function' subcommand skips all functions whose full name matches the regular expression, for example 'skip function fmt\..*'. The 'file' subcommand skips all functions defined in files matching the glob, '*' matches any sequence of characters except '/' and '**' matches any sequence of characters, a glob that doesn't start with '/' is matched against the trailing components of the path, for example 'skip file **/vendor/**' or 'skip file *.pb.go'. The 'delete' subcommand removes the rules with the specified IDs.
+
+Skip rules only affect step: breakpoints set inside skipped functions are still hit and next, stepout and step-into ignore them.`},
+		{aliases: []string{"step-instruction", "si"}, group: runCmds, allowedPrefixes: revPrefix, cmdFn: c.stepInstruction, helpMsg: `Single step a single cpu instruction.
+
+	step-instruction [-over] [count]
+
+Optional [count] argument executes count instructions, stepping stops early if a breakpoint is reached.
+
+With -over a CALL instruction is executed together with the function it calls, as a single instruction.`},
 		{aliases: []string{"next", "n"}, group: runCmds, cmdFn: c.next, allowedPrefixes: revPrefix, helpMsg: `Step over to next source line.
 
 	next [count]
 
 Optional [count] argument allows you to skip multiple lines.
 `},
-		{aliases: []string{"stepout", "so"}, group: runCmds, allowedPrefixes: revPrefix, cmdFn: c.stepout, helpMsg: "Step out of the current function."},
+		{aliases: []string{"stepout", "so"}, group: runCmds, allowedPrefixes: revPrefix, cmdFn: c.stepout, helpMsg: `Step out of the current function.
+
+	stepout [-iterator]
+
+When the current function is the body of a range-over-func loop stepout stops when execution returns to the function containing the loop, at the next iteration of the loop or after the loop. With -iterator it stops in the iterator function that called the body instead.`},
+		{aliases: []string{"pin"}, group: runCmds, cmdFn: pin, helpMsg: `Resumes only the current goroutine.
+
+	pin [on|off]
+
+While pinning is on continue, next, step and stepout only run the thread executing the current goroutine, every other goroutine stays frozen where it is. Breakpoints, conditions and stepping work normally for the current goroutine.
+
+If the current goroutine blocks on something that only another goroutine could provide, for example a channel operation or a mutex, or it yields, is preempted, exits or triggers a garbage collection, the program is stopped and an error is reported instead of waiting forever.
+
+Without arguments prints whether pinning is on. Not supported by all backends, in particular not when replaying a recording.`},
+		{aliases: []string{"until"}, group: runCmds, cmdFn: c.until, helpMsg: `Resumes process until a boolean expression becomes true.
+
+	until <expression>
+
+The expression is evaluated in the topmost frame of the current goroutine. If it only reads variables and fields of variables, without dereferencing pointers, slices or interfaces, a temporary watchpoint is set on each variable and the expression is evaluated again every time one of them changes. If the expression reads local variables execution also stops when the current function returns.
+
+Otherwise the current goroutine is single stepped, while all other goroutines are stopped, and the expression is evaluated on every new source line: this is very slow and will never terminate if the goroutine waits for another goroutine. Press ctrl-C to interrupt it.
+
+Breakpoints reached before the expression becomes true stop execution as usual.
+`},
 		{aliases: []string{"call"}, group: runCmds, cmdFn: c.call, helpMsg: `Resumes process, injecting a function call (EXPERIMENTAL!!!)
 	
 	call [-unsafe] <function call expression>
 	
+The arguments can contain struct, array and slice composite literals and calls to make for slices, see $GOPATH/src/github.com/go-delve/delve/Documentation/cli/expr.md.
+
+Maps and slices can also be modified:
+
+	call m["key"] = 10
+	call delete(m, "key")
+	call s = append(s, 4, 5)
+
 Current limitations:
 - only pointers to stack-allocated objects can be passed as argument.
 - only some automatic type conversions are supported.
@@ -214,11 +320,42 @@ Current limitations:
 		{aliases: []string{"clearall"}, group: breakCmds, cmdFn: clearAll, helpMsg: `Deletes multiple breakpoints.
 
 	clearall [<linespec>]
+	clearall -group <group>
 
-If called with the linespec argument it will delete all the breakpoints matching the linespec. If linespec is omitted all breakpoints are deleted.`},
+If called with the linespec argument it will delete all the breakpoints matching the linespec. If linespec is omitted all breakpoints are deleted. With the -group option all the breakpoints belonging to the specified group are deleted.`},
 		{aliases: []string{"toggle"}, group: breakCmds, cmdFn: toggle, helpMsg: `Toggles on or off a breakpoint.
 
-toggle <breakpoint name or id>`},
+	toggle <breakpoint name or id>
+	toggle -group <group>
+
+With the -group option all the breakpoints belonging to the specified group are disabled if any of them is enabled, otherwise they are all enabled.`},
+		{aliases: []string{"hits"}, group: breakCmds, cmdFn: hitsCmd, helpMsg: `Prints the hit history of a breakpoint.
+
+	hits <breakpoint name or id>
+	hits -size <n> <breakpoint name or id>
+
+The hit history of a breakpoint records the goroutine, thread, address and time of its last hits. It is disabled by default, 'hits -size <n>' enables it and remembers the last n hits, 'hits -size 0' disables it.
+
+Printing the hit history clears it.`},
+		{aliases: []string{"logpoint"}, group: breakCmds, cmdFn: logpointCmd, allowedPrefixes: onPrefix, helpMsg: `Turns a breakpoint into a logpoint.
+
+	logpoint <breakpoint name or id> [<format>]
+
+When a logpoint is hit the format string is rendered and the program continues without stopping. Expressions enclosed in curly braces are evaluated and their value is substituted in the message, use '{{' and '}}' to write literal braces. Conditions and hit count conditions are honored.
+
+	logpoint 1 request {r.URL.Path} took {elapsed}
+
+Messages are printed as soon as the logpoint is hit, when connected to a headless instance of Delve they are printed the next time the program stops. Values are printed like the print command does, except that strings are not quoted. If the format is omitted the breakpoint is turned back into a normal breakpoint.`},
+		{aliases: []string{"group"}, group: breakCmds, cmdFn: groupCmd, allowedPrefixes: onPrefix, helpMsg: `Sets the group of a breakpoint.
+
+	group <breakpoint name or id> [<group>]
+
+Adds the breakpoint to the specified group, if the group is omitted the breakpoint is removed from its current group. Groups can be enabled, disabled and cleared together using 'toggle -group' and 'clearall -group'.`},
+		{aliases: []string{"clear-hitcount"}, group: breakCmds, cmdFn: clearHitCount, helpMsg: `Resets the hit counts of a breakpoint.
+
+	clear-hitcount [<breakpoint name or id>]
+
+Resets the total and per-goroutine hit counts of the specified breakpoint. If the breakpoint is omitted the hit counts of all breakpoints are reset.`},
 		{aliases: []string{"goroutines", "grs"}, group: goroutineCmds, cmdFn: goroutines, helpMsg: `List program goroutines.
 
 	goroutines [-u|-r|-g|-s] [-t [depth]] [-l] [-with loc expr] [-without loc expr] [-group argument]
@@ -286,22 +423,82 @@ Groups goroutines by the value of the label with the specified key.
 Called without arguments it will show information about the current goroutine.
 Called with a single argument it will switch to the specified goroutine.
 Called with more arguments it will execute a command on the specified goroutine.`},
-		{aliases: []string{"breakpoints", "bp"}, group: breakCmds, cmdFn: breakpoints, helpMsg: "Print out info for active breakpoints."},
+		{aliases: []string{"deadlock"}, group: goroutineCmds, cmdFn: deadlock, helpMsg: `Reports goroutines waiting on each other.
+
+	deadlock
+
+Lists the goroutines blocked on a sync.Mutex, sync.RWMutex, sync.WaitGroup, sync.Cond or channel operation, grouped by the kind of object they are waiting on, followed by the cycles of goroutines waiting on each other, for example:
+
+	goroutine 12 waits on sync.Mutex 0xc000012345 held by goroutine 31
+	goroutine 31 waits on sync.Mutex 0xc000012350 held by goroutine 12
+
+The runtime does not record which goroutine holds a lock or may operate on a channel, a goroutine is assumed to do so if the object is reachable from its stack, therefore the reported cycles are potential deadlocks.`},
+		{aliases: []string{"mutex"}, group: goroutineCmds, allowedPrefixes: onPrefix | deferredPrefix, cmdFn: mutex, helpMsg: `Shows the state of a mutex and the goroutines waiting on it.
+
+	[goroutine <n>] [frame <m>] mutex <expression>
+
+The expression must evaluate to a sync.Mutex, a sync.RWMutex or a pointer to one of them. Prints whether the lock is held, the number of readers of a sync.RWMutex, the goroutines parked acquiring the lock and the goroutines that may hold it, for example:
+
+	sync.Mutex 0xc000012345: locked, 2 waiters
+	Waiting: goroutine 18, 19
+	Possible owners:
+	Goroutine 17  /path/to/file.go:23 main.update (0x4a1b2c)
+
+The runtime does not record which goroutine holds a lock: possible owners are the goroutines stopped between a call acquiring a lock of the same kind and the call releasing it, goroutines that can not reach the mutex from their stack are only listed if no other goroutine can.`},
+		{aliases: []string{"breakpoints", "bp"}, group: breakCmds, cmdFn: breakpoints, helpMsg: `Print out info for active breakpoints.
+
+	breakpoints [-verbose]
+
+With -verbose every address covered by each breakpoint is listed, including the addresses where the breakpoint could not be set.`},
 		{aliases: []string{"print", "p"}, group: dataCmds, allowedPrefixes: onPrefix | deferredPrefix, cmdFn: printVar, helpMsg: `Evaluate an expression.
 
-	[goroutine <n>] [frame <m>] print [%format] <expression>
+	[goroutine <n>] [frame <m>] print [-raw] [-sym] [%format] <expression>
+	[goroutine <n>] [frame <m>] print [-raw] [-sym] -x|-o|-b|-c|-U|-e|-f|-p <expression>
+	[goroutine <n>] [frame <m>] print -full <expression> [> <file>]
 
 See $GOPATH/src/github.com/go-delve/delve/Documentation/cli/expr.md for a description of supported expressions.
 
-The optional format argument is a format specifier, like the ones used by the fmt package. For example "print %x v" will print v as an hexadecimal number.`},
+The optional format argument is a format specifier, like the ones used by the fmt package. For example "print %x v" will print v as an hexadecimal number.
+
+The format verb flags are applied to v and to all the values it contains: integers are printed in hexadecimal with -x, octal with -o, binary with -b, as characters with -c and as Unicode code points with -U, floating point and complex numbers in scientific notation with -e and in decimal notation with -f, pointers are printed as addresses with -p. For example "print -x flags" prints the fields of the struct flags in hexadecimal. Values the flag does not apply to are printed in the default format. To negate a variable use parentheses, like "print (-x)".
+
+Values whose type has a formatter (see help formatter) are printed using it, values of some types of the standard library, like time.Time and time.Duration, are printed in a readable form. With -raw they are printed as if they had no formatter.
+
+Function values are printed as the name of the function, with -sym they are printed as the address of the code followed by the function, for example "0x4a1b20 <main.(*Server).handle>". The values of type uintptr and unsafe.Pointer that point into the code of a function are also followed by the function and the offset from its entry point, for example "0x4a1b3a <main.main+0x1a>".
+
+With -full the whole contents of a string or byte slice are printed, instead of the first max-string-len bytes, or written to file. If part of the contents is unreadable the bytes preceding it are printed followed by the error.`},
+		{aliases: []string{"formatter"}, group: dataCmds, cmdFn: formatterCmd, helpMsg: `Manages the formatters used to print the values of specific types.
+
+	formatter
+	formatter <type> <format>
+	formatter -string <type>
+	formatter -clear <type>
+
+Without arguments prints the list of formatters. The type must be fully qualified, for example 'github.com/some/package.Decimal'.
+
+The format is a string where the expressions between braces are evaluated with the fields and methods of the value in scope, using '{{' and '}}' for literal braces, for example 'formatter main.Decimal {Int}.{Frac}'. Function calls are not allowed in format strings.
+
+With -string values are printed by calling their String method: the target is resumed while String runs, all breakpoints are ignored, at most 10 values are formatted this way by each command.
+
+With -clear the formatter of the type is removed.
+
+Formatters are kept when the target is restarted, they can also be specified in the configuration file.`},
 		{aliases: []string{"whatis"}, group: dataCmds, cmdFn: whatisCommand, helpMsg: `Prints type of an expression.
 
 	whatis <expression>`},
 		{aliases: []string{"set"}, group: dataCmds, cmdFn: setVar, helpMsg: `Changes the value of a variable.
 
-	[goroutine <n>] [frame <m>] set <variable> = <value>
+	[goroutine <n>] [frame <m>] set [-inplace] <variable> = <value>
+
+See $GOPATH/src/github.com/go-delve/delve/Documentation/cli/expr.md for a description of supported expressions. Only numerical variables, pointers, strings, slices and CPU registers can be changed.
+
+Assigning a string literal, or a []byte or []rune converted from a string, to a variable requires allocating memory in the target: this is done with an injected function call, like the 'call' command does, which resumes the target. The same happens when adding an element to a map or assigning the result of append. The '-inplace' option, useful when function calls are not available, writes the new value over the contents of the current value instead, which must not be shorter. Every other string or slice sharing those contents, including string constants of the program, will observe the change.
 
-See $GOPATH/src/github.com/go-delve/delve/Documentation/cli/expr.md for a description of supported expressions. Only numerical variables and pointers can be changed.`},
+For example:
+
+	set s = "hello"
+	set bs = []byte("hello")
+	set -inplace s = "hi"`},
 		{aliases: []string{"sources"}, cmdFn: sources, helpMsg: `Print list of source files.
 
 	sources [<regex>]
@@ -437,14 +634,29 @@ To convert a breakpoint into a tracepoint use:
 
 The command 'on <bp> cond <cond-arguments>' is equivalent to 'cond <bp> <cond-arguments>'.
 
+Any other command is appended to the script of the breakpoint, the script is executed by the terminal, in order, every time the program stops at the breakpoint, including breakpoints hit during 'next', 'step' and 'stepout'. If the last command of the script is 'continue' the program is resumed automatically, a breakpoint hit in the middle of a step always lets the step complete. Breakpoints hit while a script is running do not execute their own script.
+
+	on 1 locals
+	on 1 goroutines
+	on 1 continue
+
 The command 'on x -edit' can be used to edit the list of commands executed when the breakpoint is hit.`},
 		{aliases: []string{"condition", "cond"}, group: breakCmds, cmdFn: conditionCmd, allowedPrefixes: onPrefix, helpMsg: `Set breakpoint condition.
 
 	condition <breakpoint name or id> <boolean expression>.
 	condition -hitcount <breakpoint name or id> <operator> <argument>
+	condition -per-g-hitcount <breakpoint name or id> <operator> <argument>
+	condition -maxhits <breakpoint name or id> <n>
+	condition -calls <breakpoint name or id> <boolean expression>
 
 Specifies that the breakpoint, tracepoint or watchpoint should break only if the boolean expression is true.
 
+Function calls are not allowed in conditions unless the -calls option is used, for example
+
+	condition -calls 1 strings.Contains(req.URL.Path, "admin")
+
+The calls are injected in the goroutine that reached the breakpoint, while they run other breakpoints are ignored. If the calls fail, or do not complete within a few seconds, the breakpoint stops the target and the error is reported.
+
 With the -hitcount option a condition on the breakpoint hit count can be set, the following operators are supported
 
 	condition -hitcount bp > n
@@ -455,7 +667,15 @@ With the -hitcount option a condition on the breakpoint hit count can be set, th
 	condition -hitcount bp != n
 	condition -hitcount bp % n
 	
-The '% n' form means we should stop at the breakpoint when the hitcount is a multiple of n.`},
+The '% n' form means we should stop at the breakpoint when the hitcount is a multiple of n.
+
+The -per-g-hitcount option is like -hitcount but the condition is evaluated on the number of times the breakpoint was hit by the current goroutine, for example
+
+	condition -per-g-hitcount bp == 1
+
+stops the first time each goroutine hits the breakpoint.
+
+With the -maxhits option the breakpoint is automatically disabled after it has been hit n times, unlike a hit count condition this removes the breakpoint from the target so that it no longer slows it down. The hits of all the locations of the breakpoint are counted, logpoints are disabled the same way even though they never stop the target. Re-enabling the breakpoint resets its hit count. Setting n to 0 removes the limit. Watchpoints can not be automatically disabled.`},
 		{aliases: []string{"config"}, cmdFn: configureCmd, helpMsg: `Changes configuration parameters.
 
 	config -list
@@ -491,18 +711,24 @@ If locspec is omitted edit will open the current source file in the editor, othe
 
 	examinemem [-fmt <format>] [-count|-len <count>] [-size <size>] <address>
 	examinemem [-fmt <format>] [-count|-len <count>] [-size <size>] -x <expression>
+	examinemem -t <type> [-count <count>] <address>
+	examinemem -t <type> [-count <count>] -x <expression>
 
 Format represents the data format and the value is one of this list (default hex): bin(binary), oct(octal), dec(decimal), hex(hexadecimal), addr(address).
 Length is the number of bytes (default 1) and must be less than or equal to 1000.
 Address is the memory location of the target to examine. Please note '-len' is deprecated by '-count and -size'.
 Expression can be an integer expression or pointer value of the memory location to examine.
 
+With '-t' the memory is printed as a value of the specified type, which can be a type name like main.Header or a type expression like [4]uint32. If a count is specified the memory is printed as an array of count values of that type. Fields stored in unreadable memory are reported as unreadable. The '-fmt' and '-size' options can not be used with '-t'.
+
 For example:
 
     x -fmt hex -count 20 -size 1 0xc00008af38
     x -fmt hex -count 20 -size 1 -x 0xc00008af38 + 8
     x -fmt hex -count 20 -size 1 -x &myVar
-    x -fmt hex -count 20 -size 1 -x myPtrVar`},
+    x -fmt hex -count 20 -size 1 -x myPtrVar
+    x -t main.Header 0xc000123400
+    x -t main.Header -count 4 -x myPtrVar`},
 
 		{aliases: []string{"display"}, group: dataCmds, cmdFn: display, helpMsg: `Print value of an expression every time the program stops.
 
@@ -518,6 +744,16 @@ If display is called without arguments it will print the value of all expression
 	dump <output file>
 
 The core dump is always written in ELF, even on systems (windows, macOS) where this is not customary. For environments other than linux/amd64 threads and registers are dumped in a format that only Delve can read back.`},
+
+		{aliases: []string{"signals"}, cmdFn: signalsCmd, helpMsg: `Shows or changes the signals delivered to the target without stopping it.
+
+	signals
+	signals pass <signal> ...
+	signals nopass <signal> ...
+
+Without arguments prints the signals currently passed through to the target. The 'pass' subcommand adds the specified signals to the list, 'nopass' removes them. Signals are specified by number, using the signal numbers of the backend.
+
+With the lldb and rr backends SIGURG, used by the Go runtime for asynchronous preemption, is passed through by default; this requires support for the QPassSignals packet in the debug stub. The native backend delivers every signal to the target and can not be configured.`},
 	}
 
 	addrecorded := client == nil
@@ -536,7 +772,21 @@ The core dump is always written in ELF, even on systems (windows, macOS) where t
 				aliases: []string{"rewind", "rw"},
 				group:   runCmds,
 				cmdFn:   c.rewind,
-				helpMsg: "Run backwards until breakpoint or program termination.",
+				helpMsg: `Run backwards until breakpoint or program termination.
+
+	rewind [<linespec>]
+
+Optional linespec argument allows you to run backwards until a specific location is reached, without creating a breakpoint. The program will halt if a breakpoint is hit before reaching the specified location, if the start of the recording is reached first an error is returned. The same can be done with 'rev continue <linespec>'.`,
+			},
+			command{
+				aliases: []string{"rewind-to"},
+				group:   runCmds,
+				cmdFn:   rewindTo,
+				helpMsg: `Restarts the recording at the specified position.
+
+	rewind-to <event> [<ticks>]
+
+The recording is restarted at the start of the specified event, if ticks is specified execution then moves forward until the current thread reaches that tick count. The current event and tick count are printed every time the program stops, and the event of each checkpoint is listed by the 'checkpoints' command.`,
 			},
 			command{
 				aliases: []string{"check", "checkpoint"},
@@ -546,6 +796,17 @@ The core dump is always written in ELF, even on systems (windows, macOS) where t
 	checkpoint [note]
 
 The "note" is arbitrary text that can be used to identify the checkpoint, if it is not specified it defaults to the current filename:line position.`,
+			},
+			command{
+				aliases: []string{"autocheckpoint"},
+				group:   breakCmds,
+				cmdFn:   autoCheckpointCmd,
+				helpMsg: `Creates checkpoints automatically when a breakpoint is hit.
+
+	autocheckpoint [-every <n>] [-max <n>] [-nostop] <breakpoint name or id>
+	autocheckpoint -off <breakpoint name or id>
+
+While replaying the recording a checkpoint is created every n hits of the breakpoint (every hit if -every is omitted), its note contains the breakpoint ID and hit count. Hits are counted, and checkpoints created, even when the breakpoint does not stop the program, for example because of its hit condition. With -nostop the breakpoint only creates checkpoints and the program is resumed automatically after it is hit. Only the last -max automatic checkpoints of the breakpoint are kept (10 if -max is omitted), older ones are deleted. Automatic checkpoints are marked as such by the 'checkpoints' command.`,
 			},
 			command{
 				aliases: []string{"checkpoints"},
@@ -558,6 +819,15 @@ The "note" is arbitrary text that can be used to identify the checkpoint, if it
 				helpMsg: `Deletes checkpoint.
 
 	clear-checkpoint <id>`,
+			},
+			command{
+				aliases: []string{"checkpoint-diff", "cpdiff"},
+				cmdFn:   checkpointDiff,
+				helpMsg: `Compares the value of an expression at a checkpoint with its current value.
+
+	[goroutine <n>] [frame <m>] checkpoint-diff <id> <expression>
+
+The recording is restarted at the checkpoint, the expression is evaluated in the same goroutine and frame and then the recording is returned to the current position. Both values are printed, followed by the fields, elements and map entries that differ between them.`,
 			},
 			command{
 				aliases: []string{"rev"},
@@ -614,9 +884,26 @@ func (c *Commands) CallWithContext(cmdstr string, t *Term, ctx callContext) erro
 	if len(vals) > 1 {
 		args = strings.TrimSpace(vals[1])
 	}
+	if ctx.Prefix == onPrefix {
+		if cmd := c.findCommand(cmdname); cmd != nil && cmd.allowedPrefixes&onPrefix == 0 {
+			// not a breakpoint attribute, add it to the breakpoint script
+			ctx.Breakpoint.Commands = append(ctx.Breakpoint.Commands, strings.TrimSpace(cmdstr))
+			return nil
+		}
+	}
 	return c.Find(cmdname, ctx.Prefix)(t, ctx, args)
 }
 
+// findCommand returns the command matching cmdstr, or nil.
+func (c *Commands) findCommand(cmdstr string) *command {
+	for i := range c.cmds {
+		if c.cmds[i].match(cmdstr) {
+			return &c.cmds[i]
+		}
+	}
+	return nil
+}
+
 // Call takes a command to execute.
 func (c *Commands) Call(cmdstr string, t *Term) error {
 	ctx := callContext{Prefix: noPrefix, Scope: api.EvalScope{GoroutineID: -1, Frame: c.frame, DeferredCall: 0}}
@@ -1396,7 +1683,7 @@ func printcontextNoState(t *Term) {
 
 func (c *Commands) rebuild(t *Term, ctx callContext, args string) error {
 	if ctx.Prefix == revPrefix {
-		return c.rewind(t, ctx, args)
+		return c.rewind(t, ctx, "")
 	}
 	defer t.onStop()
 	discarded, err := t.client.Restart(true)
@@ -1407,6 +1694,22 @@ func (c *Commands) rebuild(t *Term, ctx callContext, args string) error {
 }
 
 func (c *Commands) cont(t *Term, ctx callContext, args string) error {
+	if ctx.Prefix == revPrefix {
+		return c.rewind(t, ctx, args)
+	}
+	var deadline time.Time
+	if strings.HasPrefix(args, "-timeout") {
+		v := strings.SplitN(strings.TrimSpace(args[len("-timeout"):]), " ", 2)
+		timeout, err := time.ParseDuration(v[0])
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("wrong argument to -timeout: %q", v[0])
+		}
+		deadline = time.Now().Add(timeout)
+		args = ""
+		if len(v) > 1 {
+			args = strings.TrimSpace(v[1])
+		}
+	}
 	if args != "" {
 		tmp, err := setBreakpoint(t, ctx, false, args)
 		if err != nil {
@@ -1420,48 +1723,102 @@ func (c *Commands) cont(t *Term, ctx callContext, args string) error {
 			}
 		}()
 	}
-	if ctx.Prefix == revPrefix {
-		return c.rewind(t, ctx, args)
-	}
 	defer t.onStop()
 	c.frame = 0
-	stateChan := t.client.Continue()
-	var state *api.DebuggerState
-	for state = range stateChan {
-		if state.Err != nil {
-			printcontextNoState(t)
-			return state.Err
+	for {
+		var stateChan <-chan *api.DebuggerState
+		if deadline.IsZero() {
+			stateChan = t.client.Continue()
+		} else {
+			timeout := time.Until(deadline)
+			if timeout <= 0 {
+				timeout = time.Nanosecond
+			}
+			stateChan = t.client.ContinueWithTimeout(timeout)
+		}
+		var state *api.DebuggerState
+		for state = range stateChan {
+			if state.Err != nil {
+				printcontextNoState(t)
+				return state.Err
+			}
+			printcontext(t, state)
+		}
+		if state.StopReason == "timeout" {
+			fmt.Println("timeout expired")
+		}
+		printfile(t, state.CurrentThread.File, state.CurrentThread.Line, true)
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return nil
+		}
+		if !c.runBreakpointCommands(t, state) {
+			return nil
 		}
-		printcontext(t, state)
 	}
-	printfile(t, state.CurrentThread.File, state.CurrentThread.Line, true)
-	return nil
 }
 
-func continueUntilCompleteNext(t *Term, state *api.DebuggerState, op string, shouldPrintFile bool) error {
-	defer t.onStop()
-	if !state.NextInProgress {
-		if shouldPrintFile {
-			printfile(t, state.CurrentThread.File, state.CurrentThread.Line, true)
+// runBreakpointCommands executes the script of the breakpoint the current
+// thread is stopped at. Returns true if the last command of the script is
+// 'continue', in which case the caller should resume the target.
+// Scripts are not executed while another script is running, this prevents
+// infinite recursion when the script resumes the target.
+func (c *Commands) runBreakpointCommands(t *Term, state *api.DebuggerState) bool {
+	if t.runningBreakpointCommands || state.CurrentThread == nil || state.CurrentThread.Breakpoint == nil {
+		return false
+	}
+	cmds := state.CurrentThread.Breakpoint.Commands
+	if len(cmds) == 0 {
+		return false
+	}
+	resume := false
+	if cmd := c.findCommand(cmds[len(cmds)-1]); cmd != nil && cmd.aliases[0] == "continue" {
+		cmds = cmds[:len(cmds)-1]
+		resume = true
+	}
+	t.runningBreakpointCommands = true
+	defer func() {
+		t.runningBreakpointCommands = false
+	}()
+	for _, cmdstr := range cmds {
+		if err := c.Call(cmdstr, t); err != nil {
+			fmt.Fprintf(os.Stderr, "Command %q failed: %v\n", cmdstr, err)
+			return false
 		}
-		return nil
 	}
-	for {
+	return resume
+}
+
+// continueUntilCompleteNext resumes the target until the step operation op
+// completes, running the scripts of the breakpoints hit along the way. If
+// the target stops on a breakpoint whose script ends with 'continue' the
+// target is resumed, in the direction of op, as if by the continue command.
+func (c *Commands) continueUntilCompleteNext(t *Term, ctx callContext, state *api.DebuggerState, op string, shouldPrintFile bool) error {
+	for state.NextInProgress {
+		c.runBreakpointCommands(t, state)
 		fmt.Printf("\tbreakpoint hit during %s, continuing...\n", op)
 		stateChan := t.client.DirectionCongruentContinue()
-		var state *api.DebuggerState
 		for state = range stateChan {
 			if state.Err != nil {
 				printcontextNoState(t)
+				t.onStop()
 				return state.Err
 			}
 			printcontext(t, state)
 		}
-		if !state.NextInProgress {
-			printfile(t, state.CurrentThread.File, state.CurrentThread.Line, true)
-			return nil
+		shouldPrintFile = true
+	}
+	if shouldPrintFile {
+		printfile(t, state.CurrentThread.File, state.CurrentThread.Line, true)
+	}
+	if c.runBreakpointCommands(t, state) {
+		resumeCtx := callContext{}
+		if ctx.Prefix == revPrefix {
+			resumeCtx.Prefix = revPrefix
 		}
+		return c.cont(t, resumeCtx, "")
 	}
+	t.onStop()
+	return nil
 }
 
 func scopePrefixSwitch(t *Term, ctx callContext) error {
@@ -1487,6 +1844,16 @@ func (c *Commands) step(t *Term, ctx callContext, args string) error {
 	}
 	c.frame = 0
 	stepfn := t.client.Step
+	switch strings.TrimSpace(args) {
+	case "":
+	case "-newgoroutine":
+		if ctx.Prefix == revPrefix {
+			return errors.New("can not step into a new goroutine backwards")
+		}
+		stepfn = t.client.StepIntoNewGoroutine
+	default:
+		return fmt.Errorf("unknown argument %q", args)
+	}
 	if ctx.Prefix == revPrefix {
 		stepfn = t.client.ReverseStep
 	}
@@ -1496,11 +1863,84 @@ func (c *Commands) step(t *Term, ctx callContext, args string) error {
 		return err
 	}
 	printcontext(t, state)
-	return continueUntilCompleteNext(t, state, "step", true)
+	return c.continueUntilCompleteNext(t, ctx, state, "step", true)
 }
 
 var notOnFrameZeroErr = errors.New("not on topmost frame")
 
+func (c *Commands) stepInto(t *Term, ctx callContext, args string) error {
+	if err := scopePrefixSwitch(t, ctx); err != nil {
+		return err
+	}
+	if c.frame != 0 {
+		return notOnFrameZeroErr
+	}
+	tgts, err := t.client.StepIntoTargets()
+	if err != nil {
+		return err
+	}
+	args = strings.TrimSpace(args)
+	if args == "" {
+		if len(tgts) == 0 {
+			fmt.Println("No calls remaining on the current line")
+		}
+		for _, tgt := range tgts {
+			fmt.Printf("%#x\t%s\n", tgt.PC, stepIntoTargetName(tgt))
+		}
+		return nil
+	}
+	var pc uint64
+	if strings.HasPrefix(args, "*") {
+		pc, err = strconv.ParseUint(args[1:], 0, 64)
+		if err != nil {
+			return fmt.Errorf("could not parse address %q: %v", args[1:], err)
+		}
+		found := false
+		for _, tgt := range tgts {
+			if tgt.PC == pc {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("no call at %#x on the current line", pc)
+		}
+	} else {
+		var matches []api.StepIntoTarget
+		for _, tgt := range tgts {
+			if tgt.Function == nil {
+				continue
+			}
+			if tgt.Function.Name() == args || strings.HasSuffix(tgt.Function.Name(), "."+args) {
+				matches = append(matches, tgt)
+			}
+		}
+		switch {
+		case len(matches) == 0:
+			return fmt.Errorf("no call to %s on the current line", args)
+		case len(matches) > 1 && matches[0].Function.Name() != matches[1].Function.Name():
+			return fmt.Errorf("ambiguous function name %s", args)
+		}
+		// When the same function is called more than once on the line the
+		// first call is used.
+		pc = matches[0].PC
+	}
+	state, err := exitedToError(t.client.StepInto(pc))
+	if err != nil {
+		printcontextNoState(t)
+		return err
+	}
+	printcontext(t, state)
+	return c.continueUntilCompleteNext(t, ctx, state, "step-into", true)
+}
+
+func stepIntoTargetName(tgt api.StepIntoTarget) string {
+	if tgt.Function == nil {
+		return "<indirect call>"
+	}
+	return tgt.Function.Name()
+}
+
 func (c *Commands) stepInstruction(t *Term, ctx callContext, args string) error {
 	if err := scopePrefixSwitch(t, ctx); err != nil {
 		return err
@@ -1509,13 +1949,35 @@ func (c *Commands) stepInstruction(t *Term, ctx callContext, args string) error
 		return notOnFrameZeroErr
 	}
 
+	count, over := 1, false
+	for _, arg := range strings.Fields(args) {
+		switch arg {
+		case "-over":
+			over = true
+		default:
+			n, err := strconv.Atoi(arg)
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid instruction count %q", arg)
+			}
+			count = n
+		}
+	}
+	if over && ctx.Prefix == revPrefix {
+		return errors.New("can not step over calls backwards")
+	}
+
 	defer t.onStop()
 
 	var fn func() (*api.DebuggerState, error)
-	if ctx.Prefix == revPrefix {
+	switch {
+	case ctx.Prefix == revPrefix && count == 1:
 		fn = t.client.ReverseStepInstruction
-	} else {
+	case ctx.Prefix == revPrefix:
+		fn = func() (*api.DebuggerState, error) { return t.client.ReverseStepInstructions(count) }
+	case count == 1 && !over:
 		fn = t.client.StepInstruction
+	default:
+		fn = func() (*api.DebuggerState, error) { return t.client.StepInstructions(count, over) }
 	}
 
 	state, err := exitedToError(fn())
@@ -1523,6 +1985,9 @@ func (c *Commands) stepInstruction(t *Term, ctx callContext, args string) error
 		printcontextNoState(t)
 		return err
 	}
+	if count > 1 && state.StepInstructionCount < count {
+		fmt.Printf("stopped after %d of %d instructions\n", state.StepInstructionCount, count)
+	}
 	printcontext(t, state)
 	printfile(t, state.CurrentThread.File, state.CurrentThread.Line, true)
 	return nil
@@ -1568,7 +2033,7 @@ func (c *Commands) next(t *Term, ctx callContext, args string) error {
 		if finishedNext {
 			printcontext(t, state)
 		}
-		if err := continueUntilCompleteNext(t, state, "next", finishedNext); err != nil {
+		if err := c.continueUntilCompleteNext(t, ctx, state, "next", finishedNext); err != nil {
 			return err
 		}
 	}
@@ -1584,6 +2049,13 @@ func (c *Commands) stepout(t *Term, ctx callContext, args string) error {
 	}
 
 	stepoutfn := t.client.StepOut
+	switch strings.TrimSpace(args) {
+	case "":
+	case "-iterator":
+		stepoutfn = t.client.StepOutIterator
+	default:
+		return fmt.Errorf("unknown argument %q", args)
+	}
 	if ctx.Prefix == revPrefix {
 		stepoutfn = t.client.ReverseStepOut
 	}
@@ -1594,7 +2066,7 @@ func (c *Commands) stepout(t *Term, ctx callContext, args string) error {
 		return err
 	}
 	printcontext(t, state)
-	return continueUntilCompleteNext(t, state, "stepout", true)
+	return c.continueUntilCompleteNext(t, ctx, state, "stepout", true)
 }
 
 func (c *Commands) call(t *Term, ctx callContext, args string) error {
@@ -1614,7 +2086,27 @@ func (c *Commands) call(t *Term, ctx callContext, args string) error {
 		return err
 	}
 	printcontext(t, state)
-	return continueUntilCompleteNext(t, state, "call", true)
+	return c.continueUntilCompleteNext(t, ctx, state, "call", true)
+}
+
+func (c *Commands) until(t *Term, ctx callContext, args string) error {
+	if err := scopePrefixSwitch(t, ctx); err != nil {
+		return err
+	}
+	if c.frame != 0 {
+		return notOnFrameZeroErr
+	}
+	args = strings.TrimSpace(args)
+	if args == "" {
+		return errors.New("not enough arguments")
+	}
+	state, err := exitedToError(t.client.Until(ctx.Scope.GoroutineID, args))
+	if err != nil {
+		printcontextNoState(t)
+		return err
+	}
+	printcontext(t, state)
+	return c.continueUntilCompleteNext(t, ctx, state, "until", true)
 }
 
 func clear(t *Term, ctx callContext, args string) error {
@@ -1636,6 +2128,21 @@ func clear(t *Term, ctx callContext, args string) error {
 }
 
 func clearAll(t *Term, ctx callContext, args string) error {
+	if strings.HasPrefix(args, "-group") {
+		group := strings.TrimSpace(args[len("-group"):])
+		if group == "" {
+			return fmt.Errorf("not enough arguments")
+		}
+		bps, err := t.client.ClearBreakpointGroup(group)
+		if err != nil {
+			return err
+		}
+		for _, bp := range bps {
+			fmt.Printf("%s cleared at %s\n", formatBreakpointName(bp, true), t.formatBreakpointLocation(bp))
+		}
+		return nil
+	}
+
 	breakPoints, err := t.client.ListBreakpoints()
 	if err != nil {
 		return err
@@ -1680,6 +2187,20 @@ func toggle(t *Term, ctx callContext, args string) error {
 	if args == "" {
 		return fmt.Errorf("not enough arguments")
 	}
+	if strings.HasPrefix(args, "-group") {
+		group := strings.TrimSpace(args[len("-group"):])
+		if group == "" {
+			return fmt.Errorf("not enough arguments")
+		}
+		bps, err := t.client.ToggleBreakpointGroup(group)
+		if err != nil {
+			return err
+		}
+		for _, bp := range bps {
+			fmt.Printf("%s toggled at %s\n", formatBreakpointName(bp, true), t.formatBreakpointLocation(bp))
+		}
+		return nil
+	}
 	id, err := strconv.Atoi(args)
 	var bp *api.Breakpoint
 	if err == nil {
@@ -1694,6 +2215,117 @@ func toggle(t *Term, ctx callContext, args string) error {
 	return nil
 }
 
+func logpointCmd(t *Term, ctx callContext, argstr string) error {
+	if ctx.Prefix == onPrefix {
+		ctx.Breakpoint.Logpoint = strings.TrimSpace(argstr)
+		return nil
+	}
+	args := split2PartsBySpace(argstr)
+	if args[0] == "" {
+		return fmt.Errorf("not enough arguments")
+	}
+	bp, err := getBreakpointByIDOrName(t, args[0])
+	if err != nil {
+		return err
+	}
+	bp.Logpoint = ""
+	if len(args) > 1 {
+		bp.Logpoint = strings.TrimSpace(args[1])
+	}
+	return t.client.AmendBreakpoint(bp)
+}
+
+func hitsCmd(t *Term, ctx callContext, argstr string) error {
+	args := split2PartsBySpace(argstr)
+	if args[0] == "" {
+		return fmt.Errorf("not enough arguments")
+	}
+	if args[0] == "-size" {
+		if len(args) < 2 {
+			return fmt.Errorf("not enough arguments")
+		}
+		args = split2PartsBySpace(args[1])
+		if len(args) < 2 {
+			return fmt.Errorf("not enough arguments")
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid hit history size %q", args[0])
+		}
+		bp, err := getBreakpointByIDOrName(t, args[1])
+		if err != nil {
+			return err
+		}
+		bp.HitHistorySize = n
+		return t.client.AmendBreakpoint(bp)
+	}
+
+	bp, err := getBreakpointByIDOrName(t, strings.TrimSpace(argstr))
+	if err != nil {
+		return err
+	}
+	if bp.HitHistorySize == 0 {
+		return fmt.Errorf("the hit history of %s is disabled, use 'hits -size <n> %s' to enable it", formatBreakpointName(bp, false), strings.TrimSpace(argstr))
+	}
+	hits, err := t.client.BreakpointHitHistory(bp.ID)
+	if err != nil {
+		return err
+	}
+	if len(hits) == 0 {
+		fmt.Println("No hits recorded")
+		return nil
+	}
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 4, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "Time\tGoroutine\tThread\tPC\tLocation")
+	for _, hit := range hits {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%#x\t%s:%d\n", hit.Time.Format("15:04:05.000000"), hit.GoroutineID, hit.ThreadID, hit.PC, t.formatPath(hit.File), hit.Line)
+	}
+	w.Flush()
+	return nil
+}
+
+func groupCmd(t *Term, ctx callContext, argstr string) error {
+	if ctx.Prefix == onPrefix {
+		ctx.Breakpoint.Group = strings.TrimSpace(argstr)
+		return nil
+	}
+	args := split2PartsBySpace(argstr)
+	if args[0] == "" {
+		return fmt.Errorf("not enough arguments")
+	}
+	bp, err := getBreakpointByIDOrName(t, args[0])
+	if err != nil {
+		return err
+	}
+	bp.Group = ""
+	if len(args) > 1 {
+		bp.Group = strings.TrimSpace(args[1])
+	}
+	return t.client.AmendBreakpoint(bp)
+}
+
+func clearHitCount(t *Term, ctx callContext, args string) error {
+	if args == "" {
+		if err := t.client.ResetAllBreakpointHitCounts(); err != nil {
+			return err
+		}
+		fmt.Printf("Hit counts of all breakpoints reset\n")
+		return nil
+	}
+	id, err := strconv.Atoi(args)
+	if err == nil {
+		err = t.client.ResetBreakpointHitCounts(id)
+	} else {
+		err = t.client.ResetBreakpointHitCountsByName(args)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Hit counts of breakpoint %s reset\n", args)
+	return nil
+}
+
 // byID sorts breakpoints by ID.
 type byID []*api.Breakpoint
 
@@ -1702,6 +2334,14 @@ func (a byID) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a byID) Less(i, j int) bool { return a[i].ID < a[j].ID }
 
 func breakpoints(t *Term, ctx callContext, args string) error {
+	verbose := false
+	switch strings.TrimSpace(args) {
+	case "":
+	case "-verbose":
+		verbose = true
+	default:
+		return fmt.Errorf("wrong argument %q", args)
+	}
 	breakPoints, err := t.client.ListBreakpoints()
 	if err != nil {
 		return err
@@ -1709,12 +2349,37 @@ func breakpoints(t *Term, ctx callContext, args string) error {
 	sort.Sort(byID(breakPoints))
 	for _, bp := range breakPoints {
 		fmt.Printf("%s at %v (%d)\n", formatBreakpointName(bp, true), t.formatBreakpointLocation(bp), bp.TotalHitCount)
+		if !bp.LastHit.IsZero() {
+			fmt.Printf("\t%s\n", formatBreakpointLastHit(bp))
+		}
+		if bp.AutoDisabled {
+			fmt.Printf("\tauto-disabled after %d hits\n", bp.MaxHits)
+		}
+		if bp.Hardware {
+			fmt.Printf("\thardware breakpoint\n")
+		}
+		if bp.WatchSuspended {
+			fmt.Printf("\tsuspended, %s can not be evaluated\n", bp.WatchExpr)
+		}
+		if bp.AutoCheckpoint > 0 {
+			fmt.Printf("\t%s\n", formatAutoCheckpoint(bp))
+		}
 
 		attrs := formatBreakpointAttrs("\t", bp, false)
 
 		if len(attrs) > 0 {
 			fmt.Printf("%s\n", strings.Join(attrs, "\n"))
 		}
+
+		if verbose && bp.ID > 0 {
+			pbps, err := t.client.PhysicalBreakpoints(bp.ID)
+			if err != nil {
+				return err
+			}
+			for _, pbp := range pbps {
+				fmt.Printf("\t%s\n", formatPhysicalBreakpoint(pbp))
+			}
+		}
 	}
 	return nil
 }
@@ -1722,10 +2387,30 @@ func breakpoints(t *Term, ctx callContext, args string) error {
 func formatBreakpointAttrs(prefix string, bp *api.Breakpoint, includeTrace bool) []string {
 	var attrs []string
 	if bp.Cond != "" {
-		attrs = append(attrs, fmt.Sprintf("%scond %s", prefix, bp.Cond))
+		if bp.CondCalls {
+			attrs = append(attrs, fmt.Sprintf("%scond -calls %s", prefix, bp.Cond))
+		} else {
+			attrs = append(attrs, fmt.Sprintf("%scond %s", prefix, bp.Cond))
+		}
 	}
 	if bp.HitCond != "" {
-		attrs = append(attrs, fmt.Sprintf("%scond -hitcount %s", prefix, bp.HitCond))
+		if bp.HitCondPerG {
+			attrs = append(attrs, fmt.Sprintf("%scond -per-g-hitcount %s", prefix, bp.HitCond))
+		} else {
+			attrs = append(attrs, fmt.Sprintf("%scond -hitcount %s", prefix, bp.HitCond))
+		}
+	}
+	if bp.MaxHits > 0 {
+		attrs = append(attrs, fmt.Sprintf("%scond -maxhits %d", prefix, bp.MaxHits))
+	}
+	if bp.Group != "" {
+		attrs = append(attrs, fmt.Sprintf("%sgroup %s", prefix, bp.Group))
+	}
+	if bp.Logpoint != "" {
+		attrs = append(attrs, fmt.Sprintf("%slogpoint %s", prefix, bp.Logpoint))
+	}
+	for _, cmd := range bp.Commands {
+		attrs = append(attrs, prefix+cmd)
 	}
 	if bp.Stacktrace > 0 {
 		attrs = append(attrs, fmt.Sprintf("%sstack %d", prefix, bp.Stacktrace))
@@ -1776,7 +2461,46 @@ func setBreakpoint(t *Term, ctx callContext, tracepoint bool, argstr string) ([]
 	}
 
 	requestedBp.Tracepoint = tracepoint
+	for {
+		if strings.HasPrefix(spec, "-hw ") {
+			requestedBp.Hardware = true
+			spec = strings.TrimSpace(spec[len("-hw "):])
+		} else if strings.HasPrefix(spec, "-suspended ") {
+			requestedBp.Suspended = true
+			spec = strings.TrimSpace(spec[len("-suspended "):])
+		} else {
+			break
+		}
+	}
+	if strings.HasPrefix(spec, "-r ") || strings.HasPrefix(spec, "-package ") {
+		if strings.HasPrefix(spec, "-r ") {
+			requestedBp.FunctionRegexp = strings.TrimSpace(spec[len("-r "):])
+		} else {
+			requestedBp.Package = strings.TrimSpace(spec[len("-package "):])
+			if strings.HasPrefix(requestedBp.Package, "-no-wrappers ") {
+				requestedBp.ExcludeWrappers = true
+				requestedBp.Package = strings.TrimSpace(requestedBp.Package[len("-no-wrappers "):])
+			}
+		}
+		if tracepoint {
+			requestedBp.LoadArgs = &ShortLoadConfig
+		}
+		bp, err := t.client.CreateBreakpoint(requestedBp)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("%s set at %s\n", formatBreakpointName(bp, true), t.formatBreakpointLocation(bp))
+		printBreakpointWarnings(bp)
+		printSkippedLocations(t, bp)
+		return []*api.Breakpoint{bp}, nil
+	}
+	if strings.Contains(spec, ";") {
+		return setMultiLocationBreakpoint(t, ctx, requestedBp, strings.Split(spec, ";"))
+	}
 	locs, err := t.client.FindLocation(ctx.Scope, spec, true, t.substitutePathRules())
+	if err != nil && requestedBp.Suspended {
+		return setSuspendedBreakpoint(t, requestedBp, spec)
+	}
 	if err != nil {
 		if requestedBp.Name == "" {
 			return nil, err
@@ -1804,6 +2528,7 @@ func setBreakpoint(t *Term, ctx callContext, tracepoint bool, argstr string) ([]
 		created = append(created, bp)
 
 		fmt.Printf("%s set at %s\n", formatBreakpointName(bp, true), t.formatBreakpointLocation(bp))
+		printBreakpointWarnings(bp)
 	}
 
 	var shouldSetReturnBreakpoints bool
@@ -1842,6 +2567,75 @@ func setBreakpoint(t *Term, ctx callContext, tracepoint bool, argstr string) ([]
 	return created, nil
 }
 
+// setSuspendedBreakpoint creates a breakpoint on a location that can not
+// be found yet, the breakpoint is enabled when the target loads a shared
+// object containing the location.
+func setSuspendedBreakpoint(t *Term, requestedBp *api.Breakpoint, spec string) ([]*api.Breakpoint, error) {
+	loc, err := locspec.Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+	nloc, ok := loc.(*locspec.NormalLocationSpec)
+	if !ok {
+		return nil, fmt.Errorf("can not create a suspended breakpoint on %s", spec)
+	}
+	if nloc.LineOffset >= 0 && filepath.Ext(nloc.Base) != "" {
+		requestedBp.File = nloc.Base
+		requestedBp.Line = nloc.LineOffset
+	} else {
+		requestedBp.FunctionName = nloc.Base
+		if nloc.LineOffset > 0 {
+			requestedBp.Line = nloc.LineOffset
+		}
+	}
+	if requestedBp.Tracepoint {
+		requestedBp.LoadArgs = &ShortLoadConfig
+	}
+	bp, err := t.client.CreateBreakpoint(requestedBp)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("%s set at %s\n", formatBreakpointName(bp, true), t.formatBreakpointLocation(bp))
+	printBreakpointWarnings(bp)
+	return []*api.Breakpoint{bp}, nil
+}
+
+// setMultiLocationBreakpoint creates a single logical breakpoint covering
+// all the locations specified by specs.
+func setMultiLocationBreakpoint(t *Term, ctx callContext, requestedBp *api.Breakpoint, specs []string) ([]*api.Breakpoint, error) {
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		locs, err := t.client.FindLocation(ctx.Scope, spec, true, t.substitutePathRules())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", spec, err)
+		}
+		for _, loc := range locs {
+			if len(loc.PCs) > 0 {
+				requestedBp.Addrs = append(requestedBp.Addrs, loc.PCs...)
+			} else {
+				requestedBp.Addrs = append(requestedBp.Addrs, loc.PC)
+			}
+		}
+	}
+	if len(requestedBp.Addrs) == 0 {
+		return nil, fmt.Errorf("address required")
+	}
+	requestedBp.Addr = requestedBp.Addrs[0]
+	if requestedBp.Tracepoint {
+		requestedBp.LoadArgs = &ShortLoadConfig
+	}
+	bp, err := t.client.CreateBreakpoint(requestedBp)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("%s set at %s\n", formatBreakpointName(bp, true), t.formatBreakpointLocation(bp))
+	printBreakpointWarnings(bp)
+	return []*api.Breakpoint{bp}, nil
+}
+
 func breakpoint(t *Term, ctx callContext, args string) error {
 	_, err := setBreakpoint(t, ctx, false, args)
 	return err
@@ -1882,27 +2676,108 @@ func edit(t *Term, ctx callContext, args string) error {
 	return runEditor(fmt.Sprintf("+%d", lineno), file)
 }
 
-func watchpoint(t *Term, ctx callContext, args string) error {
-	v := strings.SplitN(args, " ", 2)
-	if len(v) != 2 {
-		return errors.New("wrong number of arguments: watch [-r|-w|-rw] <expr>")
+// watchArgs are the arguments of the watch command.
+type watchArgs struct {
+	wtype          api.WatchType
+	size           int
+	follow, notify bool
+	expr           string
+}
+
+// parseWatchArgs parses the arguments of the watch command, the options can
+// be specified in any order before the expression.
+func parseWatchArgs(args string) (watchArgs, error) {
+	const usage = "wrong number of arguments: watch [-r|-w|-rw] [-s] [-rewatch] [-follow [-notify]] <expr> or watch [-r|-w|-rw] [-s] -size <n> <address>"
+	var r watchArgs
+	rest := strings.TrimSpace(args)
+flags:
+	for {
+		v := strings.SplitN(rest, " ", 2)
+		if len(v) != 2 {
+			break
+		}
+		switch v[0] {
+		case "-r":
+			r.wtype |= api.WatchRead
+		case "-w":
+			r.wtype |= api.WatchWrite
+		case "-rw":
+			r.wtype |= api.WatchRead | api.WatchWrite
+		case "-s":
+			r.wtype |= api.WatchSoftware
+		case "-rewatch":
+			r.wtype |= api.WatchRewatch
+		case "-follow":
+			r.follow = true
+		case "-notify":
+			r.notify = true
+		case "-size":
+			w := strings.SplitN(strings.TrimSpace(v[1]), " ", 2)
+			if len(w) != 2 {
+				return r, errors.New("wrong number of arguments: watch [-r|-w|-rw] [-s] -size <n> <address>")
+			}
+			var err error
+			r.size, err = strconv.Atoi(w[0])
+			if err != nil || r.size <= 0 {
+				return r, fmt.Errorf("wrong size argument %q to watch", w[0])
+			}
+			v[1] = w[1]
+		default:
+			break flags
+		}
+		rest = strings.TrimSpace(v[1])
 	}
-	var wtype api.WatchType
-	switch v[0] {
-	case "-r":
-		wtype = api.WatchRead
-	case "-w":
-		wtype = api.WatchWrite
-	case "-rw":
-		wtype = api.WatchRead | api.WatchWrite
-	default:
-		return fmt.Errorf("wrong argument %q to watch", v[0])
+	if rest == "" || r.wtype&(api.WatchRead|api.WatchWrite) == 0 {
+		return r, errors.New(usage)
 	}
-	bp, err := t.client.CreateWatchpoint(ctx.Scope, v[1], wtype)
+	if r.notify && !r.follow {
+		return r, errors.New("-notify can only be used with -follow")
+	}
+	if r.follow && r.size != 0 {
+		return r, errors.New("-follow can not be used with -size")
+	}
+	r.expr = rest
+	return r, nil
+}
+
+func watchpoint(t *Term, ctx callContext, args string) error {
+	wargs, err := parseWatchArgs(args)
 	if err != nil {
 		return err
 	}
+	wtype := wargs.wtype
+	var bp *api.Breakpoint
+	if wargs.size != 0 {
+		addr, err := strconv.ParseUint(wargs.expr, 0, 64)
+		if err != nil {
+			return fmt.Errorf("could not parse address %q: %v", wargs.expr, err)
+		}
+		bp, err = t.client.CreateWatchpointAddr(addr, wargs.size, wtype)
+		if err != nil {
+			return err
+		}
+	} else if wargs.follow {
+		bp, err = t.client.CreateSliceWatchpoint(ctx.Scope, wargs.expr, wtype, wargs.notify)
+		if err != nil {
+			return err
+		}
+	} else {
+		bp, err = t.client.CreateWatchpoint(ctx.Scope, wargs.expr, wtype)
+		if err != nil {
+			return err
+		}
+	}
 	fmt.Printf("%s set at %s\n", formatBreakpointName(bp, true), t.formatBreakpointLocation(bp))
+	slots, err := t.client.WatchpointSlots()
+	switch {
+	case bp.WatchType&api.WatchSoftware != 0 && wtype&api.WatchSoftware == 0 && err == nil && slots.Total > 0:
+		// the hardware slots could not fit the watchpoint
+		fmt.Printf("Using software watchpoint, execution will be slower (%d of %d hardware watchpoints in use)\n", slots.Used, slots.Total)
+	case bp.WatchType&api.WatchSoftware != 0:
+		fmt.Printf("Using software watchpoint, execution will be slower\n")
+	case err == nil && slots.Total > 0:
+		fmt.Printf("%d of %d hardware watchpoints in use\n", slots.Used, slots.Total)
+	}
 	return nil
 }
 
@@ -1919,6 +2794,8 @@ func examineMemoryCmd(t *Term, ctx callContext, argstr string) error {
 	count := 1
 	size := 1
 	isExpr := false
+	typename := ""
+	rawFlags := false
 
 	// nextArg returns the next argument that is not an empty string, if any, and
 	// advances the args slice to the position after that.
@@ -1958,6 +2835,7 @@ loop:
 			if !ok {
 				return fmt.Errorf("%q is not a valid format", arg)
 			}
+			rawFlags = true
 		case "-count", "-len":
 			arg := nextArg()
 			if arg == "" {
@@ -1978,6 +2856,12 @@ loop:
 			if err != nil || size <= 0 || size > 8 {
 				return fmt.Errorf("size must be a positive integer (<=8)")
 			}
+			rawFlags = true
+		case "-t":
+			typename = nextArg()
+			if typename == "" {
+				return fmt.Errorf("expected argument after -t")
+			}
 		case "-x":
 			isExpr = true
 			break loop // remaining args are going to be interpreted as expression
@@ -1990,8 +2874,15 @@ loop:
 		}
 	}
 
-	// TODO, maybe configured by user.
-	if count*size > 1000 {
+	if typename != "" {
+		if rawFlags {
+			return fmt.Errorf("-fmt and -size can not be used with -t")
+		}
+		if count > 1000 {
+			return fmt.Errorf("count must be less than or equal to 1000")
+		}
+	} else if count*size > 1000 {
+		// TODO, maybe configured by user.
 		return fmt.Errorf("read memory range (count*size) must be less than or equal to 1000 bytes")
 	}
 
@@ -2028,6 +2919,15 @@ loop:
 		}
 	}
 
+	if typename != "" {
+		val, err := t.client.ExamineType(address, typename, int64(count), t.loadConfig())
+		if err != nil {
+			return err
+		}
+		fmt.Println(val.MultilineString("", ""))
+		return nil
+	}
+
 	memArea, isLittleEndian, err := t.client.ExamineMemory(address, count*size)
 	if err != nil {
 		return err
@@ -2047,6 +2947,20 @@ func parseFormatArg(args string) (fmtstr, argsOut string) {
 	return v[0], v[1]
 }
 
+// parseVerbArg parses a format verb flag of the print command, like -x.
+// The flag must be followed by a space and an expression, so that 'print
+// -x' still negates x.
+func parseVerbArg(args string) (verb api.FormatVerb, argsOut string) {
+	if len(args) < 3 || args[0] != '-' || args[2] != ' ' {
+		return api.FormatDefault, args
+	}
+	verb = api.FormatVerb(args[1:2])
+	if !api.ValidFormatVerb(verb) {
+		return api.FormatDefault, args
+	}
+	return verb, strings.TrimSpace(args[3:])
+}
+
 func printVar(t *Term, ctx callContext, args string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("not enough arguments")
@@ -2055,16 +2969,153 @@ func printVar(t *Term, ctx callContext, args string) error {
 		ctx.Breakpoint.Variables = append(ctx.Breakpoint.Variables, args)
 		return nil
 	}
+	if args == "-full" || strings.HasPrefix(args, "-full ") {
+		return printFull(t, ctx, strings.TrimSpace(args[len("-full"):]))
+	}
+	raw := false
+	if args == "-raw" || strings.HasPrefix(args, "-raw ") {
+		raw = true
+		args = strings.TrimSpace(args[len("-raw"):])
+	}
+	cfg := t.loadConfig()
+	if args == "-sym" || strings.HasPrefix(args, "-sym ") {
+		cfg.SymbolizePointers = true
+		args = strings.TrimSpace(args[len("-sym"):])
+	}
+	verb, args := parseVerbArg(args)
 	fmtstr, args := parseFormatArg(args)
-	val, err := t.client.EvalVariable(ctx.Scope, args, t.loadConfig())
+	if verb != api.FormatDefault && fmtstr != "" {
+		return errors.New("a format verb flag can not be used with a format argument")
+	}
+	val, err := t.client.EvalVariable(ctx.Scope, args, cfg)
 	if err != nil {
 		return err
 	}
+	if raw {
+		clearFormatted(val)
+	}
+
+	if verb == api.FormatDefault {
+		fmt.Println(val.MultilineString("", fmtstr))
+		return nil
+	}
+	out, applied := val.MultilineStringWithVerb("", verb)
+	fmt.Println(out)
+	if !applied {
+		fmt.Printf("(-%s does not apply to %s, the default format was used)\n", verb, val.Type)
+	}
+	return nil
+}
 
-	fmt.Println(val.MultilineString("", fmtstr))
+// printFullChunk is the number of bytes requested by each call to
+// ReadStringRange made by printFull.
+const printFullChunk = 1 << 20
+
+// printFull prints the whole contents of a string or byte slice, or writes
+// them to the file following '>'.
+func printFull(t *Term, ctx callContext, args string) error {
+	expr, path := args, ""
+	if i := strings.LastIndex(args, ">"); i >= 0 {
+		expr, path = strings.TrimSpace(args[:i]), strings.TrimSpace(args[i+1:])
+		if path == "" {
+			return errors.New("no file name after '>'")
+		}
+	}
+	if expr == "" {
+		return fmt.Errorf("not enough arguments")
+	}
+	val, err := t.client.EvalVariable(ctx.Scope, expr, ShortLoadConfig)
+	if err != nil {
+		return err
+	}
+	if val.Unreadable != "" {
+		return fmt.Errorf("%s is unreadable: %s", expr, val.Unreadable)
+	}
+	isbytes := (val.Kind == reflect.Slice || val.Kind == reflect.Array) && (strings.HasSuffix(val.RealType, "]uint8") || strings.HasSuffix(val.RealType, "]byte"))
+	if val.Kind != reflect.String && !isbytes {
+		return fmt.Errorf("%s is not a string or a byte slice (%s)", expr, val.Type)
+	}
+
+	var w io.Writer = os.Stdout
+	if path != "" {
+		fh, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer fh.Close()
+		w = fh
+	}
+	var n int64
+	for n < val.Len {
+		data, err := t.client.ReadStringRange(val.Base, val.Len, n, printFullChunk)
+		if _, werr := w.Write(data); werr != nil {
+			return werr
+		}
+		n += int64(len(data))
+		if err != nil {
+			if path != "" {
+				return fmt.Errorf("%d of %d bytes written to %s: %v", n, val.Len, path, err)
+			}
+			fmt.Printf("...(unreadable %v)\n", err)
+			return nil
+		}
+		if len(data) == 0 {
+			break
+		}
+	}
+	if path != "" {
+		fmt.Printf("%d bytes written to %s\n", n, path)
+	} else {
+		fmt.Println()
+	}
 	return nil
 }
 
+// clearFormatted removes the values rendered by formatters from v and its
+// children.
+func clearFormatted(v *api.Variable) {
+	v.Formatted = ""
+	for i := range v.Children {
+		clearFormatted(&v.Children[i])
+	}
+}
+
+func formatterCmd(t *Term, ctx callContext, args string) error {
+	v := strings.SplitN(strings.TrimSpace(args), " ", 2)
+	if v[0] == "" {
+		formatters, err := t.client.ListFormatters()
+		if err != nil {
+			return err
+		}
+		if len(formatters) == 0 {
+			fmt.Println("No formatters")
+			return nil
+		}
+		for _, f := range formatters {
+			if f.CallString {
+				fmt.Printf("%s: String()\n", f.TypeName)
+			} else {
+				fmt.Printf("%s: %q\n", f.TypeName, f.Format)
+			}
+		}
+		return nil
+	}
+	if len(v) < 2 || strings.TrimSpace(v[1]) == "" {
+		return errors.New("not enough arguments")
+	}
+	arg := strings.TrimSpace(v[1])
+	switch v[0] {
+	case "-clear":
+		return t.client.ClearFormatter(arg)
+	case "-string":
+		_, err := t.client.CreateFormatter(api.Formatter{TypeName: arg, CallString: true})
+		return err
+	default:
+		_, err := t.client.CreateFormatter(api.Formatter{TypeName: v[0], Format: arg})
+		return err
+	}
+}
+
 func whatisCommand(t *Term, ctx callContext, args string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("not enough arguments")
@@ -2093,6 +3144,13 @@ func whatisCommand(t *Term, ctx callContext, args string) error {
 }
 
 func setVar(t *Term, ctx callContext, args string) error {
+	const inPlacePrefix = "-inplace "
+	inPlace := false
+	if strings.HasPrefix(args, inPlacePrefix) {
+		inPlace = true
+		args = args[len(inPlacePrefix):]
+	}
+
 	// HACK: in go '=' is not an operator, we detect the error and try to recover from it by splitting the input string
 	_, err := parser.ParseExpr(args)
 	if err == nil {
@@ -2106,7 +3164,30 @@ func setVar(t *Term, ctx callContext, args string) error {
 
 	lexpr := args[:el[0].Pos.Offset]
 	rexpr := args[el[0].Pos.Offset+1:]
-	return t.client.SetVariable(ctx.Scope, lexpr, rexpr)
+	if inPlace {
+		return t.client.SetVariableInPlace(ctx.Scope, lexpr, rexpr)
+	}
+	err = t.client.SetVariable(ctx.Scope, lexpr, rexpr)
+	if err == nil || !strings.Contains(err.Error(), "because function calls are not allowed") {
+		return err
+	}
+	if ctx.Scope.Frame != 0 || ctx.Scope.DeferredCall != 0 {
+		return fmt.Errorf("%v (function calls can only be made on frame 0, use 'set -inplace' to overwrite the current value)", err)
+	}
+
+	// The new value needs to be allocated in the target (or computed by
+	// appending to a slice), let the injected call protocol do it.
+	state, err := exitedToError(t.client.Call(ctx.Scope.GoroutineID, lexpr+"="+rexpr, false))
+	if err != nil {
+		printcontextNoState(t)
+		return err
+	}
+	if th := state.CurrentThread; th == nil || !th.CallReturn || th.ReturnValues != nil {
+		// stopped at a breakpoint or the allocation panicked
+		printcontext(t, state)
+		return errors.New("set interrupted")
+	}
+	return nil
 }
 
 func printFilteredVariables(varType string, vars []api.Variable, filter string, cfg api.LoadConfig) error {
@@ -2485,32 +3566,155 @@ func disassCommand(t *Term, ctx callContext, args string) error {
 		if len(locs) != 1 {
 			return errors.New("expression specifies multiple locations")
 		}
-		disasm, disasmErr = t.client.DisassemblePC(ctx.Scope, locs[0].PC, flavor)
+		disasm, disasmErr = t.client.DisassemblePC(ctx.Scope, locs[0].PC, flavor)
+	default:
+		return disasmUsageError
+	}
+
+	if disasmErr != nil {
+		return disasmErr
+	}
+
+	disasmPrint(disasm, os.Stdout)
+
+	return nil
+}
+
+func libraries(t *Term, ctx callContext, args string) error {
+	libs, err := t.client.ListDynamicLibraries()
+	if err != nil {
+		return err
+	}
+	d := digits(len(libs))
+	for i := range libs {
+		fmt.Printf("%"+strconv.Itoa(d)+"d. %#x %s\n", i, libs[i].Address, libs[i].Path)
+	}
+	return nil
+}
+
+func skipCmd(t *Term, ctx callContext, args string) error {
+	v := strings.SplitN(strings.TrimSpace(args), " ", 2)
+	if v[0] == "" {
+		rules, err := t.client.ListStepSkips()
+		if err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			fmt.Println("No skip rules")
+			return nil
+		}
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 4, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tKind\tPattern")
+		for _, rule := range rules {
+			fmt.Fprintf(w, "%d\t%s\t%s\n", rule.ID, rule.Kind, rule.Pattern)
+		}
+		return w.Flush()
+	}
+	if len(v) < 2 || strings.TrimSpace(v[1]) == "" {
+		return errors.New("not enough arguments")
+	}
+	arg := strings.TrimSpace(v[1])
+	switch v[0] {
+	case "function", "file":
+		rule, err := t.client.CreateStepSkip(v[0], arg)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Skip rule %d: %s %s\n", rule.ID, rule.Kind, rule.Pattern)
+		return nil
+	case "delete":
+		for _, s := range strings.Fields(arg) {
+			id, err := strconv.Atoi(s)
+			if err != nil {
+				return fmt.Errorf("invalid skip rule ID %q", s)
+			}
+			if err := t.client.ClearStepSkip(id); err != nil {
+				return err
+			}
+		}
+		return nil
 	default:
-		return disasmUsageError
+		return fmt.Errorf("unknown subcommand %q", v[0])
 	}
+}
 
-	if disasmErr != nil {
-		return disasmErr
+func signalsCmd(t *Term, ctx callContext, args string) error {
+	v := strings.Fields(args)
+	if len(v) == 0 {
+		sp, err := t.client.PassSignals()
+		if err != nil {
+			return err
+		}
+		printSignalPassing(sp)
+		return nil
 	}
 
-	disasmPrint(disasm, os.Stdout)
-
-	return nil
-}
+	var pass bool
+	switch v[0] {
+	case "pass":
+		pass = true
+	case "nopass":
+		pass = false
+	default:
+		return fmt.Errorf("unknown subcommand %q", v[0])
+	}
+	if len(v) < 2 {
+		return errors.New("not enough arguments")
+	}
 
-func libraries(t *Term, ctx callContext, args string) error {
-	libs, err := t.client.ListDynamicLibraries()
+	sp, err := t.client.PassSignals()
 	if err != nil {
 		return err
 	}
-	d := digits(len(libs))
-	for i := range libs {
-		fmt.Printf("%"+strconv.Itoa(d)+"d. %#x %s\n", i, libs[i].Address, libs[i].Path)
+	if !sp.Configurable {
+		printSignalPassing(sp)
+		return errors.New("the current backend can not change the signals passed to the target")
+	}
+	sigs := make(map[int]bool)
+	for _, sig := range sp.Signals {
+		sigs[sig] = true
+	}
+	for _, arg := range v[1:] {
+		sig, err := strconv.ParseInt(arg, 0, 32)
+		if err != nil {
+			return fmt.Errorf("invalid signal %q: %v", arg, err)
+		}
+		sigs[int(sig)] = pass
+	}
+	newsigs := []int{}
+	for sig, ok := range sigs {
+		if ok {
+			newsigs = append(newsigs, sig)
+		}
+	}
+	sort.Ints(newsigs)
+	sp, err = t.client.SetPassSignals(newsigs)
+	if err != nil {
+		return err
 	}
+	printSignalPassing(sp)
 	return nil
 }
 
+func printSignalPassing(sp api.SignalPassing) {
+	switch {
+	case sp.All:
+		fmt.Println("All signals are passed to the target.")
+	case len(sp.Signals) == 0:
+		fmt.Println("No signals are passed to the target.")
+	default:
+		strs := make([]string, len(sp.Signals))
+		for i, sig := range sp.Signals {
+			strs[i] = strconv.Itoa(sig)
+		}
+		fmt.Printf("Signals passed to the target: %s\n", strings.Join(strs, " "))
+	}
+	if !sp.Configurable {
+		fmt.Println("The list of signals can not be changed with the current backend.")
+	}
+}
+
 func digits(n int) int {
 	if n <= 0 {
 		return 1
@@ -2523,6 +3727,20 @@ func printStack(t *Term, out io.Writer, stack []api.Stackframe, ind string, offs
 }
 
 func printcontext(t *Term, state *api.DebuggerState) {
+	for _, wos := range state.WatchOutOfScope {
+		if wos.LastValue == "" {
+			fmt.Printf("watchpoint on %s went out of scope at %s:%d\n", wos.Breakpoint.WatchExpr, t.formatPath(wos.Location.File), wos.Location.Line)
+			continue
+		}
+		fmt.Printf("watchpoint on %s went out of scope at %s:%d (last value %s)\n", wos.Breakpoint.WatchExpr, t.formatPath(wos.Location.File), wos.Location.Line, wos.LastValue)
+	}
+	for _, m := range state.WatchMigrations {
+		if m.NewAddr == 0 {
+			fmt.Printf("watchpoint on %s suspended: %s\n", m.Breakpoint.WatchExpr, m.Reason)
+		} else {
+			fmt.Printf("watchpoint on %s moved from %#x to %#x\n", m.Breakpoint.WatchExpr, m.OldAddr, m.NewAddr)
+		}
+	}
 	for i := range state.Threads {
 		if (state.CurrentThread != nil) && (state.Threads[i].ID == state.CurrentThread.ID) {
 			continue
@@ -2561,7 +3779,9 @@ func printcontext(t *Term, state *api.DebuggerState) {
 
 	printcontextThread(t, th)
 
-	if state.When != "" {
+	if state.Position != nil {
+		fmt.Println(state.Position)
+	} else if state.When != "" {
 		fmt.Println(state.When)
 	}
 }
@@ -2649,11 +3869,32 @@ func printcontextThread(t *Term, th *api.Thread) {
 	if th.Function != nil && th.Function.Optimized {
 		fmt.Println(optimizedFunctionWarning)
 	}
+	if th.Breakpoint.WatchField != "" {
+		sep := "."
+		if strings.HasPrefix(th.Breakpoint.WatchField, "[") {
+			sep = ""
+		}
+		fmt.Printf("\t%s%s%s changed from %#x to %#x\n", th.Breakpoint.WatchExpr, sep, th.Breakpoint.WatchField, th.Breakpoint.WatchOldValue, th.Breakpoint.WatchNewValue)
+	}
+	if th.Breakpoint.WatchRaw {
+		size := th.Breakpoint.WatchType.Size()
+		fmt.Printf("\t%s changed from %s to %s\n", th.Breakpoint.WatchExpr, formatWatchBytes(th.Breakpoint.WatchOldValue, size), formatWatchBytes(th.Breakpoint.WatchNewValue, size))
+	}
 
 	printReturnValues(th)
 	printBreakpointInfo(t, th, false)
 }
 
+// formatWatchBytes formats the first size bytes of the watched memory
+// value v, in the order they appear in memory.
+func formatWatchBytes(v uint64, size int) string {
+	buf := make([]byte, size)
+	for i := range buf {
+		buf[i] = byte(v >> (8 * uint(i)))
+	}
+	return fmt.Sprintf("[% x]", buf)
+}
+
 func printBreakpointInfo(t *Term, th *api.Thread, tracepointOnNewline bool) {
 	if th.BreakpointInfo == nil {
 		return
@@ -2844,7 +4085,13 @@ func (c *Commands) parseBreakpointAttrs(t *Term, ctx callContext, r io.Reader) e
 	ctx.Breakpoint.Stacktrace = 0
 	ctx.Breakpoint.Variables = ctx.Breakpoint.Variables[:0]
 	ctx.Breakpoint.Cond = ""
+	ctx.Breakpoint.CondCalls = false
 	ctx.Breakpoint.HitCond = ""
+	ctx.Breakpoint.HitCondPerG = false
+	ctx.Breakpoint.MaxHits = 0
+	ctx.Breakpoint.Group = ""
+	ctx.Breakpoint.Logpoint = ""
+	ctx.Breakpoint.Commands = nil
 
 	scan := bufio.NewScanner(r)
 	lineno := 0
@@ -2865,11 +4112,41 @@ func conditionCmd(t *Term, ctx callContext, argstr string) error {
 		return fmt.Errorf("not enough arguments")
 	}
 
-	if args[0] == "-hitcount" {
+	if args[0] == "-maxhits" {
+		args = split2PartsBySpace(args[1])
+		if ctx.Prefix == onPrefix {
+			n, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid maximum number of hits %q", args[0])
+			}
+			ctx.Breakpoint.MaxHits = n
+			return nil
+		}
+
+		if len(args) < 2 {
+			return fmt.Errorf("not enough arguments")
+		}
+
+		bp, err := getBreakpointByIDOrName(t, args[0])
+		if err != nil {
+			return err
+		}
+
+		bp.MaxHits, err = strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid maximum number of hits %q", args[1])
+		}
+
+		return t.client.AmendBreakpoint(bp)
+	}
+
+	if args[0] == "-hitcount" || args[0] == "-per-g-hitcount" {
 		// hitcount breakpoint
+		perG := args[0] == "-per-g-hitcount"
 
 		if ctx.Prefix == onPrefix {
 			ctx.Breakpoint.HitCond = args[1]
+			ctx.Breakpoint.HitCondPerG = perG
 			return nil
 		}
 
@@ -2884,12 +4161,24 @@ func conditionCmd(t *Term, ctx callContext, argstr string) error {
 		}
 
 		bp.HitCond = args[1]
+		bp.HitCondPerG = perG
 
 		return t.client.AmendBreakpoint(bp)
 	}
 
+	calls := false
+	if args[0] == "-calls" {
+		calls = true
+		argstr = args[1]
+		args = split2PartsBySpace(argstr)
+		if ctx.Prefix != onPrefix && len(args) < 2 {
+			return fmt.Errorf("not enough arguments")
+		}
+	}
+
 	if ctx.Prefix == onPrefix {
 		ctx.Breakpoint.Cond = argstr
+		ctx.Breakpoint.CondCalls = calls
 		return nil
 	}
 
@@ -2898,8 +4187,17 @@ func conditionCmd(t *Term, ctx callContext, argstr string) error {
 		return err
 	}
 	bp.Cond = args[1]
+	bp.CondCalls = calls
 
-	return t.client.AmendBreakpoint(bp)
+	if err := t.client.AmendBreakpoint(bp); err != nil {
+		return err
+	}
+	bp, err = t.client.GetBreakpoint(bp.ID)
+	if err != nil {
+		return err
+	}
+	printBreakpointWarnings(bp)
+	return nil
 }
 
 func (c *Commands) executeFile(t *Term, name string) error {
@@ -2931,8 +4229,27 @@ func (c *Commands) executeFile(t *Term, name string) error {
 }
 
 func (c *Commands) rewind(t *Term, ctx callContext, args string) error {
+	var addrs []uint64
+	if args != "" {
+		locs, err := t.client.FindLocation(ctx.Scope, args, true, t.substitutePathRules())
+		if err != nil {
+			return err
+		}
+		for _, loc := range locs {
+			if len(loc.PCs) > 0 {
+				addrs = append(addrs, loc.PCs...)
+			} else {
+				addrs = append(addrs, loc.PC)
+			}
+		}
+	}
 	c.frame = 0
-	stateChan := t.client.Rewind()
+	var stateChan <-chan *api.DebuggerState
+	if addrs != nil {
+		stateChan = t.client.RewindTo(addrs)
+	} else {
+		stateChan = t.client.Rewind()
+	}
 	var state *api.DebuggerState
 	for state = range stateChan {
 		if state.Err != nil {
@@ -2944,6 +4261,37 @@ func (c *Commands) rewind(t *Term, ctx callContext, args string) error {
 	return nil
 }
 
+func rewindTo(t *Term, ctx callContext, args string) error {
+	v := strings.Fields(args)
+	if len(v) < 1 || len(v) > 2 {
+		return errors.New("wrong number of arguments to rewind-to")
+	}
+	event, err := strconv.ParseInt(v[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid event number %q", v[0])
+	}
+	var ticks int64
+	if len(v) > 1 {
+		ticks, err = strconv.ParseInt(v[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid tick count %q", v[1])
+		}
+	}
+
+	if err := t.client.SeekRecording(event, ticks); err != nil {
+		return err
+	}
+
+	state, err := t.client.GetState()
+	if err != nil {
+		return err
+	}
+	printcontext(t, state)
+	printfile(t, state.CurrentThread.File, state.CurrentThread.Line, true)
+	t.onStop()
+	return nil
+}
+
 func checkpoint(t *Term, ctx callContext, args string) error {
 	if args == "" {
 		state, err := t.client.GetState()
@@ -2966,6 +4314,48 @@ func checkpoint(t *Term, ctx callContext, args string) error {
 	return nil
 }
 
+func autoCheckpointCmd(t *Term, ctx callContext, argstr string) error {
+	every, max := 1, 0
+	nostop := false
+	args := strings.Fields(argstr)
+	for len(args) > 1 && strings.HasPrefix(args[0], "-") {
+		switch args[0] {
+		case "-off":
+			every = 0
+			args = args[1:]
+			continue
+		case "-nostop":
+			nostop = true
+			args = args[1:]
+			continue
+		case "-every", "-max":
+		default:
+			return fmt.Errorf("unknown option %q", args[0])
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid argument to %s: %q", args[0], args[1])
+		}
+		if args[0] == "-every" {
+			every = n
+		} else {
+			max = n
+		}
+		args = args[2:]
+	}
+	if len(args) != 1 {
+		return errors.New("wrong number of arguments to autocheckpoint")
+	}
+	bp, err := getBreakpointByIDOrName(t, args[0])
+	if err != nil {
+		return err
+	}
+	bp.AutoCheckpoint = every
+	bp.AutoCheckpointMax = max
+	bp.AutoCheckpointOnly = nostop && every > 0
+	return t.client.AmendBreakpoint(bp)
+}
+
 func checkpoints(t *Term, ctx callContext, args string) error {
 	cps, err := t.client.ListCheckpoints()
 	if err != nil {
@@ -2973,9 +4363,17 @@ func checkpoints(t *Term, ctx callContext, args string) error {
 	}
 	w := new(tabwriter.Writer)
 	w.Init(os.Stdout, 4, 4, 2, ' ', 0)
-	fmt.Fprintln(w, "ID\tWhen\tNote")
+	fmt.Fprintln(w, "ID\tEvent\tKind\tNote")
 	for _, cp := range cps {
-		fmt.Fprintf(w, "c%d\t%s\t%s\n", cp.ID, cp.When, cp.Where)
+		when := cp.When
+		if cp.Event != 0 {
+			when = strconv.FormatInt(cp.Event, 10)
+		}
+		kind := "manual"
+		if cp.Auto {
+			kind = "auto"
+		}
+		fmt.Fprintf(w, "c%d\t%s\t%s\t%s\n", cp.ID, when, kind, cp.Where)
 	}
 	w.Flush()
 	return nil
@@ -2995,6 +4393,186 @@ func clearCheckpoint(t *Term, ctx callContext, args string) error {
 	return t.client.ClearCheckpoint(id)
 }
 
+func checkpointDiff(t *Term, ctx callContext, argstr string) error {
+	args := strings.SplitN(strings.TrimSpace(argstr), " ", 2)
+	if len(args) != 2 || strings.TrimSpace(args[1]) == "" {
+		return errors.New("not enough arguments to checkpoint-diff")
+	}
+	if args[0] == "" || args[0][0] != 'c' {
+		return errors.New("checkpoint-diff argument must be a checkpoint ID")
+	}
+	id, err := strconv.Atoi(args[0][1:])
+	if err != nil {
+		return errors.New("checkpoint-diff argument must be a checkpoint ID")
+	}
+	diffs, err := t.client.CheckpointDiff(id, ctx.Scope, []string{strings.TrimSpace(args[1])}, t.loadConfig())
+	if err != nil {
+		return err
+	}
+	for _, diff := range diffs {
+		printCheckpointDiff(args[0], diff)
+	}
+	return nil
+}
+
+func printCheckpointDiff(cpname string, diff api.CheckpointDiff) {
+	fmt.Printf("%s: %s\n", cpname, diff.Checkpoint.MultilineString("", ""))
+	fmt.Printf("current: %s\n", diff.Current.MultilineString("", ""))
+	if len(diff.Diffs) == 0 {
+		fmt.Println("No differences.")
+		return
+	}
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 4, 4, 2, ' ', 0)
+	for _, d := range diff.Diffs {
+		old, new := d.Old, d.New
+		if old == "" {
+			old = "<missing>"
+		}
+		if new == "" {
+			new = "<missing>"
+		}
+		fmt.Fprintf(w, "%s\t%s\t=> %s\n", d.Path, old, new)
+	}
+	w.Flush()
+}
+
+func pin(t *Term, ctx callContext, args string) error {
+	switch args {
+	case "":
+		// nothing to change
+	case "on":
+		t.pinnedResume = true
+	case "off":
+		t.pinnedResume = false
+	default:
+		return fmt.Errorf("wrong argument to pin: %q", args)
+	}
+	t.client.SetPinnedResume(t.pinnedResume)
+	if t.pinnedResume {
+		fmt.Println("Pinning is on, only the current goroutine will run.")
+	} else {
+		fmt.Println("Pinning is off.")
+	}
+	return nil
+}
+
+func deadlock(t *Term, ctx callContext, args string) error {
+	r, err := t.client.Deadlocks()
+	if err != nil {
+		return err
+	}
+	if len(r.Blocked) == 0 {
+		fmt.Println("No blocked goroutines.")
+		return nil
+	}
+
+	kinds := []string{}
+	count := map[string]int{}
+	for _, bg := range r.Blocked {
+		if count[bg.Kind] == 0 {
+			kinds = append(kinds, bg.Kind)
+		}
+		count[bg.Kind]++
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		fmt.Printf("%d goroutine(s) blocked on %s\n", count[kind], kind)
+	}
+	fmt.Println()
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 4, 4, 2, ' ', 0)
+	for _, bg := range r.Blocked {
+		objs := make([]string, len(bg.Objects))
+		for i := range bg.Objects {
+			objs[i] = fmt.Sprintf("%#x", bg.Objects[i])
+		}
+		fmt.Fprintf(w, "Goroutine %d\t%s %s\t%s\n", bg.Goroutine.ID, bg.Kind, strings.Join(objs, ","), t.formatLocation(bg.Location))
+	}
+	w.Flush()
+
+	if len(r.Cycles) == 0 {
+		fmt.Println("\nNo cycles found.")
+		return nil
+	}
+	for i, cycle := range r.Cycles {
+		fmt.Printf("\nCycle %d:\n", i+1)
+		for _, e := range cycle {
+			verb := "used by"
+			if e.Lock {
+				verb = "held by"
+			}
+			fmt.Printf("\tgoroutine %d waits on %s %#x %s goroutine %d\n", e.Waiter, e.Kind, e.Object, verb, e.Holder)
+		}
+	}
+	return nil
+}
+
+func mutex(t *Term, ctx callContext, args string) error {
+	if args == "" {
+		return errors.New("not enough arguments")
+	}
+	mi, err := t.client.MutexInfo(ctx.Scope, args)
+	if err != nil {
+		return err
+	}
+	kind := "sync.Mutex"
+	if mi.RW {
+		kind = "sync.RWMutex"
+	}
+	state := []string{}
+	switch {
+	case mi.Locked:
+		state = append(state, "locked")
+	case mi.RW && mi.Readers > 0:
+		state = append(state, fmt.Sprintf("%d readers", mi.Readers))
+	default:
+		state = append(state, "unlocked")
+	}
+	if mi.RW && mi.WriterPending && !mi.Locked {
+		state = append(state, "writer pending")
+	}
+	if mi.Waiters > 0 {
+		state = append(state, fmt.Sprintf("%d waiters", mi.Waiters))
+	}
+	if mi.Starving {
+		state = append(state, "starving")
+	}
+	fmt.Printf("%s %#x: %s\n", kind, mi.Addr, strings.Join(state, ", "))
+
+	printGoroutineIDs := func(label string, ids []int) {
+		if len(ids) == 0 {
+			return
+		}
+		s := make([]string, len(ids))
+		for i := range ids {
+			s[i] = strconv.Itoa(ids[i])
+		}
+		fmt.Printf("%s: goroutine %s\n", label, strings.Join(s, ", "))
+	}
+	printGoroutineIDs("Waiting", mi.Waiting)
+	printGoroutineIDs("Waiting readers", mi.WaitingReaders)
+
+	if len(mi.PossibleOwners) == 0 {
+		if mi.Locked || mi.Readers > 0 {
+			fmt.Println("Owner not found.")
+		}
+		return nil
+	}
+	fmt.Println("Possible owners:")
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 4, 4, 2, ' ', 0)
+	for _, o := range mi.PossibleOwners {
+		reader := ""
+		if o.Reader {
+			reader = " (reader)"
+		}
+		fmt.Fprintf(w, "Goroutine %d%s\t%s\n", o.GoroutineID, reader, t.formatLocation(o.Location))
+	}
+	return w.Flush()
+}
+
 func display(t *Term, ctx callContext, args string) error {
 	const (
 		addOption = "-a "
@@ -3057,6 +4635,29 @@ func dump(t *Term, ctx callContext, args string) error {
 	return nil
 }
 
+// printBreakpointWarnings prints the warnings produced while validating the
+// condition of bp.
+func printBreakpointWarnings(bp *api.Breakpoint) {
+	for _, warning := range bp.Warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+}
+
+// printSkippedLocations prints the locations of bp where a physical
+// breakpoint could not be set, for example because they already had a
+// breakpoint.
+func printSkippedLocations(t *Term, bp *api.Breakpoint) {
+	pbps, err := t.client.PhysicalBreakpoints(bp.ID)
+	if err != nil {
+		return
+	}
+	for _, pbp := range pbps {
+		if pbp.Error != "" {
+			fmt.Printf("Warning: skipped %s\n", formatPhysicalBreakpoint(pbp))
+		}
+	}
+}
+
 func formatBreakpointName(bp *api.Breakpoint, upcase bool) string {
 	thing := "breakpoint"
 	if bp.Tracepoint {
@@ -3076,13 +4677,80 @@ func formatBreakpointName(bp *api.Breakpoint, upcase bool) string {
 		return fmt.Sprintf("%s %s on [%s]", thing, id, bp.WatchExpr)
 	}
 	state := "(enabled)"
-	if bp.Disabled {
+	if bp.Suspended {
+		state = "(suspended)"
+	} else if bp.Disabled {
 		state = "(disabled)"
 	}
 	return fmt.Sprintf("%s %s %s", thing, id, state)
 }
 
+// formatBreakpointLastHit describes when bp was last hit and how often it
+// is being hit.
+func formatBreakpointLastHit(bp *api.Breakpoint) string {
+	s := fmt.Sprintf("last hit %s ago", time.Since(bp.LastHit).Round(100*time.Millisecond))
+	switch {
+	case bp.HitRate >= 10:
+		s += fmt.Sprintf(", ~%.0f hits/s", bp.HitRate)
+	case bp.HitRate >= 0.01:
+		s += fmt.Sprintf(", ~%.2g hits/s", bp.HitRate)
+	}
+	return s
+}
+
+// formatAutoCheckpoint describes the automatic checkpoints of bp.
+func formatAutoCheckpoint(bp *api.Breakpoint) string {
+	s := "checkpoint on every hit"
+	if bp.AutoCheckpoint > 1 {
+		s = fmt.Sprintf("checkpoint every %d hits", bp.AutoCheckpoint)
+	}
+	if bp.AutoCheckpointMax > 0 {
+		s += fmt.Sprintf(", keeping the last %d", bp.AutoCheckpointMax)
+	}
+	if bp.AutoCheckpointOnly {
+		s += ", without stopping"
+	}
+	return s
+}
+
+// formatPhysicalBreakpoint returns a one line description of pbp.
+func formatPhysicalBreakpoint(pbp api.PhysicalBreakpoint) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%#x", pbp.Addr)
+	if pbp.FunctionName != "" {
+		fmt.Fprintf(&buf, " in %s", pbp.FunctionName)
+	}
+	if pbp.File != "" {
+		fmt.Fprintf(&buf, " at %s:%d", pbp.File, pbp.Line)
+	}
+	switch {
+	case pbp.Error != "":
+		fmt.Fprintf(&buf, " (not set: %s)", pbp.Error)
+	case !pbp.Installed:
+		fmt.Fprintf(&buf, " (not set)")
+	case pbp.WatchType != 0 && pbp.WatchType&api.WatchSoftware == 0:
+		fmt.Fprintf(&buf, " (hardware slot %d)", pbp.HWBreakIndex)
+	}
+	return buf.String()
+}
+
 func (t *Term) formatBreakpointLocation(bp *api.Breakpoint) string {
+	if bp.FunctionRegexp != "" {
+		return fmt.Sprintf("%d addresses for functions matching /%s/", len(bp.Addrs), bp.FunctionRegexp)
+	}
+	if bp.Package != "" {
+		return fmt.Sprintf("%d functions of package %s", bp.FunctionCount, bp.Package)
+	}
+	if bp.Suspended {
+		loc := bp.FunctionName
+		if bp.File != "" {
+			loc = bp.File
+		}
+		if bp.Line > 0 {
+			loc = fmt.Sprintf("%s:%d", loc, bp.Line)
+		}
+		return fmt.Sprintf("%s (not loaded yet)", loc)
+	}
 	var out bytes.Buffer
 	if len(bp.Addrs) > 0 {
 		for i, addr := range bp.Addrs {
@@ -3096,7 +4764,16 @@ func (t *Term) formatBreakpointLocation(bp *api.Breakpoint) string {
 		// In case we are connecting to an older version of delve that does not return the Addrs field.
 		fmt.Fprintf(&out, "%#x", bp.Addr)
 	}
-	if bp.WatchExpr == "" {
+	if bp.WatchExpr == "" && len(bp.Locations) > 0 {
+		fmt.Fprintf(&out, " for %s", bp.File)
+		for _, loc := range bp.Locations {
+			fmt.Fprintf(&out, "\n\t%#x for ", loc.PC)
+			if loc.Function != nil && loc.Function.Name() != "" {
+				fmt.Fprintf(&out, "%s() ", loc.Function.Name())
+			}
+			fmt.Fprintf(&out, "%s:%d", t.formatPath(loc.File), loc.Line)
+		}
+	} else if bp.WatchExpr == "" {
 		fmt.Fprintf(&out, " for ")
 		p := t.formatPath(bp.File)
 		if bp.FunctionName != "" {
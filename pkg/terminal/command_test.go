@@ -513,6 +513,20 @@ func TestOnPrefix(t *testing.T) {
 	})
 }
 
+func TestOnPrefixScript(t *testing.T) {
+	withTestTerminal("testtoggle", t, func(term *FakeTerminal) {
+		term.MustExec("break one main.lineOne")
+		term.MustExec("break three main.lineThree")
+		term.MustExec("on one continue")
+		out := term.MustExec("breakpoints")
+		if !strings.Contains(out, "\tcontinue") {
+			t.Fatalf("script not added to breakpoint: %q", out)
+		}
+		term.MustExec("continue")
+		listIsAt(t, term, "", 16, -1, -1)
+	})
+}
+
 func TestNoVars(t *testing.T) {
 	test.AllowRecording(t)
 	withTestTerminal("locationsUpperCase", t, func(term *FakeTerminal) {
@@ -696,6 +710,38 @@ func TestReverseContinue(t *testing.T) {
 	})
 }
 
+func TestRewindTo(t *testing.T) {
+	test.AllowRecording(t)
+	if testBackend != "rr" {
+		return
+	}
+	withTestTerminal("continuetestprog", t, func(term *FakeTerminal) {
+		term.MustExec("break main.main")
+		listIsAt(t, term, "continue", 16, -1, -1)
+		state, err := term.client.GetState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Position == nil {
+			t.Fatal("no recording position")
+		}
+		event := state.Position.Event
+		term.MustExec("next")
+		term.MustExec("next")
+		term.MustExec(fmt.Sprintf("rewind-to %d", event))
+		state, err = term.client.GetState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Position == nil || state.Position.Event != event {
+			t.Fatalf("wrong position after rewind-to %d: %#v", event, state.Position)
+		}
+		if _, err := term.Exec("rewind-to"); err == nil {
+			t.Fatal("expected error for rewind-to without arguments")
+		}
+	})
+}
+
 func TestCheckpoints(t *testing.T) {
 	test.AllowRecording(t)
 	if testBackend != "rr" {
@@ -995,6 +1041,26 @@ func TestIssue1598(t *testing.T) {
 	})
 }
 
+func TestSetVarAlloc(t *testing.T) {
+	test.MustSupportFunctionCalls(t, testBackend)
+	withTestTerminal("fncall", t, func(term *FakeTerminal) {
+		term.MustExec("continue")
+		term.MustExec(`set str = "hello"`)
+		if out := strings.TrimSpace(term.MustExec("print str")); out != `"hello"` {
+			t.Errorf("wrong value of str after set: %q", out)
+		}
+		term.MustExec(`set bytebuf = []byte("abcd")`)
+		if out := strings.TrimSpace(term.MustExec("print bytebuf")); out != "[]uint8 len: 4, cap: 4, [97,98,99,100]" {
+			t.Errorf("wrong value of bytebuf after set: %q", out)
+		}
+		term.MustExec(`set -inplace bytebuf = []byte("xy")`)
+		if out := strings.TrimSpace(term.MustExec("print bytebuf")); out != "[]uint8 len: 2, cap: 4, [120,121]" {
+			t.Errorf("wrong value of bytebuf after set -inplace: %q", out)
+		}
+		term.AssertExecError(`set -inplace str = "a longer value"`, "can not overwrite value in place: new length 14 is greater than the current length 5")
+	})
+}
+
 func TestExamineMemoryCmd(t *testing.T) {
 	withTestTerminal("examinememory", t, func(term *FakeTerminal) {
 		term.MustExec("break examinememory.go:19")
@@ -1094,6 +1160,40 @@ func TestExamineMemoryCmd(t *testing.T) {
 	})
 }
 
+func TestExamineMemoryTypeCmd(t *testing.T) {
+	withTestTerminal("testvariables2", t, func(term *FakeTerminal) {
+		term.MustExec("continue")
+		tests := []struct {
+			Cmd  string
+			Want string
+		}{
+			{Cmd: "x -t main.astruct -x &c1.pb.a", Want: "main.astruct {A: 1, B: 2}"},
+			{Cmd: "examinemem -t main.astruct -x c1.pb", Want: "main.astruct {A: 1, B: 2}"},
+			{Cmd: "x -t int -count 2 -x &c1.pb.a", Want: "[2]int [1,2]"},
+			{Cmd: "x -t [2]int -x &c1.pb.a", Want: "[2]int [1,2]"},
+		}
+		for _, test := range tests {
+			res := strings.TrimSpace(term.MustExec(test.Cmd))
+			if res != test.Want {
+				t.Errorf("%q: got %q want %q", test.Cmd, res, test.Want)
+			}
+		}
+
+		addressStr := strings.TrimSpace(term.MustExec("p uintptr(c1.pb)"))
+		res := strings.TrimSpace(term.MustExec("x -t main.astruct " + addressStr))
+		if res != "main.astruct {A: 1, B: 2}" {
+			t.Errorf("got %q", res)
+		}
+
+		if _, err := term.Exec("x -t main.astruct -size 2 " + addressStr); err == nil {
+			t.Errorf("-size accepted with -t")
+		}
+		if _, err := term.Exec("x -t main.nonexistent " + addressStr); err == nil {
+			t.Errorf("nonexistent type accepted")
+		}
+	})
+}
+
 func TestPrintOnTracepoint(t *testing.T) {
 	withTestTerminal("increment", t, func(term *FakeTerminal) {
 		term.MustExec("trace main.Increment")
@@ -1181,6 +1281,57 @@ func TestPrintFormat(t *testing.T) {
 	})
 }
 
+func TestPrintFull(t *testing.T) {
+	const longstr = "very long string 0123456789a0123456789b0123456789c0123456789d0123456789e0123456789f0123456789g012345678h90123456789i0123456789j0123456789"
+	withTestTerminal("testvariables2", t, func(term *FakeTerminal) {
+		term.MustExec("continue")
+		if out := term.MustExec("print -full longstr"); out != longstr+"\n" {
+			t.Errorf("wrong output %q", out)
+		}
+		if out := term.MustExec("print -full bytearray"); out != "t\xc3\xa8st\n" {
+			t.Errorf("wrong output for bytearray %q", out)
+		}
+		if _, err := term.Exec("print -full i1"); err == nil {
+			t.Errorf("printing an integer with -full did not fail")
+		}
+
+		fh, err := ioutil.TempFile("", "printfull")
+		if err != nil {
+			t.Fatal(err)
+		}
+		fh.Close()
+		defer os.Remove(fh.Name())
+		term.MustExec("print -full longstr > " + fh.Name())
+		buf, err := ioutil.ReadFile(fh.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != longstr {
+			t.Errorf("wrong file contents %q", buf)
+		}
+	})
+}
+
+func TestPrintVerb(t *testing.T) {
+	withTestTerminal("testvariables2", t, func(term *FakeTerminal) {
+		term.MustExec("continue")
+		for _, tc := range []struct{ cmd, exp string }{
+			{"print -x i3", "0x3\n"},
+			{"print -b i3", "0b11\n"},
+			{"print -e f1", "3.000000e+00\n"},
+			{"print -e i3", "3\n(-e does not apply to int, the default format was used)\n"},
+			{"print -i1", "-1\n"},
+		} {
+			if out := term.MustExec(tc.cmd); out != tc.exp {
+				t.Errorf("%s: wrong output %q, expected %q", tc.cmd, out, tc.exp)
+			}
+		}
+		if out := term.MustExec("print -p p1"); !strings.HasPrefix(out, "(*int)(0x") {
+			t.Errorf("wrong output for pointer %q", out)
+		}
+	})
+}
+
 func TestHitCondBreakpoint(t *testing.T) {
 	withTestTerminal("break", t, func(term *FakeTerminal) {
 		term.MustExec("break bp1 main.main:4")
@@ -1262,6 +1413,36 @@ func TestBreakpointEditing(t *testing.T) {
 			"cond -hitcount % 2",
 			"cond -hitcount = 2",
 			&api.Breakpoint{HitCond: "= 2"}},
+		{ // change hitcount condition to a per goroutine hitcount condition
+			&api.Breakpoint{HitCond: "% 2"},
+			"cond -hitcount % 2",
+			"cond -per-g-hitcount == 1",
+			&api.Breakpoint{HitCond: "== 1", HitCondPerG: true}},
+		{ // change per goroutine hitcount condition to a hitcount condition
+			&api.Breakpoint{HitCond: "== 1", HitCondPerG: true},
+			"cond -per-g-hitcount == 1",
+			"cond -hitcount == 1",
+			&api.Breakpoint{HitCond: "== 1"}},
+		{ // change maximum number of hits
+			&api.Breakpoint{MaxHits: 3},
+			"cond -maxhits 3",
+			"cond -maxhits 5",
+			&api.Breakpoint{MaxHits: 5}},
+		{ // change group
+			&api.Breakpoint{Group: "netcode"},
+			"group netcode",
+			"group parser",
+			&api.Breakpoint{Group: "parser"}},
+		{ // change logpoint
+			&api.Breakpoint{Logpoint: "x = {x}"},
+			"logpoint x = {x}",
+			"logpoint y = {y}",
+			&api.Breakpoint{Logpoint: "y = {y}"}},
+		{ // change script
+			&api.Breakpoint{Commands: []string{"locals", "continue"}},
+			"locals\ncontinue",
+			"goroutines\ncontinue",
+			&api.Breakpoint{Commands: []string{"goroutines", "continue"}}},
 	}
 
 	for _, tc := range testCases {
@@ -1280,3 +1461,94 @@ func TestBreakpointEditing(t *testing.T) {
 		}
 	}
 }
+
+func TestFormatWatchBytes(t *testing.T) {
+	for _, tc := range []struct {
+		v    uint64
+		size int
+		tgt  string
+	}{
+		{0x0102, 2, "[02 01]"},
+		{0x0102, 4, "[02 01 00 00]"},
+		{0x1122334455667788, 8, "[88 77 66 55 44 33 22 11]"},
+	} {
+		if s := formatWatchBytes(tc.v, tc.size); s != tc.tgt {
+			t.Errorf("formatWatchBytes(%#x, %d): expected %q got %q", tc.v, tc.size, tc.tgt, s)
+		}
+	}
+}
+
+func TestParseWatchArgs(t *testing.T) {
+	testCases := []struct {
+		in     string
+		out    watchArgs
+		tgterr bool
+	}{
+		{"-w x", watchArgs{wtype: api.WatchWrite, expr: "x"}, false},
+		{"-s -w x", watchArgs{wtype: api.WatchWrite | api.WatchSoftware, expr: "x"}, false},
+		{"-w -s x", watchArgs{wtype: api.WatchWrite | api.WatchSoftware, expr: "x"}, false},
+		{"-follow -rw -notify s[1]", watchArgs{wtype: api.WatchRead | api.WatchWrite, follow: true, notify: true, expr: "s[1]"}, false},
+		{"-size 8 -s -w 0xc000010000", watchArgs{wtype: api.WatchWrite | api.WatchSoftware, size: 8, expr: "0xc000010000"}, false},
+		{"-w a + b", watchArgs{wtype: api.WatchWrite, expr: "a + b"}, false},
+		{"-s x", watchArgs{}, true},
+		{"-w", watchArgs{}, true},
+		{"-w -notify x", watchArgs{}, true},
+	}
+
+	for _, tc := range testCases {
+		out, err := parseWatchArgs(tc.in)
+		if tc.tgterr {
+			if err == nil {
+				t.Errorf("%q: expected error, got %#v", tc.in, out)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tc.in, err)
+			continue
+		}
+		if out != tc.out {
+			t.Errorf("%q: expected %#v got %#v", tc.in, tc.out, out)
+		}
+	}
+}
+
+func TestSignalsCmd(t *testing.T) {
+	if testBackend != "native" {
+		t.Skip("test only works with the native backend")
+	}
+	withTestTerminal("continuetestprog", t, func(term *FakeTerminal) {
+		out := term.MustExec("signals")
+		if !strings.Contains(out, "All signals are passed to the target.") {
+			t.Fatalf("wrong output: %q", out)
+		}
+		if _, err := term.Exec("signals pass 10"); err == nil {
+			t.Fatalf("expected error changing signals with the native backend")
+		}
+		if _, err := term.Exec("signals stop 10"); err == nil {
+			t.Fatalf("expected error for unknown subcommand")
+		}
+	})
+}
+
+func TestPrintSymbolize(t *testing.T) {
+	withTestTerminal("testvariables2", t, func(term *FakeTerminal) {
+		term.MustExec("continue")
+		tests := []struct {
+			Cmd  string
+			Want string
+		}{
+			{Cmd: "print fn1", Want: `^main\.afunc$`},
+			{Cmd: "print -sym fn1", Want: `^0x[0-9a-f]+ <main\.afunc>$`},
+			{Cmd: "print -sym fn2", Want: `^nil$`},
+			{Cmd: "print -sym rettm", Want: `^0x[0-9a-f]+ <main\.main\.func\d+>$`},
+			{Cmd: "print -sym up1", Want: `^unsafe\.Pointer\(0x[0-9a-f]+\)$`},
+		}
+		for _, test := range tests {
+			res := strings.TrimSpace(term.MustExec(test.Cmd))
+			if !regexp.MustCompile(test.Want).MatchString(res) {
+				t.Errorf("%q: got %q want %q", test.Cmd, res, test.Want)
+			}
+		}
+	})
+}
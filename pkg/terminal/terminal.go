@@ -67,6 +67,14 @@ type Term struct {
 	// should be resumed before quitting.
 	quitContinue bool
 
+	// runningBreakpointCommands is true while the script of a breakpoint is
+	// being executed.
+	runningBreakpointCommands bool
+
+	// pinnedResume is true if resume commands only run the current
+	// goroutine, see the pin command.
+	pinnedResume bool
+
 	longCommandMu         sync.Mutex
 	longCommandCancelFlag bool
 
@@ -219,6 +227,16 @@ func (t *Term) Run() (int, error) {
 			}
 			return
 		}
+		if strings.HasPrefix(line, "step-into ") {
+			filter := strings.TrimLeft(line[len("step-into "):], " ")
+			tgts, _ := t.client.StepIntoTargets()
+			for _, tgt := range tgts {
+				if tgt.Function != nil && strings.HasPrefix(tgt.Function.Name(), filter) {
+					c = append(c, "step-into "+tgt.Function.Name())
+				}
+			}
+			return
+		}
 		for _, cmd := range t.cmds.cmds {
 			for _, alias := range cmd.aliases {
 				if strings.HasPrefix(alias, strings.ToLower(line)) {
@@ -453,6 +471,9 @@ func (t *Term) loadConfig() api.LoadConfig {
 	if t.conf != nil && t.conf.MaxVariableRecurse != nil {
 		r.MaxVariableRecurse = *t.conf.MaxVariableRecurse
 	}
+	if t.conf != nil {
+		r.CallStringMethods = t.conf.CallStringMethods
+	}
 
 	return r
 }
@@ -498,9 +519,28 @@ func (t *Term) printDisplays() {
 }
 
 func (t *Term) onStop() {
+	t.printLogpoints()
 	t.printDisplays()
 }
 
+func (t *Term) printLogpoints() {
+	msgs, dropped, err := t.client.GetBufferedLogpoints()
+	if err != nil {
+		return
+	}
+	if dropped > 0 {
+		fmt.Fprintf(t.stdout, "%d logpoint messages dropped\n", dropped)
+	}
+	for _, msg := range msgs {
+		fmt.Fprintln(t.stdout, FormatLogpointMessage(msg))
+	}
+}
+
+// FormatLogpointMessage returns msg formatted as the terminal prints it.
+func FormatLogpointMessage(msg api.LogpointMessage) string {
+	return fmt.Sprintf("> [%d] goroutine(%d): %s", msg.BreakpointID, msg.GoroutineID, msg.Message)
+}
+
 func (t *Term) longCommandCancel() {
 	t.longCommandMu.Lock()
 	defer t.longCommandMu.Unlock()
@@ -10,6 +10,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-delve/delve/pkg/dwarf/godwarf"
 	"github.com/go-delve/delve/pkg/dwarf/op"
@@ -20,27 +21,47 @@ import (
 // an api.Breakpoint.
 func ConvertBreakpoint(bp *proc.Breakpoint) *Breakpoint {
 	b := &Breakpoint{
-		Name:         bp.Name,
-		ID:           bp.LogicalID,
-		FunctionName: bp.FunctionName,
-		File:         bp.File,
-		Line:         bp.Line,
-		Addr:         bp.Addr,
-		Tracepoint:   bp.Tracepoint,
-		TraceReturn:  bp.TraceReturn,
-		Stacktrace:   bp.Stacktrace,
-		Goroutine:    bp.Goroutine,
-		Variables:    bp.Variables,
-		LoadArgs:     LoadConfigFromProc(bp.LoadArgs),
-		LoadLocals:   LoadConfigFromProc(bp.LoadLocals),
-		WatchExpr:    bp.WatchExpr,
-		WatchType:    WatchType(bp.WatchType),
-		Addrs:        []uint64{bp.Addr},
+		Name:            bp.Name,
+		Group:           bp.Group,
+		ID:              bp.LogicalID,
+		FunctionName:    bp.FunctionName,
+		File:            bp.File,
+		Line:            bp.Line,
+		Addr:            bp.Addr,
+		Tracepoint:      bp.Tracepoint,
+		TraceReturn:     bp.TraceReturn,
+		Stacktrace:      bp.Stacktrace,
+		Goroutine:       bp.Goroutine,
+		Variables:       bp.Variables,
+		LoadArgs:        LoadConfigFromProc(bp.LoadArgs),
+		LoadLocals:      LoadConfigFromProc(bp.LoadLocals),
+		WatchExpr:       bp.WatchExpr,
+		WatchType:       WatchType(bp.WatchType),
+		WatchField:      bp.WatchField,
+		WatchOldValue:   bp.WatchOldValue,
+		WatchNewValue:   bp.WatchNewValue,
+		WatchSuspended:  bp.WatchSuspended,
+		WatchRaw:        bp.WatchRaw,
+		Hardware:        bp.WatchType.Execute(),
+		FunctionRegexp:  bp.FunctionRegexp,
+		Package:         bp.Package,
+		ExcludeWrappers: bp.ExcludeWrappers,
+		FunctionCount:   bp.FunctionCount,
+		MaxHits:         bp.MaxHits,
+		Commands:        bp.Commands,
+		Warnings:        bp.CondWarnings,
+		Addrs:           []uint64{bp.Addr},
 	}
 
 	breaklet := bp.UserBreaklet()
 	if breaklet != nil {
 		b.TotalHitCount = breaklet.TotalHitCount
+		b.LastHit = breaklet.LastHit
+		b.HitRate = hitRate(breaklet.HitIntervals(), breaklet.LastHit, time.Now())
+		b.HitHistorySize = breaklet.HitHistorySize
+		b.AutoCheckpoint = breaklet.AutoCheckpoint
+		b.AutoCheckpointMax = breaklet.AutoCheckpointMax
+		b.AutoCheckpointOnly = breaklet.AutoCheckpointOnly
 		b.HitCount = map[string]uint64{}
 		for idx := range breaklet.HitCount {
 			b.HitCount[strconv.Itoa(idx)] = breaklet.HitCount[idx]
@@ -49,14 +70,71 @@ func ConvertBreakpoint(bp *proc.Breakpoint) *Breakpoint {
 		var buf bytes.Buffer
 		printer.Fprint(&buf, token.NewFileSet(), breaklet.Cond)
 		b.Cond = buf.String()
+		b.CondCalls = breaklet.CondCalls
 		if breaklet.HitCond != nil {
 			b.HitCond = fmt.Sprintf("%s %d", breaklet.HitCond.Op.String(), breaklet.HitCond.Val)
+			b.HitCondPerG = breaklet.HitCondPerG
+		}
+		if breaklet.Logpoint != nil {
+			b.Logpoint = breaklet.Logpoint.Format
 		}
 	}
 
 	return b
 }
 
+// ConvertLogpointMessage converts a proc.LogpointMessage into an
+// api.LogpointMessage, rendering the values of its expressions.
+func ConvertLogpointMessage(msg proc.LogpointMessage) LogpointMessage {
+	var buf strings.Builder
+	for i, v := range msg.Values {
+		buf.WriteString(msg.Text[i])
+		buf.WriteString(logpointValueString(ConvertVar(v)))
+	}
+	if len(msg.Text) > 0 {
+		buf.WriteString(msg.Text[len(msg.Text)-1])
+	}
+	return LogpointMessage{
+		BreakpointID: msg.LogicalID,
+		GoroutineID:  msg.GoroutineID,
+		Message:      buf.String(),
+	}
+}
+
+// logpointValueString returns the representation of v used in logpoint
+// messages: strings are written without quotes, like the %v verb of fmt
+// does, everything else as the print command would.
+func logpointValueString(v *Variable) string {
+	switch {
+	case v.Unreadable != "":
+		return fmt.Sprintf("<error: %s>", v.Unreadable)
+	case v.Kind == reflect.String && v.Formatted == "":
+		if v.Len > int64(len(v.Value)) {
+			return fmt.Sprintf("%s...+%d more", v.Value, v.Len-int64(len(v.Value)))
+		}
+		return v.Value
+	}
+	return v.SinglelineString()
+}
+
+// ConvertStepSkip converts a proc.StepSkip into an api.StepSkip.
+func ConvertStepSkip(rule *proc.StepSkip) *StepSkip {
+	return &StepSkip{
+		ID:      rule.ID,
+		Kind:    string(rule.Kind),
+		Pattern: rule.Pattern,
+	}
+}
+
+// ConvertFormatter converts from proc.Formatter to api.Formatter.
+func ConvertFormatter(f *proc.Formatter) *Formatter {
+	return &Formatter{
+		TypeName:   f.TypeName,
+		Format:     f.Format,
+		CallString: f.CallString,
+	}
+}
+
 // ConvertBreakpoints converts a slice of physical breakpoints into a slice
 // of logical breakpoints.
 // The input must be sorted by increasing LogicalID
@@ -67,18 +145,85 @@ func ConvertBreakpoints(bps []*proc.Breakpoint) []*Breakpoint {
 	r := make([]*Breakpoint, 0, len(bps))
 	for _, bp := range bps {
 		if len(r) > 0 {
-			if r[len(r)-1].ID == bp.LogicalID {
-				r[len(r)-1].Addrs = append(r[len(r)-1].Addrs, bp.Addr)
+			if last := r[len(r)-1]; last.ID == bp.LogicalID {
+				if len(last.Locations) == 0 {
+					last.Locations = append(last.Locations, Location{PC: last.Addr, File: last.File, Line: last.Line, Function: &Function{Name_: last.FunctionName}})
+				}
+				last.Addrs = append(last.Addrs, bp.Addr)
+				last.Locations = append(last.Locations, Location{PC: bp.Addr, File: bp.File, Line: bp.Line, Function: &Function{Name_: bp.FunctionName}})
+				if breaklet := bp.UserBreaklet(); breaklet != nil {
+					// hit counts of logical breakpoints are the sum of the hit counts
+					// of all their physical breakpoints
+					last.TotalHitCount += breaklet.TotalHitCount
+					if breaklet.LastHit.After(last.LastHit) {
+						last.LastHit = breaklet.LastHit
+					}
+					last.HitRate += hitRate(breaklet.HitIntervals(), breaklet.LastHit, time.Now())
+					if last.HitCount == nil {
+						last.HitCount = map[string]uint64{}
+					}
+					for idx, n := range breaklet.HitCount {
+						last.HitCount[strconv.Itoa(idx)] += n
+					}
+				}
 				continue
-			} else if r[len(r)-1].ID > bp.LogicalID {
+			} else if last.ID > bp.LogicalID {
 				panic("input not sorted")
 			}
 		}
 		r = append(r, ConvertBreakpoint(bp))
 	}
+	for _, bp := range r {
+		multiple := false
+		for _, loc := range bp.Locations {
+			if loc.File != bp.File || loc.Line != bp.Line {
+				multiple = true
+				break
+			}
+		}
+		if multiple {
+			bp.File = MultipleLocations
+			bp.Line = 0
+			bp.FunctionName = ""
+		} else {
+			bp.Locations = nil
+		}
+	}
 	return r
 }
 
+// hitRate returns the number of hits per second corresponding to the
+// intervals between hits, the last of which happened at lastHit. The rate
+// is computed over a window that ends now, so that it decays when the
+// breakpoint stops being hit.
+func hitRate(intervals []time.Duration, lastHit, now time.Time) float64 {
+	var total time.Duration
+	for _, d := range intervals {
+		total += d
+	}
+	if since := now.Sub(lastHit); since > 0 {
+		total += since
+	}
+	if len(intervals) == 0 || total <= 0 {
+		return 0
+	}
+	return float64(len(intervals)) / total.Seconds()
+}
+
+// ConvertPhysicalBreakpoint converts a proc.Breakpoint into an API physical
+// breakpoint.
+func ConvertPhysicalBreakpoint(bp *proc.Breakpoint) PhysicalBreakpoint {
+	return PhysicalBreakpoint{
+		Addr:         bp.Addr,
+		File:         bp.File,
+		Line:         bp.Line,
+		FunctionName: bp.FunctionName,
+		Installed:    true,
+		WatchType:    WatchType(bp.WatchType),
+		HWBreakIndex: bp.HWBreakIndex,
+	}
+}
+
 // ConvertThread converts a proc.Thread into an
 // api thread.
 func ConvertThread(th proc.Thread) *Thread {
@@ -169,6 +314,14 @@ func ConvertVar(v *proc.Variable) *Variable {
 
 		LocationExpr: v.LocationExpr.String(),
 		DeclLine:     v.DeclLine,
+		Formatted:    v.Formatted,
+
+		MapContinuation: v.MapContinuation,
+		Symbol:          v.Symbol,
+	}
+
+	if v.MapFilter != nil {
+		r.MapFilter = &MapFilterResult{Scanned: v.MapFilter.Scanned, Matched: v.MapFilter.Matched}
 	}
 
 	r.Type = PrettyTypeName(v.DwarfType)
@@ -319,6 +472,56 @@ func ConvertGoroutines(tgt *proc.Target, gs []*proc.G) []*Goroutine {
 	return goroutines
 }
 
+// ConvertDeadlockReport converts from proc.DeadlockReport to api.DeadlockReport.
+func ConvertDeadlockReport(tgt *proc.Target, r *proc.DeadlockReport) *DeadlockReport {
+	out := &DeadlockReport{
+		Blocked: make([]BlockedGoroutine, len(r.Blocked)),
+		Cycles:  make([][]WaitEdge, len(r.Cycles)),
+	}
+	for i, bg := range r.Blocked {
+		out.Blocked[i] = BlockedGoroutine{
+			Goroutine: ConvertGoroutine(tgt, bg.G),
+			Kind:      bg.Kind.String(),
+			Objects:   bg.Objects,
+		}
+		if bg.Frame != nil {
+			out.Blocked[i].Location = ConvertLocation(bg.Frame.Call)
+		}
+	}
+	for i, cycle := range r.Cycles {
+		out.Cycles[i] = make([]WaitEdge, len(cycle))
+		for j, e := range cycle {
+			out.Cycles[i][j] = WaitEdge{Waiter: e.Waiter, Holder: e.Holder, Kind: e.Kind.String(), Object: e.Object, Lock: e.Kind.IsMutex()}
+		}
+	}
+	return out
+}
+
+// ConvertMutexInfo converts from proc.MutexInfo to api.MutexInfo.
+func ConvertMutexInfo(mi *proc.MutexInfo) *MutexInfo {
+	r := &MutexInfo{
+		Addr:           mi.Addr,
+		RW:             mi.RW,
+		Locked:         mi.Locked,
+		Woken:          mi.Woken,
+		Starving:       mi.Starving,
+		Waiters:        mi.Waiters,
+		Readers:        mi.Readers,
+		WriterPending:  mi.WriterPending,
+		Waiting:        mi.Waiting,
+		WaitingReaders: mi.WaitingReaders,
+	}
+	for _, o := range mi.PossibleOwners {
+		r.PossibleOwners = append(r.PossibleOwners, MutexOwner{
+			GoroutineID:     o.G.ID,
+			Location:        ConvertLocation(o.Frame.Call),
+			Reader:          o.Reader,
+			ReferencesMutex: o.ReferencesMutex,
+		})
+	}
+	return r
+}
+
 // ConvertLocation converts from proc.Location to api.Location.
 func ConvertLocation(loc proc.Location) Location {
 	return Location{
@@ -358,6 +561,10 @@ func LoadConfigToProc(cfg *LoadConfig) *proc.LoadConfig {
 		MaxArrayValues:     cfg.MaxArrayValues,
 		MaxStructFields:    cfg.MaxStructFields,
 		MaxMapBuckets:      0, // MaxMapBuckets is set internally by pkg/proc, read its documentation for an explanation.
+		CallStringMethods:  cfg.CallStringMethods,
+		Start:              cfg.Start,
+		MapContinuation:    cfg.MapContinuation,
+		SymbolizePointers:  cfg.SymbolizePointers,
 	}
 }
 
@@ -372,6 +579,10 @@ func LoadConfigFromProc(cfg *proc.LoadConfig) *LoadConfig {
 		MaxStringLen:       cfg.MaxStringLen,
 		MaxArrayValues:     cfg.MaxArrayValues,
 		MaxStructFields:    cfg.MaxStructFields,
+		CallStringMethods:  cfg.CallStringMethods,
+		Start:              cfg.Start,
+		MapContinuation:    cfg.MapContinuation,
+		SymbolizePointers:  cfg.SymbolizePointers,
 	}
 }
 
@@ -446,3 +657,8 @@ func ConvertDumpState(dumpState *proc.DumpState) *DumpState {
 	}
 	return r
 }
+
+// ConvertSignalPassing converts from proc.SignalPassing to api.SignalPassing.
+func ConvertSignalPassing(sp proc.SignalPassing) SignalPassing {
+	return SignalPassing{Signals: sp.Signals, All: sp.All, Configurable: sp.Configurable}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"errors"
+	"go/constant"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-delve/delve/pkg/proc"
+)
+
+func TestHitRateDecays(t *testing.T) {
+	intervals := []time.Duration{time.Second, time.Second, time.Second, time.Second}
+	lastHit := time.Now()
+	for _, tc := range []struct {
+		since time.Duration
+		rate  float64
+	}{
+		{0, 1},
+		{4 * time.Second, 0.5},
+		{36 * time.Second, 0.1},
+	} {
+		if r := hitRate(intervals, lastHit, lastHit.Add(tc.since)); r != tc.rate {
+			t.Errorf("%v after the last hit: got %g hits/s, expected %g", tc.since, r, tc.rate)
+		}
+	}
+	if r := hitRate(nil, lastHit, lastHit); r != 0 {
+		t.Errorf("rate without hits: %g", r)
+	}
+}
+
+func TestConvertLogpointMessage(t *testing.T) {
+	msg := ConvertLogpointMessage(proc.LogpointMessage{
+		LogicalID:   1,
+		GoroutineID: 2,
+		Text:        []string{"s=", " n=", " err=", ""},
+		Values: []*proc.Variable{
+			{Kind: reflect.String, Value: constant.MakeString("abc"), Len: 5},
+			{Kind: reflect.Int, Value: constant.MakeInt64(42)},
+			{Unreadable: errors.New("could not find symbol value for x")},
+		},
+	})
+	const tgt = "s=abc...+2 more n=42 err=<error: could not find symbol value for x>"
+	if msg.BreakpointID != 1 || msg.GoroutineID != 2 || msg.Message != tgt {
+		t.Errorf("wrong message %#v, expected %q", msg, tgt)
+	}
+}
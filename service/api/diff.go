@@ -0,0 +1,107 @@
+package api
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// DiffVariables returns the differences between old and new, two values
+// of expr. Structs, arrays, slices, maps and interfaces are compared
+// member by member, pointers are compared by address and, if it is the
+// same, by the value they point to. Everything else is compared by its
+// single line representation.
+// Only loaded children are compared.
+func DiffVariables(expr string, old, new *Variable) []VariableDiff {
+	var r []VariableDiff
+	diffVariables(expr, old, new, &r)
+	return r
+}
+
+func diffVariables(path string, old, new *Variable, r *[]VariableDiff) {
+	leaf := func() {
+		if s1, s2 := old.SinglelineString(), new.SinglelineString(); s1 != s2 {
+			*r = append(*r, VariableDiff{Path: path, Old: s1, New: s2})
+		}
+	}
+
+	if old.Unreadable != "" || new.Unreadable != "" || old.Kind != new.Kind || old.Type != new.Type {
+		leaf()
+		return
+	}
+
+	switch old.Kind {
+	case reflect.Struct:
+		for i := range old.Children {
+			if i >= len(new.Children) {
+				break
+			}
+			diffVariables(path+"."+old.Children[i].Name, &old.Children[i], &new.Children[i], r)
+		}
+
+	case reflect.Array, reflect.Slice:
+		if old.Len != new.Len {
+			*r = append(*r, VariableDiff{Path: "len(" + path + ")", Old: fmt.Sprint(old.Len), New: fmt.Sprint(new.Len)})
+		}
+		for i := 0; i < len(old.Children) || i < len(new.Children); i++ {
+			elempath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(new.Children):
+				*r = append(*r, VariableDiff{Path: elempath, Old: old.Children[i].SinglelineString()})
+			case i >= len(old.Children):
+				*r = append(*r, VariableDiff{Path: elempath, New: new.Children[i].SinglelineString()})
+			default:
+				diffVariables(elempath, &old.Children[i], &new.Children[i], r)
+			}
+		}
+
+	case reflect.Map:
+		if old.Len != new.Len {
+			*r = append(*r, VariableDiff{Path: "len(" + path + ")", Old: fmt.Sprint(old.Len), New: fmt.Sprint(new.Len)})
+		}
+		// Children of maps are key, value pairs.
+		newValues := make(map[string]*Variable)
+		for i := 0; i+1 < len(new.Children); i += 2 {
+			newValues[new.Children[i].SinglelineString()] = &new.Children[i+1]
+		}
+		for i := 0; i+1 < len(old.Children); i += 2 {
+			key := old.Children[i].SinglelineString()
+			elempath := path + "[" + key + "]"
+			if newValue := newValues[key]; newValue != nil {
+				diffVariables(elempath, &old.Children[i+1], newValue, r)
+				delete(newValues, key)
+			} else {
+				*r = append(*r, VariableDiff{Path: elempath, Old: old.Children[i+1].SinglelineString()})
+			}
+		}
+		for i := 0; i+1 < len(new.Children); i += 2 {
+			key := new.Children[i].SinglelineString()
+			if newValues[key] != nil {
+				*r = append(*r, VariableDiff{Path: path + "[" + key + "]", New: new.Children[i+1].SinglelineString()})
+			}
+		}
+
+	case reflect.Ptr:
+		if len(old.Children) != 1 || len(new.Children) != 1 {
+			leaf()
+			return
+		}
+		if old.Children[0].Addr != new.Children[0].Addr {
+			*r = append(*r, VariableDiff{Path: path, Old: fmt.Sprintf("(%s)(%#x)", old.Type, old.Children[0].Addr), New: fmt.Sprintf("(%s)(%#x)", new.Type, new.Children[0].Addr)})
+			return
+		}
+		if old.Children[0].OnlyAddr || new.Children[0].OnlyAddr {
+			return
+		}
+		diffVariables("(*"+path+")", &old.Children[0], &new.Children[0], r)
+
+	case reflect.Interface:
+		if len(old.Children) != 1 || len(new.Children) != 1 || old.Children[0].Type != new.Children[0].Type {
+			leaf()
+			return
+		}
+		diffVariables(path, &old.Children[0], &new.Children[0], r)
+
+	default:
+		leaf()
+	}
+}
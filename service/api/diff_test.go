@@ -0,0 +1,57 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffVariables(t *testing.T) {
+	intv := func(name, val string) Variable {
+		return Variable{Name: name, Type: "int", Kind: reflect.Int, Value: val}
+	}
+	structv := func(a, b string) Variable {
+		return Variable{Type: "main.T", Kind: reflect.Struct, Children: []Variable{intv("A", a), intv("B", b)}}
+	}
+	slicev := func(vals ...string) Variable {
+		v := Variable{Type: "[]int", Kind: reflect.Slice, Len: int64(len(vals)), Cap: int64(len(vals))}
+		for _, val := range vals {
+			v.Children = append(v.Children, intv("", val))
+		}
+		return v
+	}
+	mapv := func(kvs ...string) Variable {
+		v := Variable{Type: "map[int]int", Kind: reflect.Map, Len: int64(len(kvs) / 2)}
+		for _, kv := range kvs {
+			v.Children = append(v.Children, intv("", kv))
+		}
+		return v
+	}
+	ptrv := func(addr uint64, child Variable) Variable {
+		child.Addr = addr
+		return Variable{Type: "*main.T", Kind: reflect.Ptr, Children: []Variable{child}}
+	}
+
+	testCases := []struct {
+		name     string
+		old, new Variable
+		exp      []VariableDiff
+	}{
+		{"equal", structv("1", "2"), structv("1", "2"), nil},
+		{"scalar", intv("x", "1"), intv("x", "2"), []VariableDiff{{"x", "1", "2"}}},
+		{"struct field", structv("1", "2"), structv("1", "3"), []VariableDiff{{"x.B", "2", "3"}}},
+		{"slice", slicev("1", "2"), slicev("1", "3", "4"), []VariableDiff{{"len(x)", "2", "3"}, {"x[1]", "2", "3"}, {"x[2]", "", "4"}}},
+		{"map", mapv("1", "10", "2", "20"), mapv("2", "21", "3", "30"), []VariableDiff{{"x[1]", "10", ""}, {"x[2]", "20", "21"}, {"x[3]", "", "30"}}},
+		{"same pointer", ptrv(0x100, structv("1", "2")), ptrv(0x100, structv("2", "2")), []VariableDiff{{"(*x).A", "1", "2"}}},
+		{"different pointer", ptrv(0x100, structv("1", "2")), ptrv(0x200, structv("1", "2")), []VariableDiff{{"x", "(*main.T)(0x100)", "(*main.T)(0x200)"}}},
+		{"unreadable", intv("x", "1"), Variable{Name: "x", Unreadable: "could not find symbol value for x"}, []VariableDiff{{"x", "1", "(unreadable could not find symbol value for x)"}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			diffs := DiffVariables("x", &tc.old, &tc.new)
+			if !reflect.DeepEqual(diffs, tc.exp) {
+				t.Errorf("wrong diff:\ngot:      %#v\nexpected: %#v", diffs, tc.exp)
+			}
+		})
+	}
+}
@@ -21,30 +21,146 @@ const (
 // SinglelineString returns a representation of v on a single line.
 func (v *Variable) SinglelineString() string {
 	var buf bytes.Buffer
-	v.writeTo(&buf, true, false, true, "", "")
+	v.writeTo(&buf, true, false, true, "", nil)
 	return buf.String()
 }
 
 // SinglelineStringFormatted returns a representation of v on a single line, using the format specified by fmtstr.
 func (v *Variable) SinglelineStringFormatted(fmtstr string) string {
 	var buf bytes.Buffer
-	v.writeTo(&buf, true, false, true, "", fmtstr)
+	v.writeTo(&buf, true, false, true, "", &printFormat{fmtstr: fmtstr})
 	return buf.String()
 }
 
 // MultilineString returns a representation of v on multiple lines.
 func (v *Variable) MultilineString(indent, fmtstr string) string {
 	var buf bytes.Buffer
-	v.writeTo(&buf, true, true, true, indent, fmtstr)
+	v.writeTo(&buf, true, true, true, indent, &printFormat{fmtstr: fmtstr})
 	return buf.String()
 }
 
-func (v *Variable) writeTo(buf io.Writer, top, newlines, includeType bool, indent, fmtstr string) {
+// SinglelineStringWithVerb returns a representation of v on a single line,
+// printing the values of v and of its children with verb. The returned
+// bool is false if verb does not apply to any of them, in which case they
+// are printed with the default format.
+func (v *Variable) SinglelineStringWithVerb(verb FormatVerb) (string, bool) {
+	var buf bytes.Buffer
+	pf := &printFormat{verb: verb}
+	v.writeTo(&buf, true, false, true, "", pf)
+	return buf.String(), pf.applied
+}
+
+// MultilineStringWithVerb returns a representation of v on multiple lines,
+// printing the values of v and of its children with verb, see
+// SinglelineStringWithVerb.
+func (v *Variable) MultilineStringWithVerb(indent string, verb FormatVerb) (string, bool) {
+	var buf bytes.Buffer
+	pf := &printFormat{verb: verb}
+	v.writeTo(&buf, true, true, true, indent, pf)
+	return buf.String(), pf.applied
+}
+
+// FormatVerb selects an alternative representation for the values of some
+// kinds, see Variable.SinglelineStringWithVerb.
+type FormatVerb string
+
+const (
+	FormatDefault    FormatVerb = ""
+	FormatHex        FormatVerb = "x" // integers in hexadecimal
+	FormatOctal      FormatVerb = "o" // integers in octal
+	FormatBinary     FormatVerb = "b" // integers in binary
+	FormatChar       FormatVerb = "c" // integers as quoted characters
+	FormatUnicode    FormatVerb = "U" // integers as Unicode code points, U+0041
+	FormatScientific FormatVerb = "e" // floating point numbers in scientific notation
+	FormatDecimal    FormatVerb = "f" // floating point numbers without exponent
+	FormatRawPointer FormatVerb = "p" // pointers as addresses, without the value they point to
+)
+
+// intVerbs and floatVerbs map the verbs applicable to integers and to
+// floating point and complex numbers to the corresponding format of the fmt
+// package.
+var (
+	intVerbs = map[FormatVerb]string{
+		FormatHex:     "%#x",
+		FormatOctal:   "%#o",
+		FormatBinary:  "%#b",
+		FormatChar:    "%q",
+		FormatUnicode: "%U",
+	}
+	floatVerbs = map[FormatVerb]string{
+		FormatScientific: "%e",
+		FormatDecimal:    "%f",
+	}
+)
+
+// ValidFormatVerb returns true if verb is one of the format verbs.
+func ValidFormatVerb(verb FormatVerb) bool {
+	return intVerbs[verb] != "" || floatVerbs[verb] != "" || verb == FormatRawPointer
+}
+
+// printFormat is the format used by writeTo, either a format string of the
+// fmt package used for all values of basic types or a verb.
+type printFormat struct {
+	fmtstr string
+	verb   FormatVerb
+	// applied is set if verb was used to print at least one value.
+	applied bool
+}
+
+// verbFormat returns the format of the fmt package used to print values of
+// kind with the verb of pf, or the empty string if it does not apply.
+func (pf *printFormat) verbFormat(kind reflect.Kind) string {
+	if pf == nil || pf.verb == FormatDefault {
+		return ""
+	}
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return intVerbs[pf.verb]
+	case reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return floatVerbs[pf.verb]
+	}
+	return ""
+}
+
+// basicFormat returns the format of the fmt package used to print a value
+// of kind, or the empty string for the default format.
+func (pf *printFormat) basicFormat(kind reflect.Kind) string {
+	if pf == nil {
+		return ""
+	}
+	if pf.verb != FormatDefault {
+		f := pf.verbFormat(kind)
+		if f != "" {
+			pf.applied = true
+		}
+		return f
+	}
+	return pf.fmtstr
+}
+
+// rawPointers returns true if pointers are printed as addresses.
+func (pf *printFormat) rawPointers() bool {
+	if pf == nil || pf.verb != FormatRawPointer {
+		return false
+	}
+	pf.applied = true
+	return true
+}
+
+func (v *Variable) writeTo(buf io.Writer, top, newlines, includeType bool, indent string, pf *printFormat) {
 	if v.Unreadable != "" {
 		fmt.Fprintf(buf, "(unreadable %s)", v.Unreadable)
 		return
 	}
 
+	if v.Formatted != "" && pf.verbFormat(v.Kind) == "" {
+		if includeType {
+			fmt.Fprintf(buf, "%s ", v.Type)
+		}
+		fmt.Fprint(buf, v.Formatted)
+		return
+	}
+
 	if !top && v.Addr == 0 && v.Value == "" {
 		if includeType && v.Type != "void" {
 			fmt.Fprintf(buf, "%s nil", v.Type)
@@ -56,13 +172,13 @@ func (v *Variable) writeTo(buf io.Writer, top, newlines, includeType bool, inden
 
 	switch v.Kind {
 	case reflect.Slice:
-		v.writeSliceTo(buf, newlines, includeType, indent, fmtstr)
+		v.writeSliceTo(buf, newlines, includeType, indent, pf)
 	case reflect.Array:
-		v.writeArrayTo(buf, newlines, includeType, indent, fmtstr)
+		v.writeArrayTo(buf, newlines, includeType, indent, pf)
 	case reflect.Ptr:
 		if v.Type == "" || len(v.Children) == 0 {
 			fmt.Fprint(buf, "nil")
-		} else if v.Children[0].OnlyAddr && v.Children[0].Addr != 0 {
+		} else if v.Children[0].Addr != 0 && (v.Children[0].OnlyAddr || pf.rawPointers()) {
 			if strings.Contains(v.Type, "/") {
 				fmt.Fprintf(buf, "(%q)(%#x)", v.Type, v.Children[0].Addr)
 			} else {
@@ -70,26 +186,32 @@ func (v *Variable) writeTo(buf io.Writer, top, newlines, includeType bool, inden
 			}
 		} else {
 			fmt.Fprint(buf, "*")
-			v.Children[0].writeTo(buf, false, newlines, includeType, indent, fmtstr)
+			v.Children[0].writeTo(buf, false, newlines, includeType, indent, pf)
 		}
 	case reflect.UnsafePointer:
 		if len(v.Children) == 0 {
 			fmt.Fprintf(buf, "unsafe.Pointer(nil)")
 		} else {
 			fmt.Fprintf(buf, "unsafe.Pointer(%#x)", v.Children[0].Addr)
+			v.writeSymbol(buf)
 		}
 	case reflect.Chan:
 		if newlines {
-			v.writeStructTo(buf, newlines, includeType, indent, fmtstr)
+			v.writeStructTo(buf, newlines, includeType, indent, pf)
 		} else {
 			if len(v.Children) == 0 {
 				fmt.Fprintf(buf, "%s nil", v.Type)
 			} else {
 				fmt.Fprintf(buf, "%s %s/%s", v.Type, v.Children[0].Value, v.Children[1].Value)
+				for i := range v.Children {
+					if v.Children[i].Name == "closed" && v.Children[i].Value != "0" {
+						fmt.Fprint(buf, " (closed)")
+					}
+				}
 			}
 		}
 	case reflect.Struct:
-		v.writeStructTo(buf, newlines, includeType, indent, fmtstr)
+		v.writeStructTo(buf, newlines, includeType, indent, pf)
 	case reflect.Interface:
 		if v.Addr == 0 {
 			// an escaped interface variable that points to nil, this shouldn't
@@ -117,7 +239,7 @@ func (v *Variable) writeTo(buf io.Writer, top, newlines, includeType bool, inden
 			} else if data.Children[0].OnlyAddr {
 				fmt.Fprintf(buf, "0x%x", v.Children[0].Addr)
 			} else {
-				v.Children[0].writeTo(buf, false, newlines, !includeType, indent, fmtstr)
+				v.Children[0].writeTo(buf, false, newlines, !includeType, indent, pf)
 			}
 		} else if data.OnlyAddr {
 			if strings.Contains(v.Type, "/") {
@@ -126,27 +248,40 @@ func (v *Variable) writeTo(buf io.Writer, top, newlines, includeType bool, inden
 				fmt.Fprintf(buf, "*(*%s)(%#x)", v.Type, v.Addr)
 			}
 		} else {
-			v.Children[0].writeTo(buf, false, newlines, !includeType, indent, fmtstr)
+			v.Children[0].writeTo(buf, false, newlines, !includeType, indent, pf)
 		}
 	case reflect.Map:
-		v.writeMapTo(buf, newlines, includeType, indent, fmtstr)
+		v.writeMapTo(buf, newlines, includeType, indent, pf)
 	case reflect.Func:
 		if v.Value == "" {
 			fmt.Fprint(buf, "nil")
+		} else if v.Symbol != "" {
+			fmt.Fprintf(buf, "%#x", v.Base)
+			v.writeSymbol(buf)
 		} else {
 			fmt.Fprintf(buf, "%s", v.Value)
 		}
 	default:
-		v.writeBasicType(buf, fmtstr)
+		v.writeBasicType(buf, pf)
+	}
+}
+
+// writeSymbol writes the function containing the code address stored in
+// v, if known, after the address.
+func (v *Variable) writeSymbol(buf io.Writer) {
+	if v.Symbol != "" {
+		fmt.Fprintf(buf, " <%s>", v.Symbol)
 	}
 }
 
-func (v *Variable) writeBasicType(buf io.Writer, fmtstr string) {
+func (v *Variable) writeBasicType(buf io.Writer, pf *printFormat) {
 	if v.Value == "" && v.Kind != reflect.String {
 		fmt.Fprintf(buf, "(unknown %s)", v.Kind)
 		return
 	}
 
+	fmtstr := pf.basicFormat(v.Kind)
+
 	switch v.Kind {
 	case reflect.Bool:
 		if fmtstr == "" {
@@ -165,12 +300,17 @@ func (v *Variable) writeBasicType(buf io.Writer, fmtstr string) {
 		fmt.Fprintf(buf, fmtstr, n)
 
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-		if fmtstr == "" {
+		if fmtstr == "" && v.Symbol == "" {
 			buf.Write([]byte(v.Value))
 			return
 		}
+		if fmtstr == "" {
+			// code addresses are printed in hexadecimal
+			fmtstr = "%#x"
+		}
 		n, _ := strconv.ParseUint(v.Value, 10, 64)
 		fmt.Fprintf(buf, fmtstr, n)
+		v.writeSymbol(buf)
 
 	case reflect.Float32, reflect.Float64:
 		if fmtstr == "" {
@@ -203,7 +343,7 @@ func (v *Variable) writeBasicType(buf io.Writer, fmtstr string) {
 	}
 }
 
-func (v *Variable) writeSliceTo(buf io.Writer, newlines, includeType bool, indent, fmtstr string) {
+func (v *Variable) writeSliceTo(buf io.Writer, newlines, includeType bool, indent string, pf *printFormat) {
 	if includeType {
 		fmt.Fprintf(buf, "%s len: %d, cap: %d, ", v.Type, v.Len, v.Cap)
 	}
@@ -211,17 +351,17 @@ func (v *Variable) writeSliceTo(buf io.Writer, newlines, includeType bool, inden
 		fmt.Fprintf(buf, "nil")
 		return
 	}
-	v.writeSliceOrArrayTo(buf, newlines, indent, fmtstr)
+	v.writeSliceOrArrayTo(buf, newlines, indent, pf)
 }
 
-func (v *Variable) writeArrayTo(buf io.Writer, newlines, includeType bool, indent, fmtstr string) {
+func (v *Variable) writeArrayTo(buf io.Writer, newlines, includeType bool, indent string, pf *printFormat) {
 	if includeType {
 		fmt.Fprintf(buf, "%s ", v.Type)
 	}
-	v.writeSliceOrArrayTo(buf, newlines, indent, fmtstr)
+	v.writeSliceOrArrayTo(buf, newlines, indent, pf)
 }
 
-func (v *Variable) writeStructTo(buf io.Writer, newlines, includeType bool, indent, fmtstr string) {
+func (v *Variable) writeStructTo(buf io.Writer, newlines, includeType bool, indent string, pf *printFormat) {
 	if int(v.Len) != len(v.Children) && len(v.Children) == 0 {
 		if strings.Contains(v.Type, "/") {
 			fmt.Fprintf(buf, "(*%q)(%#x)", v.Type, v.Addr)
@@ -244,7 +384,7 @@ func (v *Variable) writeStructTo(buf io.Writer, newlines, includeType bool, inde
 			fmt.Fprintf(buf, "\n%s%s", indent, indentString)
 		}
 		fmt.Fprintf(buf, "%s: ", v.Children[i].Name)
-		v.Children[i].writeTo(buf, false, nl, true, indent+indentString, fmtstr)
+		v.Children[i].writeTo(buf, false, nl, true, indent+indentString, pf)
 		if i != len(v.Children)-1 || nl {
 			fmt.Fprint(buf, ",")
 			if !nl {
@@ -265,7 +405,7 @@ func (v *Variable) writeStructTo(buf io.Writer, newlines, includeType bool, inde
 	fmt.Fprint(buf, "}")
 }
 
-func (v *Variable) writeMapTo(buf io.Writer, newlines, includeType bool, indent, fmtstr string) {
+func (v *Variable) writeMapTo(buf io.Writer, newlines, includeType bool, indent string, pf *printFormat) {
 	if includeType {
 		fmt.Fprintf(buf, "%s ", v.Type)
 	}
@@ -286,15 +426,15 @@ func (v *Variable) writeMapTo(buf io.Writer, newlines, includeType bool, indent,
 			fmt.Fprintf(buf, "\n%s%s", indent, indentString)
 		}
 
-		key.writeTo(buf, false, false, false, indent+indentString, fmtstr)
+		key.writeTo(buf, false, false, false, indent+indentString, pf)
 		fmt.Fprint(buf, ": ")
-		value.writeTo(buf, false, nl, false, indent+indentString, fmtstr)
+		value.writeTo(buf, false, nl, false, indent+indentString, pf)
 		if i != len(v.Children)-1 || nl {
 			fmt.Fprint(buf, ", ")
 		}
 	}
 
-	if len(v.Children)/2 != int(v.Len) {
+	if v.MapFilter == nil && len(v.Children)/2 != int(v.Len) {
 		if len(v.Children) != 0 {
 			if nl {
 				fmt.Fprintf(buf, "\n%s%s", indent, indentString)
@@ -311,6 +451,9 @@ func (v *Variable) writeMapTo(buf io.Writer, newlines, includeType bool, indent,
 		fmt.Fprintf(buf, "\n%s", indent)
 	}
 	fmt.Fprint(buf, "]")
+	if v.MapFilter != nil {
+		fmt.Fprintf(buf, " (%d of %d entries scanned, %d matched)", v.MapFilter.Scanned, v.Len, v.MapFilter.Matched)
+	}
 }
 
 func (v *Variable) shouldNewlineArray(newlines bool) bool {
@@ -380,7 +523,7 @@ func (v *Variable) shouldNewlineStruct(newlines bool) bool {
 	return false
 }
 
-func (v *Variable) writeSliceOrArrayTo(buf io.Writer, newlines bool, indent, fmtstr string) {
+func (v *Variable) writeSliceOrArrayTo(buf io.Writer, newlines bool, indent string, pf *printFormat) {
 	nl := v.shouldNewlineArray(newlines)
 	fmt.Fprint(buf, "[")
 
@@ -388,7 +531,7 @@ func (v *Variable) writeSliceOrArrayTo(buf io.Writer, newlines bool, indent, fmt
 		if nl {
 			fmt.Fprintf(buf, "\n%s%s", indent, indentString)
 		}
-		v.Children[i].writeTo(buf, false, nl, false, indent+indentString, fmtstr)
+		v.Children[i].writeTo(buf, false, nl, false, indent+indentString, pf)
 		if i != len(v.Children)-1 || nl {
 			fmt.Fprint(buf, ",")
 		}
@@ -3,6 +3,7 @@ package api
 import (
 	"fmt"
 	"math"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -57,3 +58,89 @@ func Test_byteArrayToUInt64(t *testing.T) {
 		})
 	}
 }
+
+func TestRecordingPositionString(t *testing.T) {
+	for _, tc := range []struct {
+		pos RecordingPosition
+		exp string
+	}{
+		{RecordingPosition{Event: 1234, Ticks: 5678}, "event 1234, tick 5678"},
+		{RecordingPosition{Event: 1234, Ticks: 5678, TotalEvents: 98765}, "event 1234/98765 (1.2% of recording), tick 5678"},
+		{RecordingPosition{Event: 1, AtStart: true}, "event 1, tick 0, start of recording"},
+		{RecordingPosition{Event: 98765, TotalEvents: 98765, AtEnd: true}, "event 98765/98765 (100.0% of recording), tick 0, end of recording"},
+	} {
+		if out := tc.pos.String(); out != tc.exp {
+			t.Errorf("%#v: got %q, expected %q", tc.pos, out, tc.exp)
+		}
+	}
+}
+
+func TestFormatVerb(t *testing.T) {
+	v := &Variable{
+		Type: "main.T",
+		Kind: reflect.Struct,
+		Len:  5,
+		Children: []Variable{
+			{Name: "flags", Type: "uint8", Kind: reflect.Uint8, Value: "65"},
+			{Name: "n", Type: "int", Kind: reflect.Int, Value: "-10"},
+			{Name: "f", Type: "float64", Kind: reflect.Float64, Value: "1500.25"},
+			{Name: "d", Type: "time.Duration", Kind: reflect.Int64, Value: "1500000000", Formatted: "1.5s"},
+			{Name: "p", Type: "*int", Kind: reflect.Ptr, Addr: 0xc000020000, Children: []Variable{{Addr: 0xc000010000, Type: "int", Kind: reflect.Int, Value: "1"}}},
+		},
+	}
+	for _, tc := range []struct {
+		verb    FormatVerb
+		exp     string
+		applied bool
+	}{
+		{FormatDefault, "main.T {flags: 65, n: -10, f: 1500.25, d: time.Duration 1.5s, p: *1}", false},
+		{FormatHex, "main.T {flags: 0x41, n: -0xa, f: 1500.25, d: 0x59682f00, p: *0x1}", true},
+		{FormatOctal, "main.T {flags: 0101, n: -012, f: 1500.25, d: 013132027400, p: *01}", true},
+		{FormatBinary, "main.T {flags: 0b1000001, n: -0b1010, f: 1500.25, d: 0b1011001011010000010111100000000, p: *0b1}", true},
+		{FormatChar, "main.T {flags: 'A', n: '�', f: 1500.25, d: '�', p: *'\\x01'}", true},
+		{FormatUnicode, "main.T {flags: U+0041, n: U+FFFFFFFFFFFFFFF6, f: 1500.25, d: U+59682F00, p: *U+0001}", true},
+		{FormatScientific, "main.T {flags: 65, n: -10, f: 1.500250e+03, d: time.Duration 1.5s, p: *1}", true},
+		{FormatDecimal, "main.T {flags: 65, n: -10, f: 1500.250000, d: time.Duration 1.5s, p: *1}", true},
+		{FormatRawPointer, "main.T {flags: 65, n: -10, f: 1500.25, d: time.Duration 1.5s, p: (*int)(0xc000010000)}", true},
+	} {
+		out, applied := v.SinglelineStringWithVerb(tc.verb)
+		if out != tc.exp || applied != tc.applied {
+			t.Errorf("%q: got %q %v, expected %q %v", tc.verb, out, applied, tc.exp, tc.applied)
+		}
+	}
+
+	// verbs that do not apply fall back to the default format
+	s := &Variable{Type: "string", Kind: reflect.String, Value: "hello", Len: 5}
+	if out, applied := s.SinglelineStringWithVerb(FormatHex); out != `"hello"` || applied {
+		t.Errorf("string: got %q %v", out, applied)
+	}
+}
+
+func TestSymbolizedValues(t *testing.T) {
+	v := &Variable{
+		Type: "main.Server",
+		Kind: reflect.Struct,
+		Len:  4,
+		Children: []Variable{
+			{Name: "handler", Type: "func()", Kind: reflect.Func, Value: "main.(*Server).handle", Base: 0x4a1b20, Symbol: "main.(*Server).handle"},
+			{Name: "retaddr", Type: "uintptr", Kind: reflect.Uint, Value: "4856634", Symbol: "main.main+0x1a"},
+			{Name: "code", Addr: 0xc000012010, Type: "unsafe.Pointer", Kind: reflect.UnsafePointer, Children: []Variable{{Addr: 0x4a1b3a, OnlyAddr: true}}, Symbol: "main.main+0x1a"},
+			{Name: "hooks", Addr: 0xc000012018, Type: "[]func()", Kind: reflect.Slice, Len: 2, Cap: 2, Children: []Variable{
+				{Type: "func()", Kind: reflect.Func, Value: "main.main.func1", Base: 0x4a1c00, Symbol: "main.main.func1"},
+				{Type: "func()", Kind: reflect.Func},
+			}},
+		},
+	}
+	exp := "main.Server {handler: 0x4a1b20 <main.(*Server).handle>, retaddr: 0x4a1b3a <main.main+0x1a>, code: unsafe.Pointer(0x4a1b3a) <main.main+0x1a>, hooks: []func() len: 2, cap: 2, [0x4a1c00 <main.main.func1>,nil]}"
+	if out := v.SinglelineString(); out != exp {
+		t.Errorf("got %q, expected %q", out, exp)
+	}
+
+	// format verbs still apply to symbolized integers
+	if out, _ := v.Children[1].SinglelineStringWithVerb(FormatDecimal); out != "0x4a1b3a <main.main+0x1a>" {
+		t.Errorf("retaddr: got %q", out)
+	}
+	if out, _ := v.Children[1].SinglelineStringWithVerb(FormatOctal); out != "022415472 <main.main+0x1a>" {
+		t.Errorf("retaddr: got %q", out)
+	}
+}
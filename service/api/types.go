@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"time"
 	"unicode"
 
 	"github.com/go-delve/delve/pkg/proc"
@@ -44,6 +45,24 @@ type DebuggerState struct {
 	ExitStatus int  `json:"exitStatus"`
 	// When contains a description of the current position in a recording
 	When string
+	// Position is the current position in a recording, nil if the target
+	// is not a recording.
+	Position *RecordingPosition `json:"position,omitempty"`
+	// WatchOutOfScope lists the watchpoints on stack variables that went
+	// out of scope, and were cleared, during the last resume.
+	WatchOutOfScope []WatchOutOfScope `json:"watchOutOfScope,omitempty"`
+	// WatchMigrations lists the watchpoints on slice elements that were
+	// moved to the new backing array of their slice, or suspended, during
+	// the last resume.
+	WatchMigrations []WatchMigration `json:"watchMigrations,omitempty"`
+	// StepInstructionCount is the number of instructions executed by a
+	// StepInstruction or ReverseStepInstruction command, it is smaller than
+	// the requested count if execution was stopped early by a breakpoint.
+	StepInstructionCount int `json:"stepInstructionCount,omitempty"`
+	// StopReason describes why the target stopped at the end of the
+	// command, for example "breakpoint", "manual" or "timeout" if the
+	// timeout of the command expired.
+	StopReason string `json:"stopReason,omitempty"`
 	// Filled by RPCClient.Continue, indicates an error
 	Err error `json:"-"`
 }
@@ -55,6 +74,9 @@ type Breakpoint struct {
 	ID int `json:"id"`
 	// User defined name of the breakpoint.
 	Name string `json:"name"`
+	// Group is an optional label used to enable, disable or clear a set of
+	// breakpoints together.
+	Group string `json:"group,omitempty"`
 	// Addr is deprecated, use Addrs.
 	Addr uint64 `json:"addr"`
 	// Addrs is the list of addresses for this breakpoint.
@@ -69,9 +91,29 @@ type Breakpoint struct {
 
 	// Breakpoint condition
 	Cond string
+	// CondCalls, if true, allows Cond to call functions of the target
+	// process. If the function calls fail or do not complete in a timely
+	// manner the breakpoint is triggered and the error is reported.
+	CondCalls bool `json:"condCalls,omitempty"`
 	// Breakpoint hit count condition.
 	// Supported hit count conditions are "NUMBER" and "OP NUMBER".
 	HitCond string
+	// HitCondPerG, if true, evaluates HitCond with the number of times the
+	// breakpoint was reached by the current goroutine instead of the total
+	// hit count.
+	HitCondPerG bool `json:"hitCondPerG,omitempty"`
+	// Commands is a list of terminal commands executed by the client every
+	// time the breakpoint is hit.
+	Commands []string `json:"commands,omitempty"`
+	// Warnings lists problems found validating the condition of the
+	// breakpoint that did not prevent it from being set, for example
+	// variables that may not be visible at some of its addresses.
+	Warnings []string `json:"warnings,omitempty"`
+	// Logpoint, if set, is a format string with expressions enclosed in
+	// curly braces. Every time the breakpoint is hit the format string is
+	// rendered and buffered, without stopping the target, see
+	// GetBufferedLogpoints.
+	Logpoint string `json:"logpoint,omitempty"`
 
 	// Tracepoint flag, signifying this is a tracepoint.
 	Tracepoint bool `json:"continue"`
@@ -89,18 +131,108 @@ type Breakpoint struct {
 	// LoadLocals requests loading function locals when the breakpoint is hit
 	LoadLocals *LoadConfig
 
+	// MaxHits, if greater than zero, is the number of hits after which the
+	// breakpoint is automatically disabled.
+	MaxHits int `json:"maxHits,omitempty"`
+	// AutoDisabled is true if the breakpoint was disabled because it reached
+	// MaxHits.
+	AutoDisabled bool `json:"autoDisabled,omitempty"`
+
+	// FunctionRegexp, if set, is a regular expression: the breakpoint is set
+	// on the entry point of every function whose name matches it.
+	FunctionRegexp string `json:"functionRegexp,omitempty"`
+
+	// Package, if set, is the path of a package: the breakpoint is set on
+	// the entry point of every function of the package, including closures.
+	Package string `json:"package,omitempty"`
+	// ExcludeWrappers excludes autogenerated wrappers from the functions
+	// selected by Package.
+	ExcludeWrappers bool `json:"excludeWrappers,omitempty"`
+	// FunctionCount is the number of functions covered by a breakpoint set
+	// using Package.
+	FunctionCount int `json:"functionCount,omitempty"`
+
+	// Locations lists the source location of each address in Addrs, it is
+	// only set when the breakpoint covers more than one distinct source
+	// location, in which case File is set to MultipleLocations.
+	Locations []Location `json:"locations,omitempty"`
+
 	// WatchExpr is the expression used to create this watchpoint
 	WatchExpr string
 	WatchType WatchType
+	// WatchField is the header field (ptr, len or cap) of a string or slice
+	// watchpoint, or the byte range of a watchpoint split across multiple
+	// hardware slots, that triggered. It is empty if the whole variable is
+	// watched.
+	WatchField string `json:"watchField,omitempty"`
+	// WatchOldValue and WatchNewValue are the values of the watched memory
+	// before and after the watchpoint was last hit.
+	WatchOldValue uint64 `json:"watchOldValue,omitempty"`
+	WatchNewValue uint64 `json:"watchNewValue,omitempty"`
+	// WatchSuspended is true if the expression of a watchpoint created with
+	// WatchRewatch could not be evaluated the last time the target stopped.
+	WatchSuspended bool `json:"watchSuspended,omitempty"`
+	// WatchRaw is true if the watchpoint watches a region of memory
+	// specified by its address and size, instead of the value of an
+	// expression. WatchExpr is the address of the region.
+	WatchRaw bool `json:"watchRaw,omitempty"`
+	// Hardware is true if the breakpoint uses hardware execute breakpoints
+	// instead of breakpoint instructions written in the code of the target.
+	// Setting it when creating a breakpoint requests hardware breakpoints.
+	Hardware bool `json:"hardware,omitempty"`
+	// Suspended is true if the location of the breakpoint, specified by
+	// File and Line or by FunctionName and Line, could not be found yet. A
+	// suspended breakpoint is disabled and it is enabled automatically when
+	// the target loads a shared object containing its location.
+	// Setting it when creating a breakpoint requests a suspended breakpoint
+	// instead of an error if the location can not be found.
+	Suspended bool `json:"suspended,omitempty"`
 
 	// number of times a breakpoint has been reached in a certain goroutine
 	HitCount map[string]uint64 `json:"hitCount"`
 	// number of times a breakpoint has been reached
 	TotalHitCount uint64 `json:"totalHitCount"`
+	// LastHit is the time the breakpoint was last reached, it is the zero
+	// time if the breakpoint has not been reached since its hit counts
+	// were reset.
+	LastHit time.Time `json:"lastHit"`
+	// HitRate is the number of hits per second, averaged over the last hits
+	// of the breakpoint and the time elapsed since the last one.
+	HitRate float64 `json:"hitRate,omitempty"`
+	// HitHistorySize is the number of hits remembered in the hit history of
+	// the breakpoint, the hit history is disabled if it is zero.
+	HitHistorySize int `json:"hitHistorySize,omitempty"`
+	// AutoCheckpoint, if greater than zero, makes the breakpoint create a
+	// checkpoint every AutoCheckpoint hits while replaying a recording.
+	AutoCheckpoint int `json:"autoCheckpoint,omitempty"`
+	// AutoCheckpointMax is the maximum number of automatic checkpoints kept
+	// for the breakpoint, the oldest are deleted first. If it is zero a
+	// default of 10 is used.
+	AutoCheckpointMax int `json:"autoCheckpointMax,omitempty"`
+	// AutoCheckpointOnly, if set together with AutoCheckpoint, makes the
+	// breakpoint only create checkpoints without stopping the target.
+	AutoCheckpointOnly bool `json:"autoCheckpointOnly,omitempty"`
 	// Disabled flag, signifying the state of the breakpoint
 	Disabled bool `json:"disabled"`
 }
 
+// BreakpointHit describes a hit of a breakpoint recorded in its hit
+// history.
+type BreakpointHit struct {
+	GoroutineID int `json:"goroutineID"`
+	ThreadID    int `json:"threadID"`
+	// PC is the address of the physical breakpoint that was hit, or the
+	// address of the instruction that triggered a watchpoint.
+	PC   uint64    `json:"pc"`
+	File string    `json:"file"`
+	Line int       `json:"line"`
+	Time time.Time `json:"time"`
+}
+
+// MultipleLocations is the value of Breakpoint.File for breakpoints
+// that cover more than one distinct source location.
+const MultipleLocations = "<multiple locations>"
+
 // ValidBreakpointName returns an error if
 // the name to be chosen for a breakpoint is invalid.
 // The name can not be just a number, and must contain a series
@@ -125,8 +257,93 @@ type WatchType uint8
 const (
 	WatchRead WatchType = 1 << iota
 	WatchWrite
+	WatchSoftware
+	WatchRewatch
 )
 
+// Size returns the number of bytes watched by a physical watchpoint of
+// type wtype, it is only set on the types returned by the debugger.
+func (wtype WatchType) Size() int {
+	return int(wtype >> 4)
+}
+
+// PhysicalBreakpoint describes one of the addresses covered by a logical
+// breakpoint.
+type PhysicalBreakpoint struct {
+	Addr         uint64 `json:"addr"`
+	File         string `json:"file"`
+	Line         int    `json:"line"`
+	FunctionName string `json:"functionName,omitempty"`
+	// Installed is true if the breakpoint is currently set in the target.
+	Installed bool `json:"installed"`
+	// Error is the error that prevented the breakpoint from being set, if
+	// any.
+	Error string `json:"error,omitempty"`
+	// WatchType and HWBreakIndex describe the hardware breakpoint used by
+	// watchpoints and hardware breakpoints.
+	WatchType    WatchType `json:"watchType,omitempty"`
+	HWBreakIndex uint8     `json:"hwBreakIndex,omitempty"`
+}
+
+// StepIntoTarget is a call instruction on the current line that can be
+// stepped into.
+type StepIntoTarget struct {
+	// PC is the address of the call instruction.
+	PC uint64 `json:"pc"`
+	// Function is the function called, nil if the call is indirect or the
+	// destination is unknown.
+	Function *Function `json:"function,omitempty"`
+}
+
+// WatchpointSlots describes the hardware breakpoint slots that can be used
+// to implement watchpoints.
+type WatchpointSlots struct {
+	// Total is the number of hardware breakpoint slots of the architecture.
+	Total int
+	// Used is the number of slots currently used by watchpoints.
+	Used int
+	// MaxSize is the maximum number of bytes watched by a single slot.
+	MaxSize int
+}
+
+// WatchOutOfScope describes a watchpoint on a stack variable that was
+// cleared because the variable went out of scope.
+type WatchOutOfScope struct {
+	// Breakpoint is the watchpoint that was cleared.
+	Breakpoint *Breakpoint `json:"breakpoint"`
+	// LastValue is the last value of the watched variable, as it was the
+	// last time the watchpoint was hit, it is empty if the variable went out
+	// of scope while executing backward.
+	LastValue string `json:"lastValue"`
+	// Location is where the frame of the variable was destroyed, that is
+	// the return address in the caller of the function owning the variable.
+	Location Location `json:"location"`
+}
+
+// WatchMigration describes a watchpoint on a slice element that was moved
+// to the new backing array of the slice, or suspended because its index
+// went out of range.
+type WatchMigration struct {
+	// Breakpoint is the watchpoint that was moved.
+	Breakpoint *Breakpoint `json:"breakpoint"`
+	// OldAddr and NewAddr are the addresses of the watched element before
+	// and after the move, NewAddr is zero if the watchpoint was suspended.
+	OldAddr uint64 `json:"oldAddr"`
+	NewAddr uint64 `json:"newAddr"`
+	// Reason describes why the watchpoint was suspended.
+	Reason string `json:"reason,omitempty"`
+}
+
+// LogpointMessage is a message produced by a logpoint.
+type LogpointMessage struct {
+	// BreakpointID is the ID of the breakpoint that produced the message.
+	BreakpointID int `json:"breakpointID"`
+	// GoroutineID is the goroutine that hit the breakpoint.
+	GoroutineID int `json:"goroutineID"`
+	// Message is the rendered format string of the logpoint.
+	Message string `json:"message"`
+}
+
 // Thread is a thread within the debugged process.
 type Thread struct {
 	// ID is a unique identifier for the thread.
@@ -311,6 +528,36 @@ type Variable struct {
 	LocationExpr string
 	// DeclLine is the line number of this variable's declaration
 	DeclLine int64
+
+	// Formatted is the value rendered by the formatter registered for the
+	// type of the variable, or returned by its Error or String method if
+	// LoadConfig.CallStringMethods was set, if any.
+	Formatted string `json:"formatted,omitempty"`
+
+	// MapFilter is set for maps evaluated through a filter expression, such
+	// as m[@key == "a"], Children only contains the matching entries.
+	MapFilter *MapFilterResult `json:"mapFilter,omitempty"`
+
+	// MapContinuation is an opaque token returned for maps when not all of
+	// their entries were loaded, see LoadConfig.MapContinuation.
+	MapContinuation string `json:"mapContinuation,omitempty"`
+
+	// Symbol is the function containing the code address stored in a
+	// function, uintptr or unsafe.Pointer value, for example
+	// "main.(*Server).handle+0x1a", only set if LoadConfig.SymbolizePointers
+	// was set.
+	Symbol string `json:"symbol,omitempty"`
+}
+
+// MapFilterResult describes the entries of a map examined while evaluating
+// a filter expression.
+type MapFilterResult struct {
+	// Scanned is the number of entries examined. The following entries can
+	// be examined by reslicing the filter expression at the number of
+	// entries skipped plus Scanned, for example m[@key == "a"][100:].
+	Scanned int64 `json:"scanned"`
+	// Matched is the number of entries that satisfied the filter.
+	Matched int64 `json:"matched"`
 }
 
 // LoadConfig describes how to load values from target's memory
@@ -325,6 +572,23 @@ type LoadConfig struct {
 	MaxArrayValues int
 	// MaxStructFields is the maximum number of fields read from a struct, -1 will read all fields.
 	MaxStructFields int
+	// CallStringMethods requests the Error or String method of the values
+	// implementing error or fmt.Stringer to be called, the result is returned
+	// in Variable.Formatted. Ignored for core files and recordings.
+	CallStringMethods bool
+	// Start is the index of the first element loaded from an array or slice,
+	// or the number of entries skipped from a map, when evaluating an
+	// expression. The elements preceding it are not read.
+	Start int
+	// MapContinuation is the token returned in Variable.MapContinuation by
+	// a previous evaluation of the same map expression, the entries
+	// following the ones returned by that evaluation are loaded.
+	// Evaluation fails if the map changed since the token was returned.
+	MapContinuation string
+	// SymbolizePointers requests function values, and uintptr and
+	// unsafe.Pointer values pointing into the code of a known function, to
+	// be resolved to the function, see Variable.Symbol.
+	SymbolizePointers bool
 }
 
 // Goroutine represents the information relevant to Delve from the runtime's
@@ -363,12 +627,13 @@ type DebuggerCommand struct {
 	// command.
 	ThreadID int `json:"threadID,omitempty"`
 	// GoroutineID is used to specify which thread to use with the SwitchGoroutine
-	// and Call commands.
+	// and Call commands, and the goroutine whose topmost frame is used to
+	// evaluate the expression of an Until command.
 	GoroutineID int `json:"goroutineID,omitempty"`
 	// When ReturnInfoLoadConfig is not nil it will be used to load the value
 	// of any return variables.
 	ReturnInfoLoadConfig *LoadConfig
-	// Expr is the expression argument for a Call command
+	// Expr is the expression argument for a Call or Until command
 	Expr string `json:"expr,omitempty"`
 
 	// UnsafeCall disables parameter escape checking for function calls.
@@ -385,6 +650,31 @@ type DebuggerCommand struct {
 	// violate the rules about stack objects you can disable this safety check
 	// by setting UnsafeCall to true.
 	UnsafeCall bool `json:"unsafeCall,omitempty"`
+
+	// Addrs is the list of destination addresses for a RewindTo command.
+	// For a StepInto command Addrs[0] is the address of the CALL
+	// instruction to step into.
+	Addrs []uint64 `json:"addrs,omitempty"`
+
+	// Count is the number of instructions executed by a StepInstruction or
+	// ReverseStepInstruction command, zero means one.
+	Count int `json:"count,omitempty"`
+	// StepOverCalls, if true, makes a StepInstruction command execute CALL
+	// instructions, and the function they call, as a single instruction.
+	StepOverCalls bool `json:"stepOverCalls,omitempty"`
+
+	// Timeout, if greater than zero, is the maximum amount of time the
+	// target runs during a Continue, Rewind, Next, Step or StepOut command
+	// (and their reverse variants). When it expires the target is stopped
+	// and StopReason is set to "timeout" in the returned state.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// Pinned, if true, makes a Continue, Next, Step or StepOut command
+	// resume only the thread running the selected goroutine, every other
+	// goroutine stays frozen. The command fails if the selected goroutine
+	// can not continue running without the other goroutines, for example
+	// because it blocks on a channel.
+	Pinned bool `json:"pinned,omitempty"`
 }
 
 // BreakpointInfo contains informations about the current breakpoint
@@ -409,17 +699,32 @@ const (
 	Continue = "continue"
 	// Rewind resumes process execution backwards (target must be a recording).
 	Rewind = "rewind"
+	// RewindTo resumes process execution backwards until one of the
+	// addresses in Addrs is reached (target must be a recording).
+	RewindTo = "rewindTo"
 	// DirecitonCongruentContinue resumes process execution, if a reverse next, step or stepout operation is in progress it will resume execution backward.
 	DirectionCongruentContinue = "directionCongruentContinue"
 	// Step continues to next source line, entering function calls.
 	Step = "step"
 	// ReverseStep continues backward to the previous line of source code, entering function calls.
 	ReverseStep = "reverseStep"
-	// StepOut continues to the return address of the current function
+	// StepInto continues into the function called by the CALL instruction
+	// at Addrs[0], which must be on the current line.
+	StepInto = "stepInto"
+	// StepIntoNewGoroutine executes the go statement on the current line and
+	// continues to the first instruction of the goroutine it creates.
+	StepIntoNewGoroutine = "stepIntoNewGoroutine"
+	// StepOut continues to the return address of the current function, if
+	// the current function is the body of a range-over-func loop it
+	// continues until the function containing the loop is reached.
 	StepOut = "stepOut"
+	// StepOutIterator continues to the return address of the current
+	// function, even if it is the body of a range-over-func loop.
+	StepOutIterator = "stepOutIterator"
 	// ReverseStepOut continues backward to the calle rof the current function.
 	ReverseStepOut = "reverseStepOut"
-	// StepInstruction continues for exactly 1 cpu instruction.
+	// StepInstruction continues for exactly 1 cpu instruction, or for the
+	// number of instructions specified by DebuggerCommand.Count.
 	StepInstruction = "stepInstruction"
 	// ReverseStepInstruction reverses execution for exactly 1 cpu instruction.
 	ReverseStepInstruction = "reverseStepInstruction"
@@ -438,6 +743,9 @@ const (
 	Halt = "halt"
 	// Call resumes process execution injecting a function call.
 	Call = "call"
+	// Until resumes process execution until the boolean expression Expr
+	// becomes true.
+	Until = "until"
 )
 
 // AssemblyFlavour describes the output
@@ -534,6 +842,189 @@ type Checkpoint struct {
 	ID    int
 	When  string
 	Where string
+	Event int64
+	// Auto is true for checkpoints created automatically by a breakpoint.
+	Auto bool
+}
+
+// StepSkip is a rule that makes the step command step over the functions
+// it matches instead of stopping inside them.
+type StepSkip struct {
+	ID int `json:"id"`
+	// Kind is "function" if Pattern is a regular expression matched against
+	// the full name of functions, "file" if it is a glob matched against the
+	// path of the file containing them.
+	Kind    string `json:"kind"`
+	Pattern string `json:"pattern"`
+}
+
+// Formatter describes how the values of a type are rendered, see
+// Variable.Formatted.
+type Formatter struct {
+	// TypeName is the fully qualified name of the type.
+	TypeName string `json:"typeName"`
+	// Format is a format string, the expressions between braces are
+	// evaluated with the fields and methods of the value in scope, function
+	// calls are not allowed.
+	Format string `json:"format,omitempty"`
+	// CallString is true if the value is rendered by calling its String
+	// method instead of using Format.
+	CallString bool `json:"callString,omitempty"`
+}
+
+// CheckpointDiff is the value of an expression evaluated both at a
+// checkpoint and at the current position.
+type CheckpointDiff struct {
+	Expr       string
+	Checkpoint Variable
+	Current    Variable
+	// Diffs lists the values that are different between Checkpoint and
+	// Current, for composite types only the differing fields, elements and
+	// map entries are listed.
+	Diffs []VariableDiff
+}
+
+// VariableDiff is a value that changed between two evaluations of the
+// same expression.
+type VariableDiff struct {
+	// Path is an expression that evaluates to the value, built from the
+	// compared expression.
+	Path string
+	// Old and New are the two values, they are empty if the value does
+	// not exist in the corresponding variable.
+	Old string
+	New string
+}
+
+// BlockedGoroutine is a goroutine parked on a synchronization object.
+type BlockedGoroutine struct {
+	Goroutine *Goroutine `json:"goroutine"`
+	// Kind describes the object the goroutine is waiting on, for example
+	// "sync.Mutex" or "chan receive".
+	Kind string `json:"kind"`
+	// Objects are the addresses of the objects the goroutine is waiting on,
+	// for channels the address of their runtime.hchan struct.
+	Objects []uint64 `json:"objects,omitempty"`
+	// Location is the position where the blocking operation was requested.
+	Location Location `json:"location"`
+}
+
+// WaitEdge is an edge of a wait-for graph: goroutine Waiter waits on
+// Object, which goroutine Holder holds or may operate on.
+type WaitEdge struct {
+	Waiter int    `json:"waiter"`
+	Holder int    `json:"holder"`
+	Kind   string `json:"kind"`
+	Object uint64 `json:"object"`
+	// Lock is true if Object is a lock held by Holder.
+	Lock bool `json:"lock,omitempty"`
+}
+
+// DeadlockReport lists the goroutines blocked on synchronization objects
+// and the cycles of goroutines waiting on each other.
+type DeadlockReport struct {
+	Blocked []BlockedGoroutine `json:"blocked"`
+	// Cycles lists potential deadlocks, the holder of each edge is the
+	// waiter of the next one.
+	Cycles [][]WaitEdge `json:"cycles"`
+}
+
+// MutexInfo describes the state of a sync.Mutex or sync.RWMutex, see
+// proc.MutexInfo.
+type MutexInfo struct {
+	Addr uint64 `json:"addr"`
+	// RW is true for a sync.RWMutex.
+	RW bool `json:"rw,omitempty"`
+	// Locked is true if the mutex, or the write lock of a read-write mutex,
+	// is held.
+	Locked   bool `json:"locked"`
+	Woken    bool `json:"woken,omitempty"`
+	Starving bool `json:"starving,omitempty"`
+	// Waiters is the number of waiters recorded in the state of the mutex.
+	Waiters int64 `json:"waiters"`
+	// Readers is the number of goroutines holding the read lock.
+	Readers int64 `json:"readers,omitempty"`
+	// WriterPending is true if a writer holds the write lock or waits for
+	// the readers to leave.
+	WriterPending bool `json:"writerPending,omitempty"`
+	// Waiting and WaitingReaders are the IDs of the goroutines parked
+	// acquiring the lock and the read lock, respectively.
+	Waiting        []int `json:"waiting,omitempty"`
+	WaitingReaders []int `json:"waitingReaders,omitempty"`
+	// PossibleOwners are the goroutines that may hold the lock, inferred
+	// from their stacks.
+	PossibleOwners []MutexOwner `json:"possibleOwners,omitempty"`
+}
+
+// MutexOwner is a goroutine that may hold a lock.
+type MutexOwner struct {
+	GoroutineID int `json:"goroutineID"`
+	// Location is the position in the function that acquired the lock.
+	Location Location `json:"location"`
+	// Reader is true if the goroutine holds the read lock.
+	Reader bool `json:"reader,omitempty"`
+	// ReferencesMutex is true if the mutex is reachable from the stack of
+	// the goroutine.
+	ReferencesMutex bool `json:"referencesMutex,omitempty"`
+}
+
+// RecordOptions are the options used to record the target with rr.
+type RecordOptions struct {
+	Chaos          bool     `json:"chaos,omitempty"`
+	NumCores       int      `json:"numCores,omitempty"`
+	OutputTraceDir string   `json:"outputTraceDir,omitempty"`
+	ExtraArgs      []string `json:"extraArgs,omitempty"`
+}
+
+// RecordedProcess is a process recorded in a rr trace.
+type RecordedProcess struct {
+	Pid     int    `json:"pid"`
+	Ppid    int    `json:"ppid,omitempty"`
+	Exit    string `json:"exit"`
+	Cmdline string `json:"cmdline"`
+	// Current is true for the process being debugged.
+	Current bool `json:"current,omitempty"`
+}
+
+// SignalPassing describes which signals are delivered to the target
+// without stopping it, see proc.SignalPassing.
+type SignalPassing struct {
+	// Signals is the list of signals passed through to the target, using
+	// the signal numbers of the backend.
+	Signals []int `json:"signals"`
+	// All is true if the backend delivers every signal to the target
+	// regardless of Signals.
+	All bool `json:"all,omitempty"`
+	// Configurable is true if Signals can be changed.
+	Configurable bool `json:"configurable,omitempty"`
+}
+
+// RecordingPosition is a position in a recording, see
+// proc.RecordingPosition.
+type RecordingPosition struct {
+	Event       int64 `json:"event"`
+	Ticks       int64 `json:"ticks"`
+	TotalEvents int64 `json:"totalEvents,omitempty"`
+	AtStart     bool  `json:"atStart,omitempty"`
+	AtEnd       bool  `json:"atEnd,omitempty"`
+}
+
+// String returns a description of pos, such as "event 1234/98765 (1.2% of
+// recording), tick 5678".
+func (pos *RecordingPosition) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "event %d", pos.Event)
+	if pos.TotalEvents > 0 {
+		fmt.Fprintf(&buf, "/%d (%.1f%% of recording)", pos.TotalEvents, 100*float64(pos.Event)/float64(pos.TotalEvents))
+	}
+	fmt.Fprintf(&buf, ", tick %d", pos.Ticks)
+	switch {
+	case pos.AtStart:
+		buf.WriteString(", start of recording")
+	case pos.AtEnd:
+		buf.WriteString(", end of recording")
+	}
+	return buf.String()
 }
 
 // Image represents a loaded shared object (go plugin or shared library)
@@ -30,29 +30,57 @@ type Client interface {
 
 	// Continue resumes process execution.
 	Continue() <-chan *api.DebuggerState
+	// ContinueWithTimeout resumes process execution, stopping it after timeout.
+	ContinueWithTimeout(timeout time.Duration) <-chan *api.DebuggerState
 	// Rewind resumes process execution backwards.
 	Rewind() <-chan *api.DebuggerState
+	// RewindTo resumes process execution backwards until one of addrs is reached.
+	RewindTo(addrs []uint64) <-chan *api.DebuggerState
 	// DirecitonCongruentContinue resumes process execution, if a reverse next, step or stepout operation is in progress it will resume execution backward.
 	DirectionCongruentContinue() <-chan *api.DebuggerState
 	// Next continues to the next source line, not entering function calls.
 	Next() (*api.DebuggerState, error)
+	// NextWithTimeout is like Next but stops the process after timeout.
+	NextWithTimeout(timeout time.Duration) (*api.DebuggerState, error)
 	// ReverseNext continues backward to the previous line of source code, not entering function calls.
 	ReverseNext() (*api.DebuggerState, error)
 	// Step continues to the next source line, entering function calls.
 	Step() (*api.DebuggerState, error)
+	// StepWithTimeout is like Step but stops the process after timeout.
+	StepWithTimeout(timeout time.Duration) (*api.DebuggerState, error)
 	// ReverseStep continues backward to the previous line of source code, entering function calls.
 	ReverseStep() (*api.DebuggerState, error)
+	// StepInto steps into the function called by the call instruction at pc,
+	// which must be one of the targets returned by StepIntoTargets.
+	StepInto(pc uint64) (*api.DebuggerState, error)
+	// StepIntoTargets returns the calls remaining on the current line.
+	StepIntoTargets() ([]api.StepIntoTarget, error)
+	// StepIntoNewGoroutine executes the go statement on the current line and
+	// stops at the first instruction of the goroutine it creates.
+	StepIntoNewGoroutine() (*api.DebuggerState, error)
 	// StepOut continues to the return address of the current function.
 	StepOut() (*api.DebuggerState, error)
 	// ReverseStepOut continues backward to the calle rof the current function.
 	ReverseStepOut() (*api.DebuggerState, error)
+	// StepOutIterator continues to the return address of the current
+	// function, even if it is the body of a range-over-func loop.
+	StepOutIterator() (*api.DebuggerState, error)
 	// Call resumes process execution while making a function call.
 	Call(goroutineID int, expr string, unsafe bool) (*api.DebuggerState, error)
+	// Until resumes process execution until expr, evaluated in the topmost
+	// frame of the goroutine, becomes true.
+	Until(goroutineID int, expr string) (*api.DebuggerState, error)
 
 	// SingleStep will step a single cpu instruction.
 	StepInstruction() (*api.DebuggerState, error)
 	// ReverseSingleStep will reverse step a single cpu instruction.
 	ReverseStepInstruction() (*api.DebuggerState, error)
+	// StepInstructions steps count cpu instructions, if stepOverCalls is
+	// true CALL instructions are executed together with the called
+	// function. Stepping stops early if a breakpoint is reached.
+	StepInstructions(count int, stepOverCalls bool) (*api.DebuggerState, error)
+	// ReverseStepInstructions reverse steps count cpu instructions.
+	ReverseStepInstructions(count int) (*api.DebuggerState, error)
 	// SwitchThread switches the current thread context.
 	SwitchThread(threadID int) (*api.DebuggerState, error)
 	// SwitchGoroutine switches the current goroutine (and the current thread as well)
@@ -68,6 +96,19 @@ type Client interface {
 	CreateBreakpoint(*api.Breakpoint) (*api.Breakpoint, error)
 	// CreateWatchpoint creates a new watchpoint.
 	CreateWatchpoint(api.EvalScope, string, api.WatchType) (*api.Breakpoint, error)
+	// CreateSliceWatchpoint creates a new watchpoint on an element of a slice that follows the reallocations of the slice.
+	CreateSliceWatchpoint(scope api.EvalScope, expr string, wtype api.WatchType, notify bool) (*api.Breakpoint, error)
+	// CreateWatchpointAddr creates a new watchpoint on the specified number of bytes of memory starting at an address.
+	CreateWatchpointAddr(addr uint64, size int, wtype api.WatchType) (*api.Breakpoint, error)
+	// WatchpointSlots returns the number of hardware watchpoint slots and how many are in use.
+	WatchpointSlots() (api.WatchpointSlots, error)
+	// BreakpointHitHistory returns and clears the hit history of a breakpoint.
+	BreakpointHitHistory(id int) ([]api.BreakpointHit, error)
+	// PhysicalBreakpoints returns the physical breakpoints of a logical breakpoint.
+	PhysicalBreakpoints(id int) ([]api.PhysicalBreakpoint, error)
+	// GetBufferedLogpoints returns the messages produced by logpoints since
+	// the last call and the number of messages that were discarded.
+	GetBufferedLogpoints() ([]api.LogpointMessage, int, error)
 	// ListBreakpoints gets all breakpoints.
 	ListBreakpoints() ([]*api.Breakpoint, error)
 	// ClearBreakpoint deletes a breakpoint by ID.
@@ -78,6 +119,17 @@ type Client interface {
 	ToggleBreakpoint(id int) (*api.Breakpoint, error)
 	// ToggleBreakpointByName toggles on or off a breakpoint by name.
 	ToggleBreakpointByName(name string) (*api.Breakpoint, error)
+	// ClearBreakpointGroup deletes all breakpoints belonging to a group.
+	ClearBreakpointGroup(group string) ([]*api.Breakpoint, error)
+	// ToggleBreakpointGroup disables all breakpoints belonging to a group if
+	// any of them is enabled, otherwise it enables all of them.
+	ToggleBreakpointGroup(group string) ([]*api.Breakpoint, error)
+	// ResetBreakpointHitCounts resets the hit counts of a breakpoint by ID.
+	ResetBreakpointHitCounts(id int) error
+	// ResetBreakpointHitCountsByName resets the hit counts of a breakpoint by name.
+	ResetBreakpointHitCountsByName(name string) error
+	// ResetAllBreakpointHitCounts resets the hit counts of all breakpoints.
+	ResetAllBreakpointHitCounts() error
 	// Allows user to update an existing breakpoint for example to change the information
 	// retrieved when the breakpoint is hit or to change, add or remove the break condition
 	AmendBreakpoint(*api.Breakpoint) error
@@ -96,6 +148,10 @@ type Client interface {
 
 	// SetVariable sets the value of a variable
 	SetVariable(scope api.EvalScope, symbol, value string) error
+	// SetVariableInPlace sets the value of a variable, a string or slice
+	// value that can not be allocated is written over the current contents
+	// of the variable, changing everything that shares them.
+	SetVariableInPlace(scope api.EvalScope, symbol, value string) error
 
 	// ListSources lists all source files in the process matching filter.
 	ListSources(filter string) ([]string, error)
@@ -149,19 +205,58 @@ type Client interface {
 	Recorded() bool
 	// TraceDirectory returns the path to the trace directory for a recording.
 	TraceDirectory() (string, error)
+	// RecordOptions returns the options used to record the target.
+	RecordOptions() (*api.RecordOptions, error)
+	// ListRecordedProcesses lists the processes recorded in the trace.
+	ListRecordedProcesses() ([]api.RecordedProcess, error)
+	// PassSignals returns the signals that are delivered to the target
+	// without stopping it.
+	PassSignals() (api.SignalPassing, error)
+	// SetPassSignals changes the list of signals that are delivered to the
+	// target without stopping it.
+	SetPassSignals(sigs []int) (api.SignalPassing, error)
 	// Checkpoint sets a checkpoint at the current position.
 	Checkpoint(where string) (checkpointID int, err error)
 	// ListCheckpoints gets all checkpoints.
 	ListCheckpoints() ([]api.Checkpoint, error)
 	// ClearCheckpoint removes a checkpoint
 	ClearCheckpoint(id int) error
+	// CheckpointDiff evaluates exprs both at the current position and at a
+	// checkpoint and returns the two values of each expression.
+	CheckpointDiff(id int, scope api.EvalScope, exprs []string, cfg api.LoadConfig) ([]api.CheckpointDiff, error)
+	// SeekRecording restarts the recording positioned at the specified event and tick count.
+	SeekRecording(event, ticks int64) error
+
+	// CreateStepSkip adds a rule that makes step step over the functions it matches.
+	CreateStepSkip(kind, pattern string) (*api.StepSkip, error)
+	// ClearStepSkip removes a step skip rule.
+	ClearStepSkip(id int) error
+	// ListStepSkips returns the list of step skip rules.
+	ListStepSkips() ([]*api.StepSkip, error)
+
+	// CreateFormatter registers a formatter for the values of a type.
+	CreateFormatter(f api.Formatter) (*api.Formatter, error)
+	// ClearFormatter removes the formatter registered for a type.
+	ClearFormatter(typeName string) error
+	// ListFormatters returns the list of formatters.
+	ListFormatters() ([]*api.Formatter, error)
 
 	// SetReturnValuesLoadConfig sets the load configuration for return values.
 	SetReturnValuesLoadConfig(*api.LoadConfig)
+	// SetPinnedResume sets whether Continue, Next, Step and StepOut resume
+	// only the selected goroutine, keeping every other goroutine frozen.
+	SetPinnedResume(pinned bool)
 
 	// IsMulticlien returns true if the headless instance is multiclient.
 	IsMulticlient() bool
 
+	// Deadlocks returns the goroutines blocked on synchronization objects
+	// and the cycles of goroutines waiting on each other.
+	Deadlocks() (*api.DeadlockReport, error)
+	// MutexInfo returns the state of the sync.Mutex or sync.RWMutex expr and
+	// the goroutines that may hold it.
+	MutexInfo(scope api.EvalScope, expr string) (*api.MutexInfo, error)
+
 	// ListDynamicLibraries returns a list of loaded dynamic libraries.
 	ListDynamicLibraries() ([]api.Image, error)
 
@@ -170,6 +265,17 @@ type Client interface {
 	// This function will return an error if it reads less than `length` bytes.
 	ExamineMemory(address uint64, length int) ([]byte, bool, error)
 
+	// ExamineType returns the memory at address interpreted as a value of
+	// type typename, or as an array of count such values if count is
+	// greater than 1. Count must be less than or equal to 1000.
+	ExamineType(address uint64, typename string, count int64, cfg api.LoadConfig) (*api.Variable, error)
+
+	// ReadStringRange returns count bytes, starting at offset, of the
+	// contents of a string or byte slice, given the Base and Len of its
+	// api.Variable. Count must be less than or equal to 16MB. If part of the
+	// range is unreadable the bytes preceding it are returned with an error.
+	ReadStringRange(base uint64, length, offset, count int64) ([]byte, error)
+
 	// StopRecording stops a recording if one is in progress.
 	StopRecording() error
 
@@ -105,6 +105,7 @@ func (c *Client) ExpectInitializeResponseAndCapabilities(t *testing.T) *dap.Init
 		SupportsFunctionBreakpoints:      true,
 		SupportsEvaluateForHovers:        true,
 		SupportsClipboardContext:         true,
+		SupportsStepInTargetsRequest:     true,
 	}
 	if !reflect.DeepEqual(initResp.Body, wantCapabilities) {
 		t.Errorf("capabilities in initializeResponse: got %+v, want %v", pretty(initResp.Body), pretty(wantCapabilities))
@@ -300,6 +301,14 @@ func (c *Client) StepInRequest(thread int) {
 	c.send(request)
 }
 
+// StepInTargetRequest sends a 'stepIn' request into the specified target.
+func (c *Client) StepInTargetRequest(thread, target int) {
+	request := &dap.StepInRequest{Request: *c.newRequest("stepIn")}
+	request.Arguments.ThreadId = thread
+	request.Arguments.TargetId = target
+	c.send(request)
+}
+
 // StepOutRequest sends a 'stepOut' request.
 func (c *Client) StepOutRequest(thread int) {
 	request := &dap.NextRequest{Request: *c.newRequest("stepOut")}
@@ -343,6 +352,14 @@ func (c *Client) VariablesRequest(variablesReference int) {
 	c.send(request)
 }
 
+// VariablesRequestWithFormat sends a 'variables' request with the given value format.
+func (c *Client) VariablesRequestWithFormat(variablesReference int, format dap.ValueFormat) {
+	request := &dap.VariablesRequest{Request: *c.newRequest("variables")}
+	request.Arguments.VariablesReference = variablesReference
+	request.Arguments.Format = format
+	c.send(request)
+}
+
 // IndexedVariablesRequest sends a 'variables' request.
 func (c *Client) IndexedVariablesRequest(variablesReference, start, count int) {
 	request := &dap.VariablesRequest{Request: *c.newRequest("variables")}
@@ -434,9 +451,21 @@ func (c *Client) EvaluateRequest(expr string, fid int, context string) {
 	c.send(request)
 }
 
+// EvaluateRequestWithFormat sends a 'evaluate' request with the given value format.
+func (c *Client) EvaluateRequestWithFormat(expr string, fid int, context string, format dap.ValueFormat) {
+	request := &dap.EvaluateRequest{Request: *c.newRequest("evaluate")}
+	request.Arguments.Expression = expr
+	request.Arguments.FrameId = fid
+	request.Arguments.Context = context
+	request.Arguments.Format = format
+	c.send(request)
+}
+
 // StepInTargetsRequest sends a 'stepInTargets' request.
-func (c *Client) StepInTargetsRequest() {
-	c.send(&dap.StepInTargetsRequest{Request: *c.newRequest("stepInTargets")})
+func (c *Client) StepInTargetsRequest(frameID int) {
+	request := &dap.StepInTargetsRequest{Request: *c.newRequest("stepInTargets")}
+	request.Arguments.FrameId = frameID
+	c.send(request)
 }
 
 // GotoTargetsRequest sends a 'gotoTargets' request.
@@ -462,8 +491,11 @@ func (c *Client) LoadedSourcesRequest() {
 }
 
 // DataBreakpointInfoRequest sends a 'dataBreakpointInfo' request.
-func (c *Client) DataBreakpointInfoRequest() {
-	c.send(&dap.DataBreakpointInfoRequest{Request: *c.newRequest("dataBreakpointInfo")})
+func (c *Client) DataBreakpointInfoRequest(variablesRef int, name string) {
+	request := &dap.DataBreakpointInfoRequest{Request: *c.newRequest("dataBreakpointInfo")}
+	request.Arguments.VariablesReference = variablesRef
+	request.Arguments.Name = name
+	c.send(request)
 }
 
 // SetDataBreakpointsRequest sends a 'setDataBreakpoints' request.
@@ -24,6 +24,8 @@ const (
 	UnableToHalt               = 2010
 	UnableToGetExceptionInfo   = 2011
 	UnableToSetVariable        = 2012
+	UnableToListStepInTargets  = 2013
+	UnableToStepIn             = 2014
 	// Add more codes as we support more requests
 	DebuggeeIsRunning = 4000
 	DisconnectError   = 5000
@@ -103,6 +103,10 @@ type Server struct {
 	// stackFrameHandles maps frames of each goroutine to unique ids across all goroutines.
 	// Reset at every stop.
 	stackFrameHandles *handlesMap
+	// stepInTargetHandles maps the targets returned by stepInTargets
+	// requests to the address of their call instruction.
+	// Reset at every stop.
+	stepInTargetHandles *handlesMap
 	// variableHandles maps compound variables to unique references within their stack frame.
 	// Reset at every stop.
 	// See also comment for convertVariable.
@@ -205,14 +209,15 @@ func NewServer(config *service.Config) *Server {
 	logflags.WriteDAPListeningMessage(config.Listener.Addr().String())
 	logger.Debug("DAP server pid = ", os.Getpid())
 	return &Server{
-		config:            config,
-		listener:          config.Listener,
-		stopTriggered:     make(chan struct{}),
-		log:               logger,
-		stackFrameHandles: newHandlesMap(),
-		variableHandles:   newVariablesHandlesMap(),
-		args:              defaultArgs,
-		exceptionErr:      nil,
+		config:              config,
+		listener:            config.Listener,
+		stopTriggered:       make(chan struct{}),
+		log:                 logger,
+		stackFrameHandles:   newHandlesMap(),
+		stepInTargetHandles: newHandlesMap(),
+		variableHandles:     newVariablesHandlesMap(),
+		args:                defaultArgs,
+		exceptionErr:        nil,
 	}
 }
 
@@ -625,6 +630,9 @@ func (s *Server) handleRequest(request dap.Message) {
 	case *dap.ExceptionInfoRequest:
 		// Optional (capability ‘supportsExceptionInfoRequest’)
 		s.onExceptionInfoRequest(request)
+	case *dap.StepInTargetsRequest:
+		// Optional (capability ‘supportsStepInTargetsRequest’)
+		s.onStepInTargetsRequest(request)
 	//--- Requests that we do not plan to support ---
 	case *dap.RestartFrameRequest:
 		// Optional (capability ’supportsRestartFrame’)
@@ -640,9 +648,6 @@ func (s *Server) handleRequest(request dap.Message) {
 	case *dap.TerminateThreadsRequest:
 		// Optional (capability ‘supportsTerminateThreadsRequest’)
 		s.sendUnsupportedErrorResponse(request.Request)
-	case *dap.StepInTargetsRequest:
-		// Optional (capability ‘supportsStepInTargetsRequest’)
-		s.sendUnsupportedErrorResponse(request.Request)
 	case *dap.GotoTargetsRequest:
 		// Optional (capability ‘supportsGotoTargetsRequest’)
 		s.sendUnsupportedErrorResponse(request.Request)
@@ -651,7 +656,8 @@ func (s *Server) handleRequest(request dap.Message) {
 		s.sendUnsupportedErrorResponse(request.Request)
 	case *dap.DataBreakpointInfoRequest:
 		// Optional (capability ‘supportsDataBreakpoints’)
-		s.sendUnsupportedErrorResponse(request.Request)
+		// Not advertised until setDataBreakpoints is supported.
+		s.onDataBreakpointInfoRequest(request)
 	case *dap.SetDataBreakpointsRequest:
 		// Optional (capability ‘supportsDataBreakpoints’)
 		s.sendUnsupportedErrorResponse(request.Request)
@@ -717,8 +723,10 @@ func (s *Server) onInitializeRequest(request *dap.InitializeRequest) {
 	response.Body.SupportsFunctionBreakpoints = true
 	response.Body.SupportsExceptionInfoRequest = true
 	response.Body.SupportsSetVariable = true
+	response.Body.SupportsValueFormattingOptions = true
 	response.Body.SupportsEvaluateForHovers = true
 	response.Body.SupportsClipboardContext = true
+	response.Body.SupportsStepInTargetsRequest = true
 	// TODO(polina): support these requests in addition to vscode-go feature parity
 	response.Body.SupportsTerminateRequest = false
 	response.Body.SupportsRestartRequest = false
@@ -1181,7 +1189,7 @@ func (s *Server) onSetBreakpointsRequest(request *dap.SetBreakpointsRequest) {
 			bpAdded[reqString] = struct{}{}
 		}
 
-		updateBreakpointsResponse(breakpoints, i, err, got, clientPath)
+		s.updateBreakpointsResponse(breakpoints, i, err, got, clientPath)
 	}
 
 	// Clear existing breakpoints that were not added.
@@ -1208,7 +1216,7 @@ func (s *Server) onSetBreakpointsRequest(request *dap.SetBreakpointsRequest) {
 			bpAdded[reqString] = struct{}{}
 		}
 
-		updateBreakpointsResponse(breakpoints, i, err, got, clientPath)
+		s.updateBreakpointsResponse(breakpoints, i, err, got, clientPath)
 	}
 	response := &dap.SetBreakpointsResponse{Response: *newResponse(request.Request)}
 	response.Body.Breakpoints = breakpoints
@@ -1216,7 +1224,7 @@ func (s *Server) onSetBreakpointsRequest(request *dap.SetBreakpointsRequest) {
 	s.send(response)
 }
 
-func updateBreakpointsResponse(breakpoints []dap.Breakpoint, i int, err error, got *api.Breakpoint, path string) {
+func (s *Server) updateBreakpointsResponse(breakpoints []dap.Breakpoint, i int, err error, got *api.Breakpoint, path string) {
 	breakpoints[i].Verified = (err == nil)
 	if err != nil {
 		breakpoints[i].Message = err.Error()
@@ -1224,9 +1232,29 @@ func updateBreakpointsResponse(breakpoints []dap.Breakpoint, i int, err error, g
 		breakpoints[i].Id = got.ID
 		breakpoints[i].Line = got.Line
 		breakpoints[i].Source = dap.Source{Name: filepath.Base(path), Path: path}
+		breakpoints[i].Message = s.physicalBreakpointsMessage(got.ID)
 	}
 }
 
+// physicalBreakpointsMessage describes the addresses of the breakpoint with
+// the specified ID where a physical breakpoint could not be set, if any.
+func (s *Server) physicalBreakpointsMessage(id int) string {
+	pbps, err := s.debugger.PhysicalBreakpoints(id)
+	if err != nil {
+		return ""
+	}
+	var failed []string
+	for _, pbp := range pbps {
+		if pbp.Error != "" {
+			failed = append(failed, fmt.Sprintf("%s:%d (%#x): %s", pbp.File, pbp.Line, pbp.Addr, pbp.Error))
+		}
+	}
+	if len(failed) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("could not set breakpoint at %d of %d addresses: %s", len(failed), len(pbps), strings.Join(failed, "; "))
+}
+
 // functionBpPrefix is the prefix of bp.Name for every breakpoint bp set
 // in this request.
 const functionBpPrefix = "functionBreakpoint"
@@ -1280,7 +1308,7 @@ func (s *Server) onSetFunctionBreakpointsRequest(request *dap.SetFunctionBreakpo
 		if got != nil {
 			clientPath = s.toClientPath(got.File)
 		}
-		updateBreakpointsResponse(breakpoints, i, err, got, clientPath)
+		s.updateBreakpointsResponse(breakpoints, i, err, got, clientPath)
 	}
 
 	// Clear existing breakpoints that were not added.
@@ -1342,7 +1370,7 @@ func (s *Server) onSetFunctionBreakpointsRequest(request *dap.SetFunctionBreakpo
 		if got != nil {
 			clientPath = s.toClientPath(got.File)
 		}
-		updateBreakpointsResponse(breakpoints, i, err, got, clientPath)
+		s.updateBreakpointsResponse(breakpoints, i, err, got, clientPath)
 	}
 
 	response := &dap.SetFunctionBreakpointsResponse{Response: *newResponse(request.Request)}
@@ -1413,7 +1441,7 @@ func (s *Server) onConfigurationDoneRequest(request *dap.ConfigurationDoneReques
 	}
 	s.send(&dap.ConfigurationDoneResponse{Response: *newResponse(request.Request)})
 	if !s.args.stopOnEntry {
-		s.doRunCommand(api.Continue, asyncSetupDone)
+		s.doRunCommand(&api.DebuggerCommand{Name: api.Continue}, asyncSetupDone)
 	}
 }
 
@@ -1423,7 +1451,7 @@ func (s *Server) onContinueRequest(request *dap.ContinueRequest, asyncSetupDone
 	s.send(&dap.ContinueResponse{
 		Response: *newResponse(request.Request),
 		Body:     dap.ContinueResponseBody{AllThreadsContinued: true}})
-	s.doRunCommand(api.Continue, asyncSetupDone)
+	s.doRunCommand(&api.DebuggerCommand{Name: api.Continue}, asyncSetupDone)
 }
 
 func fnName(loc *proc.Location) string {
@@ -1576,21 +1604,33 @@ func (s *Server) onAttachRequest(request *dap.AttachRequest) {
 // This is a mandatory request to support.
 func (s *Server) onNextRequest(request *dap.NextRequest, asyncSetupDone chan struct{}) {
 	s.sendStepResponse(request.Arguments.ThreadId, &dap.NextResponse{Response: *newResponse(request.Request)})
-	s.doStepCommand(api.Next, request.Arguments.ThreadId, asyncSetupDone)
+	s.doStepCommand(&api.DebuggerCommand{Name: api.Next}, request.Arguments.ThreadId, asyncSetupDone)
 }
 
 // onStepInRequest handles 'stepIn' request
 // This is a mandatory request to support.
+// If a target returned by a 'stepInTargets' request is specified the
+// debugger steps directly into the function called by that target.
 func (s *Server) onStepInRequest(request *dap.StepInRequest, asyncSetupDone chan struct{}) {
+	command := &api.DebuggerCommand{Name: api.Step}
+	if request.Arguments.TargetId != 0 {
+		pc, ok := s.stepInTargetHandles.get(request.Arguments.TargetId)
+		if !ok {
+			defer s.asyncCommandDone(asyncSetupDone)
+			s.sendErrorResponse(request.Request, UnableToStepIn, "Unable to step in", fmt.Sprintf("unknown step in target %d", request.Arguments.TargetId))
+			return
+		}
+		command = &api.DebuggerCommand{Name: api.StepInto, Addrs: []uint64{pc.(uint64)}}
+	}
 	s.sendStepResponse(request.Arguments.ThreadId, &dap.StepInResponse{Response: *newResponse(request.Request)})
-	s.doStepCommand(api.Step, request.Arguments.ThreadId, asyncSetupDone)
+	s.doStepCommand(command, request.Arguments.ThreadId, asyncSetupDone)
 }
 
 // onStepOutRequest handles 'stepOut' request
 // This is a mandatory request to support.
 func (s *Server) onStepOutRequest(request *dap.StepOutRequest, asyncSetupDone chan struct{}) {
 	s.sendStepResponse(request.Arguments.ThreadId, &dap.StepOutResponse{Response: *newResponse(request.Request)})
-	s.doStepCommand(api.StepOut, request.Arguments.ThreadId, asyncSetupDone)
+	s.doStepCommand(&api.DebuggerCommand{Name: api.StepOut}, request.Arguments.ThreadId, asyncSetupDone)
 }
 
 func (s *Server) sendStepResponse(threadId int, message dap.Message) {
@@ -1620,7 +1660,7 @@ func stoppedGoroutineID(state *api.DebuggerState) (id int) {
 // a channel that will be closed to signal that an
 // asynchornous command has completed setup or was interrupted
 // due to an error, so the server is ready to receive new requests.
-func (s *Server) doStepCommand(command string, threadId int, asyncSetupDone chan struct{}) {
+func (s *Server) doStepCommand(command *api.DebuggerCommand, threadId int, asyncSetupDone chan struct{}) {
 	defer s.asyncCommandDone(asyncSetupDone)
 	_, err := s.debugger.Command(&api.DebuggerCommand{Name: api.SwitchGoroutine, GoroutineID: threadId}, nil)
 	if err != nil {
@@ -1848,7 +1888,11 @@ func (s *Server) onVariablesRequest(request *dap.VariablesRequest) {
 		children = append(children, named...)
 	}
 	if request.Arguments.Filter == "indexed" || request.Arguments.Filter == "" {
-		indexed, err := s.childrenToDAPVariables(v)
+		var opts convertVariableFlags
+		if request.Arguments.Format.Hex {
+			opts |= hexFormat
+		}
+		indexed, err := s.childrenToDAPVariables(v, opts)
 		if err != nil {
 			s.sendErrorResponse(request.Request, UnableToLookupVariable, "Unable to lookup variable", err.Error())
 			return
@@ -1887,7 +1931,8 @@ func getIndexedVariableCount(c *proc.Variable) int {
 }
 
 // childrenToDAPVariables returns the DAP presentation of the referenced variable's children.
-func (s *Server) childrenToDAPVariables(v *fullyQualifiedVariable) ([]dap.Variable, error) {
+// The values of the children are converted with opts, see convertVariableWithOpts.
+func (s *Server) childrenToDAPVariables(v *fullyQualifiedVariable, opts convertVariableFlags) ([]dap.Variable, error) {
 	// TODO(polina): consider convertVariableToString instead of convertVariable
 	// and avoid unnecessary creation of variable handles when this is called to
 	// compute evaluate names when this is called from onSetVariableRequest.
@@ -1913,8 +1958,8 @@ func (s *Server) childrenToDAPVariables(v *fullyQualifiedVariable) ([]dap.Variab
 					valexpr = fmt.Sprintf("%s[%q]", v.fullyQualifiedNameOrExpr, key)
 				}
 			}
-			key, keyref := s.convertVariable(keyv, keyexpr)
-			val, valref := s.convertVariable(valv, valexpr)
+			key, keyref := s.convertVariableWithOpts(keyv, keyexpr, opts)
+			val, valref := s.convertVariableWithOpts(valv, valexpr, opts)
 			keyType := s.getTypeIfSupported(keyv)
 			valType := s.getTypeIfSupported(valv)
 			// If key or value or both are scalars, we can use
@@ -1972,7 +2017,7 @@ func (s *Server) childrenToDAPVariables(v *fullyQualifiedVariable) ([]dap.Variab
 		for i := range v.Children {
 			idx := v.startIndex + i
 			cfqname := fmt.Sprintf("%s[%d]", v.fullyQualifiedNameOrExpr, idx)
-			cvalue, cvarref := s.convertVariable(&v.Children[i], cfqname)
+			cvalue, cvarref := s.convertVariableWithOpts(&v.Children[i], cfqname, opts)
 			children[i] = dap.Variable{
 				Name:               fmt.Sprintf("[%d]", idx),
 				EvaluateName:       cfqname,
@@ -2002,7 +2047,7 @@ func (s *Server) childrenToDAPVariables(v *fullyQualifiedVariable) ([]dap.Variab
 			} else if v.Kind == reflect.Complex64 || v.Kind == reflect.Complex128 {
 				cfqname = "" // complex children are not struct fields and can't be accessed directly
 			}
-			cvalue, cvarref := s.convertVariable(c, cfqname)
+			cvalue, cvarref := s.convertVariableWithOpts(c, cfqname, opts)
 
 			// Annotate any shadowed variables to "(name)" in order
 			// to distinguish from non-shadowed variables.
@@ -2125,6 +2170,8 @@ type convertVariableFlags uint8
 const (
 	skipRef convertVariableFlags = 1 << iota
 	showFullValue
+	// hexFormat prints integers in hexadecimal, see api.FormatHex.
+	hexFormat
 )
 
 // convertVariableWithOpts allows to skip reference generation in case all we need is
@@ -2140,7 +2187,14 @@ func (s *Server) convertVariableWithOpts(v *proc.Variable, qualifiedNameOrExpr s
 		}
 		return s.variableHandles.create(&fullyQualifiedVariable{v, qualifiedNameOrExpr, false /*not a scope*/, 0})
 	}
-	value = api.ConvertVar(v).SinglelineString()
+	stringify := func(v *proc.Variable) string {
+		if opts&hexFormat != 0 {
+			value, _ := api.ConvertVar(v).SinglelineStringWithVerb(api.FormatHex)
+			return value
+		}
+		return api.ConvertVar(v).SinglelineString()
+	}
+	value = stringify(v)
 	if v.Unreadable != nil {
 		return value, 0
 	}
@@ -2154,7 +2208,7 @@ func (s *Server) convertVariableWithOpts(v *proc.Variable, qualifiedNameOrExpr s
 		// TODO(polina): Get *proc.Variable object from debugger instead. Export a function to set v.loaded to false
 		// and call v.loadValue gain with a different load config. It's more efficient, and it's guaranteed to keep
 		// working with generics.
-		value = api.ConvertVar(v).SinglelineString()
+		value = stringify(v)
 		typeName := api.PrettyTypeName(v.DwarfType)
 		loadExpr := fmt.Sprintf("*(*%q)(%#x)", typeName, v.Addr)
 		s.log.Debugf("loading %s (type %s) with %s", qualifiedNameOrExpr, typeName, loadExpr)
@@ -2167,7 +2221,7 @@ func (s *Server) convertVariableWithOpts(v *proc.Variable, qualifiedNameOrExpr s
 			value += fmt.Sprintf(" - FAILED TO LOAD: %s", err)
 		} else {
 			v.Children = vLoaded.Children
-			value = api.ConvertVar(v).SinglelineString()
+			value = stringify(v)
 		}
 		return value
 	}
@@ -2196,7 +2250,7 @@ func (s *Server) convertVariableWithOpts(v *proc.Variable, qualifiedNameOrExpr s
 					} else {
 						cLoaded.Name = v.Children[0].Name // otherwise, this will be the pointer expression
 						v.Children = []proc.Variable{*cLoaded}
-						value = api.ConvertVar(v).SinglelineString()
+						value = stringify(v)
 					}
 				} else {
 					value = reloadVariable(v, qualifiedNameOrExpr)
@@ -2355,6 +2409,9 @@ func (s *Server) onEvaluateRequest(request *dap.EvaluateRequest) {
 		if ctxt == "clipboard" || ctxt == "variables" {
 			opts |= showFullValue
 		}
+		if request.Arguments.Format.Hex {
+			opts |= hexFormat
+		}
 		exprVal, exprRef := s.convertVariableWithOpts(exprVar, fmt.Sprintf("(%s)", request.Arguments.Expression), opts)
 		response.Body = dap.EvaluateResponseBody{Result: exprVal, VariablesReference: exprRef, IndexedVariables: getIndexedVariableCount(exprVar), NamedVariables: getNamedVariableCount(exprVar)}
 	}
@@ -2481,7 +2538,7 @@ func (s *Server) onRestartRequest(request *dap.RestartRequest) {
 // This is an optional request enabled by capability ‘supportsStepBackRequest’.
 func (s *Server) onStepBackRequest(request *dap.StepBackRequest, asyncSetupDone chan struct{}) {
 	s.sendStepResponse(request.Arguments.ThreadId, &dap.StepBackResponse{Response: *newResponse(request.Request)})
-	s.doStepCommand(api.ReverseNext, request.Arguments.ThreadId, asyncSetupDone)
+	s.doStepCommand(&api.DebuggerCommand{Name: api.ReverseNext}, request.Arguments.ThreadId, asyncSetupDone)
 }
 
 // onReverseContinueRequest performs a rewind command call up to the previous
@@ -2491,12 +2548,12 @@ func (s *Server) onReverseContinueRequest(request *dap.ReverseContinueRequest, a
 	s.send(&dap.ReverseContinueResponse{
 		Response: *newResponse(request.Request),
 	})
-	s.doRunCommand(api.Rewind, asyncSetupDone)
+	s.doRunCommand(&api.DebuggerCommand{Name: api.Rewind}, asyncSetupDone)
 }
 
 // computeEvaluateName finds the named child, and computes its evaluate name.
 func (s *Server) computeEvaluateName(v *fullyQualifiedVariable, cname string) (string, error) {
-	children, err := s.childrenToDAPVariables(v)
+	children, err := s.childrenToDAPVariables(v, 0)
 	if err != nil {
 		return "", err
 	}
@@ -2512,6 +2569,55 @@ func (s *Server) computeEvaluateName(v *fullyQualifiedVariable, cname string) (s
 }
 
 // onSetVariableRequest handles 'setVariable' requests.
+// onDataBreakpointInfoRequest handles 'dataBreakpointInfo' requests.
+// The variable is evaluated in the topmost frame of the current goroutine
+// to check that it can be watched. The description reports how many
+// hardware watchpoints are in use; when all are in use the watchpoint
+// is implemented in software.
+func (s *Server) onDataBreakpointInfoRequest(request *dap.DataBreakpointInfoRequest) {
+	arg := request.Arguments
+
+	evaluateName := arg.Name
+	if arg.VariablesReference > 0 {
+		v, ok := s.variableHandles.get(arg.VariablesReference)
+		if !ok {
+			s.sendErrorResponse(request.Request, UnableToLookupVariable, "Unable to lookup variable", fmt.Sprintf("unknown reference %d", arg.VariablesReference))
+			return
+		}
+		var err error
+		evaluateName, err = s.computeEvaluateName(v, arg.Name)
+		if err != nil {
+			s.sendErrorResponse(request.Request, UnableToLookupVariable, "Unable to lookup variable", err.Error())
+			return
+		}
+	}
+
+	response := &dap.DataBreakpointInfoResponse{Response: *newResponse(request.Request)}
+	goid, frame := -1, 0
+	evaluated, err := s.debugger.EvalVariableInScope(goid, frame, 0, evaluateName, proc.LoadConfig{})
+	if err == nil && evaluated.Addr == 0 {
+		err = errors.New("value has no address")
+	}
+	if err != nil {
+		// a nil DataId tells the client that no data breakpoint can be set
+		response.Body.Description = fmt.Sprintf("%s can not be watched: %v", evaluateName, err)
+		s.send(response)
+		return
+	}
+
+	response.Body.DataId = evaluateName
+	response.Body.AccessTypes = []dap.DataBreakpointAccessType{"write", "read", "readWrite"}
+	switch slots := s.debugger.WatchpointSlots(); {
+	case slots.Total == 0:
+		response.Body.Description = fmt.Sprintf("%s (software watchpoint)", evaluateName)
+	case slots.Used >= slots.Total:
+		response.Body.Description = fmt.Sprintf("%s (all %d hardware watchpoints in use, a software watchpoint will be used)", evaluateName, slots.Total)
+	default:
+		response.Body.Description = fmt.Sprintf("%s (%d of %d hardware watchpoints in use)", evaluateName, slots.Used, slots.Total)
+	}
+	s.send(response)
+}
+
 func (s *Server) onSetVariableRequest(request *dap.SetVariableRequest) {
 	arg := request.Arguments
 
@@ -2577,7 +2683,7 @@ func (s *Server) onSetVariableRequest(request *dap.SetVariableRequest) {
 			return
 		}
 	} else {
-		if err := s.debugger.SetVariableInScope(goid, frame, 0, evaluateName, arg.Value); err != nil {
+		if err := s.debugger.SetVariableInScope(goid, frame, 0, evaluateName, arg.Value, false); err != nil {
 			s.sendErrorResponse(request.Request, UnableToSetVariable, "Unable to set variable", err.Error())
 			return
 		}
@@ -2636,6 +2742,42 @@ func (s *Server) onCancelRequest(request *dap.CancelRequest) {
 	s.sendNotYetImplementedErrorResponse(request.Request)
 }
 
+// onStepInTargetsRequest handles 'stepInTargets' requests.
+// Capability 'supportsStepInTargetsRequest' is set in 'initialize' response.
+// Only the topmost frame of a goroutine has targets, the ids returned can
+// be used as the target of a 'stepIn' request until the next stop.
+func (s *Server) onStepInTargetsRequest(request *dap.StepInTargetsRequest) {
+	response := &dap.StepInTargetsResponse{Response: *newResponse(request.Request)}
+	response.Body.Targets = []dap.StepInTarget{}
+	sf, ok := s.stackFrameHandles.get(request.Arguments.FrameId)
+	if !ok {
+		s.sendErrorResponse(request.Request, UnableToListStepInTargets, "Unable to list step in targets", fmt.Sprintf("unknown frame id %d", request.Arguments.FrameId))
+		return
+	}
+	frame := sf.(stackFrame)
+	if frame.frameIndex != 0 {
+		s.send(response)
+		return
+	}
+	if _, err := s.debugger.Command(&api.DebuggerCommand{Name: api.SwitchGoroutine, GoroutineID: frame.goroutineID}, nil); err != nil {
+		s.sendErrorResponse(request.Request, UnableToListStepInTargets, "Unable to list step in targets", err.Error())
+		return
+	}
+	tgts, err := s.debugger.StepIntoTargets()
+	if err != nil {
+		s.sendErrorResponse(request.Request, UnableToListStepInTargets, "Unable to list step in targets", err.Error())
+		return
+	}
+	for _, tgt := range tgts {
+		label := fmt.Sprintf("<indirect call at %#x>", tgt.PC)
+		if tgt.Function != nil {
+			label = tgt.Function.Name()
+		}
+		response.Body.Targets = append(response.Body.Targets, dap.StepInTarget{Id: s.stepInTargetHandles.create(tgt.PC), Label: label})
+	}
+	s.send(response)
+}
+
 // onExceptionInfoRequest handles 'exceptionInfo' requests.
 // Capability 'supportsExceptionInfoRequest' is set in 'initialize' response.
 func (s *Server) onExceptionInfoRequest(request *dap.ExceptionInfoRequest) {
@@ -2822,6 +2964,7 @@ Unable to propagate EXC_BAD_ACCESS signal to target process and panic (see https
 
 func (s *Server) resetHandlesForStoppedEvent() {
 	s.stackFrameHandles.reset()
+	s.stepInTargetHandles.reset()
 	s.variableHandles.reset()
 	s.exceptionErr = nil
 }
@@ -2837,12 +2980,12 @@ func processExited(state *api.DebuggerState, err error) bool {
 // a channel that will be closed to signal that an
 // asynchornous command has completed setup or was interrupted
 // due to an error, so the server is ready to receive new requests.
-func (s *Server) doRunCommand(command string, asyncSetupDone chan struct{}) {
+func (s *Server) doRunCommand(command *api.DebuggerCommand, asyncSetupDone chan struct{}) {
 	// TODO(polina): it appears that debugger.Command doesn't always close
 	// asyncSetupDone (e.g. when having an error next while nexting).
 	// So we should always close it ourselves just in case.
 	defer s.asyncCommandDone(asyncSetupDone)
-	state, err := s.debugger.Command(&api.DebuggerCommand{Name: command}, asyncSetupDone)
+	state, err := s.debugger.Command(command, asyncSetupDone)
 	if processExited(state, err) {
 		s.send(&dap.TerminatedEvent{Event: *newEvent("terminated")})
 		return
@@ -2853,7 +2996,7 @@ func (s *Server) doRunCommand(command string, asyncSetupDone chan struct{}) {
 	if state != nil && state.CurrentThread != nil {
 		file, line = state.CurrentThread.File, state.CurrentThread.Line
 	}
-	s.log.Debugf("%q command stopped - reason %q, location %s:%d", command, stopReason, file, line)
+	s.log.Debugf("%q command stopped - reason %q, location %s:%d", command.Name, stopReason, file, line)
 
 	s.resetHandlesForStoppedEvent()
 	stopped := &dap.StoppedEvent{Event: *newEvent("stopped")}
@@ -2893,6 +3036,9 @@ func (s *Server) doRunCommand(command string, asyncSetupDone chan struct{}) {
 			}
 			stopped.Body.HitBreakpointIds = []int{state.CurrentThread.Breakpoint.ID}
 		}
+		if stopped.Body.Text == "" && state.Position != nil {
+			stopped.Body.Text = state.Position.String()
+		}
 	} else {
 		s.exceptionErr = err
 		s.log.Error("runtime error: ", err)
@@ -1217,11 +1217,14 @@ func TestScopesAndVariablesRequests2(t *testing.T) {
 					if ref > 0 {
 						client.VariablesRequest(ref)
 						ch1 := client.ExpectVariablesResponse(t)
-						checkChildren(t, ch1, "ch1", 11)
+						checkChildren(t, ch1, "ch1", 14)
 						checkVarExact(t, ch1, 0, "qcount", "ch1.qcount", "4", "uint", noChildren)
 						checkVarRegex(t, ch1, 10, "lock", "ch1.lock", `runtime\.mutex {.*key: 0.*}`, `runtime\.mutex`, hasChildren)
+						checkVarExact(t, ch1, 11, "buffered", "ch1.buffered", "[]int len: 4, cap: 4, [1,4,3,2]", "[]int", hasChildren)
+						checkVarRegex(t, ch1, 12, "recvWaiting", "ch1.recvWaiting", `\[\]u?int64 len: 0, cap: 0, nil`, `\[\]u?int64`, noChildren)
 						validateEvaluateName(t, client, ch1, 0)
 						validateEvaluateName(t, client, ch1, 10)
+						validateEvaluateName(t, client, ch1, 11)
 					}
 					checkVarExact(t, locals, -1, "chnil", "chnil", "chan int nil", "chan int", noChildren)
 					// reflect.Kind == Func
@@ -2865,6 +2868,20 @@ func TestEvaluateRequest(t *testing.T) {
 						validateEvaluateName(t, client, a5, 4)
 					}
 
+					// Hexadecimal format
+					client.EvaluateRequestWithFormat("a2", 1000, "repl", dap.ValueFormat{Hex: true})
+					got = client.ExpectEvaluateResponse(t)
+					checkEval(t, got, "0x6", noChildren)
+
+					client.EvaluateRequestWithFormat("a5", 1000, "repl", dap.ValueFormat{Hex: true})
+					got = client.ExpectEvaluateResponse(t)
+					ref = checkEval(t, got, "[]int len: 5, cap: 5, [0x1,0x2,0x3,0x4,0x5]", hasChildren)
+					if ref > 0 {
+						client.VariablesRequestWithFormat(ref, dap.ValueFormat{Hex: true})
+						a5 := client.ExpectVariablesResponse(t)
+						checkVarExact(t, a5, 4, "[4]", "(a5)[4]", "0x5", "int", noChildren)
+					}
+
 					// Variable lookup that's not fully loaded
 					client.EvaluateRequest("ba", 1000, "this context will be ignored")
 					got = client.ExpectEvaluateResponse(t)
@@ -4160,18 +4177,12 @@ func TestUnupportedCommandResponses(t *testing.T) {
 		client.TerminateThreadsRequest()
 		expectUnsupportedCommand("terminateThreads")
 
-		client.StepInTargetsRequest()
-		expectUnsupportedCommand("stepInTargets")
-
 		client.GotoTargetsRequest()
 		expectUnsupportedCommand("gotoTargets")
 
 		client.CompletionsRequest()
 		expectUnsupportedCommand("completions")
 
-		client.DataBreakpointInfoRequest()
-		expectUnsupportedCommand("dataBreakpointInfo")
-
 		client.SetDataBreakpointsRequest()
 		expectUnsupportedCommand("setDataBreakpoints")
 
@@ -4183,6 +4194,40 @@ func TestUnupportedCommandResponses(t *testing.T) {
 	})
 }
 
+func TestDataBreakpointInfo(t *testing.T) {
+	runTest(t, "testvariables", func(client *daptest.Client, fixture protest.Fixture) {
+		runDebugSessionWithBPs(t, client, "launch",
+			func() {
+				client.LaunchRequest("exec", fixture.Path, !stopOnEntry)
+			},
+			fixture.Source, []int{}, // breakpoints are set within the program.
+			[]onBreakpoint{{
+				execute: func() {
+					client.StackTraceRequest(1, 0, 20)
+					client.ExpectStackTraceResponse(t)
+					client.ScopesRequest(1000)
+					client.ExpectScopesResponse(t)
+
+					client.DataBreakpointInfoRequest(1001, "a2")
+					got := client.ExpectDataBreakpointInfoResponse(t)
+					if got.Body.DataId != "a2" || !strings.Contains(got.Body.Description, "watchpoint") {
+						t.Errorf("got %#v, want DataId=a2 and a description of the watchpoint slots", got.Body)
+					}
+
+					client.DataBreakpointInfoRequest(0, "a2+1")
+					got = client.ExpectDataBreakpointInfoResponse(t)
+					if got.Body.DataId != nil || !strings.Contains(got.Body.Description, "can not be watched") {
+						t.Errorf("got %#v, want DataId=nil", got.Body)
+					}
+
+					client.DataBreakpointInfoRequest(1001, "nosuchvariable")
+					client.ExpectErrorResponse(t)
+				},
+				disconnect: true,
+			}})
+	})
+}
+
 type helperForSetVariable struct {
 	t *testing.T
 	c *daptest.Client
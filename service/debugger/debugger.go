@@ -1,7 +1,6 @@
 package debugger
 
 import (
-	"bytes"
 	"debug/dwarf"
 	"errors"
 	"fmt"
@@ -76,6 +75,9 @@ type Debugger struct {
 	// so lower layers like proc doesn't need to deal
 	// with them
 	disabledBreakpoints map[int]*api.Breakpoint
+	// failedBreakpoints maps the ID of a logical breakpoint to the list of
+	// its addresses where a physical breakpoint could not be set.
+	failedBreakpoints map[int][]api.PhysicalBreakpoint
 }
 
 type ExecuteKind int
@@ -137,6 +139,23 @@ type Config struct {
 
 	// DisableASLR disables ASLR
 	DisableASLR bool
+
+	// RecordOptions are the options used to record the target when Backend
+	// is "rr".
+	RecordOptions gdbserial.RecordOptions
+
+	// ReplayOnProcess selects the process of the trace that is debugged
+	// when Backend is "rr", either a pid or a regular expression matched
+	// against the command line of the recorded processes.
+	ReplayOnProcess string
+
+	// Formatters are the formatters registered when the target is created.
+	Formatters []api.Formatter
+
+	// LogpointOutput, if set, receives every message produced by a logpoint
+	// as soon as the logpoint is hit, instead of buffering it until it is
+	// retrieved by GetBufferedLogpoints.
+	LogpointOutput func(api.LogpointMessage)
 }
 
 // New creates a new Debugger. ProcessArgs specify the commandline arguments for the
@@ -149,6 +168,10 @@ func New(config *Config, processArgs []string) (*Debugger, error) {
 		log:         logger,
 	}
 
+	if _, err := d.configFormatters(); err != nil {
+		return nil, err
+	}
+
 	// Create the process by either attaching or launching.
 	switch {
 	case d.config.AttachPid > 0:
@@ -170,7 +193,7 @@ func New(config *Config, processArgs []string) (*Debugger, error) {
 		switch d.config.Backend {
 		case "rr":
 			d.log.Infof("opening trace %s", d.config.CoreFile)
-			p, err = gdbserial.Replay(d.config.CoreFile, false, false, d.config.DebugInfoDirectories)
+			p, err = gdbserial.Replay(d.config.CoreFile, false, false, d.config.DebugInfoDirectories, d.config.ReplayOnProcess)
 		default:
 			d.log.Infof("opening core file %s (executable %s)", d.config.CoreFile, d.processArgs[0])
 			p, err = core.OpenCore(d.config.CoreFile, d.processArgs[0], d.config.DebugInfoDirectories)
@@ -206,10 +229,30 @@ func New(config *Config, processArgs []string) (*Debugger, error) {
 	}
 
 	d.disabledBreakpoints = make(map[int]*api.Breakpoint)
+	d.failedBreakpoints = make(map[int][]api.PhysicalBreakpoint)
+	if d.target != nil {
+		d.target.SetSharedObjectsLoadedCallback(d.sharedObjectsLoaded)
+		d.target.SetLogpointOutput(d.logpointOutput())
+		d.target.SetMaxHitsCallback(d.maxHitsReached)
+		formatters, _ := d.configFormatters()
+		d.target.SetFormatters(formatters)
+	}
 
 	return d, nil
 }
 
+// configFormatters returns the list of formatters specified by the
+// configuration.
+func (d *Debugger) configFormatters() (*proc.FormatterList, error) {
+	l := &proc.FormatterList{}
+	for _, f := range d.config.Formatters {
+		if _, err := l.Add(f.TypeName, f.Format, f.CallString); err != nil {
+			return nil, fmt.Errorf("invalid formatter for %s: %v", f.TypeName, err)
+		}
+	}
+	return l, nil
+}
+
 // canRestart returns true if the target was started with Launch and can be restarted
 func (d *Debugger) canRestart() bool {
 	switch {
@@ -268,7 +311,7 @@ func (d *Debugger) Launch(processArgs []string, wd string) (*proc.Target, error)
 			panic("internal error: call to Launch with rr backend and target already exists")
 		}
 
-		run, stop, err := gdbserial.RecordAsync(processArgs, wd, false, d.config.Redirects)
+		run, stop, err := gdbserial.RecordAsync(processArgs, wd, false, d.config.Redirects, d.config.RecordOptions)
 		if err != nil {
 			return nil, err
 		}
@@ -291,6 +334,11 @@ func (d *Debugger) Launch(processArgs []string, wd string) (*proc.Target, error)
 			}
 			d.recordingDone()
 			d.target = p
+			d.target.SetSharedObjectsLoadedCallback(d.sharedObjectsLoaded)
+			d.target.SetLogpointOutput(d.logpointOutput())
+			d.target.SetMaxHitsCallback(d.maxHitsReached)
+			formatters, _ := d.configFormatters()
+			d.target.SetFormatters(formatters)
 			if err := d.checkGoVersion(); err != nil {
 				d.log.Error(err)
 				err := d.target.Detach(true)
@@ -335,7 +383,8 @@ func (d *Debugger) recordingRun(run func() (string, error)) (*proc.Target, error
 		return nil, err
 	}
 
-	return gdbserial.Replay(tracedir, false, true, d.config.DebugInfoDirectories)
+	// traces saved to a directory chosen by the user are not deleted
+	return gdbserial.Replay(tracedir, false, d.config.RecordOptions.OutputTraceDir == "", d.config.DebugInfoDirectories, d.config.ReplayOnProcess)
 }
 
 // Attach will attach to the process specified by 'pid'.
@@ -499,7 +548,7 @@ func (d *Debugger) Restart(rerecord bool, pos string, resetArgs bool, newArgs []
 	}
 
 	if recorded {
-		run, stop, err2 := gdbserial.RecordAsync(d.processArgs, d.config.WorkingDir, false, d.config.Redirects)
+		run, stop, err2 := gdbserial.RecordAsync(d.processArgs, d.config.WorkingDir, false, d.config.Redirects, d.config.RecordOptions.Rerecord())
 		if err2 != nil {
 			return nil, err2
 		}
@@ -515,8 +564,22 @@ func (d *Debugger) Restart(rerecord bool, pos string, resetArgs bool, newArgs []
 	}
 
 	discarded := []api.DiscardedBreakpoint{}
-	breakpoints := api.ConvertBreakpoints(d.breakpoints())
+	oldBps := d.breakpoints()
+	watchPackages := make(map[int]string)
+	for _, bp := range oldBps {
+		if bp.WatchPackage != "" {
+			watchPackages[bp.LogicalID] = bp.WatchPackage
+		}
+	}
+	breakpoints := api.ConvertBreakpoints(oldBps)
+	oldBi := d.target.BinInfo()
+	p.SetStepSkips(d.target.StepSkips())
+	p.SetFormatters(d.target.Formatters())
 	d.target = p
+	d.failedBreakpoints = make(map[int][]api.PhysicalBreakpoint)
+	d.target.SetSharedObjectsLoadedCallback(d.sharedObjectsLoaded)
+	d.target.SetLogpointOutput(d.logpointOutput())
+	d.target.SetMaxHitsCallback(d.maxHitsReached)
 	maxID := 0
 	for _, oldBp := range breakpoints {
 		if oldBp.ID < 0 {
@@ -526,9 +589,33 @@ func (d *Debugger) Restart(rerecord bool, pos string, resetArgs bool, newArgs []
 			maxID = oldBp.ID
 		}
 		if oldBp.WatchExpr != "" {
-			discarded = append(discarded, api.DiscardedBreakpoint{Breakpoint: oldBp, Reason: "can not recreate watchpoints on restart"})
-		} else if len(oldBp.File) > 0 {
-			addrs, err := proc.FindFileLocation(p, oldBp.File, oldBp.Line)
+			pkg, ok := watchPackages[oldBp.ID]
+			if oldBp.WatchRaw {
+				discarded = append(discarded, api.DiscardedBreakpoint{Breakpoint: oldBp, Reason: "can not recreate watchpoints on addresses on restart"})
+				continue
+			}
+			if !ok {
+				discarded = append(discarded, api.DiscardedBreakpoint{Breakpoint: oldBp, Reason: "can not recreate watchpoints on stack variables on restart"})
+				continue
+			}
+			// Watchpoints on package-level variables are set again by
+			// evaluating their expression in the scope of their package, the
+			// variable could have moved if the executable was rebuilt.
+			if err := d.restoreWatchpoint(p, oldBp, pkg); err != nil {
+				discarded = append(discarded, api.DiscardedBreakpoint{Breakpoint: oldBp, Reason: fmt.Sprintf("could not restore watchpoint: %v", err)})
+			}
+		} else if canSuspend(oldBp) {
+			addrs, err := d.breakpointLocations(oldBp)
+			if err != nil && inSharedObject(oldBi, oldBp) {
+				// The breakpoint was set on a shared object that has not been
+				// loaded yet by the new process.
+				oldBp.Addr = 0
+				oldBp.Addrs = nil
+				oldBp.Disabled = true
+				oldBp.Suspended = true
+				d.disabledBreakpoints[oldBp.ID] = oldBp
+				continue
+			}
 			if err != nil {
 				discarded = append(discarded, api.DiscardedBreakpoint{Breakpoint: oldBp, Reason: err.Error()})
 				continue
@@ -540,7 +627,12 @@ func (d *Debugger) Restart(rerecord bool, pos string, resetArgs bool, newArgs []
 				discarded = append(discarded, api.DiscardedBreakpoint{Breakpoint: oldBp, Reason: "can not recreate address breakpoints on restart"})
 				continue
 			}
-			newBp, err := p.SetBreakpointWithID(oldBp.ID, oldBp.Addr)
+			var newBp *proc.Breakpoint
+			if oldBp.Hardware {
+				newBp, err = p.SetHardwareBreakpointWithID(oldBp.ID, oldBp.Addr)
+			} else {
+				newBp, err = p.SetBreakpointWithID(oldBp.ID, oldBp.Addr)
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -605,6 +697,13 @@ func (d *Debugger) state(retLoadCfg *proc.LoadConfig) (*api.DebuggerState, error
 
 	for _, thread := range d.target.ThreadList() {
 		th := api.ConvertThread(thread)
+		if th.Breakpoint != nil {
+			// the breakpoint was removed from the target by this hit, see
+			// maxHitsReached
+			if dbp, ok := d.disabledBreakpoints[th.Breakpoint.ID]; ok && dbp.AutoDisabled {
+				th.Breakpoint = dbp
+			}
+		}
 
 		th.CallReturn = thread.Common().CallReturn
 		if retLoadCfg != nil {
@@ -619,8 +718,27 @@ func (d *Debugger) state(retLoadCfg *proc.LoadConfig) (*api.DebuggerState, error
 
 	state.NextInProgress = d.target.Breakpoints().HasSteppingBreakpoints()
 
+	for _, wos := range d.target.WatchOutOfScope() {
+		state.WatchOutOfScope = append(state.WatchOutOfScope, api.WatchOutOfScope{
+			Breakpoint: api.ConvertBreakpoint(wos.Breakpoint),
+			LastValue:  wos.LastValue,
+			Location:   api.ConvertLocation(wos.Location),
+		})
+	}
+	for _, m := range d.target.WatchMigrations() {
+		state.WatchMigrations = append(state.WatchMigrations, api.WatchMigration{
+			Breakpoint: api.ConvertBreakpoint(m.Breakpoint),
+			OldAddr:    m.OldAddr,
+			NewAddr:    m.NewAddr,
+			Reason:     m.Reason,
+		})
+	}
+
 	if recorded, _ := d.target.Recorded(); recorded {
 		state.When, _ = d.target.When()
+		if pos, err := d.target.Position(); err == nil {
+			state.Position = &api.RecordingPosition{Event: pos.Event, Ticks: pos.Ticks, TotalEvents: pos.TotalEvents, AtStart: pos.AtStart, AtEnd: pos.AtEnd}
+		}
 	}
 
 	return state, nil
@@ -671,6 +789,10 @@ func (d *Debugger) CreateBreakpoint(requestedBp *api.Breakpoint) (*api.Breakpoin
 	switch {
 	case requestedBp.TraceReturn:
 		addrs = []uint64{requestedBp.Addr}
+	case len(requestedBp.Package) > 0:
+		addrs, requestedBp.FunctionCount, err = packageLocations(d.target, requestedBp.Package, requestedBp.ExcludeWrappers)
+	case len(requestedBp.FunctionRegexp) > 0:
+		addrs, err = functionRegexpLocations(d.target, requestedBp.FunctionRegexp)
 	case len(requestedBp.File) > 0:
 		fileName := requestedBp.File
 		if runtime.GOOS == "windows" {
@@ -687,12 +809,15 @@ func (d *Debugger) CreateBreakpoint(requestedBp *api.Breakpoint) (*api.Breakpoin
 	case len(requestedBp.FunctionName) > 0:
 		addrs, err = proc.FindFunctionLocation(d.target, requestedBp.FunctionName, requestedBp.Line)
 	case len(requestedBp.Addrs) > 0:
-		addrs = requestedBp.Addrs
+		addrs = uniqueAddrs(requestedBp.Addrs)
 	default:
 		addrs = []uint64{requestedBp.Addr}
 	}
 
 	if err != nil {
+		if requestedBp.Suspended && canSuspend(requestedBp) {
+			return d.createSuspendedBreakpoint(requestedBp)
+		}
 		return nil, err
 	}
 
@@ -704,6 +829,160 @@ func (d *Debugger) CreateBreakpoint(requestedBp *api.Breakpoint) (*api.Breakpoin
 	return createdBp, nil
 }
 
+// canSuspend returns true if the location of bp is specified in a way that
+// can be searched again when a shared object is loaded: by package, by
+// function regular expression, by file and line or by function name.
+func canSuspend(bp *api.Breakpoint) bool {
+	return len(bp.Package) > 0 || len(bp.FunctionRegexp) > 0 || len(bp.File) > 0 || len(bp.FunctionName) > 0
+}
+
+// createSuspendedBreakpoint creates a breakpoint on the location of
+// requestedBp, see canSuspend. If the location can not be found the
+// breakpoint is suspended until a shared object containing it is loaded.
+func (d *Debugger) createSuspendedBreakpoint(requestedBp *api.Breakpoint) (*api.Breakpoint, error) {
+	if addrs, err := d.breakpointLocations(requestedBp); err == nil {
+		return createLogicalBreakpoint(d, addrs, requestedBp, 0)
+	}
+	if len(requestedBp.FunctionRegexp) > 0 {
+		if _, err := regexp.Compile(requestedBp.FunctionRegexp); err != nil {
+			return nil, fmt.Errorf("invalid function regexp: %v", err)
+		}
+	}
+	if err := d.validateBreakpointCondition(requestedBp, nil); err != nil {
+		return nil, err
+	}
+	bp := *requestedBp
+	bp.ID = d.target.NewBreakpointID()
+	bp.Addr = 0
+	bp.Addrs = nil
+	bp.Disabled = true
+	bp.Suspended = true
+	bp.HitCount = map[string]uint64{}
+	bp.TotalHitCount = 0
+	d.disabledBreakpoints[bp.ID] = &bp
+	d.log.Infof("created suspended breakpoint: %#v", bp)
+	return &bp, nil
+}
+
+// breakpointLocations returns the addresses of the location of bp, which
+// must be specified as described by canSuspend, or by the source locations
+// in bp.Locations. For breakpoints on a package the number of functions
+// found is stored in bp.FunctionCount.
+func (d *Debugger) breakpointLocations(bp *api.Breakpoint) ([]uint64, error) {
+	switch {
+	case len(bp.Package) > 0:
+		addrs, n, err := packageLocations(d.target, bp.Package, bp.ExcludeWrappers)
+		if err != nil {
+			return nil, err
+		}
+		bp.FunctionCount = n
+		return addrs, nil
+	case len(bp.FunctionRegexp) > 0:
+		return functionRegexpLocations(d.target, bp.FunctionRegexp)
+	case len(bp.Locations) > 0:
+		addrs := []uint64{}
+		for _, loc := range bp.Locations {
+			locAddrs, err := proc.FindFileLocation(d.target, loc.File, loc.Line)
+			if err != nil {
+				return nil, err
+			}
+			addrs = append(addrs, locAddrs...)
+		}
+		return uniqueAddrs(addrs), nil
+	case filepath.IsAbs(bp.File):
+		return proc.FindFileLocation(d.target, bp.File, bp.Line)
+	}
+	locStr := bp.FunctionName
+	if len(bp.File) > 0 {
+		locStr = bp.File
+	}
+	if bp.Line > 0 {
+		locStr = fmt.Sprintf("%s:%d", locStr, bp.Line)
+	}
+	loc, err := locspec.Parse(locStr)
+	if err != nil {
+		return nil, err
+	}
+	locs, err := loc.Find(d.target, d.processArgs, nil, locStr, false, nil)
+	if err != nil {
+		return nil, err
+	}
+	addrs := []uint64{}
+	for _, loc := range locs {
+		if len(loc.PCs) > 0 {
+			addrs = append(addrs, loc.PCs...)
+		} else {
+			addrs = append(addrs, loc.PC)
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("location %q not found", locStr)
+	}
+	return uniqueAddrs(addrs), nil
+}
+
+// sharedObjectsLoaded is called by the target, while it is stopped, every
+// time the list of loaded shared objects changes.
+func (d *Debugger) sharedObjectsLoaded() {
+	d.enableSuspendedBreakpoints()
+	d.updateFunctionBreakpoints()
+}
+
+// maxHitsReached is called by the target, while it is stopped, when the
+// breakpoint with the specified ID is hit for the MaxHits-th time, the
+// breakpoint is removed from the target and moved to the disabled
+// breakpoints.
+func (d *Debugger) maxHitsReached(id int) {
+	bps := api.ConvertBreakpoints(d.findBreakpoint(id))
+	if len(bps) == 0 {
+		return
+	}
+	bp := bps[0]
+	if _, err := d.clearBreakpoint(bp); err != nil {
+		d.log.Errorf("could not auto-disable breakpoint %d: %v", id, err)
+		return
+	}
+	bp.Disabled = true
+	bp.AutoDisabled = true
+	d.disabledBreakpoints[id] = bp
+}
+
+// inSharedObject returns true if any of the addresses of bp belongs to a
+// shared object, according to bi.
+func inSharedObject(bi *proc.BinaryInfo, bp *api.Breakpoint) bool {
+	for _, addr := range bp.Addrs {
+		if img := bi.PCToImage(addr); img != nil && img != bi.Images[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// enableSuspendedBreakpoints enables every suspended breakpoint whose
+// location can now be found.
+func (d *Debugger) enableSuspendedBreakpoints() {
+	for id, bp := range d.disabledBreakpoints {
+		if !bp.Suspended {
+			continue
+		}
+		addrs, err := d.breakpointLocations(bp)
+		if err != nil {
+			continue
+		}
+		delete(d.disabledBreakpoints, id)
+		bp.Disabled = false
+		bp.Suspended = false
+		if _, err := createLogicalBreakpoint(d, addrs, bp, id); err != nil {
+			d.log.Errorf("could not enable suspended breakpoint %d: %v", id, err)
+			bp.Disabled = true
+			bp.Suspended = true
+			d.disabledBreakpoints[id] = bp
+			continue
+		}
+		d.log.Infof("enabled suspended breakpoint %d", id)
+	}
+}
+
 // createLogicalBreakpoint creates one physical breakpoint for each address
 // in addrs and associates all of them with the same logical breakpoint.
 func createLogicalBreakpoint(d *Debugger, addrs []uint64, requestedBp *api.Breakpoint, id int) (*api.Breakpoint, error) {
@@ -713,25 +992,53 @@ func createLogicalBreakpoint(d *Debugger, addrs []uint64, requestedBp *api.Break
 		return dbp, proc.BreakpointExistsError{File: dbp.File, Line: dbp.Line, Addr: dbp.Addr}
 	}
 
-	bps := make([]*proc.Breakpoint, len(addrs))
-	var err error
-	for i := range addrs {
-		if id > 0 {
-			bps[i], err = p.SetBreakpointWithID(id, addrs[i])
-		} else {
-			bps[i], err = p.SetBreakpoint(addrs[i], proc.UserBreakpoint, nil)
+	if err := d.validateBreakpointCondition(requestedBp, addrs); err != nil {
+		return nil, err
+	}
+
+	bps := make([]*proc.Breakpoint, 0, len(addrs))
+	var failed []api.PhysicalBreakpoint
+	var err, firstErr error
+	for _, addr := range addrs {
+		var bp *proc.Breakpoint
+		switch {
+		case id > 0 && requestedBp.Hardware:
+			bp, err = p.SetHardwareBreakpointWithID(id, addr)
+		case id > 0:
+			bp, err = p.SetBreakpointWithID(id, addr)
+		case requestedBp.Hardware:
+			bp, err = p.SetHardwareBreakpoint(addr, proc.UserBreakpoint, nil)
+		default:
+			bp, err = p.SetBreakpoint(addr, proc.UserBreakpoint, nil)
+		}
+		if err != nil && len(addrs) > 1 && (!isBreakpointExistsErr(err) || requestedBp.FunctionRegexp != "" || requestedBp.Package != "") {
+			// Keep the addresses that could be set, the failure is reported
+			// by PhysicalBreakpoints. Functions matching a regular expression,
+			// or belonging to a package, that already have a breakpoint are
+			// skipped the same way.
+			d.log.Warnf("could not set breakpoint at %#x: %v", addr, err)
+			failed = append(failed, d.failedPhysicalBreakpoint(addr, err))
+			if firstErr == nil {
+				firstErr = err
+			}
+			err = nil
+			continue
 		}
 		if err != nil {
 			break
 		}
-		if i > 0 {
-			bps[i].LogicalID = bps[0].LogicalID
+		bps = append(bps, bp)
+		if len(bps) > 1 {
+			bp.LogicalID = bps[0].LogicalID
 		}
-		err = copyBreakpointInfo(bps[i], requestedBp)
+		err = copyBreakpointInfo(bp, requestedBp)
 		if err != nil {
 			break
 		}
 	}
+	if err == nil && len(bps) == 0 {
+		err = firstErr
+	}
 	if err != nil {
 		if isBreakpointExistsErr(err) {
 			return nil, err
@@ -747,11 +1054,188 @@ func createLogicalBreakpoint(d *Debugger, addrs []uint64, requestedBp *api.Break
 		}
 		return nil, err
 	}
+	if len(failed) > 0 {
+		d.failedBreakpoints[bps[0].LogicalID] = failed
+	}
 
 	createdBp := api.ConvertBreakpoints(bps)
 	return createdBp[0], nil // we created a single logical breakpoint, the slice here will always have len == 1
 }
 
+// failedPhysicalBreakpoint describes the physical breakpoint at addr that
+// could not be set because of err.
+func (d *Debugger) failedPhysicalBreakpoint(addr uint64, err error) api.PhysicalBreakpoint {
+	file, line, fn := d.target.BinInfo().PCToLine(addr)
+	pbp := api.PhysicalBreakpoint{Addr: addr, File: file, Line: line, Error: err.Error()}
+	if fn != nil {
+		pbp.FunctionName = fn.Name
+	}
+	return pbp
+}
+
+// updateFunctionBreakpoints adds a physical breakpoint to every Package
+// and FunctionRegexp breakpoint for each matching function that was not
+// known when the breakpoint was set, for example because it belongs to a
+// shared object loaded afterwards.
+func (d *Debugger) updateFunctionBreakpoints() {
+	for _, bp := range api.ConvertBreakpoints(d.breakpoints()) {
+		if bp.ID < 0 || (bp.Package == "" && bp.FunctionRegexp == "") {
+			continue
+		}
+		addrs, err := d.breakpointLocations(bp)
+		if err != nil {
+			continue
+		}
+		for _, pbp := range d.findBreakpoint(bp.ID) {
+			pbp.FunctionCount = bp.FunctionCount
+		}
+		if err := d.addBreakpointLocations(bp, addrs); err != nil {
+			d.log.Errorf("could not update breakpoint %d: %v", bp.ID, err)
+		}
+	}
+}
+
+// addBreakpointLocations sets a physical breakpoint, belonging to the
+// logical breakpoint bp, on every address in addrs that bp does not
+// already cover. Addresses where the breakpoint can not be set, including
+// addresses that already have a different breakpoint, are reported by
+// PhysicalBreakpoints.
+func (d *Debugger) addBreakpointLocations(bp *api.Breakpoint, addrs []uint64) error {
+	known := make(map[uint64]bool)
+	for _, addr := range bp.Addrs {
+		known[addr] = true
+	}
+	for _, pbp := range d.failedBreakpoints[bp.ID] {
+		known[pbp.Addr] = true
+	}
+	for _, addr := range addrs {
+		if known[addr] {
+			continue
+		}
+		var newbp *proc.Breakpoint
+		var err error
+		if bp.Hardware {
+			newbp, err = d.target.SetHardwareBreakpointWithID(bp.ID, addr)
+		} else {
+			newbp, err = d.target.SetBreakpointWithID(bp.ID, addr)
+		}
+		if err != nil {
+			d.log.Warnf("could not set breakpoint at %#x: %v", addr, err)
+			d.failedBreakpoints[bp.ID] = append(d.failedBreakpoints[bp.ID], d.failedPhysicalBreakpoint(addr, err))
+			continue
+		}
+		if err := copyBreakpointInfo(newbp, bp); err != nil {
+			return err
+		}
+		d.log.Infof("added location %#x to breakpoint %d", addr, bp.ID)
+	}
+	return nil
+}
+
+// functionRegexpLocations returns the entry point, after the prologue, of
+// every function whose name matches the regular expression re.
+func functionRegexpLocations(p *proc.Target, re string) ([]uint64, error) {
+	rx, err := regexp.Compile(re)
+	if err != nil {
+		return nil, fmt.Errorf("invalid function regexp: %v", err)
+	}
+	addrs, _ := functionLocations(p, func(fn *proc.Function) bool {
+		return rx.MatchString(fn.Name)
+	})
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no functions matching %q", re)
+	}
+	return addrs, nil
+}
+
+// packageLocations returns the entry point, after the prologue, of every
+// function of package pkg, including closures and, unless excludeWrappers
+// is set, autogenerated wrappers. The number of functions found is also
+// returned.
+// The package can be specified either by its full path or by the last
+// components of its path, see resolvePackagePath.
+func packageLocations(p *proc.Target, pkg string, excludeWrappers bool) ([]uint64, int, error) {
+	bi := p.BinInfo()
+	pkgpath, err := resolvePackagePath(bi, pkg)
+	if err != nil {
+		return nil, 0, err
+	}
+	addrs, n := functionLocations(p, func(fn *proc.Function) bool {
+		if fn.PackageName() != pkgpath {
+			return false
+		}
+		if excludeWrappers {
+			if file, line, _ := bi.PCToLine(fn.Entry); file == "<autogenerated>" && line == 1 {
+				return false
+			}
+		}
+		return true
+	})
+	if len(addrs) == 0 {
+		return nil, 0, fmt.Errorf("no functions in package %q", pkg)
+	}
+	return addrs, n, nil
+}
+
+// resolvePackagePath returns the import path of the package pkg, which can
+// be either an import path or its last elements. An error is returned if
+// pkg is the suffix of the import path of more than one package.
+func resolvePackagePath(bi *proc.BinaryInfo, pkg string) (string, error) {
+	seen := map[string]bool{}
+	var matches []string
+	for i := range bi.Functions {
+		fnpkg := bi.Functions[i].PackageName()
+		if fnpkg == pkg {
+			return pkg, nil
+		}
+		if strings.HasSuffix(fnpkg, "/"+pkg) && !seen[fnpkg] {
+			seen[fnpkg] = true
+			matches = append(matches, fnpkg)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no functions in package %q", pkg)
+	case 1:
+		return matches[0], nil
+	}
+	sort.Strings(matches)
+	return "", fmt.Errorf("package name %q is ambiguous, use one of: %s", pkg, strings.Join(matches, ", "))
+}
+
+// functionLocations returns the entry point, after the prologue, and the
+// inlined calls of all functions for which match returns true, and the
+// number of such functions.
+func functionLocations(p *proc.Target, match func(*proc.Function) bool) ([]uint64, int) {
+	addrs := []uint64{}
+	n := 0
+	for _, fn := range p.BinInfo().Functions {
+		if fn.Entry == 0 || !match(&fn) {
+			continue
+		}
+		fnaddrs, err := proc.FindFunctionLocation(p, fn.Name, 0)
+		if err != nil {
+			continue
+		}
+		addrs = append(addrs, fnaddrs...)
+		n++
+	}
+	return uniqueAddrs(addrs), n
+}
+
+// uniqueAddrs returns addrs without duplicates, preserving order.
+func uniqueAddrs(addrs []uint64) []uint64 {
+	seen := make(map[uint64]bool, len(addrs))
+	r := make([]uint64, 0, len(addrs))
+	for _, addr := range addrs {
+		if !seen[addr] {
+			seen[addr] = true
+			r = append(r, addr)
+		}
+	}
+	return r
+}
+
 func isBreakpointExistsErr(err error) bool {
 	_, r := err.(proc.BreakpointExistsError)
 	return r
@@ -773,13 +1257,41 @@ func (d *Debugger) AmendBreakpoint(amend *api.Breakpoint) error {
 	if originals == nil && !disabled {
 		return fmt.Errorf("no breakpoint with ID %d", amend.ID)
 	}
+	addrs := make([]uint64, 0, len(originals))
+	for _, original := range originals {
+		if original.WatchExpr == "" {
+			addrs = append(addrs, original.Addr)
+		}
+	}
+	if err := d.validateBreakpointCondition(amend, addrs); err != nil {
+		return err
+	}
+	if amend.MaxHits < 0 {
+		return errors.New("maximum number of hits can not be negative")
+	}
+	if len(originals) > 0 && originals[0].WatchExpr != "" && amend.MaxHits > 0 {
+		return errors.New("can not auto-disable watchpoints")
+	}
 	if !amend.Disabled && disabled { // enable the breakpoint
-		bp, err := d.target.SetBreakpointWithID(amend.ID, amend.Addr)
-		if err != nil {
-			return err
+		amend.AutoDisabled = false
+		addrs := amend.Addrs
+		if len(addrs) == 0 {
+			addrs = []uint64{amend.Addr}
+		}
+		dbp := d.disabledBreakpoints[amend.ID]
+		if dbp.Suspended {
+			var err error
+			addrs, err = d.breakpointLocations(dbp)
+			if err != nil {
+				return fmt.Errorf("breakpoint %d is suspended: %v", amend.ID, err)
+			}
+			amend.Suspended = false
 		}
-		copyBreakpointInfo(bp, amend)
 		delete(d.disabledBreakpoints, amend.ID)
+		if _, err := createLogicalBreakpoint(d, addrs, amend, amend.ID); err != nil {
+			d.disabledBreakpoints[amend.ID] = dbp
+			return err
+		}
 	}
 	if amend.Disabled && !disabled { // disable the breakpoint
 		if _, err := d.clearBreakpoint(amend); err != nil {
@@ -796,6 +1308,46 @@ func (d *Debugger) AmendBreakpoint(amend *api.Breakpoint) error {
 	return nil
 }
 
+// ResetBreakpointHitCounts resets the hit counts of the breakpoint with
+// the specified ID.
+func (d *Debugger) ResetBreakpointHitCounts(id int) error {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	bps := d.findBreakpoint(id)
+	dbps := d.findDisabledBreakpoint(id)
+	if len(bps) == 0 && len(dbps) == 0 {
+		return fmt.Errorf("no breakpoint with ID %d", id)
+	}
+	for _, bp := range bps {
+		bp.ResetHitCounts()
+	}
+	for _, dbp := range dbps {
+		resetAPIBreakpointHitCounts(dbp)
+	}
+	return nil
+}
+
+// ResetAllBreakpointHitCounts resets the hit counts of all user breakpoints.
+func (d *Debugger) ResetAllBreakpointHitCounts() {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	for _, bp := range d.breakpoints() {
+		bp.ResetHitCounts()
+	}
+	for _, dbp := range d.disabledBreakpoints {
+		resetAPIBreakpointHitCounts(dbp)
+	}
+}
+
+func resetAPIBreakpointHitCounts(bp *api.Breakpoint) {
+	bp.TotalHitCount = 0
+	bp.HitCount = map[string]uint64{}
+	bp.LastHit = time.Time{}
+	bp.HitRate = 0
+}
+
 // CancelNext will clear internal breakpoints, thus cancelling the 'next',
 // 'step' or 'stepout' operation.
 func (d *Debugger) CancelNext() error {
@@ -804,8 +1356,44 @@ func (d *Debugger) CancelNext() error {
 	return d.target.ClearSteppingBreakpoints()
 }
 
+// validateBreakpointCondition checks that the condition of requested is
+// valid for a breakpoint set at addrs. Identifiers that can not be resolved
+// yet, for example because they might not be visible at one of the
+// addresses, are logged and stored in requested.Warnings.
+func (d *Debugger) validateBreakpointCondition(requested *api.Breakpoint, addrs []uint64) error {
+	requested.Warnings = nil
+	if requested.Cond == "" {
+		return nil
+	}
+	cond, err := parser.ParseExpr(requested.Cond)
+	if err != nil {
+		return err
+	}
+	if len(addrs) == 0 {
+		// disabled breakpoints and watchpoints, only check the type of the
+		// expression.
+		addrs = []uint64{0}
+	}
+	seen := make(map[string]bool)
+	for _, addr := range addrs {
+		warnings, err := d.target.BinInfo().ValidateCondition(addr, cond)
+		if err != nil {
+			return fmt.Errorf("invalid breakpoint condition %q: %v", requested.Cond, err)
+		}
+		for _, warning := range warnings {
+			d.log.Warnf("breakpoint condition %q: %s", requested.Cond, warning)
+			if !seen[warning] {
+				seen[warning] = true
+				requested.Warnings = append(requested.Warnings, warning)
+			}
+		}
+	}
+	return nil
+}
+
 func copyBreakpointInfo(bp *proc.Breakpoint, requested *api.Breakpoint) (err error) {
 	bp.Name = requested.Name
+	bp.Group = requested.Group
 	bp.Tracepoint = requested.Tracepoint
 	bp.TraceReturn = requested.TraceReturn
 	bp.Goroutine = requested.Goroutine
@@ -813,12 +1401,20 @@ func copyBreakpointInfo(bp *proc.Breakpoint, requested *api.Breakpoint) (err err
 	bp.Variables = requested.Variables
 	bp.LoadArgs = api.LoadConfigToProc(requested.LoadArgs)
 	bp.LoadLocals = api.LoadConfigToProc(requested.LoadLocals)
+	bp.FunctionRegexp = requested.FunctionRegexp
+	bp.Package = requested.Package
+	bp.ExcludeWrappers = requested.ExcludeWrappers
+	bp.FunctionCount = requested.FunctionCount
+	bp.MaxHits = requested.MaxHits
+	bp.Commands = requested.Commands
+	bp.CondWarnings = requested.Warnings
 	breaklet := bp.UserBreaklet()
 	if breaklet != nil {
 		breaklet.Cond = nil
 		if requested.Cond != "" {
 			breaklet.Cond, err = parser.ParseExpr(requested.Cond)
 		}
+		breaklet.CondCalls = requested.CondCalls
 		breaklet.HitCond = nil
 		if requested.HitCond != "" {
 			opTok, val, parseErr := parseHitCondition(requested.HitCond)
@@ -832,6 +1428,19 @@ func copyBreakpointInfo(bp *proc.Breakpoint, requested *api.Breakpoint) (err err
 				}{opTok, val}
 			}
 		}
+		breaklet.HitCondPerG = requested.HitCondPerG
+		breaklet.HitHistorySize = requested.HitHistorySize
+		breaklet.AutoCheckpoint = requested.AutoCheckpoint
+		breaklet.AutoCheckpointMax = requested.AutoCheckpointMax
+		breaklet.AutoCheckpointOnly = requested.AutoCheckpointOnly
+		breaklet.Logpoint = nil
+		if requested.Logpoint != "" {
+			logpoint, parseErr := proc.ParseLogpoint(requested.Logpoint)
+			if err == nil {
+				err = parseErr
+			}
+			breaklet.Logpoint = logpoint
+		}
 	}
 	return err
 }
@@ -886,48 +1495,18 @@ func (d *Debugger) ClearBreakpoint(requestedBp *api.Breakpoint) (*api.Breakpoint
 
 // clearBreakpoint clears a breakpoint, we can consume this function to avoid locking a goroutine
 func (d *Debugger) clearBreakpoint(requestedBp *api.Breakpoint) (*api.Breakpoint, error) {
+	delete(d.failedBreakpoints, requestedBp.ID)
 	if bp, ok := d.disabledBreakpoints[requestedBp.ID]; ok {
 		delete(d.disabledBreakpoints, bp.ID)
 		return bp, nil
 	}
 
-	var bps []*proc.Breakpoint
-	var errs []error
-
-	clear := func(addr uint64) {
-		bp, err := d.target.ClearBreakpoint(addr)
-		if err != nil {
-			errs = append(errs, fmt.Errorf("address %#x: %v", addr, err))
-		}
-		if bp != nil {
-			bps = append(bps, bp)
-		}
-	}
-
-	clearAddr := true
-	for _, addr := range requestedBp.Addrs {
-		if addr == requestedBp.Addr {
-			clearAddr = false
-		}
-		clear(addr)
-	}
-	if clearAddr {
-		clear(requestedBp.Addr)
-	}
-
-	if len(errs) > 0 {
-		buf := new(bytes.Buffer)
-		for i, err := range errs {
-			fmt.Fprintf(buf, "%s", err)
-			if i != len(errs)-1 {
-				fmt.Fprintf(buf, ", ")
-			}
-		}
-
+	bps, err := d.target.ClearLogicalBreakpoint(requestedBp.ID)
+	if err != nil {
 		if len(bps) == 0 {
-			return nil, fmt.Errorf("unable to clear breakpoint %d: %v", requestedBp.ID, buf.String())
+			return nil, fmt.Errorf("unable to clear breakpoint %d: %v", requestedBp.ID, err)
 		}
-		return nil, fmt.Errorf("unable to clear breakpoint %d (partial): %s", requestedBp.ID, buf.String())
+		return nil, fmt.Errorf("unable to clear breakpoint %d (partial): %v", requestedBp.ID, err)
 	}
 
 	clearedBp := api.ConvertBreakpoints(bps)
@@ -952,6 +1531,19 @@ func (d *Debugger) Breakpoints() []*api.Breakpoint {
 	return bps
 }
 
+// BreakpointGroup returns all breakpoints, enabled or disabled, belonging
+// to the specified group, sorted by ID.
+func (d *Debugger) BreakpointGroup(group string) []*api.Breakpoint {
+	bps := []*api.Breakpoint{}
+	for _, bp := range d.Breakpoints() {
+		if bp.Group == group {
+			bps = append(bps, bp)
+		}
+	}
+	sort.Slice(bps, func(i, j int) bool { return bps[i].ID < bps[j].ID })
+	return bps
+}
+
 func (d *Debugger) breakpoints() []*proc.Breakpoint {
 	bps := []*proc.Breakpoint{}
 	for _, bp := range d.target.Breakpoints().M {
@@ -1033,18 +1625,180 @@ func (d *Debugger) findDisabledBreakpointByName(name string) *api.Breakpoint {
 
 // CreateWatchpoint creates a watchpoint on the specified expression.
 func (d *Debugger) CreateWatchpoint(goid, frame, deferredCall int, expr string, wtype api.WatchType) (*api.Breakpoint, error) {
+	return d.createWatchpoint(goid, frame, deferredCall, expr, func(s *proc.EvalScope) (*proc.Breakpoint, error) {
+		return d.target.SetWatchpoint(s, expr, proc.WatchType(wtype), nil)
+	})
+}
+
+// CreateSliceWatchpoint creates a watchpoint on the specified index
+// expression, the watchpoint is moved to the new backing array of the slice
+// when the slice is reallocated. If notify is true the target stops every
+// time the watchpoint is moved.
+func (d *Debugger) CreateSliceWatchpoint(goid, frame, deferredCall int, expr string, wtype api.WatchType, notify bool) (*api.Breakpoint, error) {
+	return d.createWatchpoint(goid, frame, deferredCall, expr, func(s *proc.EvalScope) (*proc.Breakpoint, error) {
+		return d.target.SetSliceWatchpoint(s, expr, proc.WatchType(wtype), nil, notify)
+	})
+}
+
+func (d *Debugger) createWatchpoint(goid, frame, deferredCall int, expr string, set func(*proc.EvalScope) (*proc.Breakpoint, error)) (*api.Breakpoint, error) {
 	s, err := proc.ConvertEvalScope(d.target, goid, frame, deferredCall)
 	if err != nil {
 		return nil, err
 	}
-	bp, err := d.target.SetWatchpoint(s, expr, proc.WatchType(wtype), nil)
+	bp, err := set(s)
+	if err != nil {
+		return nil, err
+	}
+	setName := d.findBreakpointByName(expr) == nil
+	bps := []*proc.Breakpoint{}
+	for _, pbp := range d.target.Breakpoints().M {
+		if pbp.IsUser() && pbp.LogicalID == bp.LogicalID {
+			if setName {
+				pbp.Name = expr
+			}
+			bps = append(bps, pbp)
+		}
+	}
+	sort.Sort(breakpointsByLogicalID(bps))
+	return api.ConvertBreakpoints(bps)[0], nil
+}
+
+// CreateWatchpointAddr creates a watchpoint on the size bytes of memory
+// starting at addr.
+func (d *Debugger) CreateWatchpointAddr(addr uint64, size int, wtype api.WatchType) (*api.Breakpoint, error) {
+	bp, err := d.target.SetWatchpointAddr(addr, size, proc.WatchType(wtype), nil)
+	if err != nil {
+		return nil, err
+	}
+	return api.ConvertBreakpoints([]*proc.Breakpoint{bp})[0], nil
+}
+
+// restoreWatchpoint sets again, on the restarted target p, the watchpoint
+// oldBp by evaluating its expression in the scope of package pkg.
+func (d *Debugger) restoreWatchpoint(p *proc.Target, oldBp *api.Breakpoint, pkg string) error {
+	bp, err := p.SetWatchpointWithID(oldBp.ID, proc.PackageScope(p, pkg), oldBp.WatchExpr, proc.WatchType(oldBp.WatchType), nil)
+	if err != nil {
+		return err
+	}
+	for _, pbp := range p.Breakpoints().M {
+		if pbp.IsUser() && pbp.LogicalID == bp.LogicalID {
+			if err := copyBreakpointInfo(pbp, oldBp); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// BreakpointHitHistory returns the hits recorded in the hit history of the
+// breakpoint with the specified ID, oldest first, and clears the hit
+// history.
+func (d *Debugger) BreakpointHitHistory(id int) ([]api.BreakpointHit, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	bps := d.findBreakpoint(id)
+	if len(bps) == 0 {
+		return nil, fmt.Errorf("no breakpoint with ID %d", id)
+	}
+	r := []api.BreakpointHit{}
+	for _, bp := range bps {
+		breaklet := bp.UserBreaklet()
+		if breaklet == nil {
+			continue
+		}
+		for _, hit := range breaklet.TakeHitHistory() {
+			file, line, _ := d.target.BinInfo().PCToLine(hit.PC)
+			r = append(r, api.BreakpointHit{GoroutineID: hit.GoroutineID, ThreadID: hit.ThreadID, PC: hit.PC, File: file, Line: line, Time: hit.Time})
+		}
+	}
+	sort.SliceStable(r, func(i, j int) bool { return r[i].Time.Before(r[j].Time) })
+	return r, nil
+}
+
+// PhysicalBreakpoints returns the physical breakpoints of the logical
+// breakpoint with the specified ID, sorted by address, including the
+// addresses where a physical breakpoint could not be set.
+func (d *Debugger) PhysicalBreakpoints(id int) ([]api.PhysicalBreakpoint, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	bps := d.findBreakpoint(id)
+	dbp, disabled := d.disabledBreakpoints[id]
+	if len(bps) == 0 && !disabled {
+		return nil, fmt.Errorf("no breakpoint with ID %d", id)
+	}
+
+	r := []api.PhysicalBreakpoint{}
+	for _, bp := range bps {
+		r = append(r, api.ConvertPhysicalBreakpoint(bp))
+	}
+	if disabled {
+		addrs := dbp.Addrs
+		if len(addrs) == 0 && dbp.Addr != 0 {
+			addrs = []uint64{dbp.Addr}
+		}
+		for _, addr := range addrs {
+			file, line, fn := d.target.BinInfo().PCToLine(addr)
+			pbp := api.PhysicalBreakpoint{Addr: addr, File: file, Line: line}
+			if fn != nil {
+				pbp.FunctionName = fn.Name
+			}
+			r = append(r, pbp)
+		}
+	}
+	r = append(r, d.failedBreakpoints[id]...)
+	sort.Slice(r, func(i, j int) bool { return r[i].Addr < r[j].Addr })
+	return r, nil
+}
+
+// WatchpointSlots returns the number of hardware breakpoint slots that can
+// be used for watchpoints and how many of them are in use.
+func (d *Debugger) WatchpointSlots() api.WatchpointSlots {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	return api.WatchpointSlots(d.target.WatchpointSlots())
+}
+
+// StepIntoTargets returns the calls on the current line of the selected
+// goroutine that can be stepped into with the StepInto command.
+func (d *Debugger) StepIntoTargets() ([]api.StepIntoTarget, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	tgts, err := d.target.StepIntoTargets()
 	if err != nil {
 		return nil, err
 	}
-	if d.findBreakpointByName(expr) == nil {
-		bp.Name = expr
+	r := make([]api.StepIntoTarget, len(tgts))
+	for i := range tgts {
+		r[i] = api.StepIntoTarget{PC: tgts[i].PC, Function: api.ConvertFunction(tgts[i].Fn)}
+	}
+	return r, nil
+}
+
+// logpointOutput returns the function that receives the logpoint messages
+// of the target, nil if they are buffered.
+func (d *Debugger) logpointOutput() func(proc.LogpointMessage) {
+	if d.config.LogpointOutput == nil {
+		return nil
+	}
+	return func(msg proc.LogpointMessage) {
+		d.config.LogpointOutput(api.ConvertLogpointMessage(msg))
+	}
+}
+
+// GetBufferedLogpoints returns the messages produced by logpoints since the
+// last call and the number of messages that were discarded because too
+// many were buffered.
+func (d *Debugger) GetBufferedLogpoints() ([]api.LogpointMessage, int) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	msgs, dropped := d.target.GetBufferedLogpointMessages()
+	r := make([]api.LogpointMessage, len(msgs))
+	for i := range msgs {
+		r[i] = api.ConvertLogpointMessage(msgs[i])
 	}
-	return api.ConvertBreakpoint(bp), nil
+	return r, dropped
 }
 
 // Threads returns the threads of the target process.
@@ -1113,6 +1867,7 @@ func (d *Debugger) Command(command *api.DebuggerCommand, resumeNotify chan struc
 	}
 
 	withBreakpointInfo := true
+	stepInstructionCount := 0
 
 	d.targetMutex.Lock()
 	defer d.targetMutex.Unlock()
@@ -1132,10 +1887,10 @@ func (d *Debugger) Command(command *api.DebuggerCommand, resumeNotify chan struc
 		if err := d.target.ChangeDirection(proc.Forward); err != nil {
 			return nil, err
 		}
-		err = d.target.Continue()
+		err = d.resume(command, d.target.Continue)
 	case api.DirectionCongruentContinue:
 		d.log.Debug("continuing (direction congruent)")
-		err = d.target.Continue()
+		err = d.resume(command, d.target.Continue)
 	case api.Call:
 		d.log.Debugf("function call %s", command.Expr)
 		if err := d.target.ChangeDirection(proc.Forward); err != nil {
@@ -1152,60 +1907,99 @@ func (d *Debugger) Command(command *api.DebuggerCommand, resumeNotify chan struc
 			}
 		}
 		err = proc.EvalExpressionWithCalls(d.target, g, command.Expr, *api.LoadConfigToProc(command.ReturnInfoLoadConfig), !command.UnsafeCall)
+	case api.Until:
+		d.log.Debugf("continuing until %s", command.Expr)
+		if err := d.target.ChangeDirection(proc.Forward); err != nil {
+			return nil, err
+		}
+		gid := command.GoroutineID
+		if gid <= 0 {
+			gid = -1
+		}
+		var scope *proc.EvalScope
+		scope, err = proc.ConvertEvalScope(d.target, gid, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		err = d.target.ContinueUntil(scope, command.Expr)
 	case api.Rewind:
 		d.log.Debug("rewinding")
 		if err := d.target.ChangeDirection(proc.Backward); err != nil {
 			return nil, err
 		}
-		err = d.target.Continue()
+		err = d.resume(command, d.target.Continue)
+	case api.RewindTo:
+		d.log.Debugf("rewinding to %#x", command.Addrs)
+		err = d.target.RewindTo(command.Addrs)
 	case api.Next:
 		d.log.Debug("nexting")
 		if err := d.target.ChangeDirection(proc.Forward); err != nil {
 			return nil, err
 		}
-		err = d.target.Next()
+		err = d.resume(command, d.target.Next)
 	case api.ReverseNext:
 		d.log.Debug("reverse nexting")
 		if err := d.target.ChangeDirection(proc.Backward); err != nil {
 			return nil, err
 		}
-		err = d.target.Next()
+		err = d.resume(command, d.target.Next)
 	case api.Step:
 		d.log.Debug("stepping")
 		if err := d.target.ChangeDirection(proc.Forward); err != nil {
 			return nil, err
 		}
-		err = d.target.Step()
+		err = d.resume(command, d.target.Step)
 	case api.ReverseStep:
 		d.log.Debug("reverse stepping")
 		if err := d.target.ChangeDirection(proc.Backward); err != nil {
 			return nil, err
 		}
-		err = d.target.Step()
+		err = d.resume(command, d.target.Step)
 	case api.StepInstruction:
 		d.log.Debug("single stepping")
 		if err := d.target.ChangeDirection(proc.Forward); err != nil {
 			return nil, err
 		}
-		err = d.target.StepInstruction()
+		stepInstructionCount, err = d.target.StepInstructions(command.Count, command.StepOverCalls)
 	case api.ReverseStepInstruction:
 		d.log.Debug("reverse single stepping")
 		if err := d.target.ChangeDirection(proc.Backward); err != nil {
 			return nil, err
 		}
-		err = d.target.StepInstruction()
+		stepInstructionCount, err = d.target.StepInstructions(command.Count, false)
+	case api.StepInto:
+		if len(command.Addrs) != 1 {
+			return nil, errors.New("wrong number of addresses for step into")
+		}
+		d.log.Debugf("step into call at %#x", command.Addrs[0])
+		if err := d.target.ChangeDirection(proc.Forward); err != nil {
+			return nil, err
+		}
+		err = d.target.StepInto(command.Addrs[0])
+	case api.StepIntoNewGoroutine:
+		d.log.Debug("step into new goroutine")
+		if err := d.target.ChangeDirection(proc.Forward); err != nil {
+			return nil, err
+		}
+		err = d.target.StepIntoNewGoroutine()
 	case api.StepOut:
 		d.log.Debug("step out")
 		if err := d.target.ChangeDirection(proc.Forward); err != nil {
 			return nil, err
 		}
-		err = d.target.StepOut()
+		err = d.resume(command, d.target.StepOut)
+	case api.StepOutIterator:
+		d.log.Debug("step out to iterator")
+		if err := d.target.ChangeDirection(proc.Forward); err != nil {
+			return nil, err
+		}
+		err = d.target.StepOutIterator()
 	case api.ReverseStepOut:
 		d.log.Debug("reverse step out")
 		if err := d.target.ChangeDirection(proc.Backward); err != nil {
 			return nil, err
 		}
-		err = d.target.StepOut()
+		err = d.resume(command, d.target.StepOut)
 	case api.SwitchThread:
 		d.log.Debugf("switching to thread %d", command.ThreadID)
 		err = d.target.SwitchThread(command.ThreadID)
@@ -1238,6 +2032,10 @@ func (d *Debugger) Command(command *api.DebuggerCommand, resumeNotify chan struc
 	if stateErr != nil {
 		return state, stateErr
 	}
+	state.StepInstructionCount = stepInstructionCount
+	if command.Name != api.SwitchGoroutine && command.Name != api.SwitchThread {
+		state.StopReason = d.target.StopReason.String()
+	}
 	if withBreakpointInfo {
 		err = d.collectBreakpointInformation(state)
 	}
@@ -1253,6 +2051,16 @@ func (d *Debugger) Command(command *api.DebuggerCommand, resumeNotify chan struc
 	return state, err
 }
 
+// resume calls fn, which resumes the target, applying the Pinned and
+// Timeout options of command.
+func (d *Debugger) resume(command *api.DebuggerCommand, fn func() error) error {
+	if command.Pinned {
+		resume := fn
+		fn = func() error { return d.target.ResumePinned(resume) }
+	}
+	return d.target.ResumeWithTimeout(command.Timeout, fn)
+}
+
 func (d *Debugger) collectBreakpointInformation(state *api.DebuggerState) error {
 	if state == nil {
 		return nil
@@ -1460,7 +2268,12 @@ func (d *Debugger) LocalVariables(goid, frame, deferredCall int, cfg proc.LoadCo
 	if err != nil {
 		return nil, err
 	}
-	return s.LocalVariables(cfg)
+	vars, err := s.LocalVariables(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s.ApplyFormatters(cfg, vars...)
+	return vars, nil
 }
 
 // FunctionArguments returns the arguments to the current function.
@@ -1472,7 +2285,12 @@ func (d *Debugger) FunctionArguments(goid, frame, deferredCall int, cfg proc.Loa
 	if err != nil {
 		return nil, err
 	}
-	return s.FunctionArguments(cfg)
+	vars, err := s.FunctionArguments(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s.ApplyFormatters(cfg, vars...)
+	return vars, nil
 }
 
 // Function returns the current function.
@@ -1497,7 +2315,12 @@ func (d *Debugger) EvalVariableInScope(goid, frame, deferredCall int, symbol str
 	if err != nil {
 		return nil, err
 	}
-	return s.EvalVariable(symbol, cfg)
+	v, err := s.EvalVariable(symbol, cfg)
+	if err != nil {
+		return nil, err
+	}
+	s.ApplyFormatters(cfg, v)
+	return v, nil
 }
 
 // LoadResliced will attempt to 'reslice' a map, array or slice so that the values
@@ -1510,7 +2333,10 @@ func (d *Debugger) LoadResliced(v *proc.Variable, start int, cfg proc.LoadConfig
 
 // SetVariableInScope will set the value of the variable represented by
 // 'symbol' to the value given, in the given scope.
-func (d *Debugger) SetVariableInScope(goid, frame, deferredCall int, symbol, value string) error {
+// If inPlace is true strings and slices that can not be allocated are
+// assigned by overwriting their current contents, see
+// proc.EvalScope.SetVariableInPlace.
+func (d *Debugger) SetVariableInScope(goid, frame, deferredCall int, symbol, value string, inPlace bool) error {
 	d.targetMutex.Lock()
 	defer d.targetMutex.Unlock()
 
@@ -1518,6 +2344,9 @@ func (d *Debugger) SetVariableInScope(goid, frame, deferredCall int, symbol, val
 	if err != nil {
 		return err
 	}
+	if inPlace {
+		return s.SetVariableInPlace(symbol, value)
+	}
 	return s.SetVariable(symbol, value)
 }
 
@@ -1908,6 +2737,43 @@ func (d *Debugger) Recorded() (recorded bool, tracedir string) {
 	return d.target.Recorded()
 }
 
+// RecordOptions returns the options used to record the target, if the
+// target was recorded by the debugger.
+func (d *Debugger) RecordOptions() (opts gdbserial.RecordOptions, ok bool) {
+	if d.config.Backend != "rr" || d.config.CoreFile != "" {
+		return gdbserial.RecordOptions{}, false
+	}
+	return d.config.RecordOptions, true
+}
+
+// PassSignals returns the signals that are delivered to the target
+// without stopping it.
+func (d *Debugger) PassSignals() proc.SignalPassing {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	return d.target.PassSignals()
+}
+
+// SetPassSignals changes the list of signals that are delivered to the
+// target without stopping it.
+func (d *Debugger) SetPassSignals(sigs []int) error {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	return d.target.SetPassSignals(sigs)
+}
+
+// RecordedProcesses returns the list of processes recorded in the trace
+// being replayed.
+func (d *Debugger) RecordedProcesses() ([]gdbserial.RecordedProcess, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	recorded, tracedir := d.target.Recorded()
+	if !recorded || tracedir == "" {
+		return nil, proc.ErrNotRecorded
+	}
+	return gdbserial.RecordedProcesses(tracedir)
+}
+
 // FindThreadReturnValues returns the return values of the function that
 // the thread of the given 'id' just stepped out of.
 func (d *Debugger) FindThreadReturnValues(id int, cfg proc.LoadConfig) ([]*proc.Variable, error) {
@@ -1940,6 +2806,79 @@ func (d *Debugger) Checkpoints() ([]proc.Checkpoint, error) {
 	return d.target.Checkpoints()
 }
 
+// SeekRecording restarts the recording positioned at the specified event
+// and tick count.
+func (d *Debugger) SeekRecording(event, ticks int64) error {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	if recorded, _ := d.target.Recorded(); !recorded {
+		return proc.ErrNotRecorded
+	}
+	return d.target.SeekTo(proc.RecordingPosition{Event: event, Ticks: ticks})
+}
+
+// CreateStepSkip adds a rule that makes step step over the functions
+// matched by pattern, kind is either "function" or "file".
+func (d *Debugger) CreateStepSkip(kind, pattern string) (*api.StepSkip, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	rule, err := d.target.StepSkips().Add(proc.StepSkipKind(kind), pattern)
+	if err != nil {
+		return nil, err
+	}
+	return api.ConvertStepSkip(rule), nil
+}
+
+// ClearStepSkip removes the step skip rule with the given ID.
+func (d *Debugger) ClearStepSkip(id int) error {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	return d.target.StepSkips().Remove(id)
+}
+
+// StepSkips returns the list of step skip rules.
+func (d *Debugger) StepSkips() []*api.StepSkip {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	rules := d.target.StepSkips().Rules()
+	r := make([]*api.StepSkip, len(rules))
+	for i := range rules {
+		r[i] = api.ConvertStepSkip(rules[i])
+	}
+	return r
+}
+
+// CreateFormatter registers a formatter for the values of a type,
+// replacing the formatter already registered for it.
+func (d *Debugger) CreateFormatter(f api.Formatter) (*api.Formatter, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	pf, err := d.target.Formatters().Add(f.TypeName, f.Format, f.CallString)
+	if err != nil {
+		return nil, err
+	}
+	return api.ConvertFormatter(pf), nil
+}
+
+// ClearFormatter removes the formatter registered for typeName.
+func (d *Debugger) ClearFormatter(typeName string) error {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	return d.target.Formatters().Remove(typeName)
+}
+
+// Formatters returns the list of formatters.
+func (d *Debugger) Formatters() []*api.Formatter {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	fs := d.target.Formatters().Formatters()
+	r := make([]*api.Formatter, len(fs))
+	for i := range fs {
+		r[i] = api.ConvertFormatter(fs[i])
+	}
+	return r
+}
+
 // ClearCheckpoint will clear the checkpoint of the given ID.
 func (d *Debugger) ClearCheckpoint(id int) error {
 	d.targetMutex.Lock()
@@ -1947,6 +2886,90 @@ func (d *Debugger) ClearCheckpoint(id int) error {
 	return d.target.ClearCheckpoint(id)
 }
 
+// CheckpointDiff evaluates exprs in the given scope both at the current
+// position and at checkpoint id and returns the two values of each
+// expression, along with their differences. The debugger is returned to
+// the current position before returning.
+func (d *Debugger) CheckpointDiff(id, goid, frame, deferredCall int, exprs []string, cfg proc.LoadConfig) ([]api.CheckpointDiff, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	if recorded, _ := d.target.Recorded(); !recorded {
+		return nil, proc.ErrNotRecorded
+	}
+
+	evalAll := func() ([]api.Variable, error) {
+		s, err := proc.ConvertEvalScope(d.target, goid, frame, deferredCall)
+		if err != nil {
+			return nil, err
+		}
+		vars := make([]api.Variable, len(exprs))
+		for i, expr := range exprs {
+			v, err := s.EvalVariable(expr, cfg)
+			if err != nil {
+				vars[i] = api.Variable{Name: expr, Unreadable: err.Error()}
+				continue
+			}
+			vars[i] = *api.ConvertVar(v)
+		}
+		return vars, nil
+	}
+
+	cur, err := evalAll()
+	if err != nil {
+		return nil, err
+	}
+	var old []api.Variable
+	err = d.target.AtCheckpoint(id, goid, func() error {
+		var err error
+		old, err = evalAll()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r := make([]api.CheckpointDiff, len(exprs))
+	for i, expr := range exprs {
+		r[i] = api.CheckpointDiff{Expr: expr, Checkpoint: old[i], Current: cur[i], Diffs: api.DiffVariables(expr, &old[i], &cur[i])}
+	}
+	return r, nil
+}
+
+// FindDeadlocks returns the goroutines blocked on synchronization
+// objects and the cycles of goroutines waiting on each other.
+func (d *Debugger) FindDeadlocks() (*api.DeadlockReport, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	if _, err := d.target.Valid(); err != nil {
+		return nil, err
+	}
+	r, err := d.target.FindDeadlocks()
+	if err != nil {
+		return nil, err
+	}
+	return api.ConvertDeadlockReport(d.target, r), nil
+}
+
+// MutexInfo returns the state of the sync.Mutex or sync.RWMutex described
+// by expr, evaluated in the given scope, and the goroutines that may hold
+// it.
+func (d *Debugger) MutexInfo(goid, frame, deferredCall int, expr string) (*api.MutexInfo, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	s, err := proc.ConvertEvalScope(d.target, goid, frame, deferredCall)
+	if err != nil {
+		return nil, err
+	}
+	mi, err := s.MutexInfo(expr)
+	if err != nil {
+		return nil, err
+	}
+	return api.ConvertMutexInfo(mi), nil
+}
+
 // ListDynamicLibraries returns a list of loaded dynamic libraries.
 func (d *Debugger) ListDynamicLibraries() []*proc.Image {
 	d.targetMutex.Lock()
@@ -1974,6 +2997,25 @@ func (d *Debugger) ExamineMemory(address uint64, length int) ([]byte, error) {
 	return data, nil
 }
 
+// ExamineType returns the value of type typename stored at address, or an
+// array of count such values, see proc.ExamineType.
+func (d *Debugger) ExamineType(address uint64, typename string, count int64, cfg proc.LoadConfig) (*proc.Variable, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	return proc.ExamineType(d.target.BinInfo(), d.target.Memory(), address, typename, count, cfg)
+}
+
+// ReadStringRange returns count bytes, starting at offset, of the contents
+// of a string or byte slice that are length bytes long and start at base.
+// If part of the range is unreadable the bytes preceding it are returned
+// along with the error, see proc.ReadStringRange.
+func (d *Debugger) ReadStringRange(base uint64, length, offset, count int64) ([]byte, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	return proc.ReadStringRange(d.target.Memory(), base, length, offset, count)
+}
+
 func (d *Debugger) GetVersion(out *api.GetVersionOut) error {
 	if d.config.CoreFile != "" {
 		if d.config.Backend == "rr" {
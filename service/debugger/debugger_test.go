@@ -5,9 +5,11 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/go-delve/delve/pkg/gobuild"
+	"github.com/go-delve/delve/pkg/proc"
 	protest "github.com/go-delve/delve/pkg/proc/test"
 	"github.com/go-delve/delve/service/api"
 )
@@ -68,3 +70,34 @@ func TestDebugger_LaunchInvalidFormat(t *testing.T) {
 		t.Fatalf("expected error \"%s\" got \"%v\"", api.ErrNotExecutable, err)
 	}
 }
+
+func TestResolvePackagePath(t *testing.T) {
+	bi := &proc.BinaryInfo{Functions: []proc.Function{
+		{Name: "main.main"},
+		{Name: "text/template.New"},
+		{Name: "html/template.New"},
+		{Name: "encoding/json.Marshal"},
+		{Name: "github.com/someone/json.Marshal"},
+	}}
+	for _, tc := range []struct {
+		pkg, path string
+	}{
+		{"main", "main"},
+		{"text/template", "text/template"},
+		{"html/template", "html/template"},
+		{"encoding/json", "encoding/json"},
+		{"someone/json", "github.com/someone/json"},
+	} {
+		path, err := resolvePackagePath(bi, tc.pkg)
+		if err != nil || path != tc.path {
+			t.Errorf("resolvePackagePath(%q): got %q %v, expected %q", tc.pkg, path, err, tc.path)
+		}
+	}
+	_, err := resolvePackagePath(bi, "template")
+	if err == nil || !strings.Contains(err.Error(), "html/template, text/template") {
+		t.Errorf("ambiguous package name accepted: %v", err)
+	}
+	if _, err := resolvePackagePath(bi, "plate"); err == nil {
+		t.Errorf("partial package name accepted")
+	}
+}
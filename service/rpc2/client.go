@@ -1,6 +1,7 @@
 package rpc2
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net"
@@ -17,6 +18,7 @@ type RPCClient struct {
 	client *rpc.Client
 
 	retValLoadCfg *api.LoadConfig
+	pinned        bool
 }
 
 // Ensure the implementation satisfies the interface.
@@ -88,20 +90,52 @@ func (c *RPCClient) Continue() <-chan *api.DebuggerState {
 	return c.continueDir(api.Continue)
 }
 
+// ContinueWithTimeout is like Continue but stops the target if it is still
+// running after timeout, the StopReason of the returned state is
+// "timeout" in this case.
+func (c *RPCClient) ContinueWithTimeout(timeout time.Duration) <-chan *api.DebuggerState {
+	return c.continueCmd(api.DebuggerCommand{Name: api.Continue, Timeout: timeout})
+}
+
 func (c *RPCClient) Rewind() <-chan *api.DebuggerState {
 	return c.continueDir(api.Rewind)
 }
 
+func (c *RPCClient) RewindTo(addrs []uint64) <-chan *api.DebuggerState {
+	return c.continueCmd(api.DebuggerCommand{Name: api.RewindTo, Addrs: addrs})
+}
+
 func (c *RPCClient) DirectionCongruentContinue() <-chan *api.DebuggerState {
 	return c.continueDir(api.DirectionCongruentContinue)
 }
 
 func (c *RPCClient) continueDir(cmd string) <-chan *api.DebuggerState {
+	return c.continueCmd(api.DebuggerCommand{Name: cmd})
+}
+
+func (c *RPCClient) continueCmd(cmd api.DebuggerCommand) <-chan *api.DebuggerState {
+	cmd.ReturnInfoLoadConfig = c.retValLoadCfg
+	if cmd.Name == api.Continue || cmd.Name == api.DirectionCongruentContinue {
+		cmd.Pinned = c.pinned
+	}
 	ch := make(chan *api.DebuggerState)
+	var deadline time.Time
+	if cmd.Timeout > 0 {
+		deadline = time.Now().Add(cmd.Timeout)
+	}
 	go func() {
 		for {
+			if !deadline.IsZero() {
+				// the timeout applies to the whole command, including the
+				// resumes after tracepoints
+				cmd.Timeout = time.Until(deadline)
+				if cmd.Timeout <= 0 {
+					close(ch)
+					return
+				}
+			}
 			out := new(CommandOut)
-			err := c.call("Command", &api.DebuggerCommand{Name: cmd, ReturnInfoLoadConfig: c.retValLoadCfg}, &out)
+			err := c.call("Command", &cmd, &out)
 			state := out.State
 			if err != nil {
 				state.Err = err
@@ -136,7 +170,16 @@ func (c *RPCClient) continueDir(cmd string) <-chan *api.DebuggerState {
 
 func (c *RPCClient) Next() (*api.DebuggerState, error) {
 	var out CommandOut
-	err := c.call("Command", api.DebuggerCommand{Name: api.Next, ReturnInfoLoadConfig: c.retValLoadCfg}, &out)
+	err := c.call("Command", api.DebuggerCommand{Name: api.Next, ReturnInfoLoadConfig: c.retValLoadCfg, Pinned: c.pinned}, &out)
+	return &out.State, err
+}
+
+// NextWithTimeout is like Next but stops the target if it is still running
+// after timeout, the StopReason of the returned state is "timeout" in this
+// case and the next operation remains in progress.
+func (c *RPCClient) NextWithTimeout(timeout time.Duration) (*api.DebuggerState, error) {
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.Next, ReturnInfoLoadConfig: c.retValLoadCfg, Timeout: timeout, Pinned: c.pinned}, &out)
 	return &out.State, err
 }
 
@@ -148,10 +191,37 @@ func (c *RPCClient) ReverseNext() (*api.DebuggerState, error) {
 
 func (c *RPCClient) Step() (*api.DebuggerState, error) {
 	var out CommandOut
-	err := c.call("Command", api.DebuggerCommand{Name: api.Step, ReturnInfoLoadConfig: c.retValLoadCfg}, &out)
+	err := c.call("Command", api.DebuggerCommand{Name: api.Step, ReturnInfoLoadConfig: c.retValLoadCfg, Pinned: c.pinned}, &out)
+	return &out.State, err
+}
+
+// StepWithTimeout is like Step but stops the target if it is still running
+// after timeout, the StopReason of the returned state is "timeout" in this
+// case and the step operation remains in progress.
+func (c *RPCClient) StepWithTimeout(timeout time.Duration) (*api.DebuggerState, error) {
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.Step, ReturnInfoLoadConfig: c.retValLoadCfg, Timeout: timeout, Pinned: c.pinned}, &out)
+	return &out.State, err
+}
+
+func (c *RPCClient) StepInto(pc uint64) (*api.DebuggerState, error) {
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.StepInto, Addrs: []uint64{pc}, ReturnInfoLoadConfig: c.retValLoadCfg}, &out)
 	return &out.State, err
 }
 
+func (c *RPCClient) StepIntoNewGoroutine() (*api.DebuggerState, error) {
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.StepIntoNewGoroutine, ReturnInfoLoadConfig: c.retValLoadCfg}, &out)
+	return &out.State, err
+}
+
+func (c *RPCClient) StepIntoTargets() ([]api.StepIntoTarget, error) {
+	var out StepIntoTargetsOut
+	err := c.call("StepIntoTargets", StepIntoTargetsIn{}, &out)
+	return out.Targets, err
+}
+
 func (c *RPCClient) ReverseStep() (*api.DebuggerState, error) {
 	var out CommandOut
 	err := c.call("Command", api.DebuggerCommand{Name: api.ReverseStep, ReturnInfoLoadConfig: c.retValLoadCfg}, &out)
@@ -160,7 +230,13 @@ func (c *RPCClient) ReverseStep() (*api.DebuggerState, error) {
 
 func (c *RPCClient) StepOut() (*api.DebuggerState, error) {
 	var out CommandOut
-	err := c.call("Command", api.DebuggerCommand{Name: api.StepOut, ReturnInfoLoadConfig: c.retValLoadCfg}, &out)
+	err := c.call("Command", api.DebuggerCommand{Name: api.StepOut, ReturnInfoLoadConfig: c.retValLoadCfg, Pinned: c.pinned}, &out)
+	return &out.State, err
+}
+
+func (c *RPCClient) StepOutIterator() (*api.DebuggerState, error) {
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.StepOutIterator, ReturnInfoLoadConfig: c.retValLoadCfg}, &out)
 	return &out.State, err
 }
 
@@ -176,6 +252,12 @@ func (c *RPCClient) Call(goroutineID int, expr string, unsafe bool) (*api.Debugg
 	return &out.State, err
 }
 
+func (c *RPCClient) Until(goroutineID int, expr string) (*api.DebuggerState, error) {
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.Until, ReturnInfoLoadConfig: c.retValLoadCfg, Expr: expr, GoroutineID: goroutineID}, &out)
+	return &out.State, err
+}
+
 func (c *RPCClient) StepInstruction() (*api.DebuggerState, error) {
 	var out CommandOut
 	err := c.call("Command", api.DebuggerCommand{Name: api.StepInstruction}, &out)
@@ -188,6 +270,18 @@ func (c *RPCClient) ReverseStepInstruction() (*api.DebuggerState, error) {
 	return &out.State, err
 }
 
+func (c *RPCClient) StepInstructions(count int, stepOverCalls bool) (*api.DebuggerState, error) {
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.StepInstruction, Count: count, StepOverCalls: stepOverCalls}, &out)
+	return &out.State, err
+}
+
+func (c *RPCClient) ReverseStepInstructions(count int) (*api.DebuggerState, error) {
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.ReverseStepInstruction, Count: count}, &out)
+	return &out.State, err
+}
+
 func (c *RPCClient) SwitchThread(threadID int) (*api.DebuggerState, error) {
 	var out CommandOut
 	cmd := api.DebuggerCommand{
@@ -238,10 +332,46 @@ func (c *RPCClient) CreateBreakpoint(breakPoint *api.Breakpoint) (*api.Breakpoin
 
 func (c *RPCClient) CreateWatchpoint(scope api.EvalScope, expr string, wtype api.WatchType) (*api.Breakpoint, error) {
 	var out CreateWatchpointOut
-	err := c.call("CreateWatchpoint", CreateWatchpointIn{scope, expr, wtype}, &out)
+	err := c.call("CreateWatchpoint", CreateWatchpointIn{Scope: scope, Expr: expr, Type: wtype}, &out)
+	return out.Breakpoint, err
+}
+
+func (c *RPCClient) CreateSliceWatchpoint(scope api.EvalScope, expr string, wtype api.WatchType, notify bool) (*api.Breakpoint, error) {
+	var out CreateWatchpointOut
+	err := c.call("CreateWatchpoint", CreateWatchpointIn{Scope: scope, Expr: expr, Type: wtype, FollowSlice: true, NotifyMigration: notify}, &out)
+	return out.Breakpoint, err
+}
+
+func (c *RPCClient) CreateWatchpointAddr(addr uint64, size int, wtype api.WatchType) (*api.Breakpoint, error) {
+	var out CreateWatchpointOut
+	err := c.call("CreateWatchpoint", CreateWatchpointIn{Type: wtype, Addr: addr, Size: size}, &out)
 	return out.Breakpoint, err
 }
 
+func (c *RPCClient) WatchpointSlots() (api.WatchpointSlots, error) {
+	var out WatchpointSlotsOut
+	err := c.call("WatchpointSlots", WatchpointSlotsIn{}, &out)
+	return out.Slots, err
+}
+
+func (c *RPCClient) BreakpointHitHistory(id int) ([]api.BreakpointHit, error) {
+	var out BreakpointHitHistoryOut
+	err := c.call("BreakpointHitHistory", BreakpointHitHistoryIn{id}, &out)
+	return out.Hits, err
+}
+
+func (c *RPCClient) PhysicalBreakpoints(id int) ([]api.PhysicalBreakpoint, error) {
+	var out PhysicalBreakpointsOut
+	err := c.call("PhysicalBreakpoints", PhysicalBreakpointsIn{id}, &out)
+	return out.Breakpoints, err
+}
+
+func (c *RPCClient) GetBufferedLogpoints() ([]api.LogpointMessage, int, error) {
+	var out GetBufferedLogpointsOut
+	err := c.call("GetBufferedLogpoints", GetBufferedLogpointsIn{}, &out)
+	return out.Messages, out.Dropped, err
+}
+
 func (c *RPCClient) ListBreakpoints() ([]*api.Breakpoint, error) {
 	var out ListBreakpointsOut
 	err := c.call("ListBreakpoints", ListBreakpointsIn{}, &out)
@@ -250,28 +380,55 @@ func (c *RPCClient) ListBreakpoints() ([]*api.Breakpoint, error) {
 
 func (c *RPCClient) ClearBreakpoint(id int) (*api.Breakpoint, error) {
 	var out ClearBreakpointOut
-	err := c.call("ClearBreakpoint", ClearBreakpointIn{id, ""}, &out)
+	err := c.call("ClearBreakpoint", ClearBreakpointIn{id, "", ""}, &out)
 	return out.Breakpoint, err
 }
 
 func (c *RPCClient) ClearBreakpointByName(name string) (*api.Breakpoint, error) {
 	var out ClearBreakpointOut
-	err := c.call("ClearBreakpoint", ClearBreakpointIn{0, name}, &out)
+	err := c.call("ClearBreakpoint", ClearBreakpointIn{0, name, ""}, &out)
 	return out.Breakpoint, err
 }
 
 func (c *RPCClient) ToggleBreakpoint(id int) (*api.Breakpoint, error) {
 	var out ToggleBreakpointOut
-	err := c.call("ToggleBreakpoint", ToggleBreakpointIn{id, ""}, &out)
+	err := c.call("ToggleBreakpoint", ToggleBreakpointIn{id, "", ""}, &out)
 	return out.Breakpoint, err
 }
 
 func (c *RPCClient) ToggleBreakpointByName(name string) (*api.Breakpoint, error) {
 	var out ToggleBreakpointOut
-	err := c.call("ToggleBreakpoint", ToggleBreakpointIn{0, name}, &out)
+	err := c.call("ToggleBreakpoint", ToggleBreakpointIn{0, name, ""}, &out)
 	return out.Breakpoint, err
 }
 
+func (c *RPCClient) ClearBreakpointGroup(group string) ([]*api.Breakpoint, error) {
+	var out ClearBreakpointOut
+	err := c.call("ClearBreakpoint", ClearBreakpointIn{0, "", group}, &out)
+	return out.Breakpoints, err
+}
+
+func (c *RPCClient) ToggleBreakpointGroup(group string) ([]*api.Breakpoint, error) {
+	var out ToggleBreakpointOut
+	err := c.call("ToggleBreakpoint", ToggleBreakpointIn{0, "", group}, &out)
+	return out.Breakpoints, err
+}
+
+func (c *RPCClient) ResetBreakpointHitCounts(id int) error {
+	var out ResetBreakpointHitCountsOut
+	return c.call("ResetBreakpointHitCounts", ResetBreakpointHitCountsIn{id, "", false}, &out)
+}
+
+func (c *RPCClient) ResetBreakpointHitCountsByName(name string) error {
+	var out ResetBreakpointHitCountsOut
+	return c.call("ResetBreakpointHitCounts", ResetBreakpointHitCountsIn{0, name, false}, &out)
+}
+
+func (c *RPCClient) ResetAllBreakpointHitCounts() error {
+	var out ResetBreakpointHitCountsOut
+	return c.call("ResetBreakpointHitCounts", ResetBreakpointHitCountsIn{0, "", true}, &out)
+}
+
 func (c *RPCClient) AmendBreakpoint(bp *api.Breakpoint) error {
 	out := new(AmendBreakpointOut)
 	err := c.call("AmendBreakpoint", AmendBreakpointIn{*bp}, out)
@@ -297,13 +454,18 @@ func (c *RPCClient) GetThread(id int) (*api.Thread, error) {
 
 func (c *RPCClient) EvalVariable(scope api.EvalScope, expr string, cfg api.LoadConfig) (*api.Variable, error) {
 	var out EvalOut
-	err := c.call("Eval", EvalIn{scope, expr, &cfg}, &out)
+	err := c.call("Eval", EvalIn{scope, expr, &cfg, api.FormatDefault}, &out)
 	return out.Variable, err
 }
 
 func (c *RPCClient) SetVariable(scope api.EvalScope, symbol, value string) error {
 	out := new(SetOut)
-	return c.call("Set", SetIn{scope, symbol, value}, out)
+	return c.call("Set", SetIn{scope, symbol, value, false}, out)
+}
+
+func (c *RPCClient) SetVariableInPlace(scope api.EvalScope, symbol, value string) error {
+	out := new(SetOut)
+	return c.call("Set", SetIn{scope, symbol, value, true}, out)
 }
 
 func (c *RPCClient) ListSources(filter string) ([]string, error) {
@@ -421,6 +583,38 @@ func (c *RPCClient) TraceDirectory() (string, error) {
 	return out.TraceDirectory, err
 }
 
+// RecordOptions returns the options used to record the target, nil if the
+// target was not recorded by the server.
+func (c *RPCClient) RecordOptions() (*api.RecordOptions, error) {
+	var out RecordedOut
+	err := c.call("Recorded", RecordedIn{}, &out)
+	return out.RecordOptions, err
+}
+
+// ListRecordedProcesses lists the processes recorded in the trace being
+// replayed.
+func (c *RPCClient) ListRecordedProcesses() ([]api.RecordedProcess, error) {
+	var out ListRecordedProcessesOut
+	err := c.call("ListRecordedProcesses", ListRecordedProcessesIn{}, &out)
+	return out.Processes, err
+}
+
+// PassSignals returns the signals that are delivered to the target without
+// stopping it.
+func (c *RPCClient) PassSignals() (api.SignalPassing, error) {
+	var out PassSignalsOut
+	err := c.call("PassSignals", PassSignalsIn{}, &out)
+	return out.PassSignals, err
+}
+
+// SetPassSignals changes the list of signals that are delivered to the
+// target without stopping it.
+func (c *RPCClient) SetPassSignals(sigs []int) (api.SignalPassing, error) {
+	var out SetPassSignalsOut
+	err := c.call("SetPassSignals", SetPassSignalsIn{sigs}, &out)
+	return out.PassSignals, err
+}
+
 // Checkpoint sets a checkpoint at the current position.
 func (c *RPCClient) Checkpoint(where string) (checkpointID int, err error) {
 	var out CheckpointOut
@@ -435,6 +629,36 @@ func (c *RPCClient) ListCheckpoints() ([]api.Checkpoint, error) {
 	return out.Checkpoints, err
 }
 
+// CheckpointDiff evaluates exprs both at the current position and at a
+// checkpoint and returns the two values of each expression.
+func (c *RPCClient) CheckpointDiff(id int, scope api.EvalScope, exprs []string, cfg api.LoadConfig) ([]api.CheckpointDiff, error) {
+	var out CheckpointDiffOut
+	err := c.call("CheckpointDiff", CheckpointDiffIn{id, scope, exprs, &cfg}, &out)
+	return out.Diffs, err
+}
+
+// Deadlocks returns the goroutines blocked on synchronization objects
+// and the cycles of goroutines waiting on each other.
+func (c *RPCClient) Deadlocks() (*api.DeadlockReport, error) {
+	var out DeadlocksOut
+	err := c.call("Deadlocks", DeadlocksIn{}, &out)
+	return &out.Report, err
+}
+
+// MutexInfo returns the state of the sync.Mutex or sync.RWMutex expr and
+// the goroutines that may hold it.
+func (c *RPCClient) MutexInfo(scope api.EvalScope, expr string) (*api.MutexInfo, error) {
+	var out MutexInfoOut
+	err := c.call("MutexInfo", MutexInfoIn{scope, expr}, &out)
+	return &out.Mutex, err
+}
+
+// SeekRecording restarts the recording positioned at the specified event and tick count.
+func (c *RPCClient) SeekRecording(event, ticks int64) error {
+	var out SeekRecordingOut
+	return c.call("SeekRecording", SeekRecordingIn{event, ticks}, &out)
+}
+
 // ClearCheckpoint removes a checkpoint
 func (c *RPCClient) ClearCheckpoint(id int) error {
 	var out ClearCheckpointOut
@@ -442,10 +666,57 @@ func (c *RPCClient) ClearCheckpoint(id int) error {
 	return err
 }
 
+// CreateStepSkip adds a rule that makes step step over the functions it
+// matches, kind is either "function" or "file".
+func (c *RPCClient) CreateStepSkip(kind, pattern string) (*api.StepSkip, error) {
+	var out CreateStepSkipOut
+	err := c.call("CreateStepSkip", CreateStepSkipIn{kind, pattern}, &out)
+	return &out.StepSkip, err
+}
+
+// ClearStepSkip removes a step skip rule.
+func (c *RPCClient) ClearStepSkip(id int) error {
+	var out ClearStepSkipOut
+	return c.call("ClearStepSkip", ClearStepSkipIn{id}, &out)
+}
+
+// ListStepSkips returns the list of step skip rules.
+func (c *RPCClient) ListStepSkips() ([]*api.StepSkip, error) {
+	var out ListStepSkipsOut
+	err := c.call("ListStepSkips", ListStepSkipsIn{}, &out)
+	return out.StepSkips, err
+}
+
+// CreateFormatter registers a formatter for the values of a type.
+func (c *RPCClient) CreateFormatter(f api.Formatter) (*api.Formatter, error) {
+	var out CreateFormatterOut
+	err := c.call("CreateFormatter", CreateFormatterIn{f}, &out)
+	return &out.Formatter, err
+}
+
+// ClearFormatter removes the formatter registered for a type.
+func (c *RPCClient) ClearFormatter(typeName string) error {
+	var out ClearFormatterOut
+	return c.call("ClearFormatter", ClearFormatterIn{typeName}, &out)
+}
+
+// ListFormatters returns the list of formatters.
+func (c *RPCClient) ListFormatters() ([]*api.Formatter, error) {
+	var out ListFormattersOut
+	err := c.call("ListFormatters", ListFormattersIn{}, &out)
+	return out.Formatters, err
+}
+
 func (c *RPCClient) SetReturnValuesLoadConfig(cfg *api.LoadConfig) {
 	c.retValLoadCfg = cfg
 }
 
+// SetPinnedResume sets whether Continue, Next, Step and StepOut resume
+// only the selected goroutine, keeping every other goroutine frozen.
+func (c *RPCClient) SetPinnedResume(pinned bool) {
+	c.pinned = pinned
+}
+
 func (c *RPCClient) FunctionReturnLocations(fnName string) ([]uint64, error) {
 	var out FunctionReturnLocationsOut
 	err := c.call("FunctionReturnLocations", FunctionReturnLocationsIn{fnName}, &out)
@@ -482,6 +753,27 @@ func (c *RPCClient) ExamineMemory(address uint64, count int) ([]byte, bool, erro
 	return out.Mem, out.IsLittleEndian, nil
 }
 
+func (c *RPCClient) ExamineType(address uint64, typename string, count int64, cfg api.LoadConfig) (*api.Variable, error) {
+	out := &ExamineTypeOut{}
+	err := c.call("ExamineType", ExamineTypeIn{Address: address, Type: typename, Count: count, Cfg: &cfg}, out)
+	if err != nil {
+		return nil, err
+	}
+	return out.Variable, nil
+}
+
+func (c *RPCClient) ReadStringRange(base uint64, length, offset, count int64) ([]byte, error) {
+	out := &ReadStringRangeOut{}
+	err := c.call("ReadStringRange", ReadStringRangeIn{Base: base, Len: length, Offset: offset, Count: count}, out)
+	if err != nil {
+		return nil, err
+	}
+	if out.Unreadable != "" {
+		return out.Data, errors.New(out.Unreadable)
+	}
+	return out.Data, nil
+}
+
 func (c *RPCClient) StopRecording() error {
 	return c.call("StopRecording", StopRecordingIn{}, &StopRecordingOut{})
 }
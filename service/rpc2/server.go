@@ -252,17 +252,36 @@ func (s *RPCServer) CreateBreakpoint(arg CreateBreakpointIn, out *CreateBreakpoi
 }
 
 type ClearBreakpointIn struct {
-	Id   int
-	Name string
+	Id    int
+	Name  string
+	Group string
 }
 
 type ClearBreakpointOut struct {
 	Breakpoint *api.Breakpoint
+	// Breakpoints is the list of breakpoints deleted when a Group was specified.
+	Breakpoints []*api.Breakpoint
 }
 
 // ClearBreakpoint deletes a breakpoint by Name (if Name is not an
 // empty string) or by ID.
+// If Group is not an empty string all breakpoints belonging to the group
+// are deleted.
 func (s *RPCServer) ClearBreakpoint(arg ClearBreakpointIn, out *ClearBreakpointOut) error {
+	if arg.Group != "" {
+		bps := s.debugger.BreakpointGroup(arg.Group)
+		if len(bps) == 0 {
+			return fmt.Errorf("no breakpoints in group %s", arg.Group)
+		}
+		for _, bp := range bps {
+			deleted, err := s.debugger.ClearBreakpoint(bp)
+			if err != nil {
+				return err
+			}
+			out.Breakpoints = append(out.Breakpoints, deleted)
+		}
+		return nil
+	}
 	var bp *api.Breakpoint
 	if arg.Name != "" {
 		bp = s.debugger.FindBreakpointByName(arg.Name)
@@ -284,17 +303,45 @@ func (s *RPCServer) ClearBreakpoint(arg ClearBreakpointIn, out *ClearBreakpointO
 }
 
 type ToggleBreakpointIn struct {
-	Id   int
-	Name string
+	Id    int
+	Name  string
+	Group string
 }
 
 type ToggleBreakpointOut struct {
 	Breakpoint *api.Breakpoint
+	// Breakpoints is the list of breakpoints toggled when a Group was specified.
+	Breakpoints []*api.Breakpoint
 }
 
 // ToggleBreakpoint toggles on or off a breakpoint by Name (if Name is not an
 // empty string) or by ID.
+// If Group is not an empty string all breakpoints belonging to the group
+// are disabled if any of them is enabled, otherwise they are all enabled.
 func (s *RPCServer) ToggleBreakpoint(arg ToggleBreakpointIn, out *ToggleBreakpointOut) error {
+	if arg.Group != "" {
+		bps := s.debugger.BreakpointGroup(arg.Group)
+		if len(bps) == 0 {
+			return fmt.Errorf("no breakpoints in group %s", arg.Group)
+		}
+		disable := false
+		for _, bp := range bps {
+			if !bp.Disabled && bp.WatchExpr == "" {
+				disable = true
+			}
+		}
+		for _, bp := range bps {
+			if bp.WatchExpr != "" || bp.Disabled == disable {
+				continue
+			}
+			bp.Disabled = disable
+			if err := s.debugger.AmendBreakpoint(bp); err != nil {
+				return err
+			}
+			out.Breakpoints = append(out.Breakpoints, bp)
+		}
+		return nil
+	}
 	var bp *api.Breakpoint
 	if arg.Name != "" {
 		bp = s.debugger.FindBreakpointByName(arg.Name)
@@ -318,6 +365,34 @@ func (s *RPCServer) ToggleBreakpoint(arg ToggleBreakpointIn, out *ToggleBreakpoi
 	return nil
 }
 
+type ResetBreakpointHitCountsIn struct {
+	Id   int
+	Name string
+	All  bool
+}
+
+type ResetBreakpointHitCountsOut struct {
+}
+
+// ResetBreakpointHitCounts resets the total and per-goroutine hit counts of
+// a breakpoint by Name (if Name is not an empty string) or by ID. If All is
+// set the hit counts of all breakpoints are reset.
+func (s *RPCServer) ResetBreakpointHitCounts(arg ResetBreakpointHitCountsIn, out *ResetBreakpointHitCountsOut) error {
+	if arg.All {
+		s.debugger.ResetAllBreakpointHitCounts()
+		return nil
+	}
+	id := arg.Id
+	if arg.Name != "" {
+		bp := s.debugger.FindBreakpointByName(arg.Name)
+		if bp == nil {
+			return fmt.Errorf("no breakpoint with name %s", arg.Name)
+		}
+		id = bp.ID
+	}
+	return s.debugger.ResetBreakpointHitCounts(id)
+}
+
 type AmendBreakpointIn struct {
 	Breakpoint api.Breakpoint
 }
@@ -490,10 +565,18 @@ type EvalIn struct {
 	Scope api.EvalScope
 	Expr  string
 	Cfg   *api.LoadConfig
+	// Format is the format verb used to fill EvalOut.Printed, see
+	// api.FormatVerb. If it is empty Printed is not set.
+	Format api.FormatVerb
 }
 
 type EvalOut struct {
 	Variable *api.Variable
+	// Printed is Variable printed with the format verb EvalIn.Format.
+	Printed string
+	// FormatNote explains why the format verb was not used, if Printed is
+	// in the default format.
+	FormatNote string
 }
 
 // EvalVariable returns a variable in the specified context.
@@ -510,6 +593,18 @@ func (s *RPCServer) Eval(arg EvalIn, out *EvalOut) error {
 		return err
 	}
 	out.Variable = api.ConvertVar(v)
+	if arg.Format != api.FormatDefault {
+		if !api.ValidFormatVerb(arg.Format) {
+			out.Printed = out.Variable.MultilineString("", "")
+			out.FormatNote = fmt.Sprintf("unknown format verb %q, the default format was used", arg.Format)
+		} else {
+			var applied bool
+			out.Printed, applied = out.Variable.MultilineStringWithVerb("", arg.Format)
+			if !applied {
+				out.FormatNote = fmt.Sprintf("format verb %q does not apply to %s, the default format was used", arg.Format, out.Variable.Type)
+			}
+		}
+	}
 	return nil
 }
 
@@ -517,15 +612,25 @@ type SetIn struct {
 	Scope  api.EvalScope
 	Symbol string
 	Value  string
+	// InPlace allows assigning a string, or a []byte or []rune converted
+	// from a string, that would need to be allocated in the target by
+	// overwriting the contents of the current value, if it is not shorter.
+	// Every other string or slice sharing those contents will also change.
+	InPlace bool
 }
 
 type SetOut struct {
 }
 
-// Set sets the value of a variable. Only numerical types and
-// pointers are currently supported.
+// Set sets the value of a variable. Only numerical types, pointers,
+// strings and slices are currently supported.
+// Assigning a string literal, or a []byte or []rune converted from one,
+// requires allocating memory in the target which is only possible using
+// function call injection (the Call command with an assignment
+// expression). Without it such values can only be assigned by setting
+// InPlace.
 func (s *RPCServer) Set(arg SetIn, out *SetOut) error {
-	return s.debugger.SetVariableInScope(arg.Scope.GoroutineID, arg.Scope.Frame, arg.Scope.DeferredCall, arg.Symbol, arg.Value)
+	return s.debugger.SetVariableInScope(arg.Scope.GoroutineID, arg.Scope.Frame, arg.Scope.DeferredCall, arg.Symbol, arg.Value, arg.InPlace)
 }
 
 type ListSourcesIn struct {
@@ -728,13 +833,74 @@ type RecordedIn struct {
 type RecordedOut struct {
 	Recorded       bool
 	TraceDirectory string
+	// RecordOptions are the options used to record the target, nil if the
+	// target was not recorded by this instance of Delve.
+	RecordOptions *api.RecordOptions
 }
 
 func (s *RPCServer) Recorded(arg RecordedIn, out *RecordedOut) error {
 	out.Recorded, out.TraceDirectory = s.debugger.Recorded()
+	if opts, ok := s.debugger.RecordOptions(); ok {
+		out.RecordOptions = &api.RecordOptions{Chaos: opts.Chaos, NumCores: opts.NumCores, OutputTraceDir: opts.OutputTraceDir, ExtraArgs: opts.ExtraArgs}
+	}
+	return nil
+}
+
+type ListRecordedProcessesIn struct {
+}
+
+type ListRecordedProcessesOut struct {
+	Processes []api.RecordedProcess
+}
+
+// ListRecordedProcesses lists the processes recorded in the trace being
+// replayed. Delve can be started on a process other than the root process
+// of the trace with the --onprocess flag of 'dlv replay'.
+func (s *RPCServer) ListRecordedProcesses(arg ListRecordedProcessesIn, out *ListRecordedProcessesOut) error {
+	procs, err := s.debugger.RecordedProcesses()
+	if err != nil {
+		return err
+	}
+	pid := s.debugger.ProcessPid()
+	out.Processes = make([]api.RecordedProcess, len(procs))
+	for i, rp := range procs {
+		out.Processes[i] = api.RecordedProcess{Pid: rp.Pid, Ppid: rp.Ppid, Exit: rp.Exit, Cmdline: rp.Cmdline, Current: rp.Pid == pid}
+	}
+	return nil
+}
+
+type PassSignalsIn struct {
+}
+
+type PassSignalsOut struct {
+	PassSignals api.SignalPassing
+}
+
+// PassSignals returns the signals that are delivered to the target without
+// stopping it.
+func (s *RPCServer) PassSignals(arg PassSignalsIn, out *PassSignalsOut) error {
+	out.PassSignals = api.ConvertSignalPassing(s.debugger.PassSignals())
 	return nil
 }
 
+type SetPassSignalsIn struct {
+	Signals []int
+}
+
+type SetPassSignalsOut struct {
+	PassSignals api.SignalPassing
+}
+
+// SetPassSignals changes the list of signals that are delivered to the
+// target without stopping it. Signal numbers are the ones used by the
+// backend, the field Configurable of the value returned by PassSignals
+// reports whether the current backend supports this.
+func (s *RPCServer) SetPassSignals(arg SetPassSignalsIn, out *SetPassSignalsOut) error {
+	err := s.debugger.SetPassSignals(arg.Signals)
+	out.PassSignals = api.ConvertSignalPassing(s.debugger.PassSignals())
+	return err
+}
+
 type CheckpointIn struct {
 	Where string
 }
@@ -780,6 +946,199 @@ func (s *RPCServer) ClearCheckpoint(arg ClearCheckpointIn, out *ClearCheckpointO
 	return s.debugger.ClearCheckpoint(arg.ID)
 }
 
+type CreateStepSkipIn struct {
+	// Kind is either "function" or "file".
+	Kind    string
+	Pattern string
+}
+
+type CreateStepSkipOut struct {
+	StepSkip api.StepSkip
+}
+
+// CreateStepSkip adds a rule that makes step step over the functions it
+// matches instead of stopping inside them.
+// Rules of kind "function" match Pattern, a regular expression, against
+// the full name of functions, rules of kind "file" match Pattern, a glob,
+// against the path of the file containing them.
+// Step skip rules do not affect breakpoints, next and stepout.
+func (s *RPCServer) CreateStepSkip(arg CreateStepSkipIn, out *CreateStepSkipOut) error {
+	rule, err := s.debugger.CreateStepSkip(arg.Kind, arg.Pattern)
+	if err != nil {
+		return err
+	}
+	out.StepSkip = *rule
+	return nil
+}
+
+type ClearStepSkipIn struct {
+	ID int
+}
+
+type ClearStepSkipOut struct {
+}
+
+// ClearStepSkip removes a step skip rule.
+func (s *RPCServer) ClearStepSkip(arg ClearStepSkipIn, out *ClearStepSkipOut) error {
+	return s.debugger.ClearStepSkip(arg.ID)
+}
+
+type ListStepSkipsIn struct {
+}
+
+type ListStepSkipsOut struct {
+	StepSkips []*api.StepSkip
+}
+
+// ListStepSkips returns the list of step skip rules.
+func (s *RPCServer) ListStepSkips(arg ListStepSkipsIn, out *ListStepSkipsOut) error {
+	out.StepSkips = s.debugger.StepSkips()
+	return nil
+}
+
+type CreateFormatterIn struct {
+	Formatter api.Formatter
+}
+
+type CreateFormatterOut struct {
+	Formatter api.Formatter
+}
+
+// CreateFormatter registers a formatter for the values of the type
+// Formatter.TypeName, replacing the formatter already registered for it.
+// The variables returned by Eval, ListLocalVars and ListFunctionArgs whose
+// type has a formatter have their Formatted field set, either to the
+// rendering of Formatter.Format or to the result of calling their String
+// method if Formatter.CallString is true.
+// Calling String resumes the target until the method returns, all
+// breakpoints are ignored while it runs.
+// Formatters are kept when the target is restarted.
+func (s *RPCServer) CreateFormatter(arg CreateFormatterIn, out *CreateFormatterOut) error {
+	f, err := s.debugger.CreateFormatter(arg.Formatter)
+	if err != nil {
+		return err
+	}
+	out.Formatter = *f
+	return nil
+}
+
+type ClearFormatterIn struct {
+	TypeName string
+}
+
+type ClearFormatterOut struct {
+}
+
+// ClearFormatter removes the formatter registered for a type.
+func (s *RPCServer) ClearFormatter(arg ClearFormatterIn, out *ClearFormatterOut) error {
+	return s.debugger.ClearFormatter(arg.TypeName)
+}
+
+type ListFormattersIn struct {
+}
+
+type ListFormattersOut struct {
+	Formatters []*api.Formatter
+}
+
+// ListFormatters returns the list of formatters.
+func (s *RPCServer) ListFormatters(arg ListFormattersIn, out *ListFormattersOut) error {
+	out.Formatters = s.debugger.Formatters()
+	return nil
+}
+
+type CheckpointDiffIn struct {
+	ID    int
+	Scope api.EvalScope
+	Exprs []string
+	Cfg   *api.LoadConfig
+}
+
+type CheckpointDiffOut struct {
+	Diffs []api.CheckpointDiff
+}
+
+// CheckpointDiff evaluates the expressions in arg.Exprs both at the
+// current position and at the checkpoint with ID arg.ID and returns both
+// values of each expression, along with their differences.
+// The recording is returned to the current position before returning.
+// Only available for recorded targets.
+func (s *RPCServer) CheckpointDiff(arg CheckpointDiffIn, out *CheckpointDiffOut) error {
+	cfg := arg.Cfg
+	if cfg == nil {
+		cfg = &api.LoadConfig{FollowPointers: true, MaxVariableRecurse: 1, MaxStringLen: 64, MaxArrayValues: 64, MaxStructFields: -1}
+	}
+	var err error
+	out.Diffs, err = s.debugger.CheckpointDiff(arg.ID, arg.Scope.GoroutineID, arg.Scope.Frame, arg.Scope.DeferredCall, arg.Exprs, *api.LoadConfigToProc(cfg))
+	return err
+}
+
+type DeadlocksIn struct {
+}
+
+type DeadlocksOut struct {
+	Report api.DeadlockReport
+}
+
+// Deadlocks lists the goroutines blocked on a mutex, read-write mutex,
+// wait group, condition variable or channel operation and reports the
+// cycles of goroutines waiting on each other.
+//
+// Which goroutine holds a lock, or can operate on a channel, is not
+// recorded by the runtime: a goroutine is assumed to hold an object if the
+// object is reachable from its stack. Reported cycles should therefore be
+// treated as potential deadlocks.
+func (s *RPCServer) Deadlocks(arg DeadlocksIn, out *DeadlocksOut) error {
+	r, err := s.debugger.FindDeadlocks()
+	if err != nil {
+		return err
+	}
+	out.Report = *r
+	return nil
+}
+
+type MutexInfoIn struct {
+	Scope api.EvalScope
+	Expr  string
+}
+
+type MutexInfoOut struct {
+	Mutex api.MutexInfo
+}
+
+// MutexInfo returns the state of a sync.Mutex or sync.RWMutex and the
+// goroutines waiting to acquire it.
+//
+// The runtime does not record which goroutine holds a lock, the possible
+// owners are the goroutines whose stacks are inside the code between a
+// call acquiring a lock of the same kind and the call releasing it,
+// preferring the goroutines that can reach the lock from their stack.
+func (s *RPCServer) MutexInfo(arg MutexInfoIn, out *MutexInfoOut) error {
+	mi, err := s.debugger.MutexInfo(arg.Scope.GoroutineID, arg.Scope.Frame, arg.Scope.DeferredCall, arg.Expr)
+	if err != nil {
+		return err
+	}
+	out.Mutex = *mi
+	return nil
+}
+
+type SeekRecordingIn struct {
+	Event int64
+	Ticks int64
+}
+
+type SeekRecordingOut struct {
+}
+
+// SeekRecording restarts a recording positioned at the specified event
+// number. If Ticks is not zero execution then moves forward until the
+// current thread reaches the specified tick count.
+// The current position can be read from the Position field of the
+// debugger state.
+func (s *RPCServer) SeekRecording(arg SeekRecordingIn, out *SeekRecordingOut) error {
+	return s.debugger.SeekRecording(arg.Event, arg.Ticks)
+}
+
 type IsMulticlientIn struct {
 }
 
@@ -909,6 +1268,93 @@ func (s *RPCServer) ExamineMemory(arg ExamineMemoryIn, out *ExaminedMemoryOut) e
 	return nil
 }
 
+// maxExamineTypeCount is the maximum number of values returned by
+// ExamineType.
+const maxExamineTypeCount = 1000
+
+// ExamineTypeIn holds the arguments of ExamineType.
+type ExamineTypeIn struct {
+	Address uint64
+	// Type is the name of a type or a Go type expression.
+	Type string
+	// Count is the number of consecutive values of type Type read, if it
+	// is greater than 1 an array of Count elements is returned.
+	Count int64
+	Cfg   *api.LoadConfig
+}
+
+// ExamineTypeOut holds the return values of ExamineType.
+type ExamineTypeOut struct {
+	Variable *api.Variable
+}
+
+// ExamineType interprets the memory at Address as a value of type Type,
+// the address does not need to belong to a variable. Fields and elements
+// stored in unreadable memory are returned with their Unreadable field set.
+func (s *RPCServer) ExamineType(arg ExamineTypeIn, out *ExamineTypeOut) error {
+	if arg.Count > maxExamineTypeCount {
+		return fmt.Errorf("count must be less than or equal to %d", maxExamineTypeCount)
+	}
+	count := arg.Count
+	if count == 0 {
+		count = 1
+	}
+	cfg := arg.Cfg
+	if cfg == nil {
+		cfg = &api.LoadConfig{FollowPointers: true, MaxVariableRecurse: 1, MaxStringLen: 64, MaxArrayValues: 64, MaxStructFields: -1}
+	}
+	v, err := s.debugger.ExamineType(arg.Address, arg.Type, count, *api.LoadConfigToProc(cfg))
+	if err != nil {
+		return err
+	}
+	out.Variable = api.ConvertVar(v)
+	return nil
+}
+
+// maxReadStringRangeCount is the maximum number of bytes returned by
+// ReadStringRange.
+const maxReadStringRangeCount = 16 << 20
+
+// ReadStringRangeIn holds the arguments of ReadStringRange.
+type ReadStringRangeIn struct {
+	// Base and Len are the address and the length of the contents of the
+	// string or byte slice, as returned in api.Variable.
+	Base uint64
+	Len  int64
+	// Offset is the index of the first byte returned.
+	Offset int64
+	// Count is the number of bytes returned, it is truncated at the end of
+	// the contents.
+	Count int64
+}
+
+// ReadStringRangeOut holds the return values of ReadStringRange.
+type ReadStringRangeOut struct {
+	Data []byte
+	// Unreadable is set if part of the range could not be read, Data holds
+	// the bytes preceding the first unreadable address.
+	Unreadable string
+}
+
+// ReadStringRange returns a range of the contents of a string or byte
+// slice. It is used to load the part of a string truncated by
+// LoadConfig.MaxStringLen, or to save the whole contents to a file, a
+// piece at a time.
+func (s *RPCServer) ReadStringRange(arg ReadStringRangeIn, out *ReadStringRangeOut) error {
+	if arg.Count > maxReadStringRangeCount {
+		return fmt.Errorf("count must be less than or equal to %d", maxReadStringRangeCount)
+	}
+	data, err := s.debugger.ReadStringRange(arg.Base, arg.Len, arg.Offset, arg.Count)
+	if err != nil {
+		if data == nil {
+			return err
+		}
+		out.Unreadable = err.Error()
+	}
+	out.Data = data
+	return nil
+}
+
 type StopRecordingIn struct {
 }
 
@@ -975,6 +1421,18 @@ type CreateWatchpointIn struct {
 	Scope api.EvalScope
 	Expr  string
 	Type  api.WatchType
+
+	// Addr and Size, if Size is not zero, specify the region of memory to
+	// watch, instead of Expr and Scope.
+	Addr uint64
+	Size int
+
+	// FollowSlice, if set, requires Expr to be an index expression on a
+	// slice and moves the watchpoint to the new backing array of the slice
+	// when the slice is reallocated. If NotifyMigration is also set the
+	// target stops every time the watchpoint is moved.
+	FollowSlice     bool
+	NotifyMigration bool
 }
 
 type CreateWatchpointOut struct {
@@ -983,6 +1441,97 @@ type CreateWatchpointOut struct {
 
 func (s *RPCServer) CreateWatchpoint(arg CreateWatchpointIn, out *CreateWatchpointOut) error {
 	var err error
+	if arg.Size != 0 {
+		out.Breakpoint, err = s.debugger.CreateWatchpointAddr(arg.Addr, arg.Size, arg.Type)
+		return err
+	}
+	if arg.FollowSlice {
+		out.Breakpoint, err = s.debugger.CreateSliceWatchpoint(arg.Scope.GoroutineID, arg.Scope.Frame, arg.Scope.DeferredCall, arg.Expr, arg.Type, arg.NotifyMigration)
+		return err
+	}
 	out.Breakpoint, err = s.debugger.CreateWatchpoint(arg.Scope.GoroutineID, arg.Scope.Frame, arg.Scope.DeferredCall, arg.Expr, arg.Type)
 	return err
 }
+
+type WatchpointSlotsIn struct {
+}
+
+type WatchpointSlotsOut struct {
+	Slots api.WatchpointSlots
+}
+
+// WatchpointSlots returns the number of hardware breakpoint slots that can
+// be used for watchpoints and how many of them are in use.
+func (s *RPCServer) WatchpointSlots(arg WatchpointSlotsIn, out *WatchpointSlotsOut) error {
+	out.Slots = s.debugger.WatchpointSlots()
+	return nil
+}
+
+type StepIntoTargetsIn struct {
+}
+
+type StepIntoTargetsOut struct {
+	Targets []api.StepIntoTarget
+}
+
+// StepIntoTargets returns the calls on the current line of the selected
+// goroutine that have not been executed yet. The PC of one of them can be
+// passed to the stepInto command to step directly into the called
+// function.
+func (s *RPCServer) StepIntoTargets(arg StepIntoTargetsIn, out *StepIntoTargetsOut) error {
+	var err error
+	out.Targets, err = s.debugger.StepIntoTargets()
+	return err
+}
+
+type PhysicalBreakpointsIn struct {
+	Id int
+}
+
+type PhysicalBreakpointsOut struct {
+	Breakpoints []api.PhysicalBreakpoint
+}
+
+// PhysicalBreakpoints returns the physical breakpoints of the logical
+// breakpoint with the specified ID, including the addresses where a
+// physical breakpoint could not be set.
+func (s *RPCServer) PhysicalBreakpoints(arg PhysicalBreakpointsIn, out *PhysicalBreakpointsOut) error {
+	var err error
+	out.Breakpoints, err = s.debugger.PhysicalBreakpoints(arg.Id)
+	return err
+}
+
+type BreakpointHitHistoryIn struct {
+	Id int
+}
+
+type BreakpointHitHistoryOut struct {
+	Hits []api.BreakpointHit
+}
+
+// BreakpointHitHistory returns the hits recorded in the hit history of the
+// breakpoint with the specified ID, oldest first, and clears it.
+// The hit history of a breakpoint is enabled by setting its HitHistorySize
+// field with AmendBreakpoint.
+func (s *RPCServer) BreakpointHitHistory(arg BreakpointHitHistoryIn, out *BreakpointHitHistoryOut) error {
+	var err error
+	out.Hits, err = s.debugger.BreakpointHitHistory(arg.Id)
+	return err
+}
+
+type GetBufferedLogpointsIn struct {
+}
+
+type GetBufferedLogpointsOut struct {
+	Messages []api.LogpointMessage
+	// Dropped is the number of messages discarded because too many
+	// messages were buffered.
+	Dropped int
+}
+
+// GetBufferedLogpoints returns the messages produced by logpoints since the
+// last call.
+func (s *RPCServer) GetBufferedLogpoints(arg GetBufferedLogpointsIn, out *GetBufferedLogpointsOut) error {
+	out.Messages, out.Dropped = s.debugger.GetBufferedLogpoints()
+	return nil
+}
@@ -472,6 +472,26 @@ func TestClientServer_breakpointInSeparateGoroutine(t *testing.T) {
 	})
 }
 
+func TestClientServer_breakpointConditionWarnings(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestClient2("testnextprog", t, func(c service.Client) {
+		// j is declared after the entry point of main.testnext
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.testnext", Cond: "j == 1"})
+		assertNoError(err, t, "CreateBreakpoint")
+		if len(bp.Warnings) != 1 || !strings.Contains(bp.Warnings[0], `variable "j" may not be visible`) {
+			t.Fatalf("unexpected warnings: %q", bp.Warnings)
+		}
+
+		bp.Cond = ""
+		assertNoError(c.AmendBreakpoint(bp), t, "AmendBreakpoint")
+		bp, err = c.GetBreakpoint(bp.ID)
+		assertNoError(err, t, "GetBreakpoint")
+		if len(bp.Warnings) != 0 {
+			t.Fatalf("unexpected warnings after removing the condition: %q", bp.Warnings)
+		}
+	})
+}
+
 func TestClientServer_breakAtNonexistentPoint(t *testing.T) {
 	withTestClient2("testprog", t, func(c service.Client) {
 		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "nowhere", Line: 1})
@@ -2351,6 +2371,321 @@ func TestToggleBreakpointRestart(t *testing.T) {
 	})
 }
 
+func TestResetBreakpointHitCounts(t *testing.T) {
+	withTestClient2("testtoggle", t, func(c service.Client) {
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.lineOne", Line: 1})
+		assertNoError(err, t, "CreateBreakpoint")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue")
+		bp, err = c.GetBreakpoint(bp.ID)
+		assertNoError(err, t, "GetBreakpoint")
+		if bp.TotalHitCount != 1 {
+			t.Fatalf("wrong TotalHitCount before reset: %d", bp.TotalHitCount)
+		}
+		assertNoError(c.ResetBreakpointHitCounts(bp.ID), t, "ResetBreakpointHitCounts")
+		bp, err = c.GetBreakpoint(bp.ID)
+		assertNoError(err, t, "GetBreakpoint")
+		if bp.TotalHitCount != 0 || len(bp.HitCount) != 0 {
+			t.Fatalf("hit counts not reset: %d %v", bp.TotalHitCount, bp.HitCount)
+		}
+	})
+}
+
+func TestMultiLocationBreakpoint(t *testing.T) {
+	withTestClient2("testtoggle", t, func(c service.Client) {
+		addrs := []uint64{}
+		for _, spec := range []string{"main.lineOne", "main.lineTwo"} {
+			locs, err := c.FindLocation(api.EvalScope{GoroutineID: -1}, spec, true, nil)
+			assertNoError(err, t, "FindLocation")
+			addrs = append(addrs, locs[0].PC)
+		}
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{Addr: addrs[0], Addrs: addrs})
+		assertNoError(err, t, "CreateBreakpoint")
+		if bp.File != api.MultipleLocations || len(bp.Locations) != 2 {
+			t.Fatalf("wrong locations for breakpoint: %s %#v", bp.File, bp.Locations)
+		}
+		for i := 0; i < 2; i++ {
+			state := <-c.Continue()
+			assertNoError(state.Err, t, "Continue")
+			if state.CurrentThread.Breakpoint == nil || state.CurrentThread.Breakpoint.ID != bp.ID {
+				t.Fatalf("not stopped at breakpoint %d", bp.ID)
+			}
+		}
+		bp, err = c.GetBreakpoint(bp.ID)
+		assertNoError(err, t, "GetBreakpoint")
+		if bp.TotalHitCount != 2 {
+			t.Fatalf("wrong TotalHitCount: %d", bp.TotalHitCount)
+		}
+		_, err = c.ClearBreakpoint(bp.ID)
+		assertNoError(err, t, "ClearBreakpoint")
+		bps, err := c.ListBreakpoints()
+		assertNoError(err, t, "ListBreakpoints")
+		for _, bp2 := range bps {
+			if bp2.ID == bp.ID {
+				t.Fatalf("breakpoint %d not cleared", bp.ID)
+			}
+		}
+	})
+}
+
+func TestFunctionRegexpBreakpoint(t *testing.T) {
+	withTestClient2("testtoggle", t, func(c service.Client) {
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{FunctionRegexp: `^main\.line(One|Two)$`})
+		assertNoError(err, t, "CreateBreakpoint")
+		if len(bp.Addrs) != 2 || bp.FunctionRegexp == "" {
+			t.Fatalf("wrong breakpoint: %#v", bp)
+		}
+		for _, fn := range []string{"main.lineOne", "main.lineTwo"} {
+			state := <-c.Continue()
+			assertNoError(state.Err, t, "Continue")
+			if state.CurrentThread.Breakpoint == nil || state.CurrentThread.Breakpoint.ID != bp.ID {
+				t.Fatalf("not stopped at breakpoint %d", bp.ID)
+			}
+			if state.CurrentThread.Function.Name() != fn {
+				t.Fatalf("stopped in %s, expected %s", state.CurrentThread.Function.Name(), fn)
+			}
+		}
+		_, err = c.CreateBreakpoint(&api.Breakpoint{FunctionRegexp: `^main\.nonexistent`})
+		if err == nil {
+			t.Fatal("expected error for regexp matching no functions")
+		}
+	})
+}
+
+func TestSuspendedFunctionRegexpBreakpoint(t *testing.T) {
+	withTestClient2("testtoggle", t, func(c service.Client) {
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{FunctionRegexp: `^main\.nonexistent`, Suspended: true})
+		assertNoError(err, t, "CreateBreakpoint")
+		if !bp.Suspended || !bp.Disabled {
+			t.Fatalf("breakpoint not suspended: %#v", bp)
+		}
+		_, err = c.CreateBreakpoint(&api.Breakpoint{FunctionRegexp: `^main\.(`, Suspended: true})
+		if err == nil {
+			t.Fatal("expected error for invalid regexp")
+		}
+	})
+}
+
+func TestFunctionRegexpBreakpointExisting(t *testing.T) {
+	withTestClient2("testtoggle", t, func(c service.Client) {
+		other, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.lineOne"})
+		assertNoError(err, t, "CreateBreakpoint")
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{FunctionRegexp: `^main\.line(One|Two)$`})
+		assertNoError(err, t, "CreateBreakpoint")
+		if len(bp.Addrs) != 1 {
+			t.Fatalf("wrong breakpoint: %#v", bp)
+		}
+		pbps, err := c.PhysicalBreakpoints(bp.ID)
+		assertNoError(err, t, "PhysicalBreakpoints")
+		skipped := 0
+		for _, pbp := range pbps {
+			if pbp.Error != "" {
+				skipped++
+				if pbp.Addr != other.Addr {
+					t.Errorf("skipped wrong address %#x, expected %#x", pbp.Addr, other.Addr)
+				}
+			}
+		}
+		if skipped != 1 {
+			t.Fatalf("wrong number of skipped addresses: %#v", pbps)
+		}
+	})
+}
+
+func TestPackageBreakpointExisting(t *testing.T) {
+	withTestClient2("testtoggle", t, func(c service.Client) {
+		other, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.lineOne"})
+		assertNoError(err, t, "CreateBreakpoint")
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{Package: "main", ExcludeWrappers: true})
+		assertNoError(err, t, "CreateBreakpoint")
+		pbps, err := c.PhysicalBreakpoints(bp.ID)
+		assertNoError(err, t, "PhysicalBreakpoints")
+		skipped := 0
+		for _, pbp := range pbps {
+			if pbp.Error != "" {
+				skipped++
+				if pbp.Addr != other.Addr {
+					t.Errorf("skipped wrong address %#x, expected %#x", pbp.Addr, other.Addr)
+				}
+			}
+		}
+		if skipped != 1 || len(bp.Addrs) != len(pbps)-1 {
+			t.Fatalf("wrong number of skipped addresses: %#v", pbps)
+		}
+	})
+}
+
+func TestBreakpointMaxHits(t *testing.T) {
+	withTestClient2("testtoggle", t, func(c service.Client) {
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.lineOne", MaxHits: 1})
+		assertNoError(err, t, "CreateBreakpoint")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue")
+		if state.CurrentThread.Breakpoint == nil || state.CurrentThread.Breakpoint.ID != bp.ID {
+			t.Fatalf("not stopped at breakpoint %d", bp.ID)
+		}
+		bp, err = c.GetBreakpoint(bp.ID)
+		assertNoError(err, t, "GetBreakpoint")
+		if !bp.Disabled || !bp.AutoDisabled {
+			t.Fatalf("breakpoint not auto-disabled: %#v", bp)
+		}
+		bp.Disabled = false
+		assertNoError(c.AmendBreakpoint(bp), t, "AmendBreakpoint")
+		bp, err = c.GetBreakpoint(bp.ID)
+		assertNoError(err, t, "GetBreakpoint")
+		if bp.Disabled || bp.AutoDisabled || bp.TotalHitCount != 0 {
+			t.Fatalf("breakpoint not re-enabled: %#v", bp)
+		}
+	})
+}
+
+func TestLogpointMaxHits(t *testing.T) {
+	// A logpoint never stops the target, it must be removed from the target
+	// as soon as it reaches MaxHits.
+	withTestClient2Extended("break", t, 0, [3]string{}, func(c service.Client, fixture protest.Fixture) {
+		lp, err := c.CreateBreakpoint(&api.Breakpoint{File: fixture.Source, Line: 6, Logpoint: "i = {i}", MaxHits: 3})
+		assertNoError(err, t, "CreateBreakpoint")
+		_, err = c.CreateBreakpoint(&api.Breakpoint{File: fixture.Source, Line: 8})
+		assertNoError(err, t, "CreateBreakpoint")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue")
+		lp, err = c.GetBreakpoint(lp.ID)
+		assertNoError(err, t, "GetBreakpoint")
+		if !lp.Disabled || !lp.AutoDisabled || lp.TotalHitCount != 3 {
+			t.Fatalf("logpoint not auto-disabled after 3 hits: %#v", lp)
+		}
+		msgs, _, err := c.GetBufferedLogpoints()
+		assertNoError(err, t, "GetBufferedLogpoints")
+		if len(msgs) != 3 {
+			t.Fatalf("wrong number of logpoint messages: %#v", msgs)
+		}
+	})
+}
+
+func TestBreakpointGroups(t *testing.T) {
+	withTestClient2("testtoggle", t, func(c service.Client) {
+		for _, fn := range []string{"main.lineOne", "main.lineTwo"} {
+			_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: fn, Group: "lines"})
+			assertNoError(err, t, "CreateBreakpoint")
+		}
+		other, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.lineThree"})
+		assertNoError(err, t, "CreateBreakpoint")
+
+		bps, err := c.ToggleBreakpointGroup("lines")
+		assertNoError(err, t, "ToggleBreakpointGroup")
+		if len(bps) != 2 {
+			t.Fatalf("wrong number of toggled breakpoints: %d", len(bps))
+		}
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue")
+		if state.CurrentThread.Breakpoint == nil || state.CurrentThread.Breakpoint.ID != other.ID {
+			t.Fatalf("not stopped at breakpoint %d", other.ID)
+		}
+
+		bps, err = c.ClearBreakpointGroup("lines")
+		assertNoError(err, t, "ClearBreakpointGroup")
+		if len(bps) != 2 {
+			t.Fatalf("wrong number of cleared breakpoints: %d", len(bps))
+		}
+		bps, err = c.ListBreakpoints()
+		assertNoError(err, t, "ListBreakpoints")
+		for _, bp := range bps {
+			if bp.Group != "" {
+				t.Fatalf("breakpoint %d not cleared", bp.ID)
+			}
+		}
+	})
+}
+
+func TestLogpoints(t *testing.T) {
+	withTestClient2("testtoggle", t, func(c service.Client) {
+		lp, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.lineOne", Logpoint: "lineOne {1+1} {{x}}"})
+		assertNoError(err, t, "CreateBreakpoint")
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.lineThree"})
+		assertNoError(err, t, "CreateBreakpoint")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue")
+		if state.CurrentThread.Breakpoint == nil || state.CurrentThread.Breakpoint.ID != bp.ID {
+			t.Fatalf("not stopped at breakpoint %d", bp.ID)
+		}
+		msgs, dropped, err := c.GetBufferedLogpoints()
+		assertNoError(err, t, "GetBufferedLogpoints")
+		if len(msgs) != 1 || dropped != 0 {
+			t.Fatalf("wrong logpoint messages: %#v (dropped %d)", msgs, dropped)
+		}
+		if msgs[0].BreakpointID != lp.ID || msgs[0].Message != "lineOne 2 {x}" {
+			t.Fatalf("wrong logpoint message: %#v", msgs[0])
+		}
+		msgs, _, err = c.GetBufferedLogpoints()
+		assertNoError(err, t, "GetBufferedLogpoints")
+		if len(msgs) != 0 {
+			t.Fatalf("logpoint messages not cleared: %#v", msgs)
+		}
+	})
+}
+
+func TestPackageBreakpoint(t *testing.T) {
+	withTestClient2("testtoggle", t, func(c service.Client) {
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{Package: "main", ExcludeWrappers: true})
+		assertNoError(err, t, "CreateBreakpoint")
+		if bp.FunctionCount < 4 {
+			t.Fatalf("wrong number of functions: %d", bp.FunctionCount)
+		}
+		for _, fn := range []string{"main.main", "main.lineOne", "main.lineTwo", "main.lineThree"} {
+			state := <-c.Continue()
+			assertNoError(state.Err, t, "Continue")
+			if state.CurrentThread.Breakpoint == nil || state.CurrentThread.Breakpoint.ID != bp.ID {
+				t.Fatalf("not stopped at breakpoint %d", bp.ID)
+			}
+			if state.CurrentThread.Function.Name() != fn {
+				t.Fatalf("stopped in %s, expected %s", state.CurrentThread.Function.Name(), fn)
+			}
+		}
+	})
+}
+
+func TestPhysicalBreakpoints(t *testing.T) {
+	withTestClient2("testtoggle", t, func(c service.Client) {
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{FunctionRegexp: `^main\.line(One|Two)$`})
+		assertNoError(err, t, "CreateBreakpoint")
+		pbps, err := c.PhysicalBreakpoints(bp.ID)
+		assertNoError(err, t, "PhysicalBreakpoints")
+		if len(pbps) != 2 {
+			t.Fatalf("wrong number of physical breakpoints: %#v", pbps)
+		}
+		for _, pbp := range pbps {
+			if !pbp.Installed || pbp.Error != "" || (pbp.FunctionName != "main.lineOne" && pbp.FunctionName != "main.lineTwo") {
+				t.Fatalf("wrong physical breakpoint: %#v", pbp)
+			}
+		}
+
+		bp.Disabled = true
+		assertNoError(c.AmendBreakpoint(bp), t, "AmendBreakpoint")
+		pbps, err = c.PhysicalBreakpoints(bp.ID)
+		assertNoError(err, t, "PhysicalBreakpoints (disabled)")
+		if len(pbps) != 2 || pbps[0].Installed || pbps[1].Installed {
+			t.Fatalf("wrong physical breakpoints for disabled breakpoint: %#v", pbps)
+		}
+
+		// An address that can not be written is reported instead of making
+		// the whole breakpoint fail.
+		locs, err := c.FindLocation(api.EvalScope{GoroutineID: -1}, "main.lineThree", true, nil)
+		assertNoError(err, t, "FindLocation")
+		bp2, err := c.CreateBreakpoint(&api.Breakpoint{Addr: locs[0].PC, Addrs: []uint64{locs[0].PC, 0x1}})
+		assertNoError(err, t, "CreateBreakpoint")
+		pbps, err = c.PhysicalBreakpoints(bp2.ID)
+		assertNoError(err, t, "PhysicalBreakpoints (failed address)")
+		if len(pbps) != 2 || pbps[0].Addr != 0x1 || pbps[0].Error == "" || !pbps[1].Installed {
+			t.Fatalf("wrong physical breakpoints: %#v", pbps)
+		}
+
+		_, err = c.PhysicalBreakpoints(1000)
+		if err == nil {
+			t.Fatal("expected error for nonexistent breakpoint")
+		}
+	})
+}
+
 func TestStopServerWithClosedListener(t *testing.T) {
 	// Checks that the error erturned by listener.Accept() is ignored when we
 	// are trying to shutdown. See issue #1633.
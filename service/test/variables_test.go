@@ -138,7 +138,7 @@ func withTestProcessArgs(name string, t *testing.T, wd string, args []string, bu
 	case "rr":
 		protest.MustHaveRecordingAllowed(t)
 		t.Log("recording")
-		p, tracedir, err = gdbserial.RecordAndReplay(append([]string{fixture.Path}, args...), wd, true, []string{}, [3]string{})
+		p, tracedir, err = gdbserial.RecordAndReplay(append([]string{fixture.Path}, args...), wd, true, []string{}, [3]string{}, gdbserial.RecordOptions{}, "")
 		t.Logf("replaying %q", tracedir)
 	default:
 		t.Fatalf("unknown backend %q", testBackend)
@@ -281,6 +281,50 @@ func TestSetVariable(t *testing.T) {
 	})
 }
 
+func TestSetVariableInPlace(t *testing.T) {
+	var testcases = []struct {
+		name     string
+		typ      string // type of <name>
+		expr     string
+		finalVal string // new value of <name> after executing <name> = <expr> in place
+		err      string
+	}{
+		{"str1", "string", `"hello"`, `"hello"`, ""},
+		{"str1", "string", `"a string longer than the current one"`, `"hello"`, "can not overwrite value in place: new length 36 is greater than the current length 5"},
+		{"str1", "string", `string(bytearray)`, `"tèst"`, ""},
+		{"byteslice", "[]uint8", `[]byte("ab")`, "[]uint8 len: 2, cap: 5, [97,98]", ""},
+		{"runeslice", "[]int32", `[]rune("è")`, "[]int32 len: 1, cap: 4, [232]", ""},
+		{"byteslice", "[]uint8", `[]byte("abc")`, "[]uint8 len: 2, cap: 5, [97,98]", "can not overwrite value in place: new length 3 is greater than the current length 2"},
+	}
+
+	withTestProcess("testvariables2", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+
+		// without call injection the new values can not be allocated
+		if err := setVariable(p, "str1", `"hello"`); err == nil {
+			t.Errorf("SetVariable(str1) did not return an error")
+		}
+		if err := setVariable(p, "byteslice", `[]byte("ab")`); err == nil {
+			t.Errorf("SetVariable(byteslice) did not return an error")
+		}
+
+		for _, tc := range testcases {
+			scope, err := proc.GoroutineScope(p, p.CurrentThread())
+			assertNoError(err, t, "GoroutineScope()")
+			err = scope.SetVariableInPlace(tc.name, tc.expr)
+			if tc.err == "" {
+				assertNoError(err, t, fmt.Sprintf("SetVariableInPlace(%s, %s)", tc.name, tc.expr))
+			} else if err == nil || err.Error() != tc.err {
+				t.Errorf("SetVariableInPlace(%s, %s): expected error %q got %v", tc.name, tc.expr, tc.err, err)
+			}
+
+			variable, err := evalVariable(p, tc.name, pnormalLoadConfig)
+			assertNoError(err, t, "EvalVariable()")
+			assertVariable(t, variable, varTest{tc.name, true, tc.finalVal, "", tc.typ, nil})
+		}
+	})
+}
+
 func TestVariableEvaluationShort(t *testing.T) {
 	testcases := []varTest{
 		{"a1", true, "\"foofoofoofoofoofoo\"", "", "string", nil},
@@ -814,6 +858,12 @@ func TestEvalExpression(t *testing.T) {
 		{"string(bytearray)", false, `"tèst"`, `""`, "string", nil},
 		{"string(runearray)", false, `"tèst"`, `""`, "string", nil},
 		{"string(str1)", false, `"01234567890"`, `"01234567890"`, "string", nil},
+		{"len([]byte(str1))", false, "11", "11", "", nil},
+		{"[]rune(string(byteslice))[1]", false, "232", "232", "int32", nil},
+		{"string(byteslice) == \"tèst\"", false, "true", "true", "", nil},
+		{"string(runearray[1:3])", false, `"ès"`, `""`, "string", nil},
+		{"[]rune(\"a\\xffb\")", false, `[]int32 len: 3, cap: 3, [97,65533,98]`, `[]int32 len: 3, cap: 3, [97,65533,98]`, "[]int32", nil},
+		{"&[]byte(str1)", false, "", "", "", errors.New(`can not take address of "[]byte(str1)"`)},
 
 		// access to channel field members
 		{"ch1.qcount", false, "4", "4", "uint", nil},
@@ -948,6 +998,244 @@ func TestMapEvaluation(t *testing.T) {
 	})
 }
 
+func TestMapFilter(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("testvariables2", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue() returned an error")
+		m1, err := evalVariable(p, "m1", pnormalLoadConfig)
+		assertNoError(err, t, "EvalVariable(m1)")
+
+		for _, tc := range []struct {
+			expr     string
+			matched  int64
+			children int
+		}{
+			{`m1[@key == "Malone"]`, 1, 1},
+			{`m1[@value.A == 2]`, 1, 1},
+			{`m1[len(@key) > 5 && @key != "Malone"]`, -1, -1},
+			{`m1[@key == "nothere"]`, 0, 0},
+			{`m2[@key == 1]`, 1, 1},
+			{`mapinf[@key == "inf"]`, 1, 1},
+		} {
+			v, err := evalVariable(p, tc.expr, pnormalLoadConfig)
+			assertNoError(err, t, fmt.Sprintf("EvalVariable(%s)", tc.expr))
+			cv := api.ConvertVar(v)
+			t.Logf("%s = %s", tc.expr, cv.SinglelineString())
+			if cv.MapFilter == nil {
+				t.Fatalf("%s: no filter result", tc.expr)
+			}
+			if tc.matched >= 0 && cv.MapFilter.Matched != tc.matched {
+				t.Errorf("%s: expected %d matches, got %d", tc.expr, tc.matched, cv.MapFilter.Matched)
+			}
+			if tc.children >= 0 && len(cv.Children)/2 != tc.children {
+				t.Errorf("%s: expected %d children, got %d", tc.expr, tc.children, len(cv.Children)/2)
+			}
+			if int64(len(cv.Children)/2) != cv.MapFilter.Matched {
+				t.Errorf("%s: %d children for %d matches", tc.expr, len(cv.Children)/2, cv.MapFilter.Matched)
+			}
+			if tc.expr == `m1[@key == "Malone"]` && (cv.Children[0].Value != "Malone" || cv.MapFilter.Scanned != m1.Len) {
+				t.Errorf("%s: wrong result %s, scanned %d of %d", tc.expr, cv.SinglelineString(), cv.MapFilter.Scanned, m1.Len)
+			}
+		}
+
+		_, err = evalVariable(p, "@key", pnormalLoadConfig)
+		if err == nil {
+			t.Errorf("@key evaluated outside of a map filter")
+		}
+	})
+}
+
+func TestPagedLoading(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("testvariables2", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue() returned an error")
+
+		cfg := pnormalLoadConfig
+		cfg.MaxArrayValues = 10
+		cfg.Start = 95
+		longarr, err := evalVariable(p, "longarr", cfg)
+		assertNoError(err, t, "EvalVariable(longarr)")
+		assertVariable(t, longarr, varTest{"longarr", true, "[]int len: 5, cap: 5, [0,0,0,0,0]", "", "[]int", nil})
+		cfg.Start = 5
+		s4, err := evalVariable(p, "s4", cfg)
+		assertNoError(err, t, "EvalVariable(s4)")
+		assertVariable(t, s4, varTest{"s4", true, "[]int len: 5, cap: 5, [6,7,8,9,0]", "", "[]int", nil})
+		cfg.Start = 10
+		_, err = evalVariable(p, "s4", cfg)
+		if err == nil {
+			t.Errorf("no error for start index out of bounds")
+		}
+
+		// load all entries of m1, 10 at a time
+		cfg.Start = 0
+		keys := map[string]bool{}
+		var m1 *proc.Variable
+		for {
+			m1, err = evalVariable(p, "m1", cfg)
+			assertNoError(err, t, "EvalVariable(m1)")
+			for i := 0; i < len(m1.Children); i += 2 {
+				key := constant.StringVal(m1.Children[i].Value)
+				if keys[key] {
+					t.Errorf("key %q loaded twice", key)
+				}
+				keys[key] = true
+			}
+			if m1.MapContinuation == "" {
+				break
+			}
+			cfg.MapContinuation = m1.MapContinuation
+		}
+		if int64(len(keys)) != m1.Len {
+			t.Errorf("loaded %d keys of %d", len(keys), m1.Len)
+		}
+
+		// a token returned for a different map is stale
+		cfg.MaxArrayValues = 1
+		cfg.MapContinuation = ""
+		m3, err := evalVariable(p, "m3", cfg)
+		assertNoError(err, t, "EvalVariable(m3)")
+		if m3.MapContinuation == "" {
+			t.Fatalf("no continuation token for m3")
+		}
+		cfg.MapContinuation = m3.MapContinuation
+		_, err = evalVariable(p, "m4", cfg)
+		if err != proc.ErrStaleMapContinuation {
+			t.Errorf("expected stale token error, got %v", err)
+		}
+	})
+}
+
+func TestChanContents(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("chanbuffer", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue() returned an error")
+
+		member := func(v *api.Variable, name string) *api.Variable {
+			for i := range v.Children {
+				if v.Children[i].Name == name {
+					return &v.Children[i]
+				}
+			}
+			t.Fatalf("no member %s in %s", name, v.Name)
+			return nil
+		}
+
+		eval := func(expr string) *api.Variable {
+			v, err := evalVariable(p, expr, pnormalLoadConfig)
+			assertNoError(err, t, fmt.Sprintf("EvalVariable(%s)", expr))
+			return api.ConvertVar(v)
+		}
+
+		ch := eval("ch")
+		if s := member(ch, "buffered").SinglelineString(); s != "[]int len: 4, cap: 4, [2,3,4,5]" {
+			t.Errorf("ch.buffered: %s", s)
+		}
+		if s := eval("ch.buffered").SinglelineString(); s != "[]int len: 4, cap: 4, [2,3,4,5]" {
+			t.Errorf("ch.buffered: %s", s)
+		}
+
+		unbuf := eval("unbuf")
+		if n := len(member(unbuf, "sendWaiting").Children); n != 2 {
+			t.Errorf("unbuf: expected 2 goroutines waiting to send, got %d", n)
+		}
+		if n := len(member(unbuf, "recvWaiting").Children); n != 0 {
+			t.Errorf("unbuf: expected 0 goroutines waiting to receive, got %d", n)
+		}
+
+		recv := eval("recv")
+		if n := len(member(recv, "recvWaiting").Children); n != 1 {
+			t.Errorf("recv: expected 1 goroutine waiting to receive, got %d", n)
+		}
+
+		closed := eval("closed")
+		if s := closed.SinglelineString(); s != "chan int 1/2 (closed)" {
+			t.Errorf("closed: %s", s)
+		}
+		if s := member(closed, "buffered").SinglelineString(); s != "[]int len: 1, cap: 1, [1]" {
+			t.Errorf("closed.buffered: %s", s)
+		}
+	})
+}
+
+func TestSyncContents(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("syncvars", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue() returned an error")
+
+		eval := func(expr string) *api.Variable {
+			v, err := evalVariable(p, expr, pnormalLoadConfig)
+			assertNoError(err, t, fmt.Sprintf("EvalVariable(%s)", expr))
+			return api.ConvertVar(v)
+		}
+
+		if s := eval("wg.counter").SinglelineString(); s != "3" {
+			t.Errorf("wg.counter: %s", s)
+		}
+		if n := len(eval("wg.waiters").Children); n != 2 {
+			t.Errorf("wg: expected 2 goroutines waiting, got %d", n)
+		}
+		if s := eval("once.completed").SinglelineString(); s != "true" {
+			t.Errorf("once.completed: %s", s)
+		}
+		if s := eval("pending.completed").SinglelineString(); s != "false" {
+			t.Errorf("pending.completed: %s", s)
+		}
+		if n := len(eval("cond.waiters").Children); n != 2 {
+			t.Errorf("cond: expected 2 goroutines waiting, got %d", n)
+		}
+		if _, err := evalVariable(p, "mu.waiters", pnormalLoadConfig); err == nil {
+			t.Errorf("mu.waiters did not fail")
+		}
+	})
+}
+
+func TestBuiltinFormatters(t *testing.T) {
+	if !goversion.VersionAfterOrEqual(runtime.Version(), 1, 18) {
+		t.Skip("net/netip requires Go 1.18")
+	}
+	protest.AllowRecording(t)
+	withTestProcess("stdformat", t, func(p *proc.Target, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue() returned an error")
+
+		for _, tc := range []struct {
+			expr, tgt string
+		}{
+			{"utc", "time.Time 2021-03-04T05:06:07.000000008Z"},
+			{"cet", "time.Time 2021-03-04T06:06:07+01:00 CET"},
+			{"zero", "time.Time 0001-01-01T00:00:00Z"},
+			{"d", "time.Duration 1.5s"},
+			{"month", "time.Month March"},
+			{"ip4", "net.IP 192.168.1.1"},
+			{"ip6", "net.IP 2001:db8::1"},
+			{"nilip", "net.IP <nil>"},
+			{"addr4", "net/netip.Addr 10.0.0.1"},
+			{"addr6", "net/netip.Addr fe80::1%eth0"},
+			{"mapped", "net/netip.Addr ::ffff:10.0.0.1"},
+			{"invalid", "net/netip.Addr invalid IP"},
+			{"*bigint", "math/big.Int -123456789012345678901234567890"},
+			{"*huge", "math/big.Int 1" + strings.Repeat("0", 63) + "...(~10^4096)"},
+			{"*bigfloat", "math/big.Float 1.5"},
+			{"*bigrat", "math/big.Rat 3/4"},
+			{"*bigratint", "math/big.Rat 5/1"},
+		} {
+			v, err := evalVariable(p, tc.expr, pnormalLoadConfig)
+			assertNoError(err, t, fmt.Sprintf("EvalVariable(%s)", tc.expr))
+			if s := api.ConvertVar(v).SinglelineString(); s != tc.tgt {
+				t.Errorf("%s: expected %q got %q", tc.expr, tc.tgt, s)
+			}
+		}
+
+		now, err := evalVariable(p, "now", pnormalLoadConfig)
+		assertNoError(err, t, "EvalVariable(now)")
+		if !strings.Contains(now.Formatted, " m=+") {
+			t.Errorf("now: monotonic clock reading missing from %q", now.Formatted)
+		}
+		if len(now.Children) == 0 {
+			t.Errorf("now: fields not loaded")
+		}
+	})
+}
+
 func TestUnsafePointer(t *testing.T) {
 	protest.AllowRecording(t)
 	withTestProcess("testvariables2", t, func(p *proc.Target, fixture protest.Fixture) {
@@ -1286,6 +1574,52 @@ func TestCallFunction(t *testing.T) {
 	var testcases117 = []testCaseCallFunction{
 		{`regabistacktest("one", "two", "three", "four", "five", 4)`, []string{`:string:"onetwo"`, `:string:"twothree"`, `:string:"threefour"`, `:string:"fourfive"`, `:string:"fiveone"`, ":uint8:8"}, nil},
 		{`regabistacktest2(1, 2, 3, 4, 5, 6, 7, 8, 9, 10)`, []string{":int:3", ":int:5", ":int:7", ":int:9", ":int:11", ":int:13", ":int:15", ":int:17", ":int:19", ":int:11"}, nil},
+
+		// Variadic functions
+		{`variadicSum("s")`, []string{`:string:"s0"`}, nil},
+		{`variadicSum("s", 1, 2, 3)`, []string{`:string:"s6"`}, nil},
+		{`variadicSum("s", one, two)`, []string{`:string:"s3"`}, nil},
+		{`variadicSum("s", intslice...)`, []string{`:string:"s6"`}, nil},
+		{`variadicSum("s", "a")`, nil, errors.New(`cannot use "a" as argument n in function main.variadicSum: can not convert "a" constant to int`)},
+		{`fmt.Sprintf("%d-%d", one, two)`, []string{`:string:"1-2"`}, nil},
+		{`fmt.Sprintf("%s %v %v %v", "a", 1.5, true, pa)`, []string{`:string:"a 1.5 true &{6}"`}, nil},
+		{`fmt.Sprint()`, []string{`:string:""`}, nil},
+		{`variadicStack(1, 2, 3, 4, 5, 6, 7, 8, 9)`, []string{`:string:"45 []"`}, nil},
+		{`variadicStack(1, 2, 3, 4, 5, 6, 7, 8, 9, "x", one, comma)`, []string{`:string:"45 [x 1 ,]"`}, nil},
+
+		// Composite literals and make
+		{`litProcess(litOptions{Name: "a", Retries: 3})`, []string{`:string:"a 3 0 0 <nil> [] <nil>"`}, nil},
+		{`litProcess(litOptions{"b", 1, 1.5, astruct{2}, &astruct{X: 4}, []string{"x", "y"}, 7, nil, nil})`, []string{`:string:"b 1 1.5 2 &{4} [x y] 7"`}, nil},
+		{`litProcessPtr(&litOptions{Name: "c", Inner: astruct{X: 5}})`, []string{`:string:"c 0 0 5 <nil> [] <nil>"`}, nil},
+		{`litFill(make([]byte, 3))`, []string{`:string:"3 3 abc"`}, nil},
+		{`litFill(make([]byte, 2, 10))`, []string{`:string:"2 10 ab"`}, nil},
+		{`litSumArray([3]int{1, 2: 5})`, []string{`:int:6`}, nil},
+		{`litSumAStructs([]*astruct{{X: 1}, {2}, pa})`, []string{`:int:9`}, nil},
+		{`variadicSum("s", []int{4, 5}...)`, []string{`:string:"s9"`}, nil},
+		{`litProcess(litOptions{Foo: 1})`, nil, errors.New(`error evaluating "litOptions{Foo: 1}" as argument o in function main.litProcess: unknown field Foo in struct literal of type main.litOptions`)},
+		{`litProcess(litOptions{M: map[string]int{"a": 1}})`, nil, errors.New(`error evaluating "litOptions{M: map[string]int{\"a\": 1}}" as argument o in function main.litProcess: cannot use map[string]int{"a": 1} as field M of main.litOptions: can not create map[string]int: maps are not supported`)},
+		{`litProcess(litOptions{Ch: make(chan int)})`, nil, errors.New(`error evaluating "litOptions{Ch: make(chan int)}" as argument o in function main.litProcess: cannot use make(chan int) as field Ch of main.litOptions: can not create chan int: channels are not supported`)},
+		{`litFill(make([]byte, 3, 2))`, nil, errors.New(`error evaluating "make([]byte, 3, 2)" as argument buf in function main.litFill: len larger than cap in make([]byte)`)},
+
+		// Assignment of values converted from strings
+		{`bytebuf = []byte("new"); bytebuf`, []string{`bytebuf:[]uint8:[]uint8 len: 3, cap: 3, [110,101,119]`}, nil},
+		{`str = string(bytebuf); str`, []string{`str:string:"new"`}, nil},
+
+		// Modifying maps and appending to slices
+		{`strmap["b"] = 2; strmap["b"]`, []string{`:int:2`}, nil},
+		{`strmap["a"] = strmap["a"] + 10; strmap["a"]`, []string{`:int:11`}, nil},
+		{`delete(strmap, "b"); len(strmap)`, []string{`:int:1`}, nil},
+		{`delete(strmap, "notthere"); len(strmap)`, []string{`:int:1`}, nil},
+		{`intslice = append(intslice, 4, 5); intslice[4]`, []string{`:int:5`}, nil},
+		{`stringslice = append(stringslice[:1], "uno"); stringslice`, []string{`stringslice:[]string:[]string len: 2, cap: 3, ["one","uno"]`}, nil},
+		{`bytebuf = append(bytebuf, "!!"...); string(bytebuf)`, []string{`:string:"new!!"`}, nil},
+		{`delete(intslice, 1)`, nil, errors.New("first argument to delete must be a map, got intslice")},
+		{`append(strmap, 1)`, nil, errors.New("first argument to append must be a slice, got strmap")},
+
+		// Methods called through the itab of interface values
+		{`ifaceerr.Error()`, []string{`:string:"error 1"`}, nil},
+		{`vable_a.VRcvr(5) + ifaceerr.Error()`, []string{`:string:"5 + 3 = 8error 1"`}, nil},
+		{`vable_nil.VRcvr(1)`, nil, errors.New("vable_nil is a nil interface")},
 	}
 
 	withTestProcessArgs("fncall", t, ".", nil, protest.AllNonOptimized, func(p *proc.Target, fixture protest.Fixture) {